@@ -8,11 +8,21 @@ import (
 	"syscall"
 	"time"
 
+	"detectviz-platform/internal/adapters/http_handlers"
+	"detectviz-platform/internal/adapters/http_middleware"
 	"detectviz-platform/internal/adapters/web"
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/internal/application/backfill"
+	"detectviz-platform/internal/application/scheduler"
+	"detectviz-platform/internal/bootstrap"
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
 	"detectviz-platform/internal/infrastructure/platform/config"
+	"detectviz-platform/internal/infrastructure/platform/health"
 	"detectviz-platform/internal/infrastructure/platform/http_server"
 	"detectviz-platform/internal/infrastructure/platform/registry"
 	"detectviz-platform/internal/infrastructure/platform/telemetry"
+	"detectviz-platform/pkg/platform/contracts"
 )
 
 func main() {
@@ -69,11 +79,52 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 初始化數據庫並執行 Schema 遷移 (未配置 database.dsn 時跳過)
+	dbClient, err := bootstrap.NewDBClientFromConfig(bootstrapConfigProvider, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建數據庫客戶端失敗: %v", err)
+		os.Exit(1)
+	}
+	var persistence *bootstrap.PersistenceComponents
+	if dbClient != nil {
+		if err := bootstrap.RunStartupMigrations(context.Background(), bootstrapConfigProvider, dbClient, otelZapLogger); err != nil {
+			otelZapLogger.Error("執行數據庫遷移失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := pluginRegistry.Register("dbClient", dbClient); err != nil {
+			otelZapLogger.Error("註冊數據庫客戶端失敗: %v", err)
+			os.Exit(1)
+		}
+		defer dbClient.Close()
+
+		persistence, err = bootstrap.NewPersistenceComponents(context.Background(), bootstrapConfigProvider, dbClient, nil, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建持久化組件失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := persistence.Register(pluginRegistry); err != nil {
+			otelZapLogger.Error("註冊持久化組件失敗: %v", err)
+			os.Exit(1)
+		}
+		otelZapLogger.Info("[主程序] 數據庫初始化完成")
+	}
+
 	// 步驟 5: 創建 HTTP 服務器 (從配置文件讀取)
+	// trustedProxies 是列表，透過 Unmarshal 讀取
+	var serverSection struct {
+		Server struct {
+			TrustedProxies []string
+		}
+	}
+	if err := bootstrapConfigProvider.Unmarshal(&serverSection); err != nil {
+		otelZapLogger.Error("讀取 server 配置失敗: %v", err)
+		os.Exit(1)
+	}
 	httpServerConfig := map[string]interface{}{
-		"port":         bootstrapConfigProvider.GetInt("server.port"),
-		"readTimeout":  bootstrapConfigProvider.GetString("server.readTimeout"),
-		"writeTimeout": bootstrapConfigProvider.GetString("server.writeTimeout"),
+		"port":           bootstrapConfigProvider.GetInt("server.port"),
+		"readTimeout":    bootstrapConfigProvider.GetString("server.readTimeout"),
+		"writeTimeout":   bootstrapConfigProvider.GetString("server.writeTimeout"),
+		"trustedProxies": serverSection.Server.TrustedProxies,
 	}
 
 	httpServer, err := http_server.NewEchoHttpServerProvider(httpServerConfig, otelZapLogger)
@@ -126,6 +177,288 @@ func main() {
 
 	otelZapLogger.Info("[主程序] UI 路由註冊完成")
 
+	// 註冊內建的告警通知插件，供 alerting.receivers 的渠道引用；webhook 地址與令牌從秘密提供者讀取
+	secretsProvider := bootstrap.NewSecretsProviderFromConfig(bootstrapConfigProvider, otelZapLogger)
+	if err := bootstrap.RegisterAlertPlugins(context.Background(), bootstrapConfigProvider, pluginRegistry, secretsProvider, otelZapLogger); err != nil {
+		otelZapLogger.Error("註冊告警通知插件失敗: %v", err)
+		os.Exit(1)
+	}
+	emailNotifier, err := bootstrap.NewEmailNotifierFromConfig(context.Background(), bootstrapConfigProvider, secretsProvider, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建郵件通知插件失敗: %v", err)
+		os.Exit(1)
+	}
+	if emailNotifier != nil {
+		if err := pluginRegistry.Register(emailNotifier.GetName(), emailNotifier); err != nil {
+			otelZapLogger.Error("註冊郵件通知插件失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := emailNotifier.Start(context.Background()); err != nil {
+			otelZapLogger.Error("啟動郵件通知插件失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// 創建 RBAC 授權引擎 (auth.rbac.enabled 為 true 時)，策略文件變更時自動重新載入
+	rbacEngine, err := bootstrap.NewRBACEngineFromConfig(bootstrapConfigProvider, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建 RBAC 授權引擎失敗: %v", err)
+		os.Exit(1)
+	}
+	if rbacEngine != nil {
+		if err := rbacEngine.Start(context.Background()); err != nil {
+			otelZapLogger.Error("啟動 RBAC 策略熱重載失敗: %v", err)
+			os.Exit(1)
+		}
+		http_handlers.NewAuthzHandler(rbacEngine, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		otelZapLogger.Info("[主程序] RBAC 授權引擎已啟動")
+	}
+
+	// 會話存儲 (配置了 auth.provider 時)：登錄頁面在其中創建簽名的不透明會話，CSRF 令牌也綁定到會話保存
+	var sessionManager *storage.SessionManager
+	var sessionSweeper *storage.Sweeper
+	var authStorage contracts.AuthStorageProvider
+	if bootstrapConfigProvider.GetString("auth.provider") != "" {
+		sessionManager, sessionSweeper, err = bootstrap.NewSessionManagerFromConfig(context.Background(), bootstrapConfigProvider,
+			dbClient, secretsProvider, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建會話存儲失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := sessionSweeper.Start(context.Background()); err != nil {
+			otelZapLogger.Error("啟動會話清理器失敗: %v", err)
+			os.Exit(1)
+		}
+		authStorage = sessionManager.Store()
+	}
+
+	// 創建認證提供者與 API 認證中介層 (auth.provider 與 auth.middleware.enabled)，除明確聲明的公開路由外都需要認證
+	// 本地認證提供者以郵件通知插件發送驗證與密碼重置郵件，未啟用郵件時保持介面為 nil
+	var accountNotifier auth.AccountNotifier
+	if emailNotifier != nil {
+		accountNotifier = emailNotifier
+	}
+	authProvider, err := bootstrap.NewAuthProviderFromConfig(context.Background(), bootstrapConfigProvider, secretsProvider,
+		rbacEngine, authStorage, dbClient, accountNotifier, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建認證提供者失敗: %v", err)
+		os.Exit(1)
+	}
+	if authProvider != nil {
+		if err := pluginRegistry.Register(authProvider.GetName(), authProvider); err != nil {
+			otelZapLogger.Error("註冊認證提供者失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+	// 登錄限流 (auth.bruteForce.enabled)：失敗計數保存在 cache.provider 中供多個實例共享，鎖定與解鎖寫入審計日誌
+	var loginThrottle *auth.LoginThrottle
+	if authProvider != nil {
+		cacheProvider, err := bootstrap.NewCacheProviderFromConfig(context.Background(), bootstrapConfigProvider, dbClient, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建緩存失敗: %v", err)
+			os.Exit(1)
+		}
+		var auditLog contracts.AuditLogProvider
+		if persistence != nil {
+			auditLog = persistence.AuditLog
+		}
+		// 只有本地用戶能按登錄名查到郵件地址，其他提供者的賬戶鎖定只通知管理員
+		var lockoutAccounts auth.LockoutAccountLookup
+		if localAuth, ok := authProvider.(*auth.LocalAuthProvider); ok {
+			lockoutAccounts = localAuth
+		}
+		loginThrottle, err = bootstrap.NewLoginThrottleFromConfig(bootstrapConfigProvider, cacheProvider, auditLog,
+			accountNotifier, lockoutAccounts, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建登錄限流失敗: %v", err)
+			os.Exit(1)
+		}
+		if loginThrottle != nil {
+			http_handlers.NewLoginLockoutHandler(loginThrottle, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		}
+	}
+	if localAuth, ok := authProvider.(*auth.LocalAuthProvider); ok {
+		http_handlers.NewLocalAuthHandler(localAuth, loginThrottle, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+	}
+	// 服務帳號的 API 金鑰 (需要數據庫且 auth.apiKeys.enabled 為 true)，角色與令牌的角色聲明一樣經 RBAC 映射
+	var apiKeyResolver http_middleware.APIKeyResolver
+	if persistence != nil {
+		serviceAccountService, err := bootstrap.NewServiceAccountServiceFromConfig(context.Background(), bootstrapConfigProvider,
+			dbClient, secretsProvider, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建服務帳號服務失敗: %v", err)
+			os.Exit(1)
+		}
+		if serviceAccountService != nil {
+			apiKeyResolver = http_middleware.NewServiceAccountKeyResolver(serviceAccountService)
+			http_handlers.NewServiceAccountHandler(serviceAccountService, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		}
+	}
+	// 認證中介層以登錄頁面設置的會話 cookie 識別瀏覽器用戶
+	// 啟用 auth.oidc 時登錄頁面改用身份提供者的授權碼流程，會話的令牌過期後以保存的刷新令牌更新
+	oidcClient, oidcRefresher, err := bootstrap.NewOIDCLoginFromConfig(context.Background(), bootstrapConfigProvider,
+		sessionManager, secretsProvider, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建 OIDC 登錄失敗: %v", err)
+		os.Exit(1)
+	}
+	var sessionResolver http_middleware.SessionResolver
+	if authProvider != nil && sessionManager != nil {
+		var oidcLogin *web.OIDCLogin
+		if oidcClient != nil {
+			oidcLogin = &web.OIDCLogin{Client: oidcClient, Refresher: oidcRefresher}
+			sessionResolver = http_middleware.NewStoredSessionResolver(oidcRefresher)
+		} else {
+			sessionResolver = http_middleware.NewStoredSessionResolver(sessionManager)
+		}
+
+		authUI := web.NewAuthUIPagePlugin(authProvider, sessionManager, oidcLogin, loginThrottle, otelZapLogger)
+		if err := authUI.Init(context.Background(), map[string]interface{}{
+			"session_cookie": bootstrapConfigProvider.GetString("auth.middleware.sessionCookie"),
+			"cookie_secure":  bootstrapConfigProvider.GetBool("auth.session.cookieSecure"),
+		}); err != nil {
+			otelZapLogger.Error("初始化認證 UI 插件失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := authUI.RegisterRoute(echoHttpServer.GetRouter(), otelZapLogger); err != nil {
+			otelZapLogger.Error("註冊認證 UI 路由失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+	authMiddleware, err := bootstrap.NewAuthMiddlewareFromConfig(bootstrapConfigProvider, authProvider, apiKeyResolver,
+		sessionResolver, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建認證中介層失敗: %v", err)
+		os.Exit(1)
+	}
+	if authMiddleware != nil {
+		if err := pluginRegistry.Register(authMiddleware.GetName(), authMiddleware); err != nil {
+			otelZapLogger.Error("註冊認證中介層失敗: %v", err)
+			os.Exit(1)
+		}
+		echoHttpServer.GetRouter().Use(authMiddleware.EchoMiddleware())
+		otelZapLogger.Info("[主程序] API 認證中介層已啟用")
+	}
+
+	// CSRF 保護 (auth.csrf.enabled)：在所有 UI 頁面的 POST 表單中注入令牌，並驗證改變狀態的表單提交
+	csrfMiddleware, err := bootstrap.NewCSRFMiddlewareFromConfig(bootstrapConfigProvider, sessionManager, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("創建 CSRF 中介層失敗: %v", err)
+		os.Exit(1)
+	}
+	if csrfMiddleware != nil {
+		if err := pluginRegistry.Register(csrfMiddleware.GetName(), csrfMiddleware); err != nil {
+			otelZapLogger.Error("註冊 CSRF 中介層失敗: %v", err)
+			os.Exit(1)
+		}
+		echoHttpServer.GetRouter().Use(csrfMiddleware.EchoMiddleware())
+		otelZapLogger.Info("[主程序] CSRF 中介層已啟用")
+	}
+
+	// 創建告警管理器 (需要數據庫且 alerting.enabled 為 true)，排程結果與管線的 alert 階段都會交給它
+	var alertManager *alerting.AlertManager
+	var resultHandler scheduler.ResultHandler
+	if persistence != nil {
+		alertManager, err = bootstrap.NewAlertManagerFromConfig(context.Background(), bootstrapConfigProvider, dbClient,
+			pluginRegistry, secretsProvider, otelZapLogger, nil)
+		if err != nil {
+			otelZapLogger.Error("創建告警管理器失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+	if alertManager != nil {
+		if err := pluginRegistry.Register(alertManager.GetName(), alertManager); err != nil {
+			otelZapLogger.Error("註冊告警管理器失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := alertManager.Start(context.Background()); err != nil {
+			otelZapLogger.Error("啟動告警管理器失敗: %v", err)
+			os.Exit(1)
+		}
+		resultHandler = bootstrap.AlertResultHandler(alertManager)
+		http_handlers.NewSilenceHandler(alertManager.Silences(), otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		http_handlers.NewAlertRouteHandler(alertManager, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		http_handlers.NewOnCallHandler(alertManager.OnCall(), otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		if alertManager.Incidents() != nil {
+			http_handlers.NewIncidentHandler(alertManager.Incidents(), otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		}
+		if alertManager.Deliveries() != nil {
+			http_handlers.NewDeliveryHandler(alertManager.Deliveries(), otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		}
+		// 告警管理器保存的異常結果可以被分析師標記，標記數與精確率透過指標導出
+		feedbackService, err := bootstrap.NewFeedbackService(context.Background(), dbClient, otelZapLogger, nil)
+		if err != nil {
+			otelZapLogger.Error("創建反饋服務失敗: %v", err)
+			os.Exit(1)
+		}
+		http_handlers.NewFeedbackHandler(feedbackService, otelZapLogger).RegisterRoutes(echoHttpServer.GetRouter())
+		otelZapLogger.Info("[主程序] 告警管理器已啟動")
+	}
+
+	// 健康檢查端點始終註冊，各組件只向管理器貢獻自己的檢查
+	healthManager := health.NewHealthCheckManager(otelZapLogger, 30*time.Second)
+	web.NewHealthHandler(healthManager).RegisterRoutes(echoHttpServer.GetRouter())
+
+	// 創建檢測排程器並接入健康檢查 (需要數據庫且 scheduler.enabled 為 true)
+	var detectionScheduler *scheduler.DetectionScheduler
+	if persistence != nil {
+		detectionScheduler, err = bootstrap.NewDetectionSchedulerFromConfig(bootstrapConfigProvider, persistence.ScheduleRepo,
+			pluginRegistry, resultHandler, otelZapLogger, nil)
+		if err != nil {
+			otelZapLogger.Error("創建檢測排程器失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+	if detectionScheduler != nil {
+		if err := pluginRegistry.Register("detectionScheduler", detectionScheduler); err != nil {
+			otelZapLogger.Error("註冊檢測排程器失敗: %v", err)
+			os.Exit(1)
+		}
+		healthManager.RegisterPlugin("detectionScheduler", detectionScheduler)
+		if err := detectionScheduler.Start(context.Background()); err != nil {
+			otelZapLogger.Error("啟動檢測排程器失敗: %v", err)
+			os.Exit(1)
+		}
+		otelZapLogger.Info("[主程序] 檢測排程器已啟動")
+	}
+	if err := healthManager.Start(context.Background()); err != nil {
+		otelZapLogger.Error("啟動健康檢查管理器失敗: %v", err)
+		os.Exit(1)
+	}
+
+	// 創建歷史數據回放服務 (需要數據庫)
+	var backfillService *backfill.BackfillService
+	if persistence != nil {
+		backfillService, err = bootstrap.NewBackfillServiceFromConfig(context.Background(), bootstrapConfigProvider, dbClient,
+			persistence, pluginRegistry, otelZapLogger)
+		if err != nil {
+			otelZapLogger.Error("創建回放服務失敗: %v", err)
+			os.Exit(1)
+		}
+		if err := pluginRegistry.Register("backfillService", backfillService); err != nil {
+			otelZapLogger.Error("註冊回放服務失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// 註冊內建轉換插件並載入檢測管線
+	if err := bootstrap.RegisterTransformPlugins(pluginRegistry, otelZapLogger); err != nil {
+		otelZapLogger.Error("註冊轉換插件失敗: %v", err)
+		os.Exit(1)
+	}
+	pipelines, err := bootstrap.NewPipelinesFromConfig(context.Background(), bootstrapConfigProvider, pluginRegistry,
+		nil, nil, otelZapLogger)
+	if err != nil {
+		otelZapLogger.Error("載入檢測管線失敗: %v", err)
+		os.Exit(1)
+	}
+	for _, p := range pipelines {
+		if err := pluginRegistry.Register("pipeline."+p.Name(), p); err != nil {
+			otelZapLogger.Error("註冊檢測管線失敗: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	// 步驟 8: 打印註冊的插件列表
 	registeredPlugins := pluginRegistry.List()
 	otelZapLogger.Info("[主程序] 已註冊插件列表: %v", registeredPlugins)
@@ -176,6 +509,50 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	if detectionScheduler != nil {
+		if err := detectionScheduler.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("檢測排程器關閉失敗: %v", err)
+		}
+	}
+	healthManager.Stop(shutdownCtx)
+
+	if alertManager != nil {
+		if err := alertManager.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("告警管理器關閉失敗: %v", err)
+		}
+	}
+
+	// 在告警管理器之後關閉，使最後的通知與待發送的摘要能夠送出
+	if emailNotifier != nil {
+		if err := emailNotifier.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("郵件通知插件關閉失敗: %v", err)
+		}
+	}
+
+	if backfillService != nil {
+		if err := backfillService.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("回放服務關閉失敗: %v", err)
+		}
+	}
+
+	if rbacEngine != nil {
+		if err := rbacEngine.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("RBAC 授權引擎關閉失敗: %v", err)
+		}
+	}
+
+	if sessionSweeper != nil {
+		if err := sessionSweeper.Stop(shutdownCtx); err != nil {
+			otelZapLogger.Error("會話清理器關閉失敗: %v", err)
+		}
+	}
+
+	for _, p := range pipelines {
+		if err := p.Close(shutdownCtx); err != nil {
+			otelZapLogger.Error("檢測管線 %s 關閉失敗: %v", p.Name(), err)
+		}
+	}
+
 	if err := httpServer.Stop(shutdownCtx); err != nil {
 		otelZapLogger.Error("HTTP 服務器關閉失敗: %v", err)
 	}
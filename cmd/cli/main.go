@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"detectviz-platform/internal/adapters/cli"
+	"detectviz-platform/internal/infrastructure/platform/cli_server"
+	"detectviz-platform/internal/infrastructure/platform/config"
+	"detectviz-platform/internal/infrastructure/platform/telemetry"
+)
+
+// detectviz 命令行工具入口，提供資料庫遷移等管理命令
+func main() {
+	configPath := os.Getenv("DETECTVIZ_CONFIG")
+	if configPath == "" {
+		configPath = "configs/app_config.yaml"
+	}
+
+	configProvider, err := config.NewViperConfigProvider(configPath, nil)
+	if err != nil {
+		log.Fatalf("無法創建配置提供者: %v", err)
+	}
+
+	logger := telemetry.NewOtelZapLogger(map[string]interface{}{
+		"level": configProvider.GetString("logger.level"),
+	})
+
+	cliServer := cli_server.NewCobraCliServerProvider("detectviz", "Detectviz 平台管理工具", logger)
+	cliServer.AddCommand(cli.NewMigrateCommand(configProvider, logger))
+	cliServer.AddCommand(cli.NewSilenceCommand(configProvider, logger))
+
+	if err := cliServer.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
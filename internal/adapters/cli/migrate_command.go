@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"detectviz-platform/internal/bootstrap"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewMigrateCommand 創建 `migrate` 命令及其子命令 up、down、status、resolve
+// 職責: 讓運維人員在平台啟動之外手動檢查、套用或回滾資料庫 Schema 遷移
+func NewMigrateCommand(configProvider contracts.ConfigProvider, logger contracts.Logger) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "管理資料庫 Schema 遷移",
+	}
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "只輸出將要執行的 SQL，不修改資料庫")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "套用所有尚未執行的遷移",
+		RunE: func(c *cobra.Command, args []string) error {
+			return withMigrationRunner(c.Context(), configProvider, logger, dryRun, func(ctx context.Context, runner *database.EmbeddedMigrationRunner, client *database.SQLClientProvider) error {
+				db, err := client.GetDB(ctx)
+				if err != nil {
+					return err
+				}
+				return runner.RunMigrations(ctx, db)
+			})
+		},
+	})
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "回滾最近套用的遷移",
+		RunE: func(c *cobra.Command, args []string) error {
+			return withMigrationRunner(c.Context(), configProvider, logger, dryRun, func(ctx context.Context, runner *database.EmbeddedMigrationRunner, client *database.SQLClientProvider) error {
+				db, err := client.GetDB(ctx)
+				if err != nil {
+					return err
+				}
+				return runner.Rollback(ctx, db, steps)
+			})
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "要回滾的遷移數量")
+	cmd.AddCommand(down)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "列出所有遷移及其套用狀態",
+		RunE: func(c *cobra.Command, args []string) error {
+			return withMigrationRunner(c.Context(), configProvider, logger, dryRun, func(ctx context.Context, runner *database.EmbeddedMigrationRunner, client *database.SQLClientProvider) error {
+				db, err := client.GetDB(ctx)
+				if err != nil {
+					return err
+				}
+				statuses, err := runner.Status(ctx, db)
+				if err != nil {
+					return err
+				}
+				printMigrationStatus(c.OutOrStdout(), statuses)
+				return nil
+			})
+		},
+	})
+
+	var resolvedApplied, resolvedRolledBack bool
+	resolve := &cobra.Command{
+		Use:   "resolve <version>",
+		Short: "手動修復 Schema 後清除中途失敗遷移的 dirty 標記",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			version, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid migration version %q: %w", args[0], err)
+			}
+			if resolvedApplied == resolvedRolledBack {
+				return fmt.Errorf("specify exactly one of --applied or --rolled-back")
+			}
+			return withMigrationRunner(c.Context(), configProvider, logger, dryRun, func(ctx context.Context, runner *database.EmbeddedMigrationRunner, client *database.SQLClientProvider) error {
+				db, err := client.GetDB(ctx)
+				if err != nil {
+					return err
+				}
+				return runner.Resolve(ctx, db, version, resolvedApplied)
+			})
+		},
+	}
+	resolve.Flags().BoolVar(&resolvedApplied, "applied", false, "遷移的所有語句都已生效，保留記錄")
+	resolve.Flags().BoolVar(&resolvedRolledBack, "rolled-back", false, "已手動撤銷遷移的部分變更，刪除記錄以便重新執行")
+	cmd.AddCommand(resolve)
+
+	return cmd
+}
+
+// withMigrationRunner 建立資料庫連接與遷移執行器後執行 fn，並在結束時關閉連接
+func withMigrationRunner(ctx context.Context, configProvider contracts.ConfigProvider, logger contracts.Logger, dryRun bool,
+	fn func(ctx context.Context, runner *database.EmbeddedMigrationRunner, client *database.SQLClientProvider) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client, err := bootstrap.NewDBClientFromConfig(configProvider, logger)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("database.dsn is not configured")
+	}
+	defer client.Close()
+
+	runner, err := bootstrap.NewMigrationRunnerFromConfig(configProvider, client.Dialect(), dryRun, logger)
+	if err != nil {
+		return err
+	}
+
+	return fn(ctx, runner, client)
+}
+
+// printMigrationStatus 以表格形式輸出遷移狀態
+func printMigrationStatus(out io.Writer, statuses []database.MigrationStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		if s.Applied {
+			state = "applied"
+			if s.Modified {
+				state = "modified"
+			}
+			if s.Dirty {
+				state = "dirty"
+			}
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
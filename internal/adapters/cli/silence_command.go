@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/internal/bootstrap"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewSilenceCommand 創建 `silence` 命令及其子命令 add、list、expire
+// 職責: 讓運維人員在維護期間直接透過資料庫靜默告警，無需經過 REST API
+func NewSilenceCommand(configProvider contracts.ConfigProvider, logger contracts.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "silence",
+		Short: "管理告警靜默",
+	}
+
+	var (
+		matchers  []string
+		start     string
+		end       string
+		duration  time.Duration
+		schedule  string
+		window    time.Duration
+		createdBy string
+		comment   string
+	)
+	add := &cobra.Command{
+		Use:   "add",
+		Short: "創建靜默，例如 --matcher detector_id=cpu --duration 2h",
+		RunE: func(c *cobra.Command, args []string) error {
+			req := alerting.SilenceRequest{
+				Schedule:  schedule,
+				Duration:  window,
+				CreatedBy: createdBy,
+				Comment:   comment,
+			}
+			for _, expr := range matchers {
+				m, err := entities.ParseLabelMatcher(expr)
+				if err != nil {
+					return err
+				}
+				req.Matchers = append(req.Matchers, m)
+			}
+			var err error
+			if req.StartsAt, err = parseOptionalTime(start); err != nil {
+				return fmt.Errorf("invalid --start: %w", err)
+			}
+			if req.EndsAt, err = parseOptionalTime(end); err != nil {
+				return fmt.Errorf("invalid --end: %w", err)
+			}
+			if duration > 0 {
+				if !req.EndsAt.IsZero() {
+					return fmt.Errorf("--end and --duration cannot be used together")
+				}
+				base := req.StartsAt
+				if base.IsZero() {
+					base = time.Now()
+				}
+				req.EndsAt = base.Add(duration)
+			}
+
+			return withSilenceService(c.Context(), configProvider, logger, func(ctx context.Context, service *alerting.SilenceService) error {
+				silence, err := service.Create(ctx, req)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(c.OutOrStdout(), silence.ID)
+				return nil
+			})
+		},
+	}
+	add.Flags().StringArrayVarP(&matchers, "matcher", "m", nil, "標籤匹配條件，可重複指定：name=value、name!=value、name=~regex、name!~regex")
+	add.Flags().StringVar(&start, "start", "", "開始時間 (RFC3339)，默認立即開始")
+	add.Flags().StringVar(&end, "end", "", "結束時間 (RFC3339)")
+	add.Flags().DurationVar(&duration, "duration", 0, "從開始時間起的靜默長度，與 --end 二選一")
+	add.Flags().StringVar(&schedule, "schedule", "", "週期性維護窗口的 cron 表達式，例如 \"CRON_TZ=Asia/Taipei 0 2 * * 6\"")
+	add.Flags().DurationVar(&window, "window", 0, "每個維護窗口的長度，與 --schedule 一起使用")
+	add.Flags().StringVar(&createdBy, "created-by", "", "創建者")
+	add.Flags().StringVar(&comment, "comment", "", "靜默原因")
+	cmd.AddCommand(add)
+
+	var all bool
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "列出靜默",
+		RunE: func(c *cobra.Command, args []string) error {
+			return withSilenceService(c.Context(), configProvider, logger, func(ctx context.Context, service *alerting.SilenceService) error {
+				silences, err := service.List(ctx, all)
+				if err != nil {
+					return err
+				}
+				printSilences(c.OutOrStdout(), service, silences)
+				return nil
+			})
+		},
+	}
+	list.Flags().BoolVar(&all, "all", false, "包含已結束的靜默")
+	cmd.AddCommand(list)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "expire <id>...",
+		Short: "立即結束靜默",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return withSilenceService(c.Context(), configProvider, logger, func(ctx context.Context, service *alerting.SilenceService) error {
+				for _, id := range args {
+					if _, err := service.Expire(ctx, id); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.OutOrStdout(), "expired %s\n", id)
+				}
+				return nil
+			})
+		},
+	})
+
+	return cmd
+}
+
+// withSilenceService 建立資料庫連接與靜默服務後執行 fn，並在結束時關閉連接
+func withSilenceService(ctx context.Context, configProvider contracts.ConfigProvider, logger contracts.Logger,
+	fn func(ctx context.Context, service *alerting.SilenceService) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client, err := bootstrap.NewDBClientFromConfig(configProvider, logger)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("database.dsn is not configured")
+	}
+	defer client.Close()
+
+	service, err := bootstrap.NewSilenceService(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, service)
+}
+
+// parseOptionalTime 解析 RFC3339 時間，空字串返回零值
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// printSilences 以表格形式輸出靜默
+func printSilences(out io.Writer, service *alerting.SilenceService, silences []*entities.Silence) {
+	now := time.Now()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tMATCHERS\tSTARTS AT\tENDS AT\tSCHEDULE\tCREATED BY\tCOMMENT")
+	for _, s := range silences {
+		matchers := make([]string, len(s.Matchers))
+		for i, m := range s.Matchers {
+			matchers[i] = m.String()
+		}
+		endsAt := "-"
+		if !s.EndsAt.IsZero() {
+			endsAt = s.EndsAt.Format(time.RFC3339)
+		}
+		recurring := "-"
+		if s.IsRecurring() {
+			recurring = fmt.Sprintf("%s (%s)", s.Schedule, s.Duration)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, service.Status(s, now), strings.Join(matchers, ","),
+			s.StartsAt.Format(time.RFC3339), endsAt, recurring, s.CreatedBy, s.Comment)
+	}
+	w.Flush()
+}
@@ -0,0 +1,99 @@
+package http_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AlertRouteHandler 處理告警路由相關的 HTTP 請求
+// 職責: 讓使用者在修改路由配置前後確認樣本告警會到達哪些接收者，並確認告警以停止升級
+type AlertRouteHandler struct {
+	alertManager *alerting.AlertManager
+	logger       contracts.Logger
+}
+
+// NewAlertRouteHandler 創建新的告警路由處理器
+func NewAlertRouteHandler(alertManager *alerting.AlertManager, logger contracts.Logger) *AlertRouteHandler {
+	return &AlertRouteHandler{
+		alertManager: alertManager,
+		logger:       logger,
+	}
+}
+
+// TestRouteRequest 測試路由的請求結構，描述一個樣本告警
+type TestRouteRequest struct {
+	DetectorID string            `json:"detectorId"` // 設置時會查找檢測器擁有者作為 owner 標籤
+	Severity   string            `json:"severity"`
+	Labels     map[string]string `json:"labels"` // 分析結果帶的標籤，也可直接指定 owner
+}
+
+// TestRoute 返回樣本告警匹配到的路由與接收者
+func (h *AlertRouteHandler) TestRoute(c echo.Context) error {
+	var req TestRouteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+
+	result, err := h.alertManager.TestRoute(c.Request().Context(), req.DetectorID, req.Severity, req.Labels)
+	if err != nil {
+		h.logger.Error("測試告警路由失敗", "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// AcknowledgeResponse 確認告警的響應結構
+type AcknowledgeResponse struct {
+	Fingerprint    string    `json:"fingerprint"`
+	State          string    `json:"state"`
+	AcknowledgedAt time.Time `json:"acknowledgedAt"`
+	AcknowledgedBy string    `json:"acknowledgedBy"`
+}
+
+// Acknowledge 確認告警並停止其升級，確認者為認證主體
+func (h *AlertRouteHandler) Acknowledge(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	alert, err := h.alertManager.Acknowledge(c.Request().Context(), c.Param("fingerprint"), by)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case domainerrors.IsValidationError(err):
+			status = http.StatusBadRequest
+		case domainerrors.IsNotFoundError(err):
+			status = http.StatusNotFound
+		default:
+			h.logger.Error("確認告警失敗", "fingerprint", c.Param("fingerprint"), "error", err)
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, AcknowledgeResponse{
+		Fingerprint:    alert.Fingerprint,
+		State:          alert.State,
+		AcknowledgedAt: alert.AcknowledgedAt,
+		AcknowledgedBy: alert.AcknowledgedBy,
+	})
+}
+
+// RegisterRoutes 註冊告警路由相關路由
+func (h *AlertRouteHandler) RegisterRoutes(e *echo.Echo) {
+	routeGroup := e.Group("/api/v1/alerts/routes")
+
+	routeGroup.POST("/test", h.TestRoute)
+
+	e.POST("/api/v1/alerts/:fingerprint/acknowledge", h.Acknowledge)
+}
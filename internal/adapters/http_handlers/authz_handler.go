@@ -0,0 +1,103 @@
+package http_handlers
+
+import (
+	"net/http"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/rbac"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthzHandler 處理授權策略相關的 HTTP 請求
+// 職責: 解釋某個主體對資源的操作為何被允許或拒絕，方便排查 RBAC 策略
+type AuthzHandler struct {
+	engine *rbac.Engine
+	logger contracts.Logger
+}
+
+// NewAuthzHandler 創建新的授權處理器
+func NewAuthzHandler(engine *rbac.Engine, logger contracts.Logger) *AuthzHandler {
+	return &AuthzHandler{
+		engine: engine,
+		logger: logger,
+	}
+}
+
+// ExplainRequest 授權解釋的請求結構
+type ExplainRequest struct {
+	UserID       string   `json:"userId"`
+	Roles        []string `json:"roles"`  // 令牌聲明中的角色
+	Groups       []string `json:"groups"` // 令牌聲明中的群組
+	Resource     string   `json:"resource"`
+	Action       string   `json:"action"`
+	Organization string   `json:"organization"` // 資源所屬的組織
+	Team         string   `json:"team"`         // 資源所屬的團隊
+	OwnerID      string   `json:"ownerId"`      // 資源擁有者的用戶 ID
+}
+
+// GrantResponse 主體獲得的角色及其來源
+type GrantResponse struct {
+	Role   string `json:"role"`
+	Source string `json:"source"`
+}
+
+// ExplainResponse 授權解釋的響應結構
+type ExplainResponse struct {
+	Allowed    bool            `json:"allowed"`
+	Reason     string          `json:"reason"`
+	Permission string          `json:"permission,omitempty"`
+	Grant      *GrantResponse  `json:"grant,omitempty"`
+	Grants     []GrantResponse `json:"grants"`
+	Skipped    []string        `json:"skipped"`
+}
+
+// Explain 按目前的策略判斷請求並返回判斷理由
+func (h *AuthzHandler) Explain(c echo.Context) error {
+	var req ExplainRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	if req.UserID == "" || req.Resource == "" || req.Action == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "userId, resource and action are required",
+		})
+	}
+
+	decision := h.engine.Explain(rbac.Request{
+		Subject:  rbac.Subject{UserID: req.UserID, Roles: req.Roles, Groups: req.Groups},
+		Resource: req.Resource,
+		Action:   req.Action,
+		Scope:    rbac.Scope{Organization: req.Organization, Team: req.Team, OwnerID: req.OwnerID},
+	})
+	return c.JSON(http.StatusOK, toExplainResponse(decision))
+}
+
+// RegisterRoutes 註冊授權路由
+func (h *AuthzHandler) RegisterRoutes(e *echo.Echo) {
+	authzGroup := e.Group("/api/v1/authz")
+	authzGroup.POST("/explain", h.Explain)
+}
+
+// toExplainResponse 將授權判斷轉換為響應 DTO
+func toExplainResponse(decision rbac.Decision) ExplainResponse {
+	response := ExplainResponse{
+		Allowed:    decision.Allowed,
+		Reason:     decision.Reason,
+		Permission: decision.Permission,
+		Grants:     make([]GrantResponse, 0, len(decision.Grants)),
+		Skipped:    decision.Skipped,
+	}
+	if decision.Grant != nil {
+		response.Grant = &GrantResponse{Role: decision.Grant.Role, Source: decision.Grant.Source}
+	}
+	for _, grant := range decision.Grants {
+		response.Grants = append(response.Grants, GrantResponse{Role: grant.Role, Source: grant.Source})
+	}
+	if response.Skipped == nil {
+		response.Skipped = []string{}
+	}
+	return response
+}
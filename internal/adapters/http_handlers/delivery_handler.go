@@ -0,0 +1,157 @@
+package http_handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DeliveryHandler 處理通知投遞記錄相關的 HTTP 請求
+// 職責: 查詢每一次通知投遞的狀態、嘗試次數與錯誤，並手動重新發送投遞
+type DeliveryHandler struct {
+	deliveryService *alerting.DeliveryService
+	logger          contracts.Logger
+}
+
+// NewDeliveryHandler 創建新的通知投遞處理器
+func NewDeliveryHandler(deliveryService *alerting.DeliveryService, logger contracts.Logger) *DeliveryHandler {
+	return &DeliveryHandler{
+		deliveryService: deliveryService,
+		logger:          logger,
+	}
+}
+
+// DeliveryResponse 通知投遞的響應結構，不包含渠道設定
+type DeliveryResponse struct {
+	ID              string     `json:"id"`
+	Receiver        string     `json:"receiver"`
+	Plugin          string     `json:"plugin"`
+	Recipient       string     `json:"recipient,omitempty"`
+	Fingerprint     string     `json:"fingerprint"`
+	AlertState      string     `json:"alertState"`
+	EscalationLevel int        `json:"escalationLevel,omitempty"`
+	IncidentID      string     `json:"incidentId,omitempty"`
+	PayloadHash     string     `json:"payloadHash"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	LastError       string     `json:"lastError,omitempty"`
+	NextAttemptAt   *time.Time `json:"nextAttemptAt,omitempty"`
+	DeliveredAt     *time.Time `json:"deliveredAt,omitempty"`
+	ResendOf        string     `json:"resendOf,omitempty"`
+	RequestedBy     string     `json:"requestedBy,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// ListDeliveries 列出投遞記錄，支持 ?status=、?receiver=、?fingerprint= 與 ?limit= (默認 100)
+func (h *DeliveryHandler) ListDeliveries(c echo.Context) error {
+	filter := interfaces.NotificationDeliveryFilter{
+		Status:      c.QueryParam("status"),
+		Receiver:    c.QueryParam("receiver"),
+		Fingerprint: c.QueryParam("fingerprint"),
+		Limit:       100,
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		filter.Limit = parsed
+	}
+	deliveries, err := h.deliveryService.List(c.Request().Context(), filter)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]DeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, toDeliveryResponse(delivery))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetDelivery 獲取單個投遞記錄
+func (h *DeliveryHandler) GetDelivery(c echo.Context) error {
+	delivery, err := h.deliveryService.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toDeliveryResponse(delivery))
+}
+
+// Resend 以原投遞的內容立即重新發送，返回新建立的投遞；重新發送者為認證主體
+func (h *DeliveryHandler) Resend(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	delivery, err := h.deliveryService.Resend(c.Request().Context(), c.Param("id"), by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toDeliveryResponse(delivery))
+}
+
+// RegisterRoutes 註冊通知投遞路由
+func (h *DeliveryHandler) RegisterRoutes(e *echo.Echo) {
+	deliveryGroup := e.Group("/api/v1/notifications/deliveries")
+	deliveryGroup.GET("", h.ListDeliveries)
+	deliveryGroup.GET("/:id", h.GetDelivery)
+	deliveryGroup.POST("/:id/resend", h.Resend)
+}
+
+// toDeliveryResponse 將投遞記錄轉換為響應 DTO
+func toDeliveryResponse(delivery *entities.NotificationDelivery) DeliveryResponse {
+	response := DeliveryResponse{
+		ID:          delivery.ID,
+		Receiver:    delivery.Receiver,
+		Plugin:      delivery.Plugin,
+		Recipient:   delivery.Recipient,
+		Fingerprint: delivery.Fingerprint,
+		PayloadHash: delivery.PayloadHash,
+		Status:      delivery.Status,
+		Attempts:    delivery.Attempts,
+		LastError:   delivery.LastError,
+		ResendOf:    delivery.ResendOf,
+		RequestedBy: delivery.RequestedBy,
+		CreatedAt:   delivery.CreatedAt,
+		UpdatedAt:   delivery.UpdatedAt,
+	}
+	if notification := delivery.Notification; notification != nil {
+		response.AlertState = notification.Alert.State
+		response.EscalationLevel = notification.EscalationLevel
+		response.IncidentID = notification.IncidentID
+	}
+	if !delivery.NextAttemptAt.IsZero() {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		response.DeliveredAt = &delivery.DeliveredAt
+	}
+	return response
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *DeliveryHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理通知投遞請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
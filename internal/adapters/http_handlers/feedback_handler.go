@@ -0,0 +1,168 @@
+package http_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/application/feedback"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FeedbackHandler 處理分析結果反饋相關的 HTTP 請求
+// 職責: 查詢分析結果、標記 true_positive / false_positive / expected，並查詢檢測器的標記彙總與精確率
+type FeedbackHandler struct {
+	feedbackService *feedback.FeedbackService
+	logger          contracts.Logger
+}
+
+// NewFeedbackHandler 創建新的反饋處理器
+func NewFeedbackHandler(feedbackService *feedback.FeedbackService, logger contracts.Logger) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackService: feedbackService,
+		logger:          logger,
+	}
+}
+
+// FeedbackRequest 標記分析結果的請求結構
+type FeedbackRequest struct {
+	Label  string `json:"label"` // true_positive、false_positive 或 expected
+	Reason string `json:"reason"`
+}
+
+// FeedbackResponse 反饋標籤的響應結構
+type FeedbackResponse struct {
+	AnalysisResultID string    `json:"analysisResultId"`
+	DetectorID       string    `json:"detectorId"`
+	ResultTimestamp  time.Time `json:"resultTimestamp"`
+	Label            string    `json:"label"`
+	UserID           string    `json:"userId"`
+	Reason           string    `json:"reason,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// AnalysisResultResponse 分析結果的響應結構
+type AnalysisResultResponse struct {
+	ID         string                 `json:"id"`
+	DetectorID string                 `json:"detectorId"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Summary    string                 `json:"summary"`
+	Severity   string                 `json:"severity"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// DetectorQualityResponse 檢測器標記彙總的響應結構
+type DetectorQualityResponse struct {
+	DetectorID     string   `json:"detectorId"`
+	TruePositives  int      `json:"truePositives"`
+	FalsePositives int      `json:"falsePositives"`
+	Expected       int      `json:"expected"`
+	Labelled       int      `json:"labelled"`
+	Precision      *float64 `json:"precision,omitempty"` // 沒有 true_positive 與 false_positive 標記時省略
+}
+
+// GetResult 獲取分析結果
+func (h *FeedbackHandler) GetResult(c echo.Context) error {
+	result, err := h.feedbackService.GetResult(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, AnalysisResultResponse{
+		ID:         result.ID,
+		DetectorID: result.DetectorID,
+		Timestamp:  result.Timestamp,
+		Summary:    result.Summary,
+		Severity:   result.Severity,
+		Data:       result.Data,
+	})
+}
+
+// Label 標記分析結果，重新標記時覆蓋之前的標籤；標記者為認證主體的用戶 ID
+func (h *FeedbackHandler) Label(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	var req FeedbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	feedback, err := h.feedbackService.Label(c.Request().Context(), c.Param("id"), req.Label, userID, req.Reason)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toFeedbackResponse(feedback))
+}
+
+// GetFeedback 獲取分析結果的標記
+func (h *FeedbackHandler) GetFeedback(c echo.Context) error {
+	feedback, err := h.feedbackService.GetFeedback(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toFeedbackResponse(feedback))
+}
+
+// GetQuality 獲取檢測器的標記彙總與精確率
+func (h *FeedbackHandler) GetQuality(c echo.Context) error {
+	quality, err := h.feedbackService.Quality(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := DetectorQualityResponse{
+		DetectorID:     quality.DetectorID,
+		TruePositives:  quality.TruePositives,
+		FalsePositives: quality.FalsePositives,
+		Expected:       quality.Expected,
+		Labelled:       quality.Labelled(),
+	}
+	if precision, ok := quality.Precision(); ok {
+		response.Precision = &precision
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// RegisterRoutes 註冊反饋路由
+func (h *FeedbackHandler) RegisterRoutes(e *echo.Echo) {
+	resultGroup := e.Group("/api/v1/analysis-results")
+	resultGroup.GET("/:id", h.GetResult)
+	resultGroup.GET("/:id/feedback", h.GetFeedback)
+	resultGroup.PUT("/:id/feedback", h.Label)
+	e.GET("/api/v1/detectors/:id/quality", h.GetQuality)
+}
+
+// toFeedbackResponse 將反饋標籤轉換為響應 DTO
+func toFeedbackResponse(feedback *entities.ResultFeedback) FeedbackResponse {
+	return FeedbackResponse{
+		AnalysisResultID: feedback.AnalysisResultID,
+		DetectorID:       feedback.DetectorID,
+		ResultTimestamp:  feedback.ResultTimestamp,
+		Label:            feedback.Label,
+		UserID:           feedback.UserID,
+		Reason:           feedback.Reason,
+		CreatedAt:        feedback.CreatedAt,
+		UpdatedAt:        feedback.UpdatedAt,
+	}
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *FeedbackHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理反饋請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
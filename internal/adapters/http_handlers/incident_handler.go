@@ -0,0 +1,354 @@
+package http_handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IncidentHandler 處理事件單相關的 HTTP 請求
+// 職責: 開立、查詢、確認、指派與解決事件單，加入備註與告警；並處理通知中附帶的簽名確認鏈接
+type IncidentHandler struct {
+	incidentService *alerting.IncidentService
+	logger          contracts.Logger
+}
+
+// NewIncidentHandler 創建新的事件單處理器
+func NewIncidentHandler(incidentService *alerting.IncidentService, logger contracts.Logger) *IncidentHandler {
+	return &IncidentHandler{
+		incidentService: incidentService,
+		logger:          logger,
+	}
+}
+
+// CreateIncidentRequest 手動開立事件單的請求結構
+type CreateIncidentRequest struct {
+	Title    string   `json:"title"`
+	Severity string   `json:"severity"`
+	Assignee string   `json:"assignee"`
+	Alerts   []string `json:"alerts"` // 成員告警指紋
+}
+
+// AssignIncidentRequest 指派負責人的請求結構
+type AssignIncidentRequest struct {
+	Assignee string `json:"assignee"` // 為空時取消指派
+}
+
+// IncidentNoteRequest 加入備註的請求結構
+type IncidentNoteRequest struct {
+	Text string `json:"text"`
+}
+
+// IncidentAlertsRequest 加入告警的請求結構
+type IncidentAlertsRequest struct {
+	Alerts []string `json:"alerts"`
+}
+
+// IncidentResponse 事件單的響應結構
+type IncidentResponse struct {
+	ID                string                   `json:"id"`
+	Title             string                   `json:"title"`
+	State             string                   `json:"state"`
+	Severity          string                   `json:"severity"`
+	Assignee          string                   `json:"assignee,omitempty"`
+	Alerts            []string                 `json:"alerts"`
+	AnalysisResultIDs []string                 `json:"analysisResultIds"`
+	Timeline          []entities.IncidentEvent `json:"timeline"`
+	AcknowledgedAt    *time.Time               `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy    string                   `json:"acknowledgedBy,omitempty"`
+	ResolvedAt        *time.Time               `json:"resolvedAt,omitempty"`
+	ResolvedBy        string                   `json:"resolvedBy,omitempty"`
+	CreatedAt         time.Time                `json:"createdAt"`
+	UpdatedAt         time.Time                `json:"updatedAt"`
+}
+
+// CreateIncident 手動開立事件單，開立者為認證主體
+func (h *IncidentHandler) CreateIncident(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req CreateIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	incident, err := h.incidentService.Create(c.Request().Context(), &entities.Incident{
+		Title:    req.Title,
+		Severity: req.Severity,
+		Assignee: req.Assignee,
+		Alerts:   req.Alerts,
+	}, by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toIncidentResponse(incident))
+}
+
+// ListIncidents 列出事件單，支持 ?state= 與 ?limit= (默認 100)
+func (h *IncidentHandler) ListIncidents(c echo.Context) error {
+	limit := 100
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = parsed
+	}
+	incidents, err := h.incidentService.List(c.Request().Context(), c.QueryParam("state"), limit)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]IncidentResponse, 0, len(incidents))
+	for _, incident := range incidents {
+		response = append(response, toIncidentResponse(incident))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetIncident 獲取單個事件單及其時間線
+func (h *IncidentHandler) GetIncident(c echo.Context) error {
+	incident, err := h.incidentService.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toIncidentResponse(incident))
+}
+
+// Acknowledge 確認事件單並停止成員告警的升級，確認者為認證主體
+func (h *IncidentHandler) Acknowledge(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	incident, err := h.incidentService.Acknowledge(c.Request().Context(), c.Param("id"), by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toIncidentResponse(incident))
+}
+
+// AcknowledgeLink 處理通知中的確認鏈接 (GET)，只驗證 ?by=、?exp= 與 ?token= 並顯示確認頁面，不改變事件單。
+// 郵件掃描器與鏈接預覽會自動打開 GET 鏈接，因此確認必須由頁面中的表單提交到 ConfirmAcknowledgeLink。
+func (h *IncidentHandler) AcknowledgeLink(c echo.Context) error {
+	id, by, token := c.Param("id"), c.QueryParam("by"), c.QueryParam("token")
+	expires, err := strconv.ParseInt(c.QueryParam("exp"), 10, 64)
+	if err != nil {
+		return h.linkErrorPage(c, domainerrors.NewAuthError("確認鏈接無效"))
+	}
+	incident, err := h.incidentService.VerifyAcknowledgeLink(c.Request().Context(), id, by, expires, token)
+	if err != nil {
+		return h.linkErrorPage(c, err)
+	}
+	if incident.IsResolved() {
+		return c.HTML(http.StatusOK, acknowledgePage("事件單已解決，不需要確認。", ""))
+	}
+	if incident.State == entities.IncidentStateAcknowledged {
+		return c.HTML(http.StatusOK, acknowledgePage(fmt.Sprintf("事件單已由 %s 確認。", incident.AcknowledgedBy), ""))
+	}
+	form := fmt.Sprintf(`<form method="POST" action="/api/v1/incidents/%s/acknowledge/confirm">
+        <input type="hidden" name="by" value="%s">
+        <input type="hidden" name="exp" value="%d">
+        <input type="hidden" name="token" value="%s">
+        <button type="submit">確認事件單</button>
+    </form>`, html.EscapeString(url.PathEscape(id)), html.EscapeString(by), expires, html.EscapeString(token))
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.HTML(http.StatusOK, acknowledgePage(fmt.Sprintf("確認事件單「%s」並停止成員告警的升級？", incident.Title), form))
+}
+
+// ConfirmAcknowledgeLink 處理確認頁面提交的表單 (POST)，再次驗證令牌後確認事件單；每個鏈接只能使用一次
+func (h *IncidentHandler) ConfirmAcknowledgeLink(c echo.Context) error {
+	id, by, token := c.Param("id"), c.FormValue("by"), c.FormValue("token")
+	expires, err := strconv.ParseInt(c.FormValue("exp"), 10, 64)
+	if err != nil {
+		return h.linkErrorPage(c, domainerrors.NewAuthError("確認鏈接無效"))
+	}
+	incident, err := h.incidentService.AcknowledgeWithLink(c.Request().Context(), id, by, expires, token)
+	if err != nil {
+		return h.linkErrorPage(c, err)
+	}
+	return c.HTML(http.StatusOK, acknowledgePage(fmt.Sprintf("事件單「%s」已確認。", incident.Title), ""))
+}
+
+// Resolve 解決事件單，解決者為認證主體
+func (h *IncidentHandler) Resolve(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	incident, err := h.incidentService.Resolve(c.Request().Context(), c.Param("id"), by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toIncidentResponse(incident))
+}
+
+// Assign 指派事件單的負責人，操作者為認證主體
+func (h *IncidentHandler) Assign(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req AssignIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	incident, err := h.incidentService.Assign(c.Request().Context(), c.Param("id"), req.Assignee, by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toIncidentResponse(incident))
+}
+
+// AddNote 在事件單時間線上加入備註，作者為認證主體
+func (h *IncidentHandler) AddNote(c echo.Context) error {
+	author := requestActor(c)
+	if author == "" {
+		return authenticationRequired(c)
+	}
+	var req IncidentNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	incident, err := h.incidentService.AddNote(c.Request().Context(), c.Param("id"), author, req.Text)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toIncidentResponse(incident))
+}
+
+// AddAlerts 將告警加入事件單，操作者為認證主體
+func (h *IncidentHandler) AddAlerts(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req IncidentAlertsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	incident, err := h.incidentService.AddAlerts(c.Request().Context(), c.Param("id"), req.Alerts, by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toIncidentResponse(incident))
+}
+
+// RegisterRoutes 註冊事件單路由
+func (h *IncidentHandler) RegisterRoutes(e *echo.Echo) {
+	incidentGroup := e.Group("/api/v1/incidents")
+	incidentGroup.GET("", h.ListIncidents)
+	incidentGroup.POST("", h.CreateIncident)
+	incidentGroup.GET("/:id", h.GetIncident)
+	incidentGroup.POST("/:id/acknowledge", h.Acknowledge)
+	incidentGroup.GET("/:id/acknowledge", h.AcknowledgeLink)
+	incidentGroup.POST("/:id/acknowledge/confirm", h.ConfirmAcknowledgeLink)
+	incidentGroup.POST("/:id/resolve", h.Resolve)
+	incidentGroup.PUT("/:id/assignee", h.Assign)
+	incidentGroup.POST("/:id/notes", h.AddNote)
+	incidentGroup.POST("/:id/alerts", h.AddAlerts)
+}
+
+// toIncidentResponse 將事件單轉換為響應 DTO
+func toIncidentResponse(incident *entities.Incident) IncidentResponse {
+	response := IncidentResponse{
+		ID:                incident.ID,
+		Title:             incident.Title,
+		State:             incident.State,
+		Severity:          incident.Severity,
+		Assignee:          incident.Assignee,
+		Alerts:            incident.Alerts,
+		AnalysisResultIDs: incident.AnalysisResultIDs,
+		Timeline:          incident.Timeline,
+		AcknowledgedBy:    incident.AcknowledgedBy,
+		ResolvedBy:        incident.ResolvedBy,
+		CreatedAt:         incident.CreatedAt,
+		UpdatedAt:         incident.UpdatedAt,
+	}
+	if !incident.AcknowledgedAt.IsZero() {
+		response.AcknowledgedAt = &incident.AcknowledgedAt
+	}
+	if !incident.ResolvedAt.IsZero() {
+		response.ResolvedAt = &incident.ResolvedAt
+	}
+	if response.Alerts == nil {
+		response.Alerts = []string{}
+	}
+	if response.AnalysisResultIDs == nil {
+		response.AnalysisResultIDs = []string{}
+	}
+	if response.Timeline == nil {
+		response.Timeline = []entities.IncidentEvent{}
+	}
+	return response
+}
+
+// linkErrorPage 以頁面顯示確認鏈接的錯誤：無效、過期或已使用的鏈接返回 403
+func (h *IncidentHandler) linkErrorPage(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	message := "確認事件單失敗，請稍後再試。"
+	var domainErr domainerrors.DomainError
+	switch {
+	case domainerrors.IsAuthError(err) && errors.As(err, &domainErr):
+		status, message = http.StatusForbidden, domainErr.Message
+	case domainerrors.IsValidationError(err) && errors.As(err, &domainErr):
+		status, message = http.StatusBadRequest, domainErr.Message
+	case domainerrors.IsNotFoundError(err):
+		status, message = http.StatusNotFound, "事件單不存在。"
+	default:
+		h.logger.Error("處理事件單確認鏈接失敗", "error", err)
+	}
+	return c.HTML(status, acknowledgePage(message, ""))
+}
+
+// acknowledgePage 返回確認鏈接使用的簡單頁面
+func acknowledgePage(message, form string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="zh-TW">
+<head>
+    <meta charset="UTF-8">
+    <title>確認事件單</title>
+</head>
+<body>
+    <h1>確認事件單</h1>
+    <p>%s</p>
+    %s
+</body>
+</html>`, html.EscapeString(message), form)
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *IncidentHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理事件單請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
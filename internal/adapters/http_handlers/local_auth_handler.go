@@ -0,0 +1,293 @@
+package http_handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/adapters/http_middleware"
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LocalAuthHandler 處理本地認證提供者的 JSON API
+// 職責: 以郵箱與密碼 (及 MFA 驗證碼) 換取自簽名的訪問令牌，讓已認證的用戶修改密碼、登記與管理 TOTP 驗證器
+type LocalAuthHandler struct {
+	provider *auth.LocalAuthProvider
+	throttle *auth.LoginThrottle // 為 nil 時不限制登錄嘗試
+	logger   contracts.Logger
+}
+
+// NewLocalAuthHandler 創建新的本地認證處理器，throttle 不為 nil 時按賬戶與來源 IP 限制換取令牌的失敗次數
+func NewLocalAuthHandler(provider *auth.LocalAuthProvider, throttle *auth.LoginThrottle, logger contracts.Logger) *LocalAuthHandler {
+	return &LocalAuthHandler{
+		provider: provider,
+		throttle: throttle,
+		logger:   logger,
+	}
+}
+
+// TokenRequest 換取訪問令牌的請求結構
+type TokenRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	MFACode  string `json:"mfaCode"` // 已啟用 MFA 的用戶須提供 TOTP 驗證碼或恢復碼
+}
+
+// TokenResponse 訪問令牌的響應結構
+type TokenResponse struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int64  `json:"expiresIn"` // 令牌剩餘有效秒數
+}
+
+// ChangePasswordRequest 修改密碼的請求結構
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// MFACodeRequest 提交 TOTP 驗證碼的請求結構
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableMFARequest 停用 MFA 的請求結構
+type DisableMFARequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // TOTP 驗證碼或恢復碼
+}
+
+// MFAStatusResponse MFA 狀態的響應結構
+type MFAStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Pending                bool `json:"pending"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// MFAEnrollmentResponse 開始登記驗證器的響應結構，密鑰只在此時返回
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"` // otpauth URI，前端將其編碼為二維碼供驗證器應用掃描
+}
+
+// RecoveryCodesResponse 恢復碼的響應結構，恢復碼只在生成時返回
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// IssueToken 驗證郵箱與密碼並簽發訪問令牌
+func (h *LocalAuthHandler) IssueToken(c echo.Context) error {
+	var req TokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	ctx := c.Request().Context()
+	ip := c.RealIP()
+	if h.throttle != nil {
+		if err := h.throttle.Check(ctx, req.Email, ip); err != nil {
+			return throttledResponse(c, err)
+		}
+	}
+	token, user, err := h.provider.Login(ctx, req.Email, req.Password, req.MFACode)
+	var mfaRequired *auth.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		// 密碼正確，不計入登錄失敗
+		if h.throttle != nil {
+			h.throttle.Release(ctx, req.Email, ip)
+		}
+		if mfaRequired.Enrollment {
+			return c.JSON(http.StatusForbidden, map[string]interface{}{
+				"error":                 err.Error(),
+				"mfaEnrollmentRequired": true,
+			})
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":       err.Error(),
+			"mfaRequired": true,
+		})
+	}
+	if err != nil {
+		if h.throttle != nil {
+			if !domainerrors.IsAuthError(err) {
+				h.throttle.Release(ctx, req.Email, ip)
+			} else if err := h.throttle.RecordFailure(ctx, req.Email, ip); err != nil {
+				h.logger.Error("記錄登錄失敗次數失敗", "error", err)
+			}
+		}
+		return h.errorResponse(c, err)
+	}
+	if h.throttle != nil {
+		if err := h.throttle.RecordSuccess(ctx, req.Email, ip); err != nil {
+			h.logger.Error("重置登錄失敗次數失敗", "user_id", user.ID, "error", err)
+		}
+	}
+	h.logger.Info("簽發本地訪問令牌", "user_id", user.ID)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, TokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(token.ExpiresAt).Seconds()),
+	})
+}
+
+// ChangePassword 修改當前認證用戶的密碼
+func (h *LocalAuthHandler) ChangePassword(c echo.Context) error {
+	principal, ok := http_middleware.PrincipalFromContext(c.Request().Context())
+	if !ok || principal.UserID == "" {
+		return authenticationRequired(c)
+	}
+	var req ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	if err := h.provider.ChangePassword(c.Request().Context(), principal.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetMFAStatus 返回當前認證用戶的 MFA 狀態
+func (h *LocalAuthHandler) GetMFAStatus(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	status, err := h.provider.MFAStatus(c.Request().Context(), userID)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, MFAStatusResponse{
+		Enabled:                status.Enabled,
+		Pending:                status.Pending,
+		Required:               status.Required,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+// BeginMFAEnrollment 為當前認證用戶生成新的 TOTP 密鑰，以 ConfirmMFAEnrollment 確認後生效
+func (h *LocalAuthHandler) BeginMFAEnrollment(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	enrollment, err := h.provider.BeginMFAEnrollment(c.Request().Context(), userID)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, MFAEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// ConfirmMFAEnrollment 以驗證器顯示的第一個驗證碼完成登記並返回恢復碼
+func (h *LocalAuthHandler) ConfirmMFAEnrollment(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	var req MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	codes, err := h.provider.ConfirmMFAEnrollment(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes 以當前的驗證碼確認後生成新的恢復碼，舊的恢復碼失效
+func (h *LocalAuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	var req MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	codes, err := h.provider.RegenerateRecoveryCodes(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA 驗證密碼與驗證碼後停用當前認證用戶的 MFA
+func (h *LocalAuthHandler) DisableMFA(c echo.Context) error {
+	userID, ok := principalUserID(c)
+	if !ok {
+		return authenticationRequired(c)
+	}
+	var req DisableMFARequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	if err := h.provider.DisableMFA(c.Request().Context(), userID, req.Password, req.Code); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ResetUserMFA 由管理員刪除用戶的 MFA 設定，供遺失驗證器與恢復碼的用戶重新登記
+func (h *LocalAuthHandler) ResetUserMFA(c echo.Context) error {
+	userID := c.Param("id")
+	if err := h.provider.ResetMFA(c.Request().Context(), userID); err != nil {
+		return h.errorResponse(c, err)
+	}
+	h.logger.Warn("管理員已重置用戶的多因素認證", "user_id", userID, "actor", requestActor(c))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegisterRoutes 註冊本地認證路由，/auth/token 屬於默認的公開路由；配置了 MFA 時註冊 MFA 路由
+func (h *LocalAuthHandler) RegisterRoutes(e *echo.Echo) {
+	e.POST("/auth/token", h.IssueToken)
+	e.POST("/api/v1/auth/password", h.ChangePassword)
+
+	if h.provider.MFAEnabled() {
+		mfaGroup := e.Group("/api/v1/auth/mfa")
+		mfaGroup.GET("", h.GetMFAStatus)
+		mfaGroup.POST("/totp", h.BeginMFAEnrollment)
+		mfaGroup.POST("/totp/confirm", h.ConfirmMFAEnrollment)
+		mfaGroup.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+		mfaGroup.POST("/disable", h.DisableMFA)
+		mfaGroup.DELETE("/users/:id", h.ResetUserMFA)
+	}
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *LocalAuthHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsAuthError(err):
+		status = http.StatusUnauthorized
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理本地認證請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
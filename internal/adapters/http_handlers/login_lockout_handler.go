@@ -0,0 +1,135 @@
+package http_handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LoginLockoutHandler 處理登錄鎖定的管理 API
+// 職責: 讓管理員查看賬戶或來源 IP 的登錄失敗計數，並在鎖定期滿前手動解鎖
+type LoginLockoutHandler struct {
+	throttle *auth.LoginThrottle
+	logger   contracts.Logger
+}
+
+// NewLoginLockoutHandler 創建新的登錄鎖定處理器
+func NewLoginLockoutHandler(throttle *auth.LoginThrottle, logger contracts.Logger) *LoginLockoutHandler {
+	return &LoginLockoutHandler{
+		throttle: throttle,
+		logger:   logger,
+	}
+}
+
+// LoginLockoutResponse 登錄鎖定狀態的響應結構
+type LoginLockoutResponse struct {
+	Scope         string     `json:"scope"`
+	Subject       string     `json:"subject"`
+	Failures      int        `json:"failures"`
+	Locked        bool       `json:"locked"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+}
+
+// GetAccountLockout 返回賬戶的登錄失敗計數與鎖定狀態
+func (h *LoginLockoutHandler) GetAccountLockout(c echo.Context) error {
+	return h.status(c, auth.ThrottleScopeAccount, "account")
+}
+
+// GetIPLockout 返回來源 IP 的登錄失敗計數與鎖定狀態
+func (h *LoginLockoutHandler) GetIPLockout(c echo.Context) error {
+	return h.status(c, auth.ThrottleScopeIP, "ip")
+}
+
+// UnlockAccount 解除賬戶的登錄鎖定並清除失敗計數
+func (h *LoginLockoutHandler) UnlockAccount(c echo.Context) error {
+	return h.unlock(c, auth.ThrottleScopeAccount, "account")
+}
+
+// UnlockIP 解除來源 IP 的登錄鎖定並清除失敗計數
+func (h *LoginLockoutHandler) UnlockIP(c echo.Context) error {
+	return h.unlock(c, auth.ThrottleScopeIP, "ip")
+}
+
+// RegisterRoutes 註冊登錄鎖定路由
+func (h *LoginLockoutHandler) RegisterRoutes(e *echo.Echo) {
+	lockoutGroup := e.Group("/api/v1/auth/lockouts")
+	lockoutGroup.GET("/accounts/:account", h.GetAccountLockout)
+	lockoutGroup.DELETE("/accounts/:account", h.UnlockAccount)
+	lockoutGroup.GET("/ips/:ip", h.GetIPLockout)
+	lockoutGroup.DELETE("/ips/:ip", h.UnlockIP)
+}
+
+// status 返回 param 指定的賬戶或 IP 的狀態
+func (h *LoginLockoutHandler) status(c echo.Context, scope, param string) error {
+	subject, err := url.PathUnescape(c.Param(param))
+	if err != nil || subject == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid " + param,
+		})
+	}
+	status, err := h.throttle.Status(c.Request().Context(), scope, subject)
+	if err != nil {
+		h.logger.Error("讀取登錄鎖定狀態失敗", "scope", scope, "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	response := LoginLockoutResponse{
+		Scope:    status.Scope,
+		Subject:  status.Subject,
+		Failures: status.Failures,
+		Locked:   !status.LockedUntil.IsZero(),
+	}
+	if !status.LockedUntil.IsZero() {
+		response.LockedUntil = &status.LockedUntil
+	}
+	if !status.NextAttemptAt.IsZero() {
+		response.NextAttemptAt = &status.NextAttemptAt
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// unlock 解除 param 指定的賬戶或 IP 的鎖定，解除者為認證主體
+func (h *LoginLockoutHandler) unlock(c echo.Context, scope, param string) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	subject, err := url.PathUnescape(c.Param(param))
+	if err != nil || subject == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid " + param,
+		})
+	}
+	if err := h.throttle.Unlock(c.Request().Context(), scope, subject, by); err != nil {
+		h.logger.Error("解除登錄鎖定失敗", "scope", scope, "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// throttledResponse 將登錄限流錯誤轉換為 429 響應並設置 Retry-After
+func throttledResponse(c echo.Context, err error) error {
+	var throttled *auth.ThrottledError
+	if !errors.As(err, &throttled) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+	return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+		"error":      "too many failed login attempts",
+		"locked":     throttled.Locked,
+		"retryAfter": throttled.RetryAfterSeconds(),
+	})
+}
@@ -0,0 +1,412 @@
+package http_handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OnCallHandler 處理值班表與升級策略相關的 HTTP 請求
+// 職責: 管理值班表、臨時替班與升級策略，並查詢某一時刻的值班者
+type OnCallHandler struct {
+	oncallService *alerting.OnCallService
+	logger        contracts.Logger
+}
+
+// NewOnCallHandler 創建新的值班處理器
+func NewOnCallHandler(oncallService *alerting.OnCallService, logger contracts.Logger) *OnCallHandler {
+	return &OnCallHandler{
+		oncallService: oncallService,
+		logger:        logger,
+	}
+}
+
+// ScheduleRequest 創建或更新值班表的請求結構
+type ScheduleRequest struct {
+	Name        string                    `json:"name"`
+	Team        string                    `json:"team"`
+	Description string                    `json:"description"`
+	Timezone    string                    `json:"timezone"` // IANA 時區，例如 "Asia/Taipei"
+	Layers      []entities.OnCallLayer    `json:"layers"`
+	Overrides   []entities.OnCallOverride `json:"overrides"`
+}
+
+// ScheduleResponse 值班表的響應結構
+type ScheduleResponse struct {
+	ID          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	Team        string                    `json:"team"`
+	Description string                    `json:"description"`
+	Timezone    string                    `json:"timezone"`
+	Layers      []entities.OnCallLayer    `json:"layers"`
+	Overrides   []entities.OnCallOverride `json:"overrides"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+	UpdatedAt   time.Time                 `json:"updatedAt"`
+}
+
+// OverrideRequest 加入臨時替班的請求結構
+type OverrideRequest struct {
+	UserID string    `json:"userId"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// OnCallUserResponse 值班者的響應結構
+type OnCallUserResponse struct {
+	ScheduleID   string `json:"scheduleId"`
+	ScheduleName string `json:"scheduleName"`
+	Team         string `json:"team"`
+	UserID       string `json:"userId,omitempty"` // 沒有人值班時為空
+	Email        string `json:"email,omitempty"`
+}
+
+// EscalationPolicyRequest 創建或更新升級策略的請求結構
+type EscalationPolicyRequest struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Levels      []EscalationLevelPayload `json:"levels"`
+	RepeatCount int                      `json:"repeatCount"`
+}
+
+// EscalationLevelPayload 升級層級的請求與響應結構
+type EscalationLevelPayload struct {
+	Delay   string                      `json:"delay"` // 上一次通知後等待多久仍未確認，例如 "15m"
+	Targets []entities.EscalationTarget `json:"targets"`
+}
+
+// EscalationPolicyResponse 升級策略的響應結構
+type EscalationPolicyResponse struct {
+	ID          string                   `json:"id"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Levels      []EscalationLevelPayload `json:"levels"`
+	RepeatCount int                      `json:"repeatCount"`
+	CreatedAt   time.Time                `json:"createdAt"`
+	UpdatedAt   time.Time                `json:"updatedAt"`
+}
+
+// CreateSchedule 創建值班表
+func (h *OnCallHandler) CreateSchedule(c echo.Context) error {
+	var req ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	schedule, err := h.oncallService.CreateSchedule(c.Request().Context(), req.toEntity())
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toScheduleResponse(schedule))
+}
+
+// UpdateSchedule 更新值班表
+func (h *OnCallHandler) UpdateSchedule(c echo.Context) error {
+	var req ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	schedule, err := h.oncallService.UpdateSchedule(c.Request().Context(), c.Param("id"), req.toEntity())
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toScheduleResponse(schedule))
+}
+
+// ListSchedules 列出值班表，?team= 時只返回該團隊的值班表
+func (h *OnCallHandler) ListSchedules(c echo.Context) error {
+	schedules, err := h.oncallService.ListSchedules(c.Request().Context(), c.QueryParam("team"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]ScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		response = append(response, toScheduleResponse(schedule))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetSchedule 獲取單個值班表
+func (h *OnCallHandler) GetSchedule(c echo.Context) error {
+	schedule, err := h.oncallService.GetSchedule(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toScheduleResponse(schedule))
+}
+
+// DeleteSchedule 刪除值班表
+func (h *OnCallHandler) DeleteSchedule(c echo.Context) error {
+	if err := h.oncallService.DeleteSchedule(c.Request().Context(), c.Param("id")); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddOverride 為值班表加入臨時替班
+func (h *OnCallHandler) AddOverride(c echo.Context) error {
+	var req OverrideRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	schedule, err := h.oncallService.AddOverride(c.Request().Context(), c.Param("id"), entities.OnCallOverride{
+		UserID: req.UserID,
+		Start:  req.Start,
+		End:    req.End,
+	})
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toScheduleResponse(schedule))
+}
+
+// RemoveOverride 移除值班表的臨時替班
+func (h *OnCallHandler) RemoveOverride(c echo.Context) error {
+	schedule, err := h.oncallService.RemoveOverride(c.Request().Context(), c.Param("id"), c.Param("overrideId"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toScheduleResponse(schedule))
+}
+
+// ScheduleOnCall 返回值班表的值班者，?at= (RFC3339) 指定時刻，省略時為當前時間
+func (h *OnCallHandler) ScheduleOnCall(c echo.Context) error {
+	at, err := parseAt(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid at: " + err.Error(),
+		})
+	}
+	entry, err := h.oncallService.ScheduleOnCall(c.Request().Context(), c.Param("id"), at)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toOnCallUserResponse(*entry))
+}
+
+// TeamOnCall 返回團隊每個值班表的值班者，?at= (RFC3339) 指定時刻，省略時為當前時間
+func (h *OnCallHandler) TeamOnCall(c echo.Context) error {
+	at, err := parseAt(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid at: " + err.Error(),
+		})
+	}
+	entries, err := h.oncallService.TeamOnCall(c.Request().Context(), c.Param("team"), at)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]OnCallUserResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, toOnCallUserResponse(entry))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreatePolicy 創建升級策略
+func (h *OnCallHandler) CreatePolicy(c echo.Context) error {
+	policy, err := h.bindPolicy(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	created, err := h.oncallService.CreatePolicy(c.Request().Context(), policy)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toPolicyResponse(created))
+}
+
+// UpdatePolicy 更新升級策略
+func (h *OnCallHandler) UpdatePolicy(c echo.Context) error {
+	policy, err := h.bindPolicy(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	updated, err := h.oncallService.UpdatePolicy(c.Request().Context(), c.Param("id"), policy)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toPolicyResponse(updated))
+}
+
+// ListPolicies 列出升級策略
+func (h *OnCallHandler) ListPolicies(c echo.Context) error {
+	policies, err := h.oncallService.ListPolicies(c.Request().Context())
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]EscalationPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		response = append(response, toPolicyResponse(policy))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetPolicy 獲取單個升級策略
+func (h *OnCallHandler) GetPolicy(c echo.Context) error {
+	policy, err := h.oncallService.GetPolicy(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toPolicyResponse(policy))
+}
+
+// DeletePolicy 刪除升級策略
+func (h *OnCallHandler) DeletePolicy(c echo.Context) error {
+	if err := h.oncallService.DeletePolicy(c.Request().Context(), c.Param("id")); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegisterRoutes 註冊值班與升級策略路由
+func (h *OnCallHandler) RegisterRoutes(e *echo.Echo) {
+	scheduleGroup := e.Group("/api/v1/oncall/schedules")
+	scheduleGroup.GET("", h.ListSchedules)
+	scheduleGroup.POST("", h.CreateSchedule)
+	scheduleGroup.GET("/:id", h.GetSchedule)
+	scheduleGroup.PUT("/:id", h.UpdateSchedule)
+	scheduleGroup.DELETE("/:id", h.DeleteSchedule)
+	scheduleGroup.GET("/:id/oncall", h.ScheduleOnCall)
+	scheduleGroup.POST("/:id/overrides", h.AddOverride)
+	scheduleGroup.DELETE("/:id/overrides/:overrideId", h.RemoveOverride)
+
+	e.GET("/api/v1/oncall/teams/:team", h.TeamOnCall)
+
+	policyGroup := e.Group("/api/v1/escalation-policies")
+	policyGroup.GET("", h.ListPolicies)
+	policyGroup.POST("", h.CreatePolicy)
+	policyGroup.GET("/:id", h.GetPolicy)
+	policyGroup.PUT("/:id", h.UpdatePolicy)
+	policyGroup.DELETE("/:id", h.DeletePolicy)
+}
+
+// bindPolicy 解析升級策略請求，延遲以 Go duration 字串表示
+func (h *OnCallHandler) bindPolicy(c echo.Context) (*entities.EscalationPolicy, error) {
+	var req EscalationPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, errors.New("Invalid request format")
+	}
+	policy := &entities.EscalationPolicy{
+		Name:        req.Name,
+		Description: req.Description,
+		RepeatCount: req.RepeatCount,
+	}
+	for _, level := range req.Levels {
+		var delay time.Duration
+		if level.Delay != "" {
+			d, err := time.ParseDuration(level.Delay)
+			if err != nil {
+				return nil, domainerrors.NewValidationError("delay", "Invalid delay: "+err.Error())
+			}
+			delay = d
+		}
+		policy.Levels = append(policy.Levels, entities.EscalationLevel{Delay: delay, Targets: level.Targets})
+	}
+	return policy, nil
+}
+
+// toEntity 將請求轉換為值班表實體
+func (req ScheduleRequest) toEntity() *entities.OnCallSchedule {
+	return &entities.OnCallSchedule{
+		Name:        req.Name,
+		Team:        req.Team,
+		Description: req.Description,
+		Timezone:    req.Timezone,
+		Layers:      req.Layers,
+		Overrides:   req.Overrides,
+	}
+}
+
+// toScheduleResponse 將值班表轉換為響應 DTO
+func toScheduleResponse(schedule *entities.OnCallSchedule) ScheduleResponse {
+	response := ScheduleResponse{
+		ID:          schedule.ID,
+		Name:        schedule.Name,
+		Team:        schedule.Team,
+		Description: schedule.Description,
+		Timezone:    schedule.Timezone,
+		Layers:      schedule.Layers,
+		Overrides:   schedule.Overrides,
+		CreatedAt:   schedule.CreatedAt,
+		UpdatedAt:   schedule.UpdatedAt,
+	}
+	if response.Layers == nil {
+		response.Layers = []entities.OnCallLayer{}
+	}
+	if response.Overrides == nil {
+		response.Overrides = []entities.OnCallOverride{}
+	}
+	return response
+}
+
+// toPolicyResponse 將升級策略轉換為響應 DTO
+func toPolicyResponse(policy *entities.EscalationPolicy) EscalationPolicyResponse {
+	response := EscalationPolicyResponse{
+		ID:          policy.ID,
+		Name:        policy.Name,
+		Description: policy.Description,
+		Levels:      make([]EscalationLevelPayload, 0, len(policy.Levels)),
+		RepeatCount: policy.RepeatCount,
+		CreatedAt:   policy.CreatedAt,
+		UpdatedAt:   policy.UpdatedAt,
+	}
+	for _, level := range policy.Levels {
+		response.Levels = append(response.Levels, EscalationLevelPayload{Delay: level.Delay.String(), Targets: level.Targets})
+	}
+	return response
+}
+
+// toOnCallUserResponse 將值班者轉換為響應 DTO，不包含密碼散列等用戶欄位
+func toOnCallUserResponse(entry alerting.OnCallEntry) OnCallUserResponse {
+	response := OnCallUserResponse{
+		ScheduleID:   entry.ScheduleID,
+		ScheduleName: entry.ScheduleName,
+		Team:         entry.Team,
+	}
+	if entry.User != nil {
+		response.UserID = entry.User.ID
+		response.Email = entry.User.Email
+	}
+	return response
+}
+
+// parseAt 解析 ?at= 查詢參數，省略時返回當前時間
+func parseAt(c echo.Context) (time.Time, error) {
+	value := c.QueryParam("at")
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *OnCallHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理值班請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
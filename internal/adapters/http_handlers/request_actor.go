@@ -0,0 +1,38 @@
+package http_handlers
+
+import (
+	"net/http"
+
+	"detectviz-platform/internal/adapters/http_middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requestActor 返回認證中介層放入 context 的主體名稱，未啟用認證時為空。
+// 記錄操作者時只使用認證主體，不接受請求內容中自報的身份。
+func requestActor(c echo.Context) string {
+	principal, ok := http_middleware.PrincipalFromContext(c.Request().Context())
+	if !ok {
+		return ""
+	}
+	if principal.Username != "" {
+		return principal.Username
+	}
+	return principal.UserID
+}
+
+// principalUserID 返回認證中介層放入 context 的用戶 ID
+func principalUserID(c echo.Context) (string, bool) {
+	principal, ok := http_middleware.PrincipalFromContext(c.Request().Context())
+	if !ok || principal.UserID == "" {
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// authenticationRequired 在必須記錄操作者而請求沒有認證主體時返回 401，例如未啟用認證中介層
+func authenticationRequired(c echo.Context) error {
+	return c.JSON(http.StatusUnauthorized, map[string]string{
+		"error": "authentication required",
+	})
+}
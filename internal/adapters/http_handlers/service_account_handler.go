@@ -0,0 +1,267 @@
+package http_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/application/serviceaccount"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ServiceAccountHandler 處理服務帳號與 API 金鑰相關的 HTTP 請求
+// 職責: 管理機器客戶端的服務帳號，簽發 (明文只返回一次)、列出與撤銷 API 金鑰
+type ServiceAccountHandler struct {
+	service *serviceaccount.ServiceAccountService
+	logger  contracts.Logger
+}
+
+// NewServiceAccountHandler 創建新的服務帳號處理器
+func NewServiceAccountHandler(service *serviceaccount.ServiceAccountService, logger contracts.Logger) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateServiceAccountRequest 創建服務帳號的請求結構
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles"`
+}
+
+// UpdateServiceAccountRequest 更新服務帳號的請求結構，省略的欄位保持不變
+type UpdateServiceAccountRequest struct {
+	Description *string  `json:"description"`
+	Roles       []string `json:"roles"`
+	Disabled    *bool    `json:"disabled"`
+}
+
+// IssueAPIKeyRequest 簽發 API 金鑰的請求結構
+type IssueAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Roles     []string `json:"roles"`     // 金鑰的角色範圍，為空時使用服務帳號的全部角色
+	ExpiresIn string   `json:"expiresIn"` // 有效期，例如 "720h"，為空時使用默認有效期
+}
+
+// ServiceAccountResponse 服務帳號的響應結構
+type ServiceAccountResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Roles       []string  `json:"roles"`
+	Disabled    bool      `json:"disabled"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// APIKeyResponse API 金鑰的響應結構，Key 只在簽發時返回
+type APIKeyResponse struct {
+	ID               string     `json:"id"`
+	ServiceAccountID string     `json:"serviceAccountId"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Key              string     `json:"key,omitempty"`
+	Roles            []string   `json:"roles"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt       *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy        string     `json:"revokedBy,omitempty"`
+	CreatedBy        string     `json:"createdBy,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+// ListServiceAccounts 列出服務帳號
+func (h *ServiceAccountHandler) ListServiceAccounts(c echo.Context) error {
+	accounts, err := h.service.ListAccounts(c.Request().Context())
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]ServiceAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		response = append(response, toServiceAccountResponse(account))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateServiceAccount 創建服務帳號，創建者為認證主體
+func (h *ServiceAccountHandler) CreateServiceAccount(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req CreateServiceAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	account, err := h.service.CreateAccount(c.Request().Context(), serviceaccount.CreateAccountRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		Roles:       req.Roles,
+		CreatedBy:   by,
+	})
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, toServiceAccountResponse(account))
+}
+
+// GetServiceAccount 獲取服務帳號
+func (h *ServiceAccountHandler) GetServiceAccount(c echo.Context) error {
+	account, err := h.service.GetAccount(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toServiceAccountResponse(account))
+}
+
+// UpdateServiceAccount 更新服務帳號的說明、角色或停用狀態
+func (h *ServiceAccountHandler) UpdateServiceAccount(c echo.Context) error {
+	var req UpdateServiceAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	account, err := h.service.UpdateAccount(c.Request().Context(), c.Param("id"), serviceaccount.UpdateAccountRequest{
+		Description: req.Description,
+		Roles:       req.Roles,
+		Disabled:    req.Disabled,
+	})
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toServiceAccountResponse(account))
+}
+
+// ListAPIKeys 列出服務帳號的金鑰，不包含明文
+func (h *ServiceAccountHandler) ListAPIKeys(c echo.Context) error {
+	keys, err := h.service.ListKeys(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyResponse(key, ""))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// IssueAPIKey 簽發 API 金鑰，響應中的 key 是唯一一次返回的明文
+func (h *ServiceAccountHandler) IssueAPIKey(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req IssueAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+	key, plaintext, err := h.service.IssueKey(c.Request().Context(), c.Param("id"), serviceaccount.IssueKeyRequest{
+		Name:      req.Name,
+		Roles:     req.Roles,
+		ExpiresIn: req.ExpiresIn,
+		CreatedBy: by,
+	})
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusCreated, toAPIKeyResponse(key, plaintext))
+}
+
+// RevokeAPIKey 撤銷 API 金鑰，撤銷者為認證主體
+func (h *ServiceAccountHandler) RevokeAPIKey(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	key, err := h.service.RevokeKey(c.Request().Context(), c.Param("id"), c.Param("keyId"), by)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, toAPIKeyResponse(key, ""))
+}
+
+// RegisterRoutes 註冊服務帳號路由
+func (h *ServiceAccountHandler) RegisterRoutes(e *echo.Echo) {
+	accountGroup := e.Group("/api/v1/service-accounts")
+	accountGroup.GET("", h.ListServiceAccounts)
+	accountGroup.POST("", h.CreateServiceAccount)
+	accountGroup.GET("/:id", h.GetServiceAccount)
+	accountGroup.PUT("/:id", h.UpdateServiceAccount)
+	accountGroup.GET("/:id/keys", h.ListAPIKeys)
+	accountGroup.POST("/:id/keys", h.IssueAPIKey)
+	accountGroup.DELETE("/:id/keys/:keyId", h.RevokeAPIKey)
+}
+
+// toServiceAccountResponse 將服務帳號轉換為響應 DTO
+func toServiceAccountResponse(account *entities.ServiceAccount) ServiceAccountResponse {
+	roles := account.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return ServiceAccountResponse{
+		ID:          account.ID,
+		Name:        account.Name,
+		Description: account.Description,
+		Roles:       roles,
+		Disabled:    account.Disabled,
+		CreatedBy:   account.CreatedBy,
+		CreatedAt:   account.CreatedAt,
+		UpdatedAt:   account.UpdatedAt,
+	}
+}
+
+// toAPIKeyResponse 將 API 金鑰轉換為響應 DTO，plaintext 只在簽發時傳入
+func toAPIKeyResponse(key *entities.APIKey, plaintext string) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:               key.ID,
+		ServiceAccountID: key.ServiceAccountID,
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		Key:              plaintext,
+		Roles:            key.Roles,
+		RevokedBy:        key.RevokedBy,
+		CreatedBy:        key.CreatedBy,
+		CreatedAt:        key.CreatedAt,
+	}
+	if response.Roles == nil {
+		response.Roles = []string{}
+	}
+	if !key.ExpiresAt.IsZero() {
+		response.ExpiresAt = &key.ExpiresAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		response.LastUsedAt = &key.LastUsedAt
+	}
+	if !key.RevokedAt.IsZero() {
+		response.RevokedAt = &key.RevokedAt
+	}
+	return response
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *ServiceAccountHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理服務帳號請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,175 @@
+package http_handlers
+
+import (
+	"net/http"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SilenceHandler 處理告警靜默相關的 HTTP 請求
+// 職責: 創建、查詢與提前結束靜默；靜默由時間自動失效，因此 DELETE 表示立即結束而非刪除記錄
+type SilenceHandler struct {
+	silenceService *alerting.SilenceService
+	logger         contracts.Logger
+}
+
+// NewSilenceHandler 創建新的靜默處理器
+func NewSilenceHandler(silenceService *alerting.SilenceService, logger contracts.Logger) *SilenceHandler {
+	return &SilenceHandler{
+		silenceService: silenceService,
+		logger:         logger,
+	}
+}
+
+// CreateSilenceRequest 創建靜默的請求結構
+type CreateSilenceRequest struct {
+	Matchers []entities.LabelMatcher `json:"matchers"`
+	StartsAt *time.Time              `json:"startsAt,omitempty"` // 省略時立即開始
+	EndsAt   *time.Time              `json:"endsAt,omitempty"`   // 一次性靜默必填
+	Schedule string                  `json:"schedule,omitempty"` // 週期性維護窗口的 cron 表達式
+	Duration string                  `json:"duration,omitempty"` // 維護窗口長度，例如 "4h"
+	Comment  string                  `json:"comment"`
+}
+
+// SilenceResponse 靜默的響應結構
+type SilenceResponse struct {
+	ID        string                  `json:"id"`
+	Matchers  []entities.LabelMatcher `json:"matchers"`
+	Status    string                  `json:"status"`
+	StartsAt  time.Time               `json:"startsAt"`
+	EndsAt    *time.Time              `json:"endsAt,omitempty"`
+	Schedule  string                  `json:"schedule,omitempty"`
+	Duration  string                  `json:"duration,omitempty"`
+	CreatedBy string                  `json:"createdBy"`
+	Comment   string                  `json:"comment"`
+	CreatedAt time.Time               `json:"createdAt"`
+	UpdatedAt time.Time               `json:"updatedAt"`
+}
+
+// CreateSilence 創建新靜默，創建者為認證主體
+func (h *SilenceHandler) CreateSilence(c echo.Context) error {
+	by := requestActor(c)
+	if by == "" {
+		return authenticationRequired(c)
+	}
+	var req CreateSilenceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request format",
+		})
+	}
+
+	silenceReq := alerting.SilenceRequest{
+		Matchers:  req.Matchers,
+		Schedule:  req.Schedule,
+		CreatedBy: by,
+		Comment:   req.Comment,
+	}
+	if req.StartsAt != nil {
+		silenceReq.StartsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		silenceReq.EndsAt = *req.EndsAt
+	}
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid duration: " + err.Error(),
+			})
+		}
+		silenceReq.Duration = d
+	}
+
+	silence, err := h.silenceService.Create(c.Request().Context(), silenceReq)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, h.toResponse(silence))
+}
+
+// ListSilences 列出靜默，?all=true 時包含已結束的靜默
+func (h *SilenceHandler) ListSilences(c echo.Context) error {
+	silences, err := h.silenceService.List(c.Request().Context(), c.QueryParam("all") == "true")
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	response := make([]SilenceResponse, 0, len(silences))
+	for _, silence := range silences {
+		response = append(response, h.toResponse(silence))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetSilence 獲取單個靜默
+func (h *SilenceHandler) GetSilence(c echo.Context) error {
+	silence, err := h.silenceService.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, h.toResponse(silence))
+}
+
+// ExpireSilence 立即結束靜默
+func (h *SilenceHandler) ExpireSilence(c echo.Context) error {
+	silence, err := h.silenceService.Expire(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, h.toResponse(silence))
+}
+
+// RegisterRoutes 註冊靜默路由
+func (h *SilenceHandler) RegisterRoutes(e *echo.Echo) {
+	silenceGroup := e.Group("/api/v1/silences")
+
+	silenceGroup.GET("", h.ListSilences)
+	silenceGroup.POST("", h.CreateSilence)
+	silenceGroup.GET("/:id", h.GetSilence)
+	silenceGroup.DELETE("/:id", h.ExpireSilence)
+}
+
+// toResponse 將靜默轉換為響應 DTO
+func (h *SilenceHandler) toResponse(silence *entities.Silence) SilenceResponse {
+	response := SilenceResponse{
+		ID:        silence.ID,
+		Matchers:  silence.Matchers,
+		Status:    h.silenceService.Status(silence, time.Now()),
+		StartsAt:  silence.StartsAt,
+		Schedule:  silence.Schedule,
+		CreatedBy: silence.CreatedBy,
+		Comment:   silence.Comment,
+		CreatedAt: silence.CreatedAt,
+		UpdatedAt: silence.UpdatedAt,
+	}
+	if !silence.EndsAt.IsZero() {
+		endsAt := silence.EndsAt
+		response.EndsAt = &endsAt
+	}
+	if silence.Duration > 0 {
+		response.Duration = silence.Duration.String()
+	}
+	return response
+}
+
+// errorResponse 將領域錯誤轉換為對應的 HTTP 狀態碼
+func (h *SilenceHandler) errorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case domainerrors.IsValidationError(err):
+		status = http.StatusBadRequest
+	case domainerrors.IsNotFoundError(err):
+		status = http.StatusNotFound
+	default:
+		h.logger.Error("處理靜默請求失敗", "error", err)
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,40 @@
+package http_middleware
+
+import (
+	"context"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// apiKeyAuthenticator 驗證 API 金鑰，由 serviceaccount.ServiceAccountService 實現
+type apiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, plaintext string) (*entities.ServiceAccount, *entities.APIKey, error)
+}
+
+// ServiceAccountKeyResolver 把服務帳號的 API 金鑰解析為主體
+type ServiceAccountKeyResolver struct {
+	authenticator apiKeyAuthenticator
+}
+
+// NewServiceAccountKeyResolver 創建 API 金鑰解析器
+func NewServiceAccountKeyResolver(authenticator apiKeyAuthenticator) *ServiceAccountKeyResolver {
+	return &ServiceAccountKeyResolver{authenticator: authenticator}
+}
+
+// ResolveAPIKey 驗證金鑰並返回服務帳號主體，角色為金鑰範圍內的帳號角色，與令牌角色一樣經 RBAC 角色映射授權
+func (r *ServiceAccountKeyResolver) ResolveAPIKey(ctx context.Context, key string) (*Principal, error) {
+	account, apiKey, err := r.authenticator.Authenticate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		UserID:     account.ID,
+		Username:   account.Name,
+		Roles:      apiKey.EffectiveRoles(account),
+		Credential: CredentialAPIKey,
+		ExpiresAt:  apiKey.ExpiresAt,
+	}, nil
+}
+
+// 確保實現了 APIKeyResolver 介面
+var _ APIKeyResolver = (*ServiceAccountKeyResolver)(nil)
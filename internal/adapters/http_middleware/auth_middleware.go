@@ -0,0 +1,319 @@
+package http_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/internal/infrastructure/platform/auth/rbac"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// 主體的憑證類型
+const (
+	CredentialBearer  = "bearer"
+	CredentialAPIKey  = "api_key"
+	CredentialSession = "session"
+)
+
+// 結構化錯誤響應的錯誤碼
+const (
+	CodeUnauthenticated    = "unauthenticated"     // 請求沒有攜帶憑證
+	CodeInvalidCredentials = "invalid_credentials" // 憑證無效、過期或已撤銷
+	CodeForbidden          = "forbidden"           // 主體沒有路由要求的權限
+	CodeAuthorizationError = "authorization_error" // 授權判斷本身失敗
+)
+
+// Principal 是已認證的請求主體
+type Principal struct {
+	UserID     string
+	Username   string
+	Email      string
+	Roles      []string
+	Groups     []string
+	Credential string // bearer、api_key 或 session
+	ExpiresAt  time.Time
+}
+
+// APIKeyResolver 把 API 金鑰解析為主體，金鑰無效時返回錯誤
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// SessionResolver 把會話 cookie 的值解析為主體，會話無效或過期時返回錯誤
+type SessionResolver interface {
+	ResolveSession(ctx context.Context, sessionID string) (*Principal, error)
+}
+
+// identityVerifier 由能從令牌中取得角色與群組的 AuthProvider 實現，例如 KeycloakAuthProvider
+type identityVerifier interface {
+	VerifyIdentity(ctx context.Context, token string) (*auth.TokenIdentity, error)
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal 把主體放入 context，同時以其角色與群組作為 RBAC 主體
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalContextKey{}, principal)
+	return rbac.WithSubject(ctx, rbac.Subject{UserID: principal.UserID, Roles: principal.Roles, Groups: principal.Groups})
+}
+
+// PrincipalFromContext 返回中介層放入 context 的主體
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// RoutePermission 是路由的權限註解
+type RoutePermission struct {
+	Route      string `yaml:"route" json:"route"`           // "METHOD /path"，路徑段可以是 :param，最後一段可以是 * (匹配其餘部分)；省略 METHOD 表示任意方法
+	Permission string `yaml:"permission" json:"permission"` // 訪問路由需要的 resource:action
+}
+
+// AuthMiddlewareConfig 定義認證中介層的配置
+type AuthMiddlewareConfig struct {
+	// PublicRoutes 不需要認證的路由，格式與 RoutePermission.Route 相同，附加在 DefaultPublicRoutes 之後
+	PublicRoutes []string `yaml:"publicRoutes" json:"publicRoutes"`
+	// Permissions 路由的權限註解，優先於 DefaultRoutePermissions；沒有註解的路由只要求認證
+	Permissions []RoutePermission `yaml:"permissions" json:"permissions"`
+	// APIKeyHeader 攜帶 API 金鑰的請求頭，默認 "X-API-Key"
+	APIKeyHeader string `yaml:"apiKeyHeader" json:"apiKeyHeader"`
+	// SessionCookie 會話 cookie 名稱，默認 "detectviz_session"
+	SessionCookie string `yaml:"sessionCookie" json:"sessionCookie"`
+}
+
+// ErrorResponse 是認證或授權失敗時的結構化響應
+type ErrorResponse struct {
+	Error    string `json:"error"`
+	Code     string `json:"code"`
+	Resource string `json:"resource,omitempty"`
+	Action   string `json:"action,omitempty"`
+}
+
+// permissionRule 是解析後的權限註解
+type permissionRule struct {
+	route    routePattern
+	resource string
+	action   string
+}
+
+// AuthMiddlewarePlugin 實現了 MiddlewarePlugin 介面，為 HTTP API 提供認證與授權
+// 職責: 從 Bearer 令牌、API 金鑰或會話 cookie 解析主體並放入 context，
+// 按路由的權限註解透過 AuthProvider.Authorize 檢查權限，失敗時返回結構化的 401/403 錯誤。
+// 公開路由必須明確聲明，其他路由一律要求認證。
+type AuthMiddlewarePlugin struct {
+	authProvider contracts.AuthProvider
+	apiKeys      APIKeyResolver
+	sessions     SessionResolver
+	logger       contracts.Logger
+
+	public        []routePattern
+	permissions   []permissionRule
+	apiKeyHeader  string
+	sessionCookie string
+}
+
+// NewAuthMiddlewarePlugin 創建認證中介層。apiKeys 與 sessions 可為 nil，此時不接受對應的憑證。
+func NewAuthMiddlewarePlugin(config AuthMiddlewareConfig, authProvider contracts.AuthProvider, apiKeys APIKeyResolver,
+	sessions SessionResolver, logger contracts.Logger) (*AuthMiddlewarePlugin, error) {
+	if authProvider == nil {
+		return nil, fmt.Errorf("auth middleware requires an AuthProvider")
+	}
+	p := &AuthMiddlewarePlugin{
+		authProvider:  authProvider,
+		apiKeys:       apiKeys,
+		sessions:      sessions,
+		logger:        logger,
+		apiKeyHeader:  config.APIKeyHeader,
+		sessionCookie: config.SessionCookie,
+	}
+	if p.apiKeyHeader == "" {
+		p.apiKeyHeader = "X-API-Key"
+	}
+	if p.sessionCookie == "" {
+		p.sessionCookie = "detectviz_session"
+	}
+
+	for _, route := range append(append([]string{}, DefaultPublicRoutes...), config.PublicRoutes...) {
+		pattern, err := parseRoutePattern(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public route: %w", err)
+		}
+		p.public = append(p.public, pattern)
+	}
+	for _, annotation := range append(append([]RoutePermission{}, config.Permissions...), DefaultRoutePermissions...) {
+		pattern, err := parseRoutePattern(annotation.Route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid permission annotation: %w", err)
+		}
+		resource, action, ok := strings.Cut(annotation.Permission, ":")
+		if !ok || resource == "" || action == "" {
+			return nil, fmt.Errorf("invalid permission %q for route %q: expected resource:action", annotation.Permission, annotation.Route)
+		}
+		p.permissions = append(p.permissions, permissionRule{route: pattern, resource: resource, action: action})
+	}
+
+	logger.Info("初始化認證中介層", "public_routes", len(p.public), "permission_annotations", len(p.permissions),
+		"api_keys", apiKeys != nil, "sessions", sessions != nil)
+	return p, nil
+}
+
+// GetName 返回中介層名稱
+func (p *AuthMiddlewarePlugin) GetName() string {
+	return "auth_middleware"
+}
+
+// EchoMiddleware 把中介層轉換為 Echo 中介層，以 e.Use 註冊到所有路由
+func (p *AuthMiddlewarePlugin) EchoMiddleware() echo.MiddlewareFunc {
+	return echo.WrapMiddleware(p.Handle)
+}
+
+// Handle 實現 MiddlewarePlugin：公開路由直接放行；其他路由解析主體，
+// 有權限註解時再檢查權限，通過後把主體放入請求的 context
+func (p *AuthMiddlewarePlugin) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 以轉義後的路徑匹配，與 Echo 路由一致，避免 %2F 使路徑段錯位而繞過權限註解
+		method, path := r.Method, r.URL.EscapedPath()
+		if p.isPublic(method, path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, code, err := p.authenticate(r)
+		if err != nil {
+			p.logger.Debug("請求認證失敗", "method", method, "path", path, "code", code, "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="detectviz"`)
+			writeError(w, http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: code})
+			return
+		}
+		ctx := WithPrincipal(r.Context(), principal)
+
+		if rule, ok := p.permissionFor(method, path); ok {
+			allowed, err := p.authProvider.Authorize(ctx, principal.UserID, rule.resource, rule.action)
+			if err != nil {
+				p.logger.Error("授權檢查失敗", "user_id", principal.UserID, "resource", rule.resource, "action", rule.action, "error", err)
+				writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "authorization check failed",
+					Code: CodeAuthorizationError, Resource: rule.resource, Action: rule.action})
+				return
+			}
+			if !allowed {
+				p.logger.Info("拒絕未授權的請求", "user_id", principal.UserID, "method", method, "path", path,
+					"resource", rule.resource, "action", rule.action)
+				writeError(w, http.StatusForbidden, ErrorResponse{
+					Error:    fmt.Sprintf("permission %s:%s is required", rule.resource, rule.action),
+					Code:     CodeForbidden,
+					Resource: rule.resource,
+					Action:   rule.action,
+				})
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate 依次從 Authorization 請求頭、API 金鑰請求頭與會話 cookie 解析主體。
+// 攜帶了某種憑證但無效時直接失敗，不再嘗試其他憑證。
+func (p *AuthMiddlewarePlugin) authenticate(r *http.Request) (*Principal, string, error) {
+	ctx := r.Context()
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, _ := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, CodeInvalidCredentials, fmt.Errorf("unsupported authorization scheme")
+		}
+		principal, err := p.verifyBearer(ctx, token)
+		if err != nil {
+			return nil, CodeInvalidCredentials, fmt.Errorf("invalid bearer token")
+		}
+		return principal, "", nil
+	}
+
+	if key := r.Header.Get(p.apiKeyHeader); key != "" {
+		if p.apiKeys == nil {
+			return nil, CodeInvalidCredentials, fmt.Errorf("API keys are not accepted")
+		}
+		principal, err := p.apiKeys.ResolveAPIKey(ctx, key)
+		if err != nil || principal == nil {
+			return nil, CodeInvalidCredentials, fmt.Errorf("invalid API key")
+		}
+		principal.Credential = CredentialAPIKey
+		return principal, "", nil
+	}
+
+	if p.sessions != nil {
+		if cookie, err := r.Cookie(p.sessionCookie); err == nil && cookie.Value != "" {
+			principal, err := p.sessions.ResolveSession(ctx, cookie.Value)
+			if err != nil || principal == nil {
+				return nil, CodeInvalidCredentials, fmt.Errorf("session is invalid or expired")
+			}
+			principal.Credential = CredentialSession
+			return principal, "", nil
+		}
+	}
+	return nil, CodeUnauthenticated, fmt.Errorf("authentication required")
+}
+
+// verifyBearer 驗證 Bearer 令牌；AuthProvider 能解析令牌身份時帶上角色與群組
+func (p *AuthMiddlewarePlugin) verifyBearer(ctx context.Context, token string) (*Principal, error) {
+	if verifier, ok := p.authProvider.(identityVerifier); ok {
+		identity, err := verifier.VerifyIdentity(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{
+			UserID:     identity.UserID,
+			Username:   identity.Username,
+			Email:      identity.Email,
+			Roles:      identity.Roles,
+			Groups:     identity.Groups,
+			Credential: CredentialBearer,
+			ExpiresAt:  identity.ExpiresAt,
+		}, nil
+	}
+	userID, err := p.authProvider.VerifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	return &Principal{UserID: userID, Credential: CredentialBearer}, nil
+}
+
+// isPublic 檢查路由是否聲明為公開
+func (p *AuthMiddlewarePlugin) isPublic(method, path string) bool {
+	for _, pattern := range p.public {
+		if pattern.matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionFor 返回第一個匹配路由的權限註解
+func (p *AuthMiddlewarePlugin) permissionFor(method, path string) (permissionRule, bool) {
+	for _, rule := range p.permissions {
+		if rule.route.matches(method, path) {
+			return rule, true
+		}
+	}
+	return permissionRule{}, false
+}
+
+// writeError 寫出結構化的錯誤響應
+func writeError(w http.ResponseWriter, status int, response ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// 確保實現了 MiddlewarePlugin 介面
+var _ plugins.MiddlewarePlugin = (*AuthMiddlewarePlugin)(nil)
@@ -0,0 +1,231 @@
+package http_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/internal/infrastructure/platform/auth/rbac"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// stubAuthProvider 以固定的令牌與角色權限模擬 AuthProvider，角色取自 context 中的 RBAC 主體
+type stubAuthProvider struct {
+	identities  map[string]*auth.TokenIdentity
+	permissions map[string][]string // 角色 -> resource:action
+}
+
+func (s *stubAuthProvider) VerifyIdentity(ctx context.Context, token string) (*auth.TokenIdentity, error) {
+	if identity, ok := s.identities[token]; ok {
+		return identity, nil
+	}
+	return nil, fmt.Errorf("invalid token")
+}
+
+func (s *stubAuthProvider) Authenticate(ctx context.Context, credentials string) (string, error) {
+	return "", fmt.Errorf("not supported")
+}
+
+func (s *stubAuthProvider) VerifyToken(ctx context.Context, token string) (string, error) {
+	identity, err := s.VerifyIdentity(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return identity.UserID, nil
+}
+
+func (s *stubAuthProvider) Authorize(ctx context.Context, userID, resource, action string) (bool, error) {
+	subject, ok := rbac.SubjectFromContext(ctx)
+	if !ok || subject.UserID != userID {
+		return false, nil
+	}
+	for _, role := range subject.Roles {
+		for _, permission := range s.permissions[role] {
+			if permission == resource+":"+action {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s *stubAuthProvider) CheckPermissions(ctx context.Context, userID, resource, action string) (bool, error) {
+	return s.Authorize(ctx, userID, resource, action)
+}
+
+func (s *stubAuthProvider) HashPassword(ctx context.Context, plainPassword string) (string, error) {
+	return "", nil
+}
+
+func (s *stubAuthProvider) VerifyPassword(ctx context.Context, plainPassword, hashedPassword string) (bool, error) {
+	return false, nil
+}
+
+func (s *stubAuthProvider) GenerateCSRFToken(ctx context.Context) (string, error) { return "", nil }
+func (s *stubAuthProvider) ValidateCSRFToken(ctx context.Context, token string) error {
+	return nil
+}
+func (s *stubAuthProvider) GetName() string { return "stub_auth_provider" }
+
+type stubAPIKeys map[string]*Principal
+
+func (s stubAPIKeys) ResolveAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if principal, ok := s[key]; ok {
+		copied := *principal
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("unknown key")
+}
+
+func newTestServer(t *testing.T, config AuthMiddlewareConfig) *echo.Echo {
+	t.Helper()
+	provider := &stubAuthProvider{
+		identities: map[string]*auth.TokenIdentity{
+			"viewer-token":   {UserID: "alice", Roles: []string{"viewer"}},
+			"operator-token": {UserID: "bob", Roles: []string{"operator"}},
+		},
+		permissions: map[string][]string{
+			"viewer":   {"silences:list"},
+			"operator": {"silences:list", "silences:create"},
+		},
+	}
+	apiKeys := stubAPIKeys{"dvz_ci": {UserID: "sa-ci", Roles: []string{"operator"}}}
+	middleware, err := NewAuthMiddlewarePlugin(config, provider, apiKeys, nil, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAuthMiddlewarePlugin() error = %v", err)
+	}
+
+	e := echo.New()
+	e.Use(middleware.EchoMiddleware())
+	handler := func(c echo.Context) error {
+		principal, ok := PrincipalFromContext(c.Request().Context())
+		if !ok {
+			return c.String(http.StatusOK, "anonymous")
+		}
+		return c.String(http.StatusOK, principal.UserID+"/"+principal.Credential)
+	}
+	e.GET("/health", handler)
+	e.GET("/api/v1/silences", handler)
+	e.POST("/api/v1/silences", handler)
+	e.GET("/api/v1/unannotated", handler)
+	e.GET("/ui/hello", handler)
+	return e
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	e := newTestServer(t, AuthMiddlewareConfig{PublicRoutes: []string{"GET /ui/hello"}})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		body    string
+		code    string
+	}{
+		{"public route", http.MethodGet, "/health", nil, http.StatusOK, "anonymous", ""},
+		{"configured public route", http.MethodGet, "/ui/hello", nil, http.StatusOK, "anonymous", ""},
+		{"missing credentials", http.MethodGet, "/api/v1/silences", nil, http.StatusUnauthorized, "", CodeUnauthenticated},
+		{"invalid token", http.MethodGet, "/api/v1/silences", map[string]string{"Authorization": "Bearer nope"},
+			http.StatusUnauthorized, "", CodeInvalidCredentials},
+		{"basic scheme rejected", http.MethodGet, "/api/v1/silences", map[string]string{"Authorization": "Basic YTpi"},
+			http.StatusUnauthorized, "", CodeInvalidCredentials},
+		{"permitted", http.MethodGet, "/api/v1/silences", map[string]string{"Authorization": "Bearer viewer-token"},
+			http.StatusOK, "alice/bearer", ""},
+		{"forbidden", http.MethodPost, "/api/v1/silences", map[string]string{"Authorization": "Bearer viewer-token"},
+			http.StatusForbidden, "", CodeForbidden},
+		{"operator allowed", http.MethodPost, "/api/v1/silences", map[string]string{"Authorization": "Bearer operator-token"},
+			http.StatusOK, "bob/bearer", ""},
+		{"api key", http.MethodPost, "/api/v1/silences", map[string]string{"X-API-Key": "dvz_ci"}, http.StatusOK, "sa-ci/api_key", ""},
+		{"invalid api key", http.MethodGet, "/api/v1/silences", map[string]string{"X-API-Key": "dvz_other"},
+			http.StatusUnauthorized, "", CodeInvalidCredentials},
+		{"unannotated route only requires authentication", http.MethodGet, "/api/v1/unannotated",
+			map[string]string{"Authorization": "Bearer viewer-token"}, http.StatusOK, "alice/bearer", ""},
+		{"dot segments are not public", http.MethodGet, "/health/../api/v1/silences", nil, http.StatusUnauthorized, "", CodeUnauthenticated},
+		{"session cookie ignored without resolver", http.MethodGet, "/api/v1/silences", map[string]string{"Cookie": "detectviz_session=alice"},
+			http.StatusUnauthorized, "", CodeUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.code == "" {
+				if rec.Body.String() != tt.body {
+					t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+				}
+				return
+			}
+			var response ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("error response is not JSON: %v", err)
+			}
+			if response.Code != tt.code || response.Error == "" {
+				t.Errorf("response = %+v, want code %s", response, tt.code)
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+			if tt.status == http.StatusForbidden && (response.Resource != "silences" || response.Action != "create") {
+				t.Errorf("forbidden response = %+v", response)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_ConfiguredPermissionOverridesDefault(t *testing.T) {
+	e := newTestServer(t, AuthMiddlewareConfig{
+		Permissions: []RoutePermission{{Route: "GET /api/v1/silences", Permission: "silences:create"}},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/silences", nil)
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+}
+
+func TestParseRoutePattern(t *testing.T) {
+	for _, route := range []string{"FETCH /x", "api/v1", "GET /a/*/b", "GET /a/b*"} {
+		if _, err := parseRoutePattern(route); err == nil {
+			t.Errorf("parseRoutePattern(%q) expected error", route)
+		}
+	}
+
+	pattern, err := parseRoutePattern("GET /api/v1/incidents/:id/acknowledge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pattern.matches(http.MethodGet, "/api/v1/incidents/42/acknowledge") ||
+		pattern.matches(http.MethodPost, "/api/v1/incidents/42/acknowledge") ||
+		pattern.matches(http.MethodGet, "/api/v1/incidents/acknowledge") ||
+		pattern.matches(http.MethodGet, "/api/v1/incidents/a%2Fb/c/acknowledge") {
+		t.Error("unexpected match result for parameterised route")
+	}
+}
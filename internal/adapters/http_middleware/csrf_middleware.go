@@ -0,0 +1,267 @@
+package http_middleware
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// CodeCSRFFailed 是 CSRF 驗證失敗時的錯誤碼
+const CodeCSRFFailed = "csrf_failed"
+
+// formOpenTag 匹配以 POST 提交的表單開始標籤
+var formOpenTag = regexp.MustCompile(`(?i)<form\b[^>]*\bmethod\s*=\s*["']?post\b[^>]*>`)
+
+// signedIDIssuer 驗證與簽發簽名 ID，由 storage.SessionManager 實現
+type signedIDIssuer interface {
+	Verify(signedID string) (string, bool)
+	NewSignedID() (id string, signed string, err error)
+}
+
+// CSRFConfig 定義 CSRF 保護的配置
+type CSRFConfig struct {
+	// FieldName 表單中 CSRF 令牌欄位的名稱，默認 "csrf_token"
+	FieldName string `yaml:"fieldName" json:"fieldName"`
+	// HeaderName 以請求頭提交 CSRF 令牌時的名稱，默認 "X-CSRF-Token"
+	HeaderName string `yaml:"headerName" json:"headerName"`
+	// CookieName 未登錄訪客的 CSRF 綁定 cookie 名稱，默認 "detectviz_csrf"
+	CookieName string `yaml:"cookieName" json:"cookieName"`
+	// ExemptRoutes 不檢查 CSRF 令牌的路由，格式同 publicRoutes
+	ExemptRoutes []string `yaml:"exemptRoutes" json:"exemptRoutes"`
+	// SessionCookie 登錄會話 cookie 名稱，與認證中介層一致，默認 "detectviz_session"
+	SessionCookie string `yaml:"sessionCookie" json:"sessionCookie"`
+	// APIKeyHeader API 金鑰請求頭，與認證中介層一致，默認 "X-API-Key"
+	APIKeyHeader string `yaml:"apiKeyHeader" json:"apiKeyHeader"`
+	// CookieSecure 綁定 cookie 是否只在 HTTPS 上發送
+	CookieSecure bool `yaml:"cookieSecure" json:"cookieSecure"`
+}
+
+// CSRFMiddlewarePlugin 實現了 MiddlewarePlugin 介面，以同步器令牌模式保護表單提交
+// 職責: 在 HTML 響應中的 POST 表單自動注入綁定到會話的 CSRF 令牌，並在改變狀態的表單請求上以常數時間驗證令牌。
+// 已登錄時令牌綁定到登錄會話，未登錄時 (例如登錄頁面) 綁定到簽名的匿名 cookie。
+// 攜帶 Authorization 或 API 金鑰請求頭的請求與 JSON 請求不能由跨站表單偽造，不需要令牌。
+type CSRFMiddlewarePlugin struct {
+	config CSRFConfig
+	ids    signedIDIssuer
+	store  contracts.AuthStorageProvider
+	logger contracts.Logger
+	exempt []routePattern
+}
+
+// NewCSRFMiddlewarePlugin 創建 CSRF 中介層，ids 簽發綁定 ID，令牌保存在 store 中
+func NewCSRFMiddlewarePlugin(config CSRFConfig, ids signedIDIssuer, store contracts.AuthStorageProvider,
+	logger contracts.Logger) (*CSRFMiddlewarePlugin, error) {
+	if ids == nil || store == nil {
+		return nil, fmt.Errorf("csrf middleware requires a session manager and an auth storage")
+	}
+	if config.FieldName == "" {
+		config.FieldName = "csrf_token"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "detectviz_csrf"
+	}
+	if config.SessionCookie == "" {
+		config.SessionCookie = "detectviz_session"
+	}
+	if config.APIKeyHeader == "" {
+		config.APIKeyHeader = "X-API-Key"
+	}
+	p := &CSRFMiddlewarePlugin{config: config, ids: ids, store: store, logger: logger}
+	for _, route := range config.ExemptRoutes {
+		pattern, err := parseRoutePattern(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid csrf exempt route: %w", err)
+		}
+		p.exempt = append(p.exempt, pattern)
+	}
+	logger.Info("初始化 CSRF 中介層", "field", config.FieldName, "header", config.HeaderName, "exempt_routes", len(p.exempt))
+	return p, nil
+}
+
+// GetName 返回中介層名稱
+func (p *CSRFMiddlewarePlugin) GetName() string {
+	return "csrf_middleware"
+}
+
+// EchoMiddleware 把中介層轉換為 Echo 中介層，以 e.Use 註冊到所有路由
+func (p *CSRFMiddlewarePlugin) EchoMiddleware() echo.MiddlewareFunc {
+	return echo.WrapMiddleware(p.Handle)
+}
+
+// Handle 實現 MiddlewarePlugin：改變狀態的表單請求驗證令牌，HTML 響應中的 POST 表單注入令牌
+func (p *CSRFMiddlewarePlugin) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path := r.Method, r.URL.EscapedPath()
+		for _, pattern := range p.exempt {
+			if pattern.matches(method, path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		bindingID := p.bindingID(r)
+		ctx := r.Context()
+		if bindingID != "" {
+			ctx = storage.WithCSRFBinding(ctx, bindingID)
+		}
+
+		if !isSafeMethod(method) && p.requiresToken(r) {
+			token := r.Header.Get(p.config.HeaderName)
+			if token == "" {
+				token = r.PostFormValue(p.config.FieldName)
+			}
+			if err := storage.ValidateCSRFToken(ctx, p.store, token); err != nil {
+				p.logger.Warn("拒絕 CSRF 驗證失敗的請求", "method", method, "path", path, "error", err)
+				writeError(w, http.StatusForbidden, ErrorResponse{Error: "CSRF token is missing or invalid", Code: CodeCSRFFailed})
+				return
+			}
+		}
+
+		// 表單提交失敗後重新渲染的頁面同樣需要令牌，因此所有 HTML 響應都注入
+		writer := &csrfInjectingWriter{ResponseWriter: w, middleware: p, request: r.WithContext(ctx), bindingID: bindingID}
+		next.ServeHTTP(writer, writer.request)
+		writer.finish()
+	})
+}
+
+// isSafeMethod 判斷請求方法是否不改變狀態
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// bindingID 返回令牌綁定的 ID：有效簽名的登錄會話優先，否則為匿名綁定 cookie
+func (p *CSRFMiddlewarePlugin) bindingID(r *http.Request) string {
+	for _, name := range []string{p.config.SessionCookie, p.config.CookieName} {
+		if cookie, err := r.Cookie(name); err == nil {
+			if id, ok := p.ids.Verify(cookie.Value); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// requiresToken 判斷改變狀態的請求是否需要 CSRF 令牌。
+// 跨站表單無法設置自定義請求頭，也不能在沒有 CORS 預檢的情況下發送 JSON，因此這兩類請求不需要令牌。
+func (p *CSRFMiddlewarePlugin) requiresToken(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" || r.Header.Get(p.config.APIKeyHeader) != "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")
+}
+
+// issueToken 生成綁定到請求的令牌；請求沒有綁定 ID 時創建匿名綁定 cookie
+func (p *CSRFMiddlewarePlugin) issueToken(w http.ResponseWriter, r *http.Request, bindingID string) (string, error) {
+	ctx := r.Context()
+	if bindingID == "" {
+		id, signed, err := p.ids.NewSignedID()
+		if err != nil {
+			return "", err
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     p.config.CookieName,
+			Value:    signed,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   p.config.CookieSecure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		ctx = storage.WithCSRFBinding(ctx, id)
+	}
+	return storage.GenerateCSRFToken(ctx, p.store)
+}
+
+// csrfInjectingWriter 緩衝 HTML 響應，在 POST 表單中注入 CSRF 令牌欄位；其他響應直接寫出
+type csrfInjectingWriter struct {
+	http.ResponseWriter
+	middleware *CSRFMiddlewarePlugin
+	request    *http.Request
+	bindingID  string
+
+	status    int
+	decided   bool
+	buffering bool
+	done      bool // finish 之後的寫入 (例如 Echo 的錯誤處理) 直接寫出
+	buf       bytes.Buffer
+}
+
+// decide 在第一次寫入時按 Content-Type 決定是否緩衝
+func (w *csrfInjectingWriter) decide(status int) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.status = status
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = mediaType == "text/html" && w.request.Method != http.MethodHead
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// WriteHeader 記錄狀態碼，緩衝時延遲到 finish 寫出
+func (w *csrfInjectingWriter) WriteHeader(status int) {
+	if w.done {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.decide(status)
+}
+
+// Write 緩衝 HTML 響應，其他響應直接寫出
+func (w *csrfInjectingWriter) Write(b []byte) (int, error) {
+	if w.done {
+		return w.ResponseWriter.Write(b)
+	}
+	w.decide(http.StatusOK)
+	if w.buffering {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 讓 http.ResponseController 可以取得底層的 ResponseWriter
+func (w *csrfInjectingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish 在 HTML 響應的 POST 表單中注入令牌後寫出
+func (w *csrfInjectingWriter) finish() {
+	w.done = true
+	if !w.buffering {
+		return
+	}
+	body := w.buf.Bytes()
+	if formOpenTag.Match(body) {
+		token, err := w.middleware.issueToken(w.ResponseWriter, w.request, w.bindingID)
+		if err != nil {
+			w.middleware.logger.Error("生成 CSRF 令牌失敗", "path", w.request.URL.Path, "error", err)
+		} else {
+			field := fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, w.middleware.config.FieldName, token)
+			body = formOpenTag.ReplaceAllFunc(body, func(tag []byte) []byte {
+				return append(append([]byte{}, tag...), field...)
+			})
+			w.Header().Del("Content-Length")
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		w.middleware.logger.Debug("寫出 HTML 響應失敗", "path", w.request.URL.Path, "error", err)
+	}
+}
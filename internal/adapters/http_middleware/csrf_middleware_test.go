@@ -0,0 +1,144 @@
+package http_middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+)
+
+var csrfFieldPattern = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+func newCSRFTestServer(t *testing.T) (*echo.Echo, *storage.SessionManager) {
+	t.Helper()
+	store, err := storage.NewMemoryAuthStorageProvider(storage.Config{}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := storage.NewSessionManager(store, []byte(strings.Repeat("k", 32)), &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleware, err := NewCSRFMiddlewarePlugin(CSRFConfig{}, sessions, store, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewCSRFMiddlewarePlugin() error = %v", err)
+	}
+
+	e := echo.New()
+	e.Use(middleware.EchoMiddleware())
+	form := `<html><body><form method="POST" action="/submit"><input name="x"></form><form method="get"></form></body></html>`
+	e.GET("/form", func(c echo.Context) error { return c.HTML(http.StatusOK, form) })
+	e.GET("/json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"form": `<form method="post">`})
+	})
+	e.POST("/submit", func(c echo.Context) error { return c.String(http.StatusOK, "saved "+c.FormValue("x")) })
+	return e, sessions
+}
+
+// fetchForm 取得表單頁面，返回注入的令牌與響應設置的 cookie
+func fetchForm(t *testing.T, e *echo.Echo, cookies ...*http.Cookie) (string, []*http.Cookie) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	matches := csrfFieldPattern.FindAllStringSubmatch(body, -1)
+	if len(matches) != 1 {
+		t.Fatalf("expected one injected token in %s", body)
+	}
+	return matches[0][1], rec.Result().Cookies()
+}
+
+func postForm(e *echo.Echo, token string, headers map[string]string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	values := url.Values{"x": {"1"}}
+	if token != "" {
+		values.Set("csrf_token", token)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCSRFMiddleware_AnonymousForm(t *testing.T) {
+	e, _ := newCSRFTestServer(t)
+	token, cookies := fetchForm(t, e)
+	if len(cookies) != 1 || cookies[0].Name != "detectviz_csrf" || !cookies[0].HttpOnly {
+		t.Fatalf("expected binding cookie, got %v", cookies)
+	}
+
+	if rec := postForm(e, token, nil, cookies...); rec.Code != http.StatusOK || rec.Body.String() != "saved 1" {
+		t.Errorf("valid post: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := postForm(e, "", map[string]string{"X-CSRF-Token": token}, cookies...); rec.Code != http.StatusOK {
+		t.Errorf("token in header: %d", rec.Code)
+	}
+
+	otherToken, otherCookies := fetchForm(t, e)
+	tests := map[string]*httptest.ResponseRecorder{
+		"missing token":         postForm(e, "", nil, cookies...),
+		"missing cookie":        postForm(e, token, nil),
+		"token of other client": postForm(e, otherToken, nil, cookies...),
+		"forged cookie":         postForm(e, token, nil, &http.Cookie{Name: "detectviz_csrf", Value: "abc.def"}),
+		"tampered token":        postForm(e, token+"x", nil, cookies...),
+	}
+	for name, rec := range tests {
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), CodeCSRFFailed) {
+			t.Errorf("%s: status = %d, body = %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := postForm(e, otherToken, nil, otherCookies...); rec.Code != http.StatusOK {
+		t.Errorf("second client: %d", rec.Code)
+	}
+}
+
+func TestCSRFMiddleware_SessionBindingAndExemptions(t *testing.T) {
+	e, sessions := newCSRFTestServer(t)
+	signed, err := sessions.Create(context.Background(), storage.Session{UserID: "alice"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &http.Cookie{Name: "detectviz_session", Value: signed}
+
+	token, cookies := fetchForm(t, e, session)
+	if len(cookies) != 0 {
+		t.Errorf("logged in client got a binding cookie: %v", cookies)
+	}
+	if rec := postForm(e, token, nil, session); rec.Code != http.StatusOK {
+		t.Errorf("valid session post: %d", rec.Code)
+	}
+	// 登出後會話的令牌隨會話刪除
+	if err := sessions.Destroy(context.Background(), signed); err != nil {
+		t.Fatal(err)
+	}
+	if rec := postForm(e, token, nil, session); rec.Code != http.StatusForbidden {
+		t.Errorf("token of destroyed session: %d", rec.Code)
+	}
+
+	if rec := postForm(e, "", map[string]string{"Authorization": "Bearer x"}); rec.Code != http.StatusOK {
+		t.Errorf("bearer request: %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "csrf_token") || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("json response was modified: %s", rec.Body.String())
+	}
+}
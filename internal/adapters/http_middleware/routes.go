@@ -0,0 +1,148 @@
+package http_middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultPublicRoutes 是不需要認證的內建路由
+var DefaultPublicRoutes = []string{
+	"GET /health",
+	"GET /health/*",
+	"GET /api/v1/info",
+	// 通知中的確認鏈接以 HMAC 簽名驗證收件人與過期時間，不需要登入；GET 只顯示確認頁面，POST 才確認
+	"GET /api/v1/incidents/:id/acknowledge",
+	"POST /api/v1/incidents/:id/acknowledge/confirm",
+	// 登錄、註冊與登出頁面
+	"/auth/*",
+}
+
+// DefaultRoutePermissions 是內建 API 路由的權限註解，資源與操作對應 configs/rbac_policy.yaml 中的權限
+var DefaultRoutePermissions = []RoutePermission{
+	{Route: "GET /api/v1/silences", Permission: "silences:list"},
+	{Route: "POST /api/v1/silences", Permission: "silences:create"},
+	{Route: "GET /api/v1/silences/:id", Permission: "silences:read"},
+	{Route: "DELETE /api/v1/silences/:id", Permission: "silences:delete"},
+
+	{Route: "POST /api/v1/alerts/routes/test", Permission: "alerts:test"},
+	{Route: "POST /api/v1/alerts/:fingerprint/acknowledge", Permission: "alerts:acknowledge"},
+
+	{Route: "GET /api/v1/incidents", Permission: "incidents:list"},
+	{Route: "POST /api/v1/incidents", Permission: "incidents:create"},
+	{Route: "GET /api/v1/incidents/:id", Permission: "incidents:read"},
+	{Route: "POST /api/v1/incidents/:id/acknowledge", Permission: "incidents:acknowledge"},
+	{Route: "POST /api/v1/incidents/:id/resolve", Permission: "incidents:resolve"},
+	{Route: "PUT /api/v1/incidents/:id/assignee", Permission: "incidents:update"},
+	{Route: "POST /api/v1/incidents/:id/notes", Permission: "incidents:update"},
+	{Route: "POST /api/v1/incidents/:id/alerts", Permission: "incidents:update"},
+
+	{Route: "GET /api/v1/notifications/deliveries", Permission: "notifications:list"},
+	{Route: "GET /api/v1/notifications/deliveries/:id", Permission: "notifications:read"},
+	{Route: "POST /api/v1/notifications/deliveries/:id/resend", Permission: "notifications:resend"},
+
+	{Route: "GET /api/v1/oncall/schedules", Permission: "oncall:list"},
+	{Route: "POST /api/v1/oncall/schedules", Permission: "oncall:create"},
+	{Route: "GET /api/v1/oncall/schedules/:id", Permission: "oncall:read"},
+	{Route: "PUT /api/v1/oncall/schedules/:id", Permission: "oncall:update"},
+	{Route: "DELETE /api/v1/oncall/schedules/:id", Permission: "oncall:delete"},
+	{Route: "GET /api/v1/oncall/schedules/:id/oncall", Permission: "oncall:read"},
+	{Route: "POST /api/v1/oncall/schedules/:id/overrides", Permission: "oncall:update"},
+	{Route: "DELETE /api/v1/oncall/schedules/:id/overrides/:overrideId", Permission: "oncall:update"},
+	{Route: "GET /api/v1/oncall/teams/:team", Permission: "oncall:read"},
+
+	{Route: "GET /api/v1/analysis-results/:id", Permission: "analysis_results:read"},
+	{Route: "GET /api/v1/analysis-results/:id/feedback", Permission: "feedback:read"},
+	{Route: "PUT /api/v1/analysis-results/:id/feedback", Permission: "feedback:create"},
+	{Route: "GET /api/v1/detectors/:id/quality", Permission: "detectors:read"},
+
+	{Route: "POST /api/v1/authz/explain", Permission: "authz:explain"},
+
+	{Route: "GET /api/v1/service-accounts", Permission: "service_accounts:list"},
+	{Route: "POST /api/v1/service-accounts", Permission: "service_accounts:create"},
+	{Route: "GET /api/v1/service-accounts/:id", Permission: "service_accounts:read"},
+	{Route: "PUT /api/v1/service-accounts/:id", Permission: "service_accounts:update"},
+	{Route: "GET /api/v1/service-accounts/:id/keys", Permission: "api_keys:list"},
+	{Route: "POST /api/v1/service-accounts/:id/keys", Permission: "api_keys:create"},
+	{Route: "DELETE /api/v1/service-accounts/:id/keys/:keyId", Permission: "api_keys:revoke"},
+
+	{Route: "GET /api/v1/auth/lockouts/accounts/:account", Permission: "login_lockouts:inspect"},
+	{Route: "DELETE /api/v1/auth/lockouts/accounts/:account", Permission: "login_lockouts:unlock"},
+	{Route: "GET /api/v1/auth/lockouts/ips/:ip", Permission: "login_lockouts:inspect"},
+	{Route: "DELETE /api/v1/auth/lockouts/ips/:ip", Permission: "login_lockouts:unlock"},
+	{Route: "DELETE /api/v1/auth/mfa/users/:id", Permission: "mfa:reset"},
+}
+
+// routePattern 是解析後的 "METHOD /path" 路由
+type routePattern struct {
+	method   string // 空字串表示任意方法
+	segments []string
+	rest     bool // 最後一段為 *，匹配其餘的路徑 (包括空)
+}
+
+// parseRoutePattern 解析 "METHOD /path" 或 "/path" 格式的路由
+func parseRoutePattern(route string) (routePattern, error) {
+	var pattern routePattern
+	path := strings.TrimSpace(route)
+	if method, rest, ok := strings.Cut(path, " "); ok {
+		pattern.method = strings.ToUpper(method)
+		path = strings.TrimSpace(rest)
+		switch pattern.method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return routePattern{}, fmt.Errorf("route %q has unsupported method %q", route, method)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		return routePattern{}, fmt.Errorf("route %q must start with /", route)
+	}
+	pattern.segments = splitPath(path)
+	for i, segment := range pattern.segments {
+		if strings.Contains(segment, "*") {
+			if segment != "*" || i != len(pattern.segments)-1 {
+				return routePattern{}, fmt.Errorf("route %q: * is only allowed as the last segment", route)
+			}
+			pattern.segments = pattern.segments[:i]
+			pattern.rest = true
+		}
+	}
+	return pattern, nil
+}
+
+// matches 檢查請求的方法與路徑是否匹配路由
+func (p routePattern) matches(method, path string) bool {
+	if p.method != "" && p.method != method {
+		return false
+	}
+	segments := splitPath(path)
+	if len(segments) < len(p.segments) || (!p.rest && len(segments) != len(p.segments)) {
+		return false
+	}
+	for _, segment := range segments {
+		// 含 . 或 .. 的路徑不匹配任何路由，避免以 /auth/../api 之類的路徑冒充公開路由
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	for i, segment := range p.segments {
+		if strings.HasPrefix(segment, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPath 把路徑拆分為路徑段，忽略結尾的 /
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
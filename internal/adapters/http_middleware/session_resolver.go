@@ -0,0 +1,41 @@
+package http_middleware
+
+import (
+	"context"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+)
+
+// sessionLookup 解析簽名的會話 ID，由 storage.SessionManager 實現
+type sessionLookup interface {
+	Lookup(ctx context.Context, signedID string) (*storage.Session, error)
+}
+
+// StoredSessionResolver 把登錄頁面設置的會話 cookie 解析為主體
+type StoredSessionResolver struct {
+	sessions sessionLookup
+}
+
+// NewStoredSessionResolver 創建會話解析器
+func NewStoredSessionResolver(sessions sessionLookup) *StoredSessionResolver {
+	return &StoredSessionResolver{sessions: sessions}
+}
+
+// ResolveSession 驗證會話並返回登錄用戶的主體，每次解析都會刷新會話的閒置過期時間
+func (r *StoredSessionResolver) ResolveSession(ctx context.Context, sessionID string) (*Principal, error) {
+	session, err := r.sessions.Lookup(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		UserID:     session.UserID,
+		Username:   session.Username,
+		Email:      session.Email,
+		Roles:      session.Roles,
+		Groups:     session.Groups,
+		Credential: CredentialSession,
+	}, nil
+}
+
+// 確保實現了 SessionResolver 介面
+var _ SessionResolver = (*StoredSessionResolver)(nil)
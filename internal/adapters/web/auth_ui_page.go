@@ -2,11 +2,18 @@ package web
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	domainerrors "detectviz-platform/pkg/domain/errors"
 	"detectviz-platform/pkg/domain/interfaces/plugins"
 	"detectviz-platform/pkg/platform/contracts"
 )
@@ -16,6 +23,10 @@ import (
 type AuthUIPagePlugin struct {
 	logger       contracts.Logger
 	authProvider contracts.AuthProvider
+	sessions     *storage.SessionManager
+	oidc         *OIDCLogin
+	accounts     accountManager      // 認證提供者支持本地賬戶時不為 nil
+	throttle     *auth.LoginThrottle // 為 nil 時不限制登錄嘗試
 	config       AuthUIConfig
 }
 
@@ -24,29 +35,58 @@ type AuthUIConfig struct {
 	LoginRoute    string `yaml:"login_route" json:"login_route"`
 	RegisterRoute string `yaml:"register_route" json:"register_route"`
 	LogoutRoute   string `yaml:"logout_route" json:"logout_route"`
+	OIDCRoute     string `yaml:"oidc_route" json:"oidc_route"` // OIDC 登錄路由的前綴，回調地址為 {oidc_route}/callback
 	Title         string `yaml:"title" json:"title"`
 	BrandName     string `yaml:"brand_name" json:"brand_name"`
+	SessionCookie string `yaml:"session_cookie" json:"session_cookie"` // 會話 cookie 名稱，須與認證中介層的 sessionCookie 一致
+	CookieSecure  bool   `yaml:"cookie_secure" json:"cookie_secure"`   // 只在 HTTPS 連線上發送會話 cookie
+
+	// VerifyEmailRoute 與 ResetPasswordRoute 須與本地認證提供者郵件中的鏈接一致
+	VerifyEmailRoute    string `yaml:"verify_email_route" json:"verify_email_route"`
+	ForgotPasswordRoute string `yaml:"forgot_password_route" json:"forgot_password_route"`
+	ResetPasswordRoute  string `yaml:"reset_password_route" json:"reset_password_route"`
+	MFARoute            string `yaml:"mfa_route" json:"mfa_route"` // 密碼驗證通過後提交 TOTP 驗證碼或恢復碼
 }
 
-// NewAuthUIPagePlugin 創建新的認證 UI 頁面插件實例
-func NewAuthUIPagePlugin(authProvider contracts.AuthProvider, logger contracts.Logger) plugins.UIPagePlugin {
+// NewAuthUIPagePlugin 創建新的認證 UI 頁面插件實例，登錄成功後在 sessions 中創建會話。
+// oidc 不為 nil 時登錄頁面重定向到身份提供者 (授權碼流程與 PKCE)，不再接受用戶名密碼登錄；
+// 否則認證提供者支持本地賬戶 (auth.LocalAuthProvider) 時提供註冊、郵件驗證與密碼重置頁面，
+// 並在需要多因素認證的用戶輸入密碼後要求輸入 TOTP 驗證碼。
+// throttle 不為 nil 時按賬戶與來源 IP 限制登錄表單的失敗次數。
+func NewAuthUIPagePlugin(authProvider contracts.AuthProvider, sessions *storage.SessionManager, oidc *OIDCLogin,
+	throttle *auth.LoginThrottle, logger contracts.Logger) plugins.UIPagePlugin {
 	config := AuthUIConfig{
 		LoginRoute:    "/auth/login",
 		RegisterRoute: "/auth/register",
 		LogoutRoute:   "/auth/logout",
+		OIDCRoute:     "/auth/oidc",
 		Title:         "Detectviz 平台 - 用戶認證",
 		BrandName:     "Detectviz",
+		SessionCookie: "detectviz_session",
+
+		VerifyEmailRoute:    "/auth/verify-email",
+		ForgotPasswordRoute: "/auth/forgot-password",
+		ResetPasswordRoute:  "/auth/reset-password",
+		MFARoute:            "/auth/mfa",
 	}
 
 	logger.Info("初始化認證 UI 頁面插件",
 		"login_route", config.LoginRoute,
-		"register_route", config.RegisterRoute)
+		"register_route", config.RegisterRoute,
+		"oidc", oidc != nil)
 
-	return &AuthUIPagePlugin{
+	plugin := &AuthUIPagePlugin{
 		logger:       logger,
 		authProvider: authProvider,
+		sessions:     sessions,
+		oidc:         oidc,
+		throttle:     throttle,
 		config:       config,
 	}
+	if accounts, ok := authProvider.(accountManager); ok && oidc == nil {
+		plugin.accounts = accounts
+	}
+	return plugin
 }
 
 func (a *AuthUIPagePlugin) GetName() string {
@@ -70,6 +110,12 @@ func (a *AuthUIPagePlugin) Init(ctx context.Context, cfg map[string]interface{})
 	if brandName, ok := cfg["brand_name"].(string); ok {
 		a.config.BrandName = brandName
 	}
+	if sessionCookie, ok := cfg["session_cookie"].(string); ok && sessionCookie != "" {
+		a.config.SessionCookie = sessionCookie
+	}
+	if cookieSecure, ok := cfg["cookie_secure"].(bool); ok {
+		a.config.CookieSecure = cookieSecure
+	}
 
 	return nil
 }
@@ -114,6 +160,22 @@ func (a *AuthUIPagePlugin) RegisterRoute(router interface{}, logger interface{})
 	// 註冊登出處理
 	echoRouter.POST(a.config.LogoutRoute, a.handleLogout)
 
+	// 註冊本地賬戶的郵件驗證與密碼重置頁面
+	if a.accounts != nil {
+		echoRouter.GET(a.config.VerifyEmailRoute, a.handleVerifyEmail)
+		echoRouter.GET(a.config.ForgotPasswordRoute, a.handleForgotPasswordPage)
+		echoRouter.POST(a.config.ForgotPasswordRoute, a.handleForgotPasswordSubmit)
+		echoRouter.GET(a.config.ResetPasswordRoute, a.handleResetPasswordPage)
+		echoRouter.POST(a.config.ResetPasswordRoute, a.handleResetPasswordSubmit)
+		echoRouter.POST(a.config.MFARoute, a.handleMFASubmit)
+	}
+
+	// 註冊 OIDC 登錄與回調
+	if a.oidc != nil {
+		echoRouter.GET(a.config.OIDCRoute+"/login", a.handleOIDCLogin)
+		echoRouter.GET(a.config.OIDCRoute+"/callback", a.handleOIDCCallback)
+	}
+
 	a.logger.Info("認證路由註冊完成",
 		"login_route", a.config.LoginRoute,
 		"register_route", a.config.RegisterRoute,
@@ -122,14 +184,22 @@ func (a *AuthUIPagePlugin) RegisterRoute(router interface{}, logger interface{})
 	return nil
 }
 
-// handleLoginPage 處理登錄頁面請求
+// handleLoginPage 處理登錄頁面請求，啟用 OIDC 時重定向到身份提供者登錄
 func (a *AuthUIPagePlugin) handleLoginPage(c echo.Context) error {
+	if a.oidc != nil {
+		return c.Redirect(http.StatusFound, a.oidcLoginURL(c.QueryParam("return_to")))
+	}
 	html := a.generateLoginPageHTML()
 	return c.HTML(http.StatusOK, html)
 }
 
 // handleLoginSubmit 處理登錄表單提交
 func (a *AuthUIPagePlugin) handleLoginSubmit(c echo.Context) error {
+	// 啟用 OIDC 時密碼由身份提供者驗證，不接受本地的用戶名密碼登錄
+	if a.oidc != nil {
+		return c.Redirect(http.StatusSeeOther, a.oidcLoginURL(""))
+	}
+
 	username := c.FormValue("username")
 	password := c.FormValue("password")
 
@@ -137,38 +207,122 @@ func (a *AuthUIPagePlugin) handleLoginSubmit(c echo.Context) error {
 		return c.HTML(http.StatusBadRequest, a.generateLoginPageHTML("用戶名和密碼不能為空"))
 	}
 
+	// 賬戶或來源 IP 連續失敗時，在驗證密碼前拒絕
+	ip := c.RealIP()
+	if a.throttle != nil {
+		var throttled *auth.ThrottledError
+		if err := a.throttle.Check(c.Request().Context(), username, ip); errors.As(err, &throttled) {
+			a.logger.Warn("登錄嘗試過於頻繁", "username", username, "ip", ip, "scope", throttled.Scope)
+			c.Response().Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			return c.HTML(http.StatusTooManyRequests, a.generateLoginPageHTML(throttledMessage(throttled)))
+		}
+	}
+
 	// 使用認證提供者驗證用戶
 	credentials := fmt.Sprintf("%s:%s", username, password)
 	userID, err := a.authProvider.Authenticate(c.Request().Context(), credentials)
 	if err != nil {
 		a.logger.Warn("用戶登錄失敗", "username", username, "error", err)
+		if a.throttle != nil {
+			if err := a.throttle.RecordFailure(c.Request().Context(), username, ip); err != nil {
+				a.logger.Error("記錄登錄失敗次數失敗", "username", username, "error", err)
+			}
+		}
 		return c.HTML(http.StatusUnauthorized, a.generateLoginPageHTML("用戶名或密碼錯誤"))
 	}
 
-	a.logger.Info("用戶登錄成功", "username", username, "user_id", userID)
+	// 需要多因素認證的用戶在輸入驗證碼後才完成登錄，失敗計數在此之前不重置
+	if a.accounts != nil {
+		challenge, err := a.accounts.StartMFAChallenge(c.Request().Context(), userID)
+		// 密碼正確但登錄尚未完成，撤回本次嘗試的計數，驗證碼步驟重新計數
+		if (err != nil || challenge != nil) && a.throttle != nil {
+			a.throttle.Release(c.Request().Context(), username, ip)
+		}
+		if err != nil {
+			a.logger.Error("創建多因素認證挑戰失敗", "user_id", userID, "error", err)
+			return c.HTML(http.StatusInternalServerError, a.generateLoginPageHTML("登錄服務暫時不可用"))
+		}
+		if challenge != nil {
+			a.logger.Info("用戶密碼驗證通過，等待多因素認證", "user_id", userID, "enrollment", challenge.Enrollment != nil)
+			return c.HTML(http.StatusOK, a.mfaPage(challenge, ""))
+		}
+	}
 
-	// 設置會話或 JWT token（簡化實現）
-	// 在實際實現中，這裡應該設置適當的會話管理
-	c.SetCookie(&http.Cookie{
-		Name:     "user_id",
-		Value:    userID,
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   3600, // 1小時
-	})
+	if err := a.completeLogin(c, userID, username); err != nil {
+		return c.HTML(loginErrorStatus(err), a.generateLoginPageHTML("登錄服務暫時不可用"))
+	}
 
 	// 重定向到主頁
 	return c.Redirect(http.StatusFound, "/ui/hello")
 }
 
-// handleRegisterPage 處理註冊頁面請求
+// errSessionsUnavailable 表示未配置會話存儲，無法完成登錄
+var errSessionsUnavailable = errors.New("session storage is not configured")
+
+// loginErrorStatus 返回 completeLogin 失敗時的 HTTP 狀態碼
+func loginErrorStatus(err error) int {
+	if errors.Is(err, errSessionsUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// completeLogin 重置賬戶的失敗計數、創建會話並設置會話 cookie，錯誤已記錄日誌
+func (a *AuthUIPagePlugin) completeLogin(c echo.Context, userID, username string) error {
+	if a.throttle != nil {
+		if err := a.throttle.RecordSuccess(c.Request().Context(), username, c.RealIP()); err != nil {
+			a.logger.Error("重置登錄失敗次數失敗", "username", username, "error", err)
+		}
+	}
+
+	if a.sessions == nil {
+		a.logger.Error("未配置會話存儲，無法完成登錄", "username", username)
+		return errSessionsUnavailable
+	}
+
+	// 每次登錄都創建新的會話 ID 並刪除請求中原有的會話，避免會話固定攻擊
+	previous := ""
+	if cookie, err := c.Cookie(a.config.SessionCookie); err == nil {
+		previous = cookie.Value
+	}
+	session := storage.Session{UserID: userID, Username: username}
+	if a.accounts != nil {
+		// 本地賬戶的會話攜帶用戶的角色，供角色策略授權
+		identity, err := a.accounts.UserIdentity(c.Request().Context(), userID)
+		if err != nil {
+			a.logger.Error("讀取用戶信息失敗", "user_id", userID, "error", err)
+			return err
+		}
+		session.Username = identity.Username
+		session.Email = identity.Email
+		session.Roles = identity.Roles
+	}
+	sessionID, err := a.sessions.Create(c.Request().Context(), session, previous)
+	if err != nil {
+		a.logger.Error("創建會話失敗", "username", username, "error", err)
+		return err
+	}
+
+	a.logger.Info("用戶登錄成功", "username", username, "user_id", userID)
+	c.SetCookie(a.sessionCookie(sessionID, 0))
+	return nil
+}
+
+// handleRegisterPage 處理註冊頁面請求，啟用 OIDC 時用戶在身份提供者註冊
 func (a *AuthUIPagePlugin) handleRegisterPage(c echo.Context) error {
+	if a.oidc != nil {
+		return c.Redirect(http.StatusFound, a.oidcLoginURL(""))
+	}
 	html := a.generateRegisterPageHTML()
 	return c.HTML(http.StatusOK, html)
 }
 
 // handleRegisterSubmit 處理註冊表單提交
 func (a *AuthUIPagePlugin) handleRegisterSubmit(c echo.Context) error {
+	if a.oidc != nil {
+		return c.Redirect(http.StatusSeeOther, a.oidcLoginURL(""))
+	}
+
 	username := c.FormValue("username")
 	password := c.FormValue("password")
 	confirmPassword := c.FormValue("confirm_password")
@@ -183,29 +337,77 @@ func (a *AuthUIPagePlugin) handleRegisterSubmit(c echo.Context) error {
 		return c.HTML(http.StatusBadRequest, a.generateRegisterPageHTML("密碼確認不匹配"))
 	}
 
-	// 在實際實現中，這裡應該調用用戶註冊服務
-	// 目前簡化為直接返回成功消息
-	a.logger.Info("用戶註冊請求", "username", username, "email", email)
+	// 認證提供者不管理本地賬戶時 (例如 Keycloak) 只記錄請求，用戶需在身份提供者註冊
+	if a.accounts == nil {
+		a.logger.Info("用戶註冊請求", "username", username, "email", email)
+		return c.HTML(http.StatusOK, a.generateRegisterSuccessHTML(username, "您的帳號已成功創建。請使用您的用戶名和密碼登錄。"))
+	}
 
-	successHTML := a.generateRegisterSuccessHTML(username)
-	return c.HTML(http.StatusOK, successHTML)
+	user, err := a.accounts.Register(c.Request().Context(), auth.RegisterRequest{Name: username, Email: email, Password: password})
+	if err != nil {
+		var domainErr domainerrors.DomainError
+		if errors.As(err, &domainErr) && domainerrors.IsValidationError(err) {
+			return c.HTML(http.StatusBadRequest, a.generateRegisterPageHTML(html.EscapeString(domainErr.Message)))
+		}
+		a.logger.Error("用戶註冊失敗", "email", email, "error", err)
+		return c.HTML(http.StatusInternalServerError, a.generateRegisterPageHTML("註冊服務暫時不可用"))
+	}
+	message := "您的帳號已成功創建。請使用您的電子郵件和密碼登錄。"
+	if !user.EmailVerified() {
+		message = "您的帳號已成功創建。我們已發送驗證郵件到 " + html.EscapeString(user.Email) + "，請驗證後登錄。"
+	}
+	return c.HTML(http.StatusOK, a.generateRegisterSuccessHTML(user.Name, message))
 }
 
 // handleLogout 處理登出請求
 func (a *AuthUIPagePlugin) handleLogout(c echo.Context) error {
-	// 清除會話 cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "user_id",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1, // 刪除 cookie
-	})
+	// 刪除服務端會話與 OIDC 刷新令牌，並清除會話 cookie
+	if cookie, err := c.Cookie(a.config.SessionCookie); err == nil && a.sessions != nil {
+		if sessionID, ok := a.sessions.Verify(cookie.Value); ok && a.oidc != nil {
+			if err := a.oidc.Refresher.Revoke(c.Request().Context(), sessionID); err != nil {
+				a.logger.Warn("刪除刷新令牌失敗", "error", err)
+			}
+		}
+		if err := a.sessions.Destroy(c.Request().Context(), cookie.Value); err != nil {
+			a.logger.Warn("刪除會話失敗", "error", err)
+		}
+	}
+	c.SetCookie(a.sessionCookie("", -1))
 
 	a.logger.Info("用戶登出")
 	return c.Redirect(http.StatusFound, a.config.LoginRoute)
 }
 
+// oidcLoginURL 返回 OIDC 登錄路由，returnTo 是登錄成功後返回的站內路徑
+func (a *AuthUIPagePlugin) oidcLoginURL(returnTo string) string {
+	target := a.config.OIDCRoute + "/login"
+	if returnTo != "" {
+		target += "?return_to=" + url.QueryEscape(returnTo)
+	}
+	return target
+}
+
+// throttledMessage 返回登錄被限流時顯示的提示
+func throttledMessage(err *auth.ThrottledError) string {
+	if err.Locked {
+		return fmt.Sprintf("登錄失敗次數過多，請於 %d 分鐘後再試", (err.RetryAfterSeconds()+59)/60)
+	}
+	return fmt.Sprintf("登錄嘗試過於頻繁，請於 %d 秒後再試", err.RetryAfterSeconds())
+}
+
+// sessionCookie 返回會話 cookie，maxAge 為 0 時是瀏覽器會話 cookie，過期由服務端會話控制；-1 表示刪除
+func (a *AuthUIPagePlugin) sessionCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     a.config.SessionCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   a.config.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+}
+
 // generateLoginPageHTML 生成登錄頁面 HTML
 func (a *AuthUIPagePlugin) generateLoginPageHTML(errorMsg ...string) string {
 	errorSection := ""
@@ -216,6 +418,14 @@ func (a *AuthUIPagePlugin) generateLoginPageHTML(errorMsg ...string) string {
 		</div>`, errorMsg[0])
 	}
 
+	// 本地賬戶以電子郵件登錄，並可以自行重置密碼
+	usernameLabel, forgotPassword := "用戶名", ""
+	if a.accounts != nil {
+		usernameLabel = "電子郵件"
+		forgotPassword = fmt.Sprintf(`
+            <p><a href="%s">忘記密碼？</a></p>`, a.config.ForgotPasswordRoute)
+	}
+
 	return fmt.Sprintf(`
 <!DOCTYPE html>
 <html lang="zh-TW">
@@ -321,7 +531,7 @@ func (a *AuthUIPagePlugin) generateLoginPageHTML(errorMsg ...string) string {
         
         <form method="POST" action="%s">
             <div class="form-group">
-                <label for="username">用戶名</label>
+                <label for="username">%s</label>
                 <input type="text" id="username" name="username" required>
             </div>
             
@@ -334,11 +544,11 @@ func (a *AuthUIPagePlugin) generateLoginPageHTML(errorMsg ...string) string {
         </form>
         
         <div class="auth-links">
-            <p>還沒有帳號？ <a href="%s">立即註冊</a></p>
+            <p>還沒有帳號？ <a href="%s">立即註冊</a></p>%s
         </div>
     </div>
 </body>
-</html>`, a.config.Title, a.config.BrandName, errorSection, a.config.LoginRoute, a.config.RegisterRoute)
+</html>`, a.config.Title, a.config.BrandName, errorSection, a.config.LoginRoute, usernameLabel, a.config.RegisterRoute, forgotPassword)
 }
 
 // generateRegisterPageHTML 生成註冊頁面 HTML
@@ -486,8 +696,8 @@ func (a *AuthUIPagePlugin) generateRegisterPageHTML(errorMsg ...string) string {
 </html>`, a.config.Title, a.config.BrandName, errorSection, a.config.RegisterRoute, a.config.LoginRoute)
 }
 
-// generateRegisterSuccessHTML 生成註冊成功頁面 HTML
-func (a *AuthUIPagePlugin) generateRegisterSuccessHTML(username string) string {
+// generateRegisterSuccessHTML 生成註冊成功頁面 HTML，message 是已轉義的提示
+func (a *AuthUIPagePlugin) generateRegisterSuccessHTML(username, message string) string {
 	return fmt.Sprintf(`
 <!DOCTYPE html>
 <html lang="zh-TW">
@@ -550,10 +760,10 @@ func (a *AuthUIPagePlugin) generateRegisterSuccessHTML(username string) string {
         <div class="success-title">註冊成功！</div>
         <div class="success-message">
             歡迎 %s！<br>
-            您的帳號已成功創建。請使用您的用戶名和密碼登錄。
+            %s
         </div>
         <a href="%s" class="login-btn">前往登錄</a>
     </div>
 </body>
-</html>`, a.config.Title, username, a.config.LoginRoute)
+</html>`, a.config.Title, html.EscapeString(username), message, a.config.LoginRoute)
 }
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+// accountManager 是本地認證提供者管理用戶賬戶的能力，由 auth.LocalAuthProvider 實現。
+// 認證提供者實現此介面時 AuthUIPagePlugin 提供註冊、郵件驗證、密碼重置與多因素認證頁面。
+type accountManager interface {
+	Register(ctx context.Context, req auth.RegisterRequest) (*entities.User, error)
+	VerifyEmail(ctx context.Context, token string) (*entities.User, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	UserIdentity(ctx context.Context, userID string) (*auth.TokenIdentity, error)
+	StartMFAChallenge(ctx context.Context, userID string) (*auth.MFAChallenge, error)
+	MFAChallengeUser(ctx context.Context, token string) (string, error)
+	CompleteMFAChallenge(ctx context.Context, token, code string) (*auth.MFAChallengeResult, error)
+}
+
+// handleVerifyEmail 處理驗證郵件中的鏈接
+func (a *AuthUIPagePlugin) handleVerifyEmail(c echo.Context) error {
+	if _, err := a.accounts.VerifyEmail(c.Request().Context(), c.QueryParam("token")); err != nil {
+		if domainerrors.IsValidationError(err) {
+			return c.HTML(http.StatusBadRequest, a.accountPage("郵件驗證", "驗證鏈接無效或已過期，請重新登錄以獲取新的驗證郵件。", ""))
+		}
+		a.logger.Error("郵件驗證失敗", "error", err)
+		return c.HTML(http.StatusInternalServerError, a.accountPage("郵件驗證", "服務暫時不可用，請稍後再試。", ""))
+	}
+	return c.HTML(http.StatusOK, a.accountPage("郵件驗證", "您的郵件地址已驗證，現在可以登錄。", ""))
+}
+
+// handleForgotPasswordPage 顯示請求密碼重置的表單
+func (a *AuthUIPagePlugin) handleForgotPasswordPage(c echo.Context) error {
+	return c.HTML(http.StatusOK, a.accountPage("忘記密碼", "輸入註冊時使用的電子郵件，我們會發送密碼重置鏈接。", a.forgotPasswordForm()))
+}
+
+// handleForgotPasswordSubmit 發送密碼重置郵件。無論郵件地址是否存在都顯示相同結果，避免洩露用戶是否存在。
+func (a *AuthUIPagePlugin) handleForgotPasswordSubmit(c echo.Context) error {
+	email := c.FormValue("email")
+	if email == "" {
+		return c.HTML(http.StatusBadRequest, a.accountPage("忘記密碼", "請輸入電子郵件。", a.forgotPasswordForm()))
+	}
+	if err := a.accounts.RequestPasswordReset(c.Request().Context(), email); err != nil {
+		a.logger.Error("發送密碼重置郵件失敗", "error", err)
+	}
+	return c.HTML(http.StatusOK, a.accountPage("忘記密碼", "如果該郵件地址已註冊，您將收到密碼重置鏈接。", ""))
+}
+
+// handleResetPasswordPage 顯示設置新密碼的表單，令牌來自重置郵件中的鏈接
+func (a *AuthUIPagePlugin) handleResetPasswordPage(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.HTML(http.StatusBadRequest, a.accountPage("重置密碼", "重置鏈接無效，請重新請求密碼重置。", ""))
+	}
+	return c.HTML(http.StatusOK, a.accountPage("重置密碼", "請設置新密碼。", a.resetPasswordForm(token)))
+}
+
+// handleResetPasswordSubmit 以重置令牌設置新密碼
+func (a *AuthUIPagePlugin) handleResetPasswordSubmit(c echo.Context) error {
+	token := c.FormValue("token")
+	password := c.FormValue("password")
+	if password == "" || password != c.FormValue("confirm_password") {
+		return c.HTML(http.StatusBadRequest, a.accountPage("重置密碼", "密碼確認不匹配。", a.resetPasswordForm(token)))
+	}
+	if err := a.accounts.ResetPassword(c.Request().Context(), token, password); err != nil {
+		// 密碼不符合策略時令牌仍然有效，保留表單讓用戶重試
+		var domainErr domainerrors.DomainError
+		if errors.As(err, &domainErr) && domainerrors.IsValidationError(err) {
+			if domainErr.Field == "password" {
+				return c.HTML(http.StatusBadRequest, a.accountPage("重置密碼", domainErr.Message, a.resetPasswordForm(token)))
+			}
+			return c.HTML(http.StatusBadRequest, a.accountPage("重置密碼", "重置鏈接無效或已過期，請重新請求密碼重置。", ""))
+		}
+		a.logger.Error("重置密碼失敗", "error", err)
+		return c.HTML(http.StatusInternalServerError, a.accountPage("重置密碼", "服務暫時不可用，請稍後再試。", a.resetPasswordForm(token)))
+	}
+	return c.HTML(http.StatusOK, a.accountPage("重置密碼", "密碼已重置，請使用新密碼登錄。", ""))
+}
+
+// forgotPasswordForm 返回請求密碼重置的表單
+func (a *AuthUIPagePlugin) forgotPasswordForm() string {
+	return fmt.Sprintf(`<form method="POST" action="%s">
+        <label for="email">電子郵件</label>
+        <input type="email" id="email" name="email" required>
+        <button type="submit">發送重置鏈接</button>
+    </form>`, a.config.ForgotPasswordRoute)
+}
+
+// resetPasswordForm 返回設置新密碼的表單
+func (a *AuthUIPagePlugin) resetPasswordForm(token string) string {
+	return fmt.Sprintf(`<form method="POST" action="%s">
+        <input type="hidden" name="token" value="%s">
+        <label for="password">新密碼</label>
+        <input type="password" id="password" name="password" required>
+        <label for="confirm_password">確認新密碼</label>
+        <input type="password" id="confirm_password" name="confirm_password" required>
+        <button type="submit">重置密碼</button>
+    </form>`, a.config.ResetPasswordRoute, html.EscapeString(token))
+}
+
+// accountPage 返回賬戶操作的簡單頁面，form 為已生成的表單 HTML，message 會被轉義
+func (a *AuthUIPagePlugin) accountPage(title, message, form string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="zh-TW">
+<head>
+    <meta charset="UTF-8">
+    <title>%s - %s</title>
+</head>
+<body>
+    <h1>%s</h1>
+    <p>%s</p>
+    %s
+    <p><a href="%s">返回登錄</a></p>
+</body>
+</html>`, html.EscapeString(a.config.Title), title, title, html.EscapeString(message), form, a.config.LoginRoute)
+}
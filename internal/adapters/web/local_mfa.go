@@ -0,0 +1,129 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+// handleMFASubmit 以 TOTP 驗證碼或恢復碼完成密碼驗證後的多因素認證挑戰。
+// 用戶身份與失敗計數的賬戶都由挑戰令牌確定，表單中不攜帶用戶名。
+func (a *AuthUIPagePlugin) handleMFASubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	token := c.FormValue("token")
+	code := c.FormValue("code")
+	retry := &auth.MFAChallenge{Token: token}
+
+	if token == "" {
+		return c.HTML(http.StatusBadRequest, a.accountPage("多因素認證", "驗證已過期，請重新登錄。", ""))
+	}
+	userID, err := a.accounts.MFAChallengeUser(ctx, token)
+	if err != nil {
+		var domainErr domainerrors.DomainError
+		if errors.As(err, &domainErr) && domainerrors.IsValidationError(err) {
+			return c.HTML(http.StatusUnauthorized, a.accountPage("多因素認證", domainErr.Message, ""))
+		}
+		a.logger.Error("讀取多因素認證挑戰失敗", "error", err)
+		return c.HTML(http.StatusInternalServerError, a.mfaPage(retry, "登錄服務暫時不可用"))
+	}
+	identity, err := a.accounts.UserIdentity(ctx, userID)
+	if err != nil {
+		a.logger.Error("讀取用戶信息失敗", "user_id", userID, "error", err)
+		return c.HTML(http.StatusInternalServerError, a.mfaPage(retry, "登錄服務暫時不可用"))
+	}
+	// 登錄以郵箱作為賬戶，與密碼步驟的失敗計數共用同一鍵
+	username := identity.Email
+	if strings.TrimSpace(code) == "" {
+		return c.HTML(http.StatusBadRequest, a.mfaPage(retry, "請輸入驗證碼"))
+	}
+
+	ip := c.RealIP()
+	if a.throttle != nil {
+		var throttled *auth.ThrottledError
+		if err := a.throttle.Check(ctx, username, ip); errors.As(err, &throttled) {
+			a.logger.Warn("多因素認證嘗試過於頻繁", "username", username, "ip", ip, "scope", throttled.Scope)
+			c.Response().Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			return c.HTML(http.StatusTooManyRequests, a.mfaPage(retry, throttledMessage(throttled)))
+		}
+	}
+
+	result, err := a.accounts.CompleteMFAChallenge(ctx, token, code)
+	if err != nil {
+		var domainErr domainerrors.DomainError
+		if errors.As(err, &domainErr) && domainerrors.IsValidationError(err) && domainErr.Field == "token" {
+			if a.throttle != nil {
+				a.throttle.Release(ctx, username, ip)
+			}
+			return c.HTML(http.StatusUnauthorized, a.accountPage("多因素認證", domainErr.Message, ""))
+		}
+		if domainerrors.IsAuthError(err) || domainerrors.IsValidationError(err) {
+			a.logger.Warn("多因素認證失敗", "username", username, "error", err)
+			if a.throttle != nil {
+				if err := a.throttle.RecordFailure(ctx, username, ip); err != nil {
+					a.logger.Error("記錄登錄失敗次數失敗", "username", username, "error", err)
+				}
+			}
+			return c.HTML(http.StatusUnauthorized, a.mfaPage(retry, "驗證碼不正確或已使用"))
+		}
+		a.logger.Error("多因素認證失敗", "username", username, "error", err)
+		if a.throttle != nil {
+			a.throttle.Release(ctx, username, ip)
+		}
+		return c.HTML(http.StatusInternalServerError, a.mfaPage(retry, "登錄服務暫時不可用"))
+	}
+
+	if err := a.completeLogin(c, result.UserID, username); err != nil {
+		return c.HTML(loginErrorStatus(err), a.generateLoginPageHTML("登錄服務暫時不可用"))
+	}
+
+	// 在登錄時完成登記的用戶只在此時看到恢復碼
+	if len(result.RecoveryCodes) > 0 {
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.HTML(http.StatusOK, a.recoveryCodesPage(result.RecoveryCodes))
+	}
+	return c.Redirect(http.StatusFound, "/ui/hello")
+}
+
+// mfaPage 返回輸入驗證碼的頁面；challenge.Enrollment 不為 nil 時同時顯示登記驗證器所需的密鑰
+func (a *AuthUIPagePlugin) mfaPage(challenge *auth.MFAChallenge, message string) string {
+	if message == "" {
+		message = "請輸入驗證器應用中顯示的 6 位驗證碼，或使用一個恢復碼。"
+	}
+	enrollment := ""
+	if challenge.Enrollment != nil {
+		message = "您的角色要求啟用多因素認證。請在驗證器應用中添加以下帳戶，然後輸入顯示的 6 位驗證碼完成登記。"
+		enrollment = fmt.Sprintf(`<p>密鑰: <code>%s</code></p>
+    <p><a href="%s">在手機上打開登記鏈接</a></p>
+    <p><small>%s</small></p>
+    `, html.EscapeString(challenge.Enrollment.Secret),
+			html.EscapeString(challenge.Enrollment.ProvisioningURI),
+			html.EscapeString(challenge.Enrollment.ProvisioningURI))
+	}
+	form := fmt.Sprintf(`%s<form method="POST" action="%s">
+        <input type="hidden" name="token" value="%s">
+        <label for="code">驗證碼</label>
+        <input type="text" id="code" name="code" autocomplete="one-time-code" autofocus required>
+        <button type="submit">驗證</button>
+    </form>`, enrollment, a.config.MFARoute, html.EscapeString(challenge.Token))
+	return a.accountPage("多因素認證", message, form)
+}
+
+// recoveryCodesPage 返回顯示新生成恢復碼的頁面
+func (a *AuthUIPagePlugin) recoveryCodesPage(codes []string) string {
+	var list strings.Builder
+	list.WriteString("<ul>")
+	for _, code := range codes {
+		list.WriteString("<li><code>" + html.EscapeString(code) + "</code></li>")
+	}
+	list.WriteString(`</ul>
+    <p><a href="/ui/hello">繼續</a></p>`)
+	return a.accountPage("多因素認證", "已啟用多因素認證。請妥善保存以下恢復碼，每個只能使用一次，遺失驗證器時可用於登錄。恢復碼不會再次顯示。", list.String())
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"detectviz-platform/internal/infrastructure/platform/auth"
+)
+
+// OIDCLogin 是 UI 以 OIDC 授權碼流程 (PKCE) 登錄所需的組件，AuthUIPagePlugin 沒有 OIDCLogin 時使用用戶名密碼登錄
+type OIDCLogin struct {
+	Client    *auth.OIDCClient
+	Refresher *auth.OIDCSessionRefresher
+}
+
+const (
+	// oidcStateTokenType 是未完成的授權請求在 AuthStorageProvider 中的令牌類型，以 state 作為 userID 鍵
+	oidcStateTokenType = "oidc_login_state"
+	// oidcStateTTL 是授權請求從重定向到回調的最長時間
+	oidcStateTTL = 10 * time.Minute
+	// oidcStateCookie 把 state 綁定到發起登錄的瀏覽器，防止把他人的授權回調注入到本瀏覽器 (登錄 CSRF)
+	oidcStateCookie = "detectviz_oidc_state"
+	// defaultReturnTo 是登錄成功後默認跳轉的頁面
+	defaultReturnTo = "/ui/hello"
+)
+
+// oidcPendingLogin 是重定向到身份提供者前保存的授權請求
+type oidcPendingLogin struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	ReturnTo     string `json:"return_to"`
+}
+
+// handleOIDCLogin 生成 state、nonce 與 PKCE verifier，保存後把瀏覽器重定向到身份提供者
+func (a *AuthUIPagePlugin) handleOIDCLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+	state, err := auth.RandomURLToken(32)
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	nonce, err := auth.RandomURLToken(32)
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	verifier, err := auth.NewPKCEVerifier()
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+
+	authURL, err := a.oidc.Client.AuthCodeURL(ctx, state, nonce, auth.PKCEChallenge(verifier))
+	if err != nil {
+		return a.oidcError(c, http.StatusServiceUnavailable, "無法連接身份提供者", err)
+	}
+	pending, err := json.Marshal(oidcPendingLogin{Nonce: nonce, CodeVerifier: verifier, ReturnTo: safeReturnTo(c.QueryParam("return_to"))})
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	expiry := time.Now().Add(oidcStateTTL).Unix()
+	if err := a.sessions.Store().StoreToken(ctx, state, oidcStateTokenType, string(pending), expiry); err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+
+	c.SetCookie(a.oidcStateCookie(state, int(oidcStateTTL.Seconds())))
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// handleOIDCCallback 驗證 state 後以授權碼換取令牌，驗證 ID 令牌的 nonce 並創建會話
+func (a *AuthUIPagePlugin) handleOIDCCallback(c echo.Context) error {
+	ctx := c.Request().Context()
+	c.SetCookie(a.oidcStateCookie("", -1))
+
+	if errCode := c.QueryParam("error"); errCode != "" {
+		a.logger.Warn("身份提供者返回錯誤", "error", errCode, "description", c.QueryParam("error_description"))
+		return a.oidcError(c, http.StatusUnauthorized, "身份提供者拒絕了登錄請求", nil)
+	}
+	state := c.QueryParam("state")
+	code := c.QueryParam("code")
+	cookie, err := c.Cookie(oidcStateCookie)
+	if state == "" || code == "" || err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return a.oidcError(c, http.StatusBadRequest, "登錄請求無效，請重新登錄", nil)
+	}
+
+	// state 只能使用一次，讀取後立即刪除
+	store := a.sessions.Store()
+	raw, err := store.GetToken(ctx, state, oidcStateTokenType)
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	if err := store.RevokeToken(ctx, state, oidcStateTokenType); err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	var pending oidcPendingLogin
+	if raw == "" || json.Unmarshal([]byte(raw), &pending) != nil {
+		return a.oidcError(c, http.StatusBadRequest, "登錄請求已過期，請重新登錄", nil)
+	}
+
+	tokens, err := a.oidc.Client.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		a.logger.Warn("以授權碼換取令牌失敗", "error", err)
+		return a.oidcError(c, http.StatusUnauthorized, "登錄失敗，請重新登錄", nil)
+	}
+	identity, err := a.oidc.Client.VerifyIDToken(ctx, tokens.IDToken, pending.Nonce)
+	if err != nil {
+		a.logger.Warn("ID 令牌驗證失敗", "error", err)
+		return a.oidcError(c, http.StatusUnauthorized, "登錄失敗，請重新登錄", nil)
+	}
+
+	// 與密碼登錄一樣輪換會話 ID，並刪除請求中原有的會話
+	previous := ""
+	if cookie, err := c.Cookie(a.config.SessionCookie); err == nil {
+		previous = cookie.Value
+	}
+	signed, err := a.sessions.Create(ctx, auth.NewOIDCSession(identity, tokens.ExpiresAt), previous)
+	if err != nil {
+		return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+	}
+	if sessionID, ok := a.sessions.Verify(signed); ok {
+		if err := a.oidc.Refresher.StoreTokens(ctx, sessionID, tokens); err != nil {
+			return a.oidcError(c, http.StatusInternalServerError, "登錄服務暫時不可用", err)
+		}
+	}
+
+	a.logger.Info("用戶透過 OIDC 登錄成功", "user_id", identity.UserID, "username", identity.Username)
+	c.SetCookie(a.sessionCookie(signed, 0))
+	return c.Redirect(http.StatusFound, pending.ReturnTo)
+}
+
+// oidcStateCookie 返回綁定 state 的 cookie。身份提供者以頂層 GET 重定向回調，SameSite=Lax 的 cookie 會隨之發送。
+func (a *AuthUIPagePlugin) oidcStateCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     a.config.OIDCRoute,
+		HttpOnly: true,
+		Secure:   a.config.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+}
+
+// oidcError 記錄錯誤並返回帶重新登錄鏈接的錯誤頁面，cause 為 nil 時表示已記錄或不需要記錄
+func (a *AuthUIPagePlugin) oidcError(c echo.Context, status int, message string, cause error) error {
+	if cause != nil {
+		a.logger.Error("OIDC 登錄失敗", "error", cause)
+	}
+	return c.HTML(status, fmt.Sprintf(`<!DOCTYPE html>
+<html lang="zh-TW">
+<head>
+    <meta charset="UTF-8">
+    <title>%s - 登錄</title>
+</head>
+<body>
+    <p>%s</p>
+    <p><a href="%s">重新登錄</a></p>
+</body>
+</html>`, html.EscapeString(a.config.Title), html.EscapeString(message), a.config.OIDCRoute+"/login"))
+}
+
+// safeReturnTo 只接受站內的相對路徑，避免登錄後被重定向到外部網站
+func safeReturnTo(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.ContainsAny(returnTo, "\\\r\n") {
+		return defaultReturnTo
+	}
+	return returnTo
+}
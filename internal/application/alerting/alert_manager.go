@@ -0,0 +1,1425 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DefaultReceiver 是根路由未指定接收者時使用的接收者名稱
+const DefaultReceiver = "default"
+
+// AlertManagerConfig 定義告警管理器的配置
+type AlertManagerConfig struct {
+	EvaluationInterval string   `yaml:"evaluationInterval" json:"evaluationInterval"` // 評估 pending/超時告警並發送到期通知的間隔，默認 "10s"
+	PendingFor         string   `yaml:"pendingFor" json:"pendingFor"`                 // 異常持續多久後由 pending 轉為 firing，默認 "0s" (立即)
+	ResolveTimeout     string   `yaml:"resolveTimeout" json:"resolveTimeout"`         // 超過此時間未再收到異常即自動恢復，默認 "5m"，"0s" 表示只在收到正常結果時恢復
+	GroupBy            []string `yaml:"groupBy" json:"groupBy"`                       // 根路由的分組標籤，默認 ["detector_id"]
+	GroupWait          string   `yaml:"groupWait" json:"groupWait"`                   // 根路由新分組第一次通知前的等待時間，默認 "30s"
+	GroupInterval      string   `yaml:"groupInterval" json:"groupInterval"`           // 根路由分組內有變化時兩次通知的最小間隔，默認 "5m"
+	RepeatInterval     string   `yaml:"repeatInterval" json:"repeatInterval"`         // 根路由沒有變化時重複通知的間隔，默認 "4h"
+	SilenceRetention   string   `yaml:"silenceRetention" json:"silenceRetention"`     // 已結束的靜默保留多久後刪除，默認 "120h"
+
+	EscalationSeverities []string `yaml:"escalationSeverities" json:"escalationSeverities"` // 未確認時按接收者升級策略升級的嚴重程度，默認 ["critical"]
+
+	Route     RouteConfig      `yaml:"route" json:"route"`         // 路由樹，根路由即默認路由
+	Receivers []ReceiverConfig `yaml:"receivers" json:"receivers"` // 路由引用的接收者
+}
+
+// AlertManager 位於檢測與 AlertPlugin 之間的告警生命週期引擎
+// 職責: 以檢測器 ID 加結果標籤計算指紋，合併重複的異常結果；維護 pending → firing → resolved 狀態轉換；
+// 以路由樹為 firing 告警選擇接收者 (可匹配 severity、檢測器標籤與擁有者 owner)，在每個匹配的路由下
+// 按 group_by 標籤聚合告警，依該路由的 group_wait、group_interval 與 repeat_interval 控制通知節奏並發送恢復通知。
+// 告警與分組的通知狀態都持久化在倉儲中，重啟後不會重新通知已發送過的告警。
+// 通知失敗時分組的通知狀態不會推進，下一個 group_interval 會重新發送整組變化。
+// 被生效中靜默匹配的 firing 告警不會通知，抑制它的靜默 ID 記錄在告警的 SilencedBy 中；
+// 恢復通知不受靜默影響，以便接收者關閉曾經收到的告警。
+// 渠道設置 onCall 時在通知當下解析團隊的值班者；接收者設置升級策略時，嚴重程度需要升級的告警
+// 第一次通知後未被確認，即按策略的層級逐層通知升級目標，直到告警被確認或恢復。
+// 啟用事件單時，通知前按分組把 firing 告警歸入事件單，通知附帶事件單 ID 與確認鏈接，成功發送的通知記入事件單時間線。
+// 啟用投遞記錄時，每一次發送都記錄為投遞；發送失敗的通知交給重試佇列，分組照常推進，由每輪評估按退避時間重試。
+type AlertManager struct {
+	alerts     interfaces.AlertRepository
+	groups     interfaces.AlertGroupRepository
+	silences   *SilenceService
+	oncall     *OnCallService
+	incidents  *IncidentService
+	deliveries *DeliveryService
+	results    interfaces.AnalysisResultRepository
+	detectors  interfaces.DetectorRepository
+	registry   contracts.PluginRegistryProvider
+	router     *Router
+	logger     contracts.Logger
+	metrics    contracts.MetricsProvider
+
+	evaluationInterval time.Duration
+	pendingFor         time.Duration
+	resolveTimeout     time.Duration
+	silenceRetention   time.Duration
+
+	escalationSeverities map[string]bool
+
+	now func() time.Time
+
+	lastPurgeAt time.Time // 最近一次清理過期靜默的時間
+
+	stateMu sync.Mutex // 序列化狀態轉換，渠道發送在鎖外進行
+	tickMu  sync.Mutex // 序列化評估輪次，避免兩輪同時發送同一分組或升級層級的通知
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// AlertManagerDeps 是告警管理器的依賴。Alerts、Groups 與 Registry 必須設置，其餘可為 nil:
+// Detectors 用於查找檢測器擁有者以匹配 owner 標籤；OnCall 為 nil 時不能使用 onCall 渠道與升級策略；
+// Incidents 為 nil 時不開立事件單；Deliveries 為 nil 時不記錄投遞，發送失敗時由分組在下一個 group_interval 重發；
+// Results 不為 nil 時保存收到的異常結果，以便分析師標記。
+type AlertManagerDeps struct {
+	Alerts     interfaces.AlertRepository
+	Groups     interfaces.AlertGroupRepository
+	Registry   contracts.PluginRegistryProvider
+	Silences   *SilenceService
+	OnCall     *OnCallService
+	Incidents  *IncidentService
+	Deliveries *DeliveryService
+	Results    interfaces.AnalysisResultRepository
+	Detectors  interfaces.DetectorRepository
+	Metrics    contracts.MetricsProvider
+}
+
+// NewAlertManager 創建新的告警管理器
+func NewAlertManager(deps AlertManagerDeps, config AlertManagerConfig, logger contracts.Logger) (*AlertManager, error) {
+	if deps.Alerts == nil || deps.Groups == nil || deps.Registry == nil {
+		return nil, fmt.Errorf("alert manager requires alert and group repositories and a plugin registry")
+	}
+	m := &AlertManager{
+		alerts:     deps.Alerts,
+		groups:     deps.Groups,
+		silences:   deps.Silences,
+		oncall:     deps.OnCall,
+		incidents:  deps.Incidents,
+		deliveries: deps.Deliveries,
+		results:    deps.Results,
+		detectors:  deps.Detectors,
+		registry:   deps.Registry,
+		logger:     logger,
+		metrics:    deps.Metrics,
+		now:        time.Now,
+	}
+	defaults := Route{Receiver: DefaultReceiver, GroupBy: config.GroupBy}
+
+	var err error
+	if m.evaluationInterval, err = parseDurationDefault(config.EvaluationInterval, 10*time.Second); err != nil {
+		return nil, fmt.Errorf("invalid evaluationInterval: %w", err)
+	}
+	if m.pendingFor, err = parseOptionalDuration(config.PendingFor, 0); err != nil {
+		return nil, fmt.Errorf("invalid pendingFor: %w", err)
+	}
+	if m.resolveTimeout, err = parseOptionalDuration(config.ResolveTimeout, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid resolveTimeout: %w", err)
+	}
+	if defaults.GroupWait, err = parseOptionalDuration(config.GroupWait, 30*time.Second); err != nil {
+		return nil, fmt.Errorf("invalid groupWait: %w", err)
+	}
+	if defaults.GroupInterval, err = parseDurationDefault(config.GroupInterval, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid groupInterval: %w", err)
+	}
+	if defaults.RepeatInterval, err = parseDurationDefault(config.RepeatInterval, 4*time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid repeatInterval: %w", err)
+	}
+	if m.silenceRetention, err = parseDurationDefault(config.SilenceRetention, 120*time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid silenceRetention: %w", err)
+	}
+	if len(defaults.GroupBy) == 0 {
+		defaults.GroupBy = []string{entities.AlertLabelDetectorID}
+	}
+	severities := config.EscalationSeverities
+	if len(severities) == 0 {
+		severities = []string{"critical"}
+	}
+	m.escalationSeverities = make(map[string]bool, len(severities))
+	for _, severity := range severities {
+		m.escalationSeverities[strings.ToLower(severity)] = true
+	}
+	if m.router, err = NewRouter(config.Route, config.Receivers, defaults); err != nil {
+		return nil, fmt.Errorf("invalid alert route: %w", err)
+	}
+	if len(config.Receivers) == 0 {
+		logger.Warn("告警管理器未配置接收者，告警只會記錄狀態而不會發送通知")
+	}
+	if m.oncall == nil {
+		for _, receiver := range config.Receivers {
+			if receiver.EscalationPolicy != "" {
+				logger.Warn("未配置值班服務，接收者的升級策略不會生效", "receiver", receiver.Name, "policy", receiver.EscalationPolicy)
+			}
+		}
+	}
+	if m.incidents != nil {
+		m.incidents.acknowledger = m
+	}
+	if m.deliveries != nil {
+		m.deliveries.sender = m
+	}
+
+	return m, nil
+}
+
+// GetName 返回告警管理器名稱，管線的 alert 階段以此名稱引用
+func (m *AlertManager) GetName() string {
+	return "alert_manager"
+}
+
+// Silences 返回告警管理器使用的靜默服務，未配置時為 nil
+func (m *AlertManager) Silences() *SilenceService {
+	return m.silences
+}
+
+// OnCall 返回告警管理器使用的值班服務，未配置時為 nil
+func (m *AlertManager) OnCall() *OnCallService {
+	return m.oncall
+}
+
+// Incidents 返回告警管理器使用的事件單服務，未配置時為 nil
+func (m *AlertManager) Incidents() *IncidentService {
+	return m.incidents
+}
+
+// Deliveries 返回告警管理器使用的通知投遞服務，未啟用投遞記錄時為 nil
+func (m *AlertManager) Deliveries() *DeliveryService {
+	return m.deliveries
+}
+
+// Init 初始化告警管理器，配置已在構造時解析
+func (m *AlertManager) Init(ctx context.Context, cfg map[string]interface{}) error {
+	return nil
+}
+
+// Start 啟動評估循環
+func (m *AlertManager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return fmt.Errorf("alert manager already started")
+	}
+	m.running = true
+	m.stopChan = make(chan struct{})
+
+	m.wg.Add(1)
+	go m.loop(ctx, m.stopChan)
+
+	m.logger.Info("告警管理器已啟動", "evaluation_interval", m.evaluationInterval, "routes", len(m.router.routes))
+	return nil
+}
+
+// Stop 停止評估循環並等待進行中的通知結束
+func (m *AlertManager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return nil
+	}
+	m.running = false
+	close(m.stopChan)
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.logger.Info("告警管理器已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for alert manager to stop: %w", ctx.Err())
+	}
+}
+
+// loop 定期評估告警狀態並發送到期通知
+func (m *AlertManager) loop(ctx context.Context, stopChan chan struct{}) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.evaluationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Tick(ctx); err != nil {
+				m.logger.Error("告警評估失敗", "error", err)
+			}
+		case <-stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// TriggerAlert 實現 AlertPlugin，記錄一個異常結果
+func (m *AlertManager) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error {
+	return m.Process(ctx, []*entities.AnalysisResult{result})
+}
+
+// ResolveAlert 實現 AlertResolverPlugin，以非異常結果結束對應的告警
+func (m *AlertManager) ResolveAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error {
+	return m.Process(ctx, []*entities.AnalysisResult{result})
+}
+
+// Process 按順序處理一批分析結果：異常結果使告警進入或保持 pending/firing，
+// 非異常結果使同一指紋的活躍告警恢復。通知由 Tick 按分組節奏發送。
+func (m *AlertManager) Process(ctx context.Context, results []*entities.AnalysisResult) error {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	now := m.now()
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if err := m.observe(ctx, result, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe 根據單個分析結果更新告警狀態
+func (m *AlertManager) observe(ctx context.Context, result *entities.AnalysisResult, now time.Time) error {
+	labels := result.Labels()
+	labels[entities.AlertLabelDetectorID] = result.DetectorID
+	fingerprint := entities.AlertFingerprint(labels)
+
+	alert, err := m.alerts.GetByFingerprint(ctx, fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to load alert %s: %w", fingerprint, err)
+	}
+
+	if !result.IsAnomalous() {
+		if alert == nil || !alert.IsActive() {
+			return nil
+		}
+		return m.resolve(ctx, alert, now)
+	}
+
+	m.recordResult(ctx, result)
+	if alert == nil || alert.State == entities.AlertStateResolved {
+		alert = &entities.Alert{
+			Fingerprint: fingerprint,
+			DetectorID:  result.DetectorID,
+			Labels:      labels,
+			State:       entities.AlertStatePending,
+			StartsAt:    now,
+		}
+		m.transition(entities.AlertStatePending, alert.DetectorID)
+	}
+	alert.Severity = result.Severity
+	alert.Summary = result.Summary
+	alert.Data = copyData(result.Data)
+	alert.AnalysisResultID = result.ID
+	alert.LastSeenAt = now
+	alert.UpdatedAt = now
+
+	if alert.State == entities.AlertStatePending && now.Sub(alert.StartsAt) >= m.pendingFor {
+		return m.fire(ctx, alert, now)
+	}
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save alert %s: %w", fingerprint, err)
+	}
+	return nil
+}
+
+// fire 將告警轉為 firing 並加入每個匹配路由的通知分組
+func (m *AlertManager) fire(ctx context.Context, alert *entities.Alert, now time.Time) error {
+	alert.State = entities.AlertStateFiring
+	alert.FiredAt = now
+	alert.ResolvedAt = time.Time{}
+	alert.UpdatedAt = now
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save alert %s: %w", alert.Fingerprint, err)
+	}
+	m.transition(entities.AlertStateFiring, alert.DetectorID)
+	m.logger.Info("告警已觸發", "fingerprint", alert.Fingerprint, "detector_id", alert.DetectorID, "labels", alert.Labels)
+	labels := m.routingLabels(ctx, alert)
+	for _, route := range m.router.Match(labels) {
+		if err := m.addToGroup(ctx, route, alert, labels, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordResult 保存異常結果，使告警與事件單引用的結果可以被查詢與標記；失敗時只記錄日誌，不影響告警流程
+func (m *AlertManager) recordResult(ctx context.Context, result *entities.AnalysisResult) {
+	if m.results == nil || result.ID == "" {
+		return
+	}
+	if err := m.results.Create(ctx, result); err != nil {
+		m.logger.Error("保存分析結果失敗", "result_id", result.ID, "detector_id", result.DetectorID, "error", err)
+	}
+}
+
+// resolve 將告警轉為 resolved；曾經通知過的告警會在所屬分組下一次通知時發送恢復
+func (m *AlertManager) resolve(ctx context.Context, alert *entities.Alert, now time.Time) error {
+	alert.State = entities.AlertStateResolved
+	alert.ResolvedAt = now
+	alert.UpdatedAt = now
+	if alert.Escalation.Pending() {
+		alert.Escalation.NextAt = time.Time{}
+	}
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save alert %s: %w", alert.Fingerprint, err)
+	}
+	m.transition(entities.AlertStateResolved, alert.DetectorID)
+	m.logger.Info("告警已恢復", "fingerprint", alert.Fingerprint, "detector_id", alert.DetectorID)
+	if m.incidents != nil {
+		if err := m.incidents.alertResolved(ctx, alert, now); err != nil {
+			m.logger.Error("更新告警所屬的事件單失敗", "fingerprint", alert.Fingerprint, "error", err)
+		}
+	}
+	return nil
+}
+
+// addToGroup 將 firing 告警加入路由下對應的分組，新分組在路由的 group_wait 後第一次通知
+func (m *AlertManager) addToGroup(ctx context.Context, route *Route, alert *entities.Alert, labels map[string]string, now time.Time) error {
+	groupLabels := route.GroupLabels(labels)
+	key := entities.AlertGroupKey(route.ID, route.Receiver, groupLabels)
+
+	group, err := m.groups.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to load alert group %s: %w", key, err)
+	}
+	if group == nil {
+		group = &entities.AlertGroup{
+			Key:         key,
+			RouteID:     route.ID,
+			Receiver:    route.Receiver,
+			Labels:      groupLabels,
+			Members:     make(map[string]string),
+			NextFlushAt: now.Add(route.GroupWait),
+			CreatedAt:   now,
+		}
+	}
+	if _, ok := group.Members[alert.Fingerprint]; ok {
+		return nil
+	}
+	group.Members[alert.Fingerprint] = ""
+	group.UpdatedAt = now
+	if err := m.groups.Save(ctx, group); err != nil {
+		return fmt.Errorf("failed to save alert group %s: %w", key, err)
+	}
+	return nil
+}
+
+// Tick 執行一輪評估：轉換到期的 pending 告警、恢復超時未出現的告警、為到期的分組發送通知，
+// 升級到期仍未確認的告警，並重試到期的失敗投遞。每小時清理一次超過保留期的已結束靜默。
+// 需要發送的通知在 stateMu 下收集，釋放鎖後才調用渠道插件，再重新取得鎖記錄結果，
+// 緩慢的渠道因此不會阻塞 Process 與 Acknowledge。
+func (m *AlertManager) Tick(ctx context.Context) error {
+	m.tickMu.Lock()
+	defer m.tickMu.Unlock()
+
+	plan, err := m.planTick(ctx)
+	if err != nil {
+		return err
+	}
+	plan.send(ctx)
+
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	return m.completeTick(ctx, plan)
+}
+
+// pendingSend 是一次待發送的渠道通知。target 為 nil 表示準備時已失敗，err 即失敗原因
+type pendingSend struct {
+	target       *integrationTarget
+	receiver     string
+	recipient    string
+	notification *entities.AlertNotification
+	incident     *entities.Incident
+	err          error
+}
+
+// send 調用渠道插件，不持有任何鎖
+func (s *pendingSend) send(ctx context.Context) {
+	if s.target == nil {
+		return
+	}
+	s.err = s.target.sendTo(ctx, s.recipient, s.notification)
+}
+
+// groupFlush 是一個分組待發送的通知
+type groupFlush struct {
+	key         string
+	firing      []*entities.Alert
+	resolved    []*entities.Alert
+	newlyFiring []*entities.Alert
+	sends       []*pendingSend
+	errs        []error
+}
+
+// escalationSend 是一個告警當前升級層級待發送的通知
+type escalationSend struct {
+	alert    *entities.Alert
+	policy   *entities.EscalationPolicy
+	receiver string
+	level    int
+	sends    []*pendingSend
+	errs     []error
+}
+
+// deliveryRetry 是一次到期的失敗投遞重試
+type deliveryRetry struct {
+	delivery *entities.NotificationDelivery
+	send     *pendingSend
+}
+
+// tickPlan 是一輪評估在 stateMu 下收集的全部發送
+type tickPlan struct {
+	now         time.Time
+	flushes     []*groupFlush
+	escalations []*escalationSend
+	retries     []*deliveryRetry
+	errs        []error
+}
+
+// send 依序執行計劃中的所有發送
+func (p *tickPlan) send(ctx context.Context) {
+	for _, flush := range p.flushes {
+		for _, s := range flush.sends {
+			s.send(ctx)
+		}
+	}
+	for _, escalation := range p.escalations {
+		for _, s := range escalation.sends {
+			s.send(ctx)
+		}
+	}
+	for _, retry := range p.retries {
+		retry.send.send(ctx)
+	}
+}
+
+// planTick 轉換告警狀態並收集本輪需要發送的通知
+func (m *AlertManager) planTick(ctx context.Context) (*tickPlan, error) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	now := m.now()
+	plan := &tickPlan{now: now}
+	active, err := m.alerts.ListByState(ctx, entities.AlertStatePending, entities.AlertStateFiring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active alerts: %w", err)
+	}
+	for _, alert := range active {
+		var err error
+		switch {
+		case m.resolveTimeout > 0 && now.Sub(alert.LastSeenAt) > m.resolveTimeout:
+			err = m.resolve(ctx, alert, now)
+		case alert.State == entities.AlertStatePending && now.Sub(alert.StartsAt) >= m.pendingFor:
+			err = m.fire(ctx, alert, now)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := m.groups.ListDue(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due alert groups: %w", err)
+	}
+	var silences []*entities.Silence
+	if m.silences != nil && len(groups) > 0 {
+		if silences, err = m.silences.Active(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+	if m.silences != nil && now.Sub(m.lastPurgeAt) >= time.Hour {
+		if err := m.silences.Purge(ctx, now.Add(-m.silenceRetention)); err != nil {
+			plan.errs = append(plan.errs, err)
+		} else {
+			m.lastPurgeAt = now
+		}
+	}
+	for _, group := range groups {
+		flush, err := m.planFlush(ctx, group, silences, now)
+		if err != nil {
+			plan.errs = append(plan.errs, err)
+			continue
+		}
+		if flush != nil {
+			plan.flushes = append(plan.flushes, flush)
+		}
+	}
+	if m.oncall != nil {
+		escalations, err := m.planEscalations(ctx, now)
+		if err != nil {
+			plan.errs = append(plan.errs, err)
+		}
+		plan.escalations = escalations
+	}
+	if m.deliveries != nil {
+		retries, err := m.planRetries(ctx, now)
+		if err != nil {
+			plan.errs = append(plan.errs, err)
+		}
+		plan.retries = retries
+	}
+	return plan, nil
+}
+
+// completeTick 記錄本輪發送的結果
+func (m *AlertManager) completeTick(ctx context.Context, plan *tickPlan) error {
+	errs := plan.errs
+	for _, flush := range plan.flushes {
+		if err := m.completeFlush(ctx, flush, plan.now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, escalation := range plan.escalations {
+		if err := m.completeEscalation(ctx, escalation, plan.now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, retry := range plan.retries {
+		err := m.redelivered(ctx, retry.delivery, retry.send.err)
+		if err := m.deliveries.retried(ctx, retry.delivery, err, plan.now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planFlush 評估分組是否需要通知：有新觸發或新恢復的成員時立即通知，
+// 沒有變化但距上次通知超過 repeat_interval 時重複通知仍在 firing 的成員。
+// 被靜默的 firing 成員不計入變化也不通知，保持原來的通知狀態，靜默結束後再按正常節奏通知。
+// 需要通知時返回待發送的通知，並先把分組的下一次評估推遲一個 group_interval，發送失敗時即在那時重試。
+func (m *AlertManager) planFlush(ctx context.Context, group *entities.AlertGroup, silences []*entities.Silence, now time.Time) (*groupFlush, error) {
+	route := m.router.Route(group.RouteID)
+	var firing, resolved, newlyFiring []*entities.Alert
+	changed := false
+	for fingerprint, notified := range group.Members {
+		alert, err := m.alerts.GetByFingerprint(ctx, fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load alert %s: %w", fingerprint, err)
+		}
+		if alert != nil {
+			silenced, err := m.markSilenced(ctx, alert, silences, now)
+			if err != nil {
+				return nil, err
+			}
+			if silenced {
+				continue
+			}
+		}
+		switch {
+		case alert == nil:
+			delete(group.Members, fingerprint)
+		case alert.State == entities.AlertStateFiring:
+			firing = append(firing, alert)
+			if notified != entities.AlertStateFiring {
+				newlyFiring = append(newlyFiring, alert)
+				changed = true
+			}
+		case notified == entities.AlertStateFiring && alert.State == entities.AlertStateResolved:
+			resolved = append(resolved, alert)
+			changed = true
+		case notified == entities.AlertStateFiring:
+			// 恢復通知尚未發送就再次進入 pending，等待其觸發或恢復
+		default:
+			// 從未通知過就已恢復的告警直接移出分組
+			delete(group.Members, fingerprint)
+		}
+	}
+
+	repeat := !changed && len(firing) > 0 && !group.LastNotifiedAt.IsZero() &&
+		now.Sub(group.LastNotifiedAt) >= route.RepeatInterval
+	if !changed && !repeat {
+		return nil, m.saveFlushedGroup(ctx, group, now)
+	}
+
+	sortAlerts(firing)
+	sortAlerts(resolved)
+	incidents := m.trackIncidents(ctx, group, firing, resolved, now)
+	flush := &groupFlush{key: group.Key, firing: firing, resolved: resolved, newlyFiring: newlyFiring}
+	flush.sends, flush.errs = m.planNotify(ctx, group, append(firing, resolved...), incidents, repeat)
+
+	group.NextFlushAt = now.Add(route.GroupInterval)
+	group.UpdatedAt = now
+	if err := m.groups.Save(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to save alert group %s: %w", group.Key, err)
+	}
+	return flush, nil
+}
+
+// completeFlush 記錄分組通知的發送結果。全部成功時更新成員的通知狀態並為新觸發的告警開始升級；
+// 成員以重新讀取的狀態為準，因為發送期間 Process 可能加入新成員或改變告警狀態。
+func (m *AlertManager) completeFlush(ctx context.Context, flush *groupFlush, now time.Time) error {
+	errs := flush.errs
+	var notices []incidentNotice
+	for _, s := range flush.sends {
+		plugin := s.target.config.Plugin
+		status := "success"
+		queued, err := m.recordSend(ctx, s)
+		switch {
+		case err != nil:
+			status = "failure"
+			errs = append(errs, fmt.Errorf("receiver %s plugin %s failed for alert %s: %w",
+				s.receiver, plugin, s.notification.Alert.Fingerprint, err))
+		case queued:
+			status = "queued"
+		case s.incident != nil:
+			notices = append(notices, incidentNotice{
+				incidentID:  s.incident.ID,
+				fingerprint: s.notification.Alert.Fingerprint,
+				state:       s.notification.Alert.State,
+				receiver:    s.receiver,
+				plugin:      plugin,
+				recipient:   s.recipient,
+			})
+		}
+		if m.metrics != nil {
+			m.metrics.IncCounter("alert_notifications_total", map[string]string{
+				"receiver": s.receiver, "plugin": plugin, "state": s.notification.Alert.State, "status": status,
+			})
+		}
+	}
+	m.recordIncidentNotices(ctx, notices)
+	if err := errors.Join(errs...); err != nil {
+		m.logger.Error("發送告警通知失敗", "group", flush.key, "error", err)
+		return err
+	}
+
+	group, err := m.groups.Get(ctx, flush.key)
+	if err != nil {
+		return fmt.Errorf("failed to load alert group %s: %w", flush.key, err)
+	}
+	if group == nil {
+		return nil
+	}
+	for _, alert := range flush.firing {
+		if _, ok := group.Members[alert.Fingerprint]; ok {
+			group.Members[alert.Fingerprint] = entities.AlertStateFiring
+		}
+	}
+	for _, alert := range flush.resolved {
+		current, err := m.alerts.GetByFingerprint(ctx, alert.Fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to load alert %s: %w", alert.Fingerprint, err)
+		}
+		if current != nil && current.State == entities.AlertStateFiring {
+			// 恢復通知發送期間再次觸發，作為新觸發的成員等待下一次通知
+			group.Members[alert.Fingerprint] = ""
+			continue
+		}
+		delete(group.Members, alert.Fingerprint)
+	}
+	group.LastNotifiedAt = now
+	for _, alert := range flush.newlyFiring {
+		current, err := m.alerts.GetByFingerprint(ctx, alert.Fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to load alert %s: %w", alert.Fingerprint, err)
+		}
+		if current == nil || current.State != entities.AlertStateFiring {
+			continue
+		}
+		if err := m.startEscalation(ctx, group.Receiver, current, now); err != nil {
+			return err
+		}
+	}
+	return m.saveFlushedGroup(ctx, group, now)
+}
+
+// saveFlushedGroup 保存評估後的分組，沒有成員的分組直接刪除
+func (m *AlertManager) saveFlushedGroup(ctx context.Context, group *entities.AlertGroup, now time.Time) error {
+	if len(group.Members) == 0 {
+		if err := m.groups.Delete(ctx, group.Key); err != nil {
+			return fmt.Errorf("failed to delete alert group %s: %w", group.Key, err)
+		}
+		return nil
+	}
+	group.NextFlushAt = now.Add(m.router.Route(group.RouteID).GroupInterval)
+	group.UpdatedAt = now
+	if err := m.groups.Save(ctx, group); err != nil {
+		return fmt.Errorf("failed to save alert group %s: %w", group.Key, err)
+	}
+	return nil
+}
+
+// markSilenced 判斷 firing 告警是否被靜默，並在抑制它的靜默變化時更新告警的 SilencedBy
+func (m *AlertManager) markSilenced(ctx context.Context, alert *entities.Alert, silences []*entities.Silence, now time.Time) (bool, error) {
+	var ids []string
+	if alert.State == entities.AlertStateFiring {
+		ids = matchingSilences(silences, alert)
+	}
+	if !equalStrings(ids, alert.SilencedBy) {
+		if len(ids) > 0 && len(alert.SilencedBy) == 0 {
+			m.logger.Info("告警通知已被靜默", "fingerprint", alert.Fingerprint, "detector_id", alert.DetectorID, "silences", ids)
+			if m.metrics != nil {
+				m.metrics.IncCounter("alert_notifications_silenced_total", map[string]string{"detector_id": alert.DetectorID})
+			}
+		}
+		alert.SilencedBy = ids
+		if err := m.alerts.Save(ctx, alert); err != nil {
+			return false, fmt.Errorf("failed to save alert %s: %w", alert.Fingerprint, err)
+		}
+	}
+	return len(ids) > 0, nil
+}
+
+// trackIncidents 把即將通知的 firing 告警歸入事件單，並查找恢復告警所屬的事件單，返回指紋對應的事件單。
+// 加入已確認事件單的告警同樣視為已確認，不會開始升級。事件單的錯誤只記錄日誌，不影響通知。
+func (m *AlertManager) trackIncidents(ctx context.Context, group *entities.AlertGroup, firing, resolved []*entities.Alert,
+	now time.Time) map[string]*entities.Incident {
+	if m.incidents == nil {
+		return nil
+	}
+	incidents := make(map[string]*entities.Incident, len(firing)+len(resolved))
+	for _, alert := range firing {
+		incident, err := m.incidents.track(ctx, group.Key, alert, now)
+		if err != nil {
+			m.logger.Error("將告警歸入事件單失敗", "fingerprint", alert.Fingerprint, "group", group.Key, "error", err)
+			continue
+		}
+		incidents[alert.Fingerprint] = incident
+		if incident.State != entities.IncidentStateAcknowledged || alert.IsAcknowledged() {
+			continue
+		}
+		alert.AcknowledgedAt = now
+		alert.AcknowledgedBy = incident.AcknowledgedBy
+		if alert.Escalation.Pending() {
+			alert.Escalation.NextAt = time.Time{}
+		}
+		alert.UpdatedAt = now
+		if err := m.alerts.Save(ctx, alert); err != nil {
+			m.logger.Error("保存告警確認狀態失敗", "fingerprint", alert.Fingerprint, "incident_id", incident.ID, "error", err)
+		}
+	}
+	for _, alert := range resolved {
+		incident, err := m.incidents.latest(ctx, alert.Fingerprint)
+		if err != nil {
+			m.logger.Error("查找告警所屬的事件單失敗", "fingerprint", alert.Fingerprint, "error", err)
+			continue
+		}
+		if incident != nil {
+			incidents[alert.Fingerprint] = incident
+		}
+	}
+	return incidents
+}
+
+// incidentNotification 為通知附帶事件單 ID，事件單尚未解決的 firing 通知另附給 recipient 的確認鏈接
+func (m *AlertManager) incidentNotification(notification *entities.AlertNotification, incident *entities.Incident,
+	recipient string) *entities.AlertNotification {
+	if incident == nil {
+		return notification
+	}
+	n := *notification
+	n.IncidentID = incident.ID
+	if n.Alert.State == entities.AlertStateFiring && !incident.IsResolved() {
+		n.AcknowledgeURL = m.incidents.AcknowledgeURL(incident.ID, recipient)
+	}
+	return &n
+}
+
+// recordIncidentNotices 把成功發送的通知記入事件單時間線，失敗只記錄日誌
+func (m *AlertManager) recordIncidentNotices(ctx context.Context, notices []incidentNotice) {
+	if m.incidents == nil || len(notices) == 0 {
+		return
+	}
+	if err := m.incidents.recordNotifications(ctx, notices, m.now()); err != nil {
+		m.logger.Error("記錄事件單通知失敗", "error", err)
+	}
+}
+
+// planNotify 為分組中需要通知的告警在接收者的每個通知渠道上準備發送，incidents 為告警指紋對應的事件單。
+// 返回的錯誤是無法解析的渠道或值班者，這些渠道不會發送。
+func (m *AlertManager) planNotify(ctx context.Context, group *entities.AlertGroup, alerts []*entities.Alert,
+	incidents map[string]*entities.Incident, repeat bool) ([]*pendingSend, []error) {
+	receiver, ok := m.router.Receiver(group.Receiver)
+	if !ok {
+		// 接收者已從配置中移除，改用路由目前的接收者
+		route := m.router.Route(group.RouteID)
+		m.logger.Warn("分組的接收者已不存在，改用路由的接收者", "group", group.Key, "receiver", group.Receiver,
+			"route_receiver", route.Receiver)
+		receiver, _ = m.router.Receiver(route.Receiver)
+	}
+
+	var (
+		sends []*pendingSend
+		errs  []error
+	)
+	for _, integration := range receiver.Integrations {
+		target, err := m.resolveIntegration(integration)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("receiver %s: %w", receiver.Name, err))
+			continue
+		}
+		recipients := []string{integration.Recipient}
+		if integration.OnCall != "" {
+			if recipients, err = m.onCallRecipients(ctx, integration.OnCall); err != nil {
+				errs = append(errs, fmt.Errorf("receiver %s plugin %s: %w", receiver.Name, integration.Plugin, err))
+				continue
+			}
+		}
+		for _, alert := range alerts {
+			notification := &entities.AlertNotification{
+				Receiver:    receiver.Name,
+				GroupKey:    group.Key,
+				GroupLabels: group.Labels,
+				Alert:       *alert,
+				Repeat:      repeat,
+			}
+			incident := incidents[alert.Fingerprint]
+			for _, recipient := range recipients {
+				sends = append(sends, &pendingSend{
+					target:       target,
+					receiver:     receiver.Name,
+					recipient:    recipient,
+					notification: m.incidentNotification(notification, incident, recipient),
+					incident:     incident,
+				})
+			}
+		}
+	}
+	return sends, errs
+}
+
+// onCallRecipients 返回團隊當前值班者的郵件地址，沒有值班者時返回錯誤，分組會在下一個 group_interval 重試
+func (m *AlertManager) onCallRecipients(ctx context.Context, team string) ([]string, error) {
+	if m.oncall == nil {
+		return nil, fmt.Errorf("on-call team %s requires the on-call service", team)
+	}
+	users, err := m.oncall.CurrentOnCall(ctx, team, m.now())
+	if err != nil {
+		return nil, err
+	}
+	var recipients []string
+	for _, user := range users {
+		if user.Email != "" {
+			recipients = append(recipients, user.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no one is on call for team %s", team)
+	}
+	return recipients, nil
+}
+
+// startEscalation 在告警第一次通知到設置了升級策略的接收者後開始升級計時。
+// 只有嚴重程度需要升級、尚未確認且尚未開始升級的告警會開始升級。
+func (m *AlertManager) startEscalation(ctx context.Context, receiverName string, alert *entities.Alert, now time.Time) error {
+	if m.oncall == nil || alert.Escalation != nil || alert.IsAcknowledged() ||
+		!m.escalationSeverities[strings.ToLower(alert.Severity)] {
+		return nil
+	}
+	receiver, ok := m.router.Receiver(receiverName)
+	if !ok || receiver.EscalationPolicy == "" {
+		return nil
+	}
+	policy, err := m.oncall.PolicyByName(ctx, receiver.EscalationPolicy)
+	if err != nil {
+		return err
+	}
+	if policy == nil || len(policy.Levels) == 0 {
+		m.logger.Warn("接收者引用的升級策略不存在", "receiver", receiver.Name, "policy", receiver.EscalationPolicy)
+		return nil
+	}
+
+	alert.Escalation = &entities.AlertEscalation{
+		Policy:   policy.Name,
+		Receiver: receiver.Name,
+		NextAt:   now.Add(policy.Levels[0].Delay),
+	}
+	alert.UpdatedAt = now
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save alert %s: %w", alert.Fingerprint, err)
+	}
+	m.logger.Info("告警開始升級計時", "fingerprint", alert.Fingerprint, "policy", policy.Name, "next_at", alert.Escalation.NextAt)
+	return nil
+}
+
+// planEscalations 為升級時間已到且仍未確認的 firing 告警準備下一層目標的通知。
+// 被靜默的告警暫停升級，靜默結束後立即通知到期的層級。
+func (m *AlertManager) planEscalations(ctx context.Context, now time.Time) ([]*escalationSend, error) {
+	firing, err := m.alerts.ListByState(ctx, entities.AlertStateFiring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firing alerts: %w", err)
+	}
+	var (
+		escalations []*escalationSend
+		errs        []error
+	)
+	for _, alert := range firing {
+		if !alert.Escalation.Pending() || alert.IsAcknowledged() || alert.Escalation.NextAt.After(now) ||
+			len(alert.SilencedBy) > 0 {
+			continue
+		}
+		escalation, err := m.planEscalation(ctx, alert, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if escalation != nil {
+			escalations = append(escalations, escalation)
+		}
+	}
+	return escalations, errors.Join(errs...)
+}
+
+// planEscalation 解析告警當前升級層級的用戶，並準備經接收者中實現 NotificationPlugin 的渠道以郵件地址通知。
+// 策略或接收者已不存在時停止升級並返回 nil。
+func (m *AlertManager) planEscalation(ctx context.Context, alert *entities.Alert, now time.Time) (*escalationSend, error) {
+	escalation := alert.Escalation
+	policy, err := m.oncall.PolicyByName(ctx, escalation.Policy)
+	if err != nil {
+		return nil, err
+	}
+	receiver, ok := m.router.Receiver(escalation.Receiver)
+	if policy == nil || !ok || escalation.Level >= len(policy.Levels) {
+		m.logger.Warn("升級策略或接收者已不存在，停止升級", "fingerprint", alert.Fingerprint, "policy", escalation.Policy,
+			"receiver", escalation.Receiver)
+		escalation.NextAt = time.Time{}
+		return nil, m.saveEscalation(ctx, alert, now)
+	}
+
+	level := escalation.Level + 1
+	users, err := m.oncall.ResolveTargets(ctx, policy.Levels[escalation.Level].Targets, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve escalation targets of alert %s: %w", alert.Fingerprint, err)
+	}
+	notification := &entities.AlertNotification{
+		Receiver:        receiver.Name,
+		GroupLabels:     map[string]string{},
+		Alert:           *alert,
+		EscalationLevel: level,
+	}
+	var incident *entities.Incident
+	if m.incidents != nil {
+		if incident, err = m.incidents.latest(ctx, alert.Fingerprint); err != nil {
+			m.logger.Error("查找告警所屬的事件單失敗", "fingerprint", alert.Fingerprint, "error", err)
+		}
+	}
+
+	plan := &escalationSend{alert: alert, policy: policy, receiver: receiver.Name, level: level}
+	for _, integration := range receiver.Integrations {
+		target, err := m.resolveIntegration(integration)
+		if err != nil {
+			plan.errs = append(plan.errs, fmt.Errorf("receiver %s: %w", receiver.Name, err))
+			continue
+		}
+		if target.notification == nil {
+			continue
+		}
+		for _, user := range users {
+			if user.Email == "" {
+				continue
+			}
+			plan.sends = append(plan.sends, &pendingSend{
+				target:       target,
+				receiver:     receiver.Name,
+				recipient:    user.Email,
+				notification: m.incidentNotification(notification, incident, user.Email),
+				incident:     incident,
+			})
+		}
+	}
+	return plan, nil
+}
+
+// completeEscalation 記錄升級通知的發送結果並推進到下一層；全部發送失敗時保持層級，下一輪評估重試。
+// 發送期間告警已恢復、已確認或升級進度已改變時不再推進。
+func (m *AlertManager) completeEscalation(ctx context.Context, plan *escalationSend, now time.Time) error {
+	alert := plan.alert
+	errs := plan.errs
+	var (
+		sent    int
+		notices []incidentNotice
+	)
+	for _, s := range plan.sends {
+		queued, err := m.recordSend(ctx, s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("escalation of alert %s to %s via %s failed: %w",
+				alert.Fingerprint, s.recipient, s.target.config.Plugin, err))
+			continue
+		}
+		// 排入重試的升級通知視為已交出，升級照常推進
+		sent++
+		if s.incident != nil && !queued {
+			notices = append(notices, incidentNotice{
+				incidentID:      s.incident.ID,
+				fingerprint:     alert.Fingerprint,
+				state:           alert.State,
+				receiver:        s.receiver,
+				plugin:          s.target.config.Plugin,
+				recipient:       s.recipient,
+				escalationLevel: plan.level,
+			})
+		}
+	}
+	m.recordIncidentNotices(ctx, notices)
+	if sent == 0 && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	policy := plan.policy
+	if sent == 0 {
+		m.logger.Warn("升級層級沒有可通知的用戶或渠道", "fingerprint", alert.Fingerprint, "policy", policy.Name, "level", plan.level,
+			"receiver", plan.receiver)
+	}
+	if m.metrics != nil {
+		m.metrics.IncCounter("alert_escalations_total", map[string]string{"policy": policy.Name, "level": fmt.Sprint(plan.level)})
+	}
+	m.logger.Info("告警已升級", "fingerprint", alert.Fingerprint, "policy", policy.Name, "level", plan.level, "notified", sent)
+
+	current, err := m.alerts.GetByFingerprint(ctx, alert.Fingerprint)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to load alert %s: %w", alert.Fingerprint, err))...)
+	}
+	if current == nil || current.State != entities.AlertStateFiring || current.IsAcknowledged() ||
+		!current.Escalation.Pending() || current.Escalation.Level != alert.Escalation.Level ||
+		current.Escalation.Cycle != alert.Escalation.Cycle {
+		return errors.Join(errs...)
+	}
+
+	escalation := current.Escalation
+	escalation.LastEscalatedAt = now
+	escalation.Level++
+	switch {
+	case escalation.Level < len(policy.Levels):
+		escalation.NextAt = now.Add(policy.Levels[escalation.Level].Delay)
+	case escalation.Cycle < policy.RepeatCount:
+		escalation.Cycle++
+		escalation.Level = 0
+		escalation.NextAt = now.Add(policy.Levels[0].Delay)
+	default:
+		escalation.NextAt = time.Time{}
+	}
+	if err := m.saveEscalation(ctx, current, now); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// recordSend 記錄一次已完成的發送。啟用投遞記錄時記錄這次投遞，
+// 發送失敗並排入重試時返回 queued 為 true 且不返回錯誤；未啟用時返回發送的錯誤。
+func (m *AlertManager) recordSend(ctx context.Context, s *pendingSend) (queued bool, err error) {
+	if m.deliveries == nil {
+		return false, s.err
+	}
+	if m.deliveries.record(ctx, s.target, s.receiver, s.recipient, s.notification, s.err) {
+		m.logger.Warn("通知發送失敗，已排入重試", "fingerprint", s.notification.Alert.Fingerprint, "receiver", s.receiver,
+			"plugin", s.target.config.Plugin, "error", s.err)
+		return true, nil
+	}
+	return false, s.err
+}
+
+// planRetries 準備到期的失敗投遞重試。告警已不存在或狀態與通知不同 (例如 firing 通知重試前告警已恢復)，
+// 以及升級通知重試前告警已被確認時，放棄過時的通知。
+func (m *AlertManager) planRetries(ctx context.Context, now time.Time) ([]*deliveryRetry, error) {
+	due, err := m.deliveries.due(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		retries []*deliveryRetry
+		errs    []error
+	)
+	for _, delivery := range due {
+		notification := delivery.Notification
+		alert, err := m.alerts.GetByFingerprint(ctx, delivery.Fingerprint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load alert %s: %w", delivery.Fingerprint, err))
+			continue
+		}
+		if notification == nil || alert == nil || alert.State != notification.Alert.State ||
+			(notification.EscalationLevel > 0 && alert.IsAcknowledged()) {
+			if err := m.deliveries.supersede(ctx, delivery, now); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		retries = append(retries, &deliveryRetry{delivery: delivery, send: m.redeliverySend(delivery)})
+	}
+	return retries, errors.Join(errs...)
+}
+
+// redeliver 按投遞記錄的渠道設定重新發送通知，成功時把附帶事件單的通知記入事件單時間線
+func (m *AlertManager) redeliver(ctx context.Context, delivery *entities.NotificationDelivery) error {
+	s := m.redeliverySend(delivery)
+	s.send(ctx)
+	return m.redelivered(ctx, delivery, s.err)
+}
+
+// redeliverySend 按投遞記錄的渠道設定準備重新發送
+func (m *AlertManager) redeliverySend(delivery *entities.NotificationDelivery) *pendingSend {
+	s := &pendingSend{receiver: delivery.Receiver, recipient: delivery.Recipient, notification: delivery.Notification}
+	if delivery.Notification == nil {
+		s.err = fmt.Errorf("delivery %s has no notification", delivery.ID)
+		return s
+	}
+	s.target, s.err = m.resolveIntegration(IntegrationConfig{
+		Plugin:    delivery.Plugin,
+		Recipient: delivery.Recipient,
+		Settings:  delivery.Settings,
+	})
+	return s
+}
+
+// redelivered 在重新發送成功時把附帶事件單的通知記入事件單時間線，返回發送的錯誤
+func (m *AlertManager) redelivered(ctx context.Context, delivery *entities.NotificationDelivery, sendErr error) error {
+	if sendErr != nil {
+		return sendErr
+	}
+	if notification := delivery.Notification; notification.IncidentID != "" {
+		m.recordIncidentNotices(ctx, []incidentNotice{{
+			incidentID:      notification.IncidentID,
+			fingerprint:     delivery.Fingerprint,
+			state:           notification.Alert.State,
+			receiver:        delivery.Receiver,
+			plugin:          delivery.Plugin,
+			recipient:       delivery.Recipient,
+			escalationLevel: notification.EscalationLevel,
+		}})
+	}
+	return nil
+}
+
+// saveEscalation 保存告警的升級進度
+func (m *AlertManager) saveEscalation(ctx context.Context, alert *entities.Alert, now time.Time) error {
+	alert.UpdatedAt = now
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return fmt.Errorf("failed to save alert %s: %w", alert.Fingerprint, err)
+	}
+	return nil
+}
+
+// Acknowledge 確認 pending 或 firing 的告警並停止其升級；已確認的告警保持原來的確認者。
+// 告警恢復後再次觸發時需要重新確認。
+func (m *AlertManager) Acknowledge(ctx context.Context, fingerprint, by string) (*entities.Alert, error) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	alert, err := m.alerts.GetByFingerprint(ctx, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert %s: %w", fingerprint, err)
+	}
+	if alert == nil {
+		return nil, domainerrors.NewNotFoundError("alert", fmt.Sprintf("告警不存在: %s", fingerprint))
+	}
+	if !alert.IsActive() {
+		return nil, domainerrors.NewValidationError("state", "只能確認 pending 或 firing 的告警")
+	}
+	if alert.IsAcknowledged() {
+		return alert, nil
+	}
+
+	now := m.now()
+	alert.AcknowledgedAt = now
+	alert.AcknowledgedBy = by
+	if alert.Escalation.Pending() {
+		alert.Escalation.NextAt = time.Time{}
+	}
+	alert.UpdatedAt = now
+	if err := m.alerts.Save(ctx, alert); err != nil {
+		return nil, fmt.Errorf("failed to save alert %s: %w", fingerprint, err)
+	}
+	m.logger.Info("告警已確認", "fingerprint", fingerprint, "detector_id", alert.DetectorID, "by", by)
+	if m.metrics != nil {
+		tags := map[string]string{"detector_id": alert.DetectorID}
+		m.metrics.IncCounter("alert_acknowledgements_total", tags)
+		// 確認耗時從觸發算起，pending 中被確認的告警從第一次出現算起
+		since := alert.FiredAt
+		if since.IsZero() {
+			since = alert.StartsAt
+		}
+		m.metrics.ObserveHistogram("alert_time_to_acknowledge_seconds", now.Sub(since).Seconds(), tags)
+	}
+	return alert, nil
+}
+
+// resolveIntegration 從註冊表解析通知渠道使用的插件
+func (m *AlertManager) resolveIntegration(integration IntegrationConfig) (*integrationTarget, error) {
+	p, err := m.registry.Get(integration.Plugin)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s not found: %w", integration.Plugin, err)
+	}
+	if p == any(m) {
+		return nil, fmt.Errorf("alert manager cannot notify itself")
+	}
+	return newIntegrationTarget(integration, p)
+}
+
+// routingLabels 返回用於路由匹配的標籤：告警的匹配標籤加上檢測器擁有者。
+// 查找擁有者失敗不影響路由，只是 owner 標籤為空。
+func (m *AlertManager) routingLabels(ctx context.Context, alert *entities.Alert) map[string]string {
+	labels := alert.MatchLabels()
+	if _, ok := labels[entities.AlertLabelOwner]; ok || m.detectors == nil {
+		return labels
+	}
+	id, err := valueobjects.NewIDVO(alert.DetectorID)
+	if err != nil {
+		return labels
+	}
+	detector, err := m.detectors.GetByID(ctx, id)
+	if err != nil {
+		m.logger.Warn("查找檢測器擁有者失敗", "detector_id", alert.DetectorID, "error", err)
+		return labels
+	}
+	if detector != nil && detector.OwnerID != "" {
+		labels[entities.AlertLabelOwner] = detector.OwnerID
+	}
+	return labels
+}
+
+// RouteTestResult 描述一個樣本告警的路由結果
+type RouteTestResult struct {
+	Labels     map[string]string `json:"labels"`               // 參與匹配的標籤，包含 severity 與查找到的 owner
+	SilencedBy []string          `json:"silencedBy,omitempty"` // 目前會抑制此告警的靜默，非空時不會發送 firing 通知
+	Routes     []RouteMatch      `json:"routes"`               // 匹配到的路由
+}
+
+// RouteMatch 描述樣本告警匹配到的一個路由
+type RouteMatch struct {
+	RouteID          string              `json:"routeId"`
+	Receiver         string              `json:"receiver"`
+	EscalationPolicy string              `json:"escalationPolicy,omitempty"`
+	Integrations     []IntegrationConfig `json:"integrations"`
+	GroupKey         string              `json:"groupKey"`
+	GroupLabels      map[string]string   `json:"groupLabels"`
+	GroupWait        string              `json:"groupWait"`
+	GroupInterval    string              `json:"groupInterval"`
+	RepeatInterval   string              `json:"repeatInterval"`
+}
+
+// TestRoute 返回具有指定標籤與嚴重程度的告警會到達的接收者，不會創建告警或發送通知
+func (m *AlertManager) TestRoute(ctx context.Context, detectorID, severity string, labels map[string]string) (*RouteTestResult, error) {
+	alert := &entities.Alert{DetectorID: detectorID, Severity: severity, State: entities.AlertStateFiring,
+		Labels: make(map[string]string, len(labels)+1)}
+	for k, v := range labels {
+		alert.Labels[k] = v
+	}
+	if detectorID != "" {
+		alert.Labels[entities.AlertLabelDetectorID] = detectorID
+	}
+	routing := m.routingLabels(ctx, alert)
+
+	result := &RouteTestResult{Labels: routing, Routes: []RouteMatch{}}
+	if m.silences != nil {
+		silences, err := m.silences.Active(ctx, m.now())
+		if err != nil {
+			return nil, err
+		}
+		result.SilencedBy = matchingSilences(silences, alert)
+	}
+	for _, route := range m.router.Match(routing) {
+		receiver, _ := m.router.Receiver(route.Receiver)
+		groupLabels := route.GroupLabels(routing)
+		// 不返回渠道設定，其中可能包含密鑰
+		integrations := make([]IntegrationConfig, 0, len(receiver.Integrations))
+		for _, integration := range receiver.Integrations {
+			integrations = append(integrations, IntegrationConfig{Plugin: integration.Plugin, Recipient: integration.Recipient,
+				OnCall: integration.OnCall})
+		}
+		result.Routes = append(result.Routes, RouteMatch{
+			RouteID:          route.ID,
+			Receiver:         route.Receiver,
+			EscalationPolicy: receiver.EscalationPolicy,
+			Integrations:     integrations,
+			GroupKey:         entities.AlertGroupKey(route.ID, route.Receiver, groupLabels),
+			GroupLabels:      groupLabels,
+			GroupWait:        route.GroupWait.String(),
+			GroupInterval:    route.GroupInterval.String(),
+			RepeatInterval:   route.RepeatInterval.String(),
+		})
+	}
+	return result, nil
+}
+
+// transition 記錄狀態轉換指標
+func (m *AlertManager) transition(state, detectorID string) {
+	if m.metrics != nil {
+		m.metrics.IncCounter("alert_transitions_total", map[string]string{"state": state, "detector_id": detectorID})
+	}
+}
+
+// sortAlerts 按開始時間與指紋排序，使通知順序穩定
+func sortAlerts(alerts []*entities.Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].StartsAt.Equal(alerts[j].StartsAt) {
+			return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+		}
+		return alerts[i].Fingerprint < alerts[j].Fingerprint
+	})
+}
+
+// equalStrings 比較兩個字串切片，nil 與空切片視為相同
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyData 複製分析結果數據，避免告警狀態引用調用方的 map
+func copyData(data map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	return copied
+}
+
+// parseDurationDefault 解析正數時間長度，空字串時使用默認值
+func parseDurationDefault(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %s", value)
+	}
+	return d, nil
+}
+
+// parseOptionalDuration 解析允許為零的時間長度，空字串時使用默認值
+func parseOptionalDuration(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative: %s", value)
+	}
+	return d, nil
+}
+
+// 確保實現了 AlertResolverPlugin 介面
+var _ plugins.AlertResolverPlugin = (*AlertManager)(nil)
@@ -0,0 +1,615 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/registry"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type memoryAlertRepo struct {
+	mu     sync.Mutex
+	alerts map[string]entities.Alert
+}
+
+func (r *memoryAlertRepo) Save(ctx context.Context, alert *entities.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts[alert.Fingerprint] = *alert
+	return nil
+}
+
+func (r *memoryAlertRepo) GetByFingerprint(ctx context.Context, fingerprint string) (*entities.Alert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alert, ok := r.alerts[fingerprint]
+	if !ok {
+		return nil, nil
+	}
+	return &alert, nil
+}
+
+func (r *memoryAlertRepo) ListByState(ctx context.Context, states ...string) ([]*entities.Alert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.Alert
+	for _, alert := range r.alerts {
+		for _, state := range states {
+			if alert.State == state {
+				a := alert
+				out = append(out, &a)
+			}
+		}
+	}
+	return out, nil
+}
+
+type memoryGroupRepo struct {
+	mu     sync.Mutex
+	groups map[string]entities.AlertGroup
+}
+
+func (r *memoryGroupRepo) Save(ctx context.Context, group *entities.AlertGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g := *group
+	g.Members = make(map[string]string, len(group.Members))
+	for k, v := range group.Members {
+		g.Members[k] = v
+	}
+	r.groups[group.Key] = g
+	return nil
+}
+
+func (r *memoryGroupRepo) Get(ctx context.Context, key string) (*entities.AlertGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[key]
+	if !ok {
+		return nil, nil
+	}
+	return &g, nil
+}
+
+func (r *memoryGroupRepo) ListDue(ctx context.Context, now time.Time) ([]*entities.AlertGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.AlertGroup
+	for _, g := range r.groups {
+		if !g.NextFlushAt.After(now) {
+			group := g
+			out = append(out, &group)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryGroupRepo) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.groups, key)
+	return nil
+}
+
+// recordingAlert 記錄收到的通知
+type recordingAlert struct {
+	mu            sync.Mutex
+	fail          bool
+	started       chan struct{} // 不為 nil 時通知測試已進入發送，並等待 release
+	release       chan struct{}
+	notifications []*entities.AlertNotification
+	results       []*entities.AnalysisResult
+}
+
+func (a *recordingAlert) GetName() string                                            { return "recorder" }
+func (a *recordingAlert) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (a *recordingAlert) Start(ctx context.Context) error                            { return nil }
+func (a *recordingAlert) Stop(ctx context.Context) error                             { return nil }
+
+func (a *recordingAlert) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, cfg map[string]interface{}) error {
+	if a.started != nil {
+		a.started <- struct{}{}
+		<-a.release
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.fail {
+		return errors.New("receiver down")
+	}
+	a.notifications = append(a.notifications, plugins.AlertNotificationFromConfig(cfg))
+	a.results = append(a.results, result)
+	return nil
+}
+
+// take 返回並清空已記錄的通知
+func (a *recordingAlert) take() []*entities.AlertNotification {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := a.notifications
+	a.notifications = nil
+	a.results = nil
+	return out
+}
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type fixture struct {
+	alerts   *memoryAlertRepo
+	groups   *memoryGroupRepo
+	silences *memorySilenceRepo
+	recorder *recordingAlert
+	clock    *testClock
+	registry contracts.PluginRegistryProvider
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		alerts:   &memoryAlertRepo{alerts: map[string]entities.Alert{}},
+		groups:   &memoryGroupRepo{groups: map[string]entities.AlertGroup{}},
+		silences: &memorySilenceRepo{silences: map[string]entities.Silence{}},
+		recorder: &recordingAlert{},
+		clock:    &testClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		registry: registry.NewPluginRegistryProvider(&testLogger{}),
+	}
+	if err := f.registry.Register("recorder", f.recorder); err != nil {
+		t.Fatalf("register recorder: %v", err)
+	}
+	return f
+}
+
+func (f *fixture) manager(t *testing.T, config AlertManagerConfig) *AlertManager {
+	t.Helper()
+	if len(config.Receivers) == 0 {
+		config.Receivers = []ReceiverConfig{{Name: DefaultReceiver, Integrations: []IntegrationConfig{{Plugin: "recorder"}}}}
+	}
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, Silences: f.silenceService()}, config, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+	return m
+}
+
+func (f *fixture) silenceService() *SilenceService {
+	s := NewSilenceService(f.silences, &testLogger{})
+	s.now = f.clock.Now
+	return s
+}
+
+func result(detectorID string, anomalous bool, labels map[string]interface{}) *entities.AnalysisResult {
+	return &entities.AnalysisResult{
+		ID:         detectorID + "-result",
+		DetectorID: detectorID,
+		Summary:    "cpu above threshold",
+		Severity:   "high",
+		Data: map[string]interface{}{
+			entities.AnalysisDataKeyAnomalous: anomalous,
+			entities.AnalysisDataKeyLabels:    labels,
+			"value":                           97.5,
+		},
+	}
+}
+
+func states(notifications []*entities.AlertNotification) []string {
+	var out []string
+	for _, n := range notifications {
+		out = append(out, n.Alert.Labels["host"]+":"+n.Alert.State)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func tick(t *testing.T, m *AlertManager) {
+	t.Helper()
+	if err := m.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick() error = %v", err)
+	}
+}
+
+func process(t *testing.T, m *AlertManager, results ...*entities.AnalysisResult) {
+	t.Helper()
+	if err := m.Process(context.Background(), results); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+}
+
+func TestAlertManager_LifecycleAndTimers(t *testing.T) {
+	f := newFixture(t)
+	m := f.manager(t, AlertManagerConfig{
+		GroupWait:      "30s",
+		GroupInterval:  "5m",
+		RepeatInterval: "1h",
+		ResolveTimeout: "0s",
+	})
+	host := map[string]interface{}{"host": "a"}
+
+	// 重複的異常結果合併為同一個告警
+	process(t, m, result("cpu", true, host), result("cpu", true, host))
+	if len(f.alerts.alerts) != 1 {
+		t.Fatalf("expected 1 deduplicated alert, got %d", len(f.alerts.alerts))
+	}
+
+	f.clock.Advance(10 * time.Second)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("notified before group_wait: %v", states(got))
+	}
+
+	f.clock.Advance(20 * time.Second)
+	tick(t, m)
+	got := f.recorder.take()
+	if len(got) != 1 || got[0].Alert.State != entities.AlertStateFiring || got[0].Repeat {
+		t.Fatalf("expected one firing notification after group_wait, got %+v", got)
+	}
+	if got[0].GroupLabels[entities.AlertLabelDetectorID] != "cpu" || got[0].Alert.Labels["host"] != "a" {
+		t.Errorf("unexpected labels: group %v alert %v", got[0].GroupLabels, got[0].Alert.Labels)
+	}
+
+	// 沒有變化時 group_interval 到期也不會重複通知
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("unexpected notification without changes: %v", states(got))
+	}
+
+	// repeat_interval 到期後重複通知
+	f.clock.Advance(55 * time.Minute)
+	tick(t, m)
+	got = f.recorder.take()
+	if len(got) != 1 || !got[0].Repeat {
+		t.Fatalf("expected repeat notification, got %+v", got)
+	}
+
+	// 恢復在下一個 group_interval 發送，之後分組被移除
+	process(t, m, result("cpu", false, host))
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("resolve sent before group_interval: %v", states(got))
+	}
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	f.recorder.mu.Lock()
+	resolvedResult := f.recorder.results
+	f.recorder.mu.Unlock()
+	got = f.recorder.take()
+	if len(got) != 1 || got[0].Alert.State != entities.AlertStateResolved {
+		t.Fatalf("expected resolved notification, got %+v", got)
+	}
+	if resolvedResult[0].IsAnomalous() {
+		t.Error("resolved notification should carry a non-anomalous result")
+	}
+	if len(f.groups.groups) != 0 {
+		t.Errorf("expected empty group to be deleted, got %d groups", len(f.groups.groups))
+	}
+}
+
+func TestAlertManager_PendingForAndResolveTimeout(t *testing.T) {
+	f := newFixture(t)
+	m := f.manager(t, AlertManagerConfig{
+		PendingFor:     "2m",
+		GroupWait:      "1s",
+		ResolveTimeout: "5m",
+	})
+	host := map[string]interface{}{"host": "a"}
+
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(time.Minute)
+	tick(t, m)
+	alert := f.alerts.alerts[entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "a"})]
+	if alert.State != entities.AlertStatePending {
+		t.Fatalf("expected pending before pendingFor, got %s", alert.State)
+	}
+
+	// 短暫異常在達到 pendingFor 前恢復，不會通知
+	process(t, m, result("cpu", false, host))
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("pending alert should not notify: %v", states(got))
+	}
+
+	// 持續異常達到 pendingFor 後由評估循環轉為 firing
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(2 * time.Minute)
+	tick(t, m)
+	f.clock.Advance(time.Second)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 1 || got[0].Alert.State != entities.AlertStateFiring {
+		t.Fatalf("expected firing after pendingFor, got %v", states(got))
+	}
+
+	// 超過 resolveTimeout 未再收到異常即自動恢復
+	f.clock.Advance(6 * time.Minute)
+	tick(t, m)
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 1 || got[0].Alert.State != entities.AlertStateResolved {
+		t.Fatalf("expected resolve after timeout, got %v", states(got))
+	}
+}
+
+func TestAlertManager_Grouping(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		groupBy []string
+		flushes int
+	}{
+		{"by detector", nil, 1},
+		{"by host", []string{"host"}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			m := f.manager(t, AlertManagerConfig{GroupBy: tc.groupBy, GroupWait: "30s"})
+			process(t, m,
+				result("cpu", true, map[string]interface{}{"host": "a"}),
+				result("cpu", true, map[string]interface{}{"host": "b"}),
+			)
+			if len(f.groups.groups) != tc.flushes {
+				t.Fatalf("expected %d groups, got %d", tc.flushes, len(f.groups.groups))
+			}
+			f.clock.Advance(30 * time.Second)
+			tick(t, m)
+			got := f.recorder.take()
+			if want := []string{"a:firing", "b:firing"}; len(got) != 2 || states(got)[0] != want[0] || states(got)[1] != want[1] {
+				t.Fatalf("notifications = %v, want %v", states(got), want)
+			}
+			keys := map[string]bool{}
+			for _, n := range got {
+				keys[n.GroupKey] = true
+			}
+			if len(keys) != tc.flushes {
+				t.Errorf("expected notifications from %d groups, got %d", tc.flushes, len(keys))
+			}
+		})
+	}
+}
+
+// 渠道發送在 stateMu 之外進行，緩慢的渠道不會阻塞結果處理與確認，發送結果也不會覆蓋期間的變化
+func TestAlertManager_SlowReceiverDoesNotBlockProcessing(t *testing.T) {
+	f := newFixture(t)
+	m := f.manager(t, AlertManagerConfig{GroupWait: "0s", ResolveTimeout: "0s"})
+	ctx := context.Background()
+	process(t, m, result("cpu", true, map[string]interface{}{"host": "a"}))
+	var fingerprint string
+	for fp := range f.alerts.alerts {
+		fingerprint = fp
+	}
+
+	f.recorder.started = make(chan struct{})
+	f.recorder.release = make(chan struct{})
+	ticked := make(chan error, 1)
+	go func() { ticked <- m.Tick(ctx) }()
+	select {
+	case <-f.recorder.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("receiver was not called")
+	}
+
+	handled := make(chan error, 1)
+	go func() {
+		if err := m.Process(ctx, []*entities.AnalysisResult{result("cpu", true, map[string]interface{}{"host": "b"})}); err != nil {
+			handled <- err
+			return
+		}
+		_, err := m.Acknowledge(ctx, fingerprint, "alice")
+		handled <- err
+	}()
+	select {
+	case err := <-handled:
+		if err != nil {
+			t.Fatalf("Process/Acknowledge error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Process and Acknowledge blocked by a slow receiver")
+	}
+
+	close(f.recorder.release)
+	if err := <-ticked; err != nil {
+		t.Fatalf("Tick() error = %v", err)
+	}
+	if got := f.recorder.take(); len(got) != 1 || got[0].Alert.Labels["host"] != "a" {
+		t.Fatalf("expected one notification for host a, got %v", states(got))
+	}
+
+	alert, _ := f.alerts.GetByFingerprint(ctx, fingerprint)
+	if alert.AcknowledgedBy != "alice" {
+		t.Errorf("acknowledgement lost after the send completed: %+v", alert)
+	}
+	if len(f.groups.groups) != 1 {
+		t.Fatalf("expected one group, got %d", len(f.groups.groups))
+	}
+	for _, group := range f.groups.groups {
+		if len(group.Members) != 2 || group.Members[fingerprint] != entities.AlertStateFiring {
+			t.Errorf("members = %v, want host a notified and host b waiting", group.Members)
+		}
+	}
+}
+
+func TestAlertManager_PersistedStateSurvivesRestart(t *testing.T) {
+	f := newFixture(t)
+	config := AlertManagerConfig{GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h"}
+	m := f.manager(t, config)
+	host := map[string]interface{}{"host": "a"}
+
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(30 * time.Second)
+
+	// 第一次通知失敗時狀態不推進，下一個 group_interval 重試
+	f.recorder.fail = true
+	if err := m.Tick(context.Background()); err == nil {
+		t.Fatal("expected notification error")
+	}
+	f.recorder.fail = false
+	f.clock.Advance(4 * time.Minute)
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(time.Minute)
+	tick(t, m)
+	if got := f.recorder.take(); len(got) != 1 {
+		t.Fatalf("expected retried notification, got %v", states(got))
+	}
+
+	// 重啟後使用同一倉儲的新實例不會再次通知
+	restarted := f.manager(t, config)
+	process(t, restarted, result("cpu", true, host))
+	f.clock.Advance(5 * time.Minute)
+	tick(t, restarted)
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("restart re-paged: %v", states(got))
+	}
+}
+
+func TestAlertManager_SilenceSuppressesNotifications(t *testing.T) {
+	f := newFixture(t)
+	m := f.manager(t, AlertManagerConfig{
+		GroupBy:        []string{"host"},
+		GroupWait:      "30s",
+		GroupInterval:  "5m",
+		ResolveTimeout: "0s",
+	})
+	silence, err := f.silenceService().Create(context.Background(), SilenceRequest{
+		Matchers:  []entities.LabelMatcher{{Name: "host", Operator: entities.MatchRegexp, Value: "db-.*"}},
+		EndsAt:    f.clock.Now().Add(time.Hour),
+		CreatedBy: "ops",
+		Comment:   "database maintenance",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	process(t, m,
+		result("cpu", true, map[string]interface{}{"host": "web-1"}),
+		result("cpu", true, map[string]interface{}{"host": "db-1"}),
+	)
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	if got := states(f.recorder.take()); len(got) != 1 || got[0] != "web-1:firing" {
+		t.Fatalf("notifications = %v, want only web-1", got)
+	}
+	silenced := f.alerts.alerts[entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "db-1"})]
+	if len(silenced.SilencedBy) != 1 || silenced.SilencedBy[0] != silence.ID {
+		t.Errorf("SilencedBy = %v, want [%s]", silenced.SilencedBy, silence.ID)
+	}
+
+	// 靜默結束後在下一個 group_interval 補發通知，並清除靜默記錄
+	if _, err := f.silenceService().Expire(context.Background(), silence.ID); err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	if got := states(f.recorder.take()); len(got) != 1 || got[0] != "db-1:firing" {
+		t.Fatalf("notifications after expiry = %v, want db-1", got)
+	}
+	silenced = f.alerts.alerts[entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "db-1"})]
+	if len(silenced.SilencedBy) != 0 {
+		t.Errorf("SilencedBy = %v, want empty after expiry", silenced.SilencedBy)
+	}
+}
+
+type memoryAnalysisResultRepo struct {
+	mu      sync.Mutex
+	results []*entities.AnalysisResult
+}
+
+func (r *memoryAnalysisResultRepo) Create(ctx context.Context, result *entities.AnalysisResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+	return nil
+}
+func (r *memoryAnalysisResultRepo) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.AnalysisResult, error) {
+	return nil, nil
+}
+func (r *memoryAnalysisResultRepo) Update(ctx context.Context, result *entities.AnalysisResult) error {
+	return nil
+}
+func (r *memoryAnalysisResultRepo) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	return nil
+}
+func (r *memoryAnalysisResultRepo) List(ctx context.Context, offset, limit int) ([]*entities.AnalysisResult, error) {
+	return r.results, nil
+}
+
+// recordingMetrics 記錄計數器次數與直方圖觀測值，以指標名稱加 detector_id 為鍵
+type recordingMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]int
+	histograms map[string][]float64
+}
+
+func (m *recordingMetrics) IncCounter(name string, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name+"/"+tags["state"]+"/"+tags["detector_id"]]++
+}
+func (m *recordingMetrics) ObserveHistogram(name string, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histograms[name+"/"+tags["detector_id"]] = append(m.histograms[name+"/"+tags["detector_id"]], value)
+}
+func (m *recordingMetrics) SetGauge(name string, value float64, tags map[string]string) {}
+func (m *recordingMetrics) GetName() string                                             { return "recording_metrics" }
+
+func TestAlertManager_RecordsResultsAndQualityMetrics(t *testing.T) {
+	f := newFixture(t)
+	results := &memoryAnalysisResultRepo{}
+	metrics := &recordingMetrics{counters: map[string]int{}, histograms: map[string][]float64{}}
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, Results: results, Metrics: metrics}, AlertManagerConfig{
+		Receivers: []ReceiverConfig{{Name: DefaultReceiver, Integrations: []IntegrationConfig{{Plugin: "recorder"}}}},
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+
+	process(t, m, result("cpu", true, map[string]interface{}{"host": "web-1"}), result("cpu", false, map[string]interface{}{"host": "web-2"}))
+	if len(results.results) != 1 || results.results[0].ID != "cpu-result" {
+		t.Fatalf("only anomalous results should be recorded, got %d", len(results.results))
+	}
+	if metrics.counters["alert_transitions_total/firing/cpu"] != 1 {
+		t.Errorf("expected per-detector firing transition, got %v", metrics.counters)
+	}
+
+	f.clock.Advance(90 * time.Second)
+	if _, err := m.Acknowledge(context.Background(), entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "web-1"}), "alice"); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if got := metrics.histograms["alert_time_to_acknowledge_seconds/cpu"]; len(got) != 1 || got[0] != 90 {
+		t.Errorf("time to acknowledge = %v, want [90]", got)
+	}
+}
@@ -0,0 +1,236 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// retryBatchSize 每輪評估最多重試的投遞數量
+const retryBatchSize = 100
+
+// DeliveryConfig 定義通知投遞的重試節奏
+type DeliveryConfig struct {
+	MaxAttempts    int    `yaml:"maxAttempts" json:"maxAttempts"`       // 包含第一次發送的最大嘗試次數，默認 8
+	InitialBackoff string `yaml:"initialBackoff" json:"initialBackoff"` // 第一次重試前的等待時間，之後每次加倍，默認 "30s"
+	MaxBackoff     string `yaml:"maxBackoff" json:"maxBackoff"`         // 兩次重試之間的最長等待時間，默認 "1h"
+}
+
+// deliverySender 按投遞記錄重新發送通知，由告警管理器實現
+type deliverySender interface {
+	redeliver(ctx context.Context, delivery *entities.NotificationDelivery) error
+}
+
+// DeliveryService 管理通知投遞記錄與重試佇列
+// 職責: 記錄每一次發往渠道與接收者的通知 (payload 摘要、狀態、嘗試次數與最後的錯誤) 作為審計記錄；
+// 發送失敗的投遞按指數退避排入重試，達到最大嘗試次數後標記為 failed；支持手動重新發送任一投遞。
+// 實際的重試由告警管理器在每輪評估中執行。
+type DeliveryService struct {
+	deliveries interfaces.NotificationDeliveryRepository
+	logger     contracts.Logger
+	metrics    contracts.MetricsProvider
+	sender     deliverySender // 由 NewAlertManager 設置
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	now func() time.Time
+}
+
+// NewDeliveryService 創建新的通知投遞服務，需要交給 NewAlertManager 後才能重試與重新發送；metrics 可為 nil
+func NewDeliveryService(deliveries interfaces.NotificationDeliveryRepository, config DeliveryConfig, logger contracts.Logger,
+	metrics contracts.MetricsProvider) (*DeliveryService, error) {
+	s := &DeliveryService{
+		deliveries:  deliveries,
+		logger:      logger,
+		metrics:     metrics,
+		maxAttempts: config.MaxAttempts,
+		now:         time.Now,
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = 8
+	}
+	var err error
+	if s.initialBackoff, err = parseDurationDefault(config.InitialBackoff, 30*time.Second); err != nil {
+		return nil, fmt.Errorf("invalid initialBackoff: %w", err)
+	}
+	if s.maxBackoff, err = parseDurationDefault(config.MaxBackoff, time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid maxBackoff: %w", err)
+	}
+	if s.maxBackoff < s.initialBackoff {
+		return nil, fmt.Errorf("maxBackoff %s is shorter than initialBackoff %s", s.maxBackoff, s.initialBackoff)
+	}
+	return s, nil
+}
+
+// List 按創建時間倒序列出投遞記錄
+func (s *DeliveryService) List(ctx context.Context, filter interfaces.NotificationDeliveryFilter) ([]*entities.NotificationDelivery, error) {
+	switch filter.Status {
+	case "", entities.DeliveryStatusPending, entities.DeliveryStatusDelivered, entities.DeliveryStatusFailed,
+		entities.DeliveryStatusSuperseded:
+	default:
+		return nil, domainerrors.NewValidationError("status", fmt.Sprintf("未知的投遞狀態: %s", filter.Status))
+	}
+	deliveries, err := s.deliveries.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("列出通知投遞記錄失敗: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Get 獲取投遞記錄
+func (s *DeliveryService) Get(ctx context.Context, id string) (*entities.NotificationDelivery, error) {
+	delivery, err := s.deliveries.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找通知投遞記錄失敗: %w", err)
+	}
+	if delivery == nil {
+		return nil, domainerrors.NewNotFoundError("notification_delivery", fmt.Sprintf("通知投遞記錄不存在: %s", id))
+	}
+	return delivery, nil
+}
+
+// Resend 以原投遞的渠道、接收者與通知內容立即重新發送，不論告警目前的狀態。
+// 重新發送建立引用原投遞的新記錄，失敗時與一般投遞一樣排入重試；原投遞保持不變。
+func (s *DeliveryService) Resend(ctx context.Context, id, by string) (*entities.NotificationDelivery, error) {
+	if s.sender == nil {
+		return nil, fmt.Errorf("delivery service is not attached to an alert manager")
+	}
+	original, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	delivery := &entities.NotificationDelivery{
+		ID:           uuid.NewString(),
+		Receiver:     original.Receiver,
+		Plugin:       original.Plugin,
+		Recipient:    original.Recipient,
+		Settings:     original.Settings,
+		Notification: original.Notification,
+		Fingerprint:  original.Fingerprint,
+		PayloadHash:  original.PayloadHash,
+		ResendOf:     original.ID,
+		RequestedBy:  by,
+		CreatedAt:    now,
+	}
+	s.attempted(delivery, s.sender.redeliver(ctx, delivery), now)
+	if err := s.deliveries.Save(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("保存通知投遞記錄失敗: %w", err)
+	}
+	s.logger.Info("通知已手動重新發送", "delivery_id", delivery.ID, "resend_of", original.ID, "by", by,
+		"status", delivery.Status)
+	return delivery, nil
+}
+
+// record 記錄一次通知的第一次發送結果，sendErr 為 nil 表示發送成功。
+// 返回 true 表示發送失敗但已排入重試，呼叫方不需要再自行重發。記錄保存失敗時只記錄日誌。
+func (s *DeliveryService) record(ctx context.Context, target *integrationTarget, receiver, recipient string,
+	notification *entities.AlertNotification, sendErr error) bool {
+	hash, err := entities.NotificationPayloadHash(target.config.Plugin, recipient, notification)
+	if err != nil {
+		s.logger.Error("計算通知內容摘要失敗", "fingerprint", notification.Alert.Fingerprint, "error", err)
+	}
+	now := s.now()
+	delivery := &entities.NotificationDelivery{
+		ID:           uuid.NewString(),
+		Receiver:     receiver,
+		Plugin:       target.config.Plugin,
+		Recipient:    recipient,
+		Settings:     target.config.Settings,
+		Notification: notification,
+		Fingerprint:  notification.Alert.Fingerprint,
+		PayloadHash:  hash,
+		CreatedAt:    now,
+	}
+	s.attempted(delivery, sendErr, now)
+	if err := s.deliveries.Save(ctx, delivery); err != nil {
+		s.logger.Error("保存通知投遞記錄失敗", "fingerprint", delivery.Fingerprint, "receiver", receiver,
+			"plugin", delivery.Plugin, "error", err)
+		return false
+	}
+	return delivery.Status == entities.DeliveryStatusPending
+}
+
+// due 返回到期需要重試的投遞
+func (s *DeliveryService) due(ctx context.Context, now time.Time) ([]*entities.NotificationDelivery, error) {
+	deliveries, err := s.deliveries.ListDue(ctx, now, retryBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// retried 保存一次重試的結果
+func (s *DeliveryService) retried(ctx context.Context, delivery *entities.NotificationDelivery, sendErr error, now time.Time) error {
+	s.attempted(delivery, sendErr, now)
+	if err := s.deliveries.Save(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to save notification delivery %s: %w", delivery.ID, err)
+	}
+	if delivery.Status == entities.DeliveryStatusFailed {
+		s.logger.Warn("通知投遞達到最大嘗試次數，停止重試", "delivery_id", delivery.ID, "receiver", delivery.Receiver,
+			"plugin", delivery.Plugin, "attempts", delivery.Attempts, "error", delivery.LastError)
+	}
+	return nil
+}
+
+// supersede 標記告警狀態已改變、不再需要重試的投遞
+func (s *DeliveryService) supersede(ctx context.Context, delivery *entities.NotificationDelivery, now time.Time) error {
+	delivery.Status = entities.DeliveryStatusSuperseded
+	delivery.NextAttemptAt = time.Time{}
+	delivery.UpdatedAt = now
+	if err := s.deliveries.Save(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to save notification delivery %s: %w", delivery.ID, err)
+	}
+	s.logger.Info("告警狀態已改變，放棄重試過時的通知", "delivery_id", delivery.ID, "fingerprint", delivery.Fingerprint)
+	return nil
+}
+
+// attempted 把一次嘗試的結果記入投遞：成功時標記為 delivered；
+// 失敗時未達最大嘗試次數則按退避時間排入重試，否則標記為 failed。LastError 保留最近一次失敗的原因。
+func (s *DeliveryService) attempted(delivery *entities.NotificationDelivery, sendErr error, now time.Time) {
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+	delivery.NextAttemptAt = time.Time{}
+	status := "success"
+	switch {
+	case sendErr == nil:
+		delivery.Status = entities.DeliveryStatusDelivered
+		delivery.DeliveredAt = now
+	case delivery.Attempts >= s.maxAttempts:
+		status = "failure"
+		delivery.Status = entities.DeliveryStatusFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		status = "failure"
+		delivery.Status = entities.DeliveryStatusPending
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	}
+	if s.metrics != nil {
+		s.metrics.IncCounter("notification_delivery_attempts_total", map[string]string{
+			"plugin": delivery.Plugin, "status": status,
+		})
+	}
+}
+
+// backoff 返回第 attempts 次嘗試失敗後的等待時間：從 initialBackoff 開始每次加倍，不超過 maxBackoff
+func (s *DeliveryService) backoff(attempts int) time.Duration {
+	wait := s.initialBackoff
+	for i := 1; i < attempts && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+	return wait
+}
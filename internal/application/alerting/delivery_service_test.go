@@ -0,0 +1,230 @@
+package alerting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+)
+
+type memoryDeliveryRepo struct {
+	mu         sync.Mutex
+	deliveries map[string]entities.NotificationDelivery
+}
+
+func (r *memoryDeliveryRepo) Save(ctx context.Context, delivery *entities.NotificationDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (r *memoryDeliveryRepo) GetByID(ctx context.Context, id string) (*entities.NotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return &delivery, nil
+}
+
+func (r *memoryDeliveryRepo) List(ctx context.Context, filter interfaces.NotificationDeliveryFilter) ([]*entities.NotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.NotificationDelivery
+	for _, delivery := range r.deliveries {
+		if (filter.Status == "" || delivery.Status == filter.Status) &&
+			(filter.Receiver == "" || delivery.Receiver == filter.Receiver) &&
+			(filter.Fingerprint == "" || delivery.Fingerprint == filter.Fingerprint) {
+			d := delivery
+			out = append(out, &d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryDeliveryRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]*entities.NotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.NotificationDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == entities.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) {
+			d := delivery
+			out = append(out, &d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextAttemptAt.Before(out[j].NextAttemptAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// only 返回唯一的投遞記錄
+func (r *memoryDeliveryRepo) only(t *testing.T) entities.NotificationDelivery {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(r.deliveries))
+	}
+	for _, delivery := range r.deliveries {
+		return delivery
+	}
+	return entities.NotificationDelivery{}
+}
+
+// deliveryManager 創建啟用投遞記錄的告警管理器，分組在 30 秒後通知
+func (f *fixture) deliveryManager(t *testing.T, repo *memoryDeliveryRepo, maxAttempts int) (*AlertManager, *DeliveryService) {
+	t.Helper()
+	deliveries, err := NewDeliveryService(repo, DeliveryConfig{MaxAttempts: maxAttempts, InitialBackoff: "1m", MaxBackoff: "2m"},
+		&testLogger{}, nil)
+	if err != nil {
+		t.Fatalf("NewDeliveryService() error = %v", err)
+	}
+	deliveries.now = f.clock.Now
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, Deliveries: deliveries}, AlertManagerConfig{
+		GroupWait:      "30s",
+		GroupInterval:  "5m",
+		RepeatInterval: "4h",
+		ResolveTimeout: "0s",
+		Receivers:      []ReceiverConfig{{Name: DefaultReceiver, Integrations: []IntegrationConfig{{Plugin: "recorder"}}}},
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+	return m, deliveries
+}
+
+func TestDeliveryService_RetriesFailedNotificationsWithBackoff(t *testing.T) {
+	f := newFixture(t)
+	repo := &memoryDeliveryRepo{deliveries: map[string]entities.NotificationDelivery{}}
+	m, _ := f.deliveryManager(t, repo, 5)
+	host := map[string]interface{}{"host": "a"}
+
+	// 發送失敗的通知排入重試，評估本身不報錯
+	f.recorder.fail = true
+	process(t, m, result("cpu", true, host))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	delivery := repo.only(t)
+	if delivery.Status != entities.DeliveryStatusPending || delivery.Attempts != 1 || delivery.LastError != "receiver down" {
+		t.Fatalf("unexpected delivery after failure: %+v", delivery)
+	}
+	if !delivery.NextAttemptAt.Equal(f.clock.Now().Add(time.Minute)) || len(delivery.PayloadHash) != 64 {
+		t.Errorf("unexpected retry schedule or payload hash: %v %q", delivery.NextAttemptAt, delivery.PayloadHash)
+	}
+
+	// 退避時間內不重試，到期後重試並加倍退避
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	if got := repo.only(t); got.Attempts != 1 {
+		t.Fatalf("retried before backoff: %+v", got)
+	}
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	delivery = repo.only(t)
+	if delivery.Attempts != 2 || !delivery.NextAttemptAt.Equal(f.clock.Now().Add(2*time.Minute)) {
+		t.Fatalf("unexpected delivery after second failure: %+v", delivery)
+	}
+
+	// 渠道恢復後重試成功；分組已推進，不會再重發整組
+	f.recorder.fail = false
+	f.clock.Advance(2 * time.Minute)
+	tick(t, m)
+	delivery = repo.only(t)
+	if delivery.Status != entities.DeliveryStatusDelivered || delivery.Attempts != 3 || !delivery.DeliveredAt.Equal(f.clock.Now()) {
+		t.Fatalf("unexpected delivery after recovery: %+v", delivery)
+	}
+	if got := f.recorder.take(); len(got) != 1 || got[0].Alert.State != entities.AlertStateFiring {
+		t.Fatalf("expected the queued firing notification, got %+v", got)
+	}
+}
+
+func TestDeliveryService_FailsAfterMaxAttemptsAndSupersedesStaleNotifications(t *testing.T) {
+	f := newFixture(t)
+	repo := &memoryDeliveryRepo{deliveries: map[string]entities.NotificationDelivery{}}
+	m, _ := f.deliveryManager(t, repo, 2)
+	f.recorder.fail = true
+
+	process(t, m, result("cpu", true, map[string]interface{}{"host": "a"}))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	f.clock.Advance(time.Minute)
+	tick(t, m)
+	if got := repo.only(t); got.Status != entities.DeliveryStatusFailed || got.Attempts != 2 || !got.NextAttemptAt.IsZero() {
+		t.Fatalf("expected failed delivery after max attempts, got %+v", got)
+	}
+
+	// firing 通知重試前告警已恢復時放棄重試
+	process(t, m, result("mem", true, map[string]interface{}{"host": "b"}))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	pending, err := m.Deliveries().List(context.Background(), interfaces.NotificationDeliveryFilter{Status: entities.DeliveryStatusPending})
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected 1 pending delivery, got %d (%v)", len(pending), err)
+	}
+	process(t, m, result("mem", false, map[string]interface{}{"host": "b"}))
+	f.clock.Advance(time.Minute)
+	tick(t, m)
+	superseded, err := repo.GetByID(context.Background(), pending[0].ID)
+	if err != nil || superseded.Status != entities.DeliveryStatusSuperseded || superseded.Attempts != 1 {
+		t.Fatalf("expected superseded delivery, got %+v (%v)", superseded, err)
+	}
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Errorf("stale notification was sent: %v", states(got))
+	}
+}
+
+func TestDeliveryService_ResendAndValidation(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	repo := &memoryDeliveryRepo{deliveries: map[string]entities.NotificationDelivery{}}
+	m, deliveries := f.deliveryManager(t, repo, 3)
+
+	process(t, m, result("cpu", true, map[string]interface{}{"host": "a"}))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	original := repo.only(t)
+	if original.Status != entities.DeliveryStatusDelivered || len(f.recorder.take()) != 1 {
+		t.Fatalf("expected delivered notification, got %+v", original)
+	}
+
+	// 手動重新發送建立新的投遞並原樣發送，原投遞不變
+	f.clock.Advance(time.Hour)
+	resent, err := deliveries.Resend(ctx, original.ID, "alice")
+	if err != nil {
+		t.Fatalf("Resend() error = %v", err)
+	}
+	if resent.ID == original.ID || resent.ResendOf != original.ID || resent.RequestedBy != "alice" ||
+		resent.Status != entities.DeliveryStatusDelivered || resent.PayloadHash != original.PayloadHash {
+		t.Errorf("unexpected resent delivery: %+v", resent)
+	}
+	if got := f.recorder.take(); len(got) != 1 || got[0].Alert.Fingerprint != original.Fingerprint {
+		t.Errorf("expected resent notification, got %+v", got)
+	}
+	if got, _ := deliveries.Get(ctx, original.ID); got.Attempts != 1 || got.ResendOf != "" {
+		t.Errorf("original delivery changed: %+v", got)
+	}
+	if list, _ := deliveries.List(ctx, interfaces.NotificationDeliveryFilter{}); len(list) != 2 || list[0].ID != resent.ID {
+		t.Errorf("expected resent delivery first, got %d deliveries", len(list))
+	}
+
+	if _, err := deliveries.Resend(ctx, "missing", "alice"); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := deliveries.List(ctx, interfaces.NotificationDeliveryFilter{Status: "lost"}); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for unknown status, got %v", err)
+	}
+	if _, err := NewDeliveryService(repo, DeliveryConfig{InitialBackoff: "1h", MaxBackoff: "1m"}, &testLogger{}, nil); err == nil {
+		t.Error("expected error when maxBackoff is shorter than initialBackoff")
+	}
+}
@@ -0,0 +1,601 @@
+package alerting
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// IncidentLinkConfig 定義通知中確認鏈接的生成方式
+type IncidentLinkConfig struct {
+	// ExternalURL 是 detectviz 的外部地址，確認鏈接為 {ExternalURL}/api/v1/incidents/{id}/acknowledge。
+	ExternalURL string
+	// SigningKey 是確認鏈接的 HMAC-SHA256 簽名密鑰，為空時通知不附帶確認鏈接。
+	SigningKey []byte
+	// TTL 是確認鏈接的有效期，過期時間一併簽名，為 0 時使用 24 小時。
+	TTL time.Duration
+}
+
+// defaultAcknowledgeLinkTTL 是未配置有效期時確認鏈接的有效期
+const defaultAcknowledgeLinkTTL = 24 * time.Hour
+
+// acknowledgeLinkActor 是通過未指明收件人的確認鏈接 (例如聊天卡片) 確認時記錄的確認者
+const acknowledgeLinkActor = "acknowledge-link"
+
+// acknowledgeLinkDataKey 是確認鏈接確認事件單時，狀態事件中記錄令牌摘要的鍵，用於拒絕重複使用的鏈接
+const acknowledgeLinkDataKey = "acknowledge_link"
+
+// alertAcknowledger 確認單個告警並停止其升級，由告警管理器實現
+type alertAcknowledger interface {
+	Acknowledge(ctx context.Context, fingerprint, by string) (*entities.Alert, error)
+}
+
+// incidentNotice 描述一次成功發送、需要記入事件單時間線的通知
+type incidentNotice struct {
+	incidentID      string
+	fingerprint     string
+	state           string
+	receiver        string
+	plugin          string
+	recipient       string
+	escalationLevel int
+}
+
+// IncidentService 管理聚合告警的事件單
+// 職責: 告警第一次通知時按分組自動開立事件單，同一分組的後續告警加入尚未解決的事件單；
+// 提供確認、指派、備註與解決操作並記錄時間線。確認事件單會確認所有成員告警，從而停止升級；
+// 所有成員告警恢復後事件單自動解決。
+type IncidentService struct {
+	incidents    interfaces.IncidentRepository
+	alerts       interfaces.AlertRepository
+	links        IncidentLinkConfig
+	logger       contracts.Logger
+	acknowledger alertAcknowledger // 由 NewAlertManager 設置
+
+	now func() time.Time
+
+	mu sync.Mutex // 序列化事件單的讀取-修改-保存
+}
+
+// NewIncidentService 創建新的事件單服務，需要交給 NewAlertManager 後才能確認成員告警
+func NewIncidentService(incidents interfaces.IncidentRepository, alerts interfaces.AlertRepository, links IncidentLinkConfig,
+	logger contracts.Logger) *IncidentService {
+	return &IncidentService{
+		incidents: incidents,
+		alerts:    alerts,
+		links:     links,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Create 手動開立事件單並加入指定的告警；已屬於未解決事件單的告警不能再加入
+func (s *IncidentService) Create(ctx context.Context, incident *entities.Incident, by string) (*entities.Incident, error) {
+	if strings.TrimSpace(incident.Title) == "" {
+		return nil, domainerrors.NewValidationError("title", "事件單標題不能為空")
+	}
+	alerts, err := s.loadAlerts(ctx, incident.Alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	created := &entities.Incident{
+		ID:        uuid.New().String(),
+		Title:     incident.Title,
+		State:     entities.IncidentStateOpen,
+		Severity:  incident.Severity,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	created.Record(s.event(now, entities.IncidentEventCreated, by, "事件單已開立", nil))
+	for _, alert := range alerts {
+		if err := s.ensureUnassigned(ctx, alert.Fingerprint); err != nil {
+			return nil, err
+		}
+		created.AddAlert(alert)
+		created.Record(s.event(now, entities.IncidentEventAlertAdded, by, alert.Summary,
+			map[string]string{"fingerprint": alert.Fingerprint}))
+	}
+	if incident.Assignee != "" {
+		created.Assignee = incident.Assignee
+		created.Record(s.event(now, entities.IncidentEventAssigned, by, "", map[string]string{"assignee": incident.Assignee}))
+	}
+	if err := s.incidents.Save(ctx, created); err != nil {
+		return nil, fmt.Errorf("保存事件單失敗: %w", err)
+	}
+	s.logger.Info("已開立事件單", "id", created.ID, "title", created.Title, "alerts", len(created.Alerts), "by", by)
+	return created, nil
+}
+
+// Get 獲取事件單
+func (s *IncidentService) Get(ctx context.Context, id string) (*entities.Incident, error) {
+	incident, err := s.incidents.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找事件單失敗: %w", err)
+	}
+	if incident == nil {
+		return nil, domainerrors.NewNotFoundError("incident", fmt.Sprintf("事件單不存在: %s", id))
+	}
+	return incident, nil
+}
+
+// List 列出事件單，按開立時間倒序；state 為空時列出所有狀態
+func (s *IncidentService) List(ctx context.Context, state string, limit int) ([]*entities.Incident, error) {
+	switch state {
+	case "", entities.IncidentStateOpen, entities.IncidentStateAcknowledged, entities.IncidentStateResolved:
+	default:
+		return nil, domainerrors.NewValidationError("state", fmt.Sprintf("未知的事件單狀態: %s", state))
+	}
+	incidents, err := s.incidents.List(ctx, state, limit)
+	if err != nil {
+		return nil, fmt.Errorf("列出事件單失敗: %w", err)
+	}
+	return incidents, nil
+}
+
+// Acknowledge 確認事件單，並確認所有仍在 pending 或 firing 的成員告警以停止升級。
+// 已確認的事件單保持原來的確認者。
+func (s *IncidentService) Acknowledge(ctx context.Context, id, by string) (*entities.Incident, error) {
+	return s.acknowledge(ctx, id, by, "")
+}
+
+// AcknowledgeWithLink 以通知中的確認鏈接確認事件單。鏈接的簽名、過期時間與是否已使用在確認前再次檢查，
+// 成功確認後令牌摘要記入時間線，之後同一鏈接被拒絕。未指明收件人的鏈接以 acknowledgeLinkActor 記錄確認者。
+func (s *IncidentService) AcknowledgeWithLink(ctx context.Context, id, by string, expires int64, token string) (*entities.Incident, error) {
+	if _, err := s.VerifyAcknowledgeLink(ctx, id, by, expires, token); err != nil {
+		return nil, err
+	}
+	if by == "" {
+		by = acknowledgeLinkActor
+	}
+	return s.acknowledge(ctx, id, by, tokenDigest(token))
+}
+
+// acknowledge 確認事件單；linkDigest 不為空時表示經確認鏈接確認，在鎖內拒絕已使用的鏈接並記錄摘要
+func (s *IncidentService) acknowledge(ctx context.Context, id, by, linkDigest string) (*entities.Incident, error) {
+	if by == "" {
+		return nil, domainerrors.NewValidationError("by", "確認者不能為空")
+	}
+	incident, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.IsResolved() {
+		return nil, domainerrors.NewValidationError("state", "事件單已解決，不能確認")
+	}
+	// 先確認成員告警：告警管理器持有狀態鎖時會調用本服務，因此不能在持有 s.mu 時調用它
+	if err := s.acknowledgeAlerts(ctx, incident.Alerts, by); err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, id, func(incident *entities.Incident, now time.Time) (bool, error) {
+		if linkDigest != "" && linkUsed(incident, linkDigest) {
+			return false, domainerrors.NewAuthError("確認鏈接已使用")
+		}
+		changed, err := incident.Acknowledge(by, now)
+		if err != nil {
+			return false, domainerrors.NewValidationError("state", err.Error())
+		}
+		if changed {
+			event := s.stateEvent(now, by, entities.IncidentStateOpen, entities.IncidentStateAcknowledged)
+			if linkDigest != "" {
+				event.Data[acknowledgeLinkDataKey] = linkDigest
+			}
+			incident.Record(event)
+			s.logger.Info("事件單已確認", "id", id, "by", by)
+		}
+		return changed, nil
+	})
+}
+
+// Resolve 解決事件單；成員告警的狀態不受影響，仍在觸發的告警下一次通知時會開立新的事件單
+func (s *IncidentService) Resolve(ctx context.Context, id, by string) (*entities.Incident, error) {
+	if by == "" {
+		return nil, domainerrors.NewValidationError("by", "解決者不能為空")
+	}
+	return s.update(ctx, id, func(incident *entities.Incident, now time.Time) (bool, error) {
+		from := incident.State
+		if !incident.Resolve(by, now) {
+			return false, nil
+		}
+		incident.Record(s.stateEvent(now, by, from, entities.IncidentStateResolved))
+		s.logger.Info("事件單已解決", "id", id, "by", by)
+		return true, nil
+	})
+}
+
+// Assign 指派事件單的負責人，assignee 為空時取消指派
+func (s *IncidentService) Assign(ctx context.Context, id, assignee, by string) (*entities.Incident, error) {
+	return s.update(ctx, id, func(incident *entities.Incident, now time.Time) (bool, error) {
+		if incident.Assignee == assignee {
+			return false, nil
+		}
+		incident.Assignee = assignee
+		incident.Record(s.event(now, entities.IncidentEventAssigned, by, "", map[string]string{"assignee": assignee}))
+		return true, nil
+	})
+}
+
+// AddNote 在事件單時間線上加入備註
+func (s *IncidentService) AddNote(ctx context.Context, id, author, text string) (*entities.Incident, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, domainerrors.NewValidationError("text", "備註不能為空")
+	}
+	if author == "" {
+		return nil, domainerrors.NewValidationError("author", "備註作者不能為空")
+	}
+	return s.update(ctx, id, func(incident *entities.Incident, now time.Time) (bool, error) {
+		incident.Record(s.event(now, entities.IncidentEventNote, author, text, nil))
+		return true, nil
+	})
+}
+
+// AddAlerts 將告警加入未解決的事件單；加入已確認的事件單時，告警同樣被確認
+func (s *IncidentService) AddAlerts(ctx context.Context, id string, fingerprints []string, by string) (*entities.Incident, error) {
+	if len(fingerprints) == 0 {
+		return nil, domainerrors.NewValidationError("alerts", "至少需要一個告警指紋")
+	}
+	alerts, err := s.loadAlerts(ctx, fingerprints)
+	if err != nil {
+		return nil, err
+	}
+	incident, err := s.update(ctx, id, func(incident *entities.Incident, now time.Time) (bool, error) {
+		if incident.IsResolved() {
+			return false, domainerrors.NewValidationError("state", "事件單已解決，不能加入告警")
+		}
+		changed := false
+		for _, alert := range alerts {
+			if incident.HasAlert(alert.Fingerprint) {
+				continue
+			}
+			if err := s.ensureUnassigned(ctx, alert.Fingerprint); err != nil {
+				return false, err
+			}
+			incident.AddAlert(alert)
+			incident.Record(s.event(now, entities.IncidentEventAlertAdded, by, alert.Summary,
+				map[string]string{"fingerprint": alert.Fingerprint}))
+			changed = true
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if incident.State == entities.IncidentStateAcknowledged {
+		if err := s.acknowledgeAlerts(ctx, fingerprints, incident.AcknowledgedBy); err != nil {
+			return nil, err
+		}
+	}
+	return incident, nil
+}
+
+// AcknowledgeURL 返回通知中給 recipient 使用的確認鏈接，未配置外部地址或簽名密鑰時返回空字串。
+// 鏈接攜帶簽名的過期時間 exp (Unix 秒)，打開後顯示確認頁面，提交後才確認事件單。
+func (s *IncidentService) AcknowledgeURL(incidentID, recipient string) string {
+	if s.links.ExternalURL == "" || len(s.links.SigningKey) == 0 || incidentID == "" {
+		return ""
+	}
+	ttl := s.links.TTL
+	if ttl <= 0 {
+		ttl = defaultAcknowledgeLinkTTL
+	}
+	expires := s.now().Add(ttl).Unix()
+	query := url.Values{}
+	if recipient != "" {
+		query.Set("by", recipient)
+	}
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	query.Set("token", s.sign(incidentID, recipient, expires))
+	return strings.TrimRight(s.links.ExternalURL, "/") + "/api/v1/incidents/" + url.PathEscape(incidentID) +
+		"/acknowledge?" + query.Encode()
+}
+
+// VerifyAcknowledgeLink 驗證確認鏈接的簽名、過期時間與是否已使用，通過時返回事件單。
+// 簽名不符、已過期或已使用時返回認證錯誤；未配置簽名密鑰時所有鏈接都無效。
+func (s *IncidentService) VerifyAcknowledgeLink(ctx context.Context, incidentID, by string, expires int64, token string) (*entities.Incident, error) {
+	if !s.validSignature(incidentID, by, expires, token) {
+		return nil, domainerrors.NewAuthError("確認鏈接無效")
+	}
+	if !s.now().Before(time.Unix(expires, 0)) {
+		return nil, domainerrors.NewAuthError("確認鏈接已過期")
+	}
+	incident, err := s.Get(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if linkUsed(incident, tokenDigest(token)) {
+		return nil, domainerrors.NewAuthError("確認鏈接已使用")
+	}
+	return incident, nil
+}
+
+// validSignature 以常數時間比較確認鏈接中的簽名
+func (s *IncidentService) validSignature(incidentID, by string, expires int64, token string) bool {
+	if len(s.links.SigningKey) == 0 || token == "" {
+		return false
+	}
+	expected, err := hex.DecodeString(s.sign(incidentID, by, expires))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(token)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}
+
+// sign 以簽名密鑰計算事件單 ID、確認者與過期時間的 HMAC-SHA256
+func (s *IncidentService) sign(incidentID, by string, expires int64) string {
+	mac := hmac.New(sha256.New, s.links.SigningKey)
+	mac.Write([]byte(incidentID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(by))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// tokenDigest 返回記入時間線的令牌摘要，時間線不保存令牌本身
+func tokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// linkUsed 檢查時間線中是否已有以該令牌完成的確認
+func linkUsed(incident *entities.Incident, digest string) bool {
+	for _, event := range incident.Timeline {
+		if event.Data[acknowledgeLinkDataKey] == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// track 在告警通知前找到或開立它所屬的事件單並補充分析結果，由告警管理器在持有狀態鎖時調用。
+// 告警已屬於未解決的事件單時沿用，否則加入同一分組未解決的事件單，都沒有時開立新的事件單。
+func (s *IncidentService) track(ctx context.Context, groupKey string, alert *entities.Alert, now time.Time) (*entities.Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, err := s.incidents.GetLatestByAlert(ctx, alert.Fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("查找事件單失敗: %w", err)
+	}
+	if incident != nil && incident.IsResolved() {
+		incident = nil
+	}
+	if incident == nil {
+		if incident, err = s.incidents.GetUnresolvedByGroup(ctx, groupKey); err != nil {
+			return nil, fmt.Errorf("查找事件單失敗: %w", err)
+		}
+	}
+	if incident == nil {
+		title := alert.Summary
+		if title == "" {
+			title = "detector " + alert.DetectorID
+		}
+		incident = &entities.Incident{
+			ID:        uuid.New().String(),
+			Title:     title,
+			State:     entities.IncidentStateOpen,
+			Severity:  alert.Severity,
+			GroupKey:  groupKey,
+			CreatedAt: now,
+		}
+		incident.Record(s.event(now, entities.IncidentEventCreated, entities.IncidentActorSystem, "告警觸發，自動開立事件單", nil))
+		s.logger.Info("已自動開立事件單", "id", incident.ID, "group", groupKey, "fingerprint", alert.Fingerprint)
+	}
+
+	linked := incident.LinkAnalysisResult(alert.AnalysisResultID)
+	isNew := incident.AddAlert(alert)
+	if !isNew && !linked {
+		return incident, nil
+	}
+	if isNew {
+		incident.Record(s.event(now, entities.IncidentEventAlertAdded, entities.IncidentActorSystem, alert.Summary,
+			map[string]string{"fingerprint": alert.Fingerprint}))
+	}
+	incident.UpdatedAt = now
+	if err := s.incidents.Save(ctx, incident); err != nil {
+		return nil, fmt.Errorf("保存事件單失敗: %w", err)
+	}
+	return incident, nil
+}
+
+// latest 返回告警最近所屬的事件單，不存在時返回 nil
+func (s *IncidentService) latest(ctx context.Context, fingerprint string) (*entities.Incident, error) {
+	incident, err := s.incidents.GetLatestByAlert(ctx, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("查找事件單失敗: %w", err)
+	}
+	return incident, nil
+}
+
+// recordNotifications 把成功發送的通知記入各事件單的時間線
+func (s *IncidentService) recordNotifications(ctx context.Context, notices []incidentNotice, now time.Time) error {
+	if len(notices) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byIncident := make(map[string][]incidentNotice)
+	var order []string
+	for _, notice := range notices {
+		if _, ok := byIncident[notice.incidentID]; !ok {
+			order = append(order, notice.incidentID)
+		}
+		byIncident[notice.incidentID] = append(byIncident[notice.incidentID], notice)
+	}
+
+	var errs []error
+	for _, id := range order {
+		incident, err := s.incidents.GetByID(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("查找事件單失敗: %w", err))
+			continue
+		}
+		if incident == nil {
+			continue
+		}
+		for _, notice := range byIncident[id] {
+			data := map[string]string{
+				"fingerprint": notice.fingerprint,
+				"state":       notice.state,
+				"receiver":    notice.receiver,
+				"plugin":      notice.plugin,
+			}
+			if notice.recipient != "" {
+				data["recipient"] = notice.recipient
+			}
+			if notice.escalationLevel > 0 {
+				data["escalation_level"] = fmt.Sprint(notice.escalationLevel)
+			}
+			incident.Record(s.event(now, entities.IncidentEventNotificationSent, entities.IncidentActorSystem, "", data))
+		}
+		incident.UpdatedAt = now
+		if err := s.incidents.Save(ctx, incident); err != nil {
+			errs = append(errs, fmt.Errorf("保存事件單失敗: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// alertResolved 在告警恢復後檢查它所屬的事件單，所有成員告警都已恢復時自動解決事件單
+func (s *IncidentService) alertResolved(ctx context.Context, alert *entities.Alert, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, err := s.incidents.GetLatestByAlert(ctx, alert.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("查找事件單失敗: %w", err)
+	}
+	if incident == nil || incident.IsResolved() {
+		return nil
+	}
+	for _, fingerprint := range incident.Alerts {
+		if fingerprint == alert.Fingerprint {
+			continue
+		}
+		member, err := s.alerts.GetByFingerprint(ctx, fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to load alert %s: %w", fingerprint, err)
+		}
+		if member != nil && member.IsActive() {
+			return nil
+		}
+	}
+
+	from := incident.State
+	incident.Resolve(entities.IncidentActorSystem, now)
+	incident.Record(s.stateEvent(now, entities.IncidentActorSystem, from, entities.IncidentStateResolved))
+	incident.UpdatedAt = now
+	if err := s.incidents.Save(ctx, incident); err != nil {
+		return fmt.Errorf("保存事件單失敗: %w", err)
+	}
+	s.logger.Info("成員告警均已恢復，事件單已自動解決", "id", incident.ID)
+	return nil
+}
+
+// update 在鎖內重新讀取事件單並套用修改，有變化時保存
+func (s *IncidentService) update(ctx context.Context, id string,
+	apply func(incident *entities.Incident, now time.Time) (bool, error)) (*entities.Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incident, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	changed, err := apply(incident, now)
+	if err != nil || !changed {
+		return incident, err
+	}
+	incident.UpdatedAt = now
+	if err := s.incidents.Save(ctx, incident); err != nil {
+		return nil, fmt.Errorf("保存事件單失敗: %w", err)
+	}
+	return incident, nil
+}
+
+// acknowledgeAlerts 確認仍在 pending 或 firing 的成員告警，已恢復或已不存在的告警被跳過
+func (s *IncidentService) acknowledgeAlerts(ctx context.Context, fingerprints []string, by string) error {
+	if s.acknowledger == nil {
+		return fmt.Errorf("incident service is not attached to an alert manager")
+	}
+	for _, fingerprint := range fingerprints {
+		if _, err := s.acknowledger.Acknowledge(ctx, fingerprint, by); err != nil {
+			if domainerrors.IsNotFoundError(err) || domainerrors.IsValidationError(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAlerts 按指紋讀取告警，任何一個不存在時返回驗證錯誤
+func (s *IncidentService) loadAlerts(ctx context.Context, fingerprints []string) ([]*entities.Alert, error) {
+	alerts := make([]*entities.Alert, 0, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		alert, err := s.alerts.GetByFingerprint(ctx, fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load alert %s: %w", fingerprint, err)
+		}
+		if alert == nil {
+			return nil, domainerrors.NewValidationError("alerts", fmt.Sprintf("告警不存在: %s", fingerprint))
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// ensureUnassigned 檢查告警不屬於其他未解決的事件單
+func (s *IncidentService) ensureUnassigned(ctx context.Context, fingerprint string) error {
+	existing, err := s.incidents.GetLatestByAlert(ctx, fingerprint)
+	if err != nil {
+		return fmt.Errorf("查找事件單失敗: %w", err)
+	}
+	if existing != nil && !existing.IsResolved() {
+		return domainerrors.NewValidationError("alerts", fmt.Sprintf("告警 %s 已屬於事件單 %s", fingerprint, existing.ID))
+	}
+	return nil
+}
+
+// event 創建時間線事件
+func (s *IncidentService) event(now time.Time, eventType, actor, message string, data map[string]string) entities.IncidentEvent {
+	return entities.IncidentEvent{
+		ID:      uuid.New().String(),
+		Time:    now,
+		Type:    eventType,
+		Actor:   actor,
+		Message: message,
+		Data:    data,
+	}
+}
+
+// stateEvent 創建狀態轉換事件
+func (s *IncidentService) stateEvent(now time.Time, actor, from, to string) entities.IncidentEvent {
+	return s.event(now, entities.IncidentEventStateChanged, actor, "", map[string]string{"from": from, "to": to})
+}
@@ -0,0 +1,317 @@
+package alerting
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+)
+
+type memoryIncidentRepo struct {
+	mu        sync.Mutex
+	incidents map[string]entities.Incident
+}
+
+func (r *memoryIncidentRepo) Save(ctx context.Context, incident *entities.Incident) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *incident
+	copied.Alerts = append([]string(nil), incident.Alerts...)
+	copied.AnalysisResultIDs = append([]string(nil), incident.AnalysisResultIDs...)
+	copied.Timeline = append([]entities.IncidentEvent(nil), incident.Timeline...)
+	r.incidents[incident.ID] = copied
+	return nil
+}
+
+func (r *memoryIncidentRepo) GetByID(ctx context.Context, id string) (*entities.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	incident, ok := r.incidents[id]
+	if !ok {
+		return nil, nil
+	}
+	return &incident, nil
+}
+
+func (r *memoryIncidentRepo) List(ctx context.Context, state string, limit int) ([]*entities.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.Incident
+	for _, incident := range r.incidents {
+		if state == "" || incident.State == state {
+			i := incident
+			out = append(out, &i)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.After(out[b].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *memoryIncidentRepo) GetLatestByAlert(ctx context.Context, fingerprint string) (*entities.Incident, error) {
+	return r.latest(func(incident *entities.Incident) bool { return incident.HasAlert(fingerprint) })
+}
+
+func (r *memoryIncidentRepo) GetUnresolvedByGroup(ctx context.Context, groupKey string) (*entities.Incident, error) {
+	return r.latest(func(incident *entities.Incident) bool {
+		return incident.GroupKey == groupKey && !incident.IsResolved()
+	})
+}
+
+func (r *memoryIncidentRepo) latest(match func(incident *entities.Incident) bool) (*entities.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found *entities.Incident
+	for _, incident := range r.incidents {
+		i := incident
+		if match(&i) && (found == nil || i.CreatedAt.After(found.CreatedAt)) {
+			found = &i
+		}
+	}
+	return found, nil
+}
+
+// incidentManager 建立啟用事件單與升級策略的告警管理器，確認鏈接以 "secret" 簽名
+func (f *fixture) incidentManager(t *testing.T, mailer *recordingNotifier) (*AlertManager, *IncidentService) {
+	t.Helper()
+	incidents := NewIncidentService(&memoryIncidentRepo{incidents: map[string]entities.Incident{}}, f.alerts,
+		IncidentLinkConfig{ExternalURL: "https://detectviz.example.com/", SigningKey: []byte("secret")}, &testLogger{})
+	incidents.now = f.clock.Now
+	if err := f.registry.Register("mailer", mailer); err != nil {
+		t.Fatalf("register mailer: %v", err)
+	}
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, OnCall: onCallFixture(t, f), Incidents: incidents}, AlertManagerConfig{
+		GroupWait:      "30s",
+		ResolveTimeout: "0s",
+		Route:          RouteConfig{Receiver: "sre"},
+		Receivers: []ReceiverConfig{{Name: "sre", EscalationPolicy: "critical-path",
+			Integrations: []IntegrationConfig{{Plugin: "mailer", OnCall: "sre"}}}},
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+	return m, incidents
+}
+
+// notifications 返回並清空已記錄通知附帶的告警通知信息
+func (n *recordingNotifier) notifications() []*entities.AlertNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out []*entities.AlertNotification
+	for _, msg := range n.messages {
+		out = append(out, plugins.AlertNotificationFromConfig(msg.metadata))
+	}
+	n.messages = nil
+	return out
+}
+
+func countEvents(incident *entities.Incident, eventType string) int {
+	count := 0
+	for _, event := range incident.Timeline {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestIncidentService_GroupsAlertsAndAcknowledgeStopsEscalation(t *testing.T) {
+	f := newFixture(t)
+	mailer := &recordingNotifier{}
+	m, incidents := f.incidentManager(t, mailer)
+	ctx := context.Background()
+
+	process(t, m, criticalResult("a"), criticalResult("b"))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+
+	sent := mailer.notifications()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(sent))
+	}
+	incidentID := sent[0].IncidentID
+	if incidentID == "" || sent[1].IncidentID != incidentID {
+		t.Fatalf("alerts of one group should share an incident, got %q and %q", incidentID, sent[1].IncidentID)
+	}
+	link, err := url.Parse(sent[0].AcknowledgeURL)
+	if err != nil || !strings.HasPrefix(sent[0].AcknowledgeURL, "https://detectviz.example.com/api/v1/incidents/"+incidentID+"/acknowledge?") {
+		t.Fatalf("unexpected acknowledge link %q", sent[0].AcknowledgeURL)
+	}
+	by, token := link.Query().Get("by"), link.Query().Get("token")
+	expires, _ := strconv.ParseInt(link.Query().Get("exp"), 10, 64)
+	if expires != f.clock.Now().Add(defaultAcknowledgeLinkTTL).Unix() {
+		t.Fatalf("acknowledge link should expire after the default TTL, got exp=%d", expires)
+	}
+	if _, err := incidents.VerifyAcknowledgeLink(ctx, incidentID, by, expires, token); by != "alice@example.com" || err != nil {
+		t.Fatalf("acknowledge link should be signed for the recipient, got by=%q err=%v", by, err)
+	}
+	if _, err := incidents.VerifyAcknowledgeLink(ctx, incidentID, "mallory@example.com", expires, token); !domainerrors.IsAuthError(err) {
+		t.Errorf("token must not verify for another recipient, got %v", err)
+	}
+	if _, err := incidents.VerifyAcknowledgeLink(ctx, incidentID, by, expires+3600, token); !domainerrors.IsAuthError(err) {
+		t.Errorf("token must not verify with an extended expiry, got %v", err)
+	}
+
+	incident, err := incidents.Get(ctx, incidentID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if incident.State != entities.IncidentStateOpen || len(incident.Alerts) != 2 ||
+		len(incident.AnalysisResultIDs) != 1 || countEvents(incident, entities.IncidentEventNotificationSent) != 2 {
+		t.Fatalf("unexpected incident after first notification: %+v", incident)
+	}
+
+	if incident, err = incidents.AcknowledgeWithLink(ctx, incidentID, by, expires, token); err != nil {
+		t.Fatalf("AcknowledgeWithLink() error = %v", err)
+	}
+	if incident.State != entities.IncidentStateAcknowledged || incident.AcknowledgedBy != "alice@example.com" {
+		t.Fatalf("unexpected incident after acknowledge: %+v", incident)
+	}
+	if _, err := incidents.VerifyAcknowledgeLink(ctx, incidentID, by, expires, token); !domainerrors.IsAuthError(err) {
+		t.Errorf("used acknowledge link must be rejected, got %v", err)
+	}
+	for _, host := range []string{"a", "b"} {
+		alert, _ := f.alerts.GetByFingerprint(ctx, entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": host}))
+		if !alert.IsAcknowledged() || alert.Escalation.Pending() {
+			t.Fatalf("member alert %s should be acknowledged without pending escalation", host)
+		}
+	}
+
+	// 加入已確認事件單的新告警同樣被確認，不會升級
+	process(t, m, criticalResult("c"))
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	sent = mailer.notifications()
+	if len(sent) == 0 {
+		t.Fatal("expected a group notification for the new alert")
+	}
+	for _, n := range sent {
+		if n.IncidentID != incidentID {
+			t.Fatalf("new alert should join the acknowledged incident, got %q", n.IncidentID)
+		}
+	}
+	if incident, _ = incidents.Get(ctx, incidentID); len(incident.Alerts) != 3 {
+		t.Fatalf("expected 3 member alerts, got %v", incident.Alerts)
+	}
+	f.clock.Advance(20 * time.Minute)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 0 {
+		t.Fatalf("acknowledged incident must not escalate: %v", got)
+	}
+
+	// 所有成員告警恢復後自動解決
+	for _, host := range []string{"a", "b", "c"} {
+		process(t, m, result("cpu", false, map[string]interface{}{"host": host}))
+	}
+	if incident, _ = incidents.Get(ctx, incidentID); incident.State != entities.IncidentStateResolved ||
+		incident.ResolvedBy != entities.IncidentActorSystem {
+		t.Fatalf("incident should resolve with its alerts, got %+v", incident)
+	}
+	f.clock.Advance(5 * time.Minute)
+	tick(t, m)
+	for _, n := range mailer.notifications() {
+		if n.IncidentID != incidentID || n.AcknowledgeURL != "" {
+			t.Errorf("resolved notification should reference the incident without acknowledge link: %+v", n)
+		}
+	}
+	if _, err := incidents.Acknowledge(ctx, incidentID, "alice"); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error acknowledging a resolved incident, got %v", err)
+	}
+}
+
+func TestIncidentService_ManualOperations(t *testing.T) {
+	f := newFixture(t)
+	m, incidents := f.incidentManager(t, &recordingNotifier{})
+	ctx := context.Background()
+
+	process(t, m, criticalResult("a"))
+	fingerprint := entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "a"})
+
+	if _, err := incidents.Create(ctx, &entities.Incident{Title: "db down", Alerts: []string{"missing"}}, "alice"); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for unknown alert, got %v", err)
+	}
+	incident, err := incidents.Create(ctx, &entities.Incident{Title: "db down", Assignee: "bob", Alerts: []string{fingerprint}}, "alice")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := incidents.Create(ctx, &entities.Incident{Title: "dup", Alerts: []string{fingerprint}}, "alice"); !domainerrors.IsValidationError(err) {
+		t.Errorf("alert already in an unresolved incident should be rejected, got %v", err)
+	}
+
+	if _, err := incidents.AddNote(ctx, incident.ID, "bob", "restarted primary"); err != nil {
+		t.Fatalf("AddNote() error = %v", err)
+	}
+	if _, err := incidents.Assign(ctx, incident.ID, "carol", "bob"); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	resolved, err := incidents.Resolve(ctx, incident.ID, "carol")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	types := make([]string, 0, len(resolved.Timeline))
+	for _, event := range resolved.Timeline {
+		types = append(types, event.Type)
+	}
+	want := []string{entities.IncidentEventCreated, entities.IncidentEventAlertAdded, entities.IncidentEventAssigned,
+		entities.IncidentEventNote, entities.IncidentEventAssigned, entities.IncidentEventStateChanged}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("timeline = %v, want %v", types, want)
+	}
+	if resolved.Assignee != "carol" || resolved.ResolvedBy != "carol" || len(resolved.AnalysisResultIDs) != 1 {
+		t.Errorf("unexpected resolved incident: %+v", resolved)
+	}
+
+	if open, _ := incidents.List(ctx, entities.IncidentStateOpen, 0); len(open) != 0 {
+		t.Errorf("expected no open incidents, got %d", len(open))
+	}
+	if _, err := incidents.List(ctx, "closed", 0); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for unknown state, got %v", err)
+	}
+	if _, err := incidents.AddNote(ctx, "missing", "bob", "x"); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestIncidentService_AcknowledgeLinkExpires(t *testing.T) {
+	f := newFixture(t)
+	m, incidents := f.incidentManager(t, &recordingNotifier{})
+	ctx := context.Background()
+
+	process(t, m, criticalResult("a"))
+	incident, err := incidents.Create(ctx, &entities.Incident{Title: "db down",
+		Alerts: []string{entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "a"})}}, "alice")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	link, _ := url.Parse(incidents.AcknowledgeURL(incident.ID, ""))
+	token := link.Query().Get("token")
+	expires, _ := strconv.ParseInt(link.Query().Get("exp"), 10, 64)
+
+	f.clock.Advance(defaultAcknowledgeLinkTTL)
+	if _, err := incidents.AcknowledgeWithLink(ctx, incident.ID, "", expires, token); !domainerrors.IsAuthError(err) {
+		t.Fatalf("expired acknowledge link must be rejected, got %v", err)
+	}
+	if incident, _ = incidents.Get(ctx, incident.ID); incident.State != entities.IncidentStateOpen {
+		t.Fatalf("expired link must not acknowledge the incident, got %s", incident.State)
+	}
+
+	link, _ = url.Parse(incidents.AcknowledgeURL(incident.ID, ""))
+	expires, _ = strconv.ParseInt(link.Query().Get("exp"), 10, 64)
+	if incident, err = incidents.AcknowledgeWithLink(ctx, incident.ID, "", expires, link.Query().Get("token")); err != nil {
+		t.Fatalf("AcknowledgeWithLink() error = %v", err)
+	}
+	if incident.AcknowledgedBy != acknowledgeLinkActor {
+		t.Errorf("link without recipient should acknowledge as %q, got %q", acknowledgeLinkActor, incident.AcknowledgedBy)
+	}
+}
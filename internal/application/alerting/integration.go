@@ -0,0 +1,111 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+)
+
+// integrationTarget 是解析後的通知渠道，封裝 AlertPlugin 與 NotificationPlugin 的調用差異
+type integrationTarget struct {
+	config       IntegrationConfig
+	alert        plugins.AlertPlugin
+	notification plugins.NotificationPlugin
+}
+
+// newIntegrationTarget 根據插件類型建立通知渠道。
+// 設置了 recipient 或 onCall 且插件實現 NotificationPlugin 時以通知方式發送，否則要求插件實現 AlertPlugin。
+func newIntegrationTarget(config IntegrationConfig, p interface{}) (*integrationTarget, error) {
+	target := &integrationTarget{config: config}
+	notification, isNotification := p.(plugins.NotificationPlugin)
+	alert, isAlert := p.(plugins.AlertPlugin)
+	switch {
+	case config.OnCall != "" && !isNotification:
+		return nil, fmt.Errorf("plugin %s must be a NotificationPlugin to notify on-call users", config.Plugin)
+	case isNotification && (config.Recipient != "" || config.OnCall != "" || !isAlert):
+		if config.Recipient == "" && config.OnCall == "" {
+			return nil, fmt.Errorf("notification plugin %s requires a recipient", config.Plugin)
+		}
+		target.notification = notification
+	case isAlert:
+		target.alert = alert
+	default:
+		return nil, fmt.Errorf("plugin %s is neither an AlertPlugin nor a NotificationPlugin", config.Plugin)
+	}
+	return target, nil
+}
+
+// send 將一個告警通知交給渠道插件，渠道設定與通知信息一起傳入
+func (t *integrationTarget) send(ctx context.Context, notification *entities.AlertNotification) error {
+	return t.sendTo(ctx, t.config.Recipient, notification)
+}
+
+// sendTo 與 send 相同，但 NotificationPlugin 改為發送給 recipient，用於值班者與升級目標
+func (t *integrationTarget) sendTo(ctx context.Context, recipient string, notification *entities.AlertNotification) error {
+	settings := make(map[string]interface{}, len(t.config.Settings)+1)
+	for k, v := range t.config.Settings {
+		settings[k] = v
+	}
+	settings[plugins.AlertConfigKeyNotification] = notification
+
+	if t.notification != nil {
+		return t.notification.SendNotification(ctx, recipient, notificationSubject(notification),
+			notificationBody(notification), settings)
+	}
+	return t.alert.TriggerAlert(ctx, notification.Alert.AnalysisResult(), settings)
+}
+
+// notificationSubject 生成通知標題，例如 "[FIRING] cpu above threshold"；
+// 升級通知加上層級，例如 "[FIRING][ESCALATION 2] cpu above threshold"
+func notificationSubject(n *entities.AlertNotification) string {
+	summary := n.Alert.Summary
+	if summary == "" {
+		summary = "detector " + n.Alert.DetectorID
+	}
+	if n.EscalationLevel > 0 {
+		return fmt.Sprintf("[%s][ESCALATION %d] %s", strings.ToUpper(n.Alert.State), n.EscalationLevel, summary)
+	}
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(n.Alert.State), summary)
+}
+
+// notificationBody 生成純文字通知內容
+func notificationBody(n *entities.AlertNotification) string {
+	var b strings.Builder
+	alert := n.Alert
+	fmt.Fprintf(&b, "State: %s\n", alert.State)
+	fmt.Fprintf(&b, "Severity: %s\n", alert.Severity)
+	fmt.Fprintf(&b, "Detector: %s\n", alert.DetectorID)
+	if alert.Summary != "" {
+		fmt.Fprintf(&b, "Summary: %s\n", alert.Summary)
+	}
+	fmt.Fprintf(&b, "Started: %s\n", alert.StartsAt.Format(time.RFC3339))
+	if alert.State == entities.AlertStateResolved {
+		fmt.Fprintf(&b, "Resolved: %s\n", alert.ResolvedAt.Format(time.RFC3339))
+	}
+
+	keys := make([]string, 0, len(alert.Labels))
+	for k := range alert.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b.WriteString("Labels:\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "  %s = %s\n", k, alert.Labels[k])
+	}
+	if n.EscalationLevel > 0 {
+		fmt.Fprintf(&b, "Escalation: level %d, not acknowledged since %s\n", n.EscalationLevel, alert.FiredAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "Fingerprint: %s\n", alert.Fingerprint)
+	if n.IncidentID != "" {
+		fmt.Fprintf(&b, "Incident: %s\n", n.IncidentID)
+	}
+	if n.AcknowledgeURL != "" {
+		fmt.Fprintf(&b, "Acknowledge: %s\n", n.AcknowledgeURL)
+	}
+	return b.String()
+}
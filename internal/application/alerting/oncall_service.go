@@ -0,0 +1,452 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// OnCallService 管理值班表與升級策略
+// 職責: 驗證並保存值班表、替班與升級策略；在通知時按團隊或值班表解析當時的值班者，
+// 並把升級目標解析為具有郵件地址的用戶，供告警管理器發送通知。
+type OnCallService struct {
+	schedules interfaces.OnCallScheduleRepository
+	policies  interfaces.EscalationPolicyRepository
+	users     interfaces.UserRepository
+	logger    contracts.Logger
+
+	now func() time.Time
+}
+
+// NewOnCallService 創建新的值班服務
+func NewOnCallService(schedules interfaces.OnCallScheduleRepository, policies interfaces.EscalationPolicyRepository,
+	users interfaces.UserRepository, logger contracts.Logger) *OnCallService {
+	return &OnCallService{
+		schedules: schedules,
+		policies:  policies,
+		users:     users,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// OnCallEntry 描述一個值班表在某一時刻的值班者
+type OnCallEntry struct {
+	ScheduleID   string
+	ScheduleName string
+	Team         string
+	User         *entities.User // 沒有人值班時為 nil
+}
+
+// CreateSchedule 驗證並創建值班表
+func (s *OnCallService) CreateSchedule(ctx context.Context, schedule *entities.OnCallSchedule) (*entities.OnCallSchedule, error) {
+	now := s.now()
+	schedule.ID = uuid.New().String()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	s.assignOverrideIDs(schedule)
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.schedules.Save(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("保存值班表失敗: %w", err)
+	}
+	s.logger.Info("已創建值班表", "id", schedule.ID, "name", schedule.Name, "team", schedule.Team, "timezone", schedule.Timezone)
+	return schedule, nil
+}
+
+// UpdateSchedule 以新的設定替換值班表，保留 ID 與創建時間
+func (s *OnCallService) UpdateSchedule(ctx context.Context, id string, schedule *entities.OnCallSchedule) (*entities.OnCallSchedule, error) {
+	existing, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	schedule.ID = existing.ID
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = s.now()
+	s.assignOverrideIDs(schedule)
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.schedules.Save(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("保存值班表失敗: %w", err)
+	}
+	s.logger.Info("已更新值班表", "id", schedule.ID, "name", schedule.Name, "team", schedule.Team)
+	return schedule, nil
+}
+
+// GetSchedule 獲取值班表
+func (s *OnCallService) GetSchedule(ctx context.Context, id string) (*entities.OnCallSchedule, error) {
+	schedule, err := s.schedules.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找值班表失敗: %w", err)
+	}
+	if schedule == nil {
+		return nil, domainerrors.NewNotFoundError("schedule", fmt.Sprintf("值班表不存在: %s", id))
+	}
+	return schedule, nil
+}
+
+// ListSchedules 列出值班表，team 非空時只返回該團隊的值班表
+func (s *OnCallService) ListSchedules(ctx context.Context, team string) ([]*entities.OnCallSchedule, error) {
+	var (
+		schedules []*entities.OnCallSchedule
+		err       error
+	)
+	if team != "" {
+		schedules, err = s.schedules.ListByTeam(ctx, team)
+	} else {
+		schedules, err = s.schedules.List(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("列出值班表失敗: %w", err)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule 刪除值班表
+func (s *OnCallService) DeleteSchedule(ctx context.Context, id string) error {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+	if err := s.schedules.Delete(ctx, id); err != nil {
+		return fmt.Errorf("刪除值班表失敗: %w", err)
+	}
+	s.logger.Info("已刪除值班表", "id", id)
+	return nil
+}
+
+// AddOverride 為值班表加入臨時替班，與已有替班重疊時新的替班優先
+func (s *OnCallService) AddOverride(ctx context.Context, scheduleID string, override entities.OnCallOverride) (*entities.OnCallSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	override.ID = uuid.New().String()
+	schedule.Overrides = append(schedule.Overrides, override)
+	schedule.UpdatedAt = s.now()
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.schedules.Save(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("保存值班表失敗: %w", err)
+	}
+	s.logger.Info("已加入替班", "schedule_id", schedule.ID, "user_id", override.UserID, "start", override.Start, "end", override.End)
+	return schedule, nil
+}
+
+// RemoveOverride 移除值班表的臨時替班
+func (s *OnCallService) RemoveOverride(ctx context.Context, scheduleID, overrideID string) (*entities.OnCallSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	overrides := schedule.Overrides[:0]
+	for _, override := range schedule.Overrides {
+		if override.ID != overrideID {
+			overrides = append(overrides, override)
+		}
+	}
+	if len(overrides) == len(schedule.Overrides) {
+		return nil, domainerrors.NewNotFoundError("override", fmt.Sprintf("替班不存在: %s", overrideID))
+	}
+	schedule.Overrides = overrides
+	schedule.UpdatedAt = s.now()
+	if err := s.schedules.Save(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("保存值班表失敗: %w", err)
+	}
+	s.logger.Info("已移除替班", "schedule_id", schedule.ID, "override_id", overrideID)
+	return schedule, nil
+}
+
+// ScheduleOnCall 返回值班表在 at 時的值班者
+func (s *OnCallService) ScheduleOnCall(ctx context.Context, scheduleID string, at time.Time) (*OnCallEntry, error) {
+	schedule, err := s.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.entry(ctx, schedule, at)
+}
+
+// TeamOnCall 返回團隊每個值班表在 at 時的值班者
+func (s *OnCallService) TeamOnCall(ctx context.Context, team string, at time.Time) ([]OnCallEntry, error) {
+	schedules, err := s.ListSchedules(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]OnCallEntry, 0, len(schedules))
+	for _, schedule := range schedules {
+		entry, err := s.entry(ctx, schedule, at)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// CurrentOnCall 返回團隊在 at 時的值班用戶，多個值班表的同一用戶只返回一次
+func (s *OnCallService) CurrentOnCall(ctx context.Context, team string, at time.Time) ([]*entities.User, error) {
+	return s.ResolveTargets(ctx, []entities.EscalationTarget{{Type: entities.EscalationTargetTeam, ID: team}}, at)
+}
+
+// ResolveTargets 把升級目標解析為 at 時應通知的用戶，按目標順序去重。
+// 已被刪除的用戶與沒有值班者的值班表會被跳過並記錄警告。
+func (s *OnCallService) ResolveTargets(ctx context.Context, targets []entities.EscalationTarget, at time.Time) ([]*entities.User, error) {
+	var (
+		users []*entities.User
+		seen  = make(map[string]bool)
+	)
+	add := func(userID string) error {
+		if userID == "" || seen[userID] {
+			return nil
+		}
+		seen[userID] = true
+		user, err := s.user(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			s.logger.Warn("值班用戶不存在，已跳過", "user_id", userID)
+			return nil
+		}
+		users = append(users, user)
+		return nil
+	}
+
+	for _, target := range targets {
+		var schedules []*entities.OnCallSchedule
+		switch target.Type {
+		case entities.EscalationTargetUser:
+			if err := add(target.ID); err != nil {
+				return nil, err
+			}
+			continue
+		case entities.EscalationTargetSchedule:
+			schedule, err := s.schedules.GetByID(ctx, target.ID)
+			if err != nil {
+				return nil, fmt.Errorf("查找值班表失敗: %w", err)
+			}
+			if schedule == nil {
+				s.logger.Warn("升級目標的值班表不存在", "schedule_id", target.ID)
+				continue
+			}
+			schedules = []*entities.OnCallSchedule{schedule}
+		case entities.EscalationTargetTeam:
+			var err error
+			if schedules, err = s.schedules.ListByTeam(ctx, target.ID); err != nil {
+				return nil, fmt.Errorf("列出值班表失敗: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown escalation target type %q", target.Type)
+		}
+		for _, schedule := range schedules {
+			userID, err := schedule.OnCallAt(at)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: %w", schedule.ID, err)
+			}
+			if userID == "" {
+				s.logger.Warn("值班表當前沒有值班者", "schedule_id", schedule.ID, "team", schedule.Team, "at", at)
+				continue
+			}
+			if err := add(userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return users, nil
+}
+
+// CreatePolicy 驗證並創建升級策略
+func (s *OnCallService) CreatePolicy(ctx context.Context, policy *entities.EscalationPolicy) (*entities.EscalationPolicy, error) {
+	now := s.now()
+	policy.ID = uuid.New().String()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	if err := s.policies.Save(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存升級策略失敗: %w", err)
+	}
+	s.logger.Info("已創建升級策略", "id", policy.ID, "name", policy.Name, "levels", len(policy.Levels))
+	return policy, nil
+}
+
+// UpdatePolicy 以新的設定替換升級策略，保留 ID 與創建時間。
+// 進行中的升級在下一層觸發時使用新的設定。
+func (s *OnCallService) UpdatePolicy(ctx context.Context, id string, policy *entities.EscalationPolicy) (*entities.EscalationPolicy, error) {
+	existing, err := s.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	policy.ID = existing.ID
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = s.now()
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	if err := s.policies.Save(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存升級策略失敗: %w", err)
+	}
+	s.logger.Info("已更新升級策略", "id", policy.ID, "name", policy.Name)
+	return policy, nil
+}
+
+// GetPolicy 獲取升級策略
+func (s *OnCallService) GetPolicy(ctx context.Context, id string) (*entities.EscalationPolicy, error) {
+	policy, err := s.policies.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找升級策略失敗: %w", err)
+	}
+	if policy == nil {
+		return nil, domainerrors.NewNotFoundError("escalation_policy", fmt.Sprintf("升級策略不存在: %s", id))
+	}
+	return policy, nil
+}
+
+// PolicyByName 按名稱獲取升級策略，不存在時返回 nil
+func (s *OnCallService) PolicyByName(ctx context.Context, name string) (*entities.EscalationPolicy, error) {
+	policy, err := s.policies.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("查找升級策略失敗: %w", err)
+	}
+	return policy, nil
+}
+
+// ListPolicies 列出升級策略
+func (s *OnCallService) ListPolicies(ctx context.Context) ([]*entities.EscalationPolicy, error) {
+	policies, err := s.policies.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("列出升級策略失敗: %w", err)
+	}
+	return policies, nil
+}
+
+// DeletePolicy 刪除升級策略，引用它的告警在下一層觸發時停止升級
+func (s *OnCallService) DeletePolicy(ctx context.Context, id string) error {
+	if _, err := s.GetPolicy(ctx, id); err != nil {
+		return err
+	}
+	if err := s.policies.Delete(ctx, id); err != nil {
+		return fmt.Errorf("刪除升級策略失敗: %w", err)
+	}
+	s.logger.Info("已刪除升級策略", "id", id)
+	return nil
+}
+
+// entry 計算單個值班表在 at 時的值班者
+func (s *OnCallService) entry(ctx context.Context, schedule *entities.OnCallSchedule, at time.Time) (*OnCallEntry, error) {
+	entry := &OnCallEntry{ScheduleID: schedule.ID, ScheduleName: schedule.Name, Team: schedule.Team}
+	userID, err := schedule.OnCallAt(at)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s: %w", schedule.ID, err)
+	}
+	if userID != "" {
+		if entry.User, err = s.user(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// user 按 ID 查找用戶，不存在時返回 nil
+func (s *OnCallService) user(ctx context.Context, id string) (*entities.User, error) {
+	idVO, err := valueobjects.NewIDVO(id)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := s.users.GetByID(ctx, idVO)
+	if err != nil {
+		return nil, fmt.Errorf("查找用戶失敗: %w", err)
+	}
+	return user, nil
+}
+
+// validateSchedule 檢查值班表設定，並確認所有參與者與替班用戶都存在
+func (s *OnCallService) validateSchedule(ctx context.Context, schedule *entities.OnCallSchedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.Team = strings.TrimSpace(schedule.Team)
+	if err := schedule.Validate(); err != nil {
+		return domainerrors.NewValidationError("schedule", err.Error())
+	}
+	var userIDs []string
+	for _, layer := range schedule.Layers {
+		userIDs = append(userIDs, layer.Participants...)
+	}
+	for _, override := range schedule.Overrides {
+		userIDs = append(userIDs, override.UserID)
+	}
+	return s.requireUsers(ctx, "participants", userIDs)
+}
+
+// validatePolicy 檢查升級策略設定、名稱唯一性，並確認引用的用戶與值班表都存在
+func (s *OnCallService) validatePolicy(ctx context.Context, policy *entities.EscalationPolicy) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	if err := policy.Validate(); err != nil {
+		return domainerrors.NewValidationError("policy", err.Error())
+	}
+	existing, err := s.policies.GetByName(ctx, policy.Name)
+	if err != nil {
+		return fmt.Errorf("查找升級策略失敗: %w", err)
+	}
+	if existing != nil && existing.ID != policy.ID {
+		return domainerrors.NewValidationError("name", fmt.Sprintf("升級策略名稱已存在: %s", policy.Name))
+	}
+
+	var userIDs []string
+	for _, level := range policy.Levels {
+		for _, target := range level.Targets {
+			switch target.Type {
+			case entities.EscalationTargetUser:
+				userIDs = append(userIDs, target.ID)
+			case entities.EscalationTargetSchedule:
+				schedule, err := s.schedules.GetByID(ctx, target.ID)
+				if err != nil {
+					return fmt.Errorf("查找值班表失敗: %w", err)
+				}
+				if schedule == nil {
+					return domainerrors.NewValidationError("targets", fmt.Sprintf("值班表不存在: %s", target.ID))
+				}
+			}
+		}
+	}
+	return s.requireUsers(ctx, "targets", userIDs)
+}
+
+// requireUsers 確認所有用戶 ID 都對應已存在的用戶
+func (s *OnCallService) requireUsers(ctx context.Context, field string, ids []string) error {
+	checked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if checked[id] {
+			continue
+		}
+		checked[id] = true
+		user, err := s.user(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return domainerrors.NewValidationError(field, fmt.Sprintf("用戶不存在: %s", id))
+		}
+	}
+	return nil
+}
+
+// assignOverrideIDs 為沒有 ID 的替班生成 ID，以便之後單獨移除
+func (s *OnCallService) assignOverrideIDs(schedule *entities.OnCallSchedule) {
+	for i := range schedule.Overrides {
+		if schedule.Overrides[i].ID == "" {
+			schedule.Overrides[i].ID = uuid.New().String()
+		}
+	}
+}
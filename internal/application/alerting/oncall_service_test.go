@@ -0,0 +1,314 @@
+package alerting
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/valueobjects"
+)
+
+type memoryScheduleRepo struct {
+	mu        sync.Mutex
+	schedules map[string]entities.OnCallSchedule
+}
+
+func (r *memoryScheduleRepo) Save(ctx context.Context, schedule *entities.OnCallSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedules[schedule.ID] = *schedule
+	return nil
+}
+
+func (r *memoryScheduleRepo) GetByID(ctx context.Context, id string) (*entities.OnCallSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+	return &schedule, nil
+}
+
+func (r *memoryScheduleRepo) List(ctx context.Context) ([]*entities.OnCallSchedule, error) {
+	return r.ListByTeam(ctx, "")
+}
+
+func (r *memoryScheduleRepo) ListByTeam(ctx context.Context, team string) ([]*entities.OnCallSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.OnCallSchedule
+	for _, schedule := range r.schedules {
+		if team == "" || schedule.Team == team {
+			s := schedule
+			out = append(out, &s)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryScheduleRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.schedules, id)
+	return nil
+}
+
+type memoryPolicyRepo struct {
+	mu       sync.Mutex
+	policies map[string]entities.EscalationPolicy
+}
+
+func (r *memoryPolicyRepo) Save(ctx context.Context, policy *entities.EscalationPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+func (r *memoryPolicyRepo) GetByID(ctx context.Context, id string) (*entities.EscalationPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	policy, ok := r.policies[id]
+	if !ok {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+func (r *memoryPolicyRepo) GetByName(ctx context.Context, name string) (*entities.EscalationPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, policy := range r.policies {
+		if policy.Name == name {
+			p := policy
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryPolicyRepo) List(ctx context.Context) ([]*entities.EscalationPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.EscalationPolicy
+	for _, policy := range r.policies {
+		p := policy
+		out = append(out, &p)
+	}
+	return out, nil
+}
+
+func (r *memoryPolicyRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.policies, id)
+	return nil
+}
+
+type memoryUserRepo struct {
+	users map[string]*entities.User
+}
+
+func (r *memoryUserRepo) Create(ctx context.Context, user *entities.User) error { return nil }
+func (r *memoryUserRepo) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.User, error) {
+	return r.users[id.String()], nil
+}
+func (r *memoryUserRepo) GetByEmail(ctx context.Context, email valueobjects.EmailVO) (*entities.User, error) {
+	return nil, nil
+}
+func (r *memoryUserRepo) Update(ctx context.Context, user *entities.User) error  { return nil }
+func (r *memoryUserRepo) Delete(ctx context.Context, id valueobjects.IDVO) error { return nil }
+func (r *memoryUserRepo) List(ctx context.Context, offset, limit int) ([]*entities.User, error) {
+	return nil, nil
+}
+
+const (
+	aliceID   = "0b5c1e6a-1f3e-4c1a-9a55-6b1c2d3e4f01"
+	bobID     = "0b5c1e6a-1f3e-4c1a-9a55-6b1c2d3e4f02"
+	managerID = "0b5c1e6a-1f3e-4c1a-9a55-6b1c2d3e4f03"
+)
+
+// onCallFixture 建立兩個值班表 (sre 由 alice 值班，sre-secondary 由 bob 值班) 與一個兩層的升級策略
+func onCallFixture(t *testing.T, f *fixture) *OnCallService {
+	t.Helper()
+	s := NewOnCallService(
+		&memoryScheduleRepo{schedules: map[string]entities.OnCallSchedule{}},
+		&memoryPolicyRepo{policies: map[string]entities.EscalationPolicy{}},
+		&memoryUserRepo{users: map[string]*entities.User{
+			aliceID:   {ID: aliceID, Email: "alice@example.com"},
+			bobID:     {ID: bobID, Email: "bob@example.com"},
+			managerID: {ID: managerID, Email: "manager@example.com"},
+		}},
+		&testLogger{},
+	)
+	s.now = f.clock.Now
+	ctx := context.Background()
+	start := f.clock.Now().Add(-24 * time.Hour)
+
+	if _, err := s.CreateSchedule(ctx, &entities.OnCallSchedule{Name: "primary", Team: "sre", Timezone: "UTC",
+		Layers: []entities.OnCallLayer{{Start: start, Rotation: entities.RotationWeekly, Participants: []string{aliceID}}}}); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	secondary, err := s.CreateSchedule(ctx, &entities.OnCallSchedule{Name: "secondary", Team: "sre-secondary",
+		Layers: []entities.OnCallLayer{{Start: start, Rotation: entities.RotationDaily, Participants: []string{bobID}}}})
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if _, err := s.CreatePolicy(ctx, &entities.EscalationPolicy{Name: "critical-path", Levels: []entities.EscalationLevel{
+		{Delay: 5 * time.Minute, Targets: []entities.EscalationTarget{{Type: entities.EscalationTargetSchedule, ID: secondary.ID}}},
+		{Delay: 10 * time.Minute, Targets: []entities.EscalationTarget{{Type: entities.EscalationTargetUser, ID: managerID}}},
+	}}); err != nil {
+		t.Fatalf("CreatePolicy() error = %v", err)
+	}
+	return s
+}
+
+func (f *fixture) escalatingManager(t *testing.T, oncall *OnCallService, mailer *recordingNotifier) *AlertManager {
+	t.Helper()
+	if err := f.registry.Register("mailer", mailer); err != nil {
+		t.Fatalf("register mailer: %v", err)
+	}
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, OnCall: oncall}, AlertManagerConfig{
+		GroupWait:      "30s",
+		ResolveTimeout: "0s",
+		Route:          RouteConfig{Receiver: "sre"},
+		Receivers: []ReceiverConfig{{Name: "sre", EscalationPolicy: "critical-path",
+			Integrations: []IntegrationConfig{{Plugin: "mailer", OnCall: "sre"}}}},
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+	return m
+}
+
+// take 返回並清空已記錄的 "收件人 標題"
+func (n *recordingNotifier) take() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out []string
+	for _, msg := range n.messages {
+		out = append(out, msg.recipient+" "+msg.subject)
+	}
+	n.messages = nil
+	return out
+}
+
+func criticalResult(host string) *entities.AnalysisResult {
+	r := result("cpu", true, map[string]interface{}{"host": host})
+	r.Severity = "critical"
+	return r
+}
+
+func TestAlertManager_OnCallRoutingAndEscalation(t *testing.T) {
+	f := newFixture(t)
+	mailer := &recordingNotifier{}
+	m := f.escalatingManager(t, onCallFixture(t, f), mailer)
+
+	process(t, m, criticalResult("a"))
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 1 || got[0] != "alice@example.com [FIRING] cpu above threshold" {
+		t.Fatalf("expected the current on-call to be notified, got %v", got)
+	}
+
+	// 第一層在通知後 5 分鐘未確認時通知 secondary 值班表
+	f.clock.Advance(4 * time.Minute)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 0 {
+		t.Fatalf("escalated too early: %v", got)
+	}
+	f.clock.Advance(time.Minute)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 1 || got[0] != "bob@example.com [FIRING][ESCALATION 1] cpu above threshold" {
+		t.Fatalf("level 1 escalation = %v", got)
+	}
+
+	f.clock.Advance(10 * time.Minute)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 1 || !strings.HasPrefix(got[0], "manager@example.com [FIRING][ESCALATION 2]") {
+		t.Fatalf("level 2 escalation = %v", got)
+	}
+
+	// 策略不重複，最後一層之後不再升級
+	f.clock.Advance(time.Hour)
+	tick(t, m)
+	for _, msg := range mailer.take() {
+		if strings.Contains(msg, "ESCALATION") {
+			t.Fatalf("unexpected escalation after last level: %s", msg)
+		}
+	}
+}
+
+func TestAlertManager_AcknowledgeStopsEscalation(t *testing.T) {
+	f := newFixture(t)
+	mailer := &recordingNotifier{}
+	m := f.escalatingManager(t, onCallFixture(t, f), mailer)
+
+	warning := result("cpu", true, map[string]interface{}{"host": "b"})
+	warning.Severity = "warning"
+	process(t, m, criticalResult("a"), warning)
+	f.clock.Advance(30 * time.Second)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 2 {
+		t.Fatalf("expected both alerts to be notified, got %v", got)
+	}
+
+	critical := entities.AlertFingerprint(map[string]string{"detector_id": "cpu", "host": "a"})
+	alert, err := m.Acknowledge(context.Background(), critical, "alice")
+	if err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if alert.AcknowledgedBy != "alice" || alert.Escalation.Pending() {
+		t.Fatalf("acknowledged alert still escalating: %+v", alert.Escalation)
+	}
+
+	// 已確認的 critical 告警與不需要升級的 warning 告警都不會升級
+	f.clock.Advance(20 * time.Minute)
+	tick(t, m)
+	if got := mailer.take(); len(got) != 0 {
+		t.Fatalf("unexpected escalation: %v", got)
+	}
+
+	if _, err := m.Acknowledge(context.Background(), "missing", "alice"); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestOnCallService_Validation(t *testing.T) {
+	f := newFixture(t)
+	s := onCallFixture(t, f)
+	ctx := context.Background()
+
+	_, err := s.CreateSchedule(ctx, &entities.OnCallSchedule{Name: "ghost", Team: "sre",
+		Layers: []entities.OnCallLayer{{Start: f.clock.Now(), Rotation: entities.RotationDaily,
+			Participants: []string{"0b5c1e6a-1f3e-4c1a-9a55-6b1c2d3e4f99"}}}})
+	if !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for unknown participant, got %v", err)
+	}
+	_, err = s.CreatePolicy(ctx, &entities.EscalationPolicy{Name: "critical-path", Levels: []entities.EscalationLevel{
+		{Targets: []entities.EscalationTarget{{Type: entities.EscalationTargetTeam, ID: "sre"}}},
+	}})
+	if !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for duplicate policy name, got %v", err)
+	}
+
+	// 替班優先於輪值層
+	schedules, _ := s.ListSchedules(ctx, "sre")
+	end := f.clock.Now().Add(time.Hour)
+	if _, err := s.AddOverride(ctx, schedules[0].ID, entities.OnCallOverride{UserID: managerID, Start: f.clock.Now(), End: end}); err != nil {
+		t.Fatalf("AddOverride() error = %v", err)
+	}
+	users, err := s.CurrentOnCall(ctx, "sre", f.clock.Now())
+	if err != nil || len(users) != 1 || users[0].ID != managerID {
+		t.Fatalf("CurrentOnCall() = %v, %v", users, err)
+	}
+	if users, _ := s.CurrentOnCall(ctx, "sre", end); len(users) != 1 || users[0].ID != aliceID {
+		t.Errorf("override should end at %s, got %v", end, users)
+	}
+}
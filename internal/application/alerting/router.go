@@ -0,0 +1,207 @@
+package alerting
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// RootRouteID 是路由樹根節點的標識
+const RootRouteID = "root"
+
+// RouteConfig 定義路由樹中的一個節點。
+// 子路由未設置的 receiver、groupBy 與通知時間繼承自父路由。
+type RouteConfig struct {
+	Receiver       string        `yaml:"receiver" json:"receiver"`             // 匹配後使用的接收者名稱
+	Matchers       []string      `yaml:"matchers" json:"matchers"`             // 標籤匹配條件，例如 "severity=critical"、"owner=~team-.*"；根路由忽略此欄位
+	GroupBy        []string      `yaml:"groupBy" json:"groupBy"`               // 分組標籤
+	GroupWait      string        `yaml:"groupWait" json:"groupWait"`           // 新分組第一次通知前的等待時間
+	GroupInterval  string        `yaml:"groupInterval" json:"groupInterval"`   // 分組內有變化時兩次通知的最小間隔
+	RepeatInterval string        `yaml:"repeatInterval" json:"repeatInterval"` // 沒有變化時重複通知的間隔
+	Continue       bool          `yaml:"continue" json:"continue"`             // 匹配後是否繼續嘗試後面的兄弟路由
+	Routes         []RouteConfig `yaml:"routes" json:"routes"`                 // 子路由，按順序匹配
+}
+
+// ReceiverConfig 定義一個接收者及其通知渠道
+type ReceiverConfig struct {
+	Name             string              `yaml:"name" json:"name"`                         // 接收者名稱，供路由引用
+	Integrations     []IntegrationConfig `yaml:"integrations" json:"integrations"`         // 通知渠道，每個都會收到路由到此接收者的告警
+	EscalationPolicy string              `yaml:"escalationPolicy" json:"escalationPolicy"` // 升級策略名稱，需要升級的告警在此接收者通知後未確認即按策略升級
+}
+
+// IntegrationConfig 定義接收者中的一個通知渠道。
+// Plugin 為 AlertPlugin 時以 Settings 作為 alertConfig 調用 TriggerAlert；
+// 為 NotificationPlugin 時向 Recipient 發送通知，Settings 作為 metadata 傳入；
+// 設置 OnCall 時改為在通知當下向該團隊的值班者 (以用戶郵件地址) 發送。
+type IntegrationConfig struct {
+	Plugin    string                 `yaml:"plugin" json:"plugin"`           // 註冊表中的插件名稱
+	Recipient string                 `yaml:"recipient" json:"recipient"`     // NotificationPlugin 的接收者，例如郵件地址
+	OnCall    string                 `yaml:"onCall" json:"onCall,omitempty"` // 團隊名稱，通知當下的值班者為接收者；與 recipient 互斥
+	Settings  map[string]interface{} `yaml:"settings" json:"settings"`       // 此渠道專屬的設定
+}
+
+// Route 是編譯後的路由節點
+type Route struct {
+	ID             string
+	Receiver       string
+	Matchers       []entities.LabelMatcher
+	GroupBy        []string
+	GroupWait      time.Duration
+	GroupInterval  time.Duration
+	RepeatInterval time.Duration
+	Continue       bool
+	Routes         []*Route
+}
+
+// Router 以路由樹為告警選擇接收者。
+// 職責: 從根節點開始按順序匹配子路由，匹配到的子路由繼續向下匹配；子路由沒有設置 continue 時
+// 第一個匹配即停止，設置 continue 時同一告警可以進入多個兄弟路由。只有沒有任何子路由匹配時才使用
+// 當前節點，因此根路由就是默認路由。
+type Router struct {
+	root      *Route
+	routes    map[string]*Route
+	receivers map[string]ReceiverConfig
+}
+
+// NewRouter 編譯路由樹並檢查每個路由引用的接收者都已定義。
+// 根路由的 groupBy 與通知時間未設置時使用 defaults。
+func NewRouter(config RouteConfig, receivers []ReceiverConfig, defaults Route) (*Router, error) {
+	r := &Router{
+		routes:    make(map[string]*Route),
+		receivers: make(map[string]ReceiverConfig, len(receivers)),
+	}
+	for _, receiver := range receivers {
+		if receiver.Name == "" {
+			return nil, fmt.Errorf("receiver name is required")
+		}
+		if _, ok := r.receivers[receiver.Name]; ok {
+			return nil, fmt.Errorf("duplicate receiver %s", receiver.Name)
+		}
+		for i, integration := range receiver.Integrations {
+			if integration.Plugin == "" {
+				return nil, fmt.Errorf("receiver %s integration %d: plugin is required", receiver.Name, i)
+			}
+			if integration.OnCall != "" && integration.Recipient != "" {
+				return nil, fmt.Errorf("receiver %s integration %d: recipient and onCall are mutually exclusive", receiver.Name, i)
+			}
+		}
+		r.receivers[receiver.Name] = receiver
+	}
+
+	if config.Receiver == "" {
+		config.Receiver = DefaultReceiver
+		if _, ok := r.receivers[DefaultReceiver]; !ok {
+			// 未配置路由時保持只記錄狀態的行為
+			r.receivers[DefaultReceiver] = ReceiverConfig{Name: DefaultReceiver}
+		}
+	}
+	// 根路由匹配所有告警
+	config.Matchers = nil
+
+	root, err := r.compile(config, &defaults, RootRouteID)
+	if err != nil {
+		return nil, err
+	}
+	r.root = root
+	return r, nil
+}
+
+// compile 遞歸編譯路由節點，未設置的欄位繼承自 parent
+func (r *Router) compile(config RouteConfig, parent *Route, id string) (*Route, error) {
+	route := &Route{
+		ID:             id,
+		Receiver:       config.Receiver,
+		GroupBy:        config.GroupBy,
+		GroupWait:      parent.GroupWait,
+		GroupInterval:  parent.GroupInterval,
+		RepeatInterval: parent.RepeatInterval,
+		Continue:       config.Continue,
+	}
+	if route.Receiver == "" {
+		route.Receiver = parent.Receiver
+	}
+	if _, ok := r.receivers[route.Receiver]; !ok {
+		return nil, fmt.Errorf("route %s: receiver %s is not defined", id, route.Receiver)
+	}
+	if len(route.GroupBy) == 0 {
+		route.GroupBy = parent.GroupBy
+	}
+	for _, expr := range config.Matchers {
+		m, err := entities.ParseLabelMatcher(expr)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", id, err)
+		}
+		route.Matchers = append(route.Matchers, m)
+	}
+
+	var err error
+	if route.GroupWait, err = parseOptionalDuration(config.GroupWait, route.GroupWait); err != nil {
+		return nil, fmt.Errorf("route %s: invalid groupWait: %w", id, err)
+	}
+	if route.GroupInterval, err = parseDurationDefault(config.GroupInterval, route.GroupInterval); err != nil {
+		return nil, fmt.Errorf("route %s: invalid groupInterval: %w", id, err)
+	}
+	if route.RepeatInterval, err = parseDurationDefault(config.RepeatInterval, route.RepeatInterval); err != nil {
+		return nil, fmt.Errorf("route %s: invalid repeatInterval: %w", id, err)
+	}
+
+	r.routes[id] = route
+	for i, child := range config.Routes {
+		compiled, err := r.compile(child, route, id+"."+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		route.Routes = append(route.Routes, compiled)
+	}
+	return route, nil
+}
+
+// Match 返回告警標籤匹配到的路由，按路由樹深度優先順序排列
+func (r *Router) Match(labels map[string]string) []*Route {
+	return r.root.match(labels)
+}
+
+// match 返回節點下匹配的路由；沒有子路由匹配時返回節點本身
+func (route *Route) match(labels map[string]string) []*Route {
+	var matched []*Route
+	for _, child := range route.Routes {
+		if !entities.MatchAll(child.Matchers, labels) {
+			continue
+		}
+		matched = append(matched, child.match(labels)...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(matched) == 0 {
+		return []*Route{route}
+	}
+	return matched
+}
+
+// Route 根據標識返回路由，路由配置變更後已不存在時返回根路由
+func (r *Router) Route(id string) *Route {
+	if route, ok := r.routes[id]; ok {
+		return route
+	}
+	return r.root
+}
+
+// Receiver 根據名稱返回接收者配置
+func (r *Router) Receiver(name string) (ReceiverConfig, bool) {
+	receiver, ok := r.receivers[name]
+	return receiver, ok
+}
+
+// GroupLabels 返回告警在此路由下的分組標籤
+func (route *Route) GroupLabels(labels map[string]string) map[string]string {
+	groupLabels := make(map[string]string, len(route.GroupBy))
+	for _, name := range route.GroupBy {
+		if value, ok := labels[name]; ok {
+			groupLabels[name] = value
+		}
+	}
+	return groupLabels
+}
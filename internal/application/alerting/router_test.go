@@ -0,0 +1,194 @@
+package alerting
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/valueobjects"
+)
+
+func routeReceivers(routes []*Route) []string {
+	var out []string
+	for _, r := range routes {
+		out = append(out, r.Receiver)
+	}
+	return out
+}
+
+func TestRouter_Match(t *testing.T) {
+	receivers := []ReceiverConfig{{Name: "ops"}, {Name: "oncall"}, {Name: "db-team"}, {Name: "db-critical"}, {Name: "audit"}}
+	router, err := NewRouter(RouteConfig{
+		Receiver: "ops",
+		Routes: []RouteConfig{
+			{Receiver: "audit", Matchers: []string{"severity=~high|critical"}, Continue: true},
+			{
+				Receiver: "db-team",
+				Matchers: []string{"owner=team-db"},
+				GroupBy:  []string{"host"},
+				Routes: []RouteConfig{
+					{Receiver: "db-critical", Matchers: []string{"severity=critical"}, GroupWait: "0s"},
+				},
+			},
+			{Receiver: "oncall", Matchers: []string{"severity=critical"}},
+		},
+	}, receivers, Route{GroupBy: []string{"detector_id"}, GroupWait: 30 * time.Second, GroupInterval: 5 * time.Minute, RepeatInterval: 4 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{"default route", map[string]string{"severity": "low"}, []string{"ops"}},
+		// 有子路由匹配時不再使用父路由，即使匹配的子路由設置了 continue
+		{"continue without further match", map[string]string{"severity": "high"}, []string{"audit"}},
+		{"continue then first match stops", map[string]string{"severity": "critical"}, []string{"audit", "oncall"}},
+		{"nested route without child match", map[string]string{"severity": "low", "owner": "team-db"}, []string{"db-team"}},
+		{"nested child wins", map[string]string{"severity": "critical", "owner": "team-db"}, []string{"audit", "db-critical"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routeReceivers(router.Match(tt.labels)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match(%v) = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+
+	// 子路由繼承父路由的 groupBy 與通知時間
+	child := router.Match(map[string]string{"severity": "critical", "owner": "team-db"})[1]
+	if child.ID != "root.1.0" || !reflect.DeepEqual(child.GroupBy, []string{"host"}) {
+		t.Errorf("child route = %s group_by %v, want root.1.0 group_by [host]", child.ID, child.GroupBy)
+	}
+	if child.GroupWait != 0 || child.GroupInterval != 5*time.Minute || child.RepeatInterval != 4*time.Hour {
+		t.Errorf("unexpected child timers: %s %s %s", child.GroupWait, child.GroupInterval, child.RepeatInterval)
+	}
+	if router.Route("root.9").ID != RootRouteID {
+		t.Error("unknown route id should fall back to the root route")
+	}
+}
+
+func TestRouter_InvalidConfig(t *testing.T) {
+	defaults := Route{GroupInterval: time.Minute, RepeatInterval: time.Hour}
+	for name, tc := range map[string]struct {
+		route     RouteConfig
+		receivers []ReceiverConfig
+	}{
+		"undefined receiver":   {RouteConfig{Receiver: "missing"}, nil},
+		"undefined child":      {RouteConfig{Routes: []RouteConfig{{Receiver: "missing"}}}, nil},
+		"invalid matcher":      {RouteConfig{Routes: []RouteConfig{{Matchers: []string{"severity"}}}}, nil},
+		"duplicate receiver":   {RouteConfig{}, []ReceiverConfig{{Name: "a"}, {Name: "a"}}},
+		"integration plugin":   {RouteConfig{Receiver: "a"}, []ReceiverConfig{{Name: "a", Integrations: []IntegrationConfig{{}}}}},
+		"invalid groupWait":    {RouteConfig{GroupWait: "soon"}, nil},
+		"zero repeat interval": {RouteConfig{Routes: []RouteConfig{{RepeatInterval: "0s"}}}, nil},
+	} {
+		if _, err := NewRouter(tc.route, tc.receivers, defaults); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+type memoryDetectorRepo struct {
+	detectors map[string]*entities.Detector
+}
+
+func (r *memoryDetectorRepo) Create(ctx context.Context, d *entities.Detector) error { return nil }
+func (r *memoryDetectorRepo) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.Detector, error) {
+	return r.detectors[id.String()], nil
+}
+func (r *memoryDetectorRepo) Update(ctx context.Context, d *entities.Detector) error { return nil }
+func (r *memoryDetectorRepo) Delete(ctx context.Context, id valueobjects.IDVO) error { return nil }
+func (r *memoryDetectorRepo) List(ctx context.Context, offset, limit int) ([]*entities.Detector, error) {
+	return nil, nil
+}
+
+// recordingNotifier 記錄 NotificationPlugin 收到的通知
+type recordingNotifier struct {
+	mu       sync.Mutex
+	messages []notifierMessage
+}
+
+type notifierMessage struct {
+	recipient, subject string
+	metadata           map[string]interface{}
+}
+
+func (n *recordingNotifier) GetName() string                                            { return "mailer" }
+func (n *recordingNotifier) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (n *recordingNotifier) Start(ctx context.Context) error                            { return nil }
+func (n *recordingNotifier) Stop(ctx context.Context) error                             { return nil }
+
+func (n *recordingNotifier) SendNotification(ctx context.Context, recipient, subject, body string, metadata map[string]interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, notifierMessage{recipient: recipient, subject: subject, metadata: metadata})
+	return nil
+}
+
+func TestAlertManager_RoutesToReceivers(t *testing.T) {
+	f := newFixture(t)
+	mailer := &recordingNotifier{}
+	if err := f.registry.Register("mailer", mailer); err != nil {
+		t.Fatalf("register mailer: %v", err)
+	}
+	dbDetector := "6f1c2b1e-8a4d-4c35-9d55-0f4bb2d0a001"
+	m, err := NewAlertManager(AlertManagerDeps{Alerts: f.alerts, Groups: f.groups, Registry: f.registry, Detectors: &memoryDetectorRepo{detectors: map[string]*entities.Detector{
+		dbDetector: {ID: dbDetector, OwnerID: "team-db"},
+	}}}, AlertManagerConfig{
+		GroupWait: "30s",
+		Route: RouteConfig{
+			Receiver: "ops",
+			Routes: []RouteConfig{
+				{Receiver: "db-team", Matchers: []string{"owner=team-db"}, GroupWait: "10s", Continue: true},
+				{Receiver: "ops", Matchers: []string{"severity=~.+"}},
+			},
+		},
+		Receivers: []ReceiverConfig{
+			{Name: "ops", Integrations: []IntegrationConfig{{Plugin: "recorder", Settings: map[string]interface{}{"channel": "#ops"}}}},
+			{Name: "db-team", Integrations: []IntegrationConfig{{Plugin: "mailer", Recipient: "db@example.com"}}},
+		},
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewAlertManager() error = %v", err)
+	}
+	m.now = f.clock.Now
+
+	process(t, m, result(dbDetector, true, map[string]interface{}{"host": "db-1"}), result("cpu", true, map[string]interface{}{"host": "web-1"}))
+
+	// db-team 路由的 group_wait 較短，先收到通知
+	f.clock.Advance(10 * time.Second)
+	tick(t, m)
+	if len(mailer.messages) != 1 || mailer.messages[0].recipient != "db@example.com" ||
+		mailer.messages[0].subject != "[FIRING] cpu above threshold" {
+		t.Fatalf("unexpected mailer messages: %+v", mailer.messages)
+	}
+	if n, ok := mailer.messages[0].metadata["_alert_notification"].(*entities.AlertNotification); !ok || n.Receiver != "db-team" {
+		t.Errorf("notification metadata missing or wrong receiver: %+v", mailer.messages[0].metadata)
+	}
+	if got := f.recorder.take(); len(got) != 0 {
+		t.Fatalf("ops notified before its group_wait: %v", states(got))
+	}
+
+	// owner 路由設置了 continue，因此 db-1 也會匹配後面的 ops 路由
+	f.clock.Advance(20 * time.Second)
+	tick(t, m)
+	if got := states(f.recorder.take()); !reflect.DeepEqual(got, []string{"db-1:firing", "web-1:firing"}) {
+		t.Fatalf("ops notifications = %v", got)
+	}
+
+	res, err := m.TestRoute(context.Background(), dbDetector, "critical", map[string]string{"host": "db-2"})
+	if err != nil {
+		t.Fatalf("TestRoute() error = %v", err)
+	}
+	if res.Labels["owner"] != "team-db" || len(res.Routes) != 2 || res.Routes[0].Receiver != "db-team" || res.Routes[1].Receiver != "ops" {
+		t.Fatalf("unexpected test route result: %+v", res)
+	}
+	if res.Routes[1].Integrations[0].Settings != nil {
+		t.Error("test route should not expose integration settings")
+	}
+}
@@ -0,0 +1,249 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SilenceRequest 描述創建靜默的請求
+type SilenceRequest struct {
+	Matchers  []entities.LabelMatcher `json:"matchers"`
+	StartsAt  time.Time               `json:"startsAt"`  // 零值表示立即開始
+	EndsAt    time.Time               `json:"endsAt"`    // 一次性靜默必填；週期性靜默為零值時長期有效
+	Schedule  string                  `json:"schedule"`  // 週期性維護窗口的 cron 表達式
+	Duration  time.Duration           `json:"duration"`  // 每個維護窗口的長度
+	CreatedBy string                  `json:"createdBy"` // 創建者
+	Comment   string                  `json:"comment"`   // 靜默原因
+}
+
+// SilenceService 管理告警靜默
+// 職責: 驗證並保存靜默規則、手動結束靜默，並計算某一時刻正在生效的靜默供告警管理器抑制通知。
+// 靜默的狀態由時間推導，過了結束時間即自動失效，無需背景任務更新。
+type SilenceService struct {
+	silences interfaces.SilenceRepository
+	logger   contracts.Logger
+
+	now func() time.Time
+}
+
+// NewSilenceService 創建新的靜默服務
+func NewSilenceService(silences interfaces.SilenceRepository, logger contracts.Logger) *SilenceService {
+	return &SilenceService{
+		silences: silences,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Create 驗證並創建靜默
+func (s *SilenceService) Create(ctx context.Context, req SilenceRequest) (*entities.Silence, error) {
+	now := s.now()
+	silence := &entities.Silence{
+		ID:        uuid.New().String(),
+		Matchers:  req.Matchers,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Schedule:  strings.TrimSpace(req.Schedule),
+		Duration:  req.Duration,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if err := validateSilence(silence, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.silences.Save(ctx, silence); err != nil {
+		return nil, fmt.Errorf("保存靜默失敗: %w", err)
+	}
+	s.logger.Info("已創建靜默", "id", silence.ID, "matchers", silence.Matchers, "starts_at", silence.StartsAt,
+		"ends_at", silence.EndsAt, "schedule", silence.Schedule, "created_by", silence.CreatedBy)
+	return silence, nil
+}
+
+// Get 獲取靜默
+func (s *SilenceService) Get(ctx context.Context, id string) (*entities.Silence, error) {
+	silence, err := s.silences.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找靜默失敗: %w", err)
+	}
+	if silence == nil {
+		return nil, domainerrors.NewNotFoundError("silence", fmt.Sprintf("靜默不存在: %s", id))
+	}
+	return silence, nil
+}
+
+// List 列出靜默，includeExpired 為 false 時只返回尚未結束的靜默
+func (s *SilenceService) List(ctx context.Context, includeExpired bool) ([]*entities.Silence, error) {
+	if includeExpired {
+		silences, err := s.silences.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("列出靜默失敗: %w", err)
+		}
+		return silences, nil
+	}
+	silences, err := s.silences.ListUnexpired(ctx, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("列出靜默失敗: %w", err)
+	}
+	return silences, nil
+}
+
+// Expire 立即結束靜默，已結束的靜默保持不變
+func (s *SilenceService) Expire(ctx context.Context, id string) (*entities.Silence, error) {
+	silence, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if silence.IsExpired(now) {
+		return silence, nil
+	}
+	if now.Before(silence.StartsAt) {
+		// 尚未開始的靜默直接在開始時間結束，保證 EndsAt 不早於 StartsAt
+		now = silence.StartsAt
+	}
+	silence.EndsAt = now
+	silence.UpdatedAt = s.now()
+	if err := s.silences.Save(ctx, silence); err != nil {
+		return nil, fmt.Errorf("保存靜默失敗: %w", err)
+	}
+	s.logger.Info("已結束靜默", "id", silence.ID)
+	return silence, nil
+}
+
+// Status 返回靜默在 now 時的狀態
+func (s *SilenceService) Status(silence *entities.Silence, now time.Time) string {
+	return silenceStatus(silence, now)
+}
+
+// Active 返回在 now 時正在抑制通知的靜默
+func (s *SilenceService) Active(ctx context.Context, now time.Time) ([]*entities.Silence, error) {
+	silences, err := s.silences.ListUnexpired(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("列出靜默失敗: %w", err)
+	}
+	active := silences[:0]
+	for _, silence := range silences {
+		if silenceStatus(silence, now) == entities.SilenceStatusActive {
+			active = append(active, silence)
+		}
+	}
+	return active, nil
+}
+
+// Purge 刪除在 before 之前已結束的靜默
+func (s *SilenceService) Purge(ctx context.Context, before time.Time) error {
+	deleted, err := s.silences.DeleteExpiredBefore(ctx, before)
+	if err != nil {
+		return fmt.Errorf("清理過期靜默失敗: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("已清理過期靜默", "count", deleted)
+	}
+	return nil
+}
+
+// validateSilence 檢查匹配條件、時間範圍與週期性維護窗口
+func validateSilence(silence *entities.Silence, now time.Time) error {
+	if len(silence.Matchers) == 0 {
+		return domainerrors.NewValidationError("matchers", "靜默至少需要一個匹配條件")
+	}
+	for _, m := range silence.Matchers {
+		if err := m.Validate(); err != nil {
+			return domainerrors.NewValidationError("matchers", err.Error())
+		}
+	}
+	if !silence.EndsAt.IsZero() {
+		if !silence.EndsAt.After(silence.StartsAt) {
+			return domainerrors.NewValidationError("endsAt", "靜默結束時間必須晚於開始時間")
+		}
+		if !silence.EndsAt.After(now) {
+			return domainerrors.NewValidationError("endsAt", "靜默結束時間必須晚於當前時間")
+		}
+	}
+
+	if !silence.IsRecurring() {
+		if silence.EndsAt.IsZero() {
+			return domainerrors.NewValidationError("endsAt", "一次性靜默必須指定結束時間")
+		}
+		if silence.Duration != 0 {
+			return domainerrors.NewValidationError("duration", "只有週期性靜默可以指定維護窗口長度")
+		}
+		return nil
+	}
+	if _, err := parseSilenceSchedule(silence.Schedule); err != nil {
+		return domainerrors.NewValidationError("schedule", err.Error())
+	}
+	if silence.Duration <= 0 {
+		return domainerrors.NewValidationError("duration", "週期性靜默必須指定正數的維護窗口長度")
+	}
+	return nil
+}
+
+// parseSilenceSchedule 解析維護窗口的 cron 表達式。
+// 不接受 "@every" 間隔，因為間隔排程沒有固定的起點，無法回推最近一次窗口。
+func parseSilenceSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid silence schedule %q: %w", spec, err)
+	}
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return nil, fmt.Errorf("silence schedule %q must be a cron expression, @every is not supported", spec)
+	}
+	return schedule, nil
+}
+
+// silenceStatus 根據時間推導靜默狀態。
+// 週期性靜默在 now 所在的窗口內為 active：最早晚於 now-Duration 的觸發時間不晚於 now 即表示處於窗口中。
+func silenceStatus(silence *entities.Silence, now time.Time) string {
+	if silence.IsExpired(now) {
+		return entities.SilenceStatusExpired
+	}
+	if now.Before(silence.StartsAt) {
+		return entities.SilenceStatusPending
+	}
+	if !silence.IsRecurring() {
+		return entities.SilenceStatusActive
+	}
+	schedule, err := parseSilenceSchedule(silence.Schedule)
+	if err != nil {
+		return entities.SilenceStatusPending
+	}
+	windowStart := schedule.Next(now.Add(-silence.Duration))
+	if windowStart.IsZero() || windowStart.After(now) {
+		return entities.SilenceStatusPending
+	}
+	return entities.SilenceStatusActive
+}
+
+// matchingSilences 返回匹配告警的靜默 ID，按 ID 排序
+func matchingSilences(silences []*entities.Silence, alert *entities.Alert) []string {
+	if len(silences) == 0 {
+		return nil
+	}
+	labels := alert.MatchLabels()
+	var ids []string
+	for _, silence := range silences {
+		if entities.MatchAll(silence.Matchers, labels) {
+			ids = append(ids, silence.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,187 @@
+package alerting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+type memorySilenceRepo struct {
+	mu       sync.Mutex
+	silences map[string]entities.Silence
+}
+
+func (r *memorySilenceRepo) Save(ctx context.Context, silence *entities.Silence) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.silences[silence.ID] = *silence
+	return nil
+}
+
+func (r *memorySilenceRepo) GetByID(ctx context.Context, id string) (*entities.Silence, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	silence, ok := r.silences[id]
+	if !ok {
+		return nil, nil
+	}
+	return &silence, nil
+}
+
+func (r *memorySilenceRepo) List(ctx context.Context) ([]*entities.Silence, error) {
+	return r.filter(func(*entities.Silence) bool { return true }), nil
+}
+
+func (r *memorySilenceRepo) ListUnexpired(ctx context.Context, now time.Time) ([]*entities.Silence, error) {
+	return r.filter(func(s *entities.Silence) bool { return !s.IsExpired(now) }), nil
+}
+
+func (r *memorySilenceRepo) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, s := range r.silences {
+		if !s.EndsAt.IsZero() && s.EndsAt.Before(before) {
+			delete(r.silences, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memorySilenceRepo) filter(keep func(*entities.Silence) bool) []*entities.Silence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.Silence
+	for _, s := range r.silences {
+		silence := s
+		if keep(&silence) {
+			out = append(out, &silence)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartsAt.Before(out[j].StartsAt) })
+	return out
+}
+
+func newTestSilenceService() (*SilenceService, *testClock) {
+	clock := &testClock{now: time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)} // 星期六
+	s := NewSilenceService(&memorySilenceRepo{silences: map[string]entities.Silence{}}, &testLogger{})
+	s.now = clock.Now
+	return s, clock
+}
+
+func TestSilenceService_CreateValidation(t *testing.T) {
+	s, clock := newTestSilenceService()
+	now := clock.Now()
+	host := []entities.LabelMatcher{{Name: "host", Operator: entities.MatchEqual, Value: "a"}}
+
+	for _, tc := range []struct {
+		name  string
+		req   SilenceRequest
+		field string
+	}{
+		{"no matchers", SilenceRequest{EndsAt: now.Add(time.Hour)}, "matchers"},
+		{"bad regexp", SilenceRequest{Matchers: []entities.LabelMatcher{{Name: "host", Operator: entities.MatchRegexp, Value: "("}}, EndsAt: now.Add(time.Hour)}, "matchers"},
+		{"one-off without end", SilenceRequest{Matchers: host}, "endsAt"},
+		{"end before start", SilenceRequest{Matchers: host, StartsAt: now.Add(2 * time.Hour), EndsAt: now.Add(time.Hour)}, "endsAt"},
+		{"already ended", SilenceRequest{Matchers: host, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)}, "endsAt"},
+		{"invalid cron", SilenceRequest{Matchers: host, Schedule: "every saturday", Duration: time.Hour}, "schedule"},
+		{"interval schedule", SilenceRequest{Matchers: host, Schedule: "@every 1h", Duration: time.Hour}, "schedule"},
+		{"recurring without duration", SilenceRequest{Matchers: host, Schedule: "0 2 * * 6"}, "duration"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Create(context.Background(), tc.req)
+			verr, ok := err.(domainerrors.DomainError)
+			if !ok || !domainerrors.IsValidationError(err) {
+				t.Fatalf("Create() error = %v, want validation error", err)
+			}
+			if verr.Field != tc.field {
+				t.Errorf("field = %s, want %s", verr.Field, tc.field)
+			}
+		})
+	}
+
+	silence, err := s.Create(context.Background(), SilenceRequest{Matchers: host, EndsAt: now.Add(time.Hour), CreatedBy: "ops"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !silence.StartsAt.Equal(now) || s.Status(silence, now) != entities.SilenceStatusActive {
+		t.Errorf("expected silence to start immediately, got starts_at %s status %s", silence.StartsAt, s.Status(silence, now))
+	}
+}
+
+func TestSilenceService_RecurringWindow(t *testing.T) {
+	s, clock := newTestSilenceService()
+	// 每週六台北時間 02:00 開始的 4 小時維護窗口 (UTC 週五 18:00 - 22:00)
+	silence, err := s.Create(context.Background(), SilenceRequest{
+		Matchers: []entities.LabelMatcher{{Name: "detector_id", Operator: entities.MatchEqual, Value: "cpu"}},
+		Schedule: "CRON_TZ=Asia/Taipei 0 2 * * 6",
+		Duration: 4 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	friday := time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		at   time.Time
+		want string
+	}{
+		{friday.Add(17*time.Hour + 59*time.Minute), entities.SilenceStatusPending},
+		{friday.Add(18 * time.Hour), entities.SilenceStatusActive},
+		{friday.Add(21*time.Hour + 59*time.Minute), entities.SilenceStatusActive},
+		{friday.Add(22 * time.Hour), entities.SilenceStatusPending},
+		{friday.Add(7*24*time.Hour + 19*time.Hour), entities.SilenceStatusActive},
+	} {
+		if got := s.Status(silence, tc.at); got != tc.want {
+			t.Errorf("Status(%s) = %s, want %s", tc.at, got, tc.want)
+		}
+	}
+
+	active, err := s.Active(context.Background(), clock.Now())
+	if err != nil || len(active) != 0 {
+		t.Errorf("Active() = %v, %v; want no active silence outside the window", active, err)
+	}
+	active, err = s.Active(context.Background(), friday.Add(20*time.Hour))
+	if err != nil || len(active) != 1 {
+		t.Errorf("Active() = %v, %v; want the recurring silence inside the window", active, err)
+	}
+}
+
+func TestSilenceService_ExpireAndPurge(t *testing.T) {
+	s, clock := newTestSilenceService()
+	silence, err := s.Create(context.Background(), SilenceRequest{
+		Matchers: []entities.LabelMatcher{{Name: "host", Operator: entities.MatchEqual, Value: "a"}},
+		EndsAt:   clock.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	clock.Advance(10 * time.Minute)
+	expired, err := s.Expire(context.Background(), silence.ID)
+	if err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+	if s.Status(expired, clock.Now()) != entities.SilenceStatusExpired {
+		t.Errorf("expected expired status, got %s", s.Status(expired, clock.Now()))
+	}
+	if list, _ := s.List(context.Background(), false); len(list) != 0 {
+		t.Errorf("List(false) returned expired silences: %v", list)
+	}
+	if list, _ := s.List(context.Background(), true); len(list) != 1 {
+		t.Errorf("List(true) = %d silences, want 1", len(list))
+	}
+
+	if err := s.Purge(context.Background(), clock.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if _, err := s.Get(context.Background(), silence.ID); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("Get() error = %v, want not found for purged silence", err)
+	}
+}
@@ -0,0 +1,462 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// BackfillConfig 定義回放服務的配置
+type BackfillConfig struct {
+	ChunkSize  string `yaml:"chunkSize" json:"chunkSize"`   // 每次從數據源讀取的時間跨度，默認 "1h"
+	MaxRange   string `yaml:"maxRange" json:"maxRange"`     // 單個任務允許的最大回放範圍，默認 "2160h" (90 天)
+	SampleSize int    `yaml:"sampleSize" json:"sampleSize"` // 報告中保留的新增與消失偵測樣本數，默認 20
+}
+
+// BackfillRequest 描述一次回放請求
+type BackfillRequest struct {
+	DetectorID  string
+	Revision    int
+	Start       time.Time
+	End         time.Time
+	RequestedBy string
+}
+
+// replayPlan 是任務執行時需要的排程與候選版本
+type replayPlan struct {
+	schedule  *entities.DetectorSchedule
+	candidate *entities.DetectorConfigRevision
+}
+
+// replayVariant 是參與回放的一個檢測器實例
+type replayVariant struct {
+	name     string
+	detector plugins.DetectorPlugin
+	config   map[string]interface{}
+}
+
+// BackfillService 在歷史數據上回放檢測器的指定配置版本
+// 職責: 按時間順序把排程數據源中的記錄依次交給全新的檢測器實例 (因此有狀態檢測器的行為與線上一致)，
+// 同時以目前生效的配置回放同一批數據，將兩邊的異常寫入模擬命名空間而不觸發告警，最後生成比較報告。
+// 回放範圍內有分析師標記過的線上結果時，報告同時以這些標記評估兩種配置。
+type BackfillService struct {
+	jobs      interfaces.BackfillJobRepository
+	results   interfaces.SimulationResultRepository
+	revisions interfaces.DetectorConfigRevisionRepository
+	schedules interfaces.DetectorScheduleRepository
+	feedback  interfaces.ResultFeedbackRepository
+	registry  contracts.PluginRegistryProvider
+	factory   plugins.DetectorFactory
+	logger    contracts.Logger
+
+	chunkSize  time.Duration
+	maxRange   time.Duration
+	sampleSize int
+
+	now func() time.Time
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // 背景執行中的任務
+	wg      sync.WaitGroup
+}
+
+// NewBackfillService 創建新的回放服務，feedback 為 nil 時報告不包含標記評估
+func NewBackfillService(
+	jobs interfaces.BackfillJobRepository,
+	results interfaces.SimulationResultRepository,
+	revisions interfaces.DetectorConfigRevisionRepository,
+	schedules interfaces.DetectorScheduleRepository,
+	feedback interfaces.ResultFeedbackRepository,
+	registry contracts.PluginRegistryProvider,
+	factory plugins.DetectorFactory,
+	config BackfillConfig,
+	logger contracts.Logger,
+) (*BackfillService, error) {
+	s := &BackfillService{
+		jobs:       jobs,
+		results:    results,
+		revisions:  revisions,
+		schedules:  schedules,
+		feedback:   feedback,
+		registry:   registry,
+		factory:    factory,
+		logger:     logger,
+		sampleSize: config.SampleSize,
+		now:        time.Now,
+		cancels:    make(map[string]context.CancelFunc),
+	}
+
+	var err error
+	if s.chunkSize, err = parseDurationDefault(config.ChunkSize, time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid chunkSize: %w", err)
+	}
+	if s.maxRange, err = parseDurationDefault(config.MaxRange, 90*24*time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid maxRange: %w", err)
+	}
+	if s.sampleSize <= 0 {
+		s.sampleSize = 20
+	}
+	return s, nil
+}
+
+// GetName 返回服務名稱
+func (s *BackfillService) GetName() string {
+	return "backfill_service"
+}
+
+// Submit 創建回放任務並在背景執行，立即返回處於 pending 狀態的任務
+func (s *BackfillService) Submit(ctx context.Context, req BackfillRequest) (*entities.BackfillJob, error) {
+	job, plan, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 任務的生命週期不跟隨發起請求的 context，只能通過 Cancel 或 Stop 取消
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.mu.Lock()
+	s.cancels[job.ID] = cancel
+	s.mu.Unlock()
+
+	running := *job
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.cancels, running.ID)
+			s.mu.Unlock()
+			cancel()
+		}()
+		if err := s.execute(runCtx, &running, plan); err != nil {
+			s.logger.Error("回放任務失敗", "job_id", running.ID, "error", err)
+		}
+	}()
+
+	return job, nil
+}
+
+// Run 創建回放任務並同步執行至結束，返回包含比較報告的任務
+func (s *BackfillService) Run(ctx context.Context, req BackfillRequest) (*entities.BackfillJob, error) {
+	job, plan, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.execute(ctx, job, plan); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+// GetJob 獲取回放任務
+func (s *BackfillService) GetJob(ctx context.Context, id string) (*entities.BackfillJob, error) {
+	job, err := s.jobs.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找回放任務失敗: %w", err)
+	}
+	if job == nil {
+		return nil, domainerrors.NewNotFoundError("backfill_job", fmt.Sprintf("回放任務不存在: %s", id))
+	}
+	return job, nil
+}
+
+// ListResults 按時間順序列出任務寫入模擬命名空間的結果，variant 為空時返回兩種配置的結果
+func (s *BackfillService) ListResults(ctx context.Context, jobID, variant string, offset, limit int) ([]*entities.SimulationResult, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return s.results.ListByJob(ctx, jobID, variant, offset, limit)
+}
+
+// Cancel 取消背景執行中的任務
+func (s *BackfillService) Cancel(jobID string) error {
+	s.mu.Lock()
+	cancel, ok := s.cancels[jobID]
+	s.mu.Unlock()
+	if !ok {
+		return domainerrors.NewNotFoundError("backfill_job", fmt.Sprintf("回放任務未在執行: %s", jobID))
+	}
+	cancel()
+	return nil
+}
+
+// Stop 取消所有背景任務並等待它們記錄最終狀態
+func (s *BackfillService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for backfill jobs to stop: %w", ctx.Err())
+	}
+}
+
+// prepare 驗證請求、解析排程與候選版本並保存 pending 狀態的任務
+func (s *BackfillService) prepare(ctx context.Context, req BackfillRequest) (*entities.BackfillJob, *replayPlan, error) {
+	if req.DetectorID == "" {
+		return nil, nil, domainerrors.NewValidationError("detector_id", "檢測器 ID 不能為空")
+	}
+	if req.Revision <= 0 {
+		return nil, nil, domainerrors.NewValidationError("revision", "必須指定要回放的配置版本")
+	}
+	if !req.Start.Before(req.End) {
+		return nil, nil, domainerrors.NewValidationError("range", "回放起始時間必須早於結束時間")
+	}
+	now := s.now()
+	if req.End.After(now) {
+		return nil, nil, domainerrors.NewValidationError("range", "回放結束時間不能晚於當前時間")
+	}
+	if req.End.Sub(req.Start) > s.maxRange {
+		return nil, nil, domainerrors.NewValidationError("range", fmt.Sprintf("回放範圍不能超過 %s", s.maxRange))
+	}
+
+	schedule, err := s.schedules.GetByDetectorID(ctx, req.DetectorID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查找檢測器排程失敗: %w", err)
+	}
+	if schedule == nil {
+		return nil, nil, domainerrors.NewNotFoundError("detector_schedule",
+			fmt.Sprintf("檢測器沒有排程，無法確定回放數據源: %s", req.DetectorID))
+	}
+	candidate, err := s.revisions.Get(ctx, req.DetectorID, req.Revision)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查找配置版本失敗: %w", err)
+	}
+	if candidate == nil {
+		return nil, nil, domainerrors.NewNotFoundError("detector_config_revision",
+			fmt.Sprintf("配置版本不存在: %s@%d", req.DetectorID, req.Revision))
+	}
+
+	job := &entities.BackfillJob{
+		ID:         valueobjects.GenerateNewIDVO().String(),
+		DetectorID: req.DetectorID,
+		Revision:   req.Revision,
+		RangeStart: req.Start.UTC(),
+		RangeEnd:   req.End.UTC(),
+		Status:     entities.BackfillStatusPending,
+		CreatedBy:  req.RequestedBy,
+		CreatedAt:  now,
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, nil, fmt.Errorf("保存回放任務失敗: %w", err)
+	}
+
+	s.logger.Info("已創建回放任務", "job_id", job.ID, "detector_id", job.DetectorID, "revision", job.Revision,
+		"start", job.RangeStart, "end", job.RangeEnd)
+	return job, &replayPlan{schedule: schedule, candidate: candidate}, nil
+}
+
+// execute 執行回放並將最終狀態寫回任務
+func (s *BackfillService) execute(ctx context.Context, job *entities.BackfillJob, plan *replayPlan) error {
+	job.Status = entities.BackfillStatusRunning
+	job.StartedAt = s.now()
+	if err := s.jobs.Update(ctx, job); err != nil {
+		return fmt.Errorf("更新回放任務狀態失敗: %w", err)
+	}
+
+	report, err := s.replay(ctx, job, plan)
+
+	job.FinishedAt = s.now()
+	switch {
+	case err == nil:
+		job.Status = entities.BackfillStatusSucceeded
+		job.Report = report
+	case ctx.Err() != nil:
+		job.Status = entities.BackfillStatusCancelled
+		job.Error = err.Error()
+	default:
+		job.Status = entities.BackfillStatusFailed
+		job.Error = err.Error()
+	}
+
+	// 使用獨立 context 記錄結果，任務被取消時也能保存最終狀態
+	updateCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if updateErr := s.jobs.Update(updateCtx, job); updateErr != nil {
+		s.logger.Error("保存回放任務結果失敗", "job_id", job.ID, "error", updateErr)
+		if err == nil {
+			err = updateErr
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	s.logger.Info("回放任務完成", "job_id", job.ID, "records", report.RecordsProcessed,
+		"candidate_alerts", report.CandidateAlerts, "baseline_alerts", report.BaselineAlerts,
+		"new_detections", report.NewDetections)
+	return nil
+}
+
+// replay 分段讀取數據並以候選版本與生效配置依序執行偵測
+func (s *BackfillService) replay(ctx context.Context, job *entities.BackfillJob, plan *replayPlan) (*entities.BackfillReport, error) {
+	source, err := s.resolveSource(plan.schedule.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// 比較基準為目前生效的版本；從未啟用過版本的檢測器以排程中的配置為準
+	baselinePlugin, baselineConfig := plan.schedule.DetectorPlugin, plan.schedule.DetectorConfig
+	active, err := s.revisions.GetActive(ctx, job.DetectorID)
+	if err != nil {
+		return nil, fmt.Errorf("查找生效配置版本失敗: %w", err)
+	}
+	if active != nil {
+		baselinePlugin, baselineConfig = active.Plugin, active.Config
+		job.BaselineRevision = active.Revision
+	}
+
+	variants := make([]replayVariant, 0, 2)
+	defer func() {
+		for _, v := range variants {
+			if err := v.detector.Stop(context.WithoutCancel(ctx)); err != nil {
+				s.logger.Warn("停止回放檢測器失敗", "job_id", job.ID, "variant", v.name, "error", err)
+			}
+		}
+	}()
+	for _, v := range []struct {
+		name, plugin string
+		config       map[string]interface{}
+	}{
+		{entities.SimulationVariantCandidate, plan.candidate.Plugin, plan.candidate.Config},
+		{entities.SimulationVariantBaseline, baselinePlugin, baselineConfig},
+	} {
+		// 每個任務使用全新的實例，有狀態檢測器從空狀態開始並在整個範圍內延續
+		detector, err := s.factory.NewDetector(ctx, v.plugin, v.config)
+		if err != nil {
+			return nil, fmt.Errorf("創建 %s 檢測器失敗: %w", v.name, err)
+		}
+		variants = append(variants, replayVariant{name: v.name, detector: detector, config: v.config})
+	}
+
+	cmp := newComparison()
+	records := 0
+	for chunkStart := job.RangeStart; chunkStart.Before(job.RangeEnd); {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunkEnd := chunkStart.Add(s.chunkSize)
+		if chunkEnd.After(job.RangeEnd) {
+			chunkEnd = job.RangeEnd
+		}
+
+		batch, err := source.FetchWindow(ctx, plan.schedule.SourceQuery, chunkStart, chunkEnd)
+		if err != nil {
+			return nil, fmt.Errorf("讀取 %s 至 %s 的數據失敗: %w", chunkStart.Format(time.RFC3339), chunkEnd.Format(time.RFC3339), err)
+		}
+		ordered, err := orderByTimestamp(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		var simulated []*entities.SimulationResult
+		for _, record := range ordered {
+			for _, v := range variants {
+				result, err := v.detector.Execute(ctx, record.data, v.config)
+				if err != nil {
+					return nil, fmt.Errorf("%s 檢測器在 %s 執行失敗: %w", v.name, record.at.Format(time.RFC3339Nano), err)
+				}
+				if !result.IsAnomalous() {
+					continue
+				}
+				if result.ID == "" {
+					result.ID = valueobjects.GenerateNewIDVO().String()
+				}
+				result.DetectorID = job.DetectorID
+				result.Timestamp = record.at
+				simulated = append(simulated, &entities.SimulationResult{JobID: job.ID, Variant: v.name, Result: result})
+				cmp.add(v.name, record.at)
+			}
+		}
+		if err := s.results.SaveBatch(ctx, simulated); err != nil {
+			return nil, fmt.Errorf("保存模擬結果失敗: %w", err)
+		}
+
+		records += len(ordered)
+		job.ProcessedUntil = chunkEnd
+		if err := s.jobs.Update(ctx, job); err != nil {
+			return nil, fmt.Errorf("更新回放進度失敗: %w", err)
+		}
+		chunkStart = chunkEnd
+	}
+
+	report := cmp.report(s.sampleSize)
+	report.RecordsProcessed = records
+	if s.feedback != nil {
+		labelled, err := s.feedback.ListByDetector(ctx, job.DetectorID, job.RangeStart, job.RangeEnd)
+		if err != nil {
+			return nil, fmt.Errorf("查找標記結果失敗: %w", err)
+		}
+		if len(labelled) > 0 {
+			report.Evaluation = cmp.evaluate(labelled)
+		}
+	}
+	return report, nil
+}
+
+// resolveSource 從註冊表解析數據源插件
+func (s *BackfillService) resolveSource(name string) (plugins.DataSourcePlugin, error) {
+	p, err := s.registry.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("data source %s not found: %w", name, err)
+	}
+	source, ok := p.(plugins.DataSourcePlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s is not a data source plugin", name)
+	}
+	return source, nil
+}
+
+// timedRecord 是帶有解析後時間戳的數據記錄
+type timedRecord struct {
+	at   time.Time
+	data map[string]interface{}
+}
+
+// orderByTimestamp 按記錄時間穩定排序，不依賴數據源返回的順序
+func orderByTimestamp(records []map[string]interface{}) ([]timedRecord, error) {
+	ordered := make([]timedRecord, 0, len(records))
+	for i, record := range records {
+		at, err := plugins.RecordTimestamp(record)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 條記錄: %w", i, err)
+		}
+		ordered = append(ordered, timedRecord{at: at.UTC(), data: record})
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].at.Before(ordered[j].at) })
+	return ordered, nil
+}
+
+// parseDurationDefault 解析時間長度，空字符串時返回默認值
+func parseDurationDefault(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %s", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,398 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/registry"
+	"detectviz-platform/internal/plugins/detectors"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type memoryJobRepo struct {
+	mu   sync.Mutex
+	jobs map[string]entities.BackfillJob
+}
+
+func (r *memoryJobRepo) Create(ctx context.Context, job *entities.BackfillJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+func (r *memoryJobRepo) Update(ctx context.Context, job *entities.BackfillJob) error {
+	return r.Create(ctx, job)
+}
+func (r *memoryJobRepo) GetByID(ctx context.Context, id string) (*entities.BackfillJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+type memoryResultRepo struct {
+	mu      sync.Mutex
+	results []*entities.SimulationResult
+}
+
+func (r *memoryResultRepo) SaveBatch(ctx context.Context, results []*entities.SimulationResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, results...)
+	return nil
+}
+func (r *memoryResultRepo) ListByJob(ctx context.Context, jobID, variant string, offset, limit int) ([]*entities.SimulationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.SimulationResult
+	for _, sr := range r.results {
+		if sr.JobID == jobID && (variant == "" || sr.Variant == variant) {
+			out = append(out, sr)
+		}
+	}
+	return out, nil
+}
+
+type memoryRevisionRepo struct {
+	revisions []*entities.DetectorConfigRevision
+}
+
+func (r *memoryRevisionRepo) Create(ctx context.Context, rev *entities.DetectorConfigRevision) error {
+	r.revisions = append(r.revisions, rev)
+	return nil
+}
+func (r *memoryRevisionRepo) Get(ctx context.Context, id string, revision int) (*entities.DetectorConfigRevision, error) {
+	for _, rev := range r.revisions {
+		if rev.DetectorID == id && rev.Revision == revision {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+func (r *memoryRevisionRepo) GetActive(ctx context.Context, id string) (*entities.DetectorConfigRevision, error) {
+	for _, rev := range r.revisions {
+		if rev.DetectorID == id && rev.Active {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+func (r *memoryRevisionRepo) LatestRevision(ctx context.Context, id string) (int, error) {
+	return len(r.revisions), nil
+}
+func (r *memoryRevisionRepo) List(ctx context.Context, id string) ([]*entities.DetectorConfigRevision, error) {
+	return r.revisions, nil
+}
+func (r *memoryRevisionRepo) SetActive(ctx context.Context, id string, revision int) error {
+	for _, rev := range r.revisions {
+		rev.Active = rev.Revision == revision
+	}
+	return nil
+}
+
+type memoryScheduleRepo struct {
+	schedules map[string]*entities.DetectorSchedule
+}
+
+func (r *memoryScheduleRepo) Save(ctx context.Context, s *entities.DetectorSchedule) error {
+	r.schedules[s.DetectorID] = s
+	return nil
+}
+func (r *memoryScheduleRepo) GetByDetectorID(ctx context.Context, id string) (*entities.DetectorSchedule, error) {
+	return r.schedules[id], nil
+}
+func (r *memoryScheduleRepo) ListEnabled(ctx context.Context) ([]*entities.DetectorSchedule, error) {
+	return nil, nil
+}
+func (r *memoryScheduleRepo) ClaimNextRun(ctx context.Context, id string, expected, next time.Time) (bool, error) {
+	return true, nil
+}
+func (r *memoryScheduleRepo) RecordRun(ctx context.Context, id string, at time.Time, status, errMsg string) error {
+	return nil
+}
+func (r *memoryScheduleRepo) Delete(ctx context.Context, id string) error { return nil }
+
+type memoryFeedbackRepo struct {
+	feedback []*entities.ResultFeedback
+}
+
+func (r *memoryFeedbackRepo) Save(ctx context.Context, f *entities.ResultFeedback) error {
+	r.feedback = append(r.feedback, f)
+	return nil
+}
+func (r *memoryFeedbackRepo) GetByResult(ctx context.Context, id string) (*entities.ResultFeedback, error) {
+	for _, f := range r.feedback {
+		if f.AnalysisResultID == id {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
+func (r *memoryFeedbackRepo) ListByDetector(ctx context.Context, id string, start, end time.Time) ([]*entities.ResultFeedback, error) {
+	var out []*entities.ResultFeedback
+	for _, f := range r.feedback {
+		if f.DetectorID == id && f.ResultTimestamp.After(start) && !f.ResultTimestamp.After(end) {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+func (r *memoryFeedbackRepo) CountByDetector(ctx context.Context, id string) (map[string]int, error) {
+	return nil, nil
+}
+
+// reverseSource 以時間倒序返回窗口內的記錄，驗證回放不依賴數據源的排序
+type reverseSource struct {
+	records []map[string]interface{}
+	windows int
+}
+
+func (s *reverseSource) GetName() string                                            { return "metrics" }
+func (s *reverseSource) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (s *reverseSource) Start(ctx context.Context) error                            { return nil }
+func (s *reverseSource) Stop(ctx context.Context) error                             { return nil }
+func (s *reverseSource) FetchWindow(ctx context.Context, query map[string]interface{}, start, end time.Time) ([]map[string]interface{}, error) {
+	s.windows++
+	var out []map[string]interface{}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		at := s.records[i]["timestamp"].(time.Time)
+		if at.After(start) && !at.After(end) {
+			out = append(out, s.records[i])
+		}
+	}
+	return out, nil
+}
+
+var backfillBase = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestBackfillService(t *testing.T) (*BackfillService, *memoryRevisionRepo, *memoryResultRepo, *reverseSource) {
+	t.Helper()
+	logger := &testLogger{}
+
+	// 每分鐘一條記錄
+	values := []float64{50, 75, 75, 85, 60, 75, 90, 90, 50, 72}
+	source := &reverseSource{}
+	for i, v := range values {
+		source.records = append(source.records, map[string]interface{}{
+			"timestamp": backfillBase.Add(time.Duration(i+1) * time.Minute),
+			"cpu_usage": v,
+		})
+	}
+	reg := registry.NewPluginRegistryProvider(logger)
+	reg.Register("metrics", source)
+
+	schedules := &memoryScheduleRepo{schedules: map[string]*entities.DetectorSchedule{
+		"det-1": {
+			DetectorID:     "det-1",
+			DetectorPlugin: "threshold_detector_plugin",
+			DetectorConfig: map[string]interface{}{"field_name": "cpu_usage", "upper_threshold": 80.0, "enable_lower": false},
+			Source:         "metrics",
+		},
+	}}
+	revisions := &memoryRevisionRepo{revisions: []*entities.DetectorConfigRevision{{
+		DetectorID: "det-1",
+		Revision:   1,
+		Plugin:     "threshold_detector_plugin",
+		Config: map[string]interface{}{"field_name": "cpu_usage", "upper_threshold": 70.0, "enable_lower": false,
+			"tolerant_count": float64(2)},
+	}}}
+	results := &memoryResultRepo{}
+
+	s, err := NewBackfillService(&memoryJobRepo{jobs: map[string]entities.BackfillJob{}}, results, revisions, schedules,
+		nil, reg, detectors.NewDetectorFactory(logger, nil), BackfillConfig{ChunkSize: "3m"}, logger)
+	if err != nil {
+		t.Fatalf("NewBackfillService() error = %v", err)
+	}
+	s.now = func() time.Time { return backfillBase.Add(time.Hour) }
+	return s, revisions, results, source
+}
+
+func TestBackfillService_RunComparesAgainstActiveConfig(t *testing.T) {
+	s, _, results, source := newTestBackfillService(t)
+	ctx := context.Background()
+
+	job, err := s.Run(ctx, BackfillRequest{DetectorID: "det-1", Revision: 1,
+		Start: backfillBase, End: backfillBase.Add(10 * time.Minute), RequestedBy: "user-1"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if job.Status != entities.BackfillStatusSucceeded {
+		t.Fatalf("status = %s, want %s (error %q)", job.Status, entities.BackfillStatusSucceeded, job.Error)
+	}
+	if source.windows != 4 {
+		t.Errorf("fetched %d windows, want 4 chunks of 3m", source.windows)
+	}
+	if !job.ProcessedUntil.Equal(job.RangeEnd) {
+		t.Errorf("ProcessedUntil = %v, want %v", job.ProcessedUntil, job.RangeEnd)
+	}
+
+	// 基準 (>80): 第 4、7、8 分鐘；候選 (>70 且連續 2 次): 第 3、4、7、8 分鐘。
+	// 第 7 分鐘的連續計數來自上一個分段的第 6 分鐘，驗證狀態跨分段延續。
+	report := job.Report
+	if report.RecordsProcessed != 10 || report.CandidateAlerts != 4 || report.BaselineAlerts != 3 ||
+		report.Overlap != 3 || report.NewDetections != 1 || report.MissedDetections != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.NewDetectionSamples) != 1 || !report.NewDetectionSamples[0].Equal(backfillBase.Add(3*time.Minute)) {
+		t.Errorf("NewDetectionSamples = %v, want [minute 3]", report.NewDetectionSamples)
+	}
+
+	stored, err := s.ListResults(ctx, job.ID, entities.SimulationVariantCandidate, 0, 0)
+	if err != nil {
+		t.Fatalf("ListResults() error = %v", err)
+	}
+	var minutes []int
+	for _, sr := range stored {
+		if sr.Result.DetectorID != "det-1" || sr.Result.ID == "" {
+			t.Errorf("unexpected simulation result: %+v", sr.Result)
+		}
+		minutes = append(minutes, int(sr.Result.Timestamp.Sub(backfillBase)/time.Minute))
+	}
+	if !sort.IntsAreSorted(minutes) || len(minutes) != 4 {
+		t.Errorf("candidate results at minutes %v, want 4 results in timestamp order", minutes)
+	}
+	if len(results.results) != 7 {
+		t.Errorf("stored %d simulation results, want 7", len(results.results))
+	}
+}
+
+func TestBackfillService_UsesActiveRevisionAsBaseline(t *testing.T) {
+	s, revisions, _, _ := newTestBackfillService(t)
+	revisions.Create(context.Background(), &entities.DetectorConfigRevision{
+		DetectorID: "det-1", Revision: 2, Plugin: "threshold_detector_plugin", Active: true,
+		Config: map[string]interface{}{"field_name": "cpu_usage", "upper_threshold": 70.0, "enable_lower": false},
+	})
+
+	job, err := s.Run(context.Background(), BackfillRequest{DetectorID: "det-1", Revision: 1,
+		Start: backfillBase, End: backfillBase.Add(10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// 生效版本 (>70) 在第 2、3、4、6、7、8、10 分鐘告警，候選版本少了需要連續兩次的點
+	if job.BaselineRevision != 2 || job.Report.BaselineAlerts != 7 || job.Report.MissedDetections != 3 || job.Report.NewDetections != 0 {
+		t.Errorf("BaselineRevision = %d, report = %+v", job.BaselineRevision, job.Report)
+	}
+}
+
+func TestBackfillService_EvaluatesAgainstLabelledResults(t *testing.T) {
+	s, _, _, _ := newTestBackfillService(t)
+	label := func(detectorID string, minute int, label string) *entities.ResultFeedback {
+		return &entities.ResultFeedback{AnalysisResultID: fmt.Sprintf("result-%d", minute), DetectorID: detectorID,
+			ResultTimestamp: backfillBase.Add(time.Duration(minute) * time.Minute), Label: label}
+	}
+	s.feedback = &memoryFeedbackRepo{feedback: []*entities.ResultFeedback{
+		label("det-1", 3, entities.FeedbackLabelTruePositive),
+		label("det-1", 4, entities.FeedbackLabelTruePositive),
+		label("det-1", 6, entities.FeedbackLabelTruePositive),
+		label("det-1", 7, entities.FeedbackLabelExpected),
+		label("det-1", 8, entities.FeedbackLabelFalsePositive),
+		label("det-1", 11, entities.FeedbackLabelTruePositive), // 範圍外
+		label("det-2", 4, entities.FeedbackLabelFalsePositive), // 其他檢測器
+	}}
+
+	job, err := s.Run(context.Background(), BackfillRequest{DetectorID: "det-1", Revision: 1,
+		Start: backfillBase, End: backfillBase.Add(10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// 候選版本在第 3、4、7、8 分鐘告警，基準在第 4、7、8 分鐘告警
+	evaluation := job.Report.Evaluation
+	if evaluation == nil || evaluation.LabelledResults != 5 {
+		t.Fatalf("unexpected evaluation: %+v", evaluation)
+	}
+	wantCandidate := entities.LabelScore{TruePositivesDetected: 2, TruePositivesMissed: 1, FalsePositivesDetected: 1, ExpectedDetected: 1}
+	wantBaseline := entities.LabelScore{TruePositivesDetected: 1, TruePositivesMissed: 2, FalsePositivesDetected: 1, ExpectedDetected: 1}
+	if evaluation.Candidate != wantCandidate || evaluation.Baseline != wantBaseline {
+		t.Errorf("candidate = %+v, baseline = %+v", evaluation.Candidate, evaluation.Baseline)
+	}
+	if precision, ok := evaluation.Candidate.Precision(); !ok || precision < 0.66 || precision > 0.67 {
+		t.Errorf("candidate precision = %v, %v", precision, ok)
+	}
+}
+
+func TestBackfillService_Validation(t *testing.T) {
+	s, _, _, _ := newTestBackfillService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		req          BackfillRequest
+		wantNotFound bool
+	}{
+		{name: "missing revision", req: BackfillRequest{DetectorID: "det-1", Start: backfillBase, End: backfillBase.Add(time.Minute)}},
+		{name: "empty range", req: BackfillRequest{DetectorID: "det-1", Revision: 1, Start: backfillBase, End: backfillBase}},
+		{name: "future end", req: BackfillRequest{DetectorID: "det-1", Revision: 1, Start: backfillBase, End: backfillBase.Add(2 * time.Hour)}},
+		{name: "range too large", req: BackfillRequest{DetectorID: "det-1", Revision: 1, Start: backfillBase.AddDate(0, 0, -91), End: backfillBase}},
+		{name: "unknown revision", req: BackfillRequest{DetectorID: "det-1", Revision: 9, Start: backfillBase, End: backfillBase.Add(time.Minute)}, wantNotFound: true},
+		{name: "no schedule", req: BackfillRequest{DetectorID: "det-2", Revision: 1, Start: backfillBase, End: backfillBase.Add(time.Minute)}, wantNotFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Run(ctx, tt.req)
+			if tt.wantNotFound && !domainerrors.IsNotFoundError(err) {
+				t.Errorf("error = %v, want not found error", err)
+			}
+			if !tt.wantNotFound && !domainerrors.IsValidationError(err) {
+				t.Errorf("error = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+func TestBackfillService_SubmitRunsInBackground(t *testing.T) {
+	s, _, _, _ := newTestBackfillService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job, err := s.Submit(ctx, BackfillRequest{DetectorID: "det-1", Revision: 1,
+		Start: backfillBase, End: backfillBase.Add(10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.Status != entities.BackfillStatusPending {
+		t.Errorf("submitted status = %s, want %s", job.Status, entities.BackfillStatusPending)
+	}
+	// 請求結束不應中斷背景任務
+	cancel()
+
+	var stored *entities.BackfillJob
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if stored, err = s.GetJob(context.Background(), job.ID); err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+		if stored.Status != entities.BackfillStatusPending && stored.Status != entities.BackfillStatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stored.Status != entities.BackfillStatusSucceeded || stored.Report == nil {
+		t.Errorf("status = %s (error %q), want succeeded with report", stored.Status, stored.Error)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := s.GetJob(context.Background(), "missing"); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("GetJob(missing) error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,99 @@
+package backfill
+
+import (
+	"sort"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// comparison 統計兩種配置在每個時間點偵測到的異常數。
+// 同一時間點可能有多條記錄 (例如多台主機)，因此按時間點計數而不是只記錄是否出現。
+type comparison struct {
+	candidate map[time.Time]int
+	baseline  map[time.Time]int
+}
+
+// newComparison 創建空的比較統計
+func newComparison() *comparison {
+	return &comparison{
+		candidate: make(map[time.Time]int),
+		baseline:  make(map[time.Time]int),
+	}
+}
+
+// add 記錄一次異常
+func (c *comparison) add(variant string, at time.Time) {
+	if variant == entities.SimulationVariantCandidate {
+		c.candidate[at]++
+	} else {
+		c.baseline[at]++
+	}
+}
+
+// report 生成比較報告，樣本按時間升序最多保留 sampleSize 個
+func (c *comparison) report(sampleSize int) *entities.BackfillReport {
+	report := &entities.BackfillReport{}
+	var newAt, missedAt []time.Time
+
+	for at, n := range c.candidate {
+		report.CandidateAlerts += n
+		b := c.baseline[at]
+		report.Overlap += min(n, b)
+		if n > b {
+			report.NewDetections += n - b
+			newAt = append(newAt, at)
+		}
+	}
+	for at, b := range c.baseline {
+		report.BaselineAlerts += b
+		if n := c.candidate[at]; b > n {
+			report.MissedDetections += b - n
+			missedAt = append(missedAt, at)
+		}
+	}
+
+	report.NewDetectionSamples = firstSamples(newAt, sampleSize)
+	report.MissedDetectionSamples = firstSamples(missedAt, sampleSize)
+	return report
+}
+
+// evaluate 以標記過的結果評估兩種配置，標記結果的時間點有偵測即視為偵測到
+func (c *comparison) evaluate(labelled []*entities.ResultFeedback) *entities.BackfillEvaluation {
+	evaluation := &entities.BackfillEvaluation{LabelledResults: len(labelled)}
+	for _, feedback := range labelled {
+		at := feedback.ResultTimestamp.UTC()
+		scoreLabel(&evaluation.Candidate, feedback.Label, c.candidate[at] > 0)
+		scoreLabel(&evaluation.Baseline, feedback.Label, c.baseline[at] > 0)
+	}
+	return evaluation
+}
+
+// scoreLabel 將一個標記結果計入配置的統計
+func scoreLabel(score *entities.LabelScore, label string, detected bool) {
+	switch label {
+	case entities.FeedbackLabelTruePositive:
+		if detected {
+			score.TruePositivesDetected++
+		} else {
+			score.TruePositivesMissed++
+		}
+	case entities.FeedbackLabelFalsePositive:
+		if detected {
+			score.FalsePositivesDetected++
+		}
+	case entities.FeedbackLabelExpected:
+		if detected {
+			score.ExpectedDetected++
+		}
+	}
+}
+
+// firstSamples 返回按時間排序後的前 n 個時間點
+func firstSamples(times []time.Time, n int) []time.Time {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	if len(times) > n {
+		times = times[:n]
+	}
+	return times
+}
@@ -0,0 +1,353 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/application/scheduler"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// 檢測器生命週期事件的主題
+const (
+	TopicDetectorCreated = "detector.created"
+	TopicDetectorUpdated = "detector.updated"
+	TopicDetectorDeleted = "detector.deleted"
+)
+
+// 審計日誌中使用的動作名稱
+const (
+	AuditActionCreate   = "detector.create"
+	AuditActionUpdate   = "detector.update"
+	AuditActionDelete   = "detector.delete"
+	AuditActionSchedule = "detector.schedule"
+
+	AuditActionCreateRevision   = "detector.revision.create"
+	AuditActionActivateRevision = "detector.revision.activate"
+)
+
+// DetectorEvent 是檢測器變更時寫入發件箱的事件內容
+type DetectorEvent struct {
+	DetectorID string    `json:"detector_id"`
+	Name       string    `json:"name"`
+	OwnerID    string    `json:"owner_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// DetectorService 實現檢測器管理相關的業務邏輯
+// 職責: 在單一事務中完成檢測器變更、審計記錄與事件發布，三者同時提交或同時回滾
+type DetectorService struct {
+	detectorRepo interfaces.DetectorRepository
+	scheduleRepo interfaces.DetectorScheduleRepository
+	revisionRepo interfaces.DetectorConfigRevisionRepository
+	txManager    contracts.TransactionManager
+	auditLog     contracts.AuditLogProvider
+	outbox       contracts.OutboxProvider
+	logger       contracts.Logger
+}
+
+// NewDetectorService 創建新的檢測器服務實例
+func NewDetectorService(
+	detectorRepo interfaces.DetectorRepository,
+	scheduleRepo interfaces.DetectorScheduleRepository,
+	revisionRepo interfaces.DetectorConfigRevisionRepository,
+	txManager contracts.TransactionManager,
+	auditLog contracts.AuditLogProvider,
+	outbox contracts.OutboxProvider,
+	logger contracts.Logger,
+) *DetectorService {
+	return &DetectorService{
+		detectorRepo: detectorRepo,
+		scheduleRepo: scheduleRepo,
+		revisionRepo: revisionRepo,
+		txManager:    txManager,
+		auditLog:     auditLog,
+		outbox:       outbox,
+		logger:       logger,
+	}
+}
+
+// CreateDetector 創建新檢測器，並在同一事務中寫入審計日誌與 detector.created 事件
+func (s *DetectorService) CreateDetector(ctx context.Context, detector *entities.Detector) error {
+	if detector.Name == "" {
+		return domainerrors.NewValidationError("name", "檢測器名稱不能為空")
+	}
+
+	now := time.Now()
+	if detector.ID == "" {
+		detector.ID = valueobjects.GenerateNewIDVO().String()
+	}
+	detector.CreatedAt = now
+	detector.UpdatedAt = now
+
+	s.logger.Info("開始創建檢測器", "detector_id", detector.ID, "name", detector.Name)
+
+	err := s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.detectorRepo.Create(ctx, detector); err != nil {
+			return fmt.Errorf("保存檢測器失敗: %w", err)
+		}
+		return s.recordChange(ctx, detector, AuditActionCreate, TopicDetectorCreated)
+	})
+	if err != nil {
+		s.logger.Error("創建檢測器失敗", "detector_id", detector.ID, "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器創建成功", "detector_id", detector.ID)
+	return nil
+}
+
+// GetDetectorByID 根據 ID 獲取檢測器
+func (s *DetectorService) GetDetectorByID(ctx context.Context, id valueobjects.IDVO) (*entities.Detector, error) {
+	detector, err := s.detectorRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("查找檢測器失敗", "detector_id", id.String(), "error", err)
+		return nil, fmt.Errorf("查找檢測器失敗: %w", err)
+	}
+	if detector == nil {
+		return nil, domainerrors.NewNotFoundError("detector", fmt.Sprintf("檢測器不存在: %s", id.String()))
+	}
+	return detector, nil
+}
+
+// UpdateDetector 更新檢測器，並在同一事務中寫入審計日誌與 detector.updated 事件
+func (s *DetectorService) UpdateDetector(ctx context.Context, detector *entities.Detector) error {
+	idVO, err := valueobjects.NewIDVO(detector.ID)
+	if err != nil {
+		return domainerrors.NewValidationError("id", err.Error())
+	}
+	if detector.Name == "" {
+		return domainerrors.NewValidationError("name", "檢測器名稱不能為空")
+	}
+
+	s.logger.Info("更新檢測器", "detector_id", detector.ID)
+
+	err = s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		existing, err := s.GetDetectorByID(ctx, idVO)
+		if err != nil {
+			return err
+		}
+
+		detector.CreatedAt = existing.CreatedAt
+		detector.UpdatedAt = time.Now()
+		if err := s.detectorRepo.Update(ctx, detector); err != nil {
+			return fmt.Errorf("更新檢測器失敗: %w", err)
+		}
+		return s.recordChange(ctx, detector, AuditActionUpdate, TopicDetectorUpdated)
+	})
+	if err != nil {
+		s.logger.Error("更新檢測器失敗", "detector_id", detector.ID, "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器更新成功", "detector_id", detector.ID)
+	return nil
+}
+
+// DeleteDetector 刪除檢測器，並在同一事務中寫入審計日誌與 detector.deleted 事件
+func (s *DetectorService) DeleteDetector(ctx context.Context, id valueobjects.IDVO) error {
+	s.logger.Info("刪除檢測器", "detector_id", id.String())
+
+	err := s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		existing, err := s.GetDetectorByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.scheduleRepo.Delete(ctx, id.String()); err != nil {
+			return fmt.Errorf("刪除檢測器排程失敗: %w", err)
+		}
+		if err := s.detectorRepo.Delete(ctx, id); err != nil {
+			return fmt.Errorf("刪除檢測器失敗: %w", err)
+		}
+		return s.recordChange(ctx, existing, AuditActionDelete, TopicDetectorDeleted)
+	})
+	if err != nil {
+		s.logger.Error("刪除檢測器失敗", "detector_id", id.String(), "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器刪除成功", "detector_id", id.String())
+	return nil
+}
+
+// ListDetectors 列出檢測器
+func (s *DetectorService) ListDetectors(ctx context.Context, offset, limit int) ([]*entities.Detector, error) {
+	detectors, err := s.detectorRepo.List(ctx, offset, limit)
+	if err != nil {
+		s.logger.Error("列出檢測器失敗", "error", err)
+		return nil, fmt.Errorf("列出檢測器失敗: %w", err)
+	}
+	return detectors, nil
+}
+
+// SaveSchedule 創建或更新檢測器的執行排程；更換排程表達式後由排程器從當前時間重新計算下次執行時間
+func (s *DetectorService) SaveSchedule(ctx context.Context, schedule *entities.DetectorSchedule) error {
+	if err := scheduler.ValidateSchedule(schedule); err != nil {
+		return domainerrors.NewValidationError("schedule", err.Error())
+	}
+	idVO, err := valueobjects.NewIDVO(schedule.DetectorID)
+	if err != nil {
+		return domainerrors.NewValidationError("detector_id", err.Error())
+	}
+	schedule.UpdatedAt = time.Now()
+
+	err = s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		detector, err := s.GetDetectorByID(ctx, idVO)
+		if err != nil {
+			return err
+		}
+		if err := s.scheduleRepo.Save(ctx, schedule); err != nil {
+			return fmt.Errorf("保存檢測器排程失敗: %w", err)
+		}
+		return s.auditLog.LogAction(ctx, detector.OwnerID, AuditActionSchedule, "detector:"+detector.ID, map[string]any{
+			"spec":    schedule.Spec,
+			"source":  schedule.Source,
+			"enabled": schedule.Enabled,
+		})
+	})
+	if err != nil {
+		s.logger.Error("保存檢測器排程失敗", "detector_id", schedule.DetectorID, "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器排程已保存", "detector_id", schedule.DetectorID, "spec", schedule.Spec)
+	return nil
+}
+
+// GetSchedule 獲取檢測器的執行排程
+func (s *DetectorService) GetSchedule(ctx context.Context, detectorID string) (*entities.DetectorSchedule, error) {
+	schedule, err := s.scheduleRepo.GetByDetectorID(ctx, detectorID)
+	if err != nil {
+		return nil, fmt.Errorf("查找檢測器排程失敗: %w", err)
+	}
+	if schedule == nil {
+		return nil, domainerrors.NewNotFoundError("detector_schedule", fmt.Sprintf("檢測器排程不存在: %s", detectorID))
+	}
+	return schedule, nil
+}
+
+// CreateConfigRevision 為檢測器保存新的配置版本並分配下一個版本號；新版本不會立即生效
+func (s *DetectorService) CreateConfigRevision(ctx context.Context, revision *entities.DetectorConfigRevision) error {
+	if revision.Plugin == "" {
+		return domainerrors.NewValidationError("plugin", "檢測器插件名稱不能為空")
+	}
+	idVO, err := valueobjects.NewIDVO(revision.DetectorID)
+	if err != nil {
+		return domainerrors.NewValidationError("detector_id", err.Error())
+	}
+	revision.Active = false
+	revision.CreatedAt = time.Now()
+
+	err = s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		detector, err := s.GetDetectorByID(ctx, idVO)
+		if err != nil {
+			return err
+		}
+		latest, err := s.revisionRepo.LatestRevision(ctx, revision.DetectorID)
+		if err != nil {
+			return fmt.Errorf("查詢最新配置版本失敗: %w", err)
+		}
+		revision.Revision = latest + 1
+		if err := s.revisionRepo.Create(ctx, revision); err != nil {
+			return fmt.Errorf("保存配置版本失敗: %w", err)
+		}
+		return s.auditLog.LogAction(ctx, revision.CreatedBy, AuditActionCreateRevision, "detector:"+detector.ID, map[string]any{
+			"revision": revision.Revision,
+			"plugin":   revision.Plugin,
+			"comment":  revision.Comment,
+		})
+	})
+	if err != nil {
+		s.logger.Error("保存檢測器配置版本失敗", "detector_id", revision.DetectorID, "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器配置版本已保存", "detector_id", revision.DetectorID, "revision", revision.Revision)
+	return nil
+}
+
+// ActivateConfigRevision 將指定版本設為生效，已有排程時同時把排程的插件與配置切換到該版本
+func (s *DetectorService) ActivateConfigRevision(ctx context.Context, detectorID string, revision int, actorID string) error {
+	err := s.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		target, err := s.GetConfigRevision(ctx, detectorID, revision)
+		if err != nil {
+			return err
+		}
+		if err := s.revisionRepo.SetActive(ctx, detectorID, revision); err != nil {
+			return fmt.Errorf("切換生效配置版本失敗: %w", err)
+		}
+
+		schedule, err := s.scheduleRepo.GetByDetectorID(ctx, detectorID)
+		if err != nil {
+			return fmt.Errorf("查找檢測器排程失敗: %w", err)
+		}
+		if schedule != nil {
+			schedule.DetectorPlugin = target.Plugin
+			schedule.DetectorConfig = target.Config
+			schedule.UpdatedAt = time.Now()
+			if err := s.scheduleRepo.Save(ctx, schedule); err != nil {
+				return fmt.Errorf("更新檢測器排程失敗: %w", err)
+			}
+		}
+
+		return s.auditLog.LogAction(ctx, actorID, AuditActionActivateRevision, "detector:"+detectorID, map[string]any{
+			"revision": revision,
+			"plugin":   target.Plugin,
+		})
+	})
+	if err != nil {
+		s.logger.Error("啟用檢測器配置版本失敗", "detector_id", detectorID, "revision", revision, "error", err)
+		return err
+	}
+
+	s.logger.Info("檢測器配置版本已生效", "detector_id", detectorID, "revision", revision)
+	return nil
+}
+
+// GetConfigRevision 獲取檢測器的指定配置版本
+func (s *DetectorService) GetConfigRevision(ctx context.Context, detectorID string, revision int) (*entities.DetectorConfigRevision, error) {
+	rev, err := s.revisionRepo.Get(ctx, detectorID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("查找配置版本失敗: %w", err)
+	}
+	if rev == nil {
+		return nil, domainerrors.NewNotFoundError("detector_config_revision",
+			fmt.Sprintf("配置版本不存在: %s@%d", detectorID, revision))
+	}
+	return rev, nil
+}
+
+// ListConfigRevisions 按版本號倒序列出檢測器的配置版本
+func (s *DetectorService) ListConfigRevisions(ctx context.Context, detectorID string) ([]*entities.DetectorConfigRevision, error) {
+	revisions, err := s.revisionRepo.List(ctx, detectorID)
+	if err != nil {
+		s.logger.Error("列出檢測器配置版本失敗", "detector_id", detectorID, "error", err)
+		return nil, fmt.Errorf("列出配置版本失敗: %w", err)
+	}
+	return revisions, nil
+}
+
+// recordChange 寫入審計日誌並將變更事件放入發件箱，必須在事務中調用
+func (s *DetectorService) recordChange(ctx context.Context, detector *entities.Detector, action, topic string) error {
+	if err := s.auditLog.LogAction(ctx, detector.OwnerID, action, "detector:"+detector.ID, map[string]any{
+		"name": detector.Name,
+	}); err != nil {
+		return fmt.Errorf("寫入審計日誌失敗: %w", err)
+	}
+
+	if err := s.outbox.Enqueue(ctx, topic, DetectorEvent{
+		DetectorID: detector.ID,
+		Name:       detector.Name,
+		OwnerID:    detector.OwnerID,
+		OccurredAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("寫入事件發件箱失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// unitOfWork 模擬事務：寫入先暫存，RunInTx 成功時才提交
+type unitOfWork struct {
+	detectors map[string]*entities.Detector
+	audits    []string
+	events    []string
+
+	staged []func()
+}
+
+type txKey struct{}
+
+func (u *unitOfWork) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.staged = nil
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		u.staged = nil
+		return err
+	}
+	for _, apply := range u.staged {
+		apply()
+	}
+	u.staged = nil
+	return nil
+}
+
+func (u *unitOfWork) GetName() string { return "unit_of_work" }
+
+// stage 要求調用方處於事務中，模擬 repository 從 ctx 取得事務
+func (u *unitOfWork) stage(ctx context.Context, apply func()) error {
+	if ctx.Value(txKey{}) == nil {
+		return errors.New("not in transaction")
+	}
+	u.staged = append(u.staged, apply)
+	return nil
+}
+
+type fakeDetectorRepo struct{ uow *unitOfWork }
+
+func (r *fakeDetectorRepo) Create(ctx context.Context, d *entities.Detector) error {
+	copy := *d
+	return r.uow.stage(ctx, func() { r.uow.detectors[d.ID] = &copy })
+}
+func (r *fakeDetectorRepo) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.Detector, error) {
+	return r.uow.detectors[id.String()], nil
+}
+func (r *fakeDetectorRepo) Update(ctx context.Context, d *entities.Detector) error {
+	copy := *d
+	return r.uow.stage(ctx, func() { r.uow.detectors[d.ID] = &copy })
+}
+func (r *fakeDetectorRepo) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	return r.uow.stage(ctx, func() { delete(r.uow.detectors, id.String()) })
+}
+func (r *fakeDetectorRepo) List(ctx context.Context, offset, limit int) ([]*entities.Detector, error) {
+	var out []*entities.Detector
+	for _, d := range r.uow.detectors {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+type fakeScheduleRepo struct {
+	uow       *unitOfWork
+	schedules map[string]*entities.DetectorSchedule
+}
+
+func (r *fakeScheduleRepo) Save(ctx context.Context, sch *entities.DetectorSchedule) error {
+	copy := *sch
+	return r.uow.stage(ctx, func() { r.schedules[sch.DetectorID] = &copy })
+}
+func (r *fakeScheduleRepo) GetByDetectorID(ctx context.Context, id string) (*entities.DetectorSchedule, error) {
+	return r.schedules[id], nil
+}
+func (r *fakeScheduleRepo) ListEnabled(ctx context.Context) ([]*entities.DetectorSchedule, error) {
+	return nil, nil
+}
+func (r *fakeScheduleRepo) ClaimNextRun(ctx context.Context, id string, expected, next time.Time) (bool, error) {
+	return true, nil
+}
+func (r *fakeScheduleRepo) RecordRun(ctx context.Context, id string, at time.Time, status, errMsg string) error {
+	return nil
+}
+func (r *fakeScheduleRepo) Delete(ctx context.Context, id string) error {
+	return r.uow.stage(ctx, func() { delete(r.schedules, id) })
+}
+
+type fakeRevisionRepo struct {
+	uow       *unitOfWork
+	revisions map[string][]*entities.DetectorConfigRevision
+}
+
+func (r *fakeRevisionRepo) Create(ctx context.Context, rev *entities.DetectorConfigRevision) error {
+	copy := *rev
+	return r.uow.stage(ctx, func() { r.revisions[rev.DetectorID] = append(r.revisions[rev.DetectorID], &copy) })
+}
+func (r *fakeRevisionRepo) Get(ctx context.Context, id string, revision int) (*entities.DetectorConfigRevision, error) {
+	for _, rev := range r.revisions[id] {
+		if rev.Revision == revision {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+func (r *fakeRevisionRepo) GetActive(ctx context.Context, id string) (*entities.DetectorConfigRevision, error) {
+	for _, rev := range r.revisions[id] {
+		if rev.Active {
+			return rev, nil
+		}
+	}
+	return nil, nil
+}
+func (r *fakeRevisionRepo) LatestRevision(ctx context.Context, id string) (int, error) {
+	return len(r.revisions[id]), nil
+}
+func (r *fakeRevisionRepo) List(ctx context.Context, id string) ([]*entities.DetectorConfigRevision, error) {
+	return r.revisions[id], nil
+}
+func (r *fakeRevisionRepo) SetActive(ctx context.Context, id string, revision int) error {
+	return r.uow.stage(ctx, func() {
+		for _, rev := range r.revisions[id] {
+			rev.Active = rev.Revision == revision
+		}
+	})
+}
+
+type fakeAuditLog struct {
+	uow *unitOfWork
+	err error
+}
+
+func (a *fakeAuditLog) LogAction(ctx context.Context, userID, action, resource string, metadata map[string]any) error {
+	if a.err != nil {
+		return a.err
+	}
+	return a.uow.stage(ctx, func() { a.uow.audits = append(a.uow.audits, action) })
+}
+func (a *fakeAuditLog) GetName() string { return "fake_audit" }
+
+type fakeOutbox struct {
+	uow *unitOfWork
+	err error
+}
+
+func (o *fakeOutbox) Enqueue(ctx context.Context, topic string, payload interface{}) error {
+	if o.err != nil {
+		return o.err
+	}
+	return o.uow.stage(ctx, func() { o.uow.events = append(o.uow.events, topic) })
+}
+func (o *fakeOutbox) GetName() string { return "fake_outbox" }
+
+func newTestService(auditErr, outboxErr error) (*DetectorService, *unitOfWork) {
+	uow := &unitOfWork{detectors: map[string]*entities.Detector{}}
+	svc := NewDetectorService(
+		&fakeDetectorRepo{uow: uow},
+		&fakeScheduleRepo{uow: uow, schedules: map[string]*entities.DetectorSchedule{}},
+		&fakeRevisionRepo{uow: uow, revisions: map[string][]*entities.DetectorConfigRevision{}},
+		uow,
+		&fakeAuditLog{uow: uow, err: auditErr},
+		&fakeOutbox{uow: uow, err: outboxErr},
+		&testLogger{},
+	)
+	return svc, uow
+}
+
+func TestDetectorService_CreateDetector(t *testing.T) {
+	errFail := errors.New("write failed")
+
+	tests := []struct {
+		name          string
+		auditErr      error
+		outboxErr     error
+		wantErr       bool
+		wantDetectors int
+		wantAudits    int
+		wantEvents    int
+	}{
+		{name: "commits detector, audit and event together", wantDetectors: 1, wantAudits: 1, wantEvents: 1},
+		{name: "audit failure rolls back everything", auditErr: errFail, wantErr: true},
+		{name: "outbox failure rolls back everything", outboxErr: errFail, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, uow := newTestService(tt.auditErr, tt.outboxErr)
+
+			err := svc.CreateDetector(context.Background(), &entities.Detector{Name: "cpu", OwnerID: "user-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateDetector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(uow.detectors) != tt.wantDetectors || len(uow.audits) != tt.wantAudits || len(uow.events) != tt.wantEvents {
+				t.Errorf("committed detectors=%d audits=%d events=%d, want %d/%d/%d",
+					len(uow.detectors), len(uow.audits), len(uow.events), tt.wantDetectors, tt.wantAudits, tt.wantEvents)
+			}
+			if !tt.wantErr && uow.events[0] != TopicDetectorCreated {
+				t.Errorf("event topic = %s, want %s", uow.events[0], TopicDetectorCreated)
+			}
+		})
+	}
+}
+
+func TestDetectorService_CreateDetector_Validation(t *testing.T) {
+	svc, _ := newTestService(nil, nil)
+	err := svc.CreateDetector(context.Background(), &entities.Detector{})
+	if !domainerrors.IsValidationError(err) {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
+
+func TestDetectorService_UpdateAndDelete(t *testing.T) {
+	svc, uow := newTestService(nil, nil)
+	ctx := context.Background()
+
+	detector := &entities.Detector{Name: "cpu", OwnerID: "user-1"}
+	if err := svc.CreateDetector(ctx, detector); err != nil {
+		t.Fatalf("CreateDetector() error = %v", err)
+	}
+
+	detector.Name = "cpu-high"
+	if err := svc.UpdateDetector(ctx, detector); err != nil {
+		t.Fatalf("UpdateDetector() error = %v", err)
+	}
+	if uow.detectors[detector.ID].Name != "cpu-high" {
+		t.Errorf("detector name = %s, want cpu-high", uow.detectors[detector.ID].Name)
+	}
+
+	id, _ := valueobjects.NewIDVO(detector.ID)
+	if err := svc.DeleteDetector(ctx, id); err != nil {
+		t.Fatalf("DeleteDetector() error = %v", err)
+	}
+	if len(uow.detectors) != 0 {
+		t.Errorf("detector not deleted")
+	}
+
+	wantEvents := []string{TopicDetectorCreated, TopicDetectorUpdated, TopicDetectorDeleted}
+	if len(uow.events) != len(wantEvents) {
+		t.Fatalf("events = %v, want %v", uow.events, wantEvents)
+	}
+	for i, topic := range wantEvents {
+		if uow.events[i] != topic {
+			t.Errorf("events[%d] = %s, want %s", i, uow.events[i], topic)
+		}
+	}
+
+	if err := svc.DeleteDetector(ctx, id); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("second delete error = %v, want not found", err)
+	}
+}
+
+func TestDetectorService_SaveSchedule(t *testing.T) {
+	svc, uow := newTestService(nil, nil)
+	ctx := context.Background()
+
+	detector := &entities.Detector{Name: "cpu", OwnerID: "user-1"}
+	if err := svc.CreateDetector(ctx, detector); err != nil {
+		t.Fatalf("CreateDetector() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		schedule entities.DetectorSchedule
+		wantErr  bool
+	}{
+		{
+			name:     "valid schedule gets defaults",
+			schedule: entities.DetectorSchedule{DetectorID: detector.ID, DetectorPlugin: "threshold", Source: "metrics", Spec: "@every 1m"},
+		},
+		{
+			name:     "invalid spec",
+			schedule: entities.DetectorSchedule{DetectorID: detector.ID, DetectorPlugin: "threshold", Source: "metrics", Spec: "every minute"},
+			wantErr:  true,
+		},
+		{
+			name: "unknown missed run policy",
+			schedule: entities.DetectorSchedule{DetectorID: detector.ID, DetectorPlugin: "threshold", Source: "metrics",
+				Spec: "@every 1m", MissedRunPolicy: "replay"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.SaveSchedule(ctx, &tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SaveSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !domainerrors.IsValidationError(err) {
+				t.Errorf("error = %v, want validation error", err)
+			}
+		})
+	}
+
+	saved, err := svc.GetSchedule(ctx, detector.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule() error = %v", err)
+	}
+	if saved.MissedRunPolicy != entities.MissedRunPolicySkip || saved.MaxConcurrency != 1 {
+		t.Errorf("defaults not applied: policy=%q concurrency=%d", saved.MissedRunPolicy, saved.MaxConcurrency)
+	}
+	if uow.audits[len(uow.audits)-1] != AuditActionSchedule {
+		t.Errorf("last audit action = %s, want %s", uow.audits[len(uow.audits)-1], AuditActionSchedule)
+	}
+}
+
+func TestDetectorService_ConfigRevisions(t *testing.T) {
+	svc, uow := newTestService(nil, nil)
+	ctx := context.Background()
+
+	detector := &entities.Detector{Name: "cpu", OwnerID: "user-1"}
+	if err := svc.CreateDetector(ctx, detector); err != nil {
+		t.Fatalf("CreateDetector() error = %v", err)
+	}
+	if err := svc.SaveSchedule(ctx, &entities.DetectorSchedule{DetectorID: detector.ID, DetectorPlugin: "threshold_detector_plugin",
+		DetectorConfig: map[string]interface{}{"upper_threshold": 80.0}, Source: "metrics", Spec: "@every 1m"}); err != nil {
+		t.Fatalf("SaveSchedule() error = %v", err)
+	}
+
+	if err := svc.CreateConfigRevision(ctx, &entities.DetectorConfigRevision{DetectorID: detector.ID}); !domainerrors.IsValidationError(err) {
+		t.Fatalf("CreateConfigRevision() without plugin error = %v, want validation error", err)
+	}
+
+	for _, upper := range []float64{85, 90} {
+		rev := &entities.DetectorConfigRevision{DetectorID: detector.ID, Plugin: "threshold_detector_plugin",
+			Config: map[string]interface{}{"upper_threshold": upper}, CreatedBy: "user-1"}
+		if err := svc.CreateConfigRevision(ctx, rev); err != nil {
+			t.Fatalf("CreateConfigRevision() error = %v", err)
+		}
+	}
+	revisions, err := svc.ListConfigRevisions(ctx, detector.ID)
+	if err != nil || len(revisions) != 2 || revisions[1].Revision != 2 {
+		t.Fatalf("ListConfigRevisions() = %v, %v; want revisions 1 and 2", revisions, err)
+	}
+
+	if err := svc.ActivateConfigRevision(ctx, detector.ID, 3, "user-1"); !domainerrors.IsNotFoundError(err) {
+		t.Fatalf("ActivateConfigRevision() of unknown revision error = %v, want not found", err)
+	}
+	if err := svc.ActivateConfigRevision(ctx, detector.ID, 2, "user-1"); err != nil {
+		t.Fatalf("ActivateConfigRevision() error = %v", err)
+	}
+
+	schedule, err := svc.GetSchedule(ctx, detector.ID)
+	if err != nil {
+		t.Fatalf("GetSchedule() error = %v", err)
+	}
+	if schedule.DetectorConfig["upper_threshold"] != 90.0 {
+		t.Errorf("schedule config = %v, want revision 2 config", schedule.DetectorConfig)
+	}
+	if !revisions[1].Active || revisions[0].Active {
+		t.Error("expected only revision 2 to be active")
+	}
+	if uow.audits[len(uow.audits)-1] != AuditActionActivateRevision {
+		t.Errorf("last audit action = %s, want %s", uow.audits[len(uow.audits)-1], AuditActionActivateRevision)
+	}
+}
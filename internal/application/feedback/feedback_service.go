@@ -0,0 +1,152 @@
+package feedback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// FeedbackService 管理分析師對分析結果的反饋標籤
+// 職責: 驗證並保存 true_positive、false_positive 與 expected 標記，按檢測器彙總標籤，
+// 並透過 MetricsProvider 導出每個檢測器的標記數與精確率。
+// 標記的結果同時作為回放新配置時的評估數據，見 backfill.BackfillService。
+type FeedbackService struct {
+	results  interfaces.AnalysisResultRepository
+	feedback interfaces.ResultFeedbackRepository
+	logger   contracts.Logger
+	metrics  contracts.MetricsProvider
+
+	now func() time.Time
+}
+
+// NewFeedbackService 創建新的反饋服務，metrics 可為 nil
+func NewFeedbackService(
+	results interfaces.AnalysisResultRepository,
+	feedback interfaces.ResultFeedbackRepository,
+	logger contracts.Logger,
+	metrics contracts.MetricsProvider,
+) *FeedbackService {
+	return &FeedbackService{
+		results:  results,
+		feedback: feedback,
+		logger:   logger,
+		metrics:  metrics,
+		now:      time.Now,
+	}
+}
+
+// GetName 返回服務名稱
+func (s *FeedbackService) GetName() string {
+	return "feedback_service"
+}
+
+// GetResult 獲取分析結果
+func (s *FeedbackService) GetResult(ctx context.Context, resultID string) (*entities.AnalysisResult, error) {
+	id, err := valueobjects.NewIDVO(resultID)
+	if err != nil {
+		return nil, domainerrors.NewValidationError("analysis_result_id", err.Error())
+	}
+	result, err := s.results.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找分析結果失敗: %w", err)
+	}
+	if result == nil {
+		return nil, domainerrors.NewNotFoundError("analysis_result", fmt.Sprintf("分析結果不存在: %s", resultID))
+	}
+	return result, nil
+}
+
+// Label 標記分析結果，重新標記時覆蓋之前的標籤、標記者與原因
+func (s *FeedbackService) Label(ctx context.Context, resultID, label, userID, reason string) (*entities.ResultFeedback, error) {
+	if !entities.IsValidFeedbackLabel(label) {
+		return nil, domainerrors.NewValidationError("label",
+			fmt.Sprintf("標籤必須為 %s、%s 或 %s", entities.FeedbackLabelTruePositive,
+				entities.FeedbackLabelFalsePositive, entities.FeedbackLabelExpected))
+	}
+	if _, err := valueobjects.NewIDVO(userID); err != nil {
+		return nil, domainerrors.NewValidationError("user_id", err.Error())
+	}
+	result, err := s.GetResult(ctx, resultID)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback, err := s.feedback.GetByResult(ctx, result.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查找反饋標籤失敗: %w", err)
+	}
+	now := s.now()
+	if feedback == nil {
+		feedback = &entities.ResultFeedback{
+			AnalysisResultID: result.ID,
+			DetectorID:       result.DetectorID,
+			ResultTimestamp:  result.Timestamp,
+			CreatedAt:        now,
+		}
+	}
+	feedback.Label = label
+	feedback.UserID = userID
+	feedback.Reason = reason
+	feedback.UpdatedAt = now
+	if err := s.feedback.Save(ctx, feedback); err != nil {
+		return nil, fmt.Errorf("保存反饋標籤失敗: %w", err)
+	}
+
+	s.logger.Info("分析結果已標記", "result_id", result.ID, "detector_id", result.DetectorID, "label", label, "user_id", userID)
+	if s.metrics != nil {
+		s.metrics.IncCounter("detector_feedback_total", map[string]string{"detector_id": result.DetectorID, "label": label})
+		if err := s.exportQuality(ctx, result.DetectorID); err != nil {
+			s.logger.Warn("導出檢測器品質指標失敗", "detector_id", result.DetectorID, "error", err)
+		}
+	}
+	return feedback, nil
+}
+
+// GetFeedback 獲取分析結果的標記
+func (s *FeedbackService) GetFeedback(ctx context.Context, resultID string) (*entities.ResultFeedback, error) {
+	feedback, err := s.feedback.GetByResult(ctx, resultID)
+	if err != nil {
+		return nil, fmt.Errorf("查找反饋標籤失敗: %w", err)
+	}
+	if feedback == nil {
+		return nil, domainerrors.NewNotFoundError("result_feedback", fmt.Sprintf("分析結果尚未標記: %s", resultID))
+	}
+	return feedback, nil
+}
+
+// Quality 彙總檢測器已標記結果的標籤數量
+func (s *FeedbackService) Quality(ctx context.Context, detectorID string) (*entities.DetectorQuality, error) {
+	if detectorID == "" {
+		return nil, domainerrors.NewValidationError("detector_id", "檢測器 ID 不能為空")
+	}
+	counts, err := s.feedback.CountByDetector(ctx, detectorID)
+	if err != nil {
+		return nil, fmt.Errorf("統計反饋標籤失敗: %w", err)
+	}
+	return &entities.DetectorQuality{
+		DetectorID:     detectorID,
+		TruePositives:  counts[entities.FeedbackLabelTruePositive],
+		FalsePositives: counts[entities.FeedbackLabelFalsePositive],
+		Expected:       counts[entities.FeedbackLabelExpected],
+	}, nil
+}
+
+// exportQuality 以最新的標籤數量更新檢測器的品質指標
+func (s *FeedbackService) exportQuality(ctx context.Context, detectorID string) error {
+	quality, err := s.Quality(ctx, detectorID)
+	if err != nil {
+		return err
+	}
+	tags := map[string]string{"detector_id": detectorID}
+	s.metrics.SetGauge("detector_feedback_labelled", float64(quality.Labelled()), tags)
+	if precision, ok := quality.Precision(); ok {
+		s.metrics.SetGauge("detector_precision", precision, tags)
+	}
+	return nil
+}
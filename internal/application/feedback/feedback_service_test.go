@@ -0,0 +1,166 @@
+package feedback
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type memoryResultRepo struct {
+	results map[string]*entities.AnalysisResult
+}
+
+func (r *memoryResultRepo) Create(ctx context.Context, result *entities.AnalysisResult) error {
+	r.results[result.ID] = result
+	return nil
+}
+func (r *memoryResultRepo) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.AnalysisResult, error) {
+	return r.results[id.String()], nil
+}
+func (r *memoryResultRepo) Update(ctx context.Context, result *entities.AnalysisResult) error {
+	return r.Create(ctx, result)
+}
+func (r *memoryResultRepo) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	delete(r.results, id.String())
+	return nil
+}
+func (r *memoryResultRepo) List(ctx context.Context, offset, limit int) ([]*entities.AnalysisResult, error) {
+	return nil, nil
+}
+
+type memoryFeedbackRepo struct {
+	feedback map[string]entities.ResultFeedback
+}
+
+func (r *memoryFeedbackRepo) Save(ctx context.Context, f *entities.ResultFeedback) error {
+	r.feedback[f.AnalysisResultID] = *f
+	return nil
+}
+func (r *memoryFeedbackRepo) GetByResult(ctx context.Context, id string) (*entities.ResultFeedback, error) {
+	f, ok := r.feedback[id]
+	if !ok {
+		return nil, nil
+	}
+	return &f, nil
+}
+func (r *memoryFeedbackRepo) ListByDetector(ctx context.Context, id string, start, end time.Time) ([]*entities.ResultFeedback, error) {
+	return nil, nil
+}
+func (r *memoryFeedbackRepo) CountByDetector(ctx context.Context, id string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, f := range r.feedback {
+		if f.DetectorID == id {
+			counts[f.Label]++
+		}
+	}
+	return counts, nil
+}
+
+// recordingMetrics 記錄最近一次設置的儀表盤值與計數器次數
+type recordingMetrics struct {
+	mu       sync.Mutex
+	counters map[string]int
+	gauges   map[string]float64
+}
+
+func (m *recordingMetrics) IncCounter(name string, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name+"/"+tags["label"]]++
+}
+func (m *recordingMetrics) ObserveHistogram(name string, value float64, tags map[string]string) {}
+func (m *recordingMetrics) SetGauge(name string, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name+"/"+tags["detector_id"]] = value
+}
+func (m *recordingMetrics) GetName() string { return "recording_metrics" }
+
+func TestFeedbackService_LabelAndQuality(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	results := &memoryResultRepo{results: map[string]*entities.AnalysisResult{}}
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id := valueobjects.GenerateNewIDVO().String()
+		ids = append(ids, id)
+		results.Create(ctx, &entities.AnalysisResult{ID: id, DetectorID: "det-1", Timestamp: base.Add(time.Duration(i) * time.Minute)})
+	}
+	metrics := &recordingMetrics{counters: map[string]int{}, gauges: map[string]float64{}}
+	s := NewFeedbackService(results, &memoryFeedbackRepo{feedback: map[string]entities.ResultFeedback{}}, &testLogger{}, metrics)
+	s.now = func() time.Time { return base.Add(time.Hour) }
+	user := valueobjects.GenerateNewIDVO().String()
+
+	for i, label := range []string{entities.FeedbackLabelTruePositive, entities.FeedbackLabelTruePositive,
+		entities.FeedbackLabelFalsePositive, entities.FeedbackLabelExpected} {
+		if _, err := s.Label(ctx, ids[i], label, user, "checked dashboards"); err != nil {
+			t.Fatalf("Label(%s) error = %v", label, err)
+		}
+	}
+	// 重新標記覆蓋之前的標籤
+	relabelled, err := s.Label(ctx, ids[1], entities.FeedbackLabelFalsePositive, user, "batch job")
+	if err != nil {
+		t.Fatalf("Label() error = %v", err)
+	}
+	if relabelled.DetectorID != "det-1" || !relabelled.ResultTimestamp.Equal(base.Add(time.Minute)) || relabelled.Reason != "batch job" {
+		t.Errorf("unexpected feedback: %+v", relabelled)
+	}
+
+	quality, err := s.Quality(ctx, "det-1")
+	if err != nil {
+		t.Fatalf("Quality() error = %v", err)
+	}
+	if quality.TruePositives != 1 || quality.FalsePositives != 2 || quality.Expected != 1 || quality.Labelled() != 4 {
+		t.Errorf("unexpected quality: %+v", quality)
+	}
+	if precision, ok := quality.Precision(); !ok || precision < 0.33 || precision > 0.34 {
+		t.Errorf("precision = %v, %v", precision, ok)
+	}
+	if got := metrics.gauges["detector_precision/det-1"]; got < 0.33 || got > 0.34 {
+		t.Errorf("exported precision = %v", got)
+	}
+	if metrics.gauges["detector_feedback_labelled/det-1"] != 4 || metrics.counters["detector_feedback_total/"+entities.FeedbackLabelFalsePositive] != 2 {
+		t.Errorf("unexpected metrics: counters %v, gauges %v", metrics.counters, metrics.gauges)
+	}
+}
+
+func TestFeedbackService_Validation(t *testing.T) {
+	ctx := context.Background()
+	results := &memoryResultRepo{results: map[string]*entities.AnalysisResult{}}
+	s := NewFeedbackService(results, &memoryFeedbackRepo{feedback: map[string]entities.ResultFeedback{}}, &testLogger{}, nil)
+	user := valueobjects.GenerateNewIDVO().String()
+	missing := valueobjects.GenerateNewIDVO().String()
+
+	if _, err := s.Label(ctx, missing, "maybe", user, ""); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for unknown label, got %v", err)
+	}
+	if _, err := s.Label(ctx, missing, entities.FeedbackLabelTruePositive, "alice", ""); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for non-UUID user, got %v", err)
+	}
+	if _, err := s.Label(ctx, "not-a-uuid", entities.FeedbackLabelTruePositive, user, ""); !domainerrors.IsValidationError(err) {
+		t.Errorf("expected validation error for malformed result ID, got %v", err)
+	}
+	if _, err := s.Label(ctx, missing, entities.FeedbackLabelTruePositive, user, ""); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err := s.GetFeedback(ctx, missing); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("expected not found error for unlabelled result, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// 階段類型
+const (
+	StageTypeTransform     = "transform"
+	StageTypeDetector      = "detector"
+	StageTypePostProcessor = "post_processor"
+	StageTypeAlert         = "alert"
+)
+
+// 階段失敗時的處理策略
+const (
+	// OnErrorFail 中止整個管線
+	OnErrorFail = "fail"
+	// OnErrorContinue 將階段標記為失敗，下游以該階段的輸入繼續執行 (detector 階段則不產生結果)
+	OnErrorContinue = "continue"
+	// OnErrorSkip 將階段標記為失敗，只依賴該階段的下游階段被略過
+	OnErrorSkip = "skip"
+)
+
+// Definition 是從 YAML 解析的管線定義
+type Definition struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description"`
+	Timeout     string            `json:"timeout,omitempty" yaml:"timeout"` // 整個管線單次執行的超時時間
+	Stages      []StageDefinition `json:"stages" yaml:"stages"`
+}
+
+// StageDefinition 定義管線中的一個具名階段
+type StageDefinition struct {
+	Name      string                 `json:"name" yaml:"name"`
+	Type      string                 `json:"type" yaml:"type"`
+	Plugin    string                 `json:"plugin" yaml:"plugin"`
+	DependsOn []string               `json:"depends_on,omitempty" yaml:"depends_on"` // 上游階段，為空時接收管線輸入
+	Timeout   string                 `json:"timeout,omitempty" yaml:"timeout"`       // 單次嘗試的超時時間
+	Retries   int                    `json:"retries,omitempty" yaml:"retries"`       // 失敗後的額外嘗試次數
+	OnError   string                 `json:"on_error,omitempty" yaml:"on_error"`     // 見 OnError* 常量，默認 fail
+	Config    map[string]interface{} `json:"config,omitempty" yaml:"config"`
+}
+
+// DefinitionLoader 讀取 YAML 管線定義並以 JSON Schema 驗證
+type DefinitionLoader struct {
+	schema *gojsonschema.Schema
+}
+
+// NewDefinitionLoader 從 schemaPath (通常為 schemas/pipeline.json) 載入驗證用的 JSON Schema
+func NewDefinitionLoader(schemaPath string) (*DefinitionLoader, error) {
+	schemaBytes, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取管線 Schema 文件 %s: %w", schemaPath, err)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("無效的管線 Schema %s: %w", schemaPath, err)
+	}
+	return &DefinitionLoader{schema: schema}, nil
+}
+
+// Parse 解析並驗證一份 YAML 管線定義
+func (l *DefinitionLoader) Parse(data []byte) (*Definition, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("無法解析管線 YAML: %w", err)
+	}
+
+	// 先轉成 JSON 再驗證與解碼，Schema 驗證與結構體解碼看到的是同一份數據
+	jsonBytes, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("無法將管線定義轉換為 JSON: %w", err)
+	}
+
+	result, err := l.schema.Validate(gojsonschema.NewBytesLoader(jsonBytes))
+	if err != nil {
+		return nil, fmt.Errorf("管線 Schema 驗證過程出錯: %w", err)
+	}
+	if !result.Valid() {
+		var errors []string
+		for _, desc := range result.Errors() {
+			errors = append(errors, desc.String())
+		}
+		return nil, fmt.Errorf("管線定義驗證失敗:\n- %s", strings.Join(errors, "\n- "))
+	}
+
+	var def Definition
+	if err := json.Unmarshal(jsonBytes, &def); err != nil {
+		return nil, fmt.Errorf("無法解碼管線定義: %w", err)
+	}
+	return &def, nil
+}
+
+// LoadFile 讀取並驗證單個管線定義文件
+func (l *DefinitionLoader) LoadFile(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取管線定義 %s: %w", path, err)
+	}
+	def, err := l.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return def, nil
+}
+
+// LoadDir 按文件名順序讀取目錄下所有 .yaml 與 .yml 管線定義，管線名稱重複時返回錯誤
+func (l *DefinitionLoader) LoadDir(dir string) ([]*Definition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取管線目錄 %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	seen := make(map[string]string)
+	defs := make([]*Definition, 0, len(files))
+	for _, file := range files {
+		def, err := l.LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[def.Name]; ok {
+			return nil, fmt.Errorf("管線名稱 %s 在 %s 與 %s 中重複定義", def.Name, other, file)
+		}
+		seen[def.Name] = file
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
@@ -0,0 +1,305 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// allowedUpstreams 定義每種階段可以接收哪些階段的輸出
+// transform 與 detector 處理記錄，post_processor 與 alert 處理分析結果。
+var allowedUpstreams = map[string]map[string]bool{
+	StageTypeTransform:     {StageTypeTransform: true},
+	StageTypeDetector:      {StageTypeTransform: true},
+	StageTypePostProcessor: {StageTypeDetector: true, StageTypePostProcessor: true},
+	StageTypeAlert:         {StageTypeDetector: true, StageTypePostProcessor: true},
+}
+
+// Engine 將管線定義編譯為可執行的 Pipeline
+// 職責: 檢查階段圖 (名稱唯一、依賴存在、無環、上下游類型相容)，並解析每個階段使用的插件。
+// detector 階段通過 DetectorFactory 創建獨立實例，管線之間不共享檢測器狀態；其他階段從插件註冊表取得。
+type Engine struct {
+	registry contracts.PluginRegistryProvider
+	factory  plugins.DetectorFactory
+	tracer   contracts.TracingProvider
+	metrics  contracts.MetricsProvider
+	logger   contracts.Logger
+}
+
+// NewEngine 創建管線引擎，tracer 與 metrics 可為 nil
+func NewEngine(
+	registry contracts.PluginRegistryProvider,
+	factory plugins.DetectorFactory,
+	tracer contracts.TracingProvider,
+	metrics contracts.MetricsProvider,
+	logger contracts.Logger,
+) *Engine {
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	return &Engine{
+		registry: registry,
+		factory:  factory,
+		tracer:   tracer,
+		metrics:  metrics,
+		logger:   logger,
+	}
+}
+
+// Compile 驗證管線定義並解析所有插件，返回可重複執行的 Pipeline
+// 呼叫方在不再使用 Pipeline 時應調用 Close 停止其擁有的檢測器實例。
+func (e *Engine) Compile(ctx context.Context, def *Definition) (*Pipeline, error) {
+	if def == nil {
+		return nil, fmt.Errorf("管線定義不能為空")
+	}
+	if def.Name == "" {
+		return nil, fmt.Errorf("管線名稱不能為空")
+	}
+	if len(def.Stages) == 0 {
+		return nil, fmt.Errorf("管線 %s 沒有定義任何階段", def.Name)
+	}
+
+	timeout, err := parseOptionalDuration(def.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("管線 %s 的 timeout 無效: %w", def.Name, err)
+	}
+
+	index := make(map[string]int, len(def.Stages))
+	for i, sd := range def.Stages {
+		if sd.Name == "" {
+			return nil, fmt.Errorf("管線 %s 的第 %d 個階段缺少名稱", def.Name, i)
+		}
+		if _, ok := index[sd.Name]; ok {
+			return nil, fmt.Errorf("管線 %s 的階段名稱 %s 重複", def.Name, sd.Name)
+		}
+		index[sd.Name] = i
+	}
+
+	stages := make([]*stage, len(def.Stages))
+	for i, sd := range def.Stages {
+		st, err := newStage(sd, index)
+		if err != nil {
+			return nil, fmt.Errorf("管線 %s 的階段 %s: %w", def.Name, sd.Name, err)
+		}
+		stages[i] = st
+	}
+
+	order, err := topologicalOrder(stages)
+	if err != nil {
+		return nil, fmt.Errorf("管線 %s: %w", def.Name, err)
+	}
+
+	for _, st := range stages {
+		if len(st.upstreams) == 0 && st.stageType != StageTypeTransform && st.stageType != StageTypeDetector {
+			return nil, fmt.Errorf("管線 %s 的階段 %s: %s 階段必須依賴 detector 或 post_processor 階段", def.Name, st.name, st.stageType)
+		}
+		for _, up := range st.upstreams {
+			if upType := stages[up].stageType; !allowedUpstreams[st.stageType][upType] {
+				return nil, fmt.Errorf("管線 %s 的階段 %s: %s 階段不能接收 %s 階段 %s 的輸出",
+					def.Name, st.name, st.stageType, upType, stages[up].name)
+			}
+		}
+	}
+
+	p := &Pipeline{
+		name:    def.Name,
+		timeout: timeout,
+		tracer:  e.tracer,
+		metrics: e.metrics,
+		logger:  e.logger,
+	}
+	// 按拓撲順序重排階段並改寫依賴索引，執行時可直接按順序啟動
+	position := make([]int, len(stages))
+	for pos, i := range order {
+		position[i] = pos
+	}
+	hasDownstream := make([]bool, len(stages))
+	for _, i := range order {
+		st := stages[i]
+		for j, up := range st.upstreams {
+			st.upstreams[j] = position[up]
+			hasDownstream[up] = true
+		}
+		p.stages = append(p.stages, st)
+	}
+	for _, i := range order {
+		if !hasDownstream[i] {
+			p.sinks = append(p.sinks, position[i])
+		}
+	}
+
+	for _, st := range p.stages {
+		if err := e.resolve(ctx, st); err != nil {
+			if closeErr := p.Close(ctx); closeErr != nil {
+				e.logger.Warn("停止管線檢測器失敗", "pipeline", def.Name, "error", closeErr)
+			}
+			return nil, fmt.Errorf("管線 %s 的階段 %s: %w", def.Name, st.name, err)
+		}
+	}
+
+	e.logger.Info("已編譯檢測管線", "pipeline", def.Name, "stages", len(p.stages))
+	return p, nil
+}
+
+// resolve 為階段取得插件實例
+func (e *Engine) resolve(ctx context.Context, st *stage) error {
+	if st.stageType == StageTypeDetector {
+		if e.factory == nil {
+			return fmt.Errorf("未配置檢測器工廠，無法創建檢測器 %s", st.plugin)
+		}
+		detector, err := e.factory.NewDetector(ctx, st.plugin, st.config)
+		if err != nil {
+			return fmt.Errorf("創建檢測器 %s 失敗: %w", st.plugin, err)
+		}
+		st.detector = detector
+		return nil
+	}
+
+	instance, err := e.registry.Get(st.plugin)
+	if err != nil {
+		return fmt.Errorf("插件 %s 未註冊: %w", st.plugin, err)
+	}
+	var ok bool
+	switch st.stageType {
+	case StageTypeTransform:
+		st.transform, ok = instance.(plugins.TransformPlugin)
+	case StageTypePostProcessor:
+		st.postProcessor, ok = instance.(plugins.AnalysisPostProcessorPlugin)
+	case StageTypeAlert:
+		st.alert, ok = instance.(plugins.AlertPlugin)
+	}
+	if !ok {
+		return fmt.Errorf("插件 %s 不是 %s 插件", st.plugin, st.stageType)
+	}
+	return nil
+}
+
+// newStage 將階段定義轉換為內部表示，upstreams 暫時使用定義中的索引
+func newStage(sd StageDefinition, index map[string]int) (*stage, error) {
+	if _, ok := allowedUpstreams[sd.Type]; !ok {
+		return nil, fmt.Errorf("不支援的階段類型 %q", sd.Type)
+	}
+	if sd.Plugin == "" {
+		return nil, fmt.Errorf("缺少插件名稱")
+	}
+
+	timeout, err := parseOptionalDuration(sd.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("timeout 無效: %w", err)
+	}
+	if sd.Retries < 0 {
+		return nil, fmt.Errorf("retries 不能為負數")
+	}
+
+	onError := sd.OnError
+	switch onError {
+	case "":
+		onError = OnErrorFail
+	case OnErrorFail, OnErrorContinue, OnErrorSkip:
+	default:
+		return nil, fmt.Errorf("不支援的 on_error 策略 %q", sd.OnError)
+	}
+
+	st := &stage{
+		name:      sd.Name,
+		stageType: sd.Type,
+		plugin:    sd.Plugin,
+		config:    sd.Config,
+		timeout:   timeout,
+		retries:   sd.Retries,
+		onError:   onError,
+	}
+	if st.config == nil {
+		st.config = map[string]interface{}{}
+	}
+
+	seen := make(map[string]bool, len(sd.DependsOn))
+	for _, dep := range sd.DependsOn {
+		if dep == sd.Name {
+			return nil, fmt.Errorf("不能依賴自身")
+		}
+		if seen[dep] {
+			return nil, fmt.Errorf("重複依賴階段 %s", dep)
+		}
+		up, ok := index[dep]
+		if !ok {
+			return nil, fmt.Errorf("依賴的階段 %s 不存在", dep)
+		}
+		seen[dep] = true
+		st.upstreams = append(st.upstreams, up)
+	}
+	return st, nil
+}
+
+// topologicalOrder 返回階段的拓撲順序，同層級的階段保持定義順序
+func topologicalOrder(stages []*stage) ([]int, error) {
+	pending := make([]int, len(stages))
+	downstream := make([][]int, len(stages))
+	for i, st := range stages {
+		pending[i] = len(st.upstreams)
+		for _, up := range st.upstreams {
+			downstream[up] = append(downstream[up], i)
+		}
+	}
+
+	order := make([]int, 0, len(stages))
+	placed := make([]bool, len(stages))
+	for len(order) < len(stages) {
+		next := -1
+		for i := range stages {
+			if !placed[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cyclic []string
+			for i, st := range stages {
+				if !placed[i] {
+					cyclic = append(cyclic, st.name)
+				}
+			}
+			return nil, fmt.Errorf("階段之間存在循環依賴: %v", cyclic)
+		}
+		placed[next] = true
+		order = append(order, next)
+		for _, down := range downstream[next] {
+			pending[down]--
+		}
+	}
+	return order, nil
+}
+
+// parseOptionalDuration 解析可選的時間長度，空字串表示不限制
+func parseOptionalDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("不能為負數")
+	}
+	return d, nil
+}
+
+// noopTracer 在未配置追蹤提供者時使用
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(ctx context.Context, operationName string) (context.Context, contracts.Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) GetName() string { return "noop_tracer" }
+
+type noopSpan struct{}
+
+func (noopSpan) SetTag(key string, value interface{}) {}
+func (noopSpan) SetError(err error)                   {}
+func (noopSpan) Finish()                              {}
@@ -0,0 +1,522 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// 管線與階段的執行狀態
+const (
+	StatusProcessed = "processed"
+	StatusFailed    = "failed"
+
+	StageStatusSucceeded = "succeeded"
+	StageStatusFailed    = "failed"
+	StageStatusSkipped   = "skipped"
+)
+
+// Input 是一次管線執行的輸入
+type Input struct {
+	DetectionID string                   // 寫入 DetectionResult.DetectionID
+	DetectorID  string                   // 填充檢測器未設定 DetectorID 的分析結果
+	Records     []map[string]interface{} // 交給無上游階段的記錄
+}
+
+// Output 是一次管線執行的輸出
+type Output struct {
+	// Result 記錄處理狀態與每個階段的耗時
+	Result *entities.DetectionResult
+	// Records 是末端 transform 階段輸出的記錄
+	Records []map[string]interface{}
+	// AnalysisResults 是末端 detector、post_processor 與 alert 階段輸出的分析結果，按 ID 去重
+	AnalysisResults []*entities.AnalysisResult
+}
+
+// stage 是編譯後的管線階段
+type stage struct {
+	name      string
+	stageType string
+	plugin    string
+	config    map[string]interface{}
+	upstreams []int // 上游階段在 Pipeline.stages 中的索引
+	timeout   time.Duration
+	retries   int
+	onError   string
+
+	transform     plugins.TransformPlugin
+	detector      plugins.DetectorPlugin
+	postProcessor plugins.AnalysisPostProcessorPlugin
+	alert         plugins.AlertPlugin
+}
+
+// stageData 是階段之間傳遞的數據
+type stageData struct {
+	records []map[string]interface{}
+	results []*entities.AnalysisResult
+}
+
+// Pipeline 是編譯完成的檢測管線
+// 職責: 每個階段在自己的 goroutine 中等待所有上游完成後執行，多個階段依賴同一上游時並行扇出，
+// 一個階段依賴多個上游時合併其輸出 (記錄按依賴順序串接，分析結果按 ID 去重並合併 Data)。
+// 管線擁有 detector 階段的檢測器實例，有狀態檢測器的狀態在多次 Run 之間延續，因此同一管線的 Run 應依序調用。
+type Pipeline struct {
+	name    string
+	timeout time.Duration
+	stages  []*stage // 拓撲順序
+	sinks   []int    // 沒有下游的階段
+	tracer  contracts.TracingProvider
+	metrics contracts.MetricsProvider
+	logger  contracts.Logger
+}
+
+// Name 返回管線名稱
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// Close 停止管線擁有的檢測器實例
+func (p *Pipeline) Close(ctx context.Context) error {
+	var errs []error
+	for _, st := range p.stages {
+		if st.detector == nil {
+			continue
+		}
+		if err := st.detector.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("停止階段 %s 的檢測器失敗: %w", st.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run 執行一次管線
+// 任何 on_error 為 fail 的階段失敗或管線超時時，返回錯誤以及狀態為 failed 的 Output。
+func (p *Pipeline) Run(ctx context.Context, input Input) (*Output, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	ctx, span := p.tracer.StartSpan(ctx, "pipeline.run")
+	defer span.Finish()
+	span.SetTag("pipeline", p.name)
+	span.SetTag("detection_id", input.DetectionID)
+	span.SetTag("records_in", len(input.Records))
+
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	run := &pipelineRun{
+		pipeline: p,
+		input:    input,
+		states:   make([]*stageState, len(p.stages)),
+		abort:    abort,
+	}
+	for i := range run.states {
+		run.states[i] = &stageState{done: make(chan struct{})}
+	}
+
+	var wg sync.WaitGroup
+	for i := range p.stages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(run.states[i].done)
+			run.execute(runCtx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	if run.failure == nil && ctx.Err() != nil {
+		run.failure = fmt.Errorf("管線執行超時或被取消: %w", ctx.Err())
+	}
+
+	output := run.output()
+	status := StatusProcessed
+	if run.failure != nil {
+		status = StatusFailed
+		span.SetError(run.failure)
+	}
+	span.SetTag("status", status)
+	span.SetTag("results_out", len(output.AnalysisResults))
+	if p.metrics != nil {
+		p.metrics.IncCounter("pipeline_runs_total", map[string]string{"pipeline": p.name, "status": status})
+	}
+
+	if run.failure != nil {
+		p.logger.Error("檢測管線執行失敗", "pipeline", p.name, "detection_id", input.DetectionID, "error", run.failure)
+		return output, fmt.Errorf("管線 %s 執行失敗: %w", p.name, run.failure)
+	}
+	return output, nil
+}
+
+// stageState 記錄一次執行中單個階段的結果
+type stageState struct {
+	done    chan struct{}
+	output  stageData
+	blocked bool // 階段沒有可用輸出 (失敗後 skip/fail 或被略過)
+}
+
+// pipelineRun 是一次管線執行的狀態
+type pipelineRun struct {
+	pipeline *Pipeline
+	input    Input
+	states   []*stageState
+	abort    context.CancelFunc
+
+	mu      sync.Mutex
+	timings []entities.StageTiming
+	failure error
+}
+
+// execute 等待上游完成後執行第 i 個階段
+func (r *pipelineRun) execute(ctx context.Context, i int) {
+	p := r.pipeline
+	st := p.stages[i]
+	state := r.states[i]
+
+	for _, up := range st.upstreams {
+		<-r.states[up].done
+	}
+
+	if ctx.Err() != nil {
+		state.blocked = true
+		r.record(st, entities.StageTiming{Status: StageStatusSkipped, Error: "管線已中止"})
+		return
+	}
+	in, ok := r.collect(st)
+	if !ok {
+		state.blocked = true
+		r.record(st, entities.StageTiming{Status: StageStatusSkipped, Error: "所有上游階段均未產生輸出"})
+		return
+	}
+
+	stageCtx, span := p.tracer.StartSpan(ctx, "pipeline.stage."+st.name)
+	defer span.Finish()
+	span.SetTag("pipeline", p.name)
+	span.SetTag("stage", st.name)
+	span.SetTag("type", st.stageType)
+	span.SetTag("plugin", st.plugin)
+	span.SetTag("records_in", len(in.records))
+	span.SetTag("results_in", len(in.results))
+
+	started := time.Now()
+	out, attempts, err := p.runStage(stageCtx, st, in, r.input)
+	timing := entities.StageTiming{
+		Status:    StageStatusSucceeded,
+		StartedAt: started,
+		Duration:  time.Since(started),
+		Attempts:  attempts,
+	}
+	span.SetTag("attempts", attempts)
+
+	if err == nil {
+		state.output = out
+	} else {
+		span.SetError(err)
+		timing.Status = StageStatusFailed
+		timing.Error = err.Error()
+		switch st.onError {
+		case OnErrorContinue:
+			state.output = passthrough(st, in)
+		case OnErrorSkip:
+			state.blocked = true
+		default:
+			state.blocked = true
+			r.fail(fmt.Errorf("階段 %s 失敗: %w", st.name, err))
+		}
+		p.logger.Warn("管線階段執行失敗", "pipeline", p.name, "stage", st.name, "on_error", st.onError,
+			"attempts", attempts, "error", err)
+	}
+
+	span.SetTag("status", timing.Status)
+	span.SetTag("records_out", len(state.output.records))
+	span.SetTag("results_out", len(state.output.results))
+	r.record(st, timing)
+}
+
+// collect 合併所有可用上游的輸出，所有上游都沒有輸出時返回 false
+func (r *pipelineRun) collect(st *stage) (stageData, bool) {
+	if len(st.upstreams) == 0 {
+		return stageData{records: r.input.Records}, true
+	}
+	var data stageData
+	var resultSets [][]*entities.AnalysisResult
+	available := false
+	for _, up := range st.upstreams {
+		upstream := r.states[up]
+		if upstream.blocked {
+			continue
+		}
+		available = true
+		data.records = append(data.records, upstream.output.records...)
+		resultSets = append(resultSets, upstream.output.results)
+	}
+	data.results = mergeResults(resultSets...)
+	return data, available
+}
+
+// fail 記錄第一個導致管線失敗的錯誤並中止其餘階段
+func (r *pipelineRun) fail(err error) {
+	r.mu.Lock()
+	if r.failure == nil {
+		r.failure = err
+	}
+	r.mu.Unlock()
+	r.abort()
+}
+
+// record 保存階段耗時並輸出指標
+func (r *pipelineRun) record(st *stage, timing entities.StageTiming) {
+	timing.Stage = st.name
+	timing.Type = st.stageType
+	r.mu.Lock()
+	r.timings = append(r.timings, timing)
+	r.mu.Unlock()
+
+	if metrics := r.pipeline.metrics; metrics != nil {
+		tags := map[string]string{"pipeline": r.pipeline.name, "stage": st.name, "status": timing.Status}
+		metrics.ObserveHistogram("pipeline_stage_duration_seconds", timing.Duration.Seconds(), tags)
+	}
+}
+
+// output 匯總末端階段的輸出
+func (r *pipelineRun) output() *Output {
+	output := &Output{}
+	var resultSets [][]*entities.AnalysisResult
+	for _, sink := range r.pipeline.sinks {
+		state := r.states[sink]
+		if state.blocked {
+			continue
+		}
+		output.Records = append(output.Records, state.output.records...)
+		resultSets = append(resultSets, state.output.results)
+	}
+	output.AnalysisResults = mergeResults(resultSets...)
+
+	result := &entities.DetectionResult{
+		DetectionID:  r.input.DetectionID,
+		Status:       StatusProcessed,
+		StageTimings: r.timings,
+	}
+	anomalies := 0
+	for _, ar := range output.AnalysisResults {
+		if ar.IsAnomalous() {
+			if anomalies == 0 {
+				result.AnalysisResultID = ar.ID
+			}
+			anomalies++
+		}
+	}
+	counts := make(map[string]int)
+	for _, timing := range r.timings {
+		counts[timing.Status]++
+	}
+	if r.failure != nil {
+		result.Status = StatusFailed
+		result.Message = r.failure.Error()
+	} else {
+		result.Message = fmt.Sprintf("%d 個階段成功，%d 個失敗，%d 個略過，產生 %d 個異常結果",
+			counts[StageStatusSucceeded], counts[StageStatusFailed], counts[StageStatusSkipped], anomalies)
+	}
+	output.Result = result
+	return output
+}
+
+// runStage 執行階段並在失敗時重試，返回實際嘗試次數
+func (p *Pipeline) runStage(ctx context.Context, st *stage, in stageData, input Input) (stageData, int, error) {
+	alerted := &sync.Map{} // 重試時不重複發送已成功的告警
+	var lastErr error
+	for attempt := 1; attempt <= st.retries+1; attempt++ {
+		out, err := p.runAttempt(ctx, st, in, input, alerted)
+		if err == nil {
+			return out, attempt, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return stageData{}, attempt, lastErr
+		}
+		if attempt <= st.retries {
+			p.logger.Warn("管線階段嘗試失敗，將重試", "pipeline", p.name, "stage", st.name, "attempt", attempt, "error", err)
+		}
+	}
+	return stageData{}, st.retries + 1, lastErr
+}
+
+// runAttempt 在階段超時限制內執行一次嘗試
+// 插件未響應上下文取消時，超時後直接放棄該次調用的結果，避免整條管線被單個插件卡住。
+func (p *Pipeline) runAttempt(ctx context.Context, st *stage, in stageData, input Input, alerted *sync.Map) (stageData, error) {
+	attemptCtx := ctx
+	if st.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, st.timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		out stageData
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- outcome{err: fmt.Errorf("插件 %s 發生 panic: %v", st.plugin, recovered)}
+			}
+		}()
+		out, err := invoke(attemptCtx, st, in, input, alerted)
+		done <- outcome{out: out, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.out, o.err
+	case <-attemptCtx.Done():
+		return stageData{}, fmt.Errorf("階段執行超時或被取消: %w", attemptCtx.Err())
+	}
+}
+
+// invoke 按階段類型調用插件
+func invoke(ctx context.Context, st *stage, in stageData, input Input, alerted *sync.Map) (stageData, error) {
+	switch st.stageType {
+	case StageTypeTransform:
+		records, err := st.transform.Transform(ctx, in.records, st.config)
+		if err != nil {
+			return stageData{}, err
+		}
+		return stageData{records: records}, nil
+
+	case StageTypeDetector:
+		results := make([]*entities.AnalysisResult, 0, len(in.records))
+		for i, record := range in.records {
+			if err := ctx.Err(); err != nil {
+				return stageData{}, err
+			}
+			result, err := st.detector.Execute(ctx, record, st.config)
+			if err != nil {
+				return stageData{}, fmt.Errorf("第 %d 條記錄: %w", i, err)
+			}
+			if result == nil {
+				continue
+			}
+			if result.ID == "" {
+				result.ID = valueobjects.GenerateNewIDVO().String()
+			}
+			if result.DetectorID == "" {
+				result.DetectorID = input.DetectorID
+			}
+			if result.Timestamp.IsZero() {
+				if at, err := plugins.RecordTimestamp(record); err == nil {
+					result.Timestamp = at
+				} else {
+					result.Timestamp = time.Now()
+				}
+			}
+			results = append(results, result)
+		}
+		return stageData{results: results}, nil
+
+	case StageTypePostProcessor:
+		results := make([]*entities.AnalysisResult, 0, len(in.results))
+		for _, result := range in.results {
+			if !result.IsAnomalous() {
+				results = append(results, result)
+				continue
+			}
+			data, err := st.postProcessor.PostProcess(ctx, result, st.config)
+			if err != nil {
+				return stageData{}, fmt.Errorf("後處理分析結果 %s 失敗: %w", result.ID, err)
+			}
+			enriched := cloneResult(result)
+			enriched.Data[st.name] = data
+			results = append(results, enriched)
+		}
+		return stageData{results: results}, nil
+
+	case StageTypeAlert:
+		// 支持恢復信號的插件同時接收非異常結果，以便結束對應的告警
+		resolver, _ := st.alert.(plugins.AlertResolverPlugin)
+		for _, result := range in.results {
+			if _, sent := alerted.Load(result.ID); sent {
+				continue
+			}
+			if result.IsAnomalous() {
+				if err := st.alert.TriggerAlert(ctx, result, st.config); err != nil {
+					return stageData{}, fmt.Errorf("為分析結果 %s 觸發告警失敗: %w", result.ID, err)
+				}
+			} else if resolver != nil {
+				if err := resolver.ResolveAlert(ctx, result, st.config); err != nil {
+					return stageData{}, fmt.Errorf("為分析結果 %s 發送恢復信號失敗: %w", result.ID, err)
+				}
+			} else {
+				continue
+			}
+			alerted.Store(result.ID, true)
+		}
+		return stageData{results: in.results}, nil
+	}
+	return stageData{}, fmt.Errorf("不支援的階段類型 %q", st.stageType)
+}
+
+// passthrough 返回 on_error 為 continue 時傳遞給下游的數據
+func passthrough(st *stage, in stageData) stageData {
+	switch st.stageType {
+	case StageTypeTransform:
+		return stageData{records: in.records}
+	case StageTypeDetector:
+		return stageData{results: []*entities.AnalysisResult{}}
+	default:
+		return stageData{results: in.results}
+	}
+}
+
+// mergeResults 合併多個上游的分析結果，相同 ID 只保留一份並合併 Data 中缺少的鍵
+func mergeResults(sets ...[]*entities.AnalysisResult) []*entities.AnalysisResult {
+	if len(sets) == 1 {
+		return sets[0]
+	}
+	var merged []*entities.AnalysisResult
+	byID := make(map[string]int)
+	for _, set := range sets {
+		for _, result := range set {
+			pos, ok := byID[result.ID]
+			if !ok {
+				byID[result.ID] = len(merged)
+				merged = append(merged, result)
+				continue
+			}
+			existing := merged[pos]
+			var combined *entities.AnalysisResult
+			for key, value := range result.Data {
+				if _, present := existing.Data[key]; present {
+					continue
+				}
+				if combined == nil {
+					combined = cloneResult(existing)
+				}
+				combined.Data[key] = value
+			}
+			if combined != nil {
+				merged[pos] = combined
+			}
+		}
+	}
+	return merged
+}
+
+// cloneResult 複製分析結果及其 Data，避免並行分支互相修改
+func cloneResult(result *entities.AnalysisResult) *entities.AnalysisResult {
+	clone := *result
+	clone.Data = make(map[string]interface{}, len(result.Data)+1)
+	for key, value := range result.Data {
+		clone.Data[key] = value
+	}
+	return &clone
+}
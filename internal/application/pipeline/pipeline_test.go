@@ -0,0 +1,448 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/registry"
+	"detectviz-platform/internal/plugins/detectors"
+	"detectviz-platform/internal/plugins/transforms"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// recordingTracer 記錄所有開始過的 span 名稱
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans map[string]*recordingSpan
+}
+
+type recordingSpan struct {
+	mu       sync.Mutex
+	tags     map[string]interface{}
+	err      error
+	finished bool
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, operationName string) (context.Context, contracts.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordingSpan{tags: map[string]interface{}{}}
+	t.spans[operationName] = span
+	return ctx, span
+}
+
+func (t *recordingTracer) GetName() string { return "recording_tracer" }
+
+func (s *recordingSpan) SetTag(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[key] = value
+}
+
+func (s *recordingSpan) SetError(err error) { s.err = err }
+func (s *recordingSpan) Finish()            { s.finished = true }
+
+// stubPlugin 提供 Plugin 介面的空實現
+type stubPlugin struct{ name string }
+
+func (p *stubPlugin) GetName() string                                            { return p.name }
+func (p *stubPlugin) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (p *stubPlugin) Start(ctx context.Context) error                            { return nil }
+func (p *stubPlugin) Stop(ctx context.Context) error                             { return nil }
+
+// funcTransform 以函數實現 TransformPlugin
+type funcTransform struct {
+	stubPlugin
+	fn func(ctx context.Context, records []map[string]interface{}) ([]map[string]interface{}, error)
+}
+
+func (f *funcTransform) Transform(ctx context.Context, records []map[string]interface{}, cfg map[string]interface{}) ([]map[string]interface{}, error) {
+	return f.fn(ctx, records)
+}
+
+// tagPostProcessor 為每個異常結果附加固定的數據
+type tagPostProcessor struct {
+	stubPlugin
+	value string
+}
+
+func (p *tagPostProcessor) PostProcess(ctx context.Context, result *entities.AnalysisResult, cfg map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"tag": p.value}, nil
+}
+
+// flakyAlert 前 failures 次調用失敗，記錄成功告警的結果 ID
+type flakyAlert struct {
+	stubPlugin
+	mu       sync.Mutex
+	failures int
+	calls    int
+	alerted  []string
+}
+
+func (a *flakyAlert) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, cfg map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if a.calls <= a.failures {
+		return errors.New("receiver unavailable")
+	}
+	a.alerted = append(a.alerted, result.ID)
+	return nil
+}
+
+func schemaPath() string {
+	return filepath.Join("..", "..", "..", "schemas", "pipeline.json")
+}
+
+func newTestEngine(t *testing.T) (*Engine, contracts.PluginRegistryProvider, *recordingTracer) {
+	t.Helper()
+	logger := &testLogger{}
+	reg := registry.NewPluginRegistryProvider(logger)
+	if err := reg.Register("aggregate_transform", transforms.NewAggregateTransformPlugin(logger)); err != nil {
+		t.Fatalf("register aggregate_transform: %v", err)
+	}
+	if err := reg.Register("json_parse_transform", transforms.NewJSONParseTransformPlugin(logger)); err != nil {
+		t.Fatalf("register json_parse_transform: %v", err)
+	}
+	tracer := &recordingTracer{spans: map[string]*recordingSpan{}}
+	return NewEngine(reg, detectors.NewDetectorFactory(logger, nil), tracer, nil, logger), reg, tracer
+}
+
+func minuteRecords(base time.Time, values ...float64) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(values))
+	for i, v := range values {
+		records = append(records, map[string]interface{}{
+			"timestamp": base.Add(time.Duration(i) * time.Minute),
+			"cpu":       v,
+		})
+	}
+	return records
+}
+
+func thresholdConfig(upper float64) map[string]interface{} {
+	return map[string]interface{}{
+		"field_name":      "cpu",
+		"upper_threshold": upper,
+		"enable_upper":    true,
+		"severity":        "high",
+	}
+}
+
+func findTiming(result *entities.DetectionResult, stageName string) *entities.StageTiming {
+	for i := range result.StageTimings {
+		if result.StageTimings[i].Stage == stageName {
+			return &result.StageTimings[i]
+		}
+	}
+	return nil
+}
+
+func TestDefinitionLoader(t *testing.T) {
+	loader, err := NewDefinitionLoader(schemaPath())
+	if err != nil {
+		t.Fatalf("NewDefinitionLoader() error = %v", err)
+	}
+
+	def, err := loader.Parse([]byte(`
+name: cpu
+timeout: 30s
+stages:
+  - name: per_minute
+    type: transform
+    plugin: aggregate_transform
+    config:
+      fields: [cpu]
+  - name: high
+    type: detector
+    plugin: threshold_detector_plugin
+    depends_on: [per_minute]
+    retries: 2
+    on_error: skip
+    config:
+      upper_threshold: 90
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if def.Name != "cpu" || def.Timeout != "30s" || len(def.Stages) != 2 {
+		t.Fatalf("unexpected definition: %+v", def)
+	}
+	high := def.Stages[1]
+	if high.DependsOn[0] != "per_minute" || high.Retries != 2 || high.OnError != OnErrorSkip || high.Config["upper_threshold"] != 90.0 {
+		t.Errorf("unexpected stage: %+v", high)
+	}
+
+	invalid := []struct {
+		name string
+		yaml string
+	}{
+		{"unknown stage type", "name: x\nstages:\n  - {name: a, type: sink, plugin: p}\n"},
+		{"missing plugin", "name: x\nstages:\n  - {name: a, type: transform}\n"},
+		{"bad timeout", "name: x\nstages:\n  - {name: a, type: transform, plugin: p, timeout: soon}\n"},
+		{"unknown key", "name: x\nstages:\n  - {name: a, type: transform, plugin: p, parallel: true}\n"},
+		{"no stages", "name: x\nstages: []\n"},
+		{"not yaml", "name: [x\n"},
+	}
+	for _, tc := range invalid {
+		if _, err := loader.Parse([]byte(tc.yaml)); err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		}
+	}
+
+	// 倉庫附帶的示例管線必須通過驗證與編譯
+	defs, err := loader.LoadDir(filepath.Join("..", "..", "..", "configs", "pipelines"))
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if len(defs) == 0 {
+		t.Fatal("expected example pipelines in configs/pipelines")
+	}
+	engine, _, _ := newTestEngine(t)
+	for _, d := range defs {
+		p, err := engine.Compile(context.Background(), d)
+		if err != nil {
+			t.Errorf("Compile(%s) error = %v", d.Name, err)
+			continue
+		}
+		p.Close(context.Background())
+	}
+}
+
+func TestEngine_CompileRejectsInvalidGraphs(t *testing.T) {
+	engine, _, _ := newTestEngine(t)
+	transform := func(name string, deps ...string) StageDefinition {
+		return StageDefinition{Name: name, Type: StageTypeTransform, Plugin: "aggregate_transform", DependsOn: deps}
+	}
+
+	cases := []struct {
+		name   string
+		stages []StageDefinition
+		want   string
+	}{
+		{"duplicate", []StageDefinition{transform("a"), transform("a")}, "重複"},
+		{"missing dependency", []StageDefinition{transform("a", "b")}, "不存在"},
+		{"cycle", []StageDefinition{transform("a", "b"), transform("b", "a")}, "循環依賴"},
+		{"alert as root", []StageDefinition{{Name: "a", Type: StageTypeAlert, Plugin: "x"}}, "必須依賴"},
+		{"detector after detector", []StageDefinition{
+			{Name: "a", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", Config: thresholdConfig(1)},
+			{Name: "b", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", DependsOn: []string{"a"}},
+		}, "不能接收"},
+		{"unregistered plugin", []StageDefinition{{Name: "a", Type: StageTypeTransform, Plugin: "missing"}}, "未註冊"},
+		{"wrong plugin kind", []StageDefinition{
+			{Name: "a", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", Config: thresholdConfig(1)},
+			{Name: "b", Type: StageTypeAlert, Plugin: "aggregate_transform", DependsOn: []string{"a"}},
+		}, "不是 alert 插件"},
+		{"bad on_error", []StageDefinition{{Name: "a", Type: StageTypeTransform, Plugin: "aggregate_transform", OnError: "retry"}}, "on_error"},
+	}
+	for _, tc := range cases {
+		_, err := engine.Compile(context.Background(), &Definition{Name: "p", Stages: tc.stages})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestPipeline_FanOutFanIn(t *testing.T) {
+	engine, reg, tracer := newTestEngine(t)
+	reg.Register("enrich_a", &tagPostProcessor{stubPlugin: stubPlugin{"enrich_a"}, value: "a"})
+	reg.Register("enrich_b", &tagPostProcessor{stubPlugin: stubPlugin{"enrich_b"}, value: "b"})
+	alert := &flakyAlert{stubPlugin: stubPlugin{"notify"}, failures: 1}
+	reg.Register("notify", alert)
+
+	p, err := engine.Compile(context.Background(), &Definition{
+		Name: "cpu",
+		Stages: []StageDefinition{
+			{Name: "per_minute", Type: StageTypeTransform, Plugin: "aggregate_transform",
+				Config: map[string]interface{}{"window": "1m", "fields": []interface{}{"cpu"}, "function": "max"}},
+			{Name: "high", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", DependsOn: []string{"per_minute"},
+				Config: thresholdConfig(90)},
+			{Name: "enrich_a", Type: StageTypePostProcessor, Plugin: "enrich_a", DependsOn: []string{"high"}},
+			{Name: "enrich_b", Type: StageTypePostProcessor, Plugin: "enrich_b", DependsOn: []string{"high"}},
+			{Name: "notify", Type: StageTypeAlert, Plugin: "notify", DependsOn: []string{"enrich_a", "enrich_b"}, Retries: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	defer p.Close(context.Background())
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	out, err := p.Run(context.Background(), Input{
+		DetectionID: "det-1",
+		DetectorID:  "cpu-detector",
+		Records:     minuteRecords(base, 50, 95, 60, 99),
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	result := out.Result
+	if result.Status != StatusProcessed || result.DetectionID != "det-1" {
+		t.Fatalf("unexpected detection result: %+v", result)
+	}
+	if len(result.StageTimings) != 5 {
+		t.Fatalf("expected 5 stage timings, got %+v", result.StageTimings)
+	}
+	if notify := findTiming(result, "notify"); notify.Status != StageStatusSucceeded || notify.Attempts != 2 {
+		t.Errorf("notify timing = %+v, want succeeded after 2 attempts", notify)
+	}
+
+	// 兩個並行的後處理分支合併後，每個異常同時帶有兩者的輸出
+	if len(out.AnalysisResults) != 4 {
+		t.Fatalf("expected 4 analysis results, got %d", len(out.AnalysisResults))
+	}
+	anomalies := 0
+	for _, ar := range out.AnalysisResults {
+		if ar.DetectorID != "cpu-detector" {
+			t.Errorf("result %s has detector id %q", ar.ID, ar.DetectorID)
+		}
+		if !ar.IsAnomalous() {
+			continue
+		}
+		anomalies++
+		if ar.Data["enrich_a"] == nil || ar.Data["enrich_b"] == nil {
+			t.Errorf("anomaly %s missing enrichment: %v", ar.ID, ar.Data)
+		}
+	}
+	if anomalies != 2 || len(alert.alerted) != 2 {
+		t.Errorf("anomalies = %d, alerted = %v, want 2 each", anomalies, alert.alerted)
+	}
+	if result.AnalysisResultID == "" {
+		t.Error("expected AnalysisResultID of the first anomaly")
+	}
+
+	for _, name := range []string{"pipeline.run", "pipeline.stage.per_minute", "pipeline.stage.high", "pipeline.stage.notify"} {
+		span, ok := tracer.spans[name]
+		if !ok || !span.finished {
+			t.Errorf("span %s not recorded or not finished", name)
+		}
+	}
+	if tracer.spans["pipeline.stage.notify"].tags["attempts"] != 2 {
+		t.Errorf("notify span tags = %v", tracer.spans["pipeline.stage.notify"].tags)
+	}
+}
+
+func TestPipeline_ErrorPolicies(t *testing.T) {
+	engine, reg, _ := newTestEngine(t)
+	reg.Register("broken", &funcTransform{stubPlugin: stubPlugin{"broken"},
+		fn: func(ctx context.Context, records []map[string]interface{}) ([]map[string]interface{}, error) {
+			return nil, errors.New("parse error")
+		}})
+	reg.Register("identity", &funcTransform{stubPlugin: stubPlugin{"identity"},
+		fn: func(ctx context.Context, records []map[string]interface{}) ([]map[string]interface{}, error) {
+			return records, nil
+		}})
+
+	build := func(onError string) *Pipeline {
+		p, err := engine.Compile(context.Background(), &Definition{
+			Name: "policies",
+			Stages: []StageDefinition{
+				{Name: "parse", Type: StageTypeTransform, Plugin: "broken", OnError: onError},
+				{Name: "passthrough", Type: StageTypeTransform, Plugin: "identity"},
+				{Name: "parsed", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", DependsOn: []string{"parse"},
+					Config: thresholdConfig(90)},
+				{Name: "raw", Type: StageTypeDetector, Plugin: "threshold_detector_plugin", DependsOn: []string{"passthrough"},
+					Config: thresholdConfig(90)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Compile(%s) error = %v", onError, err)
+		}
+		t.Cleanup(func() { p.Close(context.Background()) })
+		return p
+	}
+	input := Input{DetectionID: "det", Records: minuteRecords(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 10, 95)}
+
+	// continue: 失敗的 transform 把輸入原樣交給下游
+	out, err := build(OnErrorContinue).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("continue: Run() error = %v", err)
+	}
+	if parse := findTiming(out.Result, "parse"); parse.Status != StageStatusFailed || !strings.Contains(parse.Error, "parse error") {
+		t.Errorf("continue: parse timing = %+v", parse)
+	}
+	if parsed := findTiming(out.Result, "parsed"); parsed.Status != StageStatusSucceeded {
+		t.Errorf("continue: parsed timing = %+v", parsed)
+	}
+	if len(out.AnalysisResults) != 4 {
+		t.Errorf("continue: expected 4 results, got %d", len(out.AnalysisResults))
+	}
+
+	// skip: 只依賴失敗階段的下游被略過，其他分支不受影響
+	out, err = build(OnErrorSkip).Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("skip: Run() error = %v", err)
+	}
+	if parsed := findTiming(out.Result, "parsed"); parsed.Status != StageStatusSkipped {
+		t.Errorf("skip: parsed timing = %+v", parsed)
+	}
+	if raw := findTiming(out.Result, "raw"); raw.Status != StageStatusSucceeded {
+		t.Errorf("skip: raw timing = %+v", raw)
+	}
+	if out.Result.Status != StatusProcessed || len(out.AnalysisResults) != 2 {
+		t.Errorf("skip: status = %s, results = %d", out.Result.Status, len(out.AnalysisResults))
+	}
+
+	// fail: 整條管線失敗
+	out, err = build(OnErrorFail).Run(context.Background(), input)
+	if err == nil {
+		t.Fatal("fail: expected error")
+	}
+	if out.Result.Status != StatusFailed || !strings.Contains(out.Result.Message, "parse") {
+		t.Errorf("fail: result = %+v", out.Result)
+	}
+	if parsed := findTiming(out.Result, "parsed"); parsed.Status != StageStatusSkipped {
+		t.Errorf("fail: parsed timing = %+v", parsed)
+	}
+}
+
+func TestPipeline_StageTimeout(t *testing.T) {
+	engine, reg, _ := newTestEngine(t)
+	release := make(chan struct{})
+	defer close(release)
+	reg.Register("stuck", &funcTransform{stubPlugin: stubPlugin{"stuck"},
+		fn: func(ctx context.Context, records []map[string]interface{}) ([]map[string]interface{}, error) {
+			<-release // 不響應上下文取消
+			return records, nil
+		}})
+
+	p, err := engine.Compile(context.Background(), &Definition{
+		Name: "timeouts",
+		Stages: []StageDefinition{
+			{Name: "stuck", Type: StageTypeTransform, Plugin: "stuck", Timeout: "20ms", Retries: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	started := time.Now()
+	out, err := p.Run(context.Background(), Input{Records: minuteRecords(time.Now(), 1)})
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("stage timeout not enforced, run took %s", elapsed)
+	}
+	timing := findTiming(out.Result, "stuck")
+	if timing.Status != StageStatusFailed || timing.Attempts != 2 || timing.Duration < 40*time.Millisecond {
+		t.Errorf("unexpected timing: %+v", timing)
+	}
+}
@@ -0,0 +1,466 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// RunInfo 描述一次排程執行的上下文
+type RunInfo struct {
+	DetectorID  string
+	ScheduledAt time.Time
+	WindowStart time.Time
+	WindowEnd   time.Time
+	Records     int
+}
+
+// ResultHandler 處理一次排程執行產生的分析結果，例如交給告警引擎
+type ResultHandler func(ctx context.Context, run RunInfo, results []*entities.AnalysisResult) error
+
+// SchedulerConfig 定義檢測排程器的配置
+type SchedulerConfig struct {
+	TickInterval   string `yaml:"tickInterval" json:"tickInterval"`     // 檢查到期排程的間隔，默認 "5s"
+	RunTimeout     string `yaml:"runTimeout" json:"runTimeout"`         // 單次執行的超時時間，默認 "5m"
+	DefaultWindow  string `yaml:"defaultWindow" json:"defaultWindow"`   // 排程未設置窗口時的默認數據窗口，默認 "5m"
+	MaxCatchUpRuns int    `yaml:"maxCatchUpRuns" json:"maxCatchUpRuns"` // catch_up 策略每輪最多補跑的次數，默認 10
+	StallThreshold string `yaml:"stallThreshold" json:"stallThreshold"` // 排程循環超過此時間未推進即視為卡住，默認 1m
+}
+
+// DetectionScheduler 週期性執行檢測器的排程器
+// 職責: 讀取各檢測器的排程，從配置的數據源拉取最新窗口並執行偵測，
+// 處理抖動、並發上限與錯過策略，並實現 HealthCheckCapablePlugin 以便在卡住時報告不健康。
+type DetectionScheduler struct {
+	repo     interfaces.DetectorScheduleRepository
+	registry contracts.PluginRegistryProvider
+	handler  ResultHandler
+	logger   contracts.Logger
+	metrics  contracts.MetricsProvider
+
+	tickInterval   time.Duration
+	runTimeout     time.Duration
+	defaultWindow  time.Duration
+	maxCatchUpRuns int
+	stallThreshold time.Duration
+
+	now    func() time.Time
+	jitter func(max time.Duration) time.Duration
+
+	mu       sync.Mutex
+	running  bool
+	lastTick time.Time
+	inFlight map[string]int       // 每個檢測器進行中的執行數
+	runs     map[string]time.Time // 進行中的執行及其開始時間
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewDetectionScheduler 創建新的檢測排程器，handler 與 metrics 可為 nil
+func NewDetectionScheduler(
+	repo interfaces.DetectorScheduleRepository,
+	registry contracts.PluginRegistryProvider,
+	handler ResultHandler,
+	config SchedulerConfig,
+	logger contracts.Logger,
+	metrics contracts.MetricsProvider,
+) (*DetectionScheduler, error) {
+	s := &DetectionScheduler{
+		repo:           repo,
+		registry:       registry,
+		handler:        handler,
+		logger:         logger,
+		metrics:        metrics,
+		maxCatchUpRuns: config.MaxCatchUpRuns,
+		now:            time.Now,
+		jitter:         randomJitter,
+		inFlight:       make(map[string]int),
+		runs:           make(map[string]time.Time),
+	}
+
+	var err error
+	if s.tickInterval, err = parseDurationDefault(config.TickInterval, 5*time.Second); err != nil {
+		return nil, fmt.Errorf("invalid tickInterval: %w", err)
+	}
+	if s.runTimeout, err = parseDurationDefault(config.RunTimeout, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid runTimeout: %w", err)
+	}
+	if s.defaultWindow, err = parseDurationDefault(config.DefaultWindow, 5*time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid defaultWindow: %w", err)
+	}
+	if s.stallThreshold, err = parseDurationDefault(config.StallThreshold, time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid stallThreshold: %w", err)
+	}
+	if s.stallThreshold < 2*s.tickInterval {
+		s.stallThreshold = 2 * s.tickInterval
+	}
+	if s.maxCatchUpRuns <= 0 {
+		s.maxCatchUpRuns = 10
+	}
+
+	return s, nil
+}
+
+// GetName 返回排程器名稱
+func (s *DetectionScheduler) GetName() string {
+	return "detection_scheduler"
+}
+
+// Init 初始化排程器，配置已在構造時解析
+func (s *DetectionScheduler) Init(ctx context.Context, cfg map[string]interface{}) error {
+	return nil
+}
+
+// Start 啟動排程循環
+func (s *DetectionScheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return fmt.Errorf("detection scheduler already started")
+	}
+	s.running = true
+	s.lastTick = s.now()
+	s.stopChan = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.loop(ctx, s.stopChan)
+
+	s.logger.Info("檢測排程器已啟動", "tick_interval", s.tickInterval)
+	return nil
+}
+
+// Stop 停止排程循環並等待進行中的執行結束
+func (s *DetectionScheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.stopChan)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("檢測排程器已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for scheduled runs to finish: %w", ctx.Err())
+	}
+}
+
+// loop 定期檢查到期排程
+func (s *DetectionScheduler) loop(ctx context.Context, stopChan chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.tickInterval)
+	defer ticker.Stop()
+
+	s.Tick(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.Tick(ctx)
+		case <-stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Tick 執行一輪排程檢查：計算到期的排程，認領後在背景執行
+func (s *DetectionScheduler) Tick(ctx context.Context) {
+	now := s.now()
+	s.mu.Lock()
+	s.lastTick = now
+	s.mu.Unlock()
+
+	schedules, err := s.repo.ListEnabled(ctx)
+	if err != nil {
+		s.logger.Error("讀取檢測器排程失敗", "error", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		s.evaluate(ctx, schedule, now)
+	}
+}
+
+// evaluate 處理單個排程
+func (s *DetectionScheduler) evaluate(ctx context.Context, schedule *entities.DetectorSchedule, now time.Time) {
+	spec, err := ParseSchedule(schedule.Spec)
+	if err != nil {
+		s.logger.Error("無效的排程表達式", "detector_id", schedule.DetectorID, "spec", schedule.Spec, "error", err)
+		return
+	}
+
+	runs, next := planRuns(spec, schedule, now, s.maxCatchUpRuns)
+	if len(runs) == 0 && next.Equal(schedule.NextRunAt) {
+		return
+	}
+
+	if len(runs) > 0 && !s.hasCapacity(schedule) {
+		if schedule.MissedRunPolicy == entities.MissedRunPolicyCatchUp {
+			// 保留到期狀態，等進行中的執行完成後再補跑
+			return
+		}
+		s.logger.Warn("檢測器達到並發上限，略過本次排程",
+			"detector_id", schedule.DetectorID, "scheduled_at", runs[len(runs)-1])
+		s.incCounter("scheduler_runs_skipped_total", schedule.DetectorID)
+		runs = nil
+	}
+
+	// 先推進 next_run_at 再執行：重啟後不會重複觸發，多實例時只有一個實例認領成功
+	claimed, err := s.repo.ClaimNextRun(ctx, schedule.DetectorID, schedule.NextRunAt, next)
+	if err != nil {
+		s.logger.Error("認領排程失敗", "detector_id", schedule.DetectorID, "error", err)
+		return
+	}
+	if !claimed || len(runs) == 0 {
+		return
+	}
+
+	runID := fmt.Sprintf("%s@%s", schedule.DetectorID, runs[0].Format(time.RFC3339))
+	s.mu.Lock()
+	s.inFlight[schedule.DetectorID]++
+	s.runs[runID] = s.now()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight[schedule.DetectorID]--
+			if s.inFlight[schedule.DetectorID] <= 0 {
+				delete(s.inFlight, schedule.DetectorID)
+			}
+			delete(s.runs, runID)
+			s.mu.Unlock()
+		}()
+		s.execute(ctx, schedule, runs)
+	}()
+}
+
+// hasCapacity 檢查檢測器是否還能開始新的執行
+func (s *DetectionScheduler) hasCapacity(schedule *entities.DetectorSchedule) bool {
+	limit := schedule.MaxConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight[schedule.DetectorID] < limit
+}
+
+// execute 依序執行認領到的排程時間點，補跑時保持時間順序
+func (s *DetectionScheduler) execute(ctx context.Context, schedule *entities.DetectorSchedule, runs []time.Time) {
+	for _, scheduledAt := range runs {
+		if schedule.Jitter > 0 {
+			select {
+			case <-time.After(s.jitter(schedule.Jitter)):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		start := s.now()
+		status, errMsg := entities.ScheduleRunStatusSucceeded, ""
+		if err := s.RunOnce(ctx, schedule, scheduledAt); err != nil {
+			status, errMsg = entities.ScheduleRunStatusFailed, err.Error()
+			s.logger.Error("排程執行失敗", "detector_id", schedule.DetectorID, "scheduled_at", scheduledAt, "error", err)
+		}
+
+		if s.metrics != nil {
+			tags := map[string]string{"detector_id": schedule.DetectorID, "status": status}
+			s.metrics.IncCounter("scheduler_runs_total", tags)
+			s.metrics.ObserveHistogram("scheduler_run_duration_seconds", s.now().Sub(start).Seconds(), tags)
+		}
+
+		// 使用獨立 context 記錄結果，避免關閉過程中丟失最後一次執行狀態
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		if err := s.repo.RecordRun(recordCtx, schedule.DetectorID, scheduledAt, status, errMsg); err != nil {
+			s.logger.Error("記錄排程執行結果失敗", "detector_id", schedule.DetectorID, "error", err)
+		}
+		cancel()
+	}
+}
+
+// RunOnce 以 scheduledAt 為窗口終點執行一次偵測
+func (s *DetectionScheduler) RunOnce(ctx context.Context, schedule *entities.DetectorSchedule, scheduledAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.runTimeout)
+	defer cancel()
+
+	detector, err := s.resolveDetector(schedule.DetectorPlugin)
+	if err != nil {
+		return err
+	}
+	source, err := s.resolveSource(schedule.Source)
+	if err != nil {
+		return err
+	}
+
+	window := schedule.Window
+	if window <= 0 {
+		window = s.defaultWindow
+	}
+	run := RunInfo{
+		DetectorID:  schedule.DetectorID,
+		ScheduledAt: scheduledAt,
+		WindowStart: scheduledAt.Add(-window),
+		WindowEnd:   scheduledAt,
+	}
+
+	records, err := source.FetchWindow(ctx, schedule.SourceQuery, run.WindowStart, run.WindowEnd)
+	if err != nil {
+		return fmt.Errorf("failed to fetch data window from %s: %w", schedule.Source, err)
+	}
+	run.Records = len(records)
+
+	var results []*entities.AnalysisResult
+	for _, record := range records {
+		result, err := detector.Execute(ctx, record, schedule.DetectorConfig)
+		if err != nil {
+			return fmt.Errorf("detector %s failed: %w", schedule.DetectorPlugin, err)
+		}
+		if result == nil {
+			continue
+		}
+		if result.DetectorID == "" {
+			result.DetectorID = schedule.DetectorID
+		}
+		if result.Timestamp.IsZero() {
+			result.Timestamp = scheduledAt
+		}
+		results = append(results, result)
+	}
+
+	s.logger.Debug("排程執行完成", "detector_id", schedule.DetectorID, "scheduled_at", scheduledAt,
+		"records", run.Records, "results", len(results))
+
+	if s.handler != nil {
+		if err := s.handler(ctx, run, results); err != nil {
+			return fmt.Errorf("failed to handle detection results: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveDetector 從註冊表解析檢測器插件
+func (s *DetectionScheduler) resolveDetector(name string) (plugins.DetectorPlugin, error) {
+	p, err := s.registry.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("detector plugin %s not found: %w", name, err)
+	}
+	detector, ok := p.(plugins.DetectorPlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s is not a detector plugin", name)
+	}
+	return detector, nil
+}
+
+// resolveSource 從註冊表解析數據源插件
+func (s *DetectionScheduler) resolveSource(name string) (plugins.DataSourcePlugin, error) {
+	p, err := s.registry.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("data source %s not found: %w", name, err)
+	}
+	source, ok := p.(plugins.DataSourcePlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s is not a data source plugin", name)
+	}
+	return source, nil
+}
+
+// HealthCheck 檢查排程循環是否持續推進，以及是否有執行超時未結束
+func (s *DetectionScheduler) HealthCheck(ctx context.Context) plugins.HealthCheckResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	details := map[string]interface{}{
+		"last_tick":   s.lastTick,
+		"in_flight":   len(s.runs),
+		"tick_period": s.tickInterval.String(),
+	}
+
+	if !s.running {
+		return plugins.NewUnhealthyResult("detection scheduler is not running", details)
+	}
+	if since := now.Sub(s.lastTick); since > s.stallThreshold {
+		return plugins.NewUnhealthyResult(fmt.Sprintf("scheduler loop has not ticked for %s", since.Round(time.Second)), details)
+	}
+
+	var stuck []string
+	for runID, startedAt := range s.runs {
+		// 執行超時後 context 已取消，仍未結束表示插件沒有遵守取消信號
+		if now.Sub(startedAt) > s.runTimeout+s.stallThreshold {
+			stuck = append(stuck, runID)
+		}
+	}
+	if len(stuck) > 0 {
+		details["stuck_runs"] = stuck
+		return plugins.NewDegradedResult(fmt.Sprintf("%d scheduled runs exceeded their timeout", len(stuck)), details)
+	}
+
+	result := plugins.NewHealthyResult("detection scheduler is running")
+	result.Details = details
+	return result
+}
+
+// GetHealthCheckInterval 返回建議的健康檢查間隔
+func (s *DetectionScheduler) GetHealthCheckInterval() time.Duration {
+	return s.tickInterval
+}
+
+// IsHealthy 快速檢查排程器是否健康
+func (s *DetectionScheduler) IsHealthy(ctx context.Context) bool {
+	return s.HealthCheck(ctx).Status == plugins.HealthStatusHealthy
+}
+
+// incCounter 在配置了指標提供者時增加計數器
+func (s *DetectionScheduler) incCounter(name, detectorID string) {
+	if s.metrics != nil {
+		s.metrics.IncCounter(name, map[string]string{"detector_id": detectorID})
+	}
+}
+
+// randomJitter 返回 [0, max) 之間的隨機延遲
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(max)))
+}
+
+// parseDurationDefault 解析時間長度，空字符串時返回默認值
+func parseDurationDefault(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %s", value)
+	}
+	return d, nil
+}
+
+// 確保實現了 HealthCheckCapablePlugin 介面
+var _ plugins.HealthCheckCapablePlugin = (*DetectionScheduler)(nil)
@@ -0,0 +1,410 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/registry"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// memoryScheduleRepo 以記憶體保存排程，行為與 SQL 實現一致
+type memoryScheduleRepo struct {
+	mu        sync.Mutex
+	schedules map[string]*entities.DetectorSchedule
+	recorded  []time.Time
+}
+
+func (r *memoryScheduleRepo) Save(ctx context.Context, s *entities.DetectorSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy := *s
+	r.schedules[s.DetectorID] = &copy
+	return nil
+}
+func (r *memoryScheduleRepo) GetByDetectorID(ctx context.Context, id string) (*entities.DetectorSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy := *r.schedules[id]
+	return &copy, nil
+}
+func (r *memoryScheduleRepo) ListEnabled(ctx context.Context) ([]*entities.DetectorSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.DetectorSchedule
+	for _, s := range r.schedules {
+		copy := *s
+		out = append(out, &copy)
+	}
+	return out, nil
+}
+func (r *memoryScheduleRepo) ClaimNextRun(ctx context.Context, id string, expected, next time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.schedules[id]
+	if !s.NextRunAt.Equal(expected) {
+		return false, nil
+	}
+	s.NextRunAt = next
+	return true, nil
+}
+func (r *memoryScheduleRepo) RecordRun(ctx context.Context, id string, at time.Time, status, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.schedules[id]
+	if at.After(s.LastRunAt) {
+		s.LastRunAt = at
+	}
+	s.LastStatus, s.LastError = status, errMsg
+	r.recorded = append(r.recorded, at)
+	return nil
+}
+func (r *memoryScheduleRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.schedules, id)
+	return nil
+}
+
+// testClock 是可並發讀取的可控時鐘
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+type stubPlugin struct{ name string }
+
+func (p *stubPlugin) GetName() string                                            { return p.name }
+func (p *stubPlugin) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (p *stubPlugin) Start(ctx context.Context) error                            { return nil }
+func (p *stubPlugin) Stop(ctx context.Context) error                             { return nil }
+
+// stubSource 記錄請求的窗口並返回固定記錄
+type stubSource struct {
+	stubPlugin
+	mu      sync.Mutex
+	windows [][2]time.Time
+}
+
+func (s *stubSource) FetchWindow(ctx context.Context, query map[string]interface{}, start, end time.Time) ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows = append(s.windows, [2]time.Time{start, end})
+	return []map[string]interface{}{{"value": 1.0}, {"value": 2.0}}, nil
+}
+
+// stubDetector 每條記錄產生一個結果，block 非 nil 時阻塞直到關閉
+type stubDetector struct {
+	stubPlugin
+	block chan struct{}
+}
+
+func (d *stubDetector) Execute(ctx context.Context, data map[string]interface{}, cfg map[string]interface{}) (*entities.AnalysisResult, error) {
+	if d.block != nil {
+		<-d.block
+	}
+	return &entities.AnalysisResult{Summary: "ok"}, nil
+}
+
+var (
+	_ plugins.DataSourcePlugin = (*stubSource)(nil)
+	_ plugins.DetectorPlugin   = (*stubDetector)(nil)
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{spec: "30s", want: base.Add(30 * time.Second)},
+		{spec: "@every 5m", want: base.Add(5 * time.Minute)},
+		{spec: "*/15 * * * *", want: base.Add(15 * time.Minute)},
+		{spec: "@hourly", want: base.Add(time.Hour)},
+		{spec: "100ms", wantErr: true},
+		{spec: "", wantErr: true},
+		{spec: "not a cron", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !schedule.Next(base).Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", schedule.Next(base), tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRuns(t *testing.T) {
+	spec, _ := ParseSchedule("@every 1m")
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		schedule   entities.DetectorSchedule
+		now        time.Time
+		maxCatchUp int
+		wantRuns   []time.Time
+		wantNext   time.Time
+	}{
+		{
+			name:     "first evaluation only computes next run",
+			schedule: entities.DetectorSchedule{},
+			now:      base,
+			wantNext: base.Add(time.Minute),
+		},
+		{
+			name:     "not yet due",
+			schedule: entities.DetectorSchedule{NextRunAt: base.Add(time.Minute)},
+			now:      base,
+			wantNext: base.Add(time.Minute),
+		},
+		{
+			name:     "due exactly once",
+			schedule: entities.DetectorSchedule{NextRunAt: base},
+			now:      base.Add(10 * time.Second),
+			wantRuns: []time.Time{base},
+			wantNext: base.Add(time.Minute),
+		},
+		{
+			name:     "skip runs only the latest missed occurrence",
+			schedule: entities.DetectorSchedule{NextRunAt: base, MissedRunPolicy: entities.MissedRunPolicySkip},
+			now:      base.Add(3*time.Minute + 10*time.Second),
+			wantRuns: []time.Time{base.Add(3 * time.Minute)},
+			wantNext: base.Add(4 * time.Minute),
+		},
+		{
+			name:       "catch up runs every missed occurrence in order",
+			schedule:   entities.DetectorSchedule{NextRunAt: base, MissedRunPolicy: entities.MissedRunPolicyCatchUp},
+			now:        base.Add(2*time.Minute + 10*time.Second),
+			maxCatchUp: 10,
+			wantRuns:   []time.Time{base, base.Add(time.Minute), base.Add(2 * time.Minute)},
+			wantNext:   base.Add(3 * time.Minute),
+		},
+		{
+			name:       "catch up is capped per tick",
+			schedule:   entities.DetectorSchedule{NextRunAt: base, MissedRunPolicy: entities.MissedRunPolicyCatchUp},
+			now:        base.Add(10 * time.Minute),
+			maxCatchUp: 2,
+			wantRuns:   []time.Time{base, base.Add(time.Minute)},
+			wantNext:   base.Add(2 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, next := planRuns(spec, &tt.schedule, tt.now, tt.maxCatchUp)
+			if len(runs) != len(tt.wantRuns) {
+				t.Fatalf("runs = %v, want %v", runs, tt.wantRuns)
+			}
+			for i := range runs {
+				if !runs[i].Equal(tt.wantRuns[i]) {
+					t.Errorf("runs[%d] = %v, want %v", i, runs[i], tt.wantRuns[i])
+				}
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("next = %v, want %v", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+// newTestScheduler 創建一個時間可控的排程器及其依賴
+func newTestScheduler(t *testing.T, schedule entities.DetectorSchedule, detector *stubDetector) (*DetectionScheduler, *memoryScheduleRepo, *stubSource, *testClock, *[]RunInfo) {
+	t.Helper()
+	logger := &testLogger{}
+	repo := &memoryScheduleRepo{schedules: map[string]*entities.DetectorSchedule{}}
+	repo.Save(context.Background(), &schedule)
+
+	source := &stubSource{stubPlugin: stubPlugin{name: "source"}}
+	reg := registry.NewPluginRegistryProvider(logger)
+	reg.Register("source", source)
+	reg.Register("detector", detector)
+
+	var (
+		mu      sync.Mutex
+		handled []RunInfo
+	)
+	handler := func(ctx context.Context, run RunInfo, results []*entities.AnalysisResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(results) != run.Records {
+			t.Errorf("results = %d, records = %d", len(results), run.Records)
+		}
+		for _, r := range results {
+			if r.DetectorID != run.DetectorID {
+				t.Errorf("result detector id = %q, want %q", r.DetectorID, run.DetectorID)
+			}
+		}
+		handled = append(handled, run)
+		return nil
+	}
+
+	s, err := NewDetectionScheduler(repo, reg, handler, SchedulerConfig{DefaultWindow: "1m"}, logger, nil)
+	if err != nil {
+		t.Fatalf("NewDetectionScheduler() error = %v", err)
+	}
+	clock := &testClock{now: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)}
+	s.now = clock.Now
+	return s, repo, source, clock, &handled
+}
+
+func TestDetectionScheduler_TickRunsDueScheduleOnce(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, repo, source, clock, handled := newTestScheduler(t, entities.DetectorSchedule{
+		DetectorID:     "det-1",
+		DetectorPlugin: "detector",
+		Source:         "source",
+		Spec:           "@every 1m",
+		Enabled:        true,
+	}, &stubDetector{stubPlugin: stubPlugin{name: "detector"}})
+	ctx := context.Background()
+
+	// 首次檢查只計算 next_run_at
+	s.Tick(ctx)
+	s.wg.Wait()
+	if got, _ := repo.GetByDetectorID(ctx, "det-1"); !got.NextRunAt.Equal(base.Add(time.Minute)) {
+		t.Fatalf("NextRunAt = %v, want %v", got.NextRunAt, base.Add(time.Minute))
+	}
+
+	clock.Set(base.Add(time.Minute + 5*time.Second))
+	s.Tick(ctx)
+	s.wg.Wait()
+	// 同一時間再次檢查不應重複觸發
+	s.Tick(ctx)
+	s.wg.Wait()
+
+	if len(*handled) != 1 {
+		t.Fatalf("handled runs = %d, want 1", len(*handled))
+	}
+	run := (*handled)[0]
+	if !run.WindowEnd.Equal(base.Add(time.Minute)) || !run.WindowStart.Equal(base) {
+		t.Errorf("window = (%v, %v], want (%v, %v]", run.WindowStart, run.WindowEnd, base, base.Add(time.Minute))
+	}
+	if len(source.windows) != 1 {
+		t.Errorf("source fetched %d windows, want 1", len(source.windows))
+	}
+
+	got, _ := repo.GetByDetectorID(ctx, "det-1")
+	if !got.LastRunAt.Equal(base.Add(time.Minute)) || got.LastStatus != entities.ScheduleRunStatusSucceeded {
+		t.Errorf("last run = %v %s, want %v succeeded", got.LastRunAt, got.LastStatus, base.Add(time.Minute))
+	}
+	if !got.NextRunAt.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("NextRunAt = %v, want %v", got.NextRunAt, base.Add(2*time.Minute))
+	}
+}
+
+func TestDetectionScheduler_CatchUpAfterRestart(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	s, repo, _, clock, handled := newTestScheduler(t, entities.DetectorSchedule{
+		DetectorID:      "det-1",
+		DetectorPlugin:  "detector",
+		Source:          "source",
+		Spec:            "@every 1m",
+		MissedRunPolicy: entities.MissedRunPolicyCatchUp,
+		Enabled:         true,
+		NextRunAt:       base, // 上次關閉前持久化的下次執行時間
+	}, &stubDetector{stubPlugin: stubPlugin{name: "detector"}})
+
+	clock.Set(base.Add(2*time.Minute + time.Second))
+	s.Tick(context.Background())
+	s.wg.Wait()
+
+	if len(*handled) != 3 {
+		t.Fatalf("handled runs = %d, want 3", len(*handled))
+	}
+	for i, run := range *handled {
+		if want := base.Add(time.Duration(i) * time.Minute); !run.ScheduledAt.Equal(want) {
+			t.Errorf("run %d scheduled at %v, want %v", i, run.ScheduledAt, want)
+		}
+	}
+	if got, _ := repo.GetByDetectorID(context.Background(), "det-1"); !got.LastRunAt.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("LastRunAt = %v", got.LastRunAt)
+	}
+}
+
+func TestDetectionScheduler_ConcurrencyLimitSkips(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	detector := &stubDetector{stubPlugin: stubPlugin{name: "detector"}, block: make(chan struct{})}
+	s, repo, _, clock, handled := newTestScheduler(t, entities.DetectorSchedule{
+		DetectorID:     "det-1",
+		DetectorPlugin: "detector",
+		Source:         "source",
+		Spec:           "@every 1m",
+		MaxConcurrency: 1,
+		Enabled:        true,
+		NextRunAt:      base,
+	}, detector)
+	ctx := context.Background()
+
+	clock.Set(base.Add(time.Second))
+	s.Tick(ctx) // 開始第一次執行並阻塞
+
+	clock.Set(base.Add(time.Minute + time.Second))
+	s.Tick(ctx) // 第一次仍在執行，skip 策略略過並推進 next_run_at
+
+	close(detector.block)
+	s.wg.Wait()
+
+	if len(*handled) != 1 {
+		t.Errorf("handled runs = %d, want 1", len(*handled))
+	}
+	if got, _ := repo.GetByDetectorID(ctx, "det-1"); !got.NextRunAt.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("NextRunAt = %v, want %v", got.NextRunAt, base.Add(2*time.Minute))
+	}
+}
+
+func TestDetectionScheduler_HealthCheck(t *testing.T) {
+	s, _, _, clock, _ := newTestScheduler(t, entities.DetectorSchedule{DetectorID: "det-1"},
+		&stubDetector{stubPlugin: stubPlugin{name: "detector"}})
+	ctx := context.Background()
+
+	if got := s.HealthCheck(ctx).Status; got != plugins.HealthStatusUnhealthy {
+		t.Errorf("status before start = %s, want unhealthy", got)
+	}
+
+	s.mu.Lock()
+	s.running = true
+	s.lastTick = clock.Now()
+	s.mu.Unlock()
+	if got := s.HealthCheck(ctx).Status; got != plugins.HealthStatusHealthy {
+		t.Errorf("status while ticking = %s, want healthy", got)
+	}
+
+	clock.Set(clock.Now().Add(2 * time.Minute))
+	if got := s.HealthCheck(ctx).Status; got != plugins.HealthStatusUnhealthy {
+		t.Errorf("status when loop stalled = %s, want unhealthy", got)
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// ParseSchedule 解析排程表達式。
+// 支持標準 5 欄位 cron (可加 "CRON_TZ=Asia/Taipei " 前綴)、"@every 5m"、"@hourly" 等描述符，
+// 以及直接寫成時間長度的間隔 (例如 "30s")。
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("schedule spec is empty")
+	}
+
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval < time.Second {
+			return nil, fmt.Errorf("schedule interval %s is shorter than 1s", interval)
+		}
+		return cron.Every(interval), nil
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule spec %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// planRuns 根據排程與錯過策略計算本次應執行的排程時間，以及認領後的 next_run_at。
+// 返回的 next 與 s.NextRunAt 相同表示無需更新。
+func planRuns(schedule cron.Schedule, s *entities.DetectorSchedule, now time.Time, maxCatchUp int) (runs []time.Time, next time.Time) {
+	if s.NextRunAt.IsZero() {
+		// 新排程或配置剛變更：從現在開始計算，不補跑過去的時間點
+		return nil, schedule.Next(now)
+	}
+	if now.Before(s.NextRunAt) {
+		return nil, s.NextRunAt
+	}
+
+	if s.MissedRunPolicy == entities.MissedRunPolicyCatchUp {
+		if maxCatchUp <= 0 {
+			maxCatchUp = 1
+		}
+		for at := s.NextRunAt; !at.After(now) && len(runs) < maxCatchUp; at = schedule.Next(at) {
+			runs = append(runs, at)
+		}
+		// 超過上限時 next 仍在過去，剩餘的排程在下一輪繼續補跑
+		return runs, schedule.Next(runs[len(runs)-1])
+	}
+
+	// skip: 只執行最近一次到期的排程
+	latest := s.NextRunAt
+	for at := schedule.Next(latest); !at.After(now); at = schedule.Next(at) {
+		latest = at
+	}
+	return []time.Time{latest}, schedule.Next(latest)
+}
+
+// ValidateSchedule 驗證排程配置，並為未設置的欄位填入默認值
+func ValidateSchedule(s *entities.DetectorSchedule) error {
+	if s.DetectorID == "" {
+		return fmt.Errorf("detector id is required")
+	}
+	if s.DetectorPlugin == "" {
+		return fmt.Errorf("detector plugin is required")
+	}
+	if s.Source == "" {
+		return fmt.Errorf("data source is required")
+	}
+	if _, err := ParseSchedule(s.Spec); err != nil {
+		return err
+	}
+	if s.Window < 0 || s.Jitter < 0 {
+		return fmt.Errorf("window and jitter must not be negative")
+	}
+
+	switch s.MissedRunPolicy {
+	case "":
+		s.MissedRunPolicy = entities.MissedRunPolicySkip
+	case entities.MissedRunPolicySkip, entities.MissedRunPolicyCatchUp:
+	default:
+		return fmt.Errorf("unknown missed run policy %q", s.MissedRunPolicy)
+	}
+	if s.MaxConcurrency <= 0 {
+		s.MaxConcurrency = 1
+	}
+	return nil
+}
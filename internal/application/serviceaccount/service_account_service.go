@@ -0,0 +1,431 @@
+package serviceaccount
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// hmacHashScheme 是以 HMAC-SHA256 保存的金鑰雜湊的標記，其他雜湊交給 PasswordHasher 驗證
+const hmacHashScheme = "hmac-sha256$"
+
+var (
+	accountNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+	keyPrefixPattern   = regexp.MustCompile(`^[a-z][a-z0-9]{1,15}$`)
+)
+
+// PasswordHasher 以慢雜湊保存金鑰，由 hasher.BcryptPasswordHasher 實現
+type PasswordHasher interface {
+	HashPassword(ctx context.Context, plainPassword string) (string, error)
+	VerifyPassword(ctx context.Context, plainPassword, hashedPassword string) (bool, error)
+}
+
+// Config 定義 API 金鑰的簽發規則
+type Config struct {
+	KeyPrefix        string `yaml:"keyPrefix" json:"keyPrefix"`               // 金鑰明文的前綴，默認 "dvz"
+	DefaultTTL       string `yaml:"defaultTTL" json:"defaultTTL"`             // 簽發時未指定有效期時使用，默認 "2160h"，"0s" 表示不過期
+	MaxTTL           string `yaml:"maxTTL" json:"maxTTL"`                     // 允許的最長有效期，默認 "8760h"，"0s" 表示不限制
+	LastUsedInterval string `yaml:"lastUsedInterval" json:"lastUsedInterval"` // 最近使用時間的最小更新間隔，默認 "1m"
+}
+
+// CreateAccountRequest 創建服務帳號的參數
+type CreateAccountRequest struct {
+	Name        string
+	Description string
+	Roles       []string
+	CreatedBy   string
+}
+
+// UpdateAccountRequest 更新服務帳號的參數，nil 表示不變
+type UpdateAccountRequest struct {
+	Description *string
+	Roles       []string
+	Disabled    *bool
+}
+
+// IssueKeyRequest 簽發 API 金鑰的參數
+type IssueKeyRequest struct {
+	Name      string
+	Roles     []string // 金鑰的角色範圍，必須是服務帳號角色的子集；為空時使用帳號的全部角色
+	ExpiresIn string   // 有效期，為空時使用默認有效期，"0s" 表示不過期 (需要 maxTTL 為 0)
+	CreatedBy string
+}
+
+// ServiceAccountService 管理服務帳號與 API 金鑰
+// 職責: 簽發帶識別前綴與有效期的金鑰 (明文只返回一次，只保存雜湊)，撤銷金鑰，
+// 並驗證 API 請求中的金鑰、記錄最近的使用時間。
+// 配置了雜湊密鑰時新金鑰以 HMAC-SHA256 保存，否則以 PasswordHasher (bcrypt) 保存；兩種雜湊都能驗證。
+type ServiceAccountService struct {
+	repo    interfaces.ServiceAccountRepository
+	hmacKey []byte
+	hasher  PasswordHasher
+	logger  contracts.Logger
+
+	keyPrefix        string
+	defaultTTL       time.Duration
+	maxTTL           time.Duration
+	lastUsedInterval time.Duration
+
+	now func() time.Time
+}
+
+// NewServiceAccountService 創建服務帳號服務。hmacKey 為空時以 hasher 保存金鑰，兩者不能都為空。
+func NewServiceAccountService(repo interfaces.ServiceAccountRepository, config Config, hmacKey []byte, hasher PasswordHasher,
+	logger contracts.Logger) (*ServiceAccountService, error) {
+	if len(hmacKey) == 0 && hasher == nil {
+		return nil, fmt.Errorf("service accounts require an HMAC key or a password hasher")
+	}
+	s := &ServiceAccountService{
+		repo:      repo,
+		hmacKey:   hmacKey,
+		hasher:    hasher,
+		logger:    logger,
+		keyPrefix: config.KeyPrefix,
+		now:       time.Now,
+	}
+	if s.keyPrefix == "" {
+		s.keyPrefix = "dvz"
+	}
+	if !keyPrefixPattern.MatchString(s.keyPrefix) {
+		return nil, fmt.Errorf("invalid keyPrefix %q: expected 2-16 lowercase letters or digits", s.keyPrefix)
+	}
+	var err error
+	if s.defaultTTL, err = parseDurationDefault(config.DefaultTTL, 90*24*time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid defaultTTL: %w", err)
+	}
+	if s.maxTTL, err = parseDurationDefault(config.MaxTTL, 365*24*time.Hour); err != nil {
+		return nil, fmt.Errorf("invalid maxTTL: %w", err)
+	}
+	if s.lastUsedInterval, err = parseDurationDefault(config.LastUsedInterval, time.Minute); err != nil {
+		return nil, fmt.Errorf("invalid lastUsedInterval: %w", err)
+	}
+	if s.maxTTL > 0 && (s.defaultTTL == 0 || s.defaultTTL > s.maxTTL) {
+		return nil, fmt.Errorf("defaultTTL %s exceeds maxTTL %s", s.defaultTTL, s.maxTTL)
+	}
+	return s, nil
+}
+
+// CreateAccount 創建服務帳號，名稱不能重複
+func (s *ServiceAccountService) CreateAccount(ctx context.Context, req CreateAccountRequest) (*entities.ServiceAccount, error) {
+	if !accountNamePattern.MatchString(req.Name) {
+		return nil, domainerrors.NewValidationError("name", "服務帳號名稱只能包含小寫字母、數字、點、底線與連字號，且不超過 63 個字元")
+	}
+	roles, err := normalizeRoles(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetAccountByName(ctx, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("查找服務帳號失敗: %w", err)
+	}
+	if existing != nil {
+		return nil, domainerrors.NewValidationError("name", fmt.Sprintf("服務帳號已存在: %s", req.Name))
+	}
+
+	now := s.now()
+	account := &entities.ServiceAccount{
+		ID:          uuid.NewString(),
+		Name:        req.Name,
+		Description: req.Description,
+		Roles:       roles,
+		CreatedBy:   req.CreatedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.SaveAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("保存服務帳號失敗: %w", err)
+	}
+	s.logger.Info("已創建服務帳號", "service_account_id", account.ID, "name", account.Name, "roles", roles, "by", req.CreatedBy)
+	return account, nil
+}
+
+// GetAccount 獲取服務帳號
+func (s *ServiceAccountService) GetAccount(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	account, err := s.repo.GetAccount(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查找服務帳號失敗: %w", err)
+	}
+	if account == nil {
+		return nil, domainerrors.NewNotFoundError("service_account", fmt.Sprintf("服務帳號不存在: %s", id))
+	}
+	return account, nil
+}
+
+// ListAccounts 按名稱列出服務帳號
+func (s *ServiceAccountService) ListAccounts(ctx context.Context) ([]*entities.ServiceAccount, error) {
+	accounts, err := s.repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("列出服務帳號失敗: %w", err)
+	}
+	return accounts, nil
+}
+
+// UpdateAccount 更新服務帳號的說明、角色或停用狀態。移除的角色立即從所有金鑰中失效。
+func (s *ServiceAccountService) UpdateAccount(ctx context.Context, id string, req UpdateAccountRequest) (*entities.ServiceAccount, error) {
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Description != nil {
+		account.Description = *req.Description
+	}
+	if req.Roles != nil {
+		if account.Roles, err = normalizeRoles(req.Roles); err != nil {
+			return nil, err
+		}
+	}
+	if req.Disabled != nil {
+		account.Disabled = *req.Disabled
+	}
+	account.UpdatedAt = s.now()
+	if err := s.repo.SaveAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("保存服務帳號失敗: %w", err)
+	}
+	s.logger.Info("已更新服務帳號", "service_account_id", account.ID, "roles", account.Roles, "disabled", account.Disabled)
+	return account, nil
+}
+
+// IssueKey 為服務帳號簽發 API 金鑰，返回金鑰記錄與只在此時可見的明文
+func (s *ServiceAccountService) IssueKey(ctx context.Context, accountID string, req IssueKeyRequest) (*entities.APIKey, string, error) {
+	account, err := s.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, "", domainerrors.NewValidationError("name", "金鑰名稱不能為空")
+	}
+	roles, err := normalizeRoles(req.Roles)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, role := range roles {
+		if !contains(account.Roles, role) {
+			return nil, "", domainerrors.NewValidationError("roles", fmt.Sprintf("服務帳號沒有角色 %s", role))
+		}
+	}
+	ttl := s.defaultTTL
+	if req.ExpiresIn != "" {
+		if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl < 0 {
+			return nil, "", domainerrors.NewValidationError("expiresIn", fmt.Sprintf("無效的有效期: %s", req.ExpiresIn))
+		}
+	}
+	if s.maxTTL > 0 && (ttl == 0 || ttl > s.maxTTL) {
+		return nil, "", domainerrors.NewValidationError("expiresIn", fmt.Sprintf("有效期不能超過 %s", s.maxTTL))
+	}
+
+	prefix, secret, err := s.generate()
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := s.hash(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+	now := s.now()
+	key := &entities.APIKey{
+		ID:               uuid.NewString(),
+		ServiceAccountID: account.ID,
+		Name:             req.Name,
+		Prefix:           prefix,
+		Hash:             hash,
+		Roles:            roles,
+		CreatedBy:        req.CreatedBy,
+		CreatedAt:        now,
+	}
+	if ttl > 0 {
+		key.ExpiresAt = now.Add(ttl)
+	}
+	if err := s.repo.SaveKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("保存 API 金鑰失敗: %w", err)
+	}
+	s.logger.Info("已簽發 API 金鑰", "service_account_id", account.ID, "api_key_id", key.ID, "prefix", prefix,
+		"expires_at", key.ExpiresAt, "by", req.CreatedBy)
+	return key, prefix + "_" + secret, nil
+}
+
+// ListKeys 列出服務帳號的金鑰
+func (s *ServiceAccountService) ListKeys(ctx context.Context, accountID string) ([]*entities.APIKey, error) {
+	if _, err := s.GetAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	keys, err := s.repo.ListKeys(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("列出 API 金鑰失敗: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey 撤銷金鑰，已撤銷的金鑰保持原撤銷記錄
+func (s *ServiceAccountService) RevokeKey(ctx context.Context, accountID, keyID, by string) (*entities.APIKey, error) {
+	key, err := s.repo.GetKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("查找 API 金鑰失敗: %w", err)
+	}
+	if key == nil || key.ServiceAccountID != accountID {
+		return nil, domainerrors.NewNotFoundError("api_key", fmt.Sprintf("API 金鑰不存在: %s", keyID))
+	}
+	if key.Revoked() {
+		return key, nil
+	}
+	key.RevokedAt = s.now()
+	key.RevokedBy = by
+	if err := s.repo.SaveKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("保存 API 金鑰失敗: %w", err)
+	}
+	s.logger.Info("已撤銷 API 金鑰", "service_account_id", accountID, "api_key_id", key.ID, "prefix", key.Prefix, "by", by)
+	return key, nil
+}
+
+// Authenticate 驗證 API 金鑰明文，返回服務帳號與金鑰。
+// 金鑰格式錯誤、不存在、雜湊不符、已撤銷、已過期或帳號已停用時都返回同一個認證錯誤，原因只記錄在日誌中。
+func (s *ServiceAccountService) Authenticate(ctx context.Context, plaintext string) (*entities.ServiceAccount, *entities.APIKey, error) {
+	invalid := domainerrors.NewAuthError("invalid API key")
+	prefix, secret, ok := s.parse(plaintext)
+	if !ok {
+		return nil, nil, invalid
+	}
+	key, err := s.repo.GetKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查找 API 金鑰失敗: %w", err)
+	}
+	if key == nil {
+		s.logger.Debug("API 金鑰前綴不存在", "prefix", prefix)
+		return nil, nil, invalid
+	}
+	if !s.verify(ctx, secret, key.Hash) {
+		s.logger.Warn("API 金鑰不符", "prefix", prefix)
+		return nil, nil, invalid
+	}
+	now := s.now()
+	if key.Revoked() || key.Expired(now) {
+		s.logger.Info("拒絕已撤銷或已過期的 API 金鑰", "prefix", prefix, "revoked", key.Revoked())
+		return nil, nil, invalid
+	}
+	account, err := s.repo.GetAccount(ctx, key.ServiceAccountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查找服務帳號失敗: %w", err)
+	}
+	if account == nil || account.Disabled {
+		s.logger.Info("拒絕已停用服務帳號的 API 金鑰", "prefix", prefix, "service_account_id", key.ServiceAccountID)
+		return nil, nil, invalid
+	}
+
+	if now.Sub(key.LastUsedAt) >= s.lastUsedInterval {
+		key.LastUsedAt = now
+		// 使用時間只用於審計，更新失敗不影響請求
+		if err := s.repo.TouchKey(ctx, key.ID, now); err != nil {
+			s.logger.Warn("更新 API 金鑰使用時間失敗", "api_key_id", key.ID, "error", err)
+		}
+	}
+	return account, key, nil
+}
+
+// generate 生成金鑰的識別前綴與秘密部分
+func (s *ServiceAccountService) generate() (string, string, error) {
+	buf := make([]byte, 6+32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("生成 API 金鑰失敗: %w", err)
+	}
+	return s.keyPrefix + "_" + hex.EncodeToString(buf[:6]), base64.RawURLEncoding.EncodeToString(buf[6:]), nil
+}
+
+// parse 把金鑰明文拆分為識別前綴與秘密部分，格式為 <keyPrefix>_<12 位十六進位>_<秘密>
+func (s *ServiceAccountService) parse(plaintext string) (string, string, bool) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != s.keyPrefix || len(parts[1]) != 12 || parts[2] == "" {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", "", false
+	}
+	return parts[0] + "_" + parts[1], parts[2], true
+}
+
+// hash 計算秘密部分的雜湊
+func (s *ServiceAccountService) hash(ctx context.Context, secret string) (string, error) {
+	if len(s.hmacKey) > 0 {
+		return hmacHashScheme + s.hmac(secret), nil
+	}
+	hash, err := s.hasher.HashPassword(ctx, secret)
+	if err != nil {
+		return "", fmt.Errorf("雜湊 API 金鑰失敗: %w", err)
+	}
+	return hash, nil
+}
+
+// verify 以保存雜湊的演算法驗證秘密部分
+func (s *ServiceAccountService) verify(ctx context.Context, secret, hash string) bool {
+	if encoded, ok := strings.CutPrefix(hash, hmacHashScheme); ok {
+		if len(s.hmacKey) == 0 {
+			s.logger.Error("API 金鑰以 HMAC 保存，但未配置雜湊密鑰")
+			return false
+		}
+		return hmac.Equal([]byte(encoded), []byte(s.hmac(secret)))
+	}
+	if s.hasher == nil {
+		return false
+	}
+	ok, err := s.hasher.VerifyPassword(ctx, secret, hash)
+	return err == nil && ok
+}
+
+// hmac 返回秘密部分的 HMAC-SHA256 十六進位摘要
+func (s *ServiceAccountService) hmac(secret string) string {
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeRoles 去除空白與重複的角色
+func normalizeRoles(roles []string) ([]string, error) {
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return nil, domainerrors.NewValidationError("roles", "角色不能為空")
+		}
+		if !contains(normalized, role) {
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized, nil
+}
+
+// contains 檢查 values 是否包含 value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDurationDefault 解析時間長度，空字串時返回默認值
+func parseDurationDefault(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative: %s", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,270 @@
+package serviceaccount
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type memoryServiceAccountRepo struct {
+	mu       sync.Mutex
+	accounts map[string]entities.ServiceAccount
+	keys     map[string]entities.APIKey
+	touches  int
+}
+
+func newMemoryRepo() *memoryServiceAccountRepo {
+	return &memoryServiceAccountRepo{
+		accounts: make(map[string]entities.ServiceAccount),
+		keys:     make(map[string]entities.APIKey),
+	}
+}
+
+func (r *memoryServiceAccountRepo) SaveAccount(ctx context.Context, account *entities.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[account.ID] = *account
+	return nil
+}
+
+func (r *memoryServiceAccountRepo) GetAccount(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, nil
+	}
+	return &account, nil
+}
+
+func (r *memoryServiceAccountRepo) GetAccountByName(ctx context.Context, name string) (*entities.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, account := range r.accounts {
+		if account.Name == name {
+			return &account, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryServiceAccountRepo) ListAccounts(ctx context.Context) ([]*entities.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.ServiceAccount
+	for _, account := range r.accounts {
+		a := account
+		out = append(out, &a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (r *memoryServiceAccountRepo) SaveKey(ctx context.Context, key *entities.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *memoryServiceAccountRepo) GetKey(ctx context.Context, id string) (*entities.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (r *memoryServiceAccountRepo) GetKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryServiceAccountRepo) ListKeys(ctx context.Context, accountID string) ([]*entities.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*entities.APIKey
+	for _, key := range r.keys {
+		if key.ServiceAccountID == accountID {
+			k := key
+			out = append(out, &k)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryServiceAccountRepo) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := r.keys[id]
+	key.LastUsedAt = usedAt
+	r.keys[id] = key
+	r.touches++
+	return nil
+}
+
+func newTestService(t *testing.T, hmacKey []byte) (*ServiceAccountService, *memoryServiceAccountRepo, *time.Time) {
+	t.Helper()
+	repo := newMemoryRepo()
+	bcrypt, err := hasher.NewBcryptPasswordHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServiceAccountService(repo, Config{DefaultTTL: "720h", MaxTTL: "2160h"}, hmacKey, bcrypt, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewServiceAccountService() error = %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, repo, &now
+}
+
+func TestServiceAccountService_IssueAndAuthenticate(t *testing.T) {
+	for name, hmacKey := range map[string][]byte{"hmac": []byte("pepper"), "bcrypt": nil} {
+		t.Run(name, func(t *testing.T) {
+			s, repo, now := newTestService(t, hmacKey)
+			ctx := context.Background()
+			account, err := s.CreateAccount(ctx, CreateAccountRequest{Name: "ci-runner", Roles: []string{"operator", "viewer"}, CreatedBy: "alice"})
+			if err != nil {
+				t.Fatalf("CreateAccount() error = %v", err)
+			}
+			key, plaintext, err := s.IssueKey(ctx, account.ID, IssueKeyRequest{Name: "deploy", Roles: []string{"viewer"}, CreatedBy: "alice"})
+			if err != nil {
+				t.Fatalf("IssueKey() error = %v", err)
+			}
+			if !strings.HasPrefix(plaintext, key.Prefix+"_") || !strings.HasPrefix(key.Prefix, "dvz_") {
+				t.Errorf("plaintext %q does not start with prefix %q", plaintext, key.Prefix)
+			}
+			stored := repo.keys[key.ID]
+			if strings.Contains(stored.Hash, strings.TrimPrefix(plaintext, key.Prefix+"_")) {
+				t.Error("secret is stored in plain text")
+			}
+			if want := now.Add(720 * time.Hour); !key.ExpiresAt.Equal(want) {
+				t.Errorf("ExpiresAt = %v, want %v", key.ExpiresAt, want)
+			}
+
+			gotAccount, gotKey, err := s.Authenticate(ctx, plaintext)
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if gotAccount.ID != account.ID || strings.Join(gotKey.EffectiveRoles(gotAccount), ",") != "viewer" {
+				t.Errorf("unexpected principal %s with roles %v", gotAccount.ID, gotKey.EffectiveRoles(gotAccount))
+			}
+			if !repo.keys[key.ID].LastUsedAt.Equal(*now) {
+				t.Error("last used time was not recorded")
+			}
+			// 最小更新間隔內的再次使用不寫入數據庫
+			if _, _, err := s.Authenticate(ctx, plaintext); err != nil || repo.touches != 1 {
+				t.Errorf("second Authenticate() error = %v, touches = %d", err, repo.touches)
+			}
+
+			for _, bad := range []string{"", plaintext + "x", "dvz_000000000000_" + strings.TrimPrefix(plaintext, key.Prefix+"_"),
+				strings.Replace(plaintext, "dvz_", "abc_", 1)} {
+				if _, _, err := s.Authenticate(ctx, bad); !domainerrors.IsAuthError(err) {
+					t.Errorf("Authenticate(%q) error = %v, want auth error", bad, err)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceAccountService_RejectsRevokedExpiredAndDisabled(t *testing.T) {
+	s, _, now := newTestService(t, []byte("pepper"))
+	ctx := context.Background()
+	account, _ := s.CreateAccount(ctx, CreateAccountRequest{Name: "collector", Roles: []string{"viewer"}})
+
+	revoked, revokedPlaintext, _ := s.IssueKey(ctx, account.ID, IssueKeyRequest{Name: "old"})
+	if _, err := s.RevokeKey(ctx, account.ID, revoked.ID, "bob"); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	if _, _, err := s.Authenticate(ctx, revokedPlaintext); !domainerrors.IsAuthError(err) {
+		t.Errorf("revoked key accepted: %v", err)
+	}
+	if _, err := s.RevokeKey(ctx, "other-account", revoked.ID, "bob"); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("RevokeKey() of another account error = %v", err)
+	}
+
+	_, shortPlaintext, _ := s.IssueKey(ctx, account.ID, IssueKeyRequest{Name: "short", ExpiresIn: "1h"})
+	*now = now.Add(time.Hour)
+	if _, _, err := s.Authenticate(ctx, shortPlaintext); !domainerrors.IsAuthError(err) {
+		t.Errorf("expired key accepted: %v", err)
+	}
+
+	_, plaintext, _ := s.IssueKey(ctx, account.ID, IssueKeyRequest{Name: "current"})
+	disabled := true
+	if _, err := s.UpdateAccount(ctx, account.ID, UpdateAccountRequest{Disabled: &disabled}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Authenticate(ctx, plaintext); !domainerrors.IsAuthError(err) {
+		t.Errorf("key of disabled account accepted: %v", err)
+	}
+}
+
+func TestServiceAccountService_Validation(t *testing.T) {
+	s, _, _ := newTestService(t, []byte("pepper"))
+	ctx := context.Background()
+	if _, err := s.CreateAccount(ctx, CreateAccountRequest{Name: "CI Runner"}); !domainerrors.IsValidationError(err) {
+		t.Errorf("invalid name error = %v", err)
+	}
+	account, _ := s.CreateAccount(ctx, CreateAccountRequest{Name: "ci", Roles: []string{"viewer"}})
+	if _, err := s.CreateAccount(ctx, CreateAccountRequest{Name: "ci"}); !domainerrors.IsValidationError(err) {
+		t.Errorf("duplicate name error = %v", err)
+	}
+
+	tests := map[string]IssueKeyRequest{
+		"missing name":        {},
+		"role outside scope":  {Name: "k", Roles: []string{"admin"}},
+		"exceeds maxTTL":      {Name: "k", ExpiresIn: "2161h"},
+		"never expiring":      {Name: "k", ExpiresIn: "0s"},
+		"invalid expiresIn":   {Name: "k", ExpiresIn: "soon"},
+		"empty role in scope": {Name: "k", Roles: []string{" "}},
+	}
+	for name, req := range tests {
+		if _, _, err := s.IssueKey(ctx, account.ID, req); !domainerrors.IsValidationError(err) {
+			t.Errorf("%s: IssueKey() error = %v, want validation error", name, err)
+		}
+	}
+	if _, _, err := s.IssueKey(ctx, "missing", IssueKeyRequest{Name: "k"}); !domainerrors.IsNotFoundError(err) {
+		t.Errorf("IssueKey() for missing account error = %v", err)
+	}
+}
+
+func TestAPIKey_EffectiveRolesFollowAccount(t *testing.T) {
+	account := &entities.ServiceAccount{Roles: []string{"viewer"}}
+	key := &entities.APIKey{Roles: []string{"operator", "viewer"}}
+	// 帳號移除的角色不再經金鑰生效
+	if roles := key.EffectiveRoles(account); len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("EffectiveRoles() = %v", roles)
+	}
+	if roles := (&entities.APIKey{}).EffectiveRoles(account); len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("EffectiveRoles() without scope = %v", roles)
+	}
+}
@@ -0,0 +1,204 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/application/alerting"
+	"detectviz-platform/internal/application/scheduler"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/plugins/alerts"
+	"detectviz-platform/internal/plugins/notifications"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewAlertManagerFromConfig 根據 app_config.yaml 的 alerting 區塊創建告警管理器。
+// alerting.enabled 為 false 時返回 nil；secrets 與 metrics 可為 nil。
+// alerting.incidents.enabled 為 true 時啟用事件單，確認鏈接的簽名密鑰從 secrets 讀取；
+// alerting.deliveryQueue.enabled 為 true 時記錄每一次通知投遞並重試發送失敗的通知。
+func NewAlertManagerFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	registry contracts.PluginRegistryProvider, secrets contracts.SecretsProvider, logger contracts.Logger,
+	metrics contracts.MetricsProvider) (*alerting.AlertManager, error) {
+	if !configProvider.GetBool("alerting.enabled") {
+		return nil, nil
+	}
+
+	// groupBy、route 與 receivers 是列表或巢狀結構，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Alerting alerting.AlertManagerConfig
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode alerting config: %w", err)
+	}
+
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect := dbClient.Dialect()
+	alerts := mysql.NewAlertRepository(db, dialect, logger)
+	incidents, err := newIncidentService(ctx, configProvider, alerts, mysql.NewIncidentRepository(db, dialect, logger), secrets, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := newDeliveryService(configProvider, mysql.NewNotificationDeliveryRepository(db, dialect, logger), logger, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	oncall := alerting.NewOnCallService(mysql.NewOnCallScheduleRepository(db, dialect, logger), mysql.NewEscalationPolicyRepository(db, dialect, logger),
+		mysql.NewUserRepository(db, dialect, logger), logger)
+
+	m, err := alerting.NewAlertManager(alerting.AlertManagerDeps{
+		Alerts:     alerts,
+		Groups:     mysql.NewAlertGroupRepository(db, dialect, logger),
+		Registry:   registry,
+		Silences:   alerting.NewSilenceService(mysql.NewSilenceRepository(db, dialect, logger), logger),
+		OnCall:     oncall,
+		Incidents:  incidents,
+		Deliveries: deliveries,
+		Results:    mysql.NewAnalysisResultRepository(db, dialect, logger),
+		Detectors:  mysql.NewDetectorRepository(db, dialect, logger),
+		Metrics:    metrics,
+	}, root.Alerting, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert manager: %w", err)
+	}
+	return m, nil
+}
+
+// newIncidentService 根據 alerting.incidents 區塊創建事件單服務，未啟用時返回 nil。
+// 配置了 acknowledgeLinkSecret 時，通知附帶以該秘密簽名、指向 alerting.externalURL 的確認鏈接，有效期為 acknowledgeLinkTTL。
+func newIncidentService(ctx context.Context, configProvider contracts.ConfigProvider, alerts interfaces.AlertRepository,
+	incidents interfaces.IncidentRepository, secrets contracts.SecretsProvider, logger contracts.Logger) (*alerting.IncidentService, error) {
+	if !configProvider.GetBool("alerting.incidents.enabled") {
+		return nil, nil
+	}
+	links := alerting.IncidentLinkConfig{ExternalURL: configProvider.GetString("alerting.externalURL")}
+	if name := configProvider.GetString("alerting.incidents.acknowledgeLinkSecret"); name != "" {
+		if secrets == nil {
+			return nil, fmt.Errorf("alerting.incidents.acknowledgeLinkSecret requires a secrets provider")
+		}
+		key, err := secrets.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read acknowledge link secret %s: %w", name, err)
+		}
+		links.SigningKey = []byte(key)
+	}
+	if value := configProvider.GetString("alerting.incidents.acknowledgeLinkTTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid alerting.incidents.acknowledgeLinkTTL %q", value)
+		}
+		links.TTL = ttl
+	}
+	if links.ExternalURL == "" || len(links.SigningKey) == 0 {
+		logger.Info("未配置 alerting.externalURL 或確認鏈接密鑰，通知不會附帶事件單確認鏈接")
+	}
+	return alerting.NewIncidentService(incidents, alerts, links, logger), nil
+}
+
+// newDeliveryService 根據 alerting.deliveryQueue 區塊創建通知投遞服務，未啟用時返回 nil
+func newDeliveryService(configProvider contracts.ConfigProvider, deliveries interfaces.NotificationDeliveryRepository,
+	logger contracts.Logger, metrics contracts.MetricsProvider) (*alerting.DeliveryService, error) {
+	if !configProvider.GetBool("alerting.deliveryQueue.enabled") {
+		return nil, nil
+	}
+	service, err := alerting.NewDeliveryService(deliveries, alerting.DeliveryConfig{
+		MaxAttempts:    configProvider.GetInt("alerting.deliveryQueue.maxAttempts"),
+		InitialBackoff: configProvider.GetString("alerting.deliveryQueue.initialBackoff"),
+		MaxBackoff:     configProvider.GetString("alerting.deliveryQueue.maxBackoff"),
+	}, logger, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alerting.deliveryQueue config: %w", err)
+	}
+	return service, nil
+}
+
+// NewSilenceService 創建以數據庫保存的靜默服務，供 CLI 等不啟動告警管理器的入口管理靜默
+func NewSilenceService(ctx context.Context, dbClient *database.SQLClientProvider, logger contracts.Logger) (*alerting.SilenceService, error) {
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return alerting.NewSilenceService(mysql.NewSilenceRepository(db, dbClient.Dialect(), logger), logger), nil
+}
+
+// RegisterAlertPlugins 初始化內建的通知插件並註冊到插件註冊表，供 alerting.receivers 的渠道引用。
+// 發送參數來自 alerting.delivery 區塊，卡片中返回 UI 的鏈接使用 alerting.externalURL；
+// secrets 可為 nil，此時渠道不能使用 *_secret 設定 (slack_alert 與 teams_alert 因此不可用)。
+func RegisterAlertPlugins(ctx context.Context, configProvider contracts.ConfigProvider, registry contracts.PluginRegistryProvider,
+	secrets contracts.SecretsProvider, logger contracts.Logger) error {
+	deliveryConfig := map[string]interface{}{
+		"timeout":          configProvider.GetString("alerting.delivery.timeout"),
+		"initial_interval": configProvider.GetString("alerting.delivery.initialInterval"),
+		"max_interval":     configProvider.GetString("alerting.delivery.maxInterval"),
+		"dead_letter_path": configProvider.GetString("alerting.delivery.deadLetterPath"),
+		"ui_url":           configProvider.GetString("alerting.externalURL"),
+	}
+	if maxAttempts := configProvider.GetInt("alerting.delivery.maxAttempts"); maxAttempts > 0 {
+		deliveryConfig["max_attempts"] = maxAttempts
+	}
+
+	for _, plugin := range []plugins.AlertPlugin{
+		alerts.NewWebhookAlertPlugin(secrets, logger),
+		alerts.NewSlackAlertPlugin(secrets, logger),
+		alerts.NewTeamsAlertPlugin(secrets, logger),
+	} {
+		if err := plugin.Init(ctx, deliveryConfig); err != nil {
+			return fmt.Errorf("failed to initialize alert plugin %s: %w", plugin.GetName(), err)
+		}
+		if err := registry.Register(plugin.GetName(), plugin); err != nil {
+			return fmt.Errorf("failed to register alert plugin %s: %w", plugin.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// NewEmailNotifierFromConfig 根據 notifications.email 區塊創建並初始化郵件通知插件，
+// 註冊後可在 alerting.receivers 的渠道中以 plugin: email_notifier 與 recipient 引用。
+// notifications.email.enabled 為 false 時返回 nil。
+func NewEmailNotifierFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, secrets contracts.SecretsProvider,
+	logger contracts.Logger) (*notifications.EmailNotifierPlugin, error) {
+	if !configProvider.GetBool("notifications.email.enabled") {
+		return nil, nil
+	}
+	cfg := map[string]interface{}{
+		"host":                 configProvider.GetString("notifications.email.host"),
+		"tls":                  configProvider.GetString("notifications.email.tls"),
+		"insecure_skip_verify": configProvider.GetBool("notifications.email.insecureSkipVerify"),
+		"username":             configProvider.GetString("notifications.email.username"),
+		"password_secret":      configProvider.GetString("notifications.email.passwordSecret"),
+		"from":                 configProvider.GetString("notifications.email.from"),
+		"helo_name":            configProvider.GetString("notifications.email.heloName"),
+		"timeout":              configProvider.GetString("notifications.email.timeout"),
+		"idle_timeout":         configProvider.GetString("notifications.email.idleTimeout"),
+		"templates_dir":        configProvider.GetString("notifications.email.templatesDir"),
+		"digest_interval":      configProvider.GetString("notifications.email.digestInterval"),
+		"rate_per_hour":        configProvider.GetInt("notifications.email.ratePerHour"),
+		"ui_url":               configProvider.GetString("alerting.externalURL"),
+	}
+	if port := configProvider.GetInt("notifications.email.port"); port > 0 {
+		cfg["port"] = port
+	}
+
+	notifier := notifications.NewEmailNotifierPlugin(secrets, logger)
+	if err := notifier.Init(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("failed to initialize email notifier: %w", err)
+	}
+	return notifier, nil
+}
+
+// AlertResultHandler 將排程執行產生的分析結果交給告警管理器
+func AlertResultHandler(manager *alerting.AlertManager) scheduler.ResultHandler {
+	return func(ctx context.Context, run scheduler.RunInfo, results []*entities.AnalysisResult) error {
+		return manager.Process(ctx, results)
+	}
+}
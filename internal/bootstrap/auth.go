@@ -0,0 +1,344 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"detectviz-platform/internal/adapters/http_middleware"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/infrastructure/platform/auth"
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/internal/infrastructure/platform/auth/rbac"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewRBACEngineFromConfig 根據 app_config.yaml 的 auth.rbac 區塊創建授權引擎，
+// auth.rbac.enabled 為 false 時返回 nil。策略文件無效時返回錯誤，避免以空策略拒絕所有請求。
+func NewRBACEngineFromConfig(configProvider contracts.ConfigProvider, logger contracts.Logger) (*rbac.Engine, error) {
+	if !configProvider.GetBool("auth.rbac.enabled") {
+		return nil, nil
+	}
+	engine, err := rbac.NewEngine(rbac.EngineConfig{
+		PolicyFile:     configProvider.GetString("auth.rbac.policyFile"),
+		ReloadInterval: configProvider.GetString("auth.rbac.reloadInterval"),
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RBAC engine: %w", err)
+	}
+	return engine, nil
+}
+
+// NewAuthProviderFromConfig 根據 auth.provider 創建認證提供者，留空時返回 nil。
+// engine 為 nil 時提供者拒絕所有授權請求；authStorage 保存 CSRF 令牌；客戶端密鑰從 secrets 讀取。
+// "local" 提供者的用戶保存在數據庫中，notifier 發送驗證與密碼重置郵件，可以為 nil。
+func NewAuthProviderFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, secrets contracts.SecretsProvider,
+	engine *rbac.Engine, authStorage contracts.AuthStorageProvider, dbClient *database.SQLClientProvider,
+	notifier auth.AccountNotifier, logger contracts.Logger) (contracts.AuthProvider, error) {
+	var authorizer auth.Authorizer
+	if engine != nil {
+		authorizer = engine
+	}
+
+	switch provider := configProvider.GetString("auth.provider"); provider {
+	case "":
+		return nil, nil
+	case "keycloak":
+		config := auth.KeycloakConfig{
+			BaseURL:               configProvider.GetString("auth.keycloak.baseURL"),
+			Realm:                 configProvider.GetString("auth.keycloak.realm"),
+			ClientID:              configProvider.GetString("auth.keycloak.clientID"),
+			Timeout:               configProvider.GetString("auth.keycloak.timeout"),
+			JWKSURL:               configProvider.GetString("auth.keycloak.jwksURL"),
+			Issuer:                configProvider.GetString("auth.keycloak.issuer"),
+			IntrospectionFallback: configProvider.GetBool("auth.keycloak.introspectionFallback"),
+		}
+		if name := configProvider.GetString("auth.keycloak.clientSecretKey"); name != "" {
+			if secrets == nil {
+				return nil, fmt.Errorf("auth.keycloak.clientSecretKey requires a secrets provider")
+			}
+			secret, err := secrets.GetSecret(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read keycloak client secret %s: %w", name, err)
+			}
+			config.ClientSecret = secret
+		}
+		return auth.NewKeycloakAuthProvider(config, authorizer, authStorage, logger)
+	case "local":
+		return newLocalAuthProvider(ctx, configProvider, secrets, authorizer, authStorage, dbClient, notifier, logger)
+	default:
+		return nil, fmt.Errorf("unsupported auth.provider %q", provider)
+	}
+}
+
+// newLocalAuthProvider 根據 auth.local 區塊創建本地認證提供者，令牌簽名主密鑰從 secrets 讀取
+func newLocalAuthProvider(ctx context.Context, configProvider contracts.ConfigProvider, secrets contracts.SecretsProvider,
+	authorizer auth.Authorizer, authStorage contracts.AuthStorageProvider, dbClient *database.SQLClientProvider,
+	notifier auth.AccountNotifier, logger contracts.Logger) (contracts.AuthProvider, error) {
+	if dbClient == nil {
+		return nil, fmt.Errorf("auth.provider local requires a database")
+	}
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// defaultRoles 是列表，passwordPolicy 是嵌套區塊，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Auth struct {
+			Local auth.LocalAuthConfig
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode local auth config: %w", err)
+	}
+
+	var signingKey []byte
+	if name := configProvider.GetString("auth.local.signingKeySecret"); name != "" {
+		if secrets == nil {
+			return nil, fmt.Errorf("auth.local.signingKeySecret requires a secrets provider")
+		}
+		secret, err := secrets.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local token signing key %s: %w", name, err)
+		}
+		signingKey = []byte(secret)
+	}
+
+	var mfa *auth.TOTPAuthenticator
+	if configProvider.GetBool("auth.mfa.enabled") {
+		if mfa, err = newTOTPAuthenticator(ctx, configProvider, secrets, dbClient, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	provider, err := auth.NewLocalAuthProvider(root.Auth.Local, mysql.NewUserRepository(db, dbClient.Dialect(), logger),
+		hasher.NewDefaultBcryptPasswordHasher(), signingKey, authorizer, authStorage, notifier, mfa, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local auth provider: %w", err)
+	}
+	return provider, nil
+}
+
+// newTOTPAuthenticator 根據 auth.mfa 區塊創建本地用戶的 TOTP 多因素認證 (遷移 0022)。
+// TOTP 密鑰以 auth.mfa.encryptionKeySecret 指向的 SecretsProvider 密鑰加密保存，必須配置，否則重啟後無法解密。
+func newTOTPAuthenticator(ctx context.Context, configProvider contracts.ConfigProvider, secrets contracts.SecretsProvider,
+	dbClient *database.SQLClientProvider, logger contracts.Logger) (*auth.TOTPAuthenticator, error) {
+	name := configProvider.GetString("auth.mfa.encryptionKeySecret")
+	if name == "" {
+		return nil, fmt.Errorf("auth.mfa.enabled requires auth.mfa.encryptionKeySecret")
+	}
+	if secrets == nil {
+		return nil, fmt.Errorf("auth.mfa.encryptionKeySecret requires a secrets provider")
+	}
+	encryptionKey, err := secrets.GetSecret(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MFA encryption key %s: %w", name, err)
+	}
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// requiredRoles 是列表，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Auth struct {
+			MFA auth.MFAConfig
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode MFA config: %w", err)
+	}
+	mfa, err := auth.NewTOTPAuthenticator(root.Auth.MFA, mysql.NewUserMFARepository(db, dbClient.Dialect(), logger),
+		database.NewSQLTransactionManager(db, nil, logger), []byte(encryptionKey), logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TOTP authenticator: %w", err)
+	}
+	return mfa, nil
+}
+
+// NewLoginThrottleFromConfig 根據 auth.bruteForce 區塊創建登錄限流器，未啟用時返回 nil。
+// 失敗計數保存在 cacheProvider 中；auditLog 記錄鎖定與解鎖，notifier 發送鎖定通知，
+// accounts 查找被鎖定賬戶的郵件地址以通知本人，三者都可以為 nil。
+func NewLoginThrottleFromConfig(configProvider contracts.ConfigProvider, cacheProvider contracts.CacheProvider,
+	auditLog contracts.AuditLogProvider, notifier auth.AccountNotifier, accounts auth.LockoutAccountLookup,
+	logger contracts.Logger) (*auth.LoginThrottle, error) {
+	if !configProvider.GetBool("auth.bruteForce.enabled") {
+		return nil, nil
+	}
+
+	// notifyRecipients 是列表，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Auth struct {
+			BruteForce auth.LoginThrottleConfig
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode brute force config: %w", err)
+	}
+	throttle, err := auth.NewLoginThrottle(root.Auth.BruteForce, cacheProvider, auditLog, notifier, accounts, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login throttle: %w", err)
+	}
+	return throttle, nil
+}
+
+// NewAuthMiddlewareFromConfig 根據 auth.middleware 區塊創建 API 認證中介層，未啟用時返回 nil。
+// 啟用時必須配置 auth.provider，避免在沒有認證的情況下暴露 API。
+func NewAuthMiddlewareFromConfig(configProvider contracts.ConfigProvider, authProvider contracts.AuthProvider,
+	apiKeys http_middleware.APIKeyResolver, sessions http_middleware.SessionResolver,
+	logger contracts.Logger) (*http_middleware.AuthMiddlewarePlugin, error) {
+	if !configProvider.GetBool("auth.middleware.enabled") {
+		return nil, nil
+	}
+	if authProvider == nil {
+		return nil, fmt.Errorf("auth.middleware.enabled requires auth.provider")
+	}
+
+	// publicRoutes 與 permissions 是列表，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Auth struct {
+			Middleware http_middleware.AuthMiddlewareConfig
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode auth middleware config: %w", err)
+	}
+	middleware, err := http_middleware.NewAuthMiddlewarePlugin(root.Auth.Middleware, authProvider, apiKeys, sessions, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth middleware: %w", err)
+	}
+	return middleware, nil
+}
+
+// NewSessionManagerFromConfig 根據 auth.session 區塊創建會話存儲、簽名會話管理器與過期數據清理器。
+// store 為 "sql" 時需要數據庫；未配置 signingKeySecret 時使用隨機簽名密鑰，重啟後會話失效。
+func NewSessionManagerFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	secrets contracts.SecretsProvider, logger contracts.Logger) (*storage.SessionManager, *storage.Sweeper, error) {
+	config := storage.Config{
+		IdleTimeout:     configProvider.GetString("auth.session.idleTimeout"),
+		AbsoluteTimeout: configProvider.GetString("auth.session.absoluteTimeout"),
+		CSRFTokenTTL:    configProvider.GetString("auth.session.csrfTokenTTL"),
+	}
+
+	var store contracts.AuthStorageProvider
+	switch kind := configProvider.GetString("auth.session.store"); kind {
+	case "", "memory":
+		memoryStore, err := storage.NewMemoryAuthStorageProvider(config, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create memory session store: %w", err)
+		}
+		store = memoryStore
+	case "sql":
+		if dbClient == nil {
+			return nil, nil, fmt.Errorf("auth.session.store sql requires a database")
+		}
+		db, err := dbClient.GetDB(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.Dialect = dbClient.Dialect()
+		sqlStore, err := storage.NewSQLAuthStorageProvider(db, config, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create sql session store: %w", err)
+		}
+		store = sqlStore
+	default:
+		return nil, nil, fmt.Errorf("unsupported auth.session.store %q", kind)
+	}
+
+	var signingKey []byte
+	if name := configProvider.GetString("auth.session.signingKeySecret"); name != "" {
+		if secrets == nil {
+			return nil, nil, fmt.Errorf("auth.session.signingKeySecret requires a secrets provider")
+		}
+		secret, err := secrets.GetSecret(ctx, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read session signing key %s: %w", name, err)
+		}
+		signingKey = []byte(secret)
+	}
+	sessions, err := storage.NewSessionManager(store, signingKey, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	sweeper, err := storage.NewSweeper(store, configProvider.GetString("auth.session.sweepInterval"), logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sessions, sweeper, nil
+}
+
+// NewOIDCLoginFromConfig 根據 auth.oidc 區塊創建 UI 登錄使用的 OIDC 客戶端與會話刷新器，未啟用時返回 nil。
+// 刷新令牌保存在會話管理器的存儲中；客戶端密鑰從 secrets 讀取，未配置時作為公開客戶端只依靠 PKCE。
+func NewOIDCLoginFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, sessions *storage.SessionManager,
+	secrets contracts.SecretsProvider, logger contracts.Logger) (*auth.OIDCClient, *auth.OIDCSessionRefresher, error) {
+	if !configProvider.GetBool("auth.oidc.enabled") {
+		return nil, nil, nil
+	}
+	if sessions == nil {
+		return nil, nil, fmt.Errorf("auth.oidc.enabled requires auth.provider for session storage")
+	}
+	// scopes 是列表，透過 Unmarshal 讀取
+	var root struct {
+		Auth struct {
+			OIDC struct {
+				Scopes []string
+			}
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode oidc config: %w", err)
+	}
+	config := auth.OIDCConfig{
+		Issuer:      configProvider.GetString("auth.oidc.issuer"),
+		ClientID:    configProvider.GetString("auth.oidc.clientID"),
+		RedirectURL: configProvider.GetString("auth.oidc.redirectURL"),
+		Scopes:      root.Auth.OIDC.Scopes,
+		Timeout:     configProvider.GetString("auth.oidc.timeout"),
+	}
+	if name := configProvider.GetString("auth.oidc.clientSecretKey"); name != "" {
+		if secrets == nil {
+			return nil, nil, fmt.Errorf("auth.oidc.clientSecretKey requires a secrets provider")
+		}
+		secret, err := secrets.GetSecret(ctx, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read oidc client secret %s: %w", name, err)
+		}
+		config.ClientSecret = secret
+	}
+	client, err := auth.NewOIDCClient(config, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create oidc client: %w", err)
+	}
+	return client, auth.NewOIDCSessionRefresher(client, sessions, logger), nil
+}
+
+// NewCSRFMiddlewareFromConfig 根據 auth.csrf 區塊創建 CSRF 中介層，未啟用或沒有會話管理器時返回 nil。
+// 會話 cookie、API 金鑰請求頭與 cookie 的 Secure 屬性沿用 auth.middleware 與 auth.session 的配置。
+func NewCSRFMiddlewareFromConfig(configProvider contracts.ConfigProvider, sessions *storage.SessionManager,
+	logger contracts.Logger) (*http_middleware.CSRFMiddlewarePlugin, error) {
+	if !configProvider.GetBool("auth.csrf.enabled") || sessions == nil {
+		return nil, nil
+	}
+
+	// exemptRoutes 是列表，透過 Unmarshal 讀取整個區塊
+	var root struct {
+		Auth struct {
+			CSRF http_middleware.CSRFConfig
+		}
+	}
+	if err := configProvider.Unmarshal(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode csrf config: %w", err)
+	}
+	config := root.Auth.CSRF
+	config.SessionCookie = configProvider.GetString("auth.middleware.sessionCookie")
+	config.APIKeyHeader = configProvider.GetString("auth.middleware.apiKeyHeader")
+	config.CookieSecure = configProvider.GetBool("auth.session.cookieSecure")
+	middleware, err := http_middleware.NewCSRFMiddlewarePlugin(config, sessions, sessions.Store(), logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create csrf middleware: %w", err)
+	}
+	return middleware, nil
+}
@@ -0,0 +1,42 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"detectviz-platform/internal/application/backfill"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/plugins/detectors"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewBackfillServiceFromConfig 根據 app_config.yaml 的 backfill 區塊創建回放服務。
+// 回放使用不帶指標提供者的檢測器工廠，模擬執行不會計入線上檢測指標。
+func NewBackfillServiceFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	persistence *PersistenceComponents, registry contracts.PluginRegistryProvider, logger contracts.Logger) (*backfill.BackfillService, error) {
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := backfill.NewBackfillService(
+		mysql.NewBackfillJobRepository(db, dbClient.Dialect(), logger),
+		mysql.NewSimulationResultRepository(db, dbClient.Dialect(), logger),
+		persistence.RevisionRepo,
+		persistence.ScheduleRepo,
+		mysql.NewResultFeedbackRepository(db, dbClient.Dialect(), logger),
+		registry,
+		detectors.NewDetectorFactory(logger, nil),
+		backfill.BackfillConfig{
+			ChunkSize:  configProvider.GetString("backfill.chunkSize"),
+			MaxRange:   configProvider.GetString("backfill.maxRange"),
+			SampleSize: configProvider.GetInt("backfill.sampleSize"),
+		},
+		logger,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backfill service: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/infrastructure/platform/cache"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewCacheProviderFromConfig 根據 cache.provider 創建緩存，默認為進程內存。
+// 多實例部署時使用 "sql"，計數器等共享狀態保存在數據庫的 cache_entries 表中。
+func NewCacheProviderFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	logger contracts.Logger) (contracts.CacheProvider, error) {
+	switch kind := configProvider.GetString("cache.provider"); kind {
+	case "", "memory":
+		return cache.NewMemoryCacheProvider(logger), nil
+	case "sql":
+		if dbClient == nil {
+			return nil, fmt.Errorf("cache.provider sql requires a database")
+		}
+		db, err := dbClient.GetDB(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return cache.NewSQLCacheProvider(db, cache.SQLCacheConfig{Dialect: dbClient.Dialect()}, logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported cache.provider %q", kind)
+	}
+}
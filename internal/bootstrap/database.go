@@ -0,0 +1,131 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"detectviz-platform/internal/application/detector"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/infrastructure/platform/audit"
+	"detectviz-platform/internal/infrastructure/platform/outbox"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewDBClientFromConfig 根據 app_config.yaml 的 database 區塊創建資料庫客戶端。
+// 未配置 database.dsn 時返回 nil，表示平台以無資料庫模式運行。
+func NewDBClientFromConfig(configProvider contracts.ConfigProvider, logger contracts.Logger) (*database.SQLClientProvider, error) {
+	dsn := configProvider.GetString("database.dsn")
+	if dsn == "" {
+		return nil, nil
+	}
+
+	client, err := database.NewSQLClientProvider(database.SQLClientConfig{
+		Driver:          configProvider.GetString("database.driver"),
+		DSN:             dsn,
+		MaxOpenConns:    configProvider.GetInt("database.maxOpenConns"),
+		MaxIdleConns:    configProvider.GetInt("database.maxIdleConns"),
+		ConnMaxLifetime: configProvider.GetString("database.connMaxLifetime"),
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database client: %w", err)
+	}
+
+	return client.(*database.SQLClientProvider), nil
+}
+
+// NewMigrationRunnerFromConfig 根據 database.migrations 區塊創建遷移執行器，dryRun 為 true 時強制啟用演練模式
+func NewMigrationRunnerFromConfig(configProvider contracts.ConfigProvider, dialect string, dryRun bool, logger contracts.Logger) (*database.EmbeddedMigrationRunner, error) {
+	return database.NewEmbeddedMigrationRunner(database.MigrationRunnerConfig{
+		Dialect:     dialect,
+		TableName:   configProvider.GetString("database.migrations.tableName"),
+		LockName:    configProvider.GetString("database.migrations.lockName"),
+		LockTimeout: configProvider.GetString("database.migrations.lockTimeout"),
+		DryRun:      dryRun || configProvider.GetBool("database.migrations.dryRun"),
+	}, logger)
+}
+
+// RunStartupMigrations 在平台啟動時執行尚未套用的遷移（database.migrations.autoRun 為 true 時）
+func RunStartupMigrations(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider, logger contracts.Logger) error {
+	if !configProvider.GetBool("database.migrations.autoRun") {
+		logger.Info("已停用啟動時自動遷移")
+		return nil
+	}
+
+	runner, err := NewMigrationRunnerFromConfig(configProvider, dbClient.Dialect(), false, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create migration runner: %w", err)
+	}
+
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := runner.RunMigrations(ctx, db); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+// PersistenceComponents 聚合依賴資料庫的平台服務，它們共用同一個事務管理器
+type PersistenceComponents struct {
+	TxManager       contracts.TransactionManager
+	AuditLog        contracts.AuditLogProvider
+	Outbox          *outbox.SQLOutboxProvider
+	ScheduleRepo    interfaces.DetectorScheduleRepository
+	RevisionRepo    interfaces.DetectorConfigRevisionRepository
+	DetectorService *detector.DetectorService
+}
+
+// NewPersistenceComponents 創建事務管理器、審計日誌、發件箱與檢測器服務；bus 為 nil 時發件箱只寫入不投遞
+func NewPersistenceComponents(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	bus contracts.EventBusProvider, logger contracts.Logger) (*PersistenceComponents, error) {
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dialect := dbClient.Dialect()
+
+	txManager := database.NewSQLTransactionManager(db, nil, logger)
+	auditLog := audit.NewDBAuditLogProvider(db, audit.DBAuditLogConfig{Dialect: dialect}, logger)
+	outboxProvider, err := outbox.NewSQLOutboxProvider(db, outbox.SQLOutboxConfig{
+		Dialect:      dialect,
+		PollInterval: configProvider.GetString("database.outbox.pollInterval"),
+		BatchSize:    configProvider.GetInt("database.outbox.batchSize"),
+	}, bus, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outbox provider: %w", err)
+	}
+
+	scheduleRepo := mysql.NewDetectorScheduleRepository(db, dialect, logger)
+	revisionRepo := mysql.NewDetectorConfigRevisionRepository(db, dialect, logger)
+	detectorService := detector.NewDetectorService(mysql.NewDetectorRepository(db, dialect, logger), scheduleRepo,
+		revisionRepo, txManager, auditLog, outboxProvider, logger)
+
+	return &PersistenceComponents{
+		TxManager:       txManager,
+		AuditLog:        auditLog,
+		Outbox:          outboxProvider,
+		ScheduleRepo:    scheduleRepo,
+		RevisionRepo:    revisionRepo,
+		DetectorService: detectorService,
+	}, nil
+}
+
+// Register 將各組件註冊到插件註冊表
+func (c *PersistenceComponents) Register(registry contracts.PluginRegistryProvider) error {
+	components := map[string]any{
+		"transactionManager": c.TxManager,
+		"auditLogProvider":   c.AuditLog,
+		"outboxProvider":     c.Outbox,
+		"detectorService":    c.DetectorService,
+	}
+	for name, component := range components {
+		if err := registry.Register(name, component); err != nil {
+			return fmt.Errorf("failed to register %s: %w", name, err)
+		}
+	}
+	return nil
+}
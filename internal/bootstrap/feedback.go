@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"context"
+
+	"detectviz-platform/internal/application/feedback"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewFeedbackService 創建分析結果反饋服務，標記的結果由告警管理器保存；metrics 可為 nil
+func NewFeedbackService(ctx context.Context, dbClient *database.SQLClientProvider, logger contracts.Logger,
+	metrics contracts.MetricsProvider) (*feedback.FeedbackService, error) {
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return feedback.NewFeedbackService(
+		mysql.NewAnalysisResultRepository(db, dbClient.Dialect(), logger),
+		mysql.NewResultFeedbackRepository(db, dbClient.Dialect(), logger),
+		logger,
+		metrics,
+	), nil
+}
@@ -0,0 +1,64 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"detectviz-platform/internal/application/pipeline"
+	"detectviz-platform/internal/plugins/detectors"
+	"detectviz-platform/internal/plugins/transforms"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// RegisterTransformPlugins 將內建的轉換插件註冊到插件註冊表，供管線的 transform 階段使用。
+func RegisterTransformPlugins(registry contracts.PluginRegistryProvider, logger contracts.Logger) error {
+	for _, plugin := range []interface{ GetName() string }{
+		transforms.NewAggregateTransformPlugin(logger),
+		transforms.NewJSONParseTransformPlugin(logger),
+	} {
+		if err := registry.Register(plugin.GetName(), plugin); err != nil {
+			return fmt.Errorf("failed to register transform plugin %s: %w", plugin.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// NewPipelinesFromConfig 根據 app_config.yaml 的 pipelines 區塊載入並編譯所有管線定義。
+// 未配置 pipelines.directory 時返回 nil；任一定義無效時停止已編譯的管線並返回錯誤。
+func NewPipelinesFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, registry contracts.PluginRegistryProvider,
+	tracer contracts.TracingProvider, metrics contracts.MetricsProvider, logger contracts.Logger) ([]*pipeline.Pipeline, error) {
+	dir := configProvider.GetString("pipelines.directory")
+	if dir == "" {
+		logger.Info("pipelines.directory 未配置，跳過檢測管線載入")
+		return nil, nil
+	}
+	schemaPath := configProvider.GetString("pipelines.schemaPath")
+	if schemaPath == "" {
+		schemaPath = "schemas/pipeline.json"
+	}
+
+	loader, err := pipeline.NewDefinitionLoader(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := loader.LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := pipeline.NewEngine(registry, detectors.NewDetectorFactory(logger, metrics), tracer, metrics, logger)
+	compiled := make([]*pipeline.Pipeline, 0, len(defs))
+	for _, def := range defs {
+		p, err := engine.Compile(ctx, def)
+		if err != nil {
+			var errs []error
+			for _, c := range compiled {
+				errs = append(errs, c.Close(ctx))
+			}
+			return nil, errors.Join(append([]error{fmt.Errorf("failed to compile pipeline: %w", err)}, errs...)...)
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
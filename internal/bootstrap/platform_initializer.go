@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"detectviz-platform/internal/infrastructure/database"
 	"detectviz-platform/internal/infrastructure/platform/config"
 	"detectviz-platform/internal/infrastructure/platform/registry"
 	"detectviz-platform/internal/infrastructure/platform/telemetry"
@@ -16,6 +17,8 @@ type PlatformInitializer struct {
 	configProvider   contracts.ConfigProvider
 	loggerProvider   contracts.Logger
 	registryProvider contracts.PluginRegistryProvider
+	dbClient         *database.SQLClientProvider
+	persistence      *PersistenceComponents
 }
 
 // NewPlatformInitializer 創建新的平台初始化器
@@ -45,8 +48,33 @@ func NewPlatformInitializer() (*PlatformInitializer, error) {
 func (p *PlatformInitializer) Initialize(ctx context.Context) error {
 	p.loggerProvider.Info("開始初始化平台")
 
+	// 初始化數據庫連接並執行 Schema 遷移
+	dbClient, err := NewDBClientFromConfig(p.configProvider, p.loggerProvider)
+	if err != nil {
+		return err
+	}
+	if dbClient != nil {
+		if err := RunStartupMigrations(ctx, p.configProvider, dbClient, p.loggerProvider); err != nil {
+			return err
+		}
+		if err := p.registryProvider.Register("dbClient", dbClient); err != nil {
+			return fmt.Errorf("failed to register database client: %w", err)
+		}
+		persistence, err := NewPersistenceComponents(ctx, p.configProvider, dbClient, nil, p.loggerProvider)
+		if err != nil {
+			return err
+		}
+		if err := persistence.Register(p.registryProvider); err != nil {
+			return err
+		}
+		p.dbClient = dbClient
+		p.persistence = persistence
+	} else {
+		p.loggerProvider.Warn("未配置 database.dsn，跳過數據庫初始化")
+	}
+
 	// 這裡可以添加更多的初始化邏輯
-	// 例如: 數據庫連接、插件加載等
+	// 例如: 插件加載等
 
 	p.loggerProvider.Info("平台初始化完成")
 	return nil
@@ -62,6 +90,16 @@ func (p *PlatformInitializer) GetLogger() contracts.Logger {
 	return p.loggerProvider
 }
 
+// GetDBClient 返回數據庫客戶端，未配置數據庫時為 nil
+func (p *PlatformInitializer) GetDBClient() *database.SQLClientProvider {
+	return p.dbClient
+}
+
+// GetPersistence 返回依賴資料庫的平台服務，未配置數據庫時為 nil
+func (p *PlatformInitializer) GetPersistence() *PersistenceComponents {
+	return p.persistence
+}
+
 // GetRegistry 返回插件註冊表
 func (p *PlatformInitializer) GetRegistry() contracts.PluginRegistryProvider {
 	return p.registryProvider
@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"detectviz-platform/internal/application/scheduler"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewDetectionSchedulerFromConfig 根據 app_config.yaml 的 scheduler 區塊創建檢測排程器。
+// scheduler.enabled 為 false 時返回 nil；handler 與 metrics 可為 nil。
+func NewDetectionSchedulerFromConfig(configProvider contracts.ConfigProvider, repo interfaces.DetectorScheduleRepository,
+	registry contracts.PluginRegistryProvider, handler scheduler.ResultHandler, logger contracts.Logger,
+	metrics contracts.MetricsProvider) (*scheduler.DetectionScheduler, error) {
+	if !configProvider.GetBool("scheduler.enabled") {
+		return nil, nil
+	}
+
+	s, err := scheduler.NewDetectionScheduler(repo, registry, handler, scheduler.SchedulerConfig{
+		TickInterval:   configProvider.GetString("scheduler.tickInterval"),
+		RunTimeout:     configProvider.GetString("scheduler.runTimeout"),
+		DefaultWindow:  configProvider.GetString("scheduler.defaultWindow"),
+		MaxCatchUpRuns: configProvider.GetInt("scheduler.maxCatchUpRuns"),
+		StallThreshold: configProvider.GetString("scheduler.stallThreshold"),
+	}, logger, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detection scheduler: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,14 @@
+package bootstrap
+
+import (
+	"detectviz-platform/internal/infrastructure/platform/secrets"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewSecretsProviderFromConfig 根據 app_config.yaml 的 secrets 區塊創建秘密提供者
+func NewSecretsProviderFromConfig(configProvider contracts.ConfigProvider, logger contracts.Logger) contracts.SecretsProvider {
+	return secrets.NewEnvSecretsProvider(secrets.EnvSecretsConfig{
+		EnvPrefix: configProvider.GetString("secrets.envPrefix"),
+		Directory: configProvider.GetString("secrets.directory"),
+	}, logger)
+}
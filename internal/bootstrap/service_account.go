@@ -0,0 +1,50 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"detectviz-platform/internal/application/serviceaccount"
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/internal/repositories/mysql"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NewServiceAccountServiceFromConfig 根據 auth.apiKeys 區塊創建服務帳號服務，未啟用時返回 nil。
+// 配置了 hashKeySecret 時金鑰以從 secrets 讀取的密鑰做 HMAC-SHA256 雜湊，否則使用 bcrypt。
+func NewServiceAccountServiceFromConfig(ctx context.Context, configProvider contracts.ConfigProvider, dbClient *database.SQLClientProvider,
+	secrets contracts.SecretsProvider, logger contracts.Logger) (*serviceaccount.ServiceAccountService, error) {
+	if !configProvider.GetBool("auth.apiKeys.enabled") {
+		return nil, nil
+	}
+	db, err := dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hmacKey []byte
+	if name := configProvider.GetString("auth.apiKeys.hashKeySecret"); name != "" {
+		if secrets == nil {
+			return nil, fmt.Errorf("auth.apiKeys.hashKeySecret requires a secrets provider")
+		}
+		secret, err := secrets.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key hash secret %s: %w", name, err)
+		}
+		hmacKey = []byte(secret)
+	}
+
+	config := serviceaccount.Config{
+		KeyPrefix:        configProvider.GetString("auth.apiKeys.keyPrefix"),
+		DefaultTTL:       configProvider.GetString("auth.apiKeys.defaultTTL"),
+		MaxTTL:           configProvider.GetString("auth.apiKeys.maxTTL"),
+		LastUsedInterval: configProvider.GetString("auth.apiKeys.lastUsedInterval"),
+	}
+	service, err := serviceaccount.NewServiceAccountService(mysql.NewServiceAccountRepository(db, dbClient.Dialect(), logger), config, hmacKey,
+		hasher.NewDefaultBcryptPasswordHasher(), logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service account service: %w", err)
+	}
+	return service, nil
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// 支援的 SQL 方言
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+)
+
+// DialectForDriver 根據 database/sql 驅動名稱推斷 SQL 方言
+func DialectForDriver(driver string) string {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql", "pgx", "pq":
+		return DialectPostgres
+	default:
+		return DialectMySQL
+	}
+}
+
+// sqlDriverName 返回 sql.Open 使用的已註冊驅動名稱，PostgreSQL 的各個別名都使用 pgx 驅動
+func sqlDriverName(driver string) string {
+	if DialectForDriver(driver) == DialectPostgres {
+		return "pgx"
+	}
+	return strings.ToLower(driver)
+}
+
+// dialect 封裝不同資料庫之間的語法差異
+type dialect interface {
+	// name 返回方言名稱，同時也是遷移文件所在的子目錄
+	name() string
+	// placeholder 返回第 n 個（從 1 開始）綁定參數的佔位符
+	placeholder(n int) string
+	// createMigrationsTableSQL 返回建立遷移記錄表的語句
+	createMigrationsTableSQL(table string) string
+	// tableExistsSQL 返回查詢表是否存在的語句，結果為計數
+	tableExistsSQL() string
+	// columnExistsSQL 返回查詢表中欄位是否存在的語句，參數為表名與欄位名，結果為計數
+	columnExistsSQL() string
+	// transactionalDDL 表示 DDL 能否在事務中回滾；MySQL 的 DDL 會隱式提交
+	transactionalDDL() bool
+	// acquireLock 在指定連接上取得諮詢鎖，超時返回錯誤
+	acquireLock(ctx context.Context, conn *sql.Conn, lockName string, timeout time.Duration) error
+	// releaseLock 釋放指定連接上的諮詢鎖
+	releaseLock(ctx context.Context, conn *sql.Conn, lockName string) error
+}
+
+// newDialect 根據名稱創建方言實例
+func newDialect(name string) (dialect, error) {
+	switch name {
+	case DialectMySQL:
+		return mysqlDialect{}, nil
+	case DialectPostgres:
+		return postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect: %s", name)
+	}
+}
+
+// mysqlDialect 實現 MySQL 方言
+type mysqlDialect struct{}
+
+func (mysqlDialect) name() string { return DialectMySQL }
+
+func (mysqlDialect) placeholder(int) string { return "?" }
+
+func (mysqlDialect) createMigrationsTableSQL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at DATETIME(6) NOT NULL,
+	execution_ms BIGINT NOT NULL DEFAULT 0,
+	dirty BOOLEAN NOT NULL DEFAULT FALSE
+)`, table)
+}
+
+func (mysqlDialect) tableExistsSQL() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+}
+
+func (mysqlDialect) columnExistsSQL() string {
+	return `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`
+}
+
+func (mysqlDialect) transactionalDDL() bool { return false }
+
+func (mysqlDialect) acquireLock(ctx context.Context, conn *sql.Conn, lockName string, timeout time.Duration) error {
+	// GET_LOCK 成功返回 1，超時返回 0，錯誤返回 NULL
+	var acquired sql.NullInt64
+	seconds := int(timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName, seconds).Scan(&acquired); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("timed out waiting for migration lock %q after %s", lockName, timeout)
+	}
+	return nil
+}
+
+func (mysqlDialect) releaseLock(ctx context.Context, conn *sql.Conn, lockName string) error {
+	_, err := conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, lockName)
+	return err
+}
+
+// postgresDialect 實現 PostgreSQL 方言
+type postgresDialect struct{}
+
+func (postgresDialect) name() string { return DialectPostgres }
+
+func (postgresDialect) placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) createMigrationsTableSQL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL,
+	execution_ms BIGINT NOT NULL DEFAULT 0,
+	dirty BOOLEAN NOT NULL DEFAULT FALSE
+)`, table)
+}
+
+func (postgresDialect) tableExistsSQL() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`
+}
+
+func (postgresDialect) columnExistsSQL() string {
+	return `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`
+}
+
+func (postgresDialect) transactionalDDL() bool { return true }
+
+func (postgresDialect) acquireLock(ctx context.Context, conn *sql.Conn, lockName string, timeout time.Duration) error {
+	// pg_advisory_lock 會無限等待，因此以 pg_try_advisory_lock 輪詢直到超時
+	key := advisoryLockKey(lockName)
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for migration lock %q after %s", lockName, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func (postgresDialect) releaseLock(ctx context.Context, conn *sql.Conn, lockName string) error {
+	_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, advisoryLockKey(lockName))
+	return err
+}
+
+// advisoryLockKey 將鎖名稱轉換為 PostgreSQL 諮詢鎖所需的 int64 鍵
+func advisoryLockKey(lockName string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(lockName))
+	return int64(h.Sum64())
+}
+
+// Rebind 將使用 `?` 佔位符的查詢改寫為指定方言的佔位符形式（PostgreSQL 為 $1, $2...）
+func Rebind(dialectName, query string) string {
+	if dialectName != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// OnConflictUpdate 返回插入語句的衝突更新子句：MySQL 為 ON DUPLICATE KEY UPDATE，
+// PostgreSQL 為 ON CONFLICT (conflictKey) DO UPDATE SET。columns 是衝突時以新值覆蓋的欄位。
+func OnConflictUpdate(dialectName, conflictKey string, columns ...string) string {
+	return OnConflictPrefix(dialectName, conflictKey) + ConflictAssignments(dialectName, columns...)
+}
+
+// OnConflictPrefix 返回衝突更新子句的開頭，後接以逗號分隔的賦值
+func OnConflictPrefix(dialectName, conflictKey string) string {
+	if dialectName == DialectPostgres {
+		return "ON CONFLICT (" + conflictKey + ") DO UPDATE SET "
+	}
+	return "ON DUPLICATE KEY UPDATE "
+}
+
+// ConflictAssignments 返回以新值覆蓋 columns 的賦值列表
+func ConflictAssignments(dialectName string, columns ...string) string {
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = column + " = " + Excluded(dialectName, column)
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// Excluded 返回衝突更新子句中引用插入值的表達式：MySQL 為 VALUES(column)，PostgreSQL 為 EXCLUDED.column
+func Excluded(dialectName, column string) string {
+	if dialectName == DialectPostgres {
+		return "EXCLUDED." + column
+	}
+	return "VALUES(" + column + ")"
+}
+
+// rebindExecutor 在執行前將 `?` 佔位符改寫為方言的形式
+type rebindExecutor struct {
+	exec    Executor
+	dialect string
+}
+
+// RebindExecutor 包裝 exec，使以 `?` 佔位符書寫的語句在 PostgreSQL 上同樣可用
+func RebindExecutor(exec Executor, dialectName string) Executor {
+	if dialectName != DialectPostgres {
+		return exec
+	}
+	return rebindExecutor{exec: exec, dialect: dialectName}
+}
+
+func (e rebindExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.exec.ExecContext(ctx, Rebind(e.dialect, query), args...)
+}
+
+func (e rebindExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return e.exec.QueryContext(ctx, Rebind(e.dialect, query), args...)
+}
+
+func (e rebindExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return e.exec.QueryRowContext(ctx, Rebind(e.dialect, query), args...)
+}
@@ -0,0 +1,577 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// embeddedMigrations 內嵌所有方言的遷移文件，目錄結構為 migrations/<dialect>/<version>_<name>.<up|down>.sql
+//
+//go:embed migrations
+var embeddedMigrations embed.FS
+
+// migrationFilePattern 匹配遷移文件名，例如 0001_create_users_table.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 表示一個版本化的 Schema 遷移
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // UpSQL 的 SHA-256，用於偵測已套用遷移被修改
+}
+
+// MigrationStatus 描述單個遷移的套用狀態
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Checksum  string
+	Modified  bool // 已套用但文件內容與記錄的校驗和不一致
+	Dirty     bool // 上次執行中途失敗，Schema 可能只套用了一部分
+}
+
+// appliedMigration 是 schema_migrations 表中的一條記錄
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+	Dirty     bool
+}
+
+// MigrationRunnerConfig 定義遷移執行器的配置
+type MigrationRunnerConfig struct {
+	Dialect     string `yaml:"dialect" json:"dialect"`         // SQL 方言: mysql, postgres
+	TableName   string `yaml:"tableName" json:"tableName"`     // 遷移記錄表名，預設 schema_migrations
+	LockName    string `yaml:"lockName" json:"lockName"`       // 諮詢鎖名稱，多實例共用同一名稱以互斥
+	LockTimeout string `yaml:"lockTimeout" json:"lockTimeout"` // 等待諮詢鎖的最長時間，例如 "60s"
+	DryRun      bool   `yaml:"dryRun" json:"dryRun"`           // 僅輸出待執行的遷移而不實際執行
+}
+
+// EmbeddedMigrationRunner 實現了 contracts.MigrationRunner 介面。
+// 職責: 以內嵌的 SQL 文件按版本順序執行 Schema 遷移，記錄校驗和並以諮詢鎖避免多實例競爭。
+// 測試說明: 單元測試驗證遷移文件的載入、排序、語句切分與執行計劃的計算。
+type EmbeddedMigrationRunner struct {
+	config      MigrationRunnerConfig
+	dialect     dialect
+	migrations  []Migration
+	lockTimeout time.Duration
+	logger      contracts.Logger
+}
+
+// NewEmbeddedMigrationRunner 使用內嵌的遷移文件創建遷移執行器
+func NewEmbeddedMigrationRunner(config MigrationRunnerConfig, logger contracts.Logger) (*EmbeddedMigrationRunner, error) {
+	return NewMigrationRunnerFromFS(embeddedMigrations, "migrations", config, logger)
+}
+
+// NewMigrationRunnerFromFS 從任意文件系統創建遷移執行器，root 下需包含以方言命名的子目錄
+func NewMigrationRunnerFromFS(fsys fs.FS, root string, config MigrationRunnerConfig, logger contracts.Logger) (*EmbeddedMigrationRunner, error) {
+	if config.Dialect == "" {
+		config.Dialect = DialectMySQL
+	}
+	if config.TableName == "" {
+		config.TableName = "schema_migrations"
+	}
+	if config.LockName == "" {
+		config.LockName = "detectviz_schema_migrations"
+	}
+
+	lockTimeout := 60 * time.Second
+	if config.LockTimeout != "" {
+		t, err := time.ParseDuration(config.LockTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lockTimeout %q: %w", config.LockTimeout, err)
+		}
+		lockTimeout = t
+	}
+
+	d, err := newDialect(config.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(fsys, path.Join(root, d.name()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmbeddedMigrationRunner{
+		config:      config,
+		dialect:     d,
+		migrations:  migrations,
+		lockTimeout: lockTimeout,
+		logger:      logger,
+	}, nil
+}
+
+// GetName 返回遷移執行器的名稱
+func (r *EmbeddedMigrationRunner) GetName() string {
+	return "embedded_migration_runner"
+}
+
+// Migrations 返回已載入的遷移列表（按版本排序）
+func (r *EmbeddedMigrationRunner) Migrations() []Migration {
+	return append([]Migration(nil), r.migrations...)
+}
+
+// RunMigrations 執行所有尚未套用的遷移。DryRun 模式下只記錄將要執行的語句。
+func (r *EmbeddedMigrationRunner) RunMigrations(ctx context.Context, db *sql.DB) error {
+	return r.withLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := r.loadApplied(ctx, conn, !r.config.DryRun)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		pending, err := planMigrations(r.migrations, applied)
+		if err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			r.logger.Info("資料庫 Schema 已是最新版本", "dialect", r.dialect.name())
+			return nil
+		}
+
+		for _, m := range pending {
+			if r.config.DryRun {
+				r.logger.Info("[dry-run] 將套用遷移", "version", m.Version, "name", m.Name)
+				for _, stmt := range splitStatements(m.UpSQL) {
+					r.logger.Info("[dry-run] " + stmt)
+				}
+				continue
+			}
+			if err := r.apply(ctx, conn, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback 依序回滾最近套用的 steps 個遷移
+func (r *EmbeddedMigrationRunner) Rollback(ctx context.Context, db *sql.DB, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("rollback steps must be positive")
+	}
+
+	return r.withLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := r.loadApplied(ctx, conn, false)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		byVersion := make(map[int64]Migration, len(r.migrations))
+		for _, m := range r.migrations {
+			byVersion[m.Version] = m
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		if steps > len(versions) {
+			steps = len(versions)
+		}
+		for _, v := range versions[:steps] {
+			m, ok := byVersion[v]
+			if !ok {
+				return fmt.Errorf("cannot roll back migration %d: migration file not found", v)
+			}
+			if strings.TrimSpace(m.DownSQL) == "" {
+				return fmt.Errorf("cannot roll back migration %d_%s: no down migration", m.Version, m.Name)
+			}
+			if r.config.DryRun {
+				r.logger.Info("[dry-run] 將回滾遷移", "version", m.Version, "name", m.Name)
+				for _, stmt := range splitStatements(m.DownSQL) {
+					r.logger.Info("[dry-run] " + stmt)
+				}
+				continue
+			}
+			if err := r.revert(ctx, conn, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status 返回所有已知遷移的套用狀態
+func (r *EmbeddedMigrationRunner) Status(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain database connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := r.loadApplied(ctx, conn, false)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name, Checksum: m.Checksum}
+		if a, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = a.AppliedAt
+			status.Modified = a.Checksum != m.Checksum
+			status.Dirty = a.Dirty
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Resolve 在運維人員手動修復 Schema 後清除遷移的 dirty 標記。
+// applied 為 true 表示該遷移已完整套用，保留記錄；否則表示已手動撤銷，刪除記錄以便重新執行。
+func (r *EmbeddedMigrationRunner) Resolve(ctx context.Context, db *sql.DB, version int64, applied bool) error {
+	return r.withLock(ctx, db, func(conn *sql.Conn) error {
+		records, err := r.loadApplied(ctx, conn, false)
+		if err != nil {
+			return err
+		}
+		a, ok := records[version]
+		if !ok || !a.Dirty {
+			return fmt.Errorf("migration %d is not marked dirty", version)
+		}
+		if r.config.DryRun {
+			r.logger.Info("[dry-run] 將清除遷移的 dirty 標記", "version", version, "applied", applied)
+			return nil
+		}
+
+		query := fmt.Sprintf(`DELETE FROM %s WHERE version = %s`, r.config.TableName, r.dialect.placeholder(1))
+		if applied {
+			query = fmt.Sprintf(`UPDATE %s SET dirty = FALSE WHERE version = %s`, r.config.TableName, r.dialect.placeholder(1))
+		}
+		if _, err := conn.ExecContext(ctx, query, version); err != nil {
+			return fmt.Errorf("failed to resolve migration %d: %w", version, err)
+		}
+		r.logger.Info("已清除遷移的 dirty 標記", "version", version, "name", a.Name, "applied", applied)
+		return nil
+	})
+}
+
+// withLock 在單一連接上取得諮詢鎖後執行 fn，確保多實例不會同時遷移
+func (r *EmbeddedMigrationRunner) withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain database connection: %w", err)
+	}
+	defer conn.Close()
+
+	r.logger.Debug("等待遷移諮詢鎖", "lock", r.config.LockName, "timeout", r.lockTimeout)
+	if err := r.dialect.acquireLock(ctx, conn, r.config.LockName, r.lockTimeout); err != nil {
+		return err
+	}
+	defer func() {
+		// 使用獨立的上下文，確保呼叫方取消後仍能釋放鎖
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.dialect.releaseLock(releaseCtx, conn, r.config.LockName); err != nil {
+			r.logger.Warn("釋放遷移諮詢鎖失敗", "lock", r.config.LockName, "error", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// loadApplied 讀取已套用的遷移記錄；create 為 true 時會在記錄表不存在時建立它
+func (r *EmbeddedMigrationRunner) loadApplied(ctx context.Context, conn *sql.Conn, create bool) (map[int64]appliedMigration, error) {
+	var count int
+	if err := conn.QueryRowContext(ctx, r.dialect.tableExistsSQL(), r.config.TableName).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+
+	applied := make(map[int64]appliedMigration)
+	if count == 0 {
+		if !create {
+			return applied, nil
+		}
+		if _, err := conn.ExecContext(ctx, r.dialect.createMigrationsTableSQL(r.config.TableName)); err != nil {
+			return nil, fmt.Errorf("failed to create migrations table: %w", err)
+		}
+		return applied, nil
+	}
+
+	// 較早建立的記錄表沒有 dirty 欄位，套用時補上；只讀操作把缺少欄位視為全部乾淨
+	if err := conn.QueryRowContext(ctx, r.dialect.columnExistsSQL(), r.config.TableName, "dirty").Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to check migrations table columns: %w", err)
+	}
+	dirtyColumn := "dirty"
+	if count == 0 {
+		if create {
+			alter := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN dirty BOOLEAN NOT NULL DEFAULT FALSE`, r.config.TableName)
+			if _, err := conn.ExecContext(ctx, alter); err != nil {
+				return nil, fmt.Errorf("failed to add dirty column to migrations table: %w", err)
+			}
+		} else {
+			dirtyColumn = "FALSE"
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf(`SELECT version, name, checksum, applied_at, %s FROM %s ORDER BY version`, dirtyColumn, r.config.TableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt, &a.Dirty); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[a.Version] = a
+	}
+	return applied, rows.Err()
+}
+
+// apply 在事務中執行一個遷移並寫入記錄；DDL 無法回滾的方言改由 applyNonTransactional 處理
+func (r *EmbeddedMigrationRunner) apply(ctx context.Context, conn *sql.Conn, m Migration) error {
+	r.logger.Info("套用遷移", "version", m.Version, "name", m.Name)
+	if !r.dialect.transactionalDDL() {
+		return r.applyNonTransactional(ctx, conn, m)
+	}
+	start := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+
+	for _, stmt := range splitStatements(m.UpSQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (version, name, checksum, applied_at, execution_ms) VALUES (%s, %s, %s, %s, %s)`,
+		r.config.TableName,
+		r.dialect.placeholder(1), r.dialect.placeholder(2), r.dialect.placeholder(3), r.dialect.placeholder(4), r.dialect.placeholder(5))
+	elapsed := time.Since(start)
+	if _, err := tx.ExecContext(ctx, insert, m.Version, m.Name, m.Checksum, time.Now().UTC(), elapsed.Milliseconds()); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+
+	r.logger.Info("遷移套用完成", "version", m.Version, "name", m.Name, "duration", elapsed)
+	return nil
+}
+
+// applyNonTransactional 先寫入 dirty 記錄再逐條執行語句，全部成功後才清除標記。
+// 中途失敗時已執行的 DDL 無法回滾，記錄保持 dirty，之後的遷移會被拒絕直到運維人員以 Resolve 處理。
+func (r *EmbeddedMigrationRunner) applyNonTransactional(ctx context.Context, conn *sql.Conn, m Migration) error {
+	start := time.Now()
+
+	insert := fmt.Sprintf(`INSERT INTO %s (version, name, checksum, applied_at, execution_ms, dirty) VALUES (%s, %s, %s, %s, 0, TRUE)`,
+		r.config.TableName,
+		r.dialect.placeholder(1), r.dialect.placeholder(2), r.dialect.placeholder(3), r.dialect.placeholder(4))
+	if _, err := conn.ExecContext(ctx, insert, m.Version, m.Name, m.Checksum, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	for i, stmt := range splitStatements(m.UpSQL) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			r.logger.Error("遷移中途失敗，已標記為 dirty", "version", m.Version, "name", m.Name, "statement", i+1, "error", err)
+			return fmt.Errorf("migration %d_%s failed at statement %d and is marked dirty: %w", m.Version, m.Name, i+1, err)
+		}
+	}
+
+	elapsed := time.Since(start)
+	update := fmt.Sprintf(`UPDATE %s SET dirty = FALSE, applied_at = %s, execution_ms = %s WHERE version = %s`,
+		r.config.TableName, r.dialect.placeholder(1), r.dialect.placeholder(2), r.dialect.placeholder(3))
+	if _, err := conn.ExecContext(ctx, update, time.Now().UTC(), elapsed.Milliseconds(), m.Version); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	r.logger.Info("遷移套用完成", "version", m.Version, "name", m.Name, "duration", elapsed)
+	return nil
+}
+
+// revert 在事務中執行一個遷移的 down 腳本並刪除記錄
+func (r *EmbeddedMigrationRunner) revert(ctx context.Context, conn *sql.Conn, m Migration) error {
+	r.logger.Info("回滾遷移", "version", m.Version, "name", m.Name)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin rollback of %d: %w", m.Version, err)
+	}
+
+	for _, stmt := range splitStatements(m.DownSQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("rollback of %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+
+	del := fmt.Sprintf(`DELETE FROM %s WHERE version = %s`, r.config.TableName, r.dialect.placeholder(1))
+	if _, err := tx.ExecContext(ctx, del, m.Version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to remove migration record %d: %w", m.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+// checkDirty 在存在中途失敗的遷移時拒絕繼續，避免在只套用了一部分的 Schema 上執行後續遷移
+func checkDirty(applied map[int64]appliedMigration) error {
+	for _, a := range sortedApplied(applied) {
+		if a.Dirty {
+			return fmt.Errorf("migration %d_%s is dirty: a previous run failed partway; repair the schema by hand, then run `migrate resolve %d --applied` or `migrate resolve %d --rolled-back`",
+				a.Version, a.Name, a.Version, a.Version)
+		}
+	}
+	return nil
+}
+
+// sortedApplied 按版本升序返回已套用記錄
+func sortedApplied(applied map[int64]appliedMigration) []appliedMigration {
+	records := make([]appliedMigration, 0, len(applied))
+	for _, a := range applied {
+		records = append(records, a)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+	return records
+}
+
+// planMigrations 根據已套用記錄計算待執行的遷移，並校驗已套用遷移的校驗和
+func planMigrations(migrations []Migration, applied map[int64]appliedMigration) ([]Migration, error) {
+	var pending []Migration
+	for _, m := range migrations {
+		a, ok := applied[m.Version]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+		if a.Checksum != m.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for applied migration %d_%s: recorded %s, file %s",
+				m.Version, m.Name, a.Checksum, m.Checksum)
+		}
+	}
+	return pending, nil
+}
+
+// loadMigrations 讀取目錄下的所有遷移文件並按版本排序
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.UpSQL) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		m.Checksum = checksum(m.UpSQL)
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// checksum 計算遷移內容的 SHA-256 十六進位字串
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStatements 將遷移腳本切分為單獨的語句。
+// 語句以行尾的分號結束；以 "--" 開頭的註釋行會被忽略。
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSpace(current.String())
+			statements = append(statements, strings.TrimSpace(strings.TrimSuffix(stmt, ";")))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// 確保實現了 MigrationRunner 介面
+var _ contracts.MigrationRunner = (*EmbeddedMigrationRunner)(nil)
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// testLogger 丟棄所有日誌的測試用記錄器
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func TestEmbeddedMigrations_AllDialectsLoad(t *testing.T) {
+	for _, dialect := range []string{DialectMySQL, DialectPostgres} {
+		t.Run(dialect, func(t *testing.T) {
+			runner, err := NewEmbeddedMigrationRunner(MigrationRunnerConfig{Dialect: dialect}, &testLogger{})
+			if err != nil {
+				t.Fatalf("NewEmbeddedMigrationRunner() error = %v", err)
+			}
+
+			migrations := runner.Migrations()
+			if len(migrations) == 0 {
+				t.Fatal("expected embedded migrations")
+			}
+			if migrations[0].Version != 1 || migrations[0].Name != "create_users_table" {
+				t.Errorf("first migration = %d_%s, want 1_create_users_table", migrations[0].Version, migrations[0].Name)
+			}
+			for _, m := range migrations {
+				if m.DownSQL == "" {
+					t.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations_DialectsInSync(t *testing.T) {
+	mysqlRunner, err := NewEmbeddedMigrationRunner(MigrationRunnerConfig{Dialect: DialectMySQL}, &testLogger{})
+	if err != nil {
+		t.Fatalf("mysql runner: %v", err)
+	}
+	pgRunner, err := NewEmbeddedMigrationRunner(MigrationRunnerConfig{Dialect: DialectPostgres}, &testLogger{})
+	if err != nil {
+		t.Fatalf("postgres runner: %v", err)
+	}
+
+	mysqlMigrations := mysqlRunner.Migrations()
+	pgMigrations := pgRunner.Migrations()
+	if len(mysqlMigrations) != len(pgMigrations) {
+		t.Fatalf("mysql has %d migrations, postgres has %d", len(mysqlMigrations), len(pgMigrations))
+	}
+	for i := range mysqlMigrations {
+		if mysqlMigrations[i].Version != pgMigrations[i].Version || mysqlMigrations[i].Name != pgMigrations[i].Name {
+			t.Errorf("migration %d differs: mysql %d_%s, postgres %d_%s", i,
+				mysqlMigrations[i].Version, mysqlMigrations[i].Name, pgMigrations[i].Version, pgMigrations[i].Name)
+		}
+	}
+}
+
+func TestLoadMigrations_SortsAndPairs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/mysql/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"m/mysql/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"m/mysql/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+	}
+
+	runner, err := NewMigrationRunnerFromFS(fsys, "m", MigrationRunnerConfig{}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewMigrationRunnerFromFS() error = %v", err)
+	}
+
+	migrations := runner.Migrations()
+	if len(migrations) != 2 {
+		t.Fatalf("len(migrations) = %d, want 2", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Errorf("migrations not sorted by version: %d, %d", migrations[0].Version, migrations[1].Version)
+	}
+	if migrations[1].DownSQL != "DROP TABLE b;" {
+		t.Errorf("down script not paired: %q", migrations[1].DownSQL)
+	}
+	if migrations[0].Checksum != checksum("CREATE TABLE a (id INT);") {
+		t.Errorf("unexpected checksum %s", migrations[0].Checksum)
+	}
+}
+
+func TestLoadMigrations_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			name: "invalid file name",
+			fsys: fstest.MapFS{"m/mysql/create_users.sql": {Data: []byte("SELECT 1;")}},
+			want: "invalid migration file name",
+		},
+		{
+			name: "missing up script",
+			fsys: fstest.MapFS{"m/mysql/0001_first.down.sql": {Data: []byte("DROP TABLE a;")}},
+			want: "has no up script",
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"m/mysql/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+				"m/mysql/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			want: "conflicting names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMigrationRunnerFromFS(tt.fsys, "m", MigrationRunnerConfig{}, &testLogger{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMigrationRunner_InvalidConfig(t *testing.T) {
+	if _, err := NewEmbeddedMigrationRunner(MigrationRunnerConfig{Dialect: "oracle"}, &testLogger{}); err == nil {
+		t.Error("expected error for unsupported dialect")
+	}
+	if _, err := NewEmbeddedMigrationRunner(MigrationRunnerConfig{LockTimeout: "soon"}, &testLogger{}); err == nil {
+		t.Error("expected error for invalid lock timeout")
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "first", Checksum: "aaa"},
+		{Version: 2, Name: "second", Checksum: "bbb"},
+		{Version: 3, Name: "third", Checksum: "ccc"},
+	}
+
+	t.Run("pending only", func(t *testing.T) {
+		applied := map[int64]appliedMigration{1: {Version: 1, Checksum: "aaa"}}
+		pending, err := planMigrations(migrations, applied)
+		if err != nil {
+			t.Fatalf("planMigrations() error = %v", err)
+		}
+		if len(pending) != 2 || pending[0].Version != 2 || pending[1].Version != 3 {
+			t.Errorf("pending = %+v, want versions 2 and 3", pending)
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		applied := map[int64]appliedMigration{2: {Version: 2, Checksum: "changed"}}
+		if _, err := planMigrations(migrations, applied); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Errorf("error = %v, want checksum mismatch", err)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		applied := map[int64]appliedMigration{
+			1: {Checksum: "aaa"}, 2: {Checksum: "bbb"}, 3: {Checksum: "ccc"},
+		}
+		pending, err := planMigrations(migrations, applied)
+		if err != nil || len(pending) != 0 {
+			t.Errorf("pending = %v, err = %v; want none", pending, err)
+		}
+	})
+}
+
+func TestCheckDirty(t *testing.T) {
+	clean := map[int64]appliedMigration{1: {Version: 1, Name: "first"}, 2: {Version: 2, Name: "second"}}
+	if err := checkDirty(clean); err != nil {
+		t.Errorf("checkDirty(clean) = %v, want nil", err)
+	}
+
+	dirty := map[int64]appliedMigration{
+		1: {Version: 1, Name: "first"},
+		2: {Version: 2, Name: "second", Dirty: true},
+	}
+	err := checkDirty(dirty)
+	if err == nil || !strings.Contains(err.Error(), "2_second is dirty") || !strings.Contains(err.Error(), "migrate resolve 2") {
+		t.Errorf("checkDirty(dirty) = %v, want dirty error naming migration 2", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment line
+CREATE TABLE a (
+    id INT,
+    name VARCHAR(10)
+);
+
+CREATE INDEX idx_a ON a (name);
+INSERT INTO a VALUES (1, 'x')`
+
+	statements := splitStatements(script)
+	if len(statements) != 3 {
+		t.Fatalf("len(statements) = %d, want 3: %q", len(statements), statements)
+	}
+	if !strings.HasPrefix(statements[0], "CREATE TABLE a (") || strings.HasSuffix(statements[0], ";") {
+		t.Errorf("unexpected first statement %q", statements[0])
+	}
+	if statements[1] != "CREATE INDEX idx_a ON a (name)" {
+		t.Errorf("unexpected second statement %q", statements[1])
+	}
+	if statements[2] != "INSERT INTO a VALUES (1, 'x')" {
+		t.Errorf("unexpected trailing statement %q", statements[2])
+	}
+}
+
+func TestDialectForDriver(t *testing.T) {
+	tests := map[string]string{
+		"mysql":    DialectMySQL,
+		"pgx":      DialectPostgres,
+		"postgres": DialectPostgres,
+		"":         DialectMySQL,
+	}
+	for driver, want := range tests {
+		if got := DialectForDriver(driver); got != want {
+			t.Errorf("DialectForDriver(%q) = %q, want %q", driver, got, want)
+		}
+	}
+}
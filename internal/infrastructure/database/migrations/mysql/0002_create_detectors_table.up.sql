@@ -0,0 +1,11 @@
+-- 檢測器表，對應 internal/repositories/mysql/detector_repository.go
+CREATE TABLE IF NOT EXISTS detectors (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    owner_id VARCHAR(64) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_detectors_owner (owner_id),
+    KEY idx_detectors_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
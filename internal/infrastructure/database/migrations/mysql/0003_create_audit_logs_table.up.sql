@@ -0,0 +1,11 @@
+-- 審計日誌表，對應 internal/infrastructure/platform/audit/db_audit_log_provider.go
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    action VARCHAR(128) NOT NULL,
+    resource VARCHAR(255) NOT NULL,
+    metadata TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    KEY idx_audit_logs_resource (resource, created_at),
+    KEY idx_audit_logs_user (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+-- 事務性發件箱表，對應 internal/infrastructure/platform/outbox/sql_outbox_provider.go
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    published_at DATETIME(6) NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    KEY idx_outbox_events_pending (published_at, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
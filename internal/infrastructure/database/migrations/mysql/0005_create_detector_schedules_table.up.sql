@@ -0,0 +1,20 @@
+-- 檢測器排程表，對應 internal/repositories/mysql/detector_schedule_repository.go
+CREATE TABLE IF NOT EXISTS detector_schedules (
+    detector_id CHAR(36) NOT NULL PRIMARY KEY,
+    detector_plugin VARCHAR(255) NOT NULL,
+    detector_config TEXT NOT NULL,
+    spec VARCHAR(255) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    source_query TEXT NOT NULL,
+    window_ms BIGINT NOT NULL DEFAULT 0,
+    jitter_ms BIGINT NOT NULL DEFAULT 0,
+    max_concurrency INT NOT NULL DEFAULT 1,
+    missed_run_policy VARCHAR(16) NOT NULL DEFAULT 'skip',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at DATETIME(6) NULL,
+    last_run_at DATETIME(6) NULL,
+    last_status VARCHAR(16) NOT NULL DEFAULT '',
+    last_error TEXT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_detector_schedules_next_run (enabled, next_run_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
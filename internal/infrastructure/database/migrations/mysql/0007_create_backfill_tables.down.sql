@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS simulation_results;
+DROP TABLE IF EXISTS backfill_jobs;
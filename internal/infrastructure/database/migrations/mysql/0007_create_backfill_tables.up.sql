@@ -0,0 +1,31 @@
+-- 回放任務與模擬結果表，對應 internal/repositories/mysql/backfill_repository.go
+-- simulation_results 是與線上結果隔離的模擬命名空間，告警流程不會讀取
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    detector_id CHAR(36) NOT NULL,
+    revision INT NOT NULL,
+    baseline_revision INT NOT NULL DEFAULT 0,
+    range_start DATETIME(6) NOT NULL,
+    range_end DATETIME(6) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    processed_until DATETIME(6) NULL,
+    report TEXT NULL,
+    error TEXT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    started_at DATETIME(6) NULL,
+    finished_at DATETIME(6) NULL,
+    KEY idx_backfill_jobs_detector (detector_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS simulation_results (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    job_id CHAR(36) NOT NULL,
+    variant VARCHAR(16) NOT NULL,
+    detector_id CHAR(36) NOT NULL,
+    result_timestamp DATETIME(6) NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL,
+    KEY idx_simulation_results_job (job_id, variant, result_timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS alert_groups;
+DROP TABLE IF EXISTS alerts;
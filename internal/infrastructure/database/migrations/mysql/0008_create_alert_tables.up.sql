@@ -0,0 +1,31 @@
+-- 告警狀態與分組通知狀態表，對應 internal/repositories/mysql/alert_repository.go
+-- 兩張表共同保證告警管理器重啟後不會重新通知已發送過的告警
+CREATE TABLE IF NOT EXISTS alerts (
+    fingerprint CHAR(32) NOT NULL PRIMARY KEY,
+    detector_id CHAR(36) NOT NULL,
+    labels TEXT NOT NULL,
+    state VARCHAR(16) NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL,
+    analysis_result_id VARCHAR(64) NOT NULL DEFAULT '',
+    starts_at DATETIME(6) NOT NULL,
+    fired_at DATETIME(6) NULL,
+    last_seen_at DATETIME(6) NOT NULL,
+    resolved_at DATETIME(6) NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_alerts_state (state, starts_at),
+    KEY idx_alerts_detector (detector_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS alert_groups (
+    group_key VARCHAR(191) NOT NULL PRIMARY KEY,
+    receiver VARCHAR(128) NOT NULL,
+    labels TEXT NOT NULL,
+    members TEXT NOT NULL,
+    next_flush_at DATETIME(6) NOT NULL,
+    last_notified_at DATETIME(6) NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_alert_groups_next_flush (next_flush_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+ALTER TABLE alerts DROP COLUMN silenced_by;
+DROP TABLE IF EXISTS silences;
@@ -0,0 +1 @@
+ALTER TABLE alert_groups DROP COLUMN route_id;
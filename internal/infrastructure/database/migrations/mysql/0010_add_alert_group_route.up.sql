@@ -0,0 +1,3 @@
+-- 告警分組記錄產生它的路由節點，用於取得該路由的通知時間
+-- 既有分組的 route_id 為空字串，由根路由處理
+ALTER TABLE alert_groups ADD COLUMN route_id VARCHAR(64) NOT NULL DEFAULT '' AFTER group_key;
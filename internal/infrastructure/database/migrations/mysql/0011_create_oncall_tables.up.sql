@@ -0,0 +1,30 @@
+-- 值班表與升級策略，對應 internal/repositories/mysql/oncall_repository.go
+-- 輪值層、替班與升級層級以 JSON 保存
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    team VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    layers TEXT NOT NULL,
+    overrides TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_oncall_schedules_team (team)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+    levels TEXT NOT NULL,
+    repeat_count INT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    UNIQUE KEY uk_escalation_policies_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 告警的確認狀態與升級進度 (JSON)
+ALTER TABLE alerts ADD COLUMN acknowledged_at DATETIME(6) NULL;
+ALTER TABLE alerts ADD COLUMN acknowledged_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE alerts ADD COLUMN escalation TEXT NULL;
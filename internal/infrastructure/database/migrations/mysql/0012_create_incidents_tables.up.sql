@@ -0,0 +1,29 @@
+-- 事件單，對應 internal/repositories/mysql/incident_repository.go
+-- 成員告警、分析結果與時間線以 JSON 保存，incident_alerts 用於按告警指紋查找事件單
+CREATE TABLE IF NOT EXISTS incidents (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    title VARCHAR(512) NOT NULL,
+    state VARCHAR(32) NOT NULL,
+    severity VARCHAR(64) NOT NULL DEFAULT '',
+    assignee VARCHAR(255) NOT NULL DEFAULT '',
+    group_key VARCHAR(191) NOT NULL DEFAULT '',
+    alerts TEXT NOT NULL,
+    analysis_result_ids TEXT NOT NULL,
+    timeline MEDIUMTEXT NOT NULL,
+    acknowledged_at DATETIME(6) NULL,
+    acknowledged_by VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at DATETIME(6) NULL,
+    resolved_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_incidents_state_created (state, created_at),
+    KEY idx_incidents_group_key (group_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS incident_alerts (
+    incident_id CHAR(36) NOT NULL,
+    fingerprint CHAR(32) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (incident_id, fingerprint),
+    KEY idx_incident_alerts_fingerprint (fingerprint)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- 分析結果與反饋標籤，對應 internal/repositories/mysql/analysis_result_repository.go 與 result_feedback_repository.go
+-- analysis_results 保存告警管理器收到的異常結果；result_feedback 每個結果一行，複製檢測器 ID 與結果時間以便評估回放
+CREATE TABLE IF NOT EXISTS analysis_results (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    detector_id CHAR(36) NOT NULL,
+    result_timestamp DATETIME(6) NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL,
+    KEY idx_analysis_results_detector (detector_id, result_timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS result_feedback (
+    analysis_result_id CHAR(36) NOT NULL PRIMARY KEY,
+    detector_id CHAR(36) NOT NULL,
+    result_timestamp DATETIME(6) NOT NULL,
+    label VARCHAR(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    reason TEXT NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_result_feedback_detector (detector_id, result_timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
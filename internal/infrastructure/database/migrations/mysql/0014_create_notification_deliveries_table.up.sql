@@ -0,0 +1,24 @@
+-- 通知投遞記錄，對應 internal/repositories/mysql/notification_delivery_repository.go
+-- 每一次發往渠道與接收者的通知一行，作為審計記錄；status 為 pending 的記錄按 next_attempt_at 重試
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    receiver VARCHAR(255) NOT NULL,
+    plugin VARCHAR(255) NOT NULL,
+    recipient VARCHAR(255) NOT NULL DEFAULT '',
+    settings TEXT NOT NULL,
+    notification MEDIUMTEXT NOT NULL,
+    fingerprint CHAR(32) NOT NULL,
+    payload_hash CHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at DATETIME(6) NULL,
+    delivered_at DATETIME(6) NULL,
+    resend_of CHAR(36) NOT NULL DEFAULT '',
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_notification_deliveries_due (status, next_attempt_at),
+    KEY idx_notification_deliveries_fingerprint (fingerprint, created_at),
+    KEY idx_notification_deliveries_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
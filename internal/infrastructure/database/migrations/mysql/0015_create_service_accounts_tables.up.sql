@@ -0,0 +1,30 @@
+-- 服務帳號與 API 金鑰，對應 internal/repositories/mysql/service_account_repository.go
+-- 金鑰只保存公開的前綴與秘密部分的雜湊，明文只在簽發時返回一次
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    roles TEXT NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    UNIQUE KEY uk_service_accounts_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    service_account_id CHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(64) NOT NULL,
+    hash VARCHAR(255) NOT NULL,
+    roles TEXT NOT NULL,
+    expires_at DATETIME(6) NULL,
+    last_used_at DATETIME(6) NULL,
+    revoked_at DATETIME(6) NULL,
+    revoked_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    UNIQUE KEY uk_api_keys_prefix (prefix),
+    KEY idx_api_keys_account (service_account_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,29 @@
+-- 會話、令牌與 CSRF 令牌，對應 internal/infrastructure/platform/auth/storage/sql_store.go
+-- 會話以隨機 ID 標識 (cookie 中另帶 HMAC 簽名)，CSRF 令牌只保存 SHA-256 雜湊
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    data TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    absolute_expires_at DATETIME(6) NOT NULL,
+    KEY idx_auth_sessions_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS auth_tokens (
+    user_id VARCHAR(255) NOT NULL,
+    token_type VARCHAR(64) NOT NULL,
+    token TEXT NOT NULL,
+    expires_at DATETIME(6) NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (user_id, token_type),
+    KEY idx_auth_tokens_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS auth_csrf_tokens (
+    session_id VARCHAR(64) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (session_id, token_hash),
+    KEY idx_auth_csrf_tokens_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
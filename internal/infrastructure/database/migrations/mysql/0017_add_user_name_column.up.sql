@@ -0,0 +1,3 @@
+-- 本地認證提供者使用的用戶欄位，對應 internal/repositories/mysql/user_repository.go
+-- MySQL 的 DDL 會隱式提交，每個遷移只含一條 ALTER，失敗後可以安全重試
+ALTER TABLE users ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT '' AFTER id;
@@ -0,0 +1,2 @@
+-- 既有用戶沒有角色
+ALTER TABLE users ADD COLUMN roles VARCHAR(1024) NOT NULL DEFAULT '[]' AFTER password_hash;
@@ -0,0 +1,2 @@
+-- 郵箱驗證時間，NULL 表示尚未驗證
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME(6) NULL AFTER roles;
@@ -0,0 +1,9 @@
+-- 多實例共享的鍵值緩存，對應 internal/infrastructure/platform/cache/sql_cache_provider.go
+-- 值以 JSON 保存，expires_at 為 NULL 表示不過期
+CREATE TABLE IF NOT EXISTS cache_entries (
+    cache_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    value TEXT NOT NULL,
+    expires_at DATETIME(6) NULL,
+    updated_at DATETIME(6) NOT NULL,
+    KEY idx_cache_entries_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,19 @@
+-- 本地用戶的 TOTP 多因素認證，對應 internal/repositories/mysql/user_mfa_repository.go
+-- 密鑰以 SecretsProvider 中的加密密鑰加密保存，恢復碼只保存雜湊
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id CHAR(36) NOT NULL PRIMARY KEY,
+    encrypted_secret VARCHAR(255) NOT NULL,
+    confirmed_at DATETIME(6) NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS user_mfa_recovery_codes (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at DATETIME(6) NULL,
+    created_at DATETIME(6) NOT NULL,
+    KEY idx_user_mfa_recovery_codes_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- 檢測器表，對應 internal/repositories/mysql/detector_repository.go
+CREATE TABLE IF NOT EXISTS detectors (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    owner_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_detectors_owner ON detectors (owner_id);
+CREATE INDEX IF NOT EXISTS idx_detectors_created_at ON detectors (created_at);
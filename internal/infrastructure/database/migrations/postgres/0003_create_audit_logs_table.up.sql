@@ -0,0 +1,12 @@
+-- 審計日誌表，對應 internal/infrastructure/platform/audit/db_audit_log_provider.go
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(64) NOT NULL,
+    action VARCHAR(128) NOT NULL,
+    resource VARCHAR(255) NOT NULL,
+    metadata TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs (resource, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user ON audit_logs (user_id, created_at);
@@ -0,0 +1,12 @@
+-- 事務性發件箱表，對應 internal/infrastructure/platform/outbox/sql_outbox_provider.go
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (published_at, created_at);
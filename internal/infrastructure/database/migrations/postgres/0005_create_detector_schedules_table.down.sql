@@ -0,0 +1 @@
+DROP TABLE IF EXISTS detector_schedules;
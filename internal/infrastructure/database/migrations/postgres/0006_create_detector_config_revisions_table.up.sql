@@ -0,0 +1,12 @@
+-- 檢測器配置版本表，對應 internal/repositories/mysql/detector_config_revision_repository.go
+CREATE TABLE IF NOT EXISTS detector_config_revisions (
+    detector_id VARCHAR(36) NOT NULL,
+    revision INT NOT NULL,
+    plugin VARCHAR(255) NOT NULL,
+    config TEXT NOT NULL,
+    comment TEXT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (detector_id, revision)
+);
@@ -0,0 +1,33 @@
+-- 回放任務與模擬結果表，對應 internal/repositories/mysql/backfill_repository.go
+-- simulation_results 是與線上結果隔離的模擬命名空間，告警流程不會讀取
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    detector_id VARCHAR(36) NOT NULL,
+    revision INT NOT NULL,
+    baseline_revision INT NOT NULL DEFAULT 0,
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    processed_until TIMESTAMPTZ NULL,
+    report TEXT NULL,
+    error TEXT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NULL,
+    finished_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_detector ON backfill_jobs (detector_id, created_at);
+
+CREATE TABLE IF NOT EXISTS simulation_results (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    job_id VARCHAR(36) NOT NULL,
+    variant VARCHAR(16) NOT NULL,
+    detector_id VARCHAR(36) NOT NULL,
+    result_timestamp TIMESTAMPTZ NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_simulation_results_job ON simulation_results (job_id, variant, result_timestamp);
@@ -0,0 +1,33 @@
+-- 告警狀態與分組通知狀態表，對應 internal/repositories/mysql/alert_repository.go
+-- 兩張表共同保證告警管理器重啟後不會重新通知已發送過的告警
+CREATE TABLE IF NOT EXISTS alerts (
+    fingerprint VARCHAR(32) NOT NULL PRIMARY KEY,
+    detector_id VARCHAR(36) NOT NULL,
+    labels TEXT NOT NULL,
+    state VARCHAR(16) NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL,
+    analysis_result_id VARCHAR(64) NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    fired_at TIMESTAMPTZ NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_state ON alerts (state, starts_at);
+CREATE INDEX IF NOT EXISTS idx_alerts_detector ON alerts (detector_id);
+
+CREATE TABLE IF NOT EXISTS alert_groups (
+    group_key VARCHAR(191) NOT NULL PRIMARY KEY,
+    receiver VARCHAR(128) NOT NULL,
+    labels TEXT NOT NULL,
+    members TEXT NOT NULL,
+    next_flush_at TIMESTAMPTZ NOT NULL,
+    last_notified_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_groups_next_flush ON alert_groups (next_flush_at);
@@ -0,0 +1,2 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS silenced_by;
+DROP TABLE IF EXISTS silences;
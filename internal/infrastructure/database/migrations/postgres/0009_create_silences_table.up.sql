@@ -0,0 +1,19 @@
+-- 告警靜默表，對應 internal/repositories/mysql/silence_repository.go
+-- ends_at 為 NULL 表示長期有效的週期性維護窗口
+CREATE TABLE IF NOT EXISTS silences (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    matchers TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NULL,
+    schedule VARCHAR(255) NOT NULL DEFAULT '',
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    comment TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_silences_ends_at ON silences (ends_at);
+
+-- 記錄最近一次通知評估時抑制告警的靜默 ID (JSON 陣列)
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS silenced_by TEXT NULL;
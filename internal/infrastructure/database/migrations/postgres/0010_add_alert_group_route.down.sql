@@ -0,0 +1 @@
+ALTER TABLE alert_groups DROP COLUMN IF EXISTS route_id;
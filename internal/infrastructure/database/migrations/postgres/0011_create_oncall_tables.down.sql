@@ -0,0 +1,5 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS escalation;
+ALTER TABLE alerts DROP COLUMN IF EXISTS acknowledged_by;
+ALTER TABLE alerts DROP COLUMN IF EXISTS acknowledged_at;
+DROP TABLE IF EXISTS escalation_policies;
+DROP TABLE IF EXISTS oncall_schedules;
@@ -0,0 +1,30 @@
+-- 值班表與升級策略，對應 internal/repositories/mysql/oncall_repository.go
+-- 輪值層、替班與升級層級以 JSON 保存
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    team VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    layers TEXT NOT NULL,
+    overrides TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_schedules_team ON oncall_schedules (team);
+
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NULL,
+    levels TEXT NOT NULL,
+    repeat_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- 告警的確認狀態與升級進度 (JSON)
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ NULL;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS acknowledged_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS escalation TEXT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS incident_alerts;
+DROP TABLE IF EXISTS incidents;
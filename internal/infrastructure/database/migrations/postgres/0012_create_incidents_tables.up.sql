@@ -0,0 +1,31 @@
+-- 事件單，對應 internal/repositories/mysql/incident_repository.go
+-- 成員告警、分析結果與時間線以 JSON 保存，incident_alerts 用於按告警指紋查找事件單
+CREATE TABLE IF NOT EXISTS incidents (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    title VARCHAR(512) NOT NULL,
+    state VARCHAR(32) NOT NULL,
+    severity VARCHAR(64) NOT NULL DEFAULT '',
+    assignee VARCHAR(255) NOT NULL DEFAULT '',
+    group_key VARCHAR(191) NOT NULL DEFAULT '',
+    alerts TEXT NOT NULL,
+    analysis_result_ids TEXT NOT NULL,
+    timeline TEXT NOT NULL,
+    acknowledged_at TIMESTAMPTZ NULL,
+    acknowledged_by VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMPTZ NULL,
+    resolved_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_state_created ON incidents (state, created_at);
+CREATE INDEX IF NOT EXISTS idx_incidents_group_key ON incidents (group_key);
+
+CREATE TABLE IF NOT EXISTS incident_alerts (
+    incident_id VARCHAR(36) NOT NULL,
+    fingerprint CHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (incident_id, fingerprint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_alerts_fingerprint ON incident_alerts (fingerprint);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS result_feedback;
+DROP TABLE IF EXISTS analysis_results;
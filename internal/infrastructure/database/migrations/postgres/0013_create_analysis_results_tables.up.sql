@@ -0,0 +1,25 @@
+-- 分析結果與反饋標籤，對應 internal/repositories/mysql/analysis_result_repository.go 與 result_feedback_repository.go
+-- analysis_results 保存告警管理器收到的異常結果；result_feedback 每個結果一行，複製檢測器 ID 與結果時間以便評估回放
+CREATE TABLE IF NOT EXISTS analysis_results (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    detector_id VARCHAR(36) NOT NULL,
+    result_timestamp TIMESTAMPTZ NOT NULL,
+    severity VARCHAR(32) NOT NULL DEFAULT '',
+    summary TEXT NULL,
+    data TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_results_detector ON analysis_results (detector_id, result_timestamp);
+
+CREATE TABLE IF NOT EXISTS result_feedback (
+    analysis_result_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    detector_id VARCHAR(36) NOT NULL,
+    result_timestamp TIMESTAMPTZ NOT NULL,
+    label VARCHAR(32) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    reason TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_result_feedback_detector ON result_feedback (detector_id, result_timestamp);
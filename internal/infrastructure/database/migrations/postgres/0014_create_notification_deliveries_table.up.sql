@@ -0,0 +1,25 @@
+-- 通知投遞記錄，對應 internal/repositories/mysql/notification_delivery_repository.go
+-- 每一次發往渠道與接收者的通知一行，作為審計記錄；status 為 pending 的記錄按 next_attempt_at 重試
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    receiver VARCHAR(255) NOT NULL,
+    plugin VARCHAR(255) NOT NULL,
+    recipient VARCHAR(255) NOT NULL DEFAULT '',
+    settings TEXT NOT NULL,
+    notification TEXT NOT NULL,
+    fingerprint VARCHAR(32) NOT NULL,
+    payload_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at TIMESTAMPTZ NULL,
+    delivered_at TIMESTAMPTZ NULL,
+    resend_of VARCHAR(36) NOT NULL DEFAULT '',
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_fingerprint ON notification_deliveries (fingerprint, created_at);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created ON notification_deliveries (created_at);
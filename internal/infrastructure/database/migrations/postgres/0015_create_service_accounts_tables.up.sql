@@ -0,0 +1,29 @@
+-- 服務帳號與 API 金鑰，對應 internal/repositories/mysql/service_account_repository.go
+-- 金鑰只保存公開的前綴與秘密部分的雜湊，明文只在簽發時返回一次
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    roles TEXT NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    service_account_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(64) NOT NULL UNIQUE,
+    hash VARCHAR(255) NOT NULL,
+    roles TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NULL,
+    last_used_at TIMESTAMPTZ NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    revoked_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_account ON api_keys (service_account_id, created_at);
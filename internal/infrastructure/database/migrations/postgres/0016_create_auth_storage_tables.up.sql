@@ -0,0 +1,32 @@
+-- 會話、令牌與 CSRF 令牌，對應 internal/infrastructure/platform/auth/storage/sql_store.go
+-- 會話以隨機 ID 標識 (cookie 中另帶 HMAC 簽名)，CSRF 令牌只保存 SHA-256 雜湊
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    data TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    absolute_expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires ON auth_sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS auth_tokens (
+    user_id VARCHAR(255) NOT NULL,
+    token_type VARCHAR(64) NOT NULL,
+    token TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, token_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_expires ON auth_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS auth_csrf_tokens (
+    session_id VARCHAR(64) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (session_id, token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_csrf_tokens_expires ON auth_csrf_tokens (expires_at);
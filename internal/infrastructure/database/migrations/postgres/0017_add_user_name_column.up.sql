@@ -0,0 +1,2 @@
+-- 本地認證提供者使用的用戶欄位，對應 internal/repositories/mysql/user_repository.go
+ALTER TABLE users ADD COLUMN IF NOT EXISTS name VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 郵箱驗證時間，NULL 表示尚未驗證
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ NULL;
@@ -0,0 +1 @@
+-- 回填無法還原；欄位由 0019 的回滾一併移除
@@ -0,0 +1,2 @@
+-- 既有用戶視為已驗證郵箱
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
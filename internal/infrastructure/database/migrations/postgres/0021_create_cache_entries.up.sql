@@ -0,0 +1,10 @@
+-- 多實例共享的鍵值緩存，對應 internal/infrastructure/platform/cache/sql_cache_provider.go
+-- 值以 JSON 保存，expires_at 為 NULL 表示不過期
+CREATE TABLE IF NOT EXISTS cache_entries (
+    cache_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    value TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cache_entries_expires ON cache_entries (expires_at);
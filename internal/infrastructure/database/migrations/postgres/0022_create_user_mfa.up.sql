@@ -0,0 +1,20 @@
+-- 本地用戶的 TOTP 多因素認證，對應 internal/repositories/mysql/user_mfa_repository.go
+-- 密鑰以 SecretsProvider 中的加密密鑰加密保存，恢復碼只保存雜湊
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    encrypted_secret VARCHAR(255) NOT NULL,
+    confirmed_at TIMESTAMPTZ NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_mfa_recovery_codes (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mfa_recovery_codes_user ON user_mfa_recovery_codes (user_id);
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	// 註冊 MySQL 與 PostgreSQL (pgx) 驅動，使 sql.Open("mysql", ...) 與 sql.Open("pgx", ...) 可用
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SQLClientProvider 實現了 contracts.DBClientProvider 介面。
+// 職責: 基於 database/sql 管理資料庫連線池，並延遲建立連線直到第一次使用。
+// 測試說明: 連線建立依賴實際資料庫，單元測試僅驗證配置解析。
+type SQLClientProvider struct {
+	config SQLClientConfig
+	logger contracts.Logger
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// SQLClientConfig 定義資料庫客戶端的配置
+type SQLClientConfig struct {
+	Driver          string `yaml:"driver" json:"driver"`                   // 驅動名稱，"mysql" 或 "postgres" (別名 "postgresql"、"pgx"、"pq")
+	DSN             string `yaml:"dsn" json:"dsn"`                         // 資料庫連接字符串
+	MaxOpenConns    int    `yaml:"maxOpenConns" json:"maxOpenConns"`       // 最大開啟連接數
+	MaxIdleConns    int    `yaml:"maxIdleConns" json:"maxIdleConns"`       // 最大空閒連接數
+	ConnMaxLifetime string `yaml:"connMaxLifetime" json:"connMaxLifetime"` // 連接最大生命週期，例如 "1h"
+}
+
+// NewSQLClientProvider 創建新的資料庫客戶端提供者實例
+func NewSQLClientProvider(config SQLClientConfig, logger contracts.Logger) (contracts.DBClientProvider, error) {
+	if config.DSN == "" {
+		return nil, fmt.Errorf("database dsn is required")
+	}
+	if config.Driver == "" {
+		config.Driver = "mysql"
+	}
+	if config.MaxOpenConns <= 0 {
+		config.MaxOpenConns = 100
+	}
+	if config.MaxIdleConns < 0 {
+		config.MaxIdleConns = 10
+	}
+	if config.ConnMaxLifetime != "" {
+		if _, err := time.ParseDuration(config.ConnMaxLifetime); err != nil {
+			return nil, fmt.Errorf("invalid connMaxLifetime %q: %w", config.ConnMaxLifetime, err)
+		}
+	}
+
+	return &SQLClientProvider{
+		config: config,
+		logger: logger,
+	}, nil
+}
+
+// GetDB 獲取底層的 *sql.DB 連線實例，首次調用時建立連線池並驗證連通性
+func (p *SQLClientProvider) GetDB(ctx context.Context) (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db, nil
+	}
+
+	db, err := sql.Open(sqlDriverName(p.config.Driver), p.config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", p.config.Driver, err)
+	}
+
+	db.SetMaxOpenConns(p.config.MaxOpenConns)
+	db.SetMaxIdleConns(p.config.MaxIdleConns)
+	if p.config.ConnMaxLifetime != "" {
+		lifetime, _ := time.ParseDuration(p.config.ConnMaxLifetime)
+		db.SetConnMaxLifetime(lifetime)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", p.config.Driver, err)
+	}
+
+	p.logger.Info("資料庫連線池建立完成",
+		"driver", p.config.Driver,
+		"max_open_conns", p.config.MaxOpenConns,
+		"max_idle_conns", p.config.MaxIdleConns)
+
+	p.db = db
+	return p.db, nil
+}
+
+// Close 關閉連線池
+func (p *SQLClientProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db == nil {
+		return nil
+	}
+	err := p.db.Close()
+	p.db = nil
+	return err
+}
+
+// Dialect 返回配置的驅動所對應的 SQL 方言名稱
+func (p *SQLClientProvider) Dialect() string {
+	return DialectForDriver(p.config.Driver)
+}
+
+// GetName 返回數據庫客戶端提供者的名稱
+func (p *SQLClientProvider) GetName() string {
+	return "sql_client_provider"
+}
+
+// 確保實現了 DBClientProvider 介面
+var _ contracts.DBClientProvider = (*SQLClientProvider)(nil)
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// Executor 是 *sql.DB 與 *sql.Tx 共同的查詢介面。
+// Repository 透過 ExecutorFromContext 取得 Executor，從而自動加入 ctx 中已開啟的事務。
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txContextKey 是事務在 context 中的鍵
+type txContextKey struct{}
+
+// txState 記錄一個進行中的事務及其巢狀深度
+type txState struct {
+	tx    *sql.Tx
+	depth int
+}
+
+// ExecutorFromContext 返回 ctx 中進行中的事務；若不存在則返回 db 本身
+func ExecutorFromContext(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// TxFromContext 返回 ctx 中進行中的事務
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+	if !ok || state == nil {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// SQLTransactionManager 實現了 pkg/platform/contracts.TransactionManager 介面。
+// 職責: 以 context 傳遞 *sql.Tx 實現工作單元 (Unit of Work)，
+// 巢狀的 RunInTx 調用以 SAVEPOINT 實現，內層失敗只回滾到對應的保存點。
+type SQLTransactionManager struct {
+	db     *sql.DB
+	opts   *sql.TxOptions
+	logger contracts.Logger
+}
+
+// NewSQLTransactionManager 創建新的事務管理器，opts 可為 nil 以使用驅動預設的隔離級別
+func NewSQLTransactionManager(db *sql.DB, opts *sql.TxOptions, logger contracts.Logger) contracts.TransactionManager {
+	return &SQLTransactionManager{
+		db:     db,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// RunInTx 在事務中執行 fn。
+// fn 返回錯誤或發生 panic 時回滾，否則提交；ctx 中已有事務時改用保存點。
+func (m *SQLTransactionManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok && state != nil {
+		return m.runInSavepoint(ctx, state, fn)
+	}
+
+	tx, err := m.db.BeginTx(ctx, m.opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				m.logger.Error("panic 後回滾事務失敗", "error", rbErr)
+			}
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, &txState{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			m.logger.Error("回滾事務失敗", "error", rbErr)
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		m.logger.Debug("事務已回滾", "reason", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// runInSavepoint 在既有事務中以保存點執行 fn
+func (m *SQLTransactionManager) runInSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	state := &txState{tx: parent.tx, depth: parent.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", savepoint, err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
+				m.logger.Error("panic 後回滾保存點失敗", "savepoint", savepoint, "error", rbErr)
+			}
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, state)); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
+			m.logger.Error("回滾保存點失敗", "savepoint", savepoint, "error", rbErr)
+			return fmt.Errorf("%w (rollback to savepoint failed: %v)", err, rbErr)
+		}
+		m.logger.Debug("已回滾至保存點", "savepoint", savepoint, "reason", err)
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", savepoint, err)
+	}
+	return nil
+}
+
+// GetName 返回事務管理器的名稱
+func (m *SQLTransactionManager) GetName() string {
+	return "sql_transaction_manager"
+}
+
+// 確保實現了 TransactionManager 介面
+var _ contracts.TransactionManager = (*SQLTransactionManager)(nil)
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingDriver 是只記錄語句的 database/sql 驅動，用於驗證事務與保存點的調用順序
+type recordingDriver struct {
+	mu   sync.Mutex
+	logs map[string]*statementLog
+}
+
+type statementLog struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (l *statementLog) add(stmt string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements = append(l.statements, stmt)
+}
+
+func (l *statementLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.statements...)
+}
+
+var testDriver = &recordingDriver{logs: map[string]*statementLog{}}
+
+func init() {
+	sql.Register("recording", testDriver)
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &recordingConn{log: d.logs[name]}, nil
+}
+
+type recordingConn struct{ log *statementLog }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare not supported")
+}
+func (c *recordingConn) Close() error { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.log.add("BEGIN")
+	return &recordingTx{log: c.log}, nil
+}
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.log.add(query)
+	return driver.RowsAffected(1), nil
+}
+
+type recordingTx struct{ log *statementLog }
+
+func (t *recordingTx) Commit() error   { t.log.add("COMMIT"); return nil }
+func (t *recordingTx) Rollback() error { t.log.add("ROLLBACK"); return nil }
+
+// openRecordingDB 開啟一個獨立記錄語句的測試資料庫
+func openRecordingDB(t *testing.T) (*sql.DB, *statementLog) {
+	t.Helper()
+	log := &statementLog{}
+	testDriver.mu.Lock()
+	testDriver.logs[t.Name()] = log
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("recording", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, log
+}
+
+func TestSQLTransactionManager_RunInTx(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		fn      func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error
+		wantErr error
+		want    []string
+	}{
+		{
+			name: "commit on success",
+			fn: func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					_, err := ExecutorFromContext(ctx, db).ExecContext(ctx, "INSERT a")
+					return err
+				}
+			},
+			want: []string{"BEGIN", "INSERT a", "COMMIT"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					ExecutorFromContext(ctx, db).ExecContext(ctx, "INSERT a")
+					return errBoom
+				}
+			},
+			wantErr: errBoom,
+			want:    []string{"BEGIN", "INSERT a", "ROLLBACK"},
+		},
+		{
+			name: "nested success releases savepoint",
+			fn: func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					return m.RunInTx(ctx, func(ctx context.Context) error {
+						_, err := ExecutorFromContext(ctx, db).ExecContext(ctx, "INSERT b")
+						return err
+					})
+				}
+			},
+			want: []string{"BEGIN", "SAVEPOINT sp_1", "INSERT b", "RELEASE SAVEPOINT sp_1", "COMMIT"},
+		},
+		{
+			name: "nested failure rolls back to savepoint only",
+			fn: func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					ExecutorFromContext(ctx, db).ExecContext(ctx, "INSERT a")
+					if err := m.RunInTx(ctx, func(ctx context.Context) error {
+						ExecutorFromContext(ctx, db).ExecContext(ctx, "INSERT b")
+						return errBoom
+					}); !errors.Is(err, errBoom) {
+						return fmt.Errorf("unexpected nested error: %v", err)
+					}
+					return nil
+				}
+			},
+			want: []string{"BEGIN", "INSERT a", "SAVEPOINT sp_1", "INSERT b", "ROLLBACK TO SAVEPOINT sp_1", "COMMIT"},
+		},
+		{
+			name: "doubly nested savepoints",
+			fn: func(m *SQLTransactionManager, db *sql.DB) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					return m.RunInTx(ctx, func(ctx context.Context) error {
+						return m.RunInTx(ctx, func(ctx context.Context) error { return nil })
+					})
+				}
+			},
+			want: []string{"BEGIN", "SAVEPOINT sp_1", "SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_1", "COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, log := openRecordingDB(t)
+			m := NewSQLTransactionManager(db, nil, &testLogger{}).(*SQLTransactionManager)
+
+			err := m.RunInTx(context.Background(), tt.fn(m, db))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RunInTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := log.all(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLTransactionManager_RollbackOnPanic(t *testing.T) {
+	db, log := openRecordingDB(t)
+	m := NewSQLTransactionManager(db, nil, &testLogger{})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover() = %v, want boom", r)
+			}
+		}()
+		m.RunInTx(context.Background(), func(ctx context.Context) error { panic("boom") })
+	}()
+
+	if got, want := log.all(), []string{"BEGIN", "ROLLBACK"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestExecutorFromContext(t *testing.T) {
+	db, _ := openRecordingDB(t)
+	m := NewSQLTransactionManager(db, nil, &testLogger{})
+
+	if _, ok := ExecutorFromContext(context.Background(), db).(*sql.DB); !ok {
+		t.Error("expected *sql.DB outside of a transaction")
+	}
+	m.RunInTx(context.Background(), func(ctx context.Context) error {
+		if _, ok := ExecutorFromContext(ctx, db).(*sql.Tx); !ok {
+			t.Error("expected *sql.Tx inside a transaction")
+		}
+		return nil
+	})
+}
+
+func TestRebind(t *testing.T) {
+	query := "INSERT INTO t (a, b) VALUES (?, ?)"
+	if got := Rebind(DialectMySQL, query); got != query {
+		t.Errorf("Rebind(mysql) = %q", got)
+	}
+	if got, want := Rebind(DialectPostgres, query), "INSERT INTO t (a, b) VALUES ($1, $2)"; got != want {
+		t.Errorf("Rebind(postgres) = %q, want %q", got, want)
+	}
+}
+
+func TestOnConflictUpdate(t *testing.T) {
+	if got, want := OnConflictUpdate(DialectMySQL, "id", "name", "updated_at"),
+		"ON DUPLICATE KEY UPDATE name = VALUES(name), updated_at = VALUES(updated_at)"; got != want {
+		t.Errorf("OnConflictUpdate(mysql) = %q, want %q", got, want)
+	}
+	if got, want := OnConflictUpdate(DialectPostgres, "id", "name", "updated_at"),
+		"ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at"; got != want {
+		t.Errorf("OnConflictUpdate(postgres) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DBAuditLogProvider 實現了 pkg/platform/contracts.AuditLogProvider 介面。
+// 職責: 將審計日誌寫入 audit_logs 表；ctx 中存在事務時與業務變更一同提交或回滾。
+type DBAuditLogProvider struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+	now     func() time.Time
+}
+
+// DBAuditLogConfig 定義資料庫審計日誌提供者的配置
+type DBAuditLogConfig struct {
+	Dialect string `yaml:"dialect" json:"dialect"` // SQL 方言，例如 "mysql", "postgres"
+}
+
+// NewDBAuditLogProvider 創建新的資料庫審計日誌提供者
+func NewDBAuditLogProvider(db *sql.DB, config DBAuditLogConfig, logger contracts.Logger) contracts.AuditLogProvider {
+	if config.Dialect == "" {
+		config.Dialect = database.DialectMySQL
+	}
+	return &DBAuditLogProvider{
+		db:      db,
+		dialect: config.Dialect,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// LogAction 記錄一個審計日誌條目，metadata 以 JSON 形式保存
+func (p *DBAuditLogProvider) LogAction(ctx context.Context, userID, action, resource string, metadata map[string]any) error {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit metadata: %w", err)
+	}
+
+	query := database.Rebind(p.dialect,
+		`INSERT INTO audit_logs (id, user_id, action, resource, metadata, created_at) VALUES (?, ?, ?, ?, ?, ?)`)
+	_, err = database.ExecutorFromContext(ctx, p.db).ExecContext(ctx, query,
+		uuid.New().String(), userID, action, resource, string(encoded), p.now().UTC())
+	if err != nil {
+		p.logger.Error("寫入審計日誌失敗", "action", action, "resource", resource, "error", err)
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	p.logger.Debug("審計日誌已記錄", "user_id", userID, "action", action, "resource", resource)
+	return nil
+}
+
+// GetName 返回審計日誌提供者的名稱
+func (p *DBAuditLogProvider) GetName() string {
+	return "db_audit_log"
+}
+
+// 確保實現了 AuditLogProvider 介面
+var _ contracts.AuditLogProvider = (*DBAuditLogProvider)(nil)
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// jsonWebKey 是 JWKS 中的單個公鑰 (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// cachedKey 是解析後的簽名公鑰
+type cachedKey struct {
+	alg string // JWK 聲明的演算法，為空表示不限制
+	key crypto.PublicKey
+}
+
+// jwksCache 緩存 realm 的簽名公鑰
+// 職責: 定期刷新 JWKS；遇到未知的 kid 時立即刷新以接收輪換後的新密鑰 (以最小間隔限制刷新頻率)；
+// Keycloak 無法訪問時繼續使用已緩存的密鑰。
+type jwksCache struct {
+	url        string
+	httpClient *http.Client
+	logger     contracts.Logger
+	ttl        time.Duration // 緩存的密鑰超過此時間後刷新
+	minRefresh time.Duration // 兩次刷新之間的最小間隔
+
+	now func() time.Time
+
+	mu          sync.Mutex // 同時只有一個請求刷新 JWKS
+	keys        map[string]cachedKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// newJWKSCache 創建 JWKS 緩存，第一次驗證令牌時才下載密鑰
+func newJWKSCache(url string, httpClient *http.Client, ttl, minRefresh time.Duration, logger contracts.Logger) *jwksCache {
+	return &jwksCache{
+		url:        url,
+		httpClient: httpClient,
+		logger:     logger,
+		ttl:        ttl,
+		minRefresh: minRefresh,
+		now:        time.Now,
+	}
+}
+
+// key 返回 kid 對應的公鑰。緩存過期或找不到 kid 時刷新 JWKS；
+// 刷新失敗但緩存中仍有該密鑰時使用緩存的密鑰。
+func (c *jwksCache) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	cached, found := c.lookup(kid)
+	if found && now.Sub(c.fetchedAt) < c.ttl {
+		return c.check(cached, kid, alg)
+	}
+	if c.attemptedAt.IsZero() || now.Sub(c.attemptedAt) >= c.minRefresh {
+		c.attemptedAt = now
+		if err := c.refresh(ctx, now); err != nil {
+			if found {
+				c.logger.Warn("刷新 JWKS 失敗，繼續使用緩存的密鑰", "url", c.url, "error", err)
+				return c.check(cached, kid, alg)
+			}
+			return nil, err
+		}
+		cached, found = c.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("no signing key with kid %q in JWKS", kid)
+	}
+	return c.check(cached, kid, alg)
+}
+
+// lookup 查找緩存的密鑰；令牌沒有 kid 且 JWKS 只有一個密鑰時使用該密鑰
+func (c *jwksCache) lookup(kid string) (cachedKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// check 確認 JWK 聲明的演算法與令牌一致
+func (c *jwksCache) check(cached cachedKey, kid, alg string) (crypto.PublicKey, error) {
+	if cached.alg != "" && cached.alg != alg {
+		return nil, fmt.Errorf("key %q is for %s, token uses %s", kid, cached.alg, alg)
+	}
+	return cached.key, nil
+}
+
+// refresh 下載並解析 JWKS，替換整個緩存以移除已輪換掉的密鑰
+func (c *jwksCache) refresh(ctx context.Context, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS request failed with status: %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	keys := make(map[string]cachedKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// 跳過不支持的密鑰 (例如加密用的 RSA-OAEP 或 OKP)，不影響其他密鑰
+			c.logger.Debug("跳過 JWKS 中無法使用的密鑰", "kid", jwk.Kid, "kty", jwk.Kty, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = cachedKey{alg: jwk.Alg, key: key}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s contains no usable signing keys", c.url)
+	}
+	c.keys = keys
+	c.fetchedAt = now
+	c.logger.Debug("已刷新 JWKS", "url", c.url, "keys", len(keys))
+	return nil
+}
+
+// publicKey 把 JWK 轉換為 RSA 或 EC 公鑰
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt 解碼 base64url 編碼的大整數
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // 註冊 SHA-256 供 crypto.SHA256 使用
+	_ "crypto/sha512" // 註冊 SHA-384 與 SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// jwtHeader 是 JWT 的 JOSE 標頭
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// audience 是 JWT 的 aud 聲明，可以是單個字串或字串陣列
+type audience []string
+
+// UnmarshalJSON 同時接受字串與字串陣列
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+// contains 檢查 aud 是否包含 expected 中的任一值
+func (a audience) contains(expected []string) bool {
+	for _, value := range a {
+		for _, e := range expected {
+			if value == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// KeycloakClaims 定義 Keycloak 訪問令牌中使用的聲明
+type KeycloakClaims struct {
+	Subject           string   `json:"sub"`
+	Issuer            string   `json:"iss"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	NotBefore         int64    `json:"nbf"`
+	IssuedAt          int64    `json:"iat"`
+	AuthorizedParty   string   `json:"azp"`
+	Scope             string   `json:"scope"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	Nonce             string   `json:"nonce"` // ID 令牌中回傳的授權請求 nonce
+	Roles             []string `json:"roles"` // 頂層 roles 聲明，供不使用 realm_access 的 OIDC 身份提供者映射角色
+	RealmAccess       struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	ResourceAccess map[string]struct {
+		Roles []string `json:"roles"`
+	} `json:"resource_access"`
+	Groups []string `json:"groups"`
+}
+
+// TokenIdentity 是驗證令牌後得到的身份信息
+type TokenIdentity struct {
+	UserID   string
+	Username string
+	Email    string
+	// Roles 包含 realm 角色與本客戶端 (clientId) 的客戶端角色
+	Roles []string
+	// Groups 來自 groups 聲明，需要在 Keycloak 中配置 Group Membership 映射器
+	Groups    []string
+	ExpiresAt time.Time
+}
+
+// identity 從聲明中提取身份信息，roles 合併頂層 roles、realm 角色與 clientID 的客戶端角色並去重
+func (c *KeycloakClaims) identity(clientID string) *TokenIdentity {
+	identity := &TokenIdentity{
+		UserID:   c.Subject,
+		Username: c.PreferredUsername,
+		Email:    c.Email,
+		Groups:   c.Groups,
+	}
+	if c.ExpiresAt > 0 {
+		identity.ExpiresAt = time.Unix(c.ExpiresAt, 0).UTC()
+	}
+	seen := make(map[string]bool)
+	roles := append(append([]string(nil), c.Roles...), c.RealmAccess.Roles...)
+	if client, ok := c.ResourceAccess[clientID]; ok {
+		roles = append(roles, client.Roles...)
+	}
+	for _, role := range roles {
+		if !seen[role] {
+			seen[role] = true
+			identity.Roles = append(identity.Roles, role)
+		}
+	}
+	return identity
+}
+
+// parsedJWT 是拆分後尚未驗證簽名的 JWT
+type parsedJWT struct {
+	header       jwtHeader
+	claims       KeycloakClaims
+	signingInput string
+	signature    []byte
+}
+
+// isJWT 判斷令牌是否為 JWS 緊湊序列化格式，否則視為不透明令牌
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// parseJWT 解碼 JWT 的標頭、聲明與簽名，不驗證簽名
+func parseJWT(token string) (*parsedJWT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT")
+	}
+	var parsed parsedJWT
+	if err := decodeSegment(parts[0], &parsed.header); err != nil {
+		return nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+	if err := decodeSegment(parts[1], &parsed.claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature encoding: %w", err)
+	}
+	parsed.signingInput = parts[0] + "." + parts[1]
+	parsed.signature = signature
+	return &parsed, nil
+}
+
+// decodeSegment 解碼 base64url 編碼的 JSON 片段
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// signatureHash 返回 JWS 演算法使用的散列函數，不支持的演算法 (包括 none 與 HS*) 返回錯誤
+func signatureHash(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, nil
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, nil
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported JWT algorithm: %q", alg)
+	}
+}
+
+// verifySignature 以公鑰驗證 JWT 簽名，公鑰類型必須與演算法相符
+func (p *parsedJWT) verifySignature(key crypto.PublicKey) error {
+	hash, err := signatureHash(p.header.Alg)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write([]byte(p.signingInput))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch p.header.Alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, p.signature)
+		case "PS":
+			err = rsa.VerifyPSS(pub, hash, digest, p.signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		default:
+			return fmt.Errorf("algorithm %s cannot be used with an RSA key", p.header.Alg)
+		}
+		if err != nil {
+			return errors.New("invalid JWT signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if p.header.Alg[:2] != "ES" || len(p.signature) != 2*size {
+			return fmt.Errorf("algorithm %s cannot be used with this EC key", p.header.Alg)
+		}
+		r := new(big.Int).SetBytes(p.signature[:size])
+		s := new(big.Int).SetBytes(p.signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid JWT signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// validate 檢查 exp、nbf、iss 與 aud，時間聲明允許 skew 的時鐘偏差
+func (c *KeycloakClaims) validate(now time.Time, issuer string, audiences []string, skew time.Duration) error {
+	if c.ExpiresAt == 0 {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(skew)) {
+		return errors.New("token has expired")
+	}
+	if c.NotBefore > 0 && now.Add(skew).Before(time.Unix(c.NotBefore, 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if c.Issuer != issuer {
+		return fmt.Errorf("unexpected token issuer %q", c.Issuer)
+	}
+	if !c.Audience.contains(audiences) {
+		return fmt.Errorf("token audience %v does not include %v", []string(c.Audience), audiences)
+	}
+	if c.Subject == "" {
+		return errors.New("token has no sub claim")
+	}
+	return nil
+}
@@ -2,8 +2,6 @@ package auth
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,11 +9,14 @@ import (
 	"strings"
 	"time"
 
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
 	"detectviz-platform/pkg/platform/contracts"
 )
 
 // KeycloakAuthProvider 實現了 AuthProvider 介面，提供 Keycloak 身份驗證集成
-// 職責: 與 Keycloak 服務交互，執行身份驗證和授權檢查
+// 職責: 與 Keycloak 服務交互，執行身份驗證和授權檢查。
+// JWT 訪問令牌以緩存的 realm JWKS 在本地驗證簽名與 exp、nbf、iss、aud，不需要每次請求都訪問 Keycloak；
+// 不透明令牌只有在啟用內省後備時才透過 Keycloak 內省端點驗證。
 type KeycloakAuthProvider struct {
 	baseURL      string
 	realm        string
@@ -23,6 +24,22 @@ type KeycloakAuthProvider struct {
 	clientSecret string
 	httpClient   *http.Client
 	logger       contracts.Logger
+
+	issuer        string
+	audiences     []string
+	clockSkew     time.Duration
+	jwks          *jwksCache
+	introspection bool // 不透明令牌是否透過內省端點驗證
+
+	authorizer Authorizer
+	csrfStore  contracts.AuthStorageProvider // CSRF 令牌綁定到會話後保存在此
+
+	now func() time.Time
+}
+
+// Authorizer 判斷用戶能否對資源執行操作，由 rbac.Engine 實現
+type Authorizer interface {
+	Authorize(ctx context.Context, userID, resource, action string) (bool, error)
 }
 
 // KeycloakConfig 定義 Keycloak 認證提供者的配置
@@ -32,6 +49,21 @@ type KeycloakConfig struct {
 	ClientID     string `yaml:"client_id" json:"client_id"`
 	ClientSecret string `yaml:"client_secret" json:"client_secret"`
 	Timeout      string `yaml:"timeout" json:"timeout"`
+
+	// JWKSURL 為空時使用 {base_url}/realms/{realm}/protocol/openid-connect/certs
+	JWKSURL string `yaml:"jwks_url" json:"jwks_url"`
+	// Issuer 令牌 iss 必須等於此值，為空時使用 {base_url}/realms/{realm}
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// Audience 令牌 aud 必須包含其中之一，為空時使用 client_id
+	Audience []string `yaml:"audience" json:"audience"`
+	// JWKSCacheTTL 緩存的簽名公鑰多久後刷新，默認 "10m"
+	JWKSCacheTTL string `yaml:"jwks_cache_ttl" json:"jwks_cache_ttl"`
+	// JWKSMinRefreshInterval 遇到未知 kid 時兩次刷新 JWKS 的最小間隔，默認 "30s"
+	JWKSMinRefreshInterval string `yaml:"jwks_min_refresh_interval" json:"jwks_min_refresh_interval"`
+	// ClockSkew 驗證 exp 與 nbf 時允許的時鐘偏差，默認 "30s"
+	ClockSkew string `yaml:"clock_skew" json:"clock_skew"`
+	// IntrospectionFallback 為 true 時不透明 (非 JWT) 令牌透過 Keycloak 內省端點驗證
+	IntrospectionFallback bool `yaml:"introspection_fallback" json:"introspection_fallback"`
 }
 
 // KeycloakTokenResponse 定義 Keycloak 令牌響應結構
@@ -71,8 +103,11 @@ type KeycloakIntrospectionResponse struct {
 	Aud       []string `json:"aud"`
 }
 
-// NewKeycloakAuthProvider 創建新的 Keycloak 認證提供者實例
-func NewKeycloakAuthProvider(config KeycloakConfig, logger contracts.Logger) (contracts.AuthProvider, error) {
+// NewKeycloakAuthProvider 創建新的 Keycloak 認證提供者實例。
+// authorizer 負責 Authorize 與 CheckPermissions，為 nil 時拒絕所有授權請求；
+// csrfStore 保存綁定到會話的 CSRF 令牌，為 nil 時 GenerateCSRFToken 與 ValidateCSRFToken 一律失敗。
+func NewKeycloakAuthProvider(config KeycloakConfig, authorizer Authorizer, csrfStore contracts.AuthStorageProvider,
+	logger contracts.Logger) (contracts.AuthProvider, error) {
 	if config.BaseURL == "" {
 		return nil, fmt.Errorf("keycloak base URL is required")
 	}
@@ -96,21 +131,73 @@ func NewKeycloakAuthProvider(config KeycloakConfig, logger contracts.Logger) (co
 		Timeout: timeout,
 	}
 
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	realmURL := fmt.Sprintf("%s/realms/%s", baseURL, config.Realm)
+	jwksURL := config.JWKSURL
+	if jwksURL == "" {
+		jwksURL = realmURL + "/protocol/openid-connect/certs"
+	}
+	issuer := config.Issuer
+	if issuer == "" {
+		issuer = realmURL
+	}
+	audiences := config.Audience
+	if len(audiences) == 0 {
+		audiences = []string{config.ClientID}
+	}
+	cacheTTL, err := parseDurationOrDefault(config.JWKSCacheTTL, 10*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks_cache_ttl: %w", err)
+	}
+	minRefresh, err := parseDurationOrDefault(config.JWKSMinRefreshInterval, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks_min_refresh_interval: %w", err)
+	}
+	clockSkew, err := parseDurationOrDefault(config.ClockSkew, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clock_skew: %w", err)
+	}
+
 	logger.Info("初始化 Keycloak 認證提供者",
-		"base_url", config.BaseURL,
+		"base_url", baseURL,
 		"realm", config.Realm,
-		"client_id", config.ClientID)
+		"client_id", config.ClientID,
+		"jwks_url", jwksURL,
+		"introspection_fallback", config.IntrospectionFallback)
 
 	return &KeycloakAuthProvider{
-		baseURL:      config.BaseURL,
-		realm:        config.Realm,
-		clientID:     config.ClientID,
-		clientSecret: config.ClientSecret,
-		httpClient:   httpClient,
-		logger:       logger,
+		baseURL:       baseURL,
+		realm:         config.Realm,
+		clientID:      config.ClientID,
+		clientSecret:  config.ClientSecret,
+		httpClient:    httpClient,
+		logger:        logger,
+		issuer:        issuer,
+		audiences:     audiences,
+		clockSkew:     clockSkew,
+		csrfStore:     csrfStore,
+		jwks:          newJWKSCache(jwksURL, httpClient, cacheTTL, minRefresh, logger),
+		introspection: config.IntrospectionFallback,
+		authorizer:    authorizer,
+		now:           time.Now,
 	}, nil
 }
 
+// parseDurationOrDefault 解析時間長度，空字串時返回默認值
+func parseDurationOrDefault(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative: %s", value)
+	}
+	return d, nil
+}
+
 // Authenticate 驗證用戶憑證並返回用戶 ID
 func (k *KeycloakAuthProvider) Authenticate(ctx context.Context, credentials string) (string, error) {
 	// credentials 可以是 JWT token 或者 username:password 格式
@@ -128,27 +215,13 @@ func (k *KeycloakAuthProvider) Authenticate(ctx context.Context, credentials str
 	return "", fmt.Errorf("invalid credentials format")
 }
 
-// Authorize 檢查用戶是否有權限訪問特定資源
+// Authorize 檢查用戶是否有權限訪問特定資源，由角色策略判斷；未配置策略時一律拒絕
 func (k *KeycloakAuthProvider) Authorize(ctx context.Context, userID string, resource string, action string) (bool, error) {
-	k.logger.Debug("檢查用戶授權",
-		"user_id", userID,
-		"resource", resource,
-		"action", action)
-
-	// 簡化的授權檢查，實際實現中應該調用 Keycloak 的授權 API
-	// 這裡可以根據需要實現更複雜的 RBAC 或 ABAC 邏輯
-
-	// 示例：管理員用戶有所有權限
-	if userID == "admin" {
-		return true, nil
+	if k.authorizer == nil {
+		k.logger.Warn("未配置授權策略，拒絕請求", "user_id", userID, "resource", resource, "action", action)
+		return false, nil
 	}
-
-	// 示例：普通用戶只能讀取資源
-	if action == "read" {
-		return true, nil
-	}
-
-	return false, nil
+	return k.authorizer.Authorize(ctx, userID, resource, action)
 }
 
 // VerifyToken 驗證 JWT 令牌並返回用戶 ID
@@ -156,16 +229,20 @@ func (k *KeycloakAuthProvider) VerifyToken(ctx context.Context, token string) (s
 	return k.validateToken(ctx, token)
 }
 
-// CheckPermissions 查詢外部服務以檢查用戶的詳細權限
+// VerifyIdentity 驗證令牌並返回身份信息，JWT 令牌包含 realm 角色、客戶端角色與 groups。
+// 經內省驗證的不透明令牌只有用戶 ID、用戶名與郵件。
+func (k *KeycloakAuthProvider) VerifyIdentity(ctx context.Context, token string) (*TokenIdentity, error) {
+	if isJWT(token) {
+		return k.verifyJWT(ctx, token)
+	}
+	if !k.introspection {
+		return nil, fmt.Errorf("opaque tokens are not accepted: introspection fallback is disabled")
+	}
+	return k.introspect(ctx, token)
+}
+
+// CheckPermissions 檢查用戶的詳細權限，與 Authorize 使用同一角色策略
 func (k *KeycloakAuthProvider) CheckPermissions(ctx context.Context, userID, resource, action string) (bool, error) {
-	// 這裡可以調用 Keycloak 的 UMA (User-Managed Access) API 進行詳細權限檢查
-	k.logger.Debug("檢查詳細權限",
-		"user_id", userID,
-		"resource", resource,
-		"action", action)
-
-	// 示例實現：調用 Keycloak 權限 API
-	// 實際實現中應該構建適當的 API 請求
 	return k.Authorize(ctx, userID, resource, action)
 }
 
@@ -187,40 +264,20 @@ func (k *KeycloakAuthProvider) VerifyPassword(ctx context.Context, plainPassword
 	return false, fmt.Errorf("password verification should be handled through Keycloak authentication API")
 }
 
-// GenerateCSRFToken 為當前會話生成一個新的 CSRF token
+// GenerateCSRFToken 為當前會話生成一個新的 CSRF token，會話由 CSRF 中介層放入 context
 func (k *KeycloakAuthProvider) GenerateCSRFToken(ctx context.Context) (string, error) {
-	// 簡單的 CSRF token 生成實現
-	// 實際生產環境中應該使用更安全的隨機生成方法
-
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed to generate CSRF token: %w", err)
+	if k.csrfStore == nil {
+		return "", fmt.Errorf("CSRF token store is not configured")
 	}
-
-	token := base64.URLEncoding.EncodeToString(bytes)
-	k.logger.Debug("生成 CSRF token", "token_length", len(token))
-
-	return token, nil
+	return storage.GenerateCSRFToken(ctx, k.csrfStore)
 }
 
-// ValidateCSRFToken 驗證傳入的 CSRF token 是否有效
+// ValidateCSRFToken 驗證傳入的 CSRF token 是否為當前會話簽發且未過期，以常數時間比較
 func (k *KeycloakAuthProvider) ValidateCSRFToken(ctx context.Context, token string) error {
-	// 簡化的 CSRF token 驗證
-	// 實際實現中應該與存儲的 token 進行比較
-	if token == "" {
-		return fmt.Errorf("CSRF token is required")
+	if k.csrfStore == nil {
+		return fmt.Errorf("CSRF token store is not configured")
 	}
-
-	// 檢查 token 格式
-	if len(token) < 32 {
-		return fmt.Errorf("invalid CSRF token format")
-	}
-
-	k.logger.Debug("驗證 CSRF token", "token_length", len(token))
-
-	// 在真實實現中，這裡應該與會話存儲中的 token 進行比較
-	// 目前返回成功以保持向後兼容
-	return nil
+	return storage.ValidateCSRFToken(ctx, k.csrfStore, token)
 }
 
 // GetName 返回提供者名稱
@@ -228,8 +285,42 @@ func (k *KeycloakAuthProvider) GetName() string {
 	return "keycloak_auth_provider"
 }
 
-// validateToken 驗證 JWT 令牌
+// validateToken 驗證令牌並返回用戶 ID
 func (k *KeycloakAuthProvider) validateToken(ctx context.Context, token string) (string, error) {
+	identity, err := k.VerifyIdentity(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return identity.UserID, nil
+}
+
+// verifyJWT 以 JWKS 中 kid 對應的公鑰在本地驗證 JWT 的簽名與聲明
+func (k *KeycloakAuthProvider) verifyJWT(ctx context.Context, token string) (*TokenIdentity, error) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := signatureHash(parsed.header.Alg); err != nil {
+		return nil, err
+	}
+	key, err := k.jwks.key(ctx, parsed.header.Kid, parsed.header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.verifySignature(key); err != nil {
+		return nil, err
+	}
+	if err := parsed.claims.validate(k.now(), k.issuer, k.audiences, k.clockSkew); err != nil {
+		return nil, err
+	}
+
+	identity := parsed.claims.identity(k.clientID)
+	k.logger.Debug("令牌驗證成功", "user_id", identity.UserID, "username", identity.Username)
+	return identity, nil
+}
+
+// introspect 透過 Keycloak 內省端點驗證不透明令牌
+func (k *KeycloakAuthProvider) introspect(ctx context.Context, token string) (*TokenIdentity, error) {
 	// 構建內省端點 URL
 	introspectURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token/introspect", k.baseURL, k.realm)
 
@@ -244,7 +335,7 @@ func (k *KeycloakAuthProvider) validateToken(ctx context.Context, token string)
 	// 創建 HTTP 請求
 	req, err := http.NewRequestWithContext(ctx, "POST", introspectURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create introspection request: %w", err)
+		return nil, fmt.Errorf("failed to create introspection request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -254,36 +345,44 @@ func (k *KeycloakAuthProvider) validateToken(ctx context.Context, token string)
 
 	resp, err := k.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to introspect token: %w", err)
+		return nil, fmt.Errorf("failed to introspect token: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 檢查響應狀態
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("token introspection failed with status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("token introspection failed with status: %d", resp.StatusCode)
 	}
 
 	// 解析響應
 	var introspectionResp KeycloakIntrospectionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&introspectionResp); err != nil {
-		return "", fmt.Errorf("failed to decode introspection response: %w", err)
+		return nil, fmt.Errorf("failed to decode introspection response: %w", err)
 	}
 
 	// 檢查令牌是否有效
 	if !introspectionResp.Active {
-		return "", fmt.Errorf("token is not active")
+		return nil, fmt.Errorf("token is not active")
 	}
 
 	// 檢查令牌是否過期
-	if introspectionResp.Exp > 0 && time.Now().Unix() > introspectionResp.Exp {
-		return "", fmt.Errorf("token has expired")
+	if introspectionResp.Exp > 0 && k.now().Unix() > introspectionResp.Exp {
+		return nil, fmt.Errorf("token has expired")
 	}
 
-	k.logger.Info("令牌驗證成功",
+	k.logger.Info("令牌內省驗證成功",
 		"user_id", introspectionResp.Sub,
 		"username", introspectionResp.Username)
 
-	return introspectionResp.Sub, nil
+	identity := &TokenIdentity{
+		UserID:   introspectionResp.Sub,
+		Username: introspectionResp.Username,
+		Email:    introspectionResp.Email,
+	}
+	if introspectionResp.Exp > 0 {
+		identity.ExpiresAt = time.Unix(introspectionResp.Exp, 0).UTC()
+	}
+	return identity, nil
 }
 
 // authenticateWithPassword 使用用戶名密碼進行身份驗證
@@ -0,0 +1,377 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// stubKeycloak 是提供 JWKS 與內省端點的本地 Keycloak 替身
+type stubKeycloak struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	keys          []map[string]string
+	jwksRequests  int
+	introspection map[string]interface{}
+	down          bool
+}
+
+func newStubKeycloak(t *testing.T) *stubKeycloak {
+	t.Helper()
+	stub := &stubKeycloak{}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		if stub.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/realms/detectviz/protocol/openid-connect/certs":
+			stub.jwksRequests++
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": stub.keys})
+		case "/realms/detectviz/protocol/openid-connect/token/introspect":
+			json.NewEncoder(w).Encode(stub.introspection)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (s *stubKeycloak) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *stubKeycloak) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jwksRequests
+}
+
+func (s *stubKeycloak) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *stubKeycloak) issuer() string {
+	return s.server.URL + "/realms/detectviz"
+}
+
+// testSigner 以 RSA 或 EC 私鑰簽發測試令牌
+type testSigner struct {
+	kid string
+	alg string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSASigner(t *testing.T, kid string) *testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	return &testSigner{kid: kid, alg: "RS256", rsa: key}
+}
+
+func newECSigner(t *testing.T, kid string) *testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate EC key: %v", err)
+	}
+	return &testSigner{kid: kid, alg: "ES256", ec: key}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (s *testSigner) jwk() map[string]string {
+	if s.rsa != nil {
+		return map[string]string{"kty": "RSA", "kid": s.kid, "use": "sig", "alg": s.alg,
+			"n": b64(s.rsa.N.Bytes()), "e": b64(big.NewInt(int64(s.rsa.E)).Bytes())}
+	}
+	return map[string]string{"kty": "EC", "kid": s.kid, "use": "sig", "crv": "P-256",
+		"x": b64(s.ec.X.FillBytes(make([]byte, 32))), "y": b64(s.ec.Y.FillBytes(make([]byte, 32)))}
+}
+
+func (s *testSigner) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+	var signature []byte
+	if s.rsa != nil {
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	} else {
+		r, sig, err := ecdsa.Sign(rand.Reader, s.ec, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), sig.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+func newTestProvider(t *testing.T, stub *stubKeycloak, config KeycloakConfig, now time.Time) *KeycloakAuthProvider {
+	t.Helper()
+	config.BaseURL = stub.server.URL
+	config.Realm = "detectviz"
+	config.ClientID = "detectviz-api"
+	provider, err := NewKeycloakAuthProvider(config, nil, nil, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewKeycloakAuthProvider() error = %v", err)
+	}
+	k := provider.(*KeycloakAuthProvider)
+	k.now = func() time.Time { return now }
+	k.jwks.now = k.now
+	return k
+}
+
+func validClaims(stub *stubKeycloak, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                "8b1f0a4e-3c5d-4e7f-9a1b-2c3d4e5f6a7b",
+		"iss":                stub.issuer(),
+		"aud":                []string{"account", "detectviz-api"},
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nbf":                now.Add(-time.Minute).Unix(),
+		"iat":                now.Add(-time.Minute).Unix(),
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"realm_access":       map[string]interface{}{"roles": []string{"viewer", "offline_access"}},
+		"resource_access": map[string]interface{}{
+			"detectviz-api": map[string]interface{}{"roles": []string{"detector-admin", "viewer"}},
+			"account":       map[string]interface{}{"roles": []string{"manage-account"}},
+		},
+		"groups": []string{"/sre", "/sre/oncall"},
+	}
+}
+
+func TestKeycloakAuthProvider_VerifiesJWTLocally(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stub := newStubKeycloak(t)
+	signer := newRSASigner(t, "rsa-1")
+	stub.publish(signer.jwk())
+	k := newTestProvider(t, stub, KeycloakConfig{}, now)
+
+	identity, err := k.VerifyIdentity(ctx, signer.sign(t, validClaims(stub, now)))
+	if err != nil {
+		t.Fatalf("VerifyIdentity() error = %v", err)
+	}
+	if identity.UserID != "8b1f0a4e-3c5d-4e7f-9a1b-2c3d4e5f6a7b" || identity.Username != "alice" || identity.Email != "alice@example.com" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+	if strings.Join(identity.Roles, ",") != "viewer,offline_access,detector-admin" {
+		t.Errorf("roles = %v", identity.Roles)
+	}
+	if strings.Join(identity.Groups, ",") != "/sre,/sre/oncall" || !identity.ExpiresAt.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("unexpected groups or expiry: %+v", identity)
+	}
+
+	// 後續驗證使用緩存的 JWKS，即使 Keycloak 無法訪問
+	stub.setDown(true)
+	if userID, err := k.Authenticate(ctx, "Bearer "+signer.sign(t, validClaims(stub, now))); err != nil || userID != identity.UserID {
+		t.Fatalf("Authenticate() = %q, %v", userID, err)
+	}
+	if stub.requests() != 1 {
+		t.Errorf("expected JWKS to be fetched once, got %d", stub.requests())
+	}
+}
+
+func TestKeycloakAuthProvider_RejectsInvalidClaims(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stub := newStubKeycloak(t)
+	signer := newRSASigner(t, "rsa-1")
+	stub.publish(signer.jwk())
+	k := newTestProvider(t, stub, KeycloakConfig{ClockSkew: "30s"}, now)
+
+	tests := []struct {
+		name   string
+		mutate func(claims map[string]interface{})
+		want   string
+	}{
+		{"expired", func(c map[string]interface{}) { c["exp"] = now.Add(-time.Minute).Unix() }, "expired"},
+		{"not yet valid", func(c map[string]interface{}) { c["nbf"] = now.Add(time.Minute).Unix() }, "not valid yet"},
+		{"missing exp", func(c map[string]interface{}) { delete(c, "exp") }, "no exp"},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com/realms/detectviz" }, "issuer"},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "account" }, "audience"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims(stub, now)
+			tt.mutate(claims)
+			if _, err := k.VerifyToken(ctx, signer.sign(t, claims)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("VerifyToken() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// 時鐘偏差內過期的令牌仍然有效
+	claims := validClaims(stub, now)
+	claims["exp"] = now.Add(-10 * time.Second).Unix()
+	if _, err := k.VerifyToken(ctx, signer.sign(t, claims)); err != nil {
+		t.Errorf("token within clock skew rejected: %v", err)
+	}
+
+	// 篡改聲明或使用其他密鑰簽名的令牌
+	token := signer.sign(t, validClaims(stub, now))
+	parts := strings.Split(token, ".")
+	forged := validClaims(stub, now)
+	forged["sub"] = "someone-else"
+	payload, _ := json.Marshal(forged)
+	if _, err := k.VerifyToken(ctx, parts[0]+"."+b64(payload)+"."+parts[2]); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected signature error for tampered token, got %v", err)
+	}
+	impostor := newRSASigner(t, "rsa-1")
+	if _, err := k.VerifyToken(ctx, impostor.sign(t, validClaims(stub, now))); err == nil {
+		t.Error("expected error for token signed by an unknown key")
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa-1"})
+	if _, err := k.VerifyToken(ctx, b64(header)+"."+parts[1]+"."); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected unsupported algorithm error, got %v", err)
+	}
+}
+
+func TestKeycloakAuthProvider_HandlesKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stub := newStubKeycloak(t)
+	oldKey := newRSASigner(t, "rsa-1")
+	newKey := newECSigner(t, "ec-2")
+	stub.publish(oldKey.jwk())
+	k := newTestProvider(t, stub, KeycloakConfig{JWKSMinRefreshInterval: "1m"}, now)
+	k.now = func() time.Time { return now }
+	k.jwks.now = k.now
+	if _, err := k.VerifyToken(ctx, oldKey.sign(t, validClaims(stub, now))); err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+
+	// Keycloak 輪換密鑰後，未知的 kid 觸發刷新
+	stub.publish(oldKey.jwk(), newKey.jwk())
+	now = now.Add(2 * time.Minute)
+	if _, err := k.VerifyToken(ctx, newKey.sign(t, validClaims(stub, now))); err != nil {
+		t.Fatalf("token signed with rotated key rejected: %v", err)
+	}
+	if stub.requests() != 2 {
+		t.Errorf("expected JWKS refresh on unknown kid, got %d requests", stub.requests())
+	}
+
+	// 未知 kid 的刷新受最小間隔限制
+	unknown := newRSASigner(t, "rsa-9")
+	for i := 0; i < 3; i++ {
+		if _, err := k.VerifyToken(ctx, unknown.sign(t, validClaims(stub, now))); err == nil || !strings.Contains(err.Error(), "kid") {
+			t.Fatalf("expected unknown kid error, got %v", err)
+		}
+	}
+	if stub.requests() != 2 {
+		t.Errorf("unknown kids should not hammer the JWKS endpoint, got %d requests", stub.requests())
+	}
+
+	// 緩存過期後刷新，移除的舊密鑰不再被接受
+	stub.publish(newKey.jwk())
+	now = now.Add(11 * time.Minute)
+	if _, err := k.VerifyToken(ctx, oldKey.sign(t, validClaims(stub, now))); err == nil {
+		t.Error("expected token signed with a retired key to be rejected")
+	}
+	if _, err := k.VerifyToken(ctx, newKey.sign(t, validClaims(stub, now))); err != nil {
+		t.Errorf("VerifyToken() error = %v", err)
+	}
+}
+
+func TestKeycloakAuthProvider_IntrospectionFallbackForOpaqueTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stub := newStubKeycloak(t)
+	stub.introspection = map[string]interface{}{
+		"active": true, "sub": "user-1", "username": "bob", "exp": now.Add(time.Minute).Unix(),
+	}
+
+	strict := newTestProvider(t, stub, KeycloakConfig{}, now)
+	if _, err := strict.VerifyToken(ctx, "opaque-token"); err == nil || !strings.Contains(err.Error(), "introspection") {
+		t.Errorf("expected opaque token to be rejected without fallback, got %v", err)
+	}
+
+	fallback := newTestProvider(t, stub, KeycloakConfig{IntrospectionFallback: true}, now)
+	identity, err := fallback.VerifyIdentity(ctx, "opaque-token")
+	if err != nil || identity.UserID != "user-1" || identity.Username != "bob" {
+		t.Fatalf("VerifyIdentity() = %+v, %v", identity, err)
+	}
+	stub.introspection["active"] = false
+	if _, err := fallback.VerifyToken(ctx, "opaque-token"); err == nil {
+		t.Error("expected inactive token to be rejected")
+	}
+}
+
+func TestKeycloakAuthProvider_CSRFTokensBoundToSession(t *testing.T) {
+	store, err := storage.NewMemoryAuthStorageProvider(storage.Config{}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewKeycloakAuthProvider(KeycloakConfig{BaseURL: "http://keycloak", Realm: "r", ClientID: "c"}, nil, store, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := storage.WithCSRFBinding(context.Background(), "session-a")
+	token, err := provider.GenerateCSRFToken(ctx)
+	if err != nil {
+		t.Fatalf("GenerateCSRFToken() error = %v", err)
+	}
+	if err := provider.ValidateCSRFToken(ctx, token); err != nil {
+		t.Errorf("ValidateCSRFToken() error = %v", err)
+	}
+
+	// 以前任何 32 字元以上的字串都能通過驗證
+	other := storage.WithCSRFBinding(context.Background(), "session-b")
+	for name, tc := range map[string]struct {
+		ctx   context.Context
+		token string
+	}{
+		"arbitrary string": {ctx, strings.Repeat("a", 43)},
+		"other session":    {other, token},
+		"no session":       {context.Background(), token},
+	} {
+		if err := provider.ValidateCSRFToken(tc.ctx, tc.token); err == nil {
+			t.Errorf("%s: ValidateCSRFToken() accepted the token", name)
+		}
+	}
+}
@@ -0,0 +1,627 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+const (
+	// EmailVerificationTokenType 是郵件驗證令牌在 AuthStorageProvider 中的令牌類型，以用戶 ID 作為鍵
+	EmailVerificationTokenType = "email_verification"
+	// PasswordResetTokenType 是密碼重置令牌在 AuthStorageProvider 中的令牌類型，以用戶 ID 作為鍵
+	PasswordResetTokenType = "password_reset"
+
+	// verifyEmailPath 與 resetPasswordPath 是郵件中鏈接的路徑，由 AuthUIPagePlugin 處理
+	verifyEmailPath   = "/auth/verify-email"
+	resetPasswordPath = "/auth/reset-password"
+)
+
+// AccountNotifier 發送驗證與密碼重置郵件，由郵件通知插件實現
+type AccountNotifier interface {
+	SendNotification(ctx context.Context, recipient, subject, body string, metadata map[string]interface{}) error
+}
+
+// LocalAuthConfig 定義本地認證提供者的配置
+type LocalAuthConfig struct {
+	// Issuer 與 Audience 是簽發令牌的 iss 與 aud，默認均為 "detectviz"
+	Issuer   string `yaml:"issuer" json:"issuer"`
+	Audience string `yaml:"audience" json:"audience"`
+	// TokenTTL 訪問令牌有效期，默認 "1h"
+	TokenTTL string `yaml:"token_ttl" json:"token_ttl"`
+	// KeyRotationInterval 簽名密鑰輪換週期，默認 "24h"，不能短於 TokenTTL
+	KeyRotationInterval string `yaml:"key_rotation_interval" json:"key_rotation_interval"`
+	// ClockSkew 驗證 exp 與 nbf 時允許的時鐘偏差，默認 "30s"
+	ClockSkew string `yaml:"clock_skew" json:"clock_skew"`
+	// RequireEmailVerification 為 true 時未驗證郵箱的用戶不能登錄
+	RequireEmailVerification bool `yaml:"require_email_verification" json:"require_email_verification"`
+	// VerificationTokenTTL 郵件驗證鏈接有效期，默認 "24h"
+	VerificationTokenTTL string `yaml:"verification_token_ttl" json:"verification_token_ttl"`
+	// ResetTokenTTL 密碼重置鏈接有效期，默認 "1h"
+	ResetTokenTTL string `yaml:"reset_token_ttl" json:"reset_token_ttl"`
+	// DefaultRoles 是新註冊用戶的角色
+	DefaultRoles []string `yaml:"default_roles" json:"default_roles"`
+	// BaseURL 是郵件中鏈接的站點地址，例如 https://detectviz.example.com
+	BaseURL        string         `yaml:"base_url" json:"base_url"`
+	PasswordPolicy PasswordPolicy `yaml:"password_policy" json:"password_policy"`
+}
+
+// RegisterRequest 是本地用戶註冊請求
+type RegisterRequest struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// IssuedToken 是本地簽發的訪問令牌
+type IssuedToken struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// LocalAuthProvider 實現了 AuthProvider 介面，以 UserRepository 中的用戶提供本地認證
+// 職責: 用戶註冊與郵件驗證、密碼登錄、密碼重置與修改，並簽發與驗證自簽名 JWT。
+// 令牌以 HS256 簽名，簽名密鑰按 KeyRotationInterval 從主密鑰派生並輪換；
+// 驗證與重置令牌只保存 SHA-256 散列，使用一次後即撤銷。適用於不部署 Keycloak 的小型環境與測試。
+type LocalAuthProvider struct {
+	users      interfaces.UserRepository
+	hasher     hasher.PasswordHasher
+	authorizer Authorizer
+	store      contracts.AuthStorageProvider // 保存 CSRF、郵件驗證與密碼重置令牌
+	notifier   AccountNotifier
+	mfa        *TOTPAuthenticator // 為 nil 時不啟用多因素認證
+	logger     contracts.Logger
+
+	keys      *signingKeyRing
+	issuer    string
+	audience  string
+	tokenTTL  time.Duration
+	clockSkew time.Duration
+
+	requireVerification bool
+	verificationTTL     time.Duration
+	resetTTL            time.Duration
+	defaultRoles        []string
+	baseURL             string
+	policy              PasswordPolicy
+
+	// dummyHash 用於未知用戶的密碼比對，使登錄耗時不洩露用戶是否存在
+	dummyHash string
+
+	now func() time.Time
+}
+
+// NewLocalAuthProvider 創建本地認證提供者。
+// signingKey 是派生令牌簽名密鑰的主密鑰，為空時使用隨機密鑰，重啟後已簽發的令牌失效；
+// authorizer 為 nil 時拒絕所有授權請求；notifier 為 nil 時不發送郵件，用戶無法自行完成驗證與重置；
+// mfa 不為 nil 時已登記 TOTP 或角色要求 MFA 的用戶登錄時需要驗證碼。
+func NewLocalAuthProvider(config LocalAuthConfig, users interfaces.UserRepository, passwordHasher hasher.PasswordHasher,
+	signingKey []byte, authorizer Authorizer, store contracts.AuthStorageProvider, notifier AccountNotifier,
+	mfa *TOTPAuthenticator, logger contracts.Logger) (*LocalAuthProvider, error) {
+	if users == nil {
+		return nil, fmt.Errorf("local auth provider requires a user repository")
+	}
+	if passwordHasher == nil {
+		return nil, fmt.Errorf("local auth provider requires a password hasher")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("local auth provider requires an auth storage provider")
+	}
+
+	tokenTTL, err := parseDurationOrDefault(config.TokenTTL, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token_ttl: %w", err)
+	}
+	rotation, err := parseDurationOrDefault(config.KeyRotationInterval, 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key_rotation_interval: %w", err)
+	}
+	if tokenTTL <= 0 || rotation < tokenTTL {
+		return nil, fmt.Errorf("key_rotation_interval (%s) must not be shorter than token_ttl (%s)", rotation, tokenTTL)
+	}
+	if rotation < time.Second || rotation%time.Second != 0 {
+		return nil, fmt.Errorf("key_rotation_interval must be a whole number of seconds")
+	}
+	clockSkew, err := parseDurationOrDefault(config.ClockSkew, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clock_skew: %w", err)
+	}
+	verificationTTL, err := parseDurationOrDefault(config.VerificationTokenTTL, 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification_token_ttl: %w", err)
+	}
+	resetTTL, err := parseDurationOrDefault(config.ResetTokenTTL, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reset_token_ttl: %w", err)
+	}
+
+	if len(signingKey) == 0 {
+		logger.Warn("未配置本地令牌簽名密鑰，使用隨機密鑰，重啟後已簽發的令牌失效")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, fmt.Errorf("failed to generate token signing key: %w", err)
+		}
+	}
+	keys, err := newSigningKeyRing(signingKey, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	dummyHash, err := passwordHasher.HashPassword(context.Background(), "detectviz-local-auth-dummy-password")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare password hasher: %w", err)
+	}
+
+	issuer := config.Issuer
+	if issuer == "" {
+		issuer = "detectviz"
+	}
+	audience := config.Audience
+	if audience == "" {
+		audience = "detectviz"
+	}
+	if notifier == nil {
+		logger.Warn("本地認證未配置郵件通知，驗證與密碼重置郵件不會發送")
+	}
+
+	logger.Info("初始化本地認證提供者",
+		"issuer", issuer,
+		"token_ttl", tokenTTL.String(),
+		"key_rotation_interval", rotation.String(),
+		"require_email_verification", config.RequireEmailVerification,
+		"mfa", mfa != nil)
+
+	return &LocalAuthProvider{
+		users:               users,
+		hasher:              passwordHasher,
+		authorizer:          authorizer,
+		store:               store,
+		notifier:            notifier,
+		mfa:                 mfa,
+		logger:              logger,
+		keys:                keys,
+		issuer:              issuer,
+		audience:            audience,
+		tokenTTL:            tokenTTL,
+		clockSkew:           clockSkew,
+		requireVerification: config.RequireEmailVerification,
+		verificationTTL:     verificationTTL,
+		resetTTL:            resetTTL,
+		defaultRoles:        append([]string(nil), config.DefaultRoles...),
+		baseURL:             strings.TrimSuffix(config.BaseURL, "/"),
+		policy:              config.PasswordPolicy.withDefaults(),
+		dummyHash:           dummyHash,
+		now:                 time.Now,
+	}, nil
+}
+
+// Authenticate 以 "email:password" 格式的憑證驗證用戶並返回用戶 ID，"Bearer " 前綴的憑證按令牌驗證。
+// 密碼憑證只完成第一步驗證，需要 MFA 的用戶由調用方透過 StartMFAChallenge 繼續，登錄頁面即是如此。
+func (l *LocalAuthProvider) Authenticate(ctx context.Context, credentials string) (string, error) {
+	if strings.HasPrefix(credentials, "Bearer ") {
+		return l.VerifyToken(ctx, strings.TrimPrefix(credentials, "Bearer "))
+	}
+	email, password, ok := strings.Cut(credentials, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid credentials format")
+	}
+	user, err := l.authenticate(ctx, email, password)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+// Login 驗證郵箱與密碼並簽發訪問令牌。需要 MFA 的用戶須同時提供 mfaCode (TOTP 驗證碼或恢復碼)，
+// 未提供時返回 *MFARequiredError；角色要求 MFA 但尚未登記的用戶須先在登錄頁面登記。
+func (l *LocalAuthProvider) Login(ctx context.Context, email, password, mfaCode string) (*IssuedToken, *entities.User, error) {
+	user, err := l.authenticate(ctx, email, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := l.verifyMFA(ctx, user, mfaCode); err != nil {
+		return nil, nil, err
+	}
+	token, err := l.IssueToken(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+// IssueToken 為用戶簽發訪問令牌，令牌攜帶用戶的角色
+func (l *LocalAuthProvider) IssueToken(user *entities.User) (*IssuedToken, error) {
+	now := l.now()
+	expiresAt := now.Add(l.tokenTTL)
+	token, err := signLocalToken(l.keys, localTokenClaims{
+		Subject:           user.ID,
+		Issuer:            l.issuer,
+		Audience:          l.audience,
+		ExpiresAt:         expiresAt.Unix(),
+		NotBefore:         now.Unix(),
+		IssuedAt:          now.Unix(),
+		PreferredUsername: user.Name,
+		Email:             user.Email,
+		Roles:             user.Roles,
+	}, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &IssuedToken{AccessToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// VerifyToken 驗證本地簽發的令牌並返回用戶 ID
+func (l *LocalAuthProvider) VerifyToken(ctx context.Context, token string) (string, error) {
+	identity, err := l.VerifyIdentity(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return identity.UserID, nil
+}
+
+// VerifyIdentity 驗證令牌的簽名、有效期、iss 與 aud 並返回身份信息，角色來自令牌的 roles 聲明
+func (l *LocalAuthProvider) VerifyIdentity(ctx context.Context, token string) (*TokenIdentity, error) {
+	now := l.now()
+	claims, err := verifyLocalToken(l.keys, token, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := claims.validate(now, l.issuer, []string{l.audience}, l.clockSkew); err != nil {
+		return nil, err
+	}
+	return claims.identity(""), nil
+}
+
+// UserIdentity 返回用戶當前的身份信息，供 UI 登錄後填充會話
+func (l *LocalAuthProvider) UserIdentity(ctx context.Context, userID string) (*TokenIdentity, error) {
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenIdentity{UserID: user.ID, Username: user.Name, Email: user.Email, Roles: append([]string(nil), user.Roles...)}, nil
+}
+
+// Authorize 檢查用戶是否有權限訪問特定資源，由角色策略判斷；未配置策略時一律拒絕
+func (l *LocalAuthProvider) Authorize(ctx context.Context, userID string, resource string, action string) (bool, error) {
+	if l.authorizer == nil {
+		l.logger.Warn("未配置授權策略，拒絕請求", "user_id", userID, "resource", resource, "action", action)
+		return false, nil
+	}
+	return l.authorizer.Authorize(ctx, userID, resource, action)
+}
+
+// CheckPermissions 檢查用戶的詳細權限，與 Authorize 使用同一角色策略
+func (l *LocalAuthProvider) CheckPermissions(ctx context.Context, userID, resource, action string) (bool, error) {
+	return l.Authorize(ctx, userID, resource, action)
+}
+
+// HashPassword 將明文密碼轉換為安全的散列值
+func (l *LocalAuthProvider) HashPassword(ctx context.Context, plainPassword string) (string, error) {
+	return l.hasher.HashPassword(ctx, plainPassword)
+}
+
+// VerifyPassword 驗證明文密碼是否與給定的散列值匹配
+func (l *LocalAuthProvider) VerifyPassword(ctx context.Context, plainPassword, hashedPassword string) (bool, error) {
+	return l.hasher.VerifyPassword(ctx, plainPassword, hashedPassword)
+}
+
+// GenerateCSRFToken 為當前會話生成一個新的 CSRF token，會話由 CSRF 中介層放入 context
+func (l *LocalAuthProvider) GenerateCSRFToken(ctx context.Context) (string, error) {
+	return storage.GenerateCSRFToken(ctx, l.store)
+}
+
+// ValidateCSRFToken 驗證傳入的 CSRF token 是否為當前會話簽發且未過期，以常數時間比較
+func (l *LocalAuthProvider) ValidateCSRFToken(ctx context.Context, token string) error {
+	return storage.ValidateCSRFToken(ctx, l.store, token)
+}
+
+// GetName 返回提供者名稱
+func (l *LocalAuthProvider) GetName() string {
+	return "local_auth_provider"
+}
+
+// PasswordPolicy 返回生效的密碼策略
+func (l *LocalAuthProvider) PasswordPolicy() PasswordPolicy {
+	return l.policy
+}
+
+// Register 創建本地用戶。需要郵件驗證時發送驗證郵件，郵件發送失敗不影響註冊，用戶可以重新請求驗證郵件。
+func (l *LocalAuthProvider) Register(ctx context.Context, req RegisterRequest) (*entities.User, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, domainerrors.NewValidationError("name", "名稱不能為空")
+	}
+	email, err := valueobjects.NewEmailVO(req.Email)
+	if err != nil {
+		return nil, domainerrors.NewValidationError("email", "無效的郵件地址")
+	}
+	if err := l.policy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+	existing, err := l.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if existing != nil {
+		return nil, domainerrors.NewValidationError("email", "該郵件地址已註冊")
+	}
+
+	hash, err := l.hasher.HashPassword(ctx, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	now := l.now().UTC()
+	user := &entities.User{
+		ID:           uuid.NewString(),
+		Name:         name,
+		Email:        email.String(),
+		PasswordHash: hash,
+		Roles:        append([]string(nil), l.defaultRoles...),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if !l.requireVerification {
+		user.EmailVerifiedAt = now
+	}
+	if err := l.users.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	l.logger.Info("本地用戶已註冊", "user_id", user.ID, "email", user.Email)
+
+	if l.requireVerification {
+		if err := l.sendVerification(ctx, user); err != nil {
+			l.logger.Error("發送驗證郵件失敗", "user_id", user.ID, "error", err)
+		}
+	}
+	return user, nil
+}
+
+// ResendVerification 為尚未驗證的用戶重新發送驗證郵件。郵件地址不存在或已驗證時同樣返回 nil，避免洩露用戶是否存在。
+func (l *LocalAuthProvider) ResendVerification(ctx context.Context, email string) error {
+	user, err := l.userByEmail(ctx, email)
+	if err != nil || user == nil || user.EmailVerified() {
+		return err
+	}
+	return l.sendVerification(ctx, user)
+}
+
+// VerifyEmail 使用郵件中的驗證令牌完成郵箱驗證
+func (l *LocalAuthProvider) VerifyEmail(ctx context.Context, token string) (*entities.User, error) {
+	userID, err := l.consumeAccountToken(ctx, token, EmailVerificationTokenType)
+	if err != nil {
+		return nil, err
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified() {
+		user.EmailVerifiedAt = l.now().UTC()
+		user.UpdatedAt = user.EmailVerifiedAt
+		if err := l.users.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+	l.logger.Info("用戶已驗證郵箱", "user_id", user.ID)
+	return user, nil
+}
+
+// RequestPasswordReset 向用戶發送密碼重置郵件。郵件地址不存在時同樣返回 nil，避免洩露用戶是否存在。
+func (l *LocalAuthProvider) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := l.userByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+	token, err := l.issueAccountToken(ctx, user.ID, PasswordResetTokenType, l.resetTTL)
+	if err != nil {
+		return err
+	}
+	link := l.baseURL + resetPasswordPath + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("%s 您好：\n\n請在 %s 內打開以下鏈接重置密碼：\n%s\n\n如果您沒有請求重置密碼，請忽略此郵件。",
+		user.Name, l.resetTTL, link)
+	return l.notify(ctx, user, "重置您的 Detectviz 密碼", body, PasswordResetTokenType)
+}
+
+// ResetPassword 使用重置令牌設置新密碼。重置令牌證明用戶擁有該郵箱，未驗證的郵箱同時標記為已驗證。
+func (l *LocalAuthProvider) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// 先檢查密碼策略，不符合時令牌仍可再次使用
+	if err := l.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	userID, err := l.consumeAccountToken(ctx, token, PasswordResetTokenType)
+	if err != nil {
+		return err
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := l.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	l.logger.Info("用戶已重置密碼", "user_id", user.ID)
+	return nil
+}
+
+// ChangePassword 驗證當前密碼後設置新密碼
+func (l *LocalAuthProvider) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ok, err := l.hasher.VerifyPassword(ctx, currentPassword, user.PasswordHash)
+	if err != nil || !ok {
+		return domainerrors.NewAuthError("current password is incorrect")
+	}
+	if currentPassword == newPassword {
+		return domainerrors.NewValidationError("newPassword", "新密碼不能與當前密碼相同")
+	}
+	if err := l.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	if err := l.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	l.logger.Info("用戶已修改密碼", "user_id", user.ID)
+	return nil
+}
+
+// authenticate 以郵箱與密碼驗證用戶。未知用戶同樣執行一次散列比對，錯誤信息不區分用戶不存在與密碼錯誤。
+func (l *LocalAuthProvider) authenticate(ctx context.Context, email, password string) (*entities.User, error) {
+	invalid := domainerrors.NewAuthError("invalid email or password")
+	user, err := l.userByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		_, _ = l.hasher.VerifyPassword(ctx, password, l.dummyHash)
+		return nil, invalid
+	}
+	ok, err := l.hasher.VerifyPassword(ctx, password, user.PasswordHash)
+	if err != nil || !ok {
+		return nil, invalid
+	}
+	if l.requireVerification && !user.EmailVerified() {
+		return nil, domainerrors.NewAuthError("email address is not verified")
+	}
+	return user, nil
+}
+
+// setPassword 散列並保存新密碼
+func (l *LocalAuthProvider) setPassword(ctx context.Context, user *entities.User, password string) error {
+	hash, err := l.hasher.HashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+	now := l.now().UTC()
+	user.PasswordHash = hash
+	user.UpdatedAt = now
+	if !user.EmailVerified() {
+		user.EmailVerifiedAt = now
+	}
+	if err := l.users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// AccountEmail 返回登錄名對應的已存在用戶保存的郵件地址，供登錄限流發送鎖定通知；用戶不存在時返回空字串
+func (l *LocalAuthProvider) AccountEmail(ctx context.Context, account string) (string, error) {
+	user, err := l.userByEmail(ctx, strings.TrimSpace(account))
+	if err != nil || user == nil {
+		return "", err
+	}
+	return user.Email, nil
+}
+
+// userByEmail 按郵箱查找用戶，郵箱格式無效或用戶不存在時返回 nil
+func (l *LocalAuthProvider) userByEmail(ctx context.Context, email string) (*entities.User, error) {
+	vo, err := valueobjects.NewEmailVO(email)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := l.users.GetByEmail(ctx, vo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user, nil
+}
+
+// getUser 按 ID 查找用戶，不存在時返回未找到錯誤
+func (l *LocalAuthProvider) getUser(ctx context.Context, userID string) (*entities.User, error) {
+	id, err := valueobjects.NewIDVO(userID)
+	if err != nil {
+		return nil, domainerrors.NewNotFoundError("user", fmt.Sprintf("用戶不存在: %s", userID))
+	}
+	user, err := l.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		return nil, domainerrors.NewNotFoundError("user", fmt.Sprintf("用戶不存在: %s", userID))
+	}
+	return user, nil
+}
+
+// sendVerification 簽發郵件驗證令牌並發送驗證郵件
+func (l *LocalAuthProvider) sendVerification(ctx context.Context, user *entities.User) error {
+	token, err := l.issueAccountToken(ctx, user.ID, EmailVerificationTokenType, l.verificationTTL)
+	if err != nil {
+		return err
+	}
+	link := l.baseURL + verifyEmailPath + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("%s 您好：\n\n請在 %s 內打開以下鏈接驗證您的郵件地址：\n%s",
+		user.Name, l.verificationTTL, link)
+	return l.notify(ctx, user, "驗證您的 Detectviz 郵件地址", body, EmailVerificationTokenType)
+}
+
+// notify 發送賬戶郵件，未配置通知器時只記錄警告，不記錄包含令牌的正文
+func (l *LocalAuthProvider) notify(ctx context.Context, user *entities.User, subject, body, kind string) error {
+	if l.notifier == nil {
+		l.logger.Warn("未配置郵件通知，賬戶郵件未發送", "user_id", user.ID, "type", kind)
+		return nil
+	}
+	if err := l.notifier.SendNotification(ctx, user.Email, subject, body, map[string]interface{}{"type": kind, "user_id": user.ID}); err != nil {
+		return fmt.Errorf("failed to send %s email: %w", kind, err)
+	}
+	return nil
+}
+
+// issueAccountToken 生成 "<用戶ID>.<隨機值>" 格式的一次性令牌，只保存隨機值的 SHA-256 散列。
+// 同一用戶同一類型只保留最新的令牌，重新請求會使之前的鏈接失效。
+func (l *LocalAuthProvider) issueAccountToken(ctx context.Context, userID, tokenType string, ttl time.Duration) (string, error) {
+	secret, err := RandomURLToken(32)
+	if err != nil {
+		return "", err
+	}
+	expiry := l.now().Add(ttl).Unix()
+	if err := l.store.StoreToken(ctx, userID, tokenType, hashAccountToken(secret), expiry); err != nil {
+		return "", fmt.Errorf("failed to store %s token: %w", tokenType, err)
+	}
+	return userID + "." + secret, nil
+}
+
+// consumeAccountToken 驗證一次性令牌並撤銷，返回令牌所屬的用戶 ID
+func (l *LocalAuthProvider) consumeAccountToken(ctx context.Context, token, tokenType string) (string, error) {
+	invalid := domainerrors.NewValidationError("token", "鏈接無效或已過期")
+	userID, secret, ok := strings.Cut(token, ".")
+	if !ok || userID == "" || secret == "" {
+		return "", invalid
+	}
+	stored, err := l.store.GetToken(ctx, userID, tokenType)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s token: %w", tokenType, err)
+	}
+	if stored == "" || subtle.ConstantTimeCompare([]byte(stored), []byte(hashAccountToken(secret))) != 1 {
+		return "", invalid
+	}
+	if err := l.store.RevokeToken(ctx, userID, tokenType); err != nil {
+		return "", fmt.Errorf("failed to revoke %s token: %w", tokenType, err)
+	}
+	return userID, nil
+}
+
+// hashAccountToken 返回一次性令牌隨機值的 SHA-256 十六進制散列
+func hashAccountToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// 確保實現了 AuthProvider 介面
+var _ contracts.AuthProvider = (*LocalAuthProvider)(nil)
@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/valueobjects"
+)
+
+// memoryUserRepository 是以 map 保存用戶的 UserRepository 替身
+type memoryUserRepository struct {
+	mu    sync.Mutex
+	users map[string]entities.User
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{users: make(map[string]entities.User)}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id.String()]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email valueobjects.EmailVO) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email.String() {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *entities.User) error {
+	return r.Create(ctx, user)
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id.String())
+	return nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, error) {
+	return nil, nil
+}
+
+// recordingMailer 記錄發送的賬戶郵件
+type recordingMailer struct {
+	mu         sync.Mutex
+	recipients []string
+	bodies     []string
+}
+
+func (m *recordingMailer) SendNotification(ctx context.Context, recipient, subject, body string, metadata map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recipients = append(m.recipients, recipient)
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+// lastToken 從最近一封郵件的鏈接中取出令牌
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.bodies) == 0 {
+		t.Fatal("no email was sent")
+	}
+	body := m.bodies[len(m.bodies)-1]
+	start := strings.Index(body, "?token=")
+	if start < 0 {
+		t.Fatalf("email has no token link: %q", body)
+	}
+	raw := body[start+len("?token="):]
+	if end := strings.IndexAny(raw, "\n "); end >= 0 {
+		raw = raw[:end]
+	}
+	token, err := url.QueryUnescape(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newTestLocalAuthProvider(t *testing.T, config LocalAuthConfig) (*LocalAuthProvider, *recordingMailer) {
+	t.Helper()
+	store, err := storage.NewMemoryAuthStorageProvider(storage.Config{}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordHasher, err := hasher.NewBcryptPasswordHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer := &recordingMailer{}
+	provider, err := NewLocalAuthProvider(config, newMemoryUserRepository(), passwordHasher,
+		[]byte("0123456789abcdef0123456789abcdef"), nil, store, mailer, nil, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewLocalAuthProvider: %v", err)
+	}
+	return provider, mailer
+}
+
+const testPassword = "Correct-Horse-42"
+
+func TestLocalAuthProvider_RegisterVerifyAndLogin(t *testing.T) {
+	ctx := context.Background()
+	provider, mailer := newTestLocalAuthProvider(t, LocalAuthConfig{
+		RequireEmailVerification: true,
+		DefaultRoles:             []string{"viewer"},
+		BaseURL:                  "https://detectviz.example.com/",
+	})
+
+	user, err := provider.Register(ctx, RegisterRequest{Name: "Alice", Email: "Alice@Example.com", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.EmailVerified() {
+		t.Fatal("new user should not be verified yet")
+	}
+	if _, err := provider.Register(ctx, RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: testPassword}); !domainerrors.IsValidationError(err) {
+		t.Fatalf("duplicate email should be rejected, got %v", err)
+	}
+	if !strings.Contains(mailer.bodies[0], "https://detectviz.example.com/auth/verify-email?token=") {
+		t.Fatalf("unexpected verification email: %q", mailer.bodies[0])
+	}
+
+	if _, _, err := provider.Login(ctx, "alice@example.com", testPassword, ""); !domainerrors.IsAuthError(err) {
+		t.Fatalf("unverified user should not log in, got %v", err)
+	}
+	if _, err := provider.VerifyEmail(ctx, mailer.lastToken(t)+"x"); !domainerrors.IsValidationError(err) {
+		t.Fatalf("tampered verification token should be rejected, got %v", err)
+	}
+	token := mailer.lastToken(t)
+	if _, err := provider.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if _, err := provider.VerifyEmail(ctx, token); err == nil {
+		t.Fatal("verification token should be single use")
+	}
+
+	if _, _, err := provider.Login(ctx, "alice@example.com", "Wrong-Password-1", ""); !domainerrors.IsAuthError(err) {
+		t.Fatalf("wrong password should fail, got %v", err)
+	}
+	issued, _, err := provider.Login(ctx, "alice@example.com", testPassword, "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	identity, err := provider.VerifyIdentity(ctx, issued.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyIdentity: %v", err)
+	}
+	if identity.UserID != user.ID || identity.Email != "alice@example.com" || len(identity.Roles) != 1 || identity.Roles[0] != "viewer" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if userID, err := provider.Authenticate(ctx, "alice@example.com:"+testPassword); err != nil || userID != user.ID {
+		t.Fatalf("Authenticate = %q, %v", userID, err)
+	}
+}
+
+func TestLocalAuthProvider_RotatesSigningKeys(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := newTestLocalAuthProvider(t, LocalAuthConfig{TokenTTL: "1h", KeyRotationInterval: "2h"})
+	user, err := provider.Register(ctx, RegisterRequest{Name: "Bob", Email: "bob@example.com", Password: testPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 1, 30, 0, 0, time.UTC)
+	provider.now = func() time.Time { return start }
+	issued, err := provider.IssueToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 進入下一個輪換週期後，上一週期簽發且未過期的令牌仍然有效
+	provider.now = func() time.Time { return start.Add(45 * time.Minute) }
+	if _, err := provider.VerifyToken(ctx, issued.AccessToken); err != nil {
+		t.Fatalf("token from previous key should still verify: %v", err)
+	}
+	fresh, err := provider.IssueToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Split(fresh.AccessToken, ".")[0] == strings.Split(issued.AccessToken, ".")[0] {
+		t.Fatal("tokens from different epochs should use different kids")
+	}
+
+	// 輪換兩次後舊密鑰不再被接受，即使忽略過期時間
+	provider.now = func() time.Time { return start.Add(5 * time.Hour) }
+	if _, err := verifyLocalToken(provider.keys, issued.AccessToken, provider.now()); err == nil {
+		t.Fatal("key should have been rotated out")
+	}
+
+	// 其他主密鑰簽名的令牌被拒絕
+	other, err := newSigningKeyRing([]byte("fedcba9876543210fedcba9876543210"), 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := signLocalToken(other, localTokenClaims{Subject: user.ID, Issuer: "detectviz", Audience: "detectviz",
+		ExpiresAt: provider.now().Add(time.Hour).Unix()}, provider.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyToken(ctx, forged); err == nil {
+		t.Fatal("token signed with another key should be rejected")
+	}
+}
+
+func TestLocalAuthProvider_PasswordResetAndChange(t *testing.T) {
+	ctx := context.Background()
+	provider, mailer := newTestLocalAuthProvider(t, LocalAuthConfig{})
+	user, err := provider.Register(ctx, RegisterRequest{Name: "Carol", Email: "carol@example.com", Password: testPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.RequestPasswordReset(ctx, "nobody@example.com"); err != nil || len(mailer.bodies) != 0 {
+		t.Fatalf("unknown email should be ignored silently, err=%v mails=%d", err, len(mailer.bodies))
+	}
+	if err := provider.RequestPasswordReset(ctx, "carol@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	token := mailer.lastToken(t)
+	if err := provider.ResetPassword(ctx, token, "short"); !domainerrors.IsValidationError(err) {
+		t.Fatalf("weak password should be rejected, got %v", err)
+	}
+	if err := provider.ResetPassword(ctx, token, "Brand-New-Pass-7"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if err := provider.ResetPassword(ctx, token, "Another-Pass-8"); !domainerrors.IsValidationError(err) {
+		t.Fatalf("reset token should be single use, got %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "carol@example.com", "Brand-New-Pass-7", ""); err != nil {
+		t.Fatalf("login with reset password: %v", err)
+	}
+
+	if err := provider.ChangePassword(ctx, user.ID, testPassword, "Changed-Pass-99"); !domainerrors.IsAuthError(err) {
+		t.Fatalf("wrong current password should fail, got %v", err)
+	}
+	if err := provider.ChangePassword(ctx, user.ID, "Brand-New-Pass-7", "Changed-Pass-99"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "carol@example.com", "Brand-New-Pass-7", ""); err == nil {
+		t.Fatal("old password should no longer work")
+	}
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSymbol: true}
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"Abcdefgh1!", true},
+		{"Abcdef1!", false},
+		{"abcdefgh1!", false},
+		{"ABCDEFGH1!", false},
+		{"Abcdefghi!", false},
+		{"Abcdefghi1", false},
+		{"Aa1!" + strings.Repeat("x", 70), false},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(tt.password)
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) = %v, want valid=%v", tt.password, err, tt.valid)
+		}
+		if err != nil && !domainerrors.IsValidationError(err) {
+			t.Errorf("Validate(%q) should return a validation error, got %T", tt.password, err)
+		}
+	}
+	if err := (PasswordPolicy{}).Validate("elevenchars"); err == nil {
+		t.Fatal("default policy should require 12 characters")
+	}
+}
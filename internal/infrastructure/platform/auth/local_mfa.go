@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+const (
+	// MFAChallengeTokenType 是登錄頁面 MFA 步驟的挑戰令牌在 AuthStorageProvider 中的令牌類型，以用戶 ID 作為鍵
+	MFAChallengeTokenType = "mfa_challenge"
+
+	// mfaChallengeMaxAttempts 是一個挑戰允許輸入錯誤驗證碼的次數，超過後須重新輸入密碼
+	mfaChallengeMaxAttempts = 5
+)
+
+// MFARequiredError 表示密碼正確但還需要多因素認證
+type MFARequiredError struct {
+	// Enrollment 為 true 表示用戶的角色要求 MFA 但尚未登記驗證器
+	Enrollment bool
+}
+
+func (e *MFARequiredError) Error() string {
+	if e.Enrollment {
+		return "multi-factor authentication enrollment required"
+	}
+	return "multi-factor authentication code required"
+}
+
+// MFAChallenge 是密碼驗證通過後等待驗證碼的登錄挑戰
+type MFAChallenge struct {
+	// Token 提交驗證碼時一併提交，格式為 "<用戶ID>.<隨機值>"
+	Token string
+	// Enrollment 不為 nil 時用戶需要先登記驗證器，提交的第一個驗證碼同時確認登記
+	Enrollment *TOTPEnrollment
+}
+
+// MFAChallengeResult 是完成 MFA 挑戰的結果
+type MFAChallengeResult struct {
+	UserID string
+	// RecoveryCodes 在挑戰中完成登記時返回，只顯示一次
+	RecoveryCodes []string
+}
+
+// MFAEnabled 返回是否配置了多因素認證
+func (l *LocalAuthProvider) MFAEnabled() bool {
+	return l.mfa != nil
+}
+
+// StartMFAChallenge 在密碼驗證通過後調用，用戶需要 MFA 時創建挑戰，不需要時返回 nil。
+// 角色要求 MFA 但尚未登記的用戶同時開始登記，挑戰中返回新的 TOTP 密鑰。
+func (l *LocalAuthProvider) StartMFAChallenge(ctx context.Context, userID string) (*MFAChallenge, error) {
+	if l.mfa == nil {
+		return nil, nil
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := l.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	challenge := &MFAChallenge{}
+	if !enabled {
+		if !l.mfa.Required(user) {
+			return nil, nil
+		}
+		if challenge.Enrollment, err = l.mfa.BeginEnrollment(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	if challenge.Token, err = l.issueMFAChallenge(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// MFAChallengeUser 返回有效挑戰令牌所屬的用戶 ID，令牌無效或已過期時返回驗證錯誤。
+// 登錄頁面以此確定 MFA 步驟失敗計數的賬戶，不信任表單提交的用戶名。
+func (l *LocalAuthProvider) MFAChallengeUser(ctx context.Context, token string) (string, error) {
+	if l.mfa == nil {
+		return "", errMFADisabled()
+	}
+	challenge, err := l.readMFAChallenge(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return challenge.userID, nil
+}
+
+// CompleteMFAChallenge 以驗證碼或恢復碼完成挑戰。驗證碼錯誤時挑戰保持有效，累計錯誤達到上限後失效。
+func (l *LocalAuthProvider) CompleteMFAChallenge(ctx context.Context, token, code string) (*MFAChallengeResult, error) {
+	if l.mfa == nil {
+		return nil, errMFADisabled()
+	}
+	challenge, err := l.readMFAChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := l.mfa.Enabled(ctx, challenge.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MFAChallengeResult{UserID: challenge.userID}
+	if enabled {
+		err = l.mfa.Verify(ctx, challenge.userID, code)
+	} else {
+		result.RecoveryCodes, err = l.mfa.ConfirmEnrollment(ctx, challenge.userID, code)
+	}
+	if err != nil {
+		if domainerrors.IsAuthError(err) || domainerrors.IsValidationError(err) {
+			l.failMFAChallenge(ctx, challenge)
+		}
+		return nil, err
+	}
+	if err := l.store.RevokeToken(ctx, challenge.userID, MFAChallengeTokenType); err != nil {
+		return nil, fmt.Errorf("failed to revoke %s token: %w", MFAChallengeTokenType, err)
+	}
+	return result, nil
+}
+
+// MFAStatus 返回用戶的多因素認證狀態
+func (l *LocalAuthProvider) MFAStatus(ctx context.Context, userID string) (*MFAStatus, error) {
+	if l.mfa == nil {
+		return nil, errMFADisabled()
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.mfa.Status(ctx, user)
+}
+
+// BeginMFAEnrollment 為已登錄的用戶開始登記 TOTP 驗證器
+func (l *LocalAuthProvider) BeginMFAEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	if l.mfa == nil {
+		return nil, errMFADisabled()
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return l.mfa.BeginEnrollment(ctx, user)
+}
+
+// ConfirmMFAEnrollment 以第一個驗證碼完成登記，返回只顯示一次的恢復碼
+func (l *LocalAuthProvider) ConfirmMFAEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	if l.mfa == nil {
+		return nil, errMFADisabled()
+	}
+	return l.mfa.ConfirmEnrollment(ctx, userID, code)
+}
+
+// RegenerateRecoveryCodes 以當前的驗證碼確認後生成新的恢復碼
+func (l *LocalAuthProvider) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if l.mfa == nil {
+		return nil, errMFADisabled()
+	}
+	return l.mfa.RegenerateRecoveryCodes(ctx, userID, code)
+}
+
+// DisableMFA 驗證密碼與驗證碼後停用用戶的多因素認證；角色要求 MFA 的用戶不能停用
+func (l *LocalAuthProvider) DisableMFA(ctx context.Context, userID, password, code string) error {
+	if l.mfa == nil {
+		return errMFADisabled()
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if l.mfa.Required(user) {
+		return domainerrors.NewValidationError("mfa", "您的角色要求啟用多因素認證，不能停用")
+	}
+	ok, err := l.hasher.VerifyPassword(ctx, password, user.PasswordHash)
+	if err != nil || !ok {
+		return domainerrors.NewAuthError("current password is incorrect")
+	}
+	enabled, err := l.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		if err := l.mfa.Verify(ctx, user.ID, code); err != nil {
+			return err
+		}
+	}
+	return l.mfa.Disable(ctx, user.ID)
+}
+
+// ResetMFA 由管理員為遺失驗證器與恢復碼的用戶刪除 MFA 設定；角色要求 MFA 的用戶在下一次登錄時重新登記
+func (l *LocalAuthProvider) ResetMFA(ctx context.Context, userID string) error {
+	if l.mfa == nil {
+		return errMFADisabled()
+	}
+	if _, err := l.getUser(ctx, userID); err != nil {
+		return err
+	}
+	return l.mfa.Disable(ctx, userID)
+}
+
+// verifyMFA 在密碼驗證通過後檢查用戶是否需要以及是否提供了正確的驗證碼
+func (l *LocalAuthProvider) verifyMFA(ctx context.Context, user *entities.User, code string) error {
+	if l.mfa == nil {
+		return nil
+	}
+	enabled, err := l.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if l.mfa.Required(user) {
+			return &MFARequiredError{Enrollment: true}
+		}
+		return nil
+	}
+	if strings.TrimSpace(code) == "" {
+		return &MFARequiredError{}
+	}
+	return l.mfa.Verify(ctx, user.ID, code)
+}
+
+// mfaChallengeState 是保存的挑戰：隨機值散列、錯誤次數與過期時間，以 "<散列>:<次數>:<過期時間>" 保存
+type mfaChallengeState struct {
+	userID   string
+	hash     string
+	attempts int
+	expiry   int64
+}
+
+// issueMFAChallenge 生成挑戰令牌，同一用戶只保留最新的挑戰
+func (l *LocalAuthProvider) issueMFAChallenge(ctx context.Context, userID string) (string, error) {
+	secret, err := RandomURLToken(32)
+	if err != nil {
+		return "", err
+	}
+	state := mfaChallengeState{
+		userID: userID,
+		hash:   hashAccountToken(secret),
+		expiry: l.now().Add(l.mfa.challengeTTL).Unix(),
+	}
+	if err := l.saveMFAChallenge(ctx, state); err != nil {
+		return "", err
+	}
+	return userID + "." + secret, nil
+}
+
+// readMFAChallenge 驗證挑戰令牌並返回保存的狀態
+func (l *LocalAuthProvider) readMFAChallenge(ctx context.Context, token string) (*mfaChallengeState, error) {
+	invalid := domainerrors.NewValidationError("token", "驗證已過期，請重新登錄")
+	userID, secret, ok := strings.Cut(token, ".")
+	if !ok || userID == "" || secret == "" {
+		return nil, invalid
+	}
+	stored, err := l.store.GetToken(ctx, userID, MFAChallengeTokenType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s token: %w", MFAChallengeTokenType, err)
+	}
+	parts := strings.Split(stored, ":")
+	if len(parts) != 3 {
+		return nil, invalid
+	}
+	attempts, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, invalid
+	}
+	expiry, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || l.now().Unix() >= expiry {
+		return nil, invalid
+	}
+	if subtle.ConstantTimeCompare([]byte(parts[0]), []byte(hashAccountToken(secret))) != 1 {
+		return nil, invalid
+	}
+	return &mfaChallengeState{userID: userID, hash: parts[0], attempts: attempts, expiry: expiry}, nil
+}
+
+// failMFAChallenge 記錄一次錯誤的驗證碼，達到上限後撤銷挑戰；失敗只記錄日誌
+func (l *LocalAuthProvider) failMFAChallenge(ctx context.Context, state *mfaChallengeState) {
+	state.attempts++
+	var err error
+	if state.attempts >= mfaChallengeMaxAttempts {
+		l.logger.Warn("MFA 驗證碼錯誤次數過多，登錄挑戰已失效", "user_id", state.userID)
+		err = l.store.RevokeToken(ctx, state.userID, MFAChallengeTokenType)
+	} else {
+		err = l.saveMFAChallenge(ctx, *state)
+	}
+	if err != nil {
+		l.logger.Error("更新 MFA 登錄挑戰失敗", "user_id", state.userID, "error", err)
+	}
+}
+
+// saveMFAChallenge 保存挑戰狀態
+func (l *LocalAuthProvider) saveMFAChallenge(ctx context.Context, state mfaChallengeState) error {
+	value := fmt.Sprintf("%s:%d:%d", state.hash, state.attempts, state.expiry)
+	if err := l.store.StoreToken(ctx, state.userID, MFAChallengeTokenType, value, state.expiry); err != nil {
+		return fmt.Errorf("failed to store %s token: %w", MFAChallengeTokenType, err)
+	}
+	return nil
+}
+
+// errMFADisabled 是未配置多因素認證時的錯誤
+func errMFADisabled() error {
+	return domainerrors.NewValidationError("mfa", "未啟用多因素認證功能")
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// localTokenAlg 是本地簽發令牌使用的 JWS 演算法。只有本地提供者驗證這些令牌，
+// HS256 只在 verifyLocalToken 中接受，Keycloak 與 OIDC 的驗證路徑仍拒絕 HS*。
+const localTokenAlg = "HS256"
+
+// localTokenClaims 是本地簽發的訪問令牌中的聲明
+type localTokenClaims struct {
+	Subject           string   `json:"sub"`
+	Issuer            string   `json:"iss"`
+	Audience          string   `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	NotBefore         int64    `json:"nbf"`
+	IssuedAt          int64    `json:"iat"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	Roles             []string `json:"roles,omitempty"`
+}
+
+// signingKeyRing 從主密鑰派生按時間輪換的簽名密鑰
+// 職責: 每個輪換週期使用 HMAC-SHA256(主密鑰, 週期編號) 作為簽名密鑰，kid 為週期編號；
+// 驗證時接受當前與上一週期的密鑰，令牌有效期不超過輪換週期即可跨越輪換。
+// 密鑰由主密鑰確定性地派生，多個實例配置同一主密鑰時無需共享狀態即可互相驗證令牌。
+type signingKeyRing struct {
+	master   []byte
+	rotation time.Duration
+}
+
+// newSigningKeyRing 創建密鑰環，主密鑰至少 32 字節
+func newSigningKeyRing(master []byte, rotation time.Duration) (*signingKeyRing, error) {
+	if len(master) < 32 {
+		return nil, fmt.Errorf("token signing key must be at least 32 bytes")
+	}
+	if rotation <= 0 {
+		return nil, fmt.Errorf("key rotation interval must be positive")
+	}
+	return &signingKeyRing{master: master, rotation: rotation}, nil
+}
+
+// epoch 返回時間所在的輪換週期編號
+func (r *signingKeyRing) epoch(now time.Time) int64 {
+	return now.Unix() / int64(r.rotation/time.Second)
+}
+
+// current 返回當前週期的 kid 與簽名密鑰
+func (r *signingKeyRing) current(now time.Time) (string, []byte) {
+	kid := strconv.FormatInt(r.epoch(now), 10)
+	return kid, r.derive(kid)
+}
+
+// key 返回 kid 對應的簽名密鑰，只接受當前與上一週期
+func (r *signingKeyRing) key(kid string, now time.Time) ([]byte, error) {
+	epoch, err := strconv.ParseInt(kid, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if current := r.epoch(now); epoch != current && epoch != current-1 {
+		return nil, fmt.Errorf("signing key %q has been rotated out", kid)
+	}
+	return r.derive(kid), nil
+}
+
+// derive 派生 kid 的簽名密鑰
+func (r *signingKeyRing) derive(kid string) []byte {
+	mac := hmac.New(sha256.New, r.master)
+	mac.Write([]byte("detectviz-local-token:" + kid))
+	return mac.Sum(nil)
+}
+
+// signLocalToken 以當前週期的密鑰簽名聲明
+func signLocalToken(keys *signingKeyRing, claims localTokenClaims, now time.Time) (string, error) {
+	kid, key := keys.current(now)
+	header, err := json.Marshal(jwtHeader{Alg: localTokenAlg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyLocalToken 驗證本地簽發令牌的簽名，返回尚未檢查時間與受眾的聲明
+func verifyLocalToken(keys *signingKeyRing, token string, now time.Time) (*KeycloakClaims, error) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.header.Alg != localTokenAlg {
+		return nil, fmt.Errorf("unsupported JWT algorithm: %q", parsed.header.Alg)
+	}
+	key, err := keys.key(parsed.header.Kid, now)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parsed.signingInput))
+	if !hmac.Equal(mac.Sum(nil), parsed.signature) {
+		return nil, errors.New("invalid JWT signature")
+	}
+	return &parsed.claims, nil
+}
@@ -0,0 +1,541 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+const (
+	// AuditActionLockout 與 AuditActionUnlock 是登錄鎖定與解鎖寫入審計日誌的操作
+	AuditActionLockout = "auth.lockout"
+	AuditActionUnlock  = "auth.unlock"
+
+	// ThrottleScopeAccount 與 ThrottleScopeIP 是登錄失敗的計數維度
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+
+	// throttleKeyPrefix 是計數器在 CacheProvider 中的鍵前綴
+	throttleKeyPrefix = "login_throttle:"
+	// 每個賬戶或 IP 的嘗試計數、鎖定截止時間與下次可嘗試時間分別保存在以下後綴的鍵中
+	attemptsKeySuffix    = ":attempts"
+	lockedUntilKeySuffix = ":locked_until"
+	nextAttemptKeySuffix = ":next_attempt_at"
+	// auditSystemUser 是系統觸發的審計事件的用戶
+	auditSystemUser = "system"
+)
+
+// ThrottledError 表示登錄請求因連續失敗被延遲或鎖定
+type ThrottledError struct {
+	Scope      string        // account 或 ip
+	Locked     bool          // true 表示達到失敗上限被暫時鎖定，否則為指數退避
+	RetryAfter time.Duration // 可以再次嘗試之前需要等待的時間
+}
+
+func (e *ThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed login attempts: %s is locked for %s", e.Scope, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts: retry %s in %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds 返回 Retry-After 響應頭的秒數，向上取整且至少為 1
+func (e *ThrottledError) RetryAfterSeconds() int {
+	seconds := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// LoginThrottleConfig 定義登錄限流的配置
+type LoginThrottleConfig struct {
+	// MaxAccountFailures 同一賬戶連續失敗多少次後鎖定，默認 5
+	MaxAccountFailures int `yaml:"max_account_failures" json:"max_account_failures"`
+	// MaxIPFailures 同一來源 IP 連續失敗多少次後鎖定，默認 50
+	MaxIPFailures int `yaml:"max_ip_failures" json:"max_ip_failures"`
+	// BaseDelay 第一次失敗後的等待時間，之後每次加倍，默認 "1s"
+	BaseDelay string `yaml:"base_delay" json:"base_delay"`
+	// MaxDelay 兩次嘗試之間的最長等待時間，默認 "1m"
+	MaxDelay string `yaml:"max_delay" json:"max_delay"`
+	// LockoutDuration 鎖定時間，默認 "15m"
+	LockoutDuration string `yaml:"lockout_duration" json:"lockout_duration"`
+	// FailureWindow 最後一次失敗後多久重置失敗計數，默認 "1h"
+	FailureWindow string `yaml:"failure_window" json:"failure_window"`
+	// NotifyRecipients 鎖定時通知的管理員郵件地址；賬戶屬於已存在的用戶時同時通知該用戶保存的郵件地址
+	NotifyRecipients []string `yaml:"notify_recipients" json:"notify_recipients"`
+}
+
+// LockoutStatus 是一個賬戶或 IP 的失敗計數與鎖定狀態
+type LockoutStatus struct {
+	Scope         string
+	Subject       string
+	Failures      int       // 窗口內的失敗次數，包括已預留但尚未有結果的嘗試
+	LockedUntil   time.Time // 零值表示未鎖定
+	NextAttemptAt time.Time // 零值表示可以立即嘗試
+}
+
+// LockoutAccountLookup 根據登錄名查找已存在用戶保存的郵件地址，用戶不存在時返回空字串。
+// 登錄名由客戶端提交，鎖定通知只發給查到的地址，不直接使用登錄名。
+type LockoutAccountLookup interface {
+	AccountEmail(ctx context.Context, account string) (string, error)
+}
+
+// LoginThrottle 按賬戶與來源 IP 限制登錄嘗試
+// 職責: 每次失敗後按指數退避延遲下一次嘗試，連續失敗達到上限時暫時鎖定並通知、寫入審計日誌，登錄成功後重置賬戶計數。
+// 計數器保存在 CacheProvider 中，多個實例共享同一緩存時共同計數。Check 放行時以原子遞增為本次嘗試預留一次計數，
+// 併發的猜測因此不會超過失敗上限；鎖定與退避截止時間各自保存在單獨的鍵中，寫入退避不會覆蓋鎖定。
+type LoginThrottle struct {
+	cache    contracts.CacheProvider
+	audit    contracts.AuditLogProvider
+	notifier AccountNotifier
+	accounts LockoutAccountLookup
+	logger   contracts.Logger
+
+	maxAccountFailures int
+	maxIPFailures      int
+	baseDelay          time.Duration
+	maxDelay           time.Duration
+	lockout            time.Duration
+	window             time.Duration
+	recipients         []string
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewLoginThrottle 創建登錄限流器。audit 為 nil 時不寫入審計日誌，notifier 為 nil 時鎖定只記錄日誌，
+// accounts 為 nil 時賬戶鎖定只通知配置的管理員。
+func NewLoginThrottle(config LoginThrottleConfig, cache contracts.CacheProvider, audit contracts.AuditLogProvider,
+	notifier AccountNotifier, accounts LockoutAccountLookup, logger contracts.Logger) (*LoginThrottle, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("login throttle requires a cache provider")
+	}
+	if config.MaxAccountFailures < 0 || config.MaxIPFailures < 0 {
+		return nil, fmt.Errorf("max failures must not be negative")
+	}
+	if config.MaxAccountFailures == 0 {
+		config.MaxAccountFailures = 5
+	}
+	if config.MaxIPFailures == 0 {
+		config.MaxIPFailures = 50
+	}
+	baseDelay, err := parseDurationOrDefault(config.BaseDelay, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base_delay: %w", err)
+	}
+	maxDelay, err := parseDurationOrDefault(config.MaxDelay, time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_delay: %w", err)
+	}
+	lockout, err := parseDurationOrDefault(config.LockoutDuration, 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lockout_duration: %w", err)
+	}
+	window, err := parseDurationOrDefault(config.FailureWindow, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("invalid failure_window: %w", err)
+	}
+	if lockout <= 0 || window <= 0 {
+		return nil, fmt.Errorf("lockout_duration and failure_window must be positive")
+	}
+
+	logger.Info("初始化登錄限流",
+		"cache", cache.GetName(),
+		"max_account_failures", config.MaxAccountFailures,
+		"max_ip_failures", config.MaxIPFailures,
+		"lockout_duration", lockout.String())
+
+	return &LoginThrottle{
+		cache:              cache,
+		audit:              audit,
+		notifier:           notifier,
+		accounts:           accounts,
+		logger:             logger,
+		maxAccountFailures: config.MaxAccountFailures,
+		maxIPFailures:      config.MaxIPFailures,
+		baseDelay:          baseDelay,
+		maxDelay:           maxDelay,
+		lockout:            lockout,
+		window:             window,
+		recipients:         append([]string(nil), config.NotifyRecipients...),
+		now:                time.Now,
+	}, nil
+}
+
+// Check 在驗證密碼前調用，賬戶或 IP 處於退避或鎖定期間時返回 *ThrottledError。
+// 放行時為本次嘗試在賬戶與 IP 的計數中各預留一次，調用方隨後必須調用 RecordFailure、RecordSuccess 或 Release 之一。
+// 讀取緩存失敗時記錄錯誤並放行，避免緩存故障導致所有用戶無法登錄。
+func (t *LoginThrottle) Check(ctx context.Context, account, ip string) error {
+	now := t.now()
+	subjects := t.subjects(account, ip)
+	for _, subject := range subjects {
+		lockedUntil, err := t.loadTime(ctx, subject.key(lockedUntilKeySuffix))
+		if err != nil {
+			t.logger.Error("讀取登錄鎖定狀態失敗", "scope", subject.scope, "error", err)
+			continue
+		}
+		if now.Before(lockedUntil) {
+			return &ThrottledError{Scope: subject.scope, Locked: true, RetryAfter: lockedUntil.Sub(now)}
+		}
+		next, err := t.loadTime(ctx, subject.key(nextAttemptKeySuffix))
+		if err != nil {
+			t.logger.Error("讀取登錄退避狀態失敗", "scope", subject.scope, "error", err)
+			continue
+		}
+		if now.Before(next) {
+			return &ThrottledError{Scope: subject.scope, RetryAfter: next.Sub(now)}
+		}
+	}
+
+	var reserved []throttleSubject
+	for _, subject := range subjects {
+		attempts, err := t.cache.Increment(ctx, subject.key(attemptsKeySuffix), 1, t.window)
+		if err != nil {
+			t.logger.Error("預留登錄嘗試失敗", "scope", subject.scope, "error", err)
+			continue
+		}
+		reserved = append(reserved, subject)
+		if attempts > int64(t.limit(subject.scope)) {
+			// 併發的嘗試已用完剩餘的次數，撤回本次的預留
+			t.release(ctx, reserved)
+			return &ThrottledError{Scope: subject.scope, RetryAfter: t.backoff(int(attempts) - 1)}
+		}
+		if subject.scope == ThrottleScopeAccount {
+			// 按本次嘗試失敗設置賬戶的退避，結果返回前同一賬戶的其他嘗試同樣需要等待；
+			// IP 不預先退避，避免同一出口的用戶互相影響
+			t.saveTime(ctx, subject.key(nextAttemptKeySuffix), now.Add(t.backoff(int(attempts))), t.window)
+		}
+	}
+	return nil
+}
+
+// RecordFailure 記錄一次失敗的登錄，設置退避並在達到上限時鎖定賬戶或 IP。
+// 失敗已在 Check 時預留計數，沒有預留時 (例如 Check 時緩存不可用) 在此計數。
+func (t *LoginThrottle) RecordFailure(ctx context.Context, account, ip string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, subject := range t.subjects(account, ip) {
+		locked, err := t.fail(ctx, subject)
+		if err != nil {
+			return err
+		}
+		if locked != nil {
+			t.onLockout(ctx, subject.scope, subject.value, ip, *locked)
+		}
+	}
+	return nil
+}
+
+// RecordSuccess 在登錄成功後重置賬戶的失敗計數，並撤回 IP 計數中本次嘗試的預留。
+// IP 計數不重置，避免攻擊者以自己的賬戶清除來源 IP 的計數。
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, account, ip string) error {
+	if normalizeAccount(account) != "" {
+		if err := t.clear(ctx, throttleSubject{ThrottleScopeAccount, account}); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		t.release(ctx, []throttleSubject{{ThrottleScopeIP, ip}})
+	}
+	return nil
+}
+
+// Release 撤回 Check 為本次嘗試預留的計數，用於密碼正確但登錄尚未完成 (例如等待多因素認證)
+// 或因非認證錯誤失敗的請求
+func (t *LoginThrottle) Release(ctx context.Context, account, ip string) {
+	t.release(ctx, t.subjects(account, ip))
+}
+
+// Status 返回賬戶或 IP 當前的失敗計數與鎖定狀態
+func (t *LoginThrottle) Status(ctx context.Context, scope, subject string) (*LockoutStatus, error) {
+	if err := validateThrottleScope(scope); err != nil {
+		return nil, err
+	}
+	target := throttleSubject{scope, subject}
+	failures, err := t.loadCount(ctx, target.key(attemptsKeySuffix))
+	if err != nil {
+		return nil, err
+	}
+	lockedUntil, err := t.loadTime(ctx, target.key(lockedUntilKeySuffix))
+	if err != nil {
+		return nil, err
+	}
+	next, err := t.loadTime(ctx, target.key(nextAttemptKeySuffix))
+	if err != nil {
+		return nil, err
+	}
+	now := t.now()
+	status := &LockoutStatus{Scope: scope, Subject: subject, Failures: int(failures)}
+	if now.Before(lockedUntil) {
+		status.LockedUntil = lockedUntil
+	}
+	if now.Before(next) {
+		status.NextAttemptAt = next
+	}
+	return status, nil
+}
+
+// Unlock 清除賬戶或 IP 的失敗計數與鎖定，actor 是執行解鎖的管理員，記錄到審計日誌
+func (t *LoginThrottle) Unlock(ctx context.Context, scope, subject, actor string) error {
+	if err := validateThrottleScope(scope); err != nil {
+		return err
+	}
+	if err := t.clear(ctx, throttleSubject{scope, subject}); err != nil {
+		return err
+	}
+	t.logger.Info("登錄鎖定已解除", "scope", scope, "subject", subject, "actor", actor)
+	if actor == "" {
+		actor = auditSystemUser
+	}
+	t.writeAudit(ctx, actor, AuditActionUnlock, scope, subject, nil)
+	return nil
+}
+
+// throttleSubject 是一個計數維度與其值
+type throttleSubject struct {
+	scope string
+	value string
+}
+
+// subjects 返回需要計數的賬戶與 IP，空值跳過
+func (t *LoginThrottle) subjects(account, ip string) []throttleSubject {
+	var subjects []throttleSubject
+	if normalizeAccount(account) != "" {
+		subjects = append(subjects, throttleSubject{ThrottleScopeAccount, account})
+	}
+	if ip != "" {
+		subjects = append(subjects, throttleSubject{ThrottleScopeIP, ip})
+	}
+	return subjects
+}
+
+// key 返回計數維度的緩存鍵
+func (s throttleSubject) key(suffix string) string {
+	return throttleKey(s.scope, s.value) + suffix
+}
+
+// limit 返回計數維度的失敗上限
+func (t *LoginThrottle) limit(scope string) int {
+	if scope == ThrottleScopeIP {
+		return t.maxIPFailures
+	}
+	return t.maxAccountFailures
+}
+
+// fail 按失敗計數設置退避，達到上限時鎖定並返回鎖定截止時間
+func (t *LoginThrottle) fail(ctx context.Context, subject throttleSubject) (*time.Time, error) {
+	now := t.now()
+	lockedUntil, err := t.loadTime(ctx, subject.key(lockedUntilKeySuffix))
+	if err != nil {
+		return nil, err
+	}
+	// 鎖定期間的失敗不再計數，也不延長鎖定
+	if now.Before(lockedUntil) {
+		return nil, nil
+	}
+
+	failures, err := t.loadCount(ctx, subject.key(attemptsKeySuffix))
+	if err != nil {
+		return nil, err
+	}
+	if failures == 0 {
+		if failures, err = t.cache.Increment(ctx, subject.key(attemptsKeySuffix), 1, t.window); err != nil {
+			return nil, fmt.Errorf("failed to save login failures: %w", err)
+		}
+	}
+
+	if failures >= int64(t.limit(subject.scope)) {
+		lockedUntil = now.Add(t.lockout)
+		if err := t.cache.Set(ctx, subject.key(lockedUntilKeySuffix), strconv.FormatInt(lockedUntil.UnixMilli(), 10), t.lockout); err != nil {
+			return nil, fmt.Errorf("failed to save login lockout: %w", err)
+		}
+		// 鎖定期滿後重新計數
+		if err := t.cache.Delete(ctx, subject.key(attemptsKeySuffix)); err != nil {
+			t.logger.Error("重置登錄失敗計數失敗", "scope", subject.scope, "error", err)
+		}
+		if err := t.cache.Delete(ctx, subject.key(nextAttemptKeySuffix)); err != nil {
+			t.logger.Error("清除登錄退避失敗", "scope", subject.scope, "error", err)
+		}
+		t.logger.Warn("連續登錄失敗，已暫時鎖定", "scope", subject.scope, "subject", subject.value, "failures", failures)
+		return &lockedUntil, nil
+	}
+	next := now.Add(t.backoff(int(failures)))
+	if err := t.cache.Set(ctx, subject.key(nextAttemptKeySuffix), strconv.FormatInt(next.UnixMilli(), 10), t.window); err != nil {
+		return nil, fmt.Errorf("failed to save login failures: %w", err)
+	}
+	return nil, nil
+}
+
+// release 撤回 subjects 中預留的嘗試，賬戶同時清除按預留設置的退避；失敗只記錄日誌
+func (t *LoginThrottle) release(ctx context.Context, subjects []throttleSubject) {
+	for _, subject := range subjects {
+		attempts, err := t.cache.Increment(ctx, subject.key(attemptsKeySuffix), -1, t.window)
+		if err != nil {
+			t.logger.Error("撤回登錄嘗試失敗", "scope", subject.scope, "error", err)
+			continue
+		}
+		// 鎖定時計數已被刪除，撤回後低於零的計數直接刪除
+		if attempts <= 0 {
+			if err := t.cache.Delete(ctx, subject.key(attemptsKeySuffix)); err != nil {
+				t.logger.Error("撤回登錄嘗試失敗", "scope", subject.scope, "error", err)
+			}
+		}
+		if subject.scope == ThrottleScopeAccount {
+			if err := t.cache.Delete(ctx, subject.key(nextAttemptKeySuffix)); err != nil {
+				t.logger.Error("清除登錄退避失敗", "scope", subject.scope, "error", err)
+			}
+		}
+	}
+}
+
+// clear 刪除賬戶或 IP 的計數、退避與鎖定
+func (t *LoginThrottle) clear(ctx context.Context, subject throttleSubject) error {
+	for _, suffix := range []string{attemptsKeySuffix, nextAttemptKeySuffix, lockedUntilKeySuffix} {
+		if err := t.cache.Delete(ctx, subject.key(suffix)); err != nil {
+			return fmt.Errorf("failed to clear login failures: %w", err)
+		}
+	}
+	return nil
+}
+
+// backoff 返回第 failures 次失敗後的等待時間：baseDelay * 2^(failures-1)，不超過 maxDelay
+func (t *LoginThrottle) backoff(failures int) time.Duration {
+	delay := t.baseDelay
+	for i := 1; i < failures && delay < t.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	return delay
+}
+
+// onLockout 寫入審計日誌並發送鎖定通知，失敗只記錄日誌
+func (t *LoginThrottle) onLockout(ctx context.Context, scope, subject, ip string, lockedUntil time.Time) {
+	t.writeAudit(ctx, auditSystemUser, AuditActionLockout, scope, subject, map[string]any{
+		"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+		"ip":           ip,
+	})
+	if t.notifier == nil {
+		return
+	}
+
+	recipients := append([]string(nil), t.recipients...)
+	if scope == ThrottleScopeAccount && t.accounts != nil {
+		email, err := t.accounts.AccountEmail(ctx, subject)
+		if err != nil {
+			t.logger.Error("查找鎖定賬戶的郵件地址失敗", "error", err)
+		} else if email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+	var subjectLine, body string
+	if scope == ThrottleScopeAccount {
+		subjectLine = "Detectviz 賬戶已暫時鎖定"
+		body = fmt.Sprintf("賬戶 %s 因連續登錄失敗已被鎖定至 %s (最後一次嘗試來自 %s)。\n\n如果這不是您本人的操作，請聯繫管理員並考慮修改密碼。",
+			subject, lockedUntil.UTC().Format(time.RFC3339), ip)
+	} else {
+		subjectLine = "Detectviz 登錄來源已暫時封鎖"
+		body = fmt.Sprintf("來源 IP %s 因連續登錄失敗已被封鎖至 %s。", subject, lockedUntil.UTC().Format(time.RFC3339))
+	}
+	metadata := map[string]interface{}{"type": AuditActionLockout, "scope": scope}
+	if err := t.notifier.SendNotification(ctx, strings.Join(recipients, ","), subjectLine, body, metadata); err != nil {
+		t.logger.Error("發送鎖定通知失敗", "scope", scope, "error", err)
+	}
+}
+
+// writeAudit 寫入審計日誌，失敗只記錄日誌
+func (t *LoginThrottle) writeAudit(ctx context.Context, actor, action, scope, subject string, metadata map[string]any) {
+	if t.audit == nil {
+		return
+	}
+	if err := t.audit.LogAction(ctx, actor, action, auditResource(scope, subject), metadata); err != nil {
+		t.logger.Error("寫入登錄鎖定審計日誌失敗", "action", action, "error", err)
+	}
+}
+
+// loadCount 讀取嘗試計數，不存在時返回 0。內存緩存返回 int64，SQL 緩存以 JSON 解碼為 float64。
+func (t *LoginThrottle) loadCount(ctx context.Context, key string) (int64, error) {
+	value, err := t.cache.Get(ctx, key)
+	if err != nil || value == nil {
+		return 0, err
+	}
+	switch count := value.(type) {
+	case int64:
+		return count, nil
+	case float64:
+		return int64(count), nil
+	default:
+		return 0, fmt.Errorf("unexpected login throttle count of type %T", value)
+	}
+}
+
+// loadTime 讀取以 Unix 毫秒字串保存的時間，不存在時返回零值
+func (t *LoginThrottle) loadTime(ctx context.Context, key string) (time.Time, error) {
+	value, err := t.cache.Get(ctx, key)
+	if err != nil || value == nil {
+		return time.Time{}, err
+	}
+	raw, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected login throttle value of type %T", value)
+	}
+	millis, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode login throttle time: %w", err)
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// saveTime 以 Unix 毫秒字串保存時間，以便在不同的緩存實現間一致往返；失敗只記錄日誌
+func (t *LoginThrottle) saveTime(ctx context.Context, key string, at time.Time, ttl time.Duration) {
+	if err := t.cache.Set(ctx, key, strconv.FormatInt(at.UnixMilli(), 10), ttl); err != nil {
+		t.logger.Error("保存登錄退避失敗", "error", err)
+	}
+}
+
+// throttleKey 返回計數器的緩存鍵，賬戶不區分大小寫並以散列表示，避免鍵過長
+func throttleKey(scope, subject string) string {
+	if scope == ThrottleScopeAccount {
+		sum := sha256.Sum256([]byte(normalizeAccount(subject)))
+		return throttleKeyPrefix + scope + ":" + hex.EncodeToString(sum[:])
+	}
+	return throttleKeyPrefix + scope + ":" + subject
+}
+
+// normalizeAccount 返回去除空白並轉為小寫的賬戶名
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// auditResource 返回審計日誌的資源，例如 "login:account:alice@example.com"，不超過 audit_logs.resource 的長度
+func auditResource(scope, subject string) string {
+	if scope == ThrottleScopeAccount {
+		subject = normalizeAccount(subject)
+	}
+	resource := "login:" + scope + ":" + subject
+	if len(resource) > 255 {
+		resource = resource[:255]
+	}
+	return resource
+}
+
+// validateThrottleScope 檢查計數維度
+func validateThrottleScope(scope string) error {
+	if scope != ThrottleScopeAccount && scope != ThrottleScopeIP {
+		return fmt.Errorf("unknown throttle scope %q", scope)
+	}
+	return nil
+}
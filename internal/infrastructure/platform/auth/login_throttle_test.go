@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/cache"
+)
+
+// recordingAuditLog 記錄寫入的審計事件
+type recordingAuditLog struct {
+	mu      sync.Mutex
+	entries []string // "userID action resource"
+}
+
+func (a *recordingAuditLog) LogAction(ctx context.Context, userID, action, resource string, metadata map[string]any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, userID+" "+action+" "+resource)
+	return nil
+}
+
+func (a *recordingAuditLog) GetName() string { return "recording_audit_log" }
+
+// knownAccounts 以小寫登錄名查找用戶保存的郵件地址
+type knownAccounts map[string]string
+
+func (k knownAccounts) AccountEmail(ctx context.Context, account string) (string, error) {
+	return k[strings.ToLower(account)], nil
+}
+
+func newTestLoginThrottle(t *testing.T, config LoginThrottleConfig) (*LoginThrottle, *recordingAuditLog, *recordingMailer, *time.Time) {
+	t.Helper()
+	audit := &recordingAuditLog{}
+	mailer := &recordingMailer{}
+	throttle, err := NewLoginThrottle(config, cache.NewMemoryCacheProvider(&testLogger{}), audit, mailer,
+		knownAccounts{"alice@example.com": "alice@example.com"}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+	return throttle, audit, mailer, &now
+}
+
+// throttled 斷言 Check 返回限流錯誤並返回它
+func throttled(t *testing.T, err error) *ThrottledError {
+	t.Helper()
+	var target *ThrottledError
+	if !errors.As(err, &target) {
+		t.Fatalf("expected throttled error, got %v", err)
+	}
+	return target
+}
+
+func TestLoginThrottle_ExponentialBackoffAndLockout(t *testing.T) {
+	ctx := context.Background()
+	throttle, audit, mailer, now := newTestLoginThrottle(t, LoginThrottleConfig{
+		MaxAccountFailures: 4,
+		BaseDelay:          "1s",
+		MaxDelay:           "3s",
+		LockoutDuration:    "10m",
+		NotifyRecipients:   []string{"security@example.com"},
+	})
+
+	if err := throttle.Check(ctx, "Alice@Example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("first attempt should be allowed: %v", err)
+	}
+
+	// 延遲依次為 1s、2s、3s (上限)，賬戶不區分大小寫
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if err := throttle.RecordFailure(ctx, "alice@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+		err := throttled(t, throttle.Check(ctx, "ALICE@example.com", "10.0.0.2"))
+		if err.Locked || err.Scope != ThrottleScopeAccount || err.RetryAfter != want {
+			t.Fatalf("failure %d: got %+v, want backoff %s", i+1, err, want)
+		}
+		*now = now.Add(want)
+		if err := throttle.Check(ctx, "alice@example.com", "10.0.0.2"); err != nil {
+			t.Fatalf("failure %d: attempt after backoff should be allowed: %v", i+1, err)
+		}
+	}
+	if len(audit.entries) != 0 {
+		t.Fatalf("no audit entry expected before lockout, got %v", audit.entries)
+	}
+
+	if err := throttle.RecordFailure(ctx, "alice@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	err := throttled(t, throttle.Check(ctx, "alice@example.com", "10.0.0.2"))
+	if !err.Locked || err.RetryAfter != 10*time.Minute || err.RetryAfterSeconds() != 600 {
+		t.Fatalf("expected 10m lockout, got %+v", err)
+	}
+	if len(audit.entries) != 1 || audit.entries[0] != "system auth.lockout login:account:alice@example.com" {
+		t.Fatalf("unexpected audit entries %v", audit.entries)
+	}
+	if len(mailer.recipients) != 1 || mailer.recipients[0] != "security@example.com,alice@example.com" {
+		t.Fatalf("unexpected lockout notification recipients %v", mailer.recipients)
+	}
+
+	// 鎖定期間的失敗不延長鎖定，也不重複通知
+	*now = now.Add(5 * time.Minute)
+	if err := throttle.RecordFailure(ctx, "alice@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttled(t, throttle.Check(ctx, "alice@example.com", "")); err.RetryAfter != 5*time.Minute || len(audit.entries) != 1 {
+		t.Fatalf("lockout should not be extended, got %+v and %v", err, audit.entries)
+	}
+
+	// 鎖定期滿後重新計數
+	*now = now.Add(5 * time.Minute)
+	status, statusErr := throttle.Status(ctx, ThrottleScopeAccount, "alice@example.com")
+	if statusErr != nil || status.Failures != 0 || !status.LockedUntil.IsZero() {
+		t.Fatalf("unexpected status after lockout %+v, %v", status, statusErr)
+	}
+	if err := throttle.Check(ctx, "alice@example.com", ""); err != nil {
+		t.Fatalf("lockout should have expired: %v", err)
+	}
+}
+
+// 登錄名由客戶端提交，不屬於已存在用戶的地址不會收到鎖定通知
+func TestLoginThrottle_LockoutOfUnknownAccountNotifiesOnlyAdmins(t *testing.T) {
+	ctx := context.Background()
+	throttle, _, mailer, _ := newTestLoginThrottle(t, LoginThrottleConfig{
+		MaxAccountFailures: 1,
+		NotifyRecipients:   []string{"security@example.com"},
+	})
+	if err := throttle.Check(ctx, "victim@elsewhere.example", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.RecordFailure(ctx, "victim@elsewhere.example", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.recipients) != 1 || mailer.recipients[0] != "security@example.com" {
+		t.Fatalf("unexpected lockout notification recipients %v", mailer.recipients)
+	}
+}
+
+func TestLoginThrottle_IPLockoutSurvivesSuccessfulLogin(t *testing.T) {
+	ctx := context.Background()
+	throttle, audit, mailer, now := newTestLoginThrottle(t, LoginThrottleConfig{
+		MaxAccountFailures: 10,
+		MaxIPFailures:      3,
+		BaseDelay:          "1s",
+		MaxDelay:           "1s",
+	})
+
+	// 同一 IP 嘗試不同的賬戶
+	for _, account := range []string{"a@example.com", "b@example.com"} {
+		if err := throttle.Check(ctx, account, "192.0.2.7"); err != nil {
+			t.Fatal(err)
+		}
+		if err := throttle.RecordFailure(ctx, account, "192.0.2.7"); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+	}
+	// 攻擊者以自己的賬戶登錄成功只重置賬戶計數，IP 計數只撤回本次嘗試
+	if err := throttle.Check(ctx, "mallory@example.com", "192.0.2.7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.RecordSuccess(ctx, "mallory@example.com", "192.0.2.7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.Check(ctx, "c@example.com", "192.0.2.7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.RecordFailure(ctx, "c@example.com", "192.0.2.7"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := throttled(t, throttle.Check(ctx, "d@example.com", "192.0.2.7"))
+	if err.Scope != ThrottleScopeIP || !err.Locked {
+		t.Fatalf("expected ip lockout, got %+v", err)
+	}
+	if err := throttle.Check(ctx, "d@example.com", "192.0.2.8"); err != nil {
+		t.Fatalf("other ips should not be affected: %v", err)
+	}
+	if len(audit.entries) != 1 || !strings.HasSuffix(audit.entries[0], "auth.lockout login:ip:192.0.2.7") {
+		t.Fatalf("unexpected audit entries %v", audit.entries)
+	}
+	if len(mailer.recipients) != 0 {
+		t.Fatalf("ip lockout without recipients should not send email, got %v", mailer.recipients)
+	}
+}
+
+func TestLoginThrottle_SuccessResetsAndAdminUnlock(t *testing.T) {
+	ctx := context.Background()
+	throttle, audit, _, now := newTestLoginThrottle(t, LoginThrottleConfig{MaxAccountFailures: 2})
+
+	if err := throttle.Check(ctx, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.RecordFailure(ctx, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.RecordSuccess(ctx, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := throttle.Check(ctx, "bob", ""); err != nil {
+			t.Fatalf("attempt %d should be allowed after a successful login: %v", i+1, err)
+		}
+		if err := throttle.RecordFailure(ctx, "bob", ""); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+	}
+	if err := throttled(t, throttle.Check(ctx, "bob", "")); !err.Locked {
+		t.Fatalf("expected lockout, got %+v", err)
+	}
+	status, err := throttle.Status(ctx, ThrottleScopeAccount, "Bob")
+	if err != nil || status.LockedUntil.IsZero() {
+		t.Fatalf("status should report the lockout: %+v, %v", status, err)
+	}
+
+	if err := throttle.Unlock(ctx, "user", "bob", "admin"); err == nil {
+		t.Fatal("unknown scope should be rejected")
+	}
+	if err := throttle.Unlock(ctx, ThrottleScopeAccount, "BOB", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := throttle.Check(ctx, "bob", ""); err != nil {
+		t.Fatalf("unlocked account should be allowed: %v", err)
+	}
+	if len(audit.entries) != 2 || audit.entries[1] != "admin auth.unlock login:account:bob" {
+		t.Fatalf("unexpected audit entries %v", audit.entries)
+	}
+}
+
+// 併發的嘗試在 Check 時預留計數，任何結果返回前都不會超過失敗上限
+func TestLoginThrottle_ConcurrentAttemptsCannotExceedLimit(t *testing.T) {
+	ctx := context.Background()
+	throttle, audit, _, _ := newTestLoginThrottle(t, LoginThrottleConfig{MaxAccountFailures: 3})
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := throttle.Check(ctx, "alice@example.com", ""); err != nil {
+				return
+			}
+			mu.Lock()
+			allowed++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if allowed == 0 || allowed > 3 {
+		t.Fatalf("expected between 1 and 3 concurrent attempts to be allowed, got %d", allowed)
+	}
+
+	for i := 0; i < allowed; i++ {
+		if err := throttle.RecordFailure(ctx, "alice@example.com", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if allowed == 3 && len(audit.entries) != 1 {
+		t.Fatalf("expected a lockout after the allowed attempts failed, got %v", audit.entries)
+	}
+
+	// 密碼正確但等待多因素認證時撤回預留，不佔用失敗次數
+	throttle, _, _, _ = newTestLoginThrottle(t, LoginThrottleConfig{MaxAccountFailures: 1})
+	for i := 0; i < 3; i++ {
+		if err := throttle.Check(ctx, "bob", "10.0.0.1"); err != nil {
+			t.Fatalf("released attempt %d should not count: %v", i+1, err)
+		}
+		throttle.Release(ctx, "bob", "10.0.0.1")
+	}
+	status, err := throttle.Status(ctx, ThrottleScopeIP, "10.0.0.1")
+	if err != nil || status.Failures != 0 {
+		t.Fatalf("released attempts should not be counted: %+v, %v", status, err)
+	}
+}
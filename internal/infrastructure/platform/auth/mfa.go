@@ -0,0 +1,428 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// recoveryCodeAlphabet 是恢復碼使用的小寫 Base32 字母表，不含容易與字母混淆的 0、1、8、9
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// MFAConfig 定義 TOTP 多因素認證的配置
+type MFAConfig struct {
+	// Issuer 驗證器應用中顯示的發行者，默認 "Detectviz"
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// RequiredRoles 擁有其中任一角色的用戶必須啟用 MFA，未登記的用戶在下一次登錄時登記
+	RequiredRoles []string `yaml:"required_roles" json:"required_roles"`
+	// RecoveryCodes 每次生成的一次性恢復碼數量，默認 10
+	RecoveryCodes int `yaml:"recovery_codes" json:"recovery_codes"`
+	// Skew 驗證時允許前後偏差的時間步 (30 秒) 數量，默認 1
+	Skew int `yaml:"skew" json:"skew"`
+	// ChallengeTTL 登錄頁面輸入驗證碼的時限，默認 "5m"
+	ChallengeTTL string `yaml:"challenge_ttl" json:"challenge_ttl"`
+}
+
+// MFAStatus 是用戶的多因素認證狀態
+type MFAStatus struct {
+	Enabled                bool // 已完成 TOTP 登記
+	Pending                bool // 已開始登記但尚未以第一個驗證碼確認
+	Required               bool // 用戶的角色要求啟用 MFA
+	RecoveryCodesRemaining int
+}
+
+// TOTPEnrollment 是開始登記時返回的 TOTP 密鑰，只在登記時顯示
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string // otpauth URI，驗證器應用掃描其二維碼即完成添加
+}
+
+// TOTPAuthenticator 管理本地用戶的 TOTP 多因素認證 (RFC 6238)
+// 職責: 登記驗證器並以第一個驗證碼確認、驗證登錄時的驗證碼或一次性恢復碼、生成恢復碼。
+// TOTP 密鑰以 AES-256-GCM 加密保存，加密密鑰來自 SecretsProvider；恢復碼只保存 SHA-256 雜湊。
+// 每個時間步的驗證碼只能使用一次，由倉儲的條件更新保證，多個實例共享同一數據庫時同樣有效。
+type TOTPAuthenticator struct {
+	repo   interfaces.UserMFARepository
+	tx     contracts.TransactionManager
+	cipher *secretCipher
+	logger contracts.Logger
+
+	issuer        string
+	requiredRoles map[string]struct{}
+	recoveryCodes int
+	skew          int
+	challengeTTL  time.Duration
+
+	now func() time.Time
+}
+
+// NewTOTPAuthenticator 創建 TOTP 多因素認證。encryptionKey 是加密 TOTP 密鑰的主密鑰 (至少 32 字節)，
+// 更換後已登記的驗證器無法解密，用戶需要以恢復碼登錄後重新登記；tx 為 nil 時不在事務中寫入。
+func NewTOTPAuthenticator(config MFAConfig, repo interfaces.UserMFARepository, tx contracts.TransactionManager,
+	encryptionKey []byte, logger contracts.Logger) (*TOTPAuthenticator, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("TOTP authenticator requires an MFA repository")
+	}
+	secrets, err := newSecretCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	challengeTTL, err := parseDurationOrDefault(config.ChallengeTTL, 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid challenge_ttl: %w", err)
+	}
+	if challengeTTL <= 0 {
+		return nil, fmt.Errorf("challenge_ttl must be positive")
+	}
+	skew := config.Skew
+	if skew == 0 {
+		skew = 1
+	}
+	if skew < 0 || skew > 10 {
+		return nil, fmt.Errorf("skew must be between 1 and 10, got %d", skew)
+	}
+	recoveryCodes := config.RecoveryCodes
+	if recoveryCodes == 0 {
+		recoveryCodes = 10
+	}
+	if recoveryCodes < 0 || recoveryCodes > 50 {
+		return nil, fmt.Errorf("recovery_codes must be between 1 and 50, got %d", recoveryCodes)
+	}
+	issuer := config.Issuer
+	if issuer == "" {
+		issuer = "Detectviz"
+	}
+	requiredRoles := make(map[string]struct{}, len(config.RequiredRoles))
+	for _, role := range config.RequiredRoles {
+		requiredRoles[role] = struct{}{}
+	}
+
+	logger.Info("初始化 TOTP 多因素認證",
+		"issuer", issuer,
+		"required_roles", config.RequiredRoles,
+		"recovery_codes", recoveryCodes)
+
+	return &TOTPAuthenticator{
+		repo:          repo,
+		tx:            tx,
+		cipher:        secrets,
+		logger:        logger,
+		issuer:        issuer,
+		requiredRoles: requiredRoles,
+		recoveryCodes: recoveryCodes,
+		skew:          skew,
+		challengeTTL:  challengeTTL,
+		now:           time.Now,
+	}, nil
+}
+
+// Required 返回用戶的角色是否要求啟用 MFA
+func (m *TOTPAuthenticator) Required(user *entities.User) bool {
+	for _, role := range user.Roles {
+		if _, ok := m.requiredRoles[role]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled 返回用戶是否已完成 TOTP 登記
+func (m *TOTPAuthenticator) Enabled(ctx context.Context, userID string) (bool, error) {
+	mfa, err := m.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read MFA settings: %w", err)
+	}
+	return mfa != nil && mfa.Enabled(), nil
+}
+
+// Status 返回用戶的多因素認證狀態
+func (m *TOTPAuthenticator) Status(ctx context.Context, user *entities.User) (*MFAStatus, error) {
+	mfa, err := m.repo.GetMFA(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MFA settings: %w", err)
+	}
+	status := &MFAStatus{Required: m.Required(user)}
+	if mfa == nil {
+		return status, nil
+	}
+	status.Enabled = mfa.Enabled()
+	status.Pending = !mfa.Enabled()
+	if status.Enabled {
+		codes, err := m.repo.ListRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list recovery codes: %w", err)
+		}
+		for _, code := range codes {
+			if !code.Used() {
+				status.RecoveryCodesRemaining++
+			}
+		}
+	}
+	return status, nil
+}
+
+// BeginEnrollment 為用戶生成新的 TOTP 密鑰，以第一個驗證碼確認前不生效；重新開始會替換尚未確認的密鑰
+func (m *TOTPAuthenticator) BeginEnrollment(ctx context.Context, user *entities.User) (*TOTPEnrollment, error) {
+	existing, err := m.repo.GetMFA(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MFA settings: %w", err)
+	}
+	if existing != nil && existing.Enabled() {
+		return nil, domainerrors.NewValidationError("mfa", "已啟用多因素認證，請先停用後再重新登記")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := m.cipher.seal(secret, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now().UTC()
+	if err := m.repo.SaveMFA(ctx, &entities.UserMFA{
+		UserID:          user.ID,
+		EncryptedSecret: sealed,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save MFA settings: %w", err)
+	}
+	m.logger.Info("開始登記 TOTP 驗證器", "user_id", user.ID)
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(m.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment 以驗證器顯示的第一個驗證碼完成登記，返回只顯示一次的恢復碼
+func (m *TOTPAuthenticator) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	mfa, err := m.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MFA settings: %w", err)
+	}
+	if mfa == nil {
+		return nil, domainerrors.NewValidationError("mfa", "請先開始登記驗證器")
+	}
+	if mfa.Enabled() {
+		return nil, domainerrors.NewValidationError("mfa", "已啟用多因素認證")
+	}
+	step, ok, err := m.matchCode(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domainerrors.NewValidationError("code", "驗證碼不正確，請確認驗證器的時間設定後重試")
+	}
+
+	var codes []string
+	err = m.inTx(ctx, func(ctx context.Context) error {
+		now := m.now().UTC()
+		mfa.ConfirmedAt = now
+		mfa.LastUsedStep = step
+		mfa.UpdatedAt = now
+		if err := m.repo.SaveMFA(ctx, mfa); err != nil {
+			return fmt.Errorf("failed to save MFA settings: %w", err)
+		}
+		codes, err = m.replaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Info("已啟用 TOTP 多因素認證", "user_id", userID)
+	return codes, nil
+}
+
+// Verify 驗證登錄時輸入的 TOTP 驗證碼或一次性恢復碼，兩者都只能使用一次
+func (m *TOTPAuthenticator) Verify(ctx context.Context, userID, code string) error {
+	invalid := domainerrors.NewAuthError("invalid verification code")
+	mfa, err := m.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to read MFA settings: %w", err)
+	}
+	if mfa == nil || !mfa.Enabled() {
+		return invalid
+	}
+
+	normalized := normalizeMFACode(code)
+	if len(normalized) != totpDigits || !isDigits(normalized) {
+		return m.useRecoveryCode(ctx, userID, normalized, invalid)
+	}
+	step, ok, err := m.matchCode(mfa, normalized)
+	if err != nil {
+		return err
+	}
+	if !ok || step <= mfa.LastUsedStep {
+		return invalid
+	}
+	advanced, err := m.repo.AdvanceStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record verification code: %w", err)
+	}
+	if !advanced {
+		// 併發請求已使用同一驗證碼
+		return invalid
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 以當前的 TOTP 驗證碼確認後生成新的恢復碼，舊的恢復碼全部失效
+func (m *TOTPAuthenticator) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	normalized := normalizeMFACode(code)
+	if len(normalized) != totpDigits || !isDigits(normalized) {
+		return nil, domainerrors.NewValidationError("code", "請輸入驗證器中的 6 位驗證碼")
+	}
+	enabled, err := m.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, domainerrors.NewValidationError("mfa", "尚未啟用多因素認證")
+	}
+	if err := m.Verify(ctx, userID, normalized); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = m.inTx(ctx, func(ctx context.Context) error {
+		codes, err = m.replaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Info("已重新生成恢復碼", "user_id", userID)
+	return codes, nil
+}
+
+// Disable 刪除用戶的 TOTP 設定與恢復碼
+func (m *TOTPAuthenticator) Disable(ctx context.Context, userID string) error {
+	if err := m.repo.DeleteMFA(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete MFA settings: %w", err)
+	}
+	m.logger.Info("已停用 TOTP 多因素認證", "user_id", userID)
+	return nil
+}
+
+// matchCode 解密 TOTP 密鑰並查找與 code 匹配的時間步
+func (m *TOTPAuthenticator) matchCode(mfa *entities.UserMFA, code string) (int64, bool, error) {
+	secret, err := m.cipher.open(mfa.EncryptedSecret, mfa.UserID)
+	if err != nil {
+		m.logger.Error("解密 TOTP 密鑰失敗，加密密鑰可能已更換", "user_id", mfa.UserID, "error", err)
+		return 0, false, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	step, ok := matchTOTP(key, normalizeMFACode(code), m.now(), m.skew)
+	return step, ok, nil
+}
+
+// useRecoveryCode 查找並標記未使用的恢復碼
+func (m *TOTPAuthenticator) useRecoveryCode(ctx context.Context, userID, code string, invalid error) error {
+	if code == "" {
+		return invalid
+	}
+	codes, err := m.repo.ListRecoveryCodes(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list recovery codes: %w", err)
+	}
+	hash := hashRecoveryCode(userID, code)
+	for _, stored := range codes {
+		if stored.Used() || subtle.ConstantTimeCompare([]byte(stored.CodeHash), []byte(hash)) != 1 {
+			continue
+		}
+		used, err := m.repo.UseRecoveryCode(ctx, stored.ID, m.now().UTC())
+		if err != nil {
+			return fmt.Errorf("failed to use recovery code: %w", err)
+		}
+		if !used {
+			return invalid
+		}
+		m.logger.Warn("用戶以恢復碼完成多因素認證", "user_id", userID)
+		return nil
+	}
+	return invalid
+}
+
+// replaceRecoveryCodes 生成並保存新的恢復碼，返回明文
+func (m *TOTPAuthenticator) replaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	now := m.now().UTC()
+	plain := make([]string, 0, m.recoveryCodes)
+	stored := make([]*entities.MFARecoveryCode, 0, m.recoveryCodes)
+	for i := 0; i < m.recoveryCodes; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain = append(plain, code)
+		stored = append(stored, &entities.MFARecoveryCode{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(userID, normalizeMFACode(code)),
+			CreatedAt: now,
+		})
+	}
+	if err := m.repo.ReplaceRecoveryCodes(ctx, userID, stored); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return plain, nil
+}
+
+// inTx 在事務中執行 fn，未配置事務管理器時直接執行
+func (m *TOTPAuthenticator) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.tx == nil {
+		return fn(ctx)
+	}
+	return m.tx.RunInTx(ctx, fn)
+}
+
+// generateRecoveryCode 生成 "xxxx-xxxx-xxxx-xxxx" 格式的恢復碼 (80 位隨機數)
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	var b strings.Builder
+	for i, v := range raw {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// hashRecoveryCode 返回恢復碼的 SHA-256 雜湊，以用戶 ID 區分不同用戶的相同恢復碼
+func hashRecoveryCode(userID, normalized string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeMFACode 去除驗證碼與恢復碼中的空白與連字號並轉為小寫
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "\t", "").Replace(code))
+}
+
+// isDigits 返回 s 是否只包含數字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/hasher"
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/domain/entities"
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+// memoryMFARepository 是以 map 保存 MFA 設定的 UserMFARepository 替身
+type memoryMFARepository struct {
+	mu    sync.Mutex
+	mfa   map[string]entities.UserMFA
+	codes map[string][]entities.MFARecoveryCode
+}
+
+func newMemoryMFARepository() *memoryMFARepository {
+	return &memoryMFARepository{
+		mfa:   make(map[string]entities.UserMFA),
+		codes: make(map[string][]entities.MFARecoveryCode),
+	}
+}
+
+func (r *memoryMFARepository) GetMFA(ctx context.Context, userID string) (*entities.UserMFA, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &mfa, nil
+}
+
+func (r *memoryMFARepository) SaveMFA(ctx context.Context, mfa *entities.UserMFA) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mfa[mfa.UserID] = *mfa
+	return nil
+}
+
+func (r *memoryMFARepository) DeleteMFA(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mfa, userID)
+	delete(r.codes, userID)
+	return nil
+}
+
+func (r *memoryMFARepository) AdvanceStep(ctx context.Context, userID string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mfa, ok := r.mfa[userID]
+	if !ok || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	r.mfa[userID] = mfa
+	return true, nil
+}
+
+func (r *memoryMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[userID] = nil
+	for _, code := range codes {
+		r.codes[userID] = append(r.codes[userID], *code)
+	}
+	return nil
+}
+
+func (r *memoryMFARepository) ListRecoveryCodes(ctx context.Context, userID string) ([]*entities.MFARecoveryCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var codes []*entities.MFARecoveryCode
+	for _, code := range r.codes[userID] {
+		codes = append(codes, &code)
+	}
+	return codes, nil
+}
+
+func (r *memoryMFARepository) UseRecoveryCode(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for userID, codes := range r.codes {
+		for i := range codes {
+			if codes[i].ID == id && !codes[i].Used() {
+				r.codes[userID][i].UsedAt = usedAt
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func newTestMFAProvider(t *testing.T, config LocalAuthConfig, mfaConfig MFAConfig) (*LocalAuthProvider, *memoryMFARepository, *time.Time) {
+	t.Helper()
+	store, err := storage.NewMemoryAuthStorageProvider(storage.Config{}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordHasher, err := hasher.NewBcryptPasswordHasher(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newMemoryMFARepository()
+	mfa, err := NewTOTPAuthenticator(mfaConfig, repo, nil, []byte("fedcba9876543210fedcba9876543210"), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewTOTPAuthenticator: %v", err)
+	}
+	provider, err := NewLocalAuthProvider(config, newMemoryUserRepository(), passwordHasher,
+		[]byte("0123456789abcdef0123456789abcdef"), nil, store, &recordingMailer{}, mfa, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewLocalAuthProvider: %v", err)
+	}
+	// 令牌存儲按實際時間判斷過期，時鐘從當前時間步的開始推進
+	now := time.Now().Truncate(totpPeriod * time.Second)
+	mfa.now = func() time.Time { return now }
+	provider.now = func() time.Time { return now }
+	return provider, repo, &now
+}
+
+// currentTOTP 返回密鑰在 now 的驗證碼
+func currentTOTP(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, totpStep(now), totpDigits)
+}
+
+// wrongTOTP 返回與 code 不同的 6 位數字
+func wrongTOTP(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestLocalAuthProvider_MFAEnrollmentAndLogin(t *testing.T) {
+	ctx := context.Background()
+	provider, repo, now := newTestMFAProvider(t, LocalAuthConfig{DefaultRoles: []string{"viewer"}},
+		MFAConfig{RequiredRoles: []string{"admin"}, RecoveryCodes: 3})
+
+	user, err := provider.Register(ctx, RegisterRequest{Name: "Bob", Email: "bob@example.com", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, ""); err != nil {
+		t.Fatalf("MFA should be optional for viewers: %v", err)
+	}
+
+	enrollment, err := provider.BeginMFAEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginMFAEnrollment: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/Detectviz:bob@example.com?") {
+		t.Fatalf("unexpected provisioning URI: %s", enrollment.ProvisioningURI)
+	}
+	if stored := repo.mfa[user.ID].EncryptedSecret; strings.Contains(stored, enrollment.Secret) {
+		t.Fatal("TOTP secret must be encrypted at rest")
+	}
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, ""); err != nil {
+		t.Fatalf("unconfirmed enrollment should not require a code: %v", err)
+	}
+
+	code := currentTOTP(t, enrollment.Secret, *now)
+	if _, err := provider.ConfirmMFAEnrollment(ctx, user.ID, wrongTOTP(code)); !domainerrors.IsValidationError(err) {
+		t.Fatalf("wrong confirmation code should be rejected, got %v", err)
+	}
+	recoveryCodes, err := provider.ConfirmMFAEnrollment(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmMFAEnrollment: %v", err)
+	}
+	if len(recoveryCodes) != 3 {
+		t.Fatalf("expected 3 recovery codes, got %v", recoveryCodes)
+	}
+	for _, stored := range repo.codes[user.ID] {
+		if stored.CodeHash == recoveryCodes[0] || strings.Contains(stored.CodeHash, "-") {
+			t.Fatalf("recovery codes must be stored hashed, got %q", stored.CodeHash)
+		}
+	}
+
+	var required *MFARequiredError
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, ""); !errors.As(err, &required) || required.Enrollment {
+		t.Fatalf("login without code should require MFA, got %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, code); !domainerrors.IsAuthError(err) {
+		t.Fatalf("code used for enrollment should not be replayed, got %v", err)
+	}
+	*now = now.Add(totpPeriod * time.Second)
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, currentTOTP(t, enrollment.Secret, *now)); err != nil {
+		t.Fatalf("Login with TOTP: %v", err)
+	}
+
+	recovery := " " + strings.ToUpper(recoveryCodes[1]) + " "
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, recovery); err != nil {
+		t.Fatalf("Login with recovery code: %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, recovery); !domainerrors.IsAuthError(err) {
+		t.Fatalf("recovery code should be single use, got %v", err)
+	}
+	status, err := provider.MFAStatus(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("MFAStatus: %v", err)
+	}
+	if !status.Enabled || status.Required || status.RecoveryCodesRemaining != 2 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	if err := provider.DisableMFA(ctx, user.ID, "Wrong-Password-1", recoveryCodes[2]); !domainerrors.IsAuthError(err) {
+		t.Fatalf("disable with wrong password should fail, got %v", err)
+	}
+	if err := provider.DisableMFA(ctx, user.ID, testPassword, recoveryCodes[2]); err != nil {
+		t.Fatalf("DisableMFA: %v", err)
+	}
+	if _, _, err := provider.Login(ctx, "bob@example.com", testPassword, ""); err != nil {
+		t.Fatalf("login after disabling MFA: %v", err)
+	}
+}
+
+func TestLocalAuthProvider_MFARequiredRoleChallenge(t *testing.T) {
+	ctx := context.Background()
+	provider, _, now := newTestMFAProvider(t, LocalAuthConfig{DefaultRoles: []string{"admin"}},
+		MFAConfig{RequiredRoles: []string{"admin"}})
+
+	user, err := provider.Register(ctx, RegisterRequest{Name: "Carol", Email: "carol@example.com", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	var required *MFARequiredError
+	if _, _, err := provider.Login(ctx, "carol@example.com", testPassword, ""); !errors.As(err, &required) || !required.Enrollment {
+		t.Fatalf("admin without MFA should be asked to enroll, got %v", err)
+	}
+
+	// 登錄頁面的挑戰同時完成登記
+	challenge, err := provider.StartMFAChallenge(ctx, user.ID)
+	if err != nil || challenge == nil || challenge.Enrollment == nil {
+		t.Fatalf("StartMFAChallenge = %+v, %v", challenge, err)
+	}
+	if userID, err := provider.MFAChallengeUser(ctx, challenge.Token); err != nil || userID != user.ID {
+		t.Fatalf("MFAChallengeUser = %q, %v", userID, err)
+	}
+	if _, err := provider.MFAChallengeUser(ctx, user.ID+".forged"); !domainerrors.IsValidationError(err) {
+		t.Fatalf("forged challenge token should be rejected, got %v", err)
+	}
+	code := currentTOTP(t, challenge.Enrollment.Secret, *now)
+	if _, err := provider.CompleteMFAChallenge(ctx, challenge.Token, wrongTOTP(code)); err == nil {
+		t.Fatal("wrong code should not complete the challenge")
+	}
+	result, err := provider.CompleteMFAChallenge(ctx, challenge.Token, code)
+	if err != nil {
+		t.Fatalf("CompleteMFAChallenge: %v", err)
+	}
+	if result.UserID != user.ID || len(result.RecoveryCodes) != 10 {
+		t.Fatalf("unexpected challenge result: %+v", result)
+	}
+	if _, err := provider.CompleteMFAChallenge(ctx, challenge.Token, code); !domainerrors.IsValidationError(err) {
+		t.Fatalf("completed challenge should not be reusable, got %v", err)
+	}
+	if _, err := provider.MFAChallengeUser(ctx, challenge.Token); !domainerrors.IsValidationError(err) {
+		t.Fatalf("completed challenge should no longer resolve to a user, got %v", err)
+	}
+	if err := provider.DisableMFA(ctx, user.ID, testPassword, result.RecoveryCodes[0]); !domainerrors.IsValidationError(err) {
+		t.Fatalf("admins should not be able to disable MFA, got %v", err)
+	}
+
+	// 錯誤次數達到上限後挑戰失效，正確的驗證碼也不再接受
+	challenge, err = provider.StartMFAChallenge(ctx, user.ID)
+	if err != nil || challenge == nil || challenge.Enrollment != nil {
+		t.Fatalf("StartMFAChallenge = %+v, %v", challenge, err)
+	}
+	for i := 0; i < mfaChallengeMaxAttempts; i++ {
+		if _, err := provider.CompleteMFAChallenge(ctx, challenge.Token, "not-a-recovery-code"); !domainerrors.IsAuthError(err) {
+			t.Fatalf("attempt %d: expected auth error, got %v", i+1, err)
+		}
+	}
+	if _, err := provider.CompleteMFAChallenge(ctx, challenge.Token, result.RecoveryCodes[1]); !domainerrors.IsValidationError(err) {
+		t.Fatalf("challenge should be revoked after too many attempts, got %v", err)
+	}
+
+	if err := provider.ResetMFA(ctx, user.ID); err != nil {
+		t.Fatalf("ResetMFA: %v", err)
+	}
+	if status, err := provider.MFAStatus(ctx, user.ID); err != nil || status.Enabled || !status.Required {
+		t.Fatalf("unexpected status after reset: %+v, %v", status, err)
+	}
+}
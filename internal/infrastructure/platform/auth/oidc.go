@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ErrOIDCTokenRejected 表示身份提供者拒絕了授權碼或刷新令牌 (例如 invalid_grant)，用戶需要重新登錄
+var ErrOIDCTokenRejected = errors.New("oidc token request rejected")
+
+// OIDCConfig 定義 OIDC 授權碼登錄的配置
+type OIDCConfig struct {
+	// Issuer 身份提供者的 issuer，從 {issuer}/.well-known/openid-configuration 讀取端點
+	Issuer       string `yaml:"issuer" json:"issuer"`
+	ClientID     string `yaml:"client_id" json:"client_id"`
+	ClientSecret string `yaml:"client_secret" json:"client_secret"` // 為空時作為公開客戶端，只依靠 PKCE
+	// RedirectURL 回調地址，必須與身份提供者中登記的地址完全一致
+	RedirectURL string `yaml:"redirect_url" json:"redirect_url"`
+	// Scopes 請求的範圍，默認 openid profile email；openid 總是包含在內
+	Scopes  []string `yaml:"scopes" json:"scopes"`
+	Timeout string   `yaml:"timeout" json:"timeout"`
+	// ClockSkew 驗證 ID 令牌 exp 與 nbf 時允許的時鐘偏差，默認 "30s"
+	ClockSkew string `yaml:"clock_skew" json:"clock_skew"`
+}
+
+// OIDCTokens 是令牌端點返回的令牌
+type OIDCTokens struct {
+	AccessToken  string
+	RefreshToken string // 身份提供者沒有返回時為空
+	IDToken      string // 刷新時身份提供者可以不返回新的 ID 令牌
+	ExpiresAt    time.Time
+	// RefreshExpiresAt 刷新令牌的過期時間，來自 refresh_expires_in (Keycloak 擴展)，未提供時為零
+	RefreshExpiresAt time.Time
+}
+
+// oidcDiscovery 是 OpenID Provider Metadata 中使用的欄位
+type oidcDiscovery struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// oidcTokenResponse 是令牌端點的響應 (RFC 6749 第 5 節)
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	IDToken          string `json:"id_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// OIDCClient 是符合 OpenID Connect Discovery 的身份提供者的授權碼流程客戶端
+// 職責: 讀取發現文檔，構建帶 PKCE (S256) 的授權地址，以授權碼與刷新令牌換取令牌，
+// 並以身份提供者的 JWKS 在本地驗證 ID 令牌的簽名、iss、aud、exp 與 nonce。
+// 發現文檔在第一次使用時讀取，失敗時下次使用再重試，身份提供者暫時不可用不影響啟動。
+type OIDCClient struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	clockSkew    time.Duration
+	httpClient   *http.Client
+	logger       contracts.Logger
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	jwks      *jwksCache
+
+	now func() time.Time
+}
+
+// NewOIDCClient 創建 OIDC 客戶端
+func NewOIDCClient(config OIDCConfig, logger contracts.Logger) (*OIDCClient, error) {
+	if config.Issuer == "" {
+		return nil, fmt.Errorf("oidc issuer is required")
+	}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("oidc client ID is required")
+	}
+	redirect, err := url.Parse(config.RedirectURL)
+	if err != nil || !redirect.IsAbs() {
+		return nil, fmt.Errorf("oidc redirect URL must be an absolute URL: %q", config.RedirectURL)
+	}
+	timeout, err := parseDurationOrDefault(config.Timeout, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	clockSkew, err := parseDurationOrDefault(config.ClockSkew, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clock_skew: %w", err)
+	}
+
+	scopes := []string{"openid"}
+	requested := config.Scopes
+	if len(requested) == 0 {
+		requested = []string{"profile", "email"}
+	}
+	for _, scope := range requested {
+		if scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	logger.Info("初始化 OIDC 客戶端",
+		"issuer", config.Issuer,
+		"client_id", config.ClientID,
+		"redirect_url", config.RedirectURL,
+		"scopes", strings.Join(scopes, " "))
+
+	return &OIDCClient{
+		issuer:       strings.TrimSuffix(config.Issuer, "/"),
+		clientID:     config.ClientID,
+		clientSecret: config.ClientSecret,
+		redirectURL:  config.RedirectURL,
+		scopes:       scopes,
+		clockSkew:    clockSkew,
+		httpClient:   &http.Client{Timeout: timeout},
+		logger:       logger,
+		now:          time.Now,
+	}, nil
+}
+
+// AuthCodeURL 返回把瀏覽器重定向到身份提供者的授權地址，codeChallenge 為 PKCEChallenge(verifier)
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.clientID)
+	params.Set("redirect_uri", c.redirectURL)
+	params.Set("scope", strings.Join(c.scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange 以授權碼與 PKCE verifier 換取令牌，響應必須包含 ID 令牌
+func (c *OIDCClient) Exchange(ctx context.Context, code, codeVerifier string) (*OIDCTokens, error) {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", c.redirectURL)
+	data.Set("code_verifier", codeVerifier)
+	tokens, err := c.tokenRequest(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+	return tokens, nil
+}
+
+// Refresh 以刷新令牌換取新令牌，身份提供者輪換刷新令牌時返回新的刷新令牌
+func (c *OIDCClient) Refresh(ctx context.Context, refreshToken string) (*OIDCTokens, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
+	data.Set("scope", strings.Join(c.scopes, " "))
+	return c.tokenRequest(ctx, data)
+}
+
+// VerifyIDToken 驗證 ID 令牌並返回身份。nonce 必須與授權請求中的 nonce 一致；
+// 刷新時得到的 ID 令牌可以不帶 nonce，此時傳入空字串跳過 nonce 檢查。
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*TokenIdentity, error) {
+	if _, err := c.discover(ctx); err != nil {
+		return nil, err
+	}
+	parsed, err := parseJWT(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := signatureHash(parsed.header.Alg); err != nil {
+		return nil, err
+	}
+	key, err := c.jwks.key(ctx, parsed.header.Kid, parsed.header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.verifySignature(key); err != nil {
+		return nil, err
+	}
+	claims := &parsed.claims
+	if err := claims.validate(c.now(), c.issuer, []string{c.clientID}, c.clockSkew); err != nil {
+		return nil, err
+	}
+	// 令牌有多個受眾時 azp 必須是本客戶端 (OpenID Connect Core 3.1.3.7)
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.clientID {
+		return nil, fmt.Errorf("id token azp %q does not match client id", claims.AuthorizedParty)
+	}
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("id token nonce does not match")
+	}
+	return claims.identity(c.clientID), nil
+}
+
+// discover 讀取並緩存發現文檔，文檔中的 issuer 必須與配置一致
+func (c *OIDCClient) discover(ctx context.Context) (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oidc discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery returned status %d", resp.StatusCode)
+	}
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode oidc discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != c.issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer %q", discovery.Issuer, c.issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery document is missing required endpoints")
+	}
+	if len(discovery.CodeChallengeMethodsSupported) > 0 && !containsString(discovery.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("oidc provider does not support the S256 code challenge method")
+	}
+	// ID 令牌的 iss 與發現文檔一致，以文檔中的值驗證以容忍結尾的 /
+	c.issuer = discovery.Issuer
+
+	c.discovery = &discovery
+	c.jwks = newJWKSCache(discovery.JWKSURI, c.httpClient, 10*time.Minute, 30*time.Second, c.logger)
+	c.logger.Info("已讀取 OIDC 發現文檔",
+		"issuer", discovery.Issuer,
+		"authorization_endpoint", discovery.AuthorizationEndpoint,
+		"token_endpoint", discovery.TokenEndpoint)
+	return c.discovery, nil
+}
+
+// tokenRequest 向令牌端點發送請求，有客戶端密鑰時以 client_secret_basic 認證
+func (c *OIDCClient) tokenRequest(ctx context.Context, data url.Values) (*OIDCTokens, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data.Set("client_id", c.clientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+
+	requestedAt := c.now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: %s %s", ErrOIDCTokenRejected, body.Error, body.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, body.Error)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	if body.TokenType != "" && !strings.EqualFold(body.TokenType, "Bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", body.TokenType)
+	}
+
+	tokens := &OIDCTokens{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+	}
+	if body.ExpiresIn > 0 {
+		tokens.ExpiresAt = requestedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	if body.RefreshExpiresIn > 0 {
+		tokens.RefreshExpiresAt = requestedAt.Add(time.Duration(body.RefreshExpiresIn) * time.Second)
+	}
+	return tokens, nil
+}
+
+// NewPKCEVerifier 生成 PKCE code verifier (RFC 7636)，32 個隨機字節編碼為 43 個字元
+func NewPKCEVerifier() (string, error) {
+	return RandomURLToken(32)
+}
+
+// PKCEChallenge 返回 verifier 的 S256 code challenge
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RandomURLToken 返回 n 個隨機字節的 base64url 編碼，用於 state、nonce 與 PKCE verifier
+func RandomURLToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// containsString 檢查 values 是否包含 s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
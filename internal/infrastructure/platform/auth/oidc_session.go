@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// OIDCRefreshTokenType 是 OIDC 刷新令牌在 AuthStorageProvider 中的令牌類型，以會話 ID 作為 userID 鍵
+const OIDCRefreshTokenType = "oidc_refresh_token"
+
+// defaultRefreshTokenTTL 是身份提供者沒有返回 refresh_expires_in 時刷新令牌在存儲中的保留時間
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// OIDCSessionRefresher 在 OIDC 登錄的會話中保持身份提供者令牌有效
+// 職責: 把刷新令牌保存在會話存儲中；解析會話時若令牌已過期，以刷新令牌換取新令牌並更新會話的角色與群組。
+// 身份提供者拒絕刷新 (用戶被停用、會話被撤銷) 時刪除會話，用戶必須重新登錄。
+type OIDCSessionRefresher struct {
+	client   *OIDCClient
+	sessions *storage.SessionManager
+	logger   contracts.Logger
+
+	mu sync.Mutex // 同時只有一個請求刷新令牌，避免刷新令牌輪換時並發請求使用已失效的令牌
+
+	now func() time.Time
+}
+
+// NewOIDCSessionRefresher 創建會話刷新器
+func NewOIDCSessionRefresher(client *OIDCClient, sessions *storage.SessionManager, logger contracts.Logger) *OIDCSessionRefresher {
+	return &OIDCSessionRefresher{
+		client:   client,
+		sessions: sessions,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// StoreTokens 保存會話的刷新令牌，令牌沒有刷新令牌時不做任何事
+func (r *OIDCSessionRefresher) StoreTokens(ctx context.Context, sessionID string, tokens *OIDCTokens) error {
+	if tokens.RefreshToken == "" {
+		return nil
+	}
+	expiresAt := tokens.RefreshExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = r.now().Add(defaultRefreshTokenTTL)
+	}
+	if err := r.sessions.Store().StoreToken(ctx, sessionID, OIDCRefreshTokenType, tokens.RefreshToken, expiresAt.Unix()); err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+// Revoke 刪除會話保存的刷新令牌，登出時調用
+func (r *OIDCSessionRefresher) Revoke(ctx context.Context, sessionID string) error {
+	return r.sessions.Store().RevokeToken(ctx, sessionID, OIDCRefreshTokenType)
+}
+
+// Lookup 解析簽名的會話 ID，令牌過期時先刷新會話
+func (r *OIDCSessionRefresher) Lookup(ctx context.Context, signedID string) (*storage.Session, error) {
+	session, err := r.sessions.Lookup(ctx, signedID)
+	if err != nil {
+		return nil, err
+	}
+	if session.TokenExpiresAt.IsZero() || r.now().Before(session.TokenExpiresAt) {
+		return session, nil
+	}
+	return r.refresh(ctx, session.ID)
+}
+
+// refresh 以刷新令牌更新會話。網絡錯誤等暫時性錯誤保留會話，下一個請求再重試。
+func (r *OIDCSessionRefresher) refresh(ctx context.Context, id string) (*storage.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 等待鎖期間其他請求可能已經刷新了同一會話
+	session, err := r.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.now().Before(session.TokenExpiresAt) {
+		return session, nil
+	}
+
+	refreshToken, err := r.sessions.Store().GetToken(ctx, id, OIDCRefreshTokenType)
+	if err != nil {
+		return nil, err
+	}
+	if refreshToken == "" {
+		r.end(ctx, session, "沒有可用的刷新令牌")
+		return nil, storage.ErrInvalidSession
+	}
+
+	tokens, err := r.client.Refresh(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, ErrOIDCTokenRejected) {
+			r.logger.Info("身份提供者拒絕刷新令牌", "user_id", session.UserID, "error", err)
+			r.end(ctx, session, "刷新令牌被拒絕")
+			return nil, storage.ErrInvalidSession
+		}
+		return nil, fmt.Errorf("failed to refresh oidc tokens: %w", err)
+	}
+
+	expiresAt := tokens.ExpiresAt
+	if tokens.IDToken != "" {
+		identity, err := r.client.VerifyIDToken(ctx, tokens.IDToken, "")
+		if err != nil {
+			r.logger.Warn("刷新得到的 ID 令牌無效", "user_id", session.UserID, "error", err)
+			r.end(ctx, session, "刷新得到的 ID 令牌無效")
+			return nil, storage.ErrInvalidSession
+		}
+		if identity.UserID != session.UserID {
+			r.end(ctx, session, "刷新得到的 ID 令牌屬於其他用戶")
+			return nil, storage.ErrInvalidSession
+		}
+		applyIdentity(session, identity)
+		if expiresAt.IsZero() {
+			expiresAt = identity.ExpiresAt
+		}
+	}
+	if expiresAt.IsZero() {
+		return nil, fmt.Errorf("refreshed tokens have no expiry")
+	}
+	session.TokenExpiresAt = expiresAt
+
+	if err := r.StoreTokens(ctx, id, tokens); err != nil {
+		return nil, err
+	}
+	if err := r.sessions.Update(ctx, session); err != nil {
+		return nil, err
+	}
+	r.logger.Debug("已刷新 OIDC 會話", "user_id", session.UserID, "token_expires_at", expiresAt)
+	return session, nil
+}
+
+// end 刪除會話與其刷新令牌
+func (r *OIDCSessionRefresher) end(ctx context.Context, session *storage.Session, reason string) {
+	r.logger.Info("結束 OIDC 會話", "user_id", session.UserID, "reason", reason)
+	if err := r.Revoke(ctx, session.ID); err != nil {
+		r.logger.Warn("刪除刷新令牌失敗", "user_id", session.UserID, "error", err)
+	}
+	if err := r.sessions.Store().DeleteSession(ctx, session.ID); err != nil {
+		r.logger.Warn("刪除會話失敗", "user_id", session.UserID, "error", err)
+	}
+}
+
+// NewOIDCSession 從 ID 令牌的身份創建會話，tokenExpiresAt 到期後由 Lookup 刷新
+func NewOIDCSession(identity *TokenIdentity, tokenExpiresAt time.Time) storage.Session {
+	session := storage.Session{UserID: identity.UserID, TokenExpiresAt: tokenExpiresAt}
+	applyIdentity(&session, identity)
+	if session.TokenExpiresAt.IsZero() {
+		session.TokenExpiresAt = identity.ExpiresAt
+	}
+	return session
+}
+
+// applyIdentity 以身份提供者的最新聲明更新會話中的用戶信息
+func applyIdentity(session *storage.Session, identity *TokenIdentity) {
+	session.Username = identity.Username
+	if session.Username == "" {
+		session.Username = identity.Email
+	}
+	session.Email = identity.Email
+	session.Roles = identity.Roles
+	session.Groups = identity.Groups
+}
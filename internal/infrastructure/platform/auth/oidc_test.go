@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/platform/auth/storage"
+)
+
+// mockIssuer 是支持發現文檔、JWKS 與令牌端點 (授權碼 + PKCE、刷新令牌) 的本地 OIDC 身份提供者
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	signer *testSigner
+	now    func() time.Time
+
+	mu            sync.Mutex
+	codes         map[string]mockAuthorization // 授權碼 -> 授權請求
+	refreshTokens map[string]bool              // 有效的刷新令牌
+	roles         []string
+	issued        int
+}
+
+type mockAuthorization struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+func newMockIssuer(t *testing.T, now func() time.Time) *mockIssuer {
+	t.Helper()
+	m := &mockIssuer{
+		t:             t,
+		signer:        newRSASigner(t, "oidc-key"),
+		now:           now,
+		codes:         make(map[string]mockAuthorization),
+		refreshTokens: make(map[string]bool),
+		roles:         []string{"viewer"},
+	}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                           m.server.URL,
+			"authorization_endpoint":           m.server.URL + "/authorize",
+			"token_endpoint":                   m.server.URL + "/token",
+			"jwks_uri":                         m.server.URL + "/jwks",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	case "/jwks":
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{m.signer.jwk()}})
+	case "/token":
+		m.token(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// authorize 模擬用戶在身份提供者登錄後重定向回調，返回授權碼
+func (m *mockIssuer) authorize(authURL string) (code, state string) {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("parse auth URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "detectviz-ui" {
+		m.t.Fatalf("unexpected authorization request %s", u.RawQuery)
+	}
+	if !strings.Contains(" "+q.Get("scope")+" ", " openid ") {
+		m.t.Fatalf("scope %q does not include openid", q.Get("scope"))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = "code-" + q.Get("state")
+	m.codes[code] = mockAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), redirectURI: q.Get("redirect_uri")}
+	return code, q.Get("state")
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") != "detectviz-ui" {
+		m.reject(w)
+		return
+	}
+	nonce := ""
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		auth, ok := m.codes[r.PostForm.Get("code")]
+		if !ok || PKCEChallenge(r.PostForm.Get("code_verifier")) != auth.challenge || r.PostForm.Get("redirect_uri") != auth.redirectURI {
+			m.reject(w)
+			return
+		}
+		delete(m.codes, r.PostForm.Get("code"))
+		nonce = auth.nonce
+	case "refresh_token":
+		if !m.refreshTokens[r.PostForm.Get("refresh_token")] {
+			m.reject(w)
+			return
+		}
+		// 每次刷新都輪換刷新令牌
+		delete(m.refreshTokens, r.PostForm.Get("refresh_token"))
+	default:
+		m.reject(w)
+		return
+	}
+
+	m.issued++
+	refreshToken := "refresh-" + strings.Repeat("x", m.issued)
+	m.refreshTokens[refreshToken] = true
+	now := m.now()
+	claims := map[string]interface{}{
+		"iss": m.server.URL, "aud": "detectviz-ui", "sub": "user-1",
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		"preferred_username": "alice", "email": "alice@example.com", "roles": m.roles,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "access-token",
+		"token_type":    "Bearer",
+		"expires_in":    300,
+		"refresh_token": refreshToken,
+		"id_token":      m.signer.sign(m.t, claims),
+	})
+}
+
+func (m *mockIssuer) reject(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+}
+
+func (m *mockIssuer) revokeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshTokens = make(map[string]bool)
+}
+
+func (m *mockIssuer) setRoles(roles ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roles = roles
+}
+
+func newTestOIDCClient(t *testing.T, issuer *mockIssuer, now func() time.Time) *OIDCClient {
+	t.Helper()
+	client, err := NewOIDCClient(OIDCConfig{
+		Issuer:      issuer.server.URL,
+		ClientID:    "detectviz-ui",
+		RedirectURL: "http://localhost:8080/auth/oidc/callback",
+	}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewOIDCClient() error = %v", err)
+	}
+	client.now = now
+	return client
+}
+
+func TestOIDCClient_AuthorizationCodeFlowWithPKCE(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	issuer := newMockIssuer(t, clock)
+	client := newTestOIDCClient(t, issuer, clock)
+	ctx := context.Background()
+
+	verifier, err := NewPKCEVerifier()
+	if err != nil || len(verifier) < 43 {
+		t.Fatalf("NewPKCEVerifier() = %q, %v", verifier, err)
+	}
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	if !strings.HasPrefix(authURL, issuer.server.URL+"/authorize?") {
+		t.Fatalf("AuthCodeURL() = %s", authURL)
+	}
+	code, state := issuer.authorize(authURL)
+	if state != "state-1" {
+		t.Errorf("state = %q", state)
+	}
+
+	// 沒有正確的 code_verifier 時授權碼無法兌換
+	if _, err := client.Exchange(ctx, code, "wrong-verifier-wrong-verifier-wrong-verifier"); !errors.Is(err, ErrOIDCTokenRejected) {
+		t.Fatalf("Exchange() with wrong verifier error = %v", err)
+	}
+	tokens, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if tokens.RefreshToken == "" || !tokens.ExpiresAt.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+	// 授權碼只能使用一次
+	if _, err := client.Exchange(ctx, code, verifier); !errors.Is(err, ErrOIDCTokenRejected) {
+		t.Errorf("second Exchange() error = %v", err)
+	}
+
+	if _, err := client.VerifyIDToken(ctx, tokens.IDToken, "other-nonce"); err == nil {
+		t.Error("ID token with mismatched nonce accepted")
+	}
+	identity, err := client.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if identity.UserID != "user-1" || identity.Username != "alice" || strings.Join(identity.Roles, ",") != "viewer" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	// 換用其他密鑰簽名的 ID 令牌無效
+	forged := newRSASigner(t, "oidc-key").sign(t, map[string]interface{}{
+		"iss": issuer.server.URL, "aud": "detectviz-ui", "sub": "user-1", "exp": now.Add(time.Minute).Unix(), "nonce": "nonce-1",
+	})
+	if _, err := client.VerifyIDToken(ctx, forged, "nonce-1"); err == nil {
+		t.Error("forged ID token accepted")
+	}
+}
+
+func TestOIDCClient_RejectsMismatchedDiscoveryIssuer(t *testing.T) {
+	issuer := newMockIssuer(t, time.Now)
+	client, err := NewOIDCClient(OIDCConfig{
+		Issuer:      issuer.server.URL + "/other",
+		ClientID:    "detectviz-ui",
+		RedirectURL: "http://localhost:8080/auth/oidc/callback",
+	}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AuthCodeURL(context.Background(), "s", "n", "c"); err == nil {
+		t.Error("AuthCodeURL() succeeded without a valid discovery document")
+	}
+	if _, err := NewOIDCClient(OIDCConfig{Issuer: issuer.server.URL, ClientID: "c", RedirectURL: "/callback"}, &testLogger{}); err == nil {
+		t.Error("relative redirect URL accepted")
+	}
+}
+
+func TestOIDCSessionRefresher_RefreshesExpiredSessions(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	issuer := newMockIssuer(t, clock)
+	client := newTestOIDCClient(t, issuer, clock)
+	ctx := context.Background()
+
+	store, err := storage.NewMemoryAuthStorageProvider(storage.Config{}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := storage.NewSessionManager(store, []byte(strings.Repeat("k", 32)), &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresher := NewOIDCSessionRefresher(client, sessions, &testLogger{})
+	refresher.now = clock
+
+	verifier, _ := NewPKCEVerifier()
+	authURL, _ := client.AuthCodeURL(ctx, "state-1", "nonce-1", PKCEChallenge(verifier))
+	code, _ := issuer.authorize(authURL)
+	tokens, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := client.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := sessions.Create(ctx, NewOIDCSession(identity, tokens.ExpiresAt), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := sessions.Verify(signed)
+	if err := refresher.StoreTokens(ctx, id, tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	// 令牌有效期內不訪問身份提供者
+	session, err := refresher.Lookup(ctx, signed)
+	if err != nil || strings.Join(session.Roles, ",") != "viewer" {
+		t.Fatalf("Lookup() = %+v, %v", session, err)
+	}
+
+	// 令牌過期後以刷新令牌更新會話的角色與令牌過期時間
+	issuer.setRoles("operator", "viewer")
+	now = now.Add(6 * time.Minute)
+	session, err = refresher.Lookup(ctx, signed)
+	if err != nil {
+		t.Fatalf("Lookup() after expiry error = %v", err)
+	}
+	if strings.Join(session.Roles, ",") != "operator,viewer" || !session.TokenExpiresAt.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("session was not refreshed: %+v", session)
+	}
+	if stored, _ := store.GetToken(ctx, id, OIDCRefreshTokenType); stored == tokens.RefreshToken || stored == "" {
+		t.Errorf("rotated refresh token was not stored: %q", stored)
+	}
+
+	// 身份提供者撤銷刷新令牌後會話失效並被刪除
+	issuer.revokeAll()
+	now = now.Add(6 * time.Minute)
+	if _, err := refresher.Lookup(ctx, signed); !errors.Is(err, storage.ErrInvalidSession) {
+		t.Fatalf("Lookup() after revocation error = %v", err)
+	}
+	if data, _ := store.GetSession(ctx, id); data != nil {
+		t.Error("session was not deleted after the refresh was rejected")
+	}
+}
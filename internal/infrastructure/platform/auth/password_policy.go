@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	domainerrors "detectviz-platform/pkg/domain/errors"
+)
+
+// maxPasswordBytes 是 bcrypt 能處理的最大密碼長度，超出部分會被靜默截斷，因此直接拒絕
+const maxPasswordBytes = 72
+
+// PasswordPolicy 定義本地用戶密碼的強度要求
+type PasswordPolicy struct {
+	// MinLength 密碼最少字符數，默認 12
+	MinLength        int  `yaml:"min_length" json:"min_length"`
+	RequireUppercase bool `yaml:"require_uppercase" json:"require_uppercase"`
+	RequireLowercase bool `yaml:"require_lowercase" json:"require_lowercase"`
+	RequireDigit     bool `yaml:"require_digit" json:"require_digit"`
+	RequireSymbol    bool `yaml:"require_symbol" json:"require_symbol"`
+}
+
+// withDefaults 返回填充默認值後的策略
+func (p PasswordPolicy) withDefaults() PasswordPolicy {
+	if p.MinLength <= 0 {
+		p.MinLength = 12
+	}
+	return p
+}
+
+// Validate 檢查密碼是否符合策略，不符合時返回列出所有未滿足要求的驗證錯誤
+func (p PasswordPolicy) Validate(password string) error {
+	p = p.withDefaults()
+	if len(password) > maxPasswordBytes {
+		return domainerrors.NewValidationError("password", fmt.Sprintf("密碼不能超過 %d 個字節", maxPasswordBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	length := 0
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("至少 %d 個字元", p.MinLength))
+	}
+	if p.RequireUppercase && !upper {
+		problems = append(problems, "大寫字母")
+	}
+	if p.RequireLowercase && !lower {
+		problems = append(problems, "小寫字母")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "數字")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "符號")
+	}
+	if len(problems) > 0 {
+		return domainerrors.NewValidationError("password", "密碼必須包含"+strings.Join(problems, "、"))
+	}
+	return nil
+}
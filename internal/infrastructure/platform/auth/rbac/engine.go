@@ -0,0 +1,341 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// Subject 是請求授權的主體
+type Subject struct {
+	UserID string
+	Roles  []string // 令牌聲明中的角色，經 roleMappings 映射為平台角色
+	Groups []string // 令牌聲明中的群組，可用於 group: 綁定與 roleMappings
+}
+
+// Scope 描述被訪問資源的歸屬，用於判斷限定範圍的綁定是否生效
+type Scope struct {
+	Organization string
+	Team         string
+	OwnerID      string // 資源擁有者的用戶 ID，例如檢測器的 OwnerID
+}
+
+// Request 是一次授權判斷的輸入
+type Request struct {
+	Subject  Subject
+	Resource string
+	Action   string
+	Scope    Scope
+}
+
+// Grant 是主體在本次請求中獲得的一個角色及其來源
+type Grant struct {
+	Role   string
+	Source string // 授予角色的綁定或角色映射
+}
+
+// Decision 是授權判斷的結果與理由
+type Decision struct {
+	Allowed bool
+	// Reason 以可讀文字說明允許或拒絕的原因
+	Reason string
+	// Permission 與 Grant 為允許請求的權限及其角色來源，拒絕時為空
+	Permission string
+	Grant      *Grant
+	// Grants 主體在資源範圍內生效的全部角色
+	Grants []Grant
+	// Skipped 匹配主體但因資源範圍不符而未生效的綁定及原因
+	Skipped []string
+}
+
+type subjectContextKey struct{}
+type scopeContextKey struct{}
+
+// WithSubject 把已認證主體的角色與群組放入 context，供 Authorize 使用
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectContextKey{}, subject)
+}
+
+// SubjectFromContext 返回 context 中的主體
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	subject, ok := ctx.Value(subjectContextKey{}).(Subject)
+	return subject, ok
+}
+
+// WithScope 把被訪問資源的歸屬放入 context，供 Authorize 判斷限定範圍的綁定
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// ScopeFromContext 返回 context 中的資源範圍
+func ScopeFromContext(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeContextKey{}).(Scope)
+	return scope
+}
+
+// EngineConfig 定義策略引擎的配置
+type EngineConfig struct {
+	PolicyFile     string `yaml:"policyFile" json:"policyFile"`         // YAML 策略文件路徑
+	ReloadInterval string `yaml:"reloadInterval" json:"reloadInterval"` // 檢查策略文件變更的間隔，默認 "10s"，"0s" 表示不熱重載
+}
+
+// Engine 是基於角色的授權引擎
+// 職責: 以策略文件中的角色、綁定與角色映射判斷主體能否對資源執行操作，並解釋判斷的理由。
+// 策略文件變更時自動重新載入；新策略無效時記錄錯誤並繼續使用舊策略。
+type Engine struct {
+	path           string
+	reloadInterval time.Duration
+	logger         contracts.Logger
+
+	policy   atomic.Pointer[compiledPolicy]
+	checksum [sha256.Size]byte // 目前策略文件內容的摘要
+
+	reloadMu sync.Mutex
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEngine 載入策略文件並創建授權引擎，策略無效時返回錯誤
+func NewEngine(config EngineConfig, logger contracts.Logger) (*Engine, error) {
+	if config.PolicyFile == "" {
+		return nil, fmt.Errorf("RBAC policy file is required")
+	}
+	interval := 10 * time.Second
+	if config.ReloadInterval != "" {
+		d, err := time.ParseDuration(config.ReloadInterval)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid reloadInterval %q", config.ReloadInterval)
+		}
+		interval = d
+	}
+	e := &Engine{
+		path:           config.PolicyFile,
+		reloadInterval: interval,
+		logger:         logger,
+	}
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetName 返回引擎名稱
+func (e *Engine) GetName() string {
+	return "rbac_engine"
+}
+
+// Start 啟動策略文件的熱重載
+func (e *Engine) Start(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running || e.reloadInterval == 0 {
+		return nil
+	}
+	e.running = true
+	e.stopChan = make(chan struct{})
+	e.wg.Add(1)
+	go e.watch(e.stopChan)
+	e.logger.Info("RBAC 策略熱重載已啟動", "policy_file", e.path, "interval", e.reloadInterval)
+	return nil
+}
+
+// Stop 停止熱重載
+func (e *Engine) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return nil
+	}
+	e.running = false
+	close(e.stopChan)
+	e.mu.Unlock()
+	e.wg.Wait()
+	return nil
+}
+
+// watch 定期檢查策略文件，內容變更時重新載入
+func (e *Engine) watch(stopChan chan struct{}) {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if _, err := e.Reload(); err != nil {
+				e.logger.Error("重新載入 RBAC 策略失敗，繼續使用目前的策略", "policy_file", e.path, "error", err)
+			}
+		}
+	}
+}
+
+// Reload 讀取策略文件，內容有變更且有效時替換目前的策略，返回是否已替換
+func (e *Engine) Reload() (bool, error) {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read RBAC policy %s: %w", e.path, err)
+	}
+	checksum := sha256.Sum256(data)
+	if e.policy.Load() != nil && bytes.Equal(checksum[:], e.checksum[:]) {
+		return false, nil
+	}
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid RBAC policy %s: %w", e.path, err)
+	}
+	e.policy.Store(policy)
+	e.checksum = checksum
+	e.logger.Info("已載入 RBAC 策略", "policy_file", e.path, "roles", len(policy.roles), "bindings", len(policy.bindings),
+		"role_mappings", len(policy.roleMappings))
+	return true, nil
+}
+
+// Authorize 判斷用戶能否對資源執行操作。context 中有 WithSubject 放入的同一用戶時使用其角色與群組，
+// 資源範圍取自 WithScope；沒有主體信息時只按 user: 與 * 綁定判斷。
+func (e *Engine) Authorize(ctx context.Context, userID, resource, action string) (bool, error) {
+	subject, ok := SubjectFromContext(ctx)
+	if !ok || subject.UserID != userID {
+		subject = Subject{UserID: userID}
+	}
+	decision := e.Explain(Request{Subject: subject, Resource: resource, Action: action, Scope: ScopeFromContext(ctx)})
+	if !decision.Allowed {
+		e.logger.Debug("授權被拒絕", "user_id", userID, "resource", resource, "action", action, "reason", decision.Reason)
+	}
+	return decision.Allowed, nil
+}
+
+// Explain 判斷請求並說明理由：列出主體生效的角色、因範圍不符而未生效的綁定，
+// 以及允許請求的角色與權限
+func (e *Engine) Explain(req Request) Decision {
+	policy := e.policy.Load()
+	var decision Decision
+	if req.Subject.UserID == "" {
+		decision.Reason = "請求沒有已認證的主體"
+		return decision
+	}
+	decision.Grants, decision.Skipped = policy.grants(req)
+	for _, grant := range decision.Grants {
+		for _, p := range policy.roles[grant.Role].permissions {
+			if p.matches(req.Resource, req.Action) {
+				grant := grant
+				decision.Allowed = true
+				decision.Permission = p.raw
+				decision.Grant = &grant
+				decision.Reason = fmt.Sprintf("角色 %s 的權限 %s 允許 %s:%s，角色來自 %s", grant.Role, p.raw,
+					req.Resource, req.Action, grant.Source)
+				return decision
+			}
+		}
+	}
+
+	var roles []string
+	for _, grant := range decision.Grants {
+		roles = append(roles, grant.Role)
+	}
+	switch {
+	case len(roles) == 0:
+		decision.Reason = fmt.Sprintf("主體 %s 在此資源範圍內沒有任何角色", req.Subject.UserID)
+	default:
+		decision.Reason = fmt.Sprintf("角色 %s 都沒有 %s:%s 權限", strings.Join(roles, ", "), req.Resource, req.Action)
+	}
+	if len(decision.Skipped) > 0 {
+		decision.Reason += fmt.Sprintf("；%d 個綁定因資源範圍不符未生效", len(decision.Skipped))
+	}
+	return decision
+}
+
+// grants 返回主體在資源範圍內生效的角色，以及匹配主體但因範圍不符未生效的綁定
+func (c *compiledPolicy) grants(req Request) ([]Grant, []string) {
+	var (
+		grants  []Grant
+		skipped []string
+		seen    = make(map[string]bool)
+	)
+	add := func(role, source string) {
+		if !seen[role] {
+			seen[role] = true
+			grants = append(grants, Grant{Role: role, Source: source})
+		}
+	}
+
+	for _, mapping := range c.roleMappings {
+		values := req.Subject.Roles
+		if mapping.Claim == ClaimGroups {
+			values = req.Subject.Groups
+		}
+		if value, ok := matchAny(mapping.Values, values); ok {
+			for _, role := range mapping.Roles {
+				add(role, fmt.Sprintf("roleMapping(%s=%s)", mapping.Claim, value))
+			}
+		}
+	}
+	for _, binding := range c.bindings {
+		if !binding.matchesSubject(req.Subject) {
+			continue
+		}
+		if reason := binding.scopeMismatch(req); reason != "" {
+			skipped = append(skipped, binding.describe()+": "+reason)
+			continue
+		}
+		add(binding.Role, binding.describe())
+	}
+	return grants, skipped
+}
+
+// matchesSubject 檢查綁定的主體是否包含請求主體
+func (b RoleBinding) matchesSubject(subject Subject) bool {
+	for _, s := range b.Subjects {
+		switch {
+		case s == Wildcard:
+			return true
+		case strings.HasPrefix(s, SubjectUserPrefix):
+			if strings.TrimPrefix(s, SubjectUserPrefix) == subject.UserID {
+				return true
+			}
+		case strings.HasPrefix(s, SubjectGroupPrefix):
+			if _, ok := matchAny([]string{strings.TrimPrefix(s, SubjectGroupPrefix)}, subject.Groups); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopeMismatch 返回綁定範圍不涵蓋資源的原因，涵蓋時返回空字串
+func (b RoleBinding) scopeMismatch(req Request) string {
+	switch {
+	case b.Organization != "" && req.Scope.Organization != b.Organization:
+		return fmt.Sprintf("資源組織為 %q", req.Scope.Organization)
+	case b.Team != "" && req.Scope.Team != b.Team:
+		return fmt.Sprintf("資源團隊為 %q", req.Scope.Team)
+	case b.Owner && (req.Scope.OwnerID == "" || req.Scope.OwnerID != req.Subject.UserID):
+		return fmt.Sprintf("資源擁有者為 %q", req.Scope.OwnerID)
+	}
+	return ""
+}
+
+// matchAny 返回 values 中第一個被 patterns 匹配的取值，* 匹配任意取值
+func matchAny(patterns, values []string) (string, bool) {
+	for _, value := range values {
+		for _, pattern := range patterns {
+			if pattern == Wildcard || pattern == value {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
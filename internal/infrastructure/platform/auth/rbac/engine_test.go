@@ -0,0 +1,192 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+const testPolicy = `
+roles:
+  - name: viewer
+    permissions: ["*:read"]
+  - name: operator
+    inherits: [viewer]
+    permissions: ["alerts:*", "silence*:*"]
+  - name: detector-owner
+    permissions: ["detectors:*"]
+  - name: admin
+    permissions: ["*:*"]
+bindings:
+  - role: operator
+    subjects: ["group:/sre"]
+    team: sre
+  - role: detector-owner
+    subjects: ["*"]
+    owner: true
+  - role: viewer
+    subjects: ["user:carol"]
+    organization: acme
+roleMappings:
+  - claim: roles
+    values: [realm-admin]
+    roles: [admin]
+`
+
+func writePolicy(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+}
+
+func newTestEngine(t *testing.T, content, interval string) (*Engine, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rbac_policy.yaml")
+	writePolicy(t, path, content)
+	e, err := NewEngine(EngineConfig{PolicyFile: path, ReloadInterval: interval}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	return e, path
+}
+
+func TestEngine_Explain(t *testing.T) {
+	e, _ := newTestEngine(t, testPolicy, "0s")
+	sre := Subject{UserID: "bob", Groups: []string{"/sre"}}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+		role    string
+	}{
+		{"role mapping grants admin", Request{Subject: Subject{UserID: "alice", Roles: []string{"realm-admin"}},
+			Resource: "users", Action: "delete"}, true, "admin"},
+		{"team binding applies to team resources", Request{Subject: sre, Resource: "silences", Action: "create",
+			Scope: Scope{Team: "sre"}}, true, "operator"},
+		{"inherited permission", Request{Subject: sre, Resource: "detectors", Action: "read", Scope: Scope{Team: "sre"}}, true, "operator"},
+		{"team binding skipped for other teams", Request{Subject: sre, Resource: "alerts", Action: "acknowledge",
+			Scope: Scope{Team: "payments"}}, false, ""},
+		{"owner binding", Request{Subject: sre, Resource: "detectors", Action: "update", Scope: Scope{OwnerID: "bob"}}, true, "detector-owner"},
+		{"owner binding requires ownership", Request{Subject: sre, Resource: "detectors", Action: "update",
+			Scope: Scope{OwnerID: "dave"}}, false, ""},
+		{"organisation binding", Request{Subject: Subject{UserID: "carol"}, Resource: "alerts", Action: "read",
+			Scope: Scope{Organization: "acme"}}, true, "viewer"},
+		{"read only role", Request{Subject: Subject{UserID: "carol"}, Resource: "alerts", Action: "acknowledge",
+			Scope: Scope{Organization: "acme"}}, false, ""},
+		{"unauthenticated", Request{Resource: "alerts", Action: "read"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Explain(tt.req)
+			if d.Allowed != tt.allowed {
+				t.Fatalf("Allowed = %v, want %v (%s)", d.Allowed, tt.allowed, d.Reason)
+			}
+			if tt.allowed && (d.Grant == nil || d.Grant.Role != tt.role || d.Permission == "") {
+				t.Errorf("unexpected grant %+v permission %q", d.Grant, d.Permission)
+			}
+			if d.Reason == "" {
+				t.Error("decision has no reason")
+			}
+		})
+	}
+
+	// 拒絕的理由列出生效的角色與因範圍不符而未生效的綁定
+	d := e.Explain(Request{Subject: sre, Resource: "alerts", Action: "acknowledge", Scope: Scope{Team: "payments"}})
+	if len(d.Grants) != 0 || len(d.Skipped) != 2 || !strings.Contains(d.Skipped[0], "team=sre") ||
+		!strings.Contains(d.Skipped[0], `"payments"`) {
+		t.Errorf("unexpected explanation: %+v", d)
+	}
+}
+
+func TestEngine_AuthorizeUsesContextSubjectAndScope(t *testing.T) {
+	e, _ := newTestEngine(t, testPolicy, "0s")
+	ctx := WithScope(context.Background(), Scope{Team: "sre"})
+
+	if ok, err := e.Authorize(ctx, "bob", "alerts", "acknowledge"); err != nil || ok {
+		t.Errorf("without subject groups Authorize() = %v, %v", ok, err)
+	}
+	withSubject := WithSubject(ctx, Subject{UserID: "bob", Groups: []string{"/sre"}})
+	if ok, err := e.Authorize(withSubject, "bob", "alerts", "acknowledge"); err != nil || !ok {
+		t.Errorf("with subject groups Authorize() = %v, %v", ok, err)
+	}
+	// context 中的主體屬於其他用戶時不使用其角色
+	if ok, _ := e.Authorize(withSubject, "mallory", "alerts", "acknowledge"); ok {
+		t.Error("subject of another user must not be used")
+	}
+}
+
+func TestEngine_InvalidPolicies(t *testing.T) {
+	tests := map[string]string{
+		"unknown binding role": "roles: [{name: viewer, permissions: ['*:read']}]\nbindings: [{role: admin, subjects: ['*']}]",
+		"inheritance cycle":    "roles: [{name: a, inherits: [b]}, {name: b, inherits: [a]}]",
+		"invalid permission":   "roles: [{name: a, permissions: ['detectors']}]",
+		"inner wildcard":       "roles: [{name: a, permissions: ['det*ors:read']}]",
+		"invalid subject":      "roles: [{name: a}]\nbindings: [{role: a, subjects: ['alice']}]",
+		"unknown claim":        "roles: [{name: a}]\nroleMappings: [{claim: email, values: ['*'], roles: [a]}]",
+		"unknown field":        "roles: [{name: a, permission: ['*:*']}]",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			writePolicy(t, path, content)
+			if _, err := NewEngine(EngineConfig{PolicyFile: path}, &testLogger{}); err == nil {
+				t.Error("expected invalid policy error")
+			}
+		})
+	}
+}
+
+func TestEngine_HotReload(t *testing.T) {
+	e, path := newTestEngine(t, testPolicy, "10ms")
+	if err := e.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer e.Stop(context.Background())
+	carol := Request{Subject: Subject{UserID: "carol"}, Resource: "alerts", Action: "read"}
+	if e.Explain(carol).Allowed {
+		t.Fatal("carol should not read alerts outside acme")
+	}
+
+	waitFor := func(allowed bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for e.Explain(carol).Allowed != allowed {
+			if time.Now().After(deadline) {
+				t.Fatalf("policy was not reloaded, allowed = %v", !allowed)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	writePolicy(t, path, testPolicy+"  - claim: groups\n    values: ['*']\n    roles: [viewer]\n"+
+		"  - claim: roles\n    values: ['*']\n    roles: [viewer]\n")
+	carol.Subject.Roles = []string{"default-roles-detectviz"}
+	waitFor(true)
+
+	// 無效的新策略不會替換目前的策略
+	writePolicy(t, path, "roles: [{name: broken, inherits: [broken]}]")
+	time.Sleep(50 * time.Millisecond)
+	if !e.Explain(carol).Allowed {
+		t.Error("invalid policy replaced the current policy")
+	}
+	if _, err := e.Reload(); err == nil {
+		t.Error("expected Reload() to report the invalid policy")
+	}
+}
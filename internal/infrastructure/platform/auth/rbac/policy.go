@@ -0,0 +1,218 @@
+package rbac
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Wildcard 匹配任意資源、操作或主體
+const Wildcard = "*"
+
+// 綁定主體的前綴
+const (
+	SubjectUserPrefix  = "user:"
+	SubjectGroupPrefix = "group:"
+)
+
+// Policy 是角色策略文件的內容
+// 職責: 定義角色與權限、把角色綁定到用戶或群組 (可限定組織、團隊或檢測器擁有者範圍)，
+// 以及把令牌聲明中的角色或群組映射為平台角色。
+type Policy struct {
+	Roles        []RoleDefinition `yaml:"roles"`
+	Bindings     []RoleBinding    `yaml:"bindings"`
+	RoleMappings []RoleMapping    `yaml:"roleMappings"`
+}
+
+// RoleDefinition 定義角色擁有的權限
+type RoleDefinition struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Permissions []string `yaml:"permissions"` // resource:action，兩段都可以是 * 或以 * 結尾的前綴，例如 "detectors:*"、"*:read"、"alert*:read"
+	Inherits    []string `yaml:"inherits"`    // 繼承其他角色的權限
+}
+
+// RoleBinding 把角色授予主體
+type RoleBinding struct {
+	Role     string   `yaml:"role"`
+	Subjects []string `yaml:"subjects"` // user:<id>、group:<name> 或 * (所有已認證主體)
+	// 以下範圍為空時綁定在所有資源上生效；設置後只在資源屬於該範圍時生效
+	Organization string `yaml:"organization"` // 資源所屬的組織
+	Team         string `yaml:"team"`         // 資源所屬的團隊
+	Owner        bool   `yaml:"owner"`        // 只對主體自己擁有的資源 (例如檢測器的 OwnerID) 生效
+}
+
+// RoleMapping 把令牌聲明映射為平台角色，映射得到的角色在所有資源上生效
+type RoleMapping struct {
+	Claim  string   `yaml:"claim"`  // roles 或 groups
+	Values []string `yaml:"values"` // 聲明中的取值，* 表示任意取值
+	Roles  []string `yaml:"roles"`  // 授予的平台角色
+}
+
+// 令牌聲明名稱
+const (
+	ClaimRoles  = "roles"
+	ClaimGroups = "groups"
+)
+
+// permission 是解析後的 resource:action 權限
+type permission struct {
+	raw      string
+	resource string
+	action   string
+}
+
+// compiledRole 是展開繼承後的角色
+type compiledRole struct {
+	name        string
+	permissions []permission
+}
+
+// compiledPolicy 是校驗並展開後的策略，引擎以此判斷請求
+type compiledPolicy struct {
+	roles        map[string]*compiledRole
+	bindings     []RoleBinding
+	roleMappings []RoleMapping
+}
+
+// parsePolicy 解析並校驗 YAML 策略，未知的欄位視為錯誤
+func parsePolicy(data []byte) (*compiledPolicy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse RBAC policy: %w", err)
+	}
+	return compile(&policy)
+}
+
+// compile 校驗策略並展開角色繼承
+func compile(policy *Policy) (*compiledPolicy, error) {
+	definitions := make(map[string]*RoleDefinition, len(policy.Roles))
+	for i := range policy.Roles {
+		role := &policy.Roles[i]
+		if role.Name == "" {
+			return nil, fmt.Errorf("role #%d has no name", i+1)
+		}
+		if _, exists := definitions[role.Name]; exists {
+			return nil, fmt.Errorf("duplicate role %q", role.Name)
+		}
+		definitions[role.Name] = role
+	}
+
+	compiled := &compiledPolicy{
+		roles:        make(map[string]*compiledRole, len(definitions)),
+		bindings:     policy.Bindings,
+		roleMappings: policy.RoleMappings,
+	}
+	for name := range definitions {
+		permissions, err := expand(name, definitions, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		compiled.roles[name] = &compiledRole{name: name, permissions: permissions}
+	}
+
+	for i, binding := range policy.Bindings {
+		if _, ok := definitions[binding.Role]; !ok {
+			return nil, fmt.Errorf("binding #%d references unknown role %q", i+1, binding.Role)
+		}
+		if len(binding.Subjects) == 0 {
+			return nil, fmt.Errorf("binding #%d of role %q has no subjects", i+1, binding.Role)
+		}
+		for _, subject := range binding.Subjects {
+			if subject != Wildcard && !strings.HasPrefix(subject, SubjectUserPrefix) && !strings.HasPrefix(subject, SubjectGroupPrefix) {
+				return nil, fmt.Errorf("binding #%d has invalid subject %q: expected user:<id>, group:<name> or *", i+1, subject)
+			}
+		}
+	}
+	for i, mapping := range policy.RoleMappings {
+		if mapping.Claim != ClaimRoles && mapping.Claim != ClaimGroups {
+			return nil, fmt.Errorf("role mapping #%d has unsupported claim %q: expected roles or groups", i+1, mapping.Claim)
+		}
+		if len(mapping.Values) == 0 || len(mapping.Roles) == 0 {
+			return nil, fmt.Errorf("role mapping #%d requires values and roles", i+1)
+		}
+		for _, role := range mapping.Roles {
+			if _, ok := definitions[role]; !ok {
+				return nil, fmt.Errorf("role mapping #%d references unknown role %q", i+1, role)
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// expand 返回角色及其繼承角色的全部權限，檢測繼承循環
+func expand(name string, definitions map[string]*RoleDefinition, visiting map[string]bool) ([]permission, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("role %q inherits itself", name)
+	}
+	role, ok := definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown inherited role %q", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	var permissions []permission
+	for _, raw := range role.Permissions {
+		p, err := parsePermission(raw)
+		if err != nil {
+			return nil, fmt.Errorf("role %q: %w", name, err)
+		}
+		permissions = append(permissions, p)
+	}
+	for _, parent := range role.Inherits {
+		inherited, err := expand(parent, definitions, visiting)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, inherited...)
+	}
+	return permissions, nil
+}
+
+// parsePermission 解析 resource:action 格式的權限
+func parsePermission(raw string) (permission, error) {
+	resource, action, ok := strings.Cut(raw, ":")
+	if !ok || resource == "" || action == "" || strings.Contains(action, ":") {
+		return permission{}, fmt.Errorf("invalid permission %q: expected resource:action", raw)
+	}
+	for _, part := range []string{resource, action} {
+		if i := strings.Index(part, Wildcard); i >= 0 && i != len(part)-1 {
+			return permission{}, fmt.Errorf("invalid permission %q: * is only allowed at the end of a segment", raw)
+		}
+	}
+	return permission{raw: raw, resource: resource, action: action}, nil
+}
+
+// matches 檢查權限是否涵蓋 resource 與 action
+func (p permission) matches(resource, action string) bool {
+	return matchSegment(p.resource, resource) && matchSegment(p.action, action)
+}
+
+// matchSegment 比較單段權限，* 匹配任意值，以 * 結尾時按前綴匹配
+func matchSegment(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, Wildcard); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
+
+// describe 返回綁定的可讀描述，用於解釋授權結果
+func (b RoleBinding) describe() string {
+	parts := []string{"role=" + b.Role, "subjects=" + strings.Join(b.Subjects, ",")}
+	if b.Organization != "" {
+		parts = append(parts, "organization="+b.Organization)
+	}
+	if b.Team != "" {
+		parts = append(parts, "team="+b.Team)
+	}
+	if b.Owner {
+		parts = append(parts, "owner")
+	}
+	return "binding(" + strings.Join(parts, " ") + ")"
+}
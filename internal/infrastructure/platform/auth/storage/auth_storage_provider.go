@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ErrSessionNotFound 表示會話不存在或已過期
+var ErrSessionNotFound = errors.New("session not found or expired")
+
+// Config 定義會話、令牌與 CSRF 令牌的過期規則，記憶體與 SQL 存儲共用
+type Config struct {
+	IdleTimeout     string `yaml:"idleTimeout" json:"idleTimeout"`         // 會話閒置多久後過期，每次刷新重新計算 (滑動過期)，默認 "30m"
+	AbsoluteTimeout string `yaml:"absoluteTimeout" json:"absoluteTimeout"` // 會話自創建起的最長存活時間，刷新不會超過此時間，默認 "12h"
+	CSRFTokenTTL    string `yaml:"csrfTokenTTL" json:"csrfTokenTTL"`       // CSRF 令牌的有效期，默認 "2h"
+	Dialect         string `yaml:"dialect" json:"dialect"`                 // SQL 方言，只有 SQL 存儲使用，默認 "mysql"
+}
+
+// timeouts 是解析後的過期規則
+type timeouts struct {
+	idle     time.Duration
+	absolute time.Duration
+	csrf     time.Duration
+}
+
+// parseConfig 解析並填充默認的過期規則
+func parseConfig(config Config) (timeouts, error) {
+	var t timeouts
+	for _, field := range []struct {
+		name     string
+		value    string
+		fallback time.Duration
+		target   *time.Duration
+	}{
+		{"idleTimeout", config.IdleTimeout, 30 * time.Minute, &t.idle},
+		{"absoluteTimeout", config.AbsoluteTimeout, 12 * time.Hour, &t.absolute},
+		{"csrfTokenTTL", config.CSRFTokenTTL, 2 * time.Hour, &t.csrf},
+	} {
+		*field.target = field.fallback
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil || d <= 0 {
+			return timeouts{}, fmt.Errorf("invalid auth storage %s %q", field.name, field.value)
+		}
+		*field.target = d
+	}
+	if t.absolute < t.idle {
+		return timeouts{}, fmt.Errorf("auth storage absoluteTimeout %s is shorter than idleTimeout %s", t.absolute, t.idle)
+	}
+	return t, nil
+}
+
+// slide 返回刷新後的過期時間，不超過會話的最長存活時間
+func (t timeouts) slide(now, absoluteExpiresAt time.Time) time.Time {
+	expiresAt := now.Add(t.idle)
+	if expiresAt.After(absoluteExpiresAt) {
+		return absoluteExpiresAt
+	}
+	return expiresAt
+}
+
+// tokenExpiry 把 StoreToken 的 Unix 秒時間戳轉換為時間，0 表示不過期
+func tokenExpiry(expiry int64) time.Time {
+	if expiry <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(expiry, 0).UTC()
+}
+
+// hashCSRFToken 以 SHA-256 保存 CSRF 令牌，存儲洩露時無法直接重放
+func hashCSRFToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeSessionData 把會話數據編碼為 JSON，兩種存儲返回的數據因此都經過相同的 JSON 轉換
+func encodeSessionData(data map[string]any) ([]byte, error) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session data: %w", err)
+	}
+	return encoded, nil
+}
+
+// decodeSessionData 解碼 JSON 會話數據
+func decodeSessionData(encoded []byte) (map[string]any, error) {
+	data := map[string]any{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode session data: %w", err)
+	}
+	return data, nil
+}
+
+// sessionCleaner 由會清理過期會話的存儲實現，AuthStorageProvider 介面沒有定義此方法
+type sessionCleaner interface {
+	CleanExpiredSessions(ctx context.Context) error
+}
+
+// Sweeper 定期清理存儲中過期的會話、令牌與 CSRF 令牌
+type Sweeper struct {
+	store    contracts.AuthStorageProvider
+	interval time.Duration
+	logger   contracts.Logger
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewSweeper 創建清理器，interval 默認 "5m"
+func NewSweeper(store contracts.AuthStorageProvider, interval string, logger contracts.Logger) (*Sweeper, error) {
+	d := 5 * time.Minute
+	if interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid auth storage sweepInterval %q", interval)
+		}
+		d = parsed
+	}
+	return &Sweeper{store: store, interval: d, logger: logger}, nil
+}
+
+// Sweep 執行一次清理，某一類清理失敗不影響其他類別，返回遇到的錯誤
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	var errs []error
+	if err := s.store.CleanExpiredTokens(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.store.CleanExpiredCSRFTokens(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if cleaner, ok := s.store.(sessionCleaner); ok {
+		if err := cleaner.CleanExpiredSessions(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start 啟動背景清理
+func (s *Sweeper) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return nil
+	}
+	s.running = true
+	s.stopChan = make(chan struct{})
+	s.wg.Add(1)
+	go s.run(s.stopChan)
+	s.logger.Info("認證存儲清理器已啟動", "store", s.store.GetName(), "interval", s.interval)
+	return nil
+}
+
+// Stop 停止背景清理並等待當前清理完成
+func (s *Sweeper) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.stopChan)
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// run 定期執行清理
+func (s *Sweeper) run(stopChan chan struct{}) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+			if err := s.Sweep(ctx); err != nil {
+				s.logger.Error("清理過期認證數據失敗", "store", s.store.GetName(), "error", err)
+			}
+			cancel()
+		}
+	}
+}
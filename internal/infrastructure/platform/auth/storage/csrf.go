@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// csrfContextKey 是 context 中 CSRF 綁定 ID 的鍵
+type csrfContextKey struct{}
+
+// WithCSRFBinding 返回帶有 CSRF 綁定 ID 的 context。綁定 ID 是登錄會話的 ID，未登錄時是匿名 CSRF cookie 中的 ID。
+func WithCSRFBinding(ctx context.Context, bindingID string) context.Context {
+	return context.WithValue(ctx, csrfContextKey{}, bindingID)
+}
+
+// CSRFBindingFromContext 返回 context 中的 CSRF 綁定 ID
+func CSRFBindingFromContext(ctx context.Context) (string, bool) {
+	bindingID, ok := ctx.Value(csrfContextKey{}).(string)
+	return bindingID, ok && bindingID != ""
+}
+
+// GenerateCSRFToken 生成隨機 CSRF 令牌並綁定到 context 中的會話 (同步器令牌模式)
+func GenerateCSRFToken(ctx context.Context, store contracts.AuthStorageProvider) (string, error) {
+	bindingID, ok := CSRFBindingFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("no session to bind the CSRF token to")
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	if err := store.StoreCSRFToken(ctx, bindingID, token); err != nil {
+		return "", fmt.Errorf("failed to store CSRF token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateCSRFToken 檢查令牌是否為 context 中的會話簽發且未過期
+func ValidateCSRFToken(ctx context.Context, store contracts.AuthStorageProvider, token string) error {
+	if token == "" {
+		return fmt.Errorf("CSRF token is required")
+	}
+	bindingID, ok := CSRFBindingFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("CSRF token is not bound to a session")
+	}
+	valid, err := store.ValidateStoredCSRFToken(ctx, bindingID, token)
+	if err != nil {
+		return fmt.Errorf("failed to validate CSRF token: %w", err)
+	}
+	if !valid {
+		return fmt.Errorf("invalid or expired CSRF token")
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// memorySession 是記憶體中的會話記錄，數據以 JSON 保存，與 SQL 存儲返回相同的類型
+type memorySession struct {
+	data              []byte
+	expiresAt         time.Time
+	absoluteExpiresAt time.Time
+}
+
+// memoryToken 是記憶體中的令牌記錄，零值 expiresAt 表示不過期
+type memoryToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// tokenKey 以用戶與令牌類型標識令牌，每個用戶每種類型只保存一個
+type tokenKey struct {
+	userID    string
+	tokenType string
+}
+
+// MemoryAuthStorageProvider 實現了 pkg/platform/contracts.AuthStorageProvider 介面。
+// 職責: 在進程記憶體中保存會話、令牌與 CSRF 令牌，用於開發與測試；重啟後數據丟失，也不能在多個實例間共享。
+type MemoryAuthStorageProvider struct {
+	timeouts timeouts
+	logger   contracts.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*memorySession
+	tokens   map[tokenKey]memoryToken
+	csrf     map[string]map[string]time.Time // sessionID -> 令牌雜湊 -> 過期時間
+}
+
+// NewMemoryAuthStorageProvider 創建記憶體認證存儲
+func NewMemoryAuthStorageProvider(config Config, logger contracts.Logger) (*MemoryAuthStorageProvider, error) {
+	t, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryAuthStorageProvider{
+		timeouts: t,
+		logger:   logger,
+		now:      time.Now,
+		sessions: make(map[string]*memorySession),
+		tokens:   make(map[tokenKey]memoryToken),
+		csrf:     make(map[string]map[string]time.Time),
+	}, nil
+}
+
+// SetSession 創建或更新會話。更新未過期的會話時保留最長存活時間並刷新閒置過期時間。
+func (p *MemoryAuthStorageProvider) SetSession(ctx context.Context, sessionID string, data map[string]any) error {
+	encoded, err := encodeSessionData(data)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if session, ok := p.sessions[sessionID]; ok && now.Before(session.expiresAt) {
+		session.data = encoded
+		session.expiresAt = p.timeouts.slide(now, session.absoluteExpiresAt)
+		return nil
+	}
+	absolute := now.Add(p.timeouts.absolute)
+	p.sessions[sessionID] = &memorySession{
+		data:              encoded,
+		expiresAt:         p.timeouts.slide(now, absolute),
+		absoluteExpiresAt: absolute,
+	}
+	return nil
+}
+
+// GetSession 返回會話數據，會話不存在或已過期時返回 nil
+func (p *MemoryAuthStorageProvider) GetSession(ctx context.Context, sessionID string) (map[string]any, error) {
+	p.mu.Lock()
+	session, ok := p.sessions[sessionID]
+	if !ok || !p.now().Before(session.expiresAt) {
+		p.mu.Unlock()
+		return nil, nil
+	}
+	encoded := session.data
+	p.mu.Unlock()
+	return decodeSessionData(encoded)
+}
+
+// DeleteSession 刪除會話及綁定到會話的 CSRF 令牌
+func (p *MemoryAuthStorageProvider) DeleteSession(ctx context.Context, sessionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sessions, sessionID)
+	delete(p.csrf, sessionID)
+	return nil
+}
+
+// RefreshSession 把會話的閒置過期時間延長到現在起的 idleTimeout，不超過最長存活時間
+func (p *MemoryAuthStorageProvider) RefreshSession(ctx context.Context, sessionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	session, ok := p.sessions[sessionID]
+	if !ok || !now.Before(session.expiresAt) {
+		return ErrSessionNotFound
+	}
+	session.expiresAt = p.timeouts.slide(now, session.absoluteExpiresAt)
+	return nil
+}
+
+// StoreToken 保存令牌，expiry 為 Unix 秒時間戳，0 表示不過期；同一用戶同一類型的舊令牌被替換
+func (p *MemoryAuthStorageProvider) StoreToken(ctx context.Context, userID, tokenType, token string, expiry int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokens[tokenKey{userID, tokenType}] = memoryToken{token: token, expiresAt: tokenExpiry(expiry)}
+	return nil
+}
+
+// GetToken 返回令牌，不存在或已過期時返回空字串
+func (p *MemoryAuthStorageProvider) GetToken(ctx context.Context, userID, tokenType string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, ok := p.tokens[tokenKey{userID, tokenType}]
+	if !ok || (!token.expiresAt.IsZero() && !p.now().Before(token.expiresAt)) {
+		return "", nil
+	}
+	return token.token, nil
+}
+
+// RevokeToken 刪除令牌
+func (p *MemoryAuthStorageProvider) RevokeToken(ctx context.Context, userID, tokenType string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tokens, tokenKey{userID, tokenType})
+	return nil
+}
+
+// CleanExpiredTokens 刪除已過期的令牌
+func (p *MemoryAuthStorageProvider) CleanExpiredTokens(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for key, token := range p.tokens {
+		if !token.expiresAt.IsZero() && !now.Before(token.expiresAt) {
+			delete(p.tokens, key)
+		}
+	}
+	return nil
+}
+
+// StoreCSRFToken 保存綁定到會話的 CSRF 令牌，同一會話可以同時有多個有效令牌 (多個頁面)
+func (p *MemoryAuthStorageProvider) StoreCSRFToken(ctx context.Context, sessionID, token string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tokens, ok := p.csrf[sessionID]
+	if !ok {
+		tokens = make(map[string]time.Time)
+		p.csrf[sessionID] = tokens
+	}
+	tokens[hashCSRFToken(token)] = p.now().Add(p.timeouts.csrf)
+	return nil
+}
+
+// ValidateStoredCSRFToken 檢查令牌是否為會話保存過且未過期的令牌，以常數時間比較
+func (p *MemoryAuthStorageProvider) ValidateStoredCSRFToken(ctx context.Context, sessionID, token string) (bool, error) {
+	if sessionID == "" || token == "" {
+		return false, nil
+	}
+	hash := []byte(hashCSRFToken(token))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	valid := false
+	for stored, expiresAt := range p.csrf[sessionID] {
+		if subtle.ConstantTimeCompare([]byte(stored), hash) == 1 && now.Before(expiresAt) {
+			valid = true
+		}
+	}
+	return valid, nil
+}
+
+// CleanExpiredCSRFTokens 刪除已過期的 CSRF 令牌
+func (p *MemoryAuthStorageProvider) CleanExpiredCSRFTokens(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for sessionID, tokens := range p.csrf {
+		for hash, expiresAt := range tokens {
+			if !now.Before(expiresAt) {
+				delete(tokens, hash)
+			}
+		}
+		if len(tokens) == 0 {
+			delete(p.csrf, sessionID)
+		}
+	}
+	return nil
+}
+
+// CleanExpiredSessions 刪除已過期的會話及其 CSRF 令牌
+func (p *MemoryAuthStorageProvider) CleanExpiredSessions(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for sessionID, session := range p.sessions {
+		if !now.Before(session.expiresAt) {
+			delete(p.sessions, sessionID)
+			delete(p.csrf, sessionID)
+		}
+	}
+	return nil
+}
+
+// GetName 返回存儲提供者的名稱
+func (p *MemoryAuthStorageProvider) GetName() string {
+	return "memory_auth_storage"
+}
+
+// 確保實現了 AuthStorageProvider 介面
+var _ contracts.AuthStorageProvider = (*MemoryAuthStorageProvider)(nil)
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ErrInvalidSession 表示會話 ID 的簽名無效，或會話不存在、已過期
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// 會話數據中的鍵
+const (
+	sessionKeyUserID          = "user_id"
+	sessionKeyUsername        = "username"
+	sessionKeyEmail           = "email"
+	sessionKeyRoles           = "roles"
+	sessionKeyGroups          = "groups"
+	sessionKeyAuthenticatedAt = "authenticated_at"
+	sessionKeyTokenExpiresAt  = "token_expires_at"
+)
+
+// Session 是已登錄用戶的會話
+type Session struct {
+	ID              string // 存儲中使用的會話 ID (未簽名)
+	UserID          string
+	Username        string
+	Email           string
+	Roles           []string
+	Groups          []string
+	AuthenticatedAt time.Time
+	// TokenExpiresAt 是外部身份提供者令牌的過期時間，過期後由 OIDC 刷新流程更新會話；為零表示不需要刷新
+	TokenExpiresAt time.Time
+}
+
+// SessionManager 管理以簽名的不透明 ID 標識的會話。
+// 職責: 登錄時創建新會話並廢棄舊會話 (會話輪換，防止會話固定攻擊)，解析 cookie 中的會話 ID 並刷新滑動過期時間。
+// cookie 的值為 <隨機 ID>.<HMAC-SHA256 簽名>，簽名不符的 ID 不會查詢存儲。
+type SessionManager struct {
+	store      contracts.AuthStorageProvider
+	signingKey []byte
+	logger     contracts.Logger
+	now        func() time.Time
+}
+
+// NewSessionManager 創建會話管理器。signingKey 為空時生成隨機密鑰，重啟後所有會話失效，多實例部署時必須配置。
+func NewSessionManager(store contracts.AuthStorageProvider, signingKey []byte, logger contracts.Logger) (*SessionManager, error) {
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, fmt.Errorf("failed to generate session signing key: %w", err)
+		}
+		logger.Warn("未配置會話簽名密鑰，使用隨機密鑰，重啟後所有會話失效")
+	} else if len(signingKey) < 32 {
+		return nil, fmt.Errorf("session signing key must be at least 32 bytes")
+	}
+	return &SessionManager{
+		store:      store,
+		signingKey: signingKey,
+		logger:     logger,
+		now:        time.Now,
+	}, nil
+}
+
+// Store 返回保存會話的存儲
+func (m *SessionManager) Store() contracts.AuthStorageProvider {
+	return m.store
+}
+
+// Create 為登錄成功的用戶創建新會話，返回放入 cookie 的簽名 ID。
+// previous 是請求中原有的會話 cookie，有效時一併刪除，確保登錄後的會話 ID 不可能被事先得知。
+func (m *SessionManager) Create(ctx context.Context, session Session, previous string) (string, error) {
+	if session.UserID == "" {
+		return "", fmt.Errorf("session requires a user id")
+	}
+	if id, ok := m.Verify(previous); ok {
+		if err := m.store.DeleteSession(ctx, id); err != nil {
+			return "", fmt.Errorf("failed to delete previous session: %w", err)
+		}
+	}
+
+	id, signed, err := m.NewSignedID()
+	if err != nil {
+		return "", err
+	}
+	if session.AuthenticatedAt.IsZero() {
+		session.AuthenticatedAt = m.now()
+	}
+	session.ID = id
+	if err := m.store.SetSession(ctx, id, sessionData(&session)); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return signed, nil
+}
+
+// Update 以 session 的內容覆蓋存儲中未過期的會話，保留會話原有的最長存活時間
+func (m *SessionManager) Update(ctx context.Context, session *Session) error {
+	if session.ID == "" || session.UserID == "" {
+		return fmt.Errorf("session requires an id and a user id")
+	}
+	data, err := m.store.GetSession(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return ErrInvalidSession
+	}
+	if err := m.store.SetSession(ctx, session.ID, sessionData(session)); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// NewSignedID 生成隨機 ID 及其簽名形式，用於會話與未登錄訪客的 CSRF 綁定
+func (m *SessionManager) NewSignedID() (id string, signed string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	id = base64.RawURLEncoding.EncodeToString(buf)
+	return id, id + "." + m.sign(id), nil
+}
+
+// Lookup 驗證簽名並返回會話，同時刷新會話的閒置過期時間
+func (m *SessionManager) Lookup(ctx context.Context, signedID string) (*Session, error) {
+	id, ok := m.Verify(signedID)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+	data, err := m.store.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrInvalidSession
+	}
+	if err := m.store.RefreshSession(ctx, id); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrInvalidSession
+		}
+		return nil, err
+	}
+	return decodeSession(id, data)
+}
+
+// Get 以未簽名的會話 ID 讀取會話，不刷新閒置過期時間
+func (m *SessionManager) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := m.store.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrInvalidSession
+	}
+	return decodeSession(id, data)
+}
+
+// sessionData 把會話轉換為存儲中的會話數據
+func sessionData(session *Session) map[string]any {
+	data := map[string]any{
+		sessionKeyUserID:          session.UserID,
+		sessionKeyUsername:        session.Username,
+		sessionKeyEmail:           session.Email,
+		sessionKeyRoles:           session.Roles,
+		sessionKeyGroups:          session.Groups,
+		sessionKeyAuthenticatedAt: session.AuthenticatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if !session.TokenExpiresAt.IsZero() {
+		data[sessionKeyTokenExpiresAt] = session.TokenExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	return data
+}
+
+// decodeSession 從存儲中的會話數據還原會話，沒有用戶 ID 的數據視為無效會話
+func decodeSession(id string, data map[string]any) (*Session, error) {
+	session := &Session{
+		ID:       id,
+		UserID:   stringValue(data[sessionKeyUserID]),
+		Username: stringValue(data[sessionKeyUsername]),
+		Email:    stringValue(data[sessionKeyEmail]),
+		Roles:    stringSlice(data[sessionKeyRoles]),
+		Groups:   stringSlice(data[sessionKeyGroups]),
+	}
+	if session.UserID == "" {
+		return nil, ErrInvalidSession
+	}
+	if t, err := time.Parse(time.RFC3339Nano, stringValue(data[sessionKeyAuthenticatedAt])); err == nil {
+		session.AuthenticatedAt = t
+	}
+	if t, err := time.Parse(time.RFC3339Nano, stringValue(data[sessionKeyTokenExpiresAt])); err == nil {
+		session.TokenExpiresAt = t
+	}
+	return session, nil
+}
+
+// Destroy 刪除簽名 ID 對應的會話，簽名無效時不做任何事
+func (m *SessionManager) Destroy(ctx context.Context, signedID string) error {
+	id, ok := m.Verify(signedID)
+	if !ok {
+		return nil
+	}
+	return m.store.DeleteSession(ctx, id)
+}
+
+// Verify 驗證簽名 ID 並返回存儲中使用的會話 ID
+func (m *SessionManager) Verify(signedID string) (string, bool) {
+	id, signature, ok := strings.Cut(signedID, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(m.sign(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// sign 返回會話 ID 的 HMAC-SHA256 簽名
+func (m *SessionManager) sign(id string) string {
+	mac := hmac.New(sha256.New, m.signingKey)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stringValue 讀取會話數據中的字串
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+// stringSlice 讀取會話數據中的字串列表，經 JSON 轉換後列表的類型為 []any
+func stringSlice(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SQLAuthStorageProvider 實現了 pkg/platform/contracts.AuthStorageProvider 介面。
+// 職責: 在 auth_sessions、auth_tokens 與 auth_csrf_tokens 表中保存認證數據，供多個實例共享，表由遷移 0016 創建。
+type SQLAuthStorageProvider struct {
+	db       *sql.DB
+	dialect  string
+	timeouts timeouts
+	logger   contracts.Logger
+	now      func() time.Time
+}
+
+// NewSQLAuthStorageProvider 創建 SQL 認證存儲
+func NewSQLAuthStorageProvider(db *sql.DB, config Config, logger contracts.Logger) (*SQLAuthStorageProvider, error) {
+	t, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Dialect == "" {
+		config.Dialect = database.DialectMySQL
+	}
+	return &SQLAuthStorageProvider{
+		db:       db,
+		dialect:  config.Dialect,
+		timeouts: t,
+		logger:   logger,
+		now:      time.Now,
+	}, nil
+}
+
+// exec 執行寫入語句，語句以 ? 佔位符書寫
+func (p *SQLAuthStorageProvider) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return database.ExecutorFromContext(ctx, p.db).ExecContext(ctx, database.Rebind(p.dialect, query), args...)
+}
+
+// queryRow 執行單行查詢，語句以 ? 佔位符書寫
+func (p *SQLAuthStorageProvider) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return database.ExecutorFromContext(ctx, p.db).QueryRowContext(ctx, database.Rebind(p.dialect, query), args...)
+}
+
+// SetSession 創建或更新會話。更新未過期的會話時保留最長存活時間並刷新閒置過期時間。
+func (p *SQLAuthStorageProvider) SetSession(ctx context.Context, sessionID string, data map[string]any) error {
+	encoded, err := encodeSessionData(data)
+	if err != nil {
+		return err
+	}
+	now := p.now().UTC()
+
+	var absolute time.Time
+	err = p.queryRow(ctx, `SELECT absolute_expires_at FROM auth_sessions WHERE id = ? AND expires_at > ?`,
+		sessionID, now).Scan(&absolute)
+	switch {
+	case err == nil:
+		if _, err := p.exec(ctx, `UPDATE auth_sessions SET data = ?, expires_at = ? WHERE id = ?`,
+			string(encoded), p.timeouts.slide(now, absolute), sessionID); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		return nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("failed to query session: %w", err)
+	}
+
+	// 同一 ID 的過期記錄先刪除，再以新的最長存活時間創建
+	if _, err := p.exec(ctx, `DELETE FROM auth_sessions WHERE id = ?`, sessionID); err != nil {
+		return fmt.Errorf("failed to delete expired session: %w", err)
+	}
+	absolute = now.Add(p.timeouts.absolute)
+	if _, err := p.exec(ctx, `INSERT INTO auth_sessions (id, data, created_at, expires_at, absolute_expires_at) VALUES (?, ?, ?, ?, ?)`,
+		sessionID, string(encoded), now, p.timeouts.slide(now, absolute), absolute); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetSession 返回會話數據，會話不存在或已過期時返回 nil
+func (p *SQLAuthStorageProvider) GetSession(ctx context.Context, sessionID string) (map[string]any, error) {
+	var encoded string
+	err := p.queryRow(ctx, `SELECT data FROM auth_sessions WHERE id = ? AND expires_at > ?`,
+		sessionID, p.now().UTC()).Scan(&encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return decodeSessionData([]byte(encoded))
+}
+
+// DeleteSession 刪除會話及綁定到會話的 CSRF 令牌
+func (p *SQLAuthStorageProvider) DeleteSession(ctx context.Context, sessionID string) error {
+	if _, err := p.exec(ctx, `DELETE FROM auth_csrf_tokens WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session csrf tokens: %w", err)
+	}
+	if _, err := p.exec(ctx, `DELETE FROM auth_sessions WHERE id = ?`, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// RefreshSession 把會話的閒置過期時間延長到現在起的 idleTimeout，不超過最長存活時間
+func (p *SQLAuthStorageProvider) RefreshSession(ctx context.Context, sessionID string) error {
+	now := p.now().UTC()
+	var absolute time.Time
+	err := p.queryRow(ctx, `SELECT absolute_expires_at FROM auth_sessions WHERE id = ? AND expires_at > ?`,
+		sessionID, now).Scan(&absolute)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query session: %w", err)
+	}
+	if _, err := p.exec(ctx, `UPDATE auth_sessions SET expires_at = ? WHERE id = ?`,
+		p.timeouts.slide(now, absolute), sessionID); err != nil {
+		return fmt.Errorf("failed to refresh session: %w", err)
+	}
+	return nil
+}
+
+// StoreToken 保存令牌，expiry 為 Unix 秒時間戳，0 表示不過期；同一用戶同一類型的舊令牌被替換
+func (p *SQLAuthStorageProvider) StoreToken(ctx context.Context, userID, tokenType, token string, expiry int64) error {
+	var expiresAt interface{}
+	if t := tokenExpiry(expiry); !t.IsZero() {
+		expiresAt = t
+	}
+	if _, err := p.exec(ctx, `DELETE FROM auth_tokens WHERE user_id = ? AND token_type = ?`, userID, tokenType); err != nil {
+		return fmt.Errorf("failed to replace token: %w", err)
+	}
+	if _, err := p.exec(ctx, `INSERT INTO auth_tokens (user_id, token_type, token, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		userID, tokenType, token, expiresAt, p.now().UTC()); err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+	return nil
+}
+
+// GetToken 返回令牌，不存在或已過期時返回空字串
+func (p *SQLAuthStorageProvider) GetToken(ctx context.Context, userID, tokenType string) (string, error) {
+	var token string
+	err := p.queryRow(ctx, `SELECT token FROM auth_tokens WHERE user_id = ? AND token_type = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		userID, tokenType, p.now().UTC()).Scan(&token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get token: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeToken 刪除令牌
+func (p *SQLAuthStorageProvider) RevokeToken(ctx context.Context, userID, tokenType string) error {
+	if _, err := p.exec(ctx, `DELETE FROM auth_tokens WHERE user_id = ? AND token_type = ?`, userID, tokenType); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// CleanExpiredTokens 刪除已過期的令牌
+func (p *SQLAuthStorageProvider) CleanExpiredTokens(ctx context.Context) error {
+	result, err := p.exec(ctx, `DELETE FROM auth_tokens WHERE expires_at IS NOT NULL AND expires_at <= ?`, p.now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to clean expired tokens: %w", err)
+	}
+	p.logCleaned("tokens", result)
+	return nil
+}
+
+// StoreCSRFToken 保存綁定到會話的 CSRF 令牌，同一會話可以同時有多個有效令牌 (多個頁面)
+func (p *SQLAuthStorageProvider) StoreCSRFToken(ctx context.Context, sessionID, token string) error {
+	now := p.now().UTC()
+	if _, err := p.exec(ctx, `INSERT INTO auth_csrf_tokens (session_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		sessionID, hashCSRFToken(token), now.Add(p.timeouts.csrf), now); err != nil {
+		return fmt.Errorf("failed to store csrf token: %w", err)
+	}
+	return nil
+}
+
+// ValidateStoredCSRFToken 檢查令牌是否為會話保存過且未過期的令牌，以常數時間比較
+func (p *SQLAuthStorageProvider) ValidateStoredCSRFToken(ctx context.Context, sessionID, token string) (bool, error) {
+	if sessionID == "" || token == "" {
+		return false, nil
+	}
+	rows, err := database.ExecutorFromContext(ctx, p.db).QueryContext(ctx, database.Rebind(p.dialect,
+		`SELECT token_hash FROM auth_csrf_tokens WHERE session_id = ? AND expires_at > ?`), sessionID, p.now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to query csrf tokens: %w", err)
+	}
+	defer rows.Close()
+
+	hash := []byte(hashCSRFToken(token))
+	valid := false
+	for rows.Next() {
+		var stored string
+		if err := rows.Scan(&stored); err != nil {
+			return false, fmt.Errorf("failed to scan csrf token: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(stored), hash) == 1 {
+			valid = true
+		}
+	}
+	return valid, rows.Err()
+}
+
+// CleanExpiredCSRFTokens 刪除已過期的 CSRF 令牌
+func (p *SQLAuthStorageProvider) CleanExpiredCSRFTokens(ctx context.Context) error {
+	result, err := p.exec(ctx, `DELETE FROM auth_csrf_tokens WHERE expires_at <= ?`, p.now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to clean expired csrf tokens: %w", err)
+	}
+	p.logCleaned("csrf_tokens", result)
+	return nil
+}
+
+// CleanExpiredSessions 刪除已過期的會話，其 CSRF 令牌的有效期不會超過會話，由 CleanExpiredCSRFTokens 清理
+func (p *SQLAuthStorageProvider) CleanExpiredSessions(ctx context.Context) error {
+	result, err := p.exec(ctx, `DELETE FROM auth_sessions WHERE expires_at <= ?`, p.now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to clean expired sessions: %w", err)
+	}
+	p.logCleaned("sessions", result)
+	return nil
+}
+
+// logCleaned 記錄清理的記錄數
+func (p *SQLAuthStorageProvider) logCleaned(kind string, result sql.Result) {
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		p.logger.Debug("已清理過期認證數據", "kind", kind, "count", n)
+	}
+}
+
+// GetName 返回存儲提供者的名稱
+func (p *SQLAuthStorageProvider) GetName() string {
+	return "sql_auth_storage"
+}
+
+// 確保實現了 AuthStorageProvider 介面
+var _ contracts.AuthStorageProvider = (*SQLAuthStorageProvider)(nil)
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func newTestStore(t *testing.T) (*MemoryAuthStorageProvider, *time.Time) {
+	t.Helper()
+	store, err := NewMemoryAuthStorageProvider(Config{IdleTimeout: "30m", AbsoluteTimeout: "1h", CSRFTokenTTL: "10m"}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewMemoryAuthStorageProvider() error = %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryStore_SlidingSessionExpiry(t *testing.T) {
+	store, now := newTestStore(t)
+	ctx := context.Background()
+	if err := store.SetSession(ctx, "s1", map[string]any{"user_id": "alice", "roles": []string{"viewer"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 閒置時間內刷新會延長會話
+	*now = now.Add(20 * time.Minute)
+	if err := store.RefreshSession(ctx, "s1"); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+	*now = now.Add(20 * time.Minute)
+	data, err := store.GetSession(ctx, "s1")
+	if err != nil || data == nil {
+		t.Fatalf("GetSession() after refresh = %v, %v", data, err)
+	}
+	if roles, ok := data["roles"].([]any); !ok || len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("roles = %#v", data["roles"])
+	}
+
+	// 刷新不超過最長存活時間
+	if err := store.RefreshSession(ctx, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(15 * time.Minute)
+	if err := store.RefreshSession(ctx, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(6 * time.Minute)
+	if data, _ := store.GetSession(ctx, "s1"); data != nil {
+		t.Error("session outlived absoluteTimeout")
+	}
+	if err := store.RefreshSession(ctx, "s1"); err != ErrSessionNotFound {
+		t.Errorf("RefreshSession() of expired session error = %v", err)
+	}
+
+	// 過期會話以相同 ID 重新創建時得到新的最長存活時間
+	if err := store.SetSession(ctx, "s1", map[string]any{"user_id": "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := store.GetSession(ctx, "s1"); data == nil || data["user_id"] != "bob" {
+		t.Errorf("recreated session = %v", data)
+	}
+}
+
+func TestMemoryStore_TokensCSRFAndSweeper(t *testing.T) {
+	store, now := newTestStore(t)
+	ctx := context.Background()
+
+	_ = store.StoreToken(ctx, "alice", "refresh", "r1", now.Add(time.Hour).Unix())
+	_ = store.StoreToken(ctx, "alice", "offline", "o1", 0)
+	_ = store.SetSession(ctx, "s1", nil)
+	_ = store.StoreCSRFToken(ctx, "s1", "csrf-a")
+	_ = store.StoreCSRFToken(ctx, "s1", "csrf-b")
+
+	if token, _ := store.GetToken(ctx, "alice", "refresh"); token != "r1" {
+		t.Errorf("GetToken() = %q", token)
+	}
+	for _, tt := range []struct {
+		session, token string
+		want           bool
+	}{
+		{"s1", "csrf-a", true},
+		{"s1", "csrf-b", true},
+		{"s1", "csrf-c", false},
+		{"s2", "csrf-a", false},
+		{"s1", "", false},
+	} {
+		if got, _ := store.ValidateStoredCSRFToken(ctx, tt.session, tt.token); got != tt.want {
+			t.Errorf("ValidateStoredCSRFToken(%q, %q) = %v, want %v", tt.session, tt.token, got, tt.want)
+		}
+	}
+	if len(store.csrf["s1"]) != 2 || strings.Contains(strings.Join(keys(store.csrf["s1"]), ","), "csrf-a") {
+		t.Error("csrf tokens are not stored hashed")
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if token, _ := store.GetToken(ctx, "alice", "refresh"); token != "" {
+		t.Error("expired token returned")
+	}
+	sweeper, err := NewSweeper(store, "1m", &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sweeper.Sweep(ctx); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(store.tokens) != 1 || len(store.csrf) != 0 || len(store.sessions) != 0 {
+		t.Errorf("after sweep: %d tokens, %d csrf sessions, %d sessions", len(store.tokens), len(store.csrf), len(store.sessions))
+	}
+	if token, _ := store.GetToken(ctx, "alice", "offline"); token != "o1" {
+		t.Error("non-expiring token was swept")
+	}
+}
+
+func TestSessionManager_SignedIDsAndRotation(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	manager, err := NewSessionManager(store, []byte(strings.Repeat("k", 32)), &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := manager.Create(ctx, Session{UserID: "u1", Username: "alice", Roles: []string{"operator"}}, "")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	session, err := manager.Lookup(ctx, first)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if session.UserID != "u1" || session.Username != "alice" || len(session.Roles) != 1 || session.AuthenticatedAt.IsZero() {
+		t.Errorf("Lookup() = %+v", session)
+	}
+
+	id, _, _ := strings.Cut(first, ".")
+	for _, forged := range []string{id, id + ".", id + ".AAAA", "other." + strings.SplitN(first, ".", 2)[1], ""} {
+		if _, err := manager.Lookup(ctx, forged); err != ErrInvalidSession {
+			t.Errorf("Lookup(%q) error = %v, want ErrInvalidSession", forged, err)
+		}
+	}
+	other, _ := NewSessionManager(store, []byte(strings.Repeat("x", 32)), &testLogger{})
+	if _, err := other.Lookup(ctx, first); err != ErrInvalidSession {
+		t.Error("session signed with another key accepted")
+	}
+
+	// 再次登錄輪換會話 ID，舊會話立即失效
+	second, err := manager.Create(ctx, Session{UserID: "u1"}, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Fatal("session id was not rotated")
+	}
+	if _, err := manager.Lookup(ctx, first); err != ErrInvalidSession {
+		t.Error("previous session still valid after login")
+	}
+	if err := manager.Destroy(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Lookup(ctx, second); err != ErrInvalidSession {
+		t.Error("destroyed session still valid")
+	}
+
+	if _, err := NewSessionManager(store, []byte("short"), &testLogger{}); err == nil {
+		t.Error("short signing key accepted")
+	}
+}
+
+func keys(m map[string]time.Time) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
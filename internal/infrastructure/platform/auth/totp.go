@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod、totpDigits 與 totpSecretBytes 是 RFC 6238 的參數，與主流驗證器應用的默認值一致 (HMAC-SHA1)
+	totpPeriod      = 30
+	totpDigits      = 6
+	totpSecretBytes = 20
+
+	// sealedSecretVersion 是加密密鑰的格式版本前綴
+	sealedSecretVersion = "v1."
+)
+
+// totpEncoding 是 TOTP 密鑰的 Base32 編碼，不帶填充，與 otpauth URI 的格式一致
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret 生成隨機的 Base32 TOTP 密鑰
+func generateTOTPSecret() (string, error) {
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(raw), nil
+}
+
+// decodeTOTPSecret 解碼 Base32 TOTP 密鑰，忽略大小寫與空白
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	return totpEncoding.DecodeString(strings.TrimRight(normalized, "="))
+}
+
+// totpStep 返回 t 所在的時間步
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode 按 RFC 4226 的動態截斷計算時間步 step 的驗證碼
+func totpCode(key []byte, step int64, digits int) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulus := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus)
+}
+
+// matchTOTP 在 now 前後 skew 個時間步內查找與 code 匹配的時間步，逐一以常數時間比較
+func matchTOTP(key []byte, code string, now time.Time, skew int) (int64, bool) {
+	current := totpStep(now)
+	var matched int64
+	found := false
+	for delta := -int64(skew); delta <= int64(skew); delta++ {
+		if hmac.Equal([]byte(totpCode(key, current+delta, totpDigits)), []byte(code)) && !found {
+			matched, found = current+delta, true
+		}
+	}
+	return matched, found
+}
+
+// TOTPProvisioningURI 返回驗證器應用掃描的 otpauth URI (Key Uri Format)，可直接編碼為二維碼
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// secretCipher 以 AES-256-GCM 加密保存的秘密，加密密鑰由 SecretsProvider 中的主密鑰派生
+type secretCipher struct {
+	aead cipher.AEAD
+}
+
+// newSecretCipher 從主密鑰派生加密密鑰，主密鑰至少 32 字節
+func newSecretCipher(master []byte) (*secretCipher, error) {
+	if len(master) < 32 {
+		return nil, fmt.Errorf("encryption key must be at least 32 bytes, got %d", len(master))
+	}
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("detectviz-secret-encryption"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &secretCipher{aead: aead}, nil
+}
+
+// seal 加密 plaintext，associated 綁定到密文 (例如用戶 ID)，解密時必須一致
+func (c *secretCipher) seal(plaintext, associated string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(associated))
+	return sealedSecretVersion + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open 解密 seal 的結果
+func (c *secretCipher) open(sealed, associated string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, sealedSecretVersion)
+	if !ok {
+		return "", fmt.Errorf("unsupported encrypted secret format")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) < c.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted secret")
+	}
+	nonce, ciphertext := raw[:c.aead.NonceSize()], raw[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(associated))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
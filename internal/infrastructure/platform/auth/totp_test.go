@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附錄 B 的 SHA1 測試向量 (8 位)
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+	for _, tc := range cases {
+		if got := totpCode(key, totpStep(time.Unix(tc.unix, 0)), 8); got != tc.want {
+			t.Errorf("T=%d: got %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestMatchTOTP_Skew(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	previous := totpCode(key, totpStep(now)-1, totpDigits)
+
+	if step, ok := matchTOTP(key, previous, now, 1); !ok || step != totpStep(now)-1 {
+		t.Fatalf("previous step should match with skew 1, got %d %v", step, ok)
+	}
+	if _, ok := matchTOTP(key, totpCode(key, totpStep(now)-2, totpDigits), now, 1); ok {
+		t.Fatal("code two steps old should not match with skew 1")
+	}
+}
+
+func TestTOTPSecret_RoundTripAndProvisioningURI(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decodeTOTPSecret(strings.ToLower(secret[:8]) + " " + secret[8:])
+	if err != nil || len(key) != totpSecretBytes {
+		t.Fatalf("decodeTOTPSecret: %d bytes, %v", len(key), err)
+	}
+
+	uri, err := url.Parse(TOTPProvisioningURI("Detectviz", "alice@example.com", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Detectviz:alice@example.com" {
+		t.Fatalf("unexpected provisioning URI: %s", uri)
+	}
+	if q := uri.Query(); q.Get("secret") != secret || q.Get("issuer") != "Detectviz" || q.Get("digits") != "6" {
+		t.Fatalf("unexpected provisioning parameters: %v", q)
+	}
+}
+
+func TestSecretCipher(t *testing.T) {
+	if _, err := newSecretCipher([]byte("too-short")); err == nil {
+		t.Fatal("short master key should be rejected")
+	}
+	c, err := newSecretCipher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.seal("JBSWY3DPEHPK3PXP", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "JBSWY3DPEHPK3PXP") || !strings.HasPrefix(sealed, sealedSecretVersion) {
+		t.Fatalf("unexpected sealed secret: %s", sealed)
+	}
+	if plain, err := c.open(sealed, "user-1"); err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("open = %q, %v", plain, err)
+	}
+	if _, err := c.open(sealed, "user-2"); err == nil {
+		t.Fatal("secret sealed for another user should not open")
+	}
+
+	other, err := newSecretCipher([]byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.open(sealed, "user-1"); err == nil {
+		t.Fatal("secret should not open with a different master key")
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// defaultSweepInterval 是寫入時順帶清理過期條目的最小間隔
+const defaultSweepInterval = time.Minute
+
+// memoryEntry 是緩存中的一個值
+type memoryEntry struct {
+	value     interface{}
+	expiresAt time.Time // 零值表示不過期
+}
+
+// MemoryCacheProvider 實現了 pkg/platform/contracts.CacheProvider 介面。
+// 職責: 在進程內存中保存帶過期時間的鍵值，只適合單實例部署與測試；過期條目在讀取時忽略，並在寫入時定期清理。
+type MemoryCacheProvider struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	logger    contracts.Logger
+	now       func() time.Time
+}
+
+// NewMemoryCacheProvider 創建內存緩存
+func NewMemoryCacheProvider(logger contracts.Logger) *MemoryCacheProvider {
+	return &MemoryCacheProvider{
+		entries: make(map[string]memoryEntry),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Set 保存值，expiration 為 0 表示不過期
+func (p *MemoryCacheProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	entry := memoryEntry{value: value}
+	if expiration > 0 {
+		entry.expiresAt = now.Add(expiration)
+	}
+	p.entries[key] = entry
+
+	if now.Sub(p.lastSweep) >= defaultSweepInterval {
+		p.lastSweep = now
+		for k, e := range p.entries {
+			if e.expired(now) {
+				delete(p.entries, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Get 返回值，不存在或已過期時返回 nil
+func (p *MemoryCacheProvider) Get(ctx context.Context, key string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if entry.expired(p.now()) {
+		delete(p.entries, key)
+		return nil, nil
+	}
+	return entry.value, nil
+}
+
+// Delete 刪除值，不存在時不報錯
+func (p *MemoryCacheProvider) Delete(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, key)
+	return nil
+}
+
+// Increment 將整數值加上 delta 並返回新值，鍵不存在或已過期時從 0 開始
+func (p *MemoryCacheProvider) Increment(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var current int64
+	if entry, ok := p.entries[key]; ok && !entry.expired(now) {
+		value, ok := entry.value.(int64)
+		if !ok {
+			return 0, fmt.Errorf("cache entry %s holds %T, not a counter", key, entry.value)
+		}
+		current = value
+	}
+	entry := memoryEntry{value: current + delta}
+	if expiration > 0 {
+		entry.expiresAt = now.Add(expiration)
+	}
+	p.entries[key] = entry
+	return current + delta, nil
+}
+
+// GetName 返回緩存提供者的名稱
+func (p *MemoryCacheProvider) GetName() string {
+	return "memory_cache"
+}
+
+// expired 判斷條目在 now 時是否已過期
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// 確保實現了 CacheProvider 介面
+var _ contracts.CacheProvider = (*MemoryCacheProvider)(nil)
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func TestMemoryCacheProvider_Expiration(t *testing.T) {
+	ctx := context.Background()
+	provider := NewMemoryCacheProvider(&testLogger{})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	if err := provider.Set(ctx, "short", "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Set(ctx, "forever", "b", 0); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := provider.Get(ctx, "short"); value != "a" {
+		t.Fatalf("expected cached value, got %v", value)
+	}
+
+	now = now.Add(time.Minute)
+	if value, _ := provider.Get(ctx, "short"); value != nil {
+		t.Fatalf("expired value should not be returned, got %v", value)
+	}
+	if value, _ := provider.Get(ctx, "forever"); value != "b" {
+		t.Fatalf("value without expiration should be kept, got %v", value)
+	}
+
+	// 寫入時清理過期條目
+	if err := provider.Set(ctx, "expiring", "c", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := provider.Set(ctx, "other", "d", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := provider.entries["expiring"]; ok {
+		t.Fatal("expired entries should be swept on write")
+	}
+
+	if err := provider.Delete(ctx, "forever"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := provider.Get(ctx, "forever"); value != nil {
+		t.Fatalf("deleted value should not be returned, got %v", value)
+	}
+}
+
+func TestMemoryCacheProvider_Increment(t *testing.T) {
+	ctx := context.Background()
+	provider := NewMemoryCacheProvider(&testLogger{})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	for want := int64(1); want <= 3; want++ {
+		if got, err := provider.Increment(ctx, "counter", 1, time.Minute); err != nil || got != want {
+			t.Fatalf("expected %d, got %d, %v", want, got, err)
+		}
+	}
+	if got, err := provider.Increment(ctx, "counter", -1, time.Minute); err != nil || got != 2 {
+		t.Fatalf("expected 2 after decrement, got %d, %v", got, err)
+	}
+	if value, _ := provider.Get(ctx, "counter"); value != int64(2) {
+		t.Fatalf("expected counter value 2, got %v", value)
+	}
+
+	// 過期的計數從 0 開始
+	now = now.Add(time.Minute)
+	if got, err := provider.Increment(ctx, "counter", 1, time.Minute); err != nil || got != 1 {
+		t.Fatalf("expired counter should restart, got %d, %v", got, err)
+	}
+
+	if err := provider.Set(ctx, "text", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Increment(ctx, "text", 1, 0); err == nil {
+		t.Fatal("incrementing a non-counter value should fail")
+	}
+}
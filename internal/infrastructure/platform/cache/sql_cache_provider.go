@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SQLCacheProvider 實現了 pkg/platform/contracts.CacheProvider 介面。
+// 職責: 在 cache_entries 表中保存帶過期時間的鍵值，供多個實例共享，表由遷移 0021 創建。
+// 值以 JSON 保存，讀取時解碼為 interface{}，數字會變為 float64；需要精確往返的調用方應保存字串。
+type SQLCacheProvider struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// SQLCacheConfig 定義 SQL 緩存的配置
+type SQLCacheConfig struct {
+	Dialect string `yaml:"dialect" json:"dialect"` // SQL 方言，例如 "mysql", "postgres"
+}
+
+// NewSQLCacheProvider 創建 SQL 緩存
+func NewSQLCacheProvider(db *sql.DB, config SQLCacheConfig, logger contracts.Logger) *SQLCacheProvider {
+	if config.Dialect == "" {
+		config.Dialect = database.DialectMySQL
+	}
+	return &SQLCacheProvider{
+		db:      db,
+		dialect: config.Dialect,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// exec 執行寫入語句，語句以 ? 佔位符書寫
+func (p *SQLCacheProvider) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return database.ExecutorFromContext(ctx, p.db).ExecContext(ctx, database.Rebind(p.dialect, query), args...)
+}
+
+// Set 保存值，expiration 為 0 表示不過期；同一鍵的舊值被替換
+func (p *SQLCacheProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	now := p.now().UTC()
+	var expiresAt interface{}
+	if expiration > 0 {
+		expiresAt = now.Add(expiration)
+	}
+	if _, err := p.exec(ctx, `DELETE FROM cache_entries WHERE cache_key = ?`, key); err != nil {
+		return fmt.Errorf("failed to replace cache entry: %w", err)
+	}
+	if _, err := p.exec(ctx, `INSERT INTO cache_entries (cache_key, value, expires_at, updated_at) VALUES (?, ?, ?, ?)`,
+		key, string(encoded), expiresAt, now); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	p.sweep(ctx, now)
+	return nil
+}
+
+// Get 返回值，不存在或已過期時返回 nil
+func (p *SQLCacheProvider) Get(ctx context.Context, key string) (interface{}, error) {
+	var encoded string
+	err := database.ExecutorFromContext(ctx, p.db).QueryRowContext(ctx,
+		database.Rebind(p.dialect, `SELECT value FROM cache_entries WHERE cache_key = ? AND (expires_at IS NULL OR expires_at > ?)`),
+		key, p.now().UTC()).Scan(&encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(encoded), &value); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Delete 刪除值，不存在時不報錯
+func (p *SQLCacheProvider) Delete(ctx context.Context, key string) error {
+	if _, err := p.exec(ctx, `DELETE FROM cache_entries WHERE cache_key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete cache entry: %w", err)
+	}
+	return nil
+}
+
+// Increment 將整數值加上 delta 並返回新值，鍵不存在或已過期時從 0 開始。
+// 以單條 upsert 在數據庫中完成加法，並在同一事務中讀回新值，多個實例併發調用時不會丟失更新。
+func (p *SQLCacheProvider) Increment(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	now := p.now().UTC()
+	var expiresAt interface{}
+	if expiration > 0 {
+		expiresAt = now.Add(expiration)
+	}
+	integer, text := "SIGNED", "CHAR"
+	if p.dialect == database.DialectPostgres {
+		integer, text = "BIGINT", "TEXT"
+	}
+	upsert := `INSERT INTO cache_entries (cache_key, value, expires_at, updated_at) VALUES (?, ?, ?, ?) ` +
+		database.OnConflictPrefix(p.dialect, "cache_key") +
+		`value = CASE WHEN cache_entries.expires_at IS NOT NULL AND cache_entries.expires_at <= ? THEN ` + database.Excluded(p.dialect, "value") +
+		` ELSE CAST(CAST(cache_entries.value AS ` + integer + `) + ? AS ` + text + `) END, ` +
+		database.ConflictAssignments(p.dialect, "expires_at", "updated_at")
+
+	var encoded string
+	err := database.NewSQLTransactionManager(p.db, nil, p.logger).RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := p.exec(ctx, upsert, key, strconv.FormatInt(delta, 10), expiresAt, now, now, delta); err != nil {
+			return fmt.Errorf("failed to increment cache entry: %w", err)
+		}
+		return database.ExecutorFromContext(ctx, p.db).QueryRowContext(ctx,
+			database.Rebind(p.dialect, `SELECT value FROM cache_entries WHERE cache_key = ?`), key).Scan(&encoded)
+	})
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(encoded, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cache entry %s is not a counter: %w", key, err)
+	}
+	p.sweep(ctx, now)
+	return value, nil
+}
+
+// GetName 返回緩存提供者的名稱
+func (p *SQLCacheProvider) GetName() string {
+	return "sql_cache"
+}
+
+// sweep 每隔 defaultSweepInterval 在寫入後刪除過期條目，失敗只記錄日誌
+func (p *SQLCacheProvider) sweep(ctx context.Context, now time.Time) {
+	p.mu.Lock()
+	if now.Sub(p.lastSweep) < defaultSweepInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSweep = now
+	p.mu.Unlock()
+
+	result, err := p.exec(ctx, `DELETE FROM cache_entries WHERE expires_at IS NOT NULL AND expires_at <= ?`, now)
+	if err != nil {
+		p.logger.Warn("清理過期緩存失敗", "error", err)
+		return
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		p.logger.Debug("已清理過期緩存", "count", n)
+	}
+}
+
+// 確保實現了 CacheProvider 介面
+var _ contracts.CacheProvider = (*SQLCacheProvider)(nil)
@@ -0,0 +1,49 @@
+package cli_server
+
+import (
+	"github.com/spf13/cobra"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// CobraCliServerProvider 實現了 pkg/platform/contracts.CliServerProvider 介面。
+// 職責: 提供基於 Cobra 的命令行入口，讓各模組以子命令形式掛載管理功能。
+type CobraCliServerProvider struct {
+	root   *cobra.Command
+	logger contracts.Logger
+}
+
+// NewCobraCliServerProvider 創建新的 Cobra CLI 服務提供者
+func NewCobraCliServerProvider(use, short string, logger contracts.Logger) contracts.CliServerProvider {
+	root := &cobra.Command{
+		Use:           use,
+		Short:         short,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+	}
+
+	return &CobraCliServerProvider{
+		root:   root,
+		logger: logger,
+	}
+}
+
+// Execute 開始執行 CLI 應用
+func (c *CobraCliServerProvider) Execute() error {
+	return c.root.Execute()
+}
+
+// AddCommand 將一個新的命令添加到 CLI 應用中
+func (c *CobraCliServerProvider) AddCommand(cmd *cobra.Command) {
+	c.root.AddCommand(cmd)
+}
+
+// RootCommand 返回根命令，用於註冊全域旗標
+func (c *CobraCliServerProvider) RootCommand() *cobra.Command {
+	return c.root
+}
+
+// GetName 返回 CLI 服務提供者的名稱
+func (c *CobraCliServerProvider) GetName() string {
+	return "cobra_cli_server"
+}
@@ -1,6 +1,9 @@
 package di
 
 import (
+	"fmt"
+	"time"
+
 	"detectviz-platform/internal/adapters/web"
 	"detectviz-platform/internal/infrastructure/platform/config"
 	"detectviz-platform/internal/infrastructure/platform/health"
@@ -8,7 +11,6 @@ import (
 	"detectviz-platform/internal/infrastructure/platform/registry"
 	"detectviz-platform/internal/infrastructure/platform/telemetry"
 	"detectviz-platform/pkg/platform/contracts"
-	"time"
 )
 
 // ServiceConfigurator 負責配置和註冊所有平台服務
@@ -70,10 +72,20 @@ func (sc *ServiceConfigurator) ConfigureServices() error {
 
 	// 註冊 HTTP 服務器工廠
 	err = sc.container.RegisterSingleton((*contracts.HttpServerProvider)(nil), func(configProvider contracts.ConfigProvider, logger contracts.Logger) (contracts.HttpServerProvider, error) {
+		// trustedProxies 是列表，透過 Unmarshal 讀取
+		var serverSection struct {
+			Server struct {
+				TrustedProxies []string
+			}
+		}
+		if err := configProvider.Unmarshal(&serverSection); err != nil {
+			return nil, fmt.Errorf("failed to decode server config: %w", err)
+		}
 		httpServerConfig := map[string]interface{}{
-			"port":         configProvider.GetInt("server.port"),
-			"readTimeout":  configProvider.GetString("server.readTimeout"),
-			"writeTimeout": configProvider.GetString("server.writeTimeout"),
+			"port":           configProvider.GetInt("server.port"),
+			"readTimeout":    configProvider.GetString("server.readTimeout"),
+			"writeTimeout":   configProvider.GetString("server.writeTimeout"),
+			"trustedProxies": serverSection.Server.TrustedProxies,
 		}
 		return http_server.NewEchoHttpServerProvider(httpServerConfig, logger)
 	})
@@ -70,7 +70,7 @@ func (hm *HealthCheckManager) Start(ctx context.Context) error {
 	hm.ticker = time.NewTicker(hm.checkInterval)
 	hm.logger.Info("Starting health check manager", "interval", hm.checkInterval)
 
-	go hm.runHealthChecks(ctx)
+	go hm.runHealthChecks(ctx, hm.ticker, hm.stopChan)
 
 	return nil
 }
@@ -94,16 +94,16 @@ func (hm *HealthCheckManager) Stop(ctx context.Context) error {
 	return nil
 }
 
-// runHealthChecks 執行健康檢查循環
-func (hm *HealthCheckManager) runHealthChecks(ctx context.Context) {
+// runHealthChecks 執行健康檢查循環；ticker 與 stopChan 由 Start 傳入，Stop 替換欄位時不影響正在運行的循環
+func (hm *HealthCheckManager) runHealthChecks(ctx context.Context, ticker *time.Ticker, stopChan <-chan struct{}) {
 	// 立即執行一次健康檢查
 	hm.performHealthChecks(ctx)
 
 	for {
 		select {
-		case <-hm.ticker.C:
+		case <-ticker.C:
 			hm.performHealthChecks(ctx)
-		case <-hm.stopChan:
+		case <-stopChan:
 			hm.logger.Info("Health check loop stopped")
 			return
 		case <-ctx.Done():
@@ -176,8 +176,12 @@ func (hm *HealthCheckManager) GetOverallHealthStatus() plugins.HealthCheckResult
 	hm.mu.RLock()
 	defer hm.mu.RUnlock()
 
+	// 沒有插件貢獻檢查時服務本身視為健康，已註冊但尚未完成首次檢查時狀態未知
+	if len(hm.plugins) == 0 {
+		return plugins.NewHealthyResult("No plugins registered")
+	}
 	if len(hm.results) == 0 {
-		return plugins.DefaultHealthCheckResult(plugins.HealthStatusUnknown, "No plugins registered")
+		return plugins.DefaultHealthCheckResult(plugins.HealthStatusUnknown, "Health checks pending")
 	}
 
 	healthyCount := 0
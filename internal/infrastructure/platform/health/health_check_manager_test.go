@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type stubHealthPlugin struct {
+	status plugins.HealthStatus
+}
+
+func (p *stubHealthPlugin) GetName() string                                            { return "stub" }
+func (p *stubHealthPlugin) Init(ctx context.Context, cfg map[string]interface{}) error { return nil }
+func (p *stubHealthPlugin) Start(ctx context.Context) error                            { return nil }
+func (p *stubHealthPlugin) Stop(ctx context.Context) error                             { return nil }
+func (p *stubHealthPlugin) GetHealthCheckInterval() time.Duration                      { return time.Minute }
+func (p *stubHealthPlugin) IsHealthy(ctx context.Context) bool {
+	return p.status == plugins.HealthStatusHealthy
+}
+func (p *stubHealthPlugin) HealthCheck(ctx context.Context) plugins.HealthCheckResult {
+	return plugins.DefaultHealthCheckResult(p.status, "stub")
+}
+
+// 沒有組件貢獻檢查時 /health 應報告健康，而不是未知
+func TestHealthCheckManager_NoPluginsIsHealthy(t *testing.T) {
+	manager := NewHealthCheckManager(&testLogger{}, time.Minute)
+	if status := manager.GetOverallHealthStatus().Status; status != plugins.HealthStatusHealthy {
+		t.Fatalf("expected healthy without plugins, got %s", status)
+	}
+}
+
+func TestHealthCheckManager_PluginContributesCheck(t *testing.T) {
+	manager := NewHealthCheckManager(&testLogger{}, time.Minute)
+	manager.RegisterPlugin("detectionScheduler", &stubHealthPlugin{status: plugins.HealthStatusUnhealthy})
+	if status := manager.GetOverallHealthStatus().Status; status != plugins.HealthStatusUnknown {
+		t.Fatalf("expected unknown before first check, got %s", status)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := manager.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Stop(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.GetOverallHealthStatus().Status != plugins.HealthStatusUnhealthy {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected unhealthy after check, got %s", manager.GetOverallHealthStatus().Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
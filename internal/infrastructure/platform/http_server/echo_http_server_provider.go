@@ -3,7 +3,9 @@ package http_server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -26,6 +28,9 @@ type HttpServerConfig struct {
 	Port         string `yaml:"port" json:"port"`
 	ReadTimeout  string `yaml:"readTimeout" json:"readTimeout"`
 	WriteTimeout string `yaml:"writeTimeout" json:"writeTimeout"`
+	// TrustedProxies 是可信反向代理的 IP 或 CIDR。為空時客戶端 IP 取自 TCP 連線，忽略 X-Forwarded-For 與 X-Real-IP；
+	// 設置時只從這些代理轉發的 X-Forwarded-For 中取客戶端 IP，防止客戶端偽造標頭繞過按 IP 的限制。
+	TrustedProxies []string `yaml:"trustedProxies" json:"trustedProxies"`
 }
 
 // NewEchoHttpServerProvider 構造函數，根據配置創建 Echo HTTP 服務器實例。
@@ -49,9 +54,24 @@ func NewEchoHttpServerProvider(config map[string]interface{}, logger contracts.L
 	if writeTimeout, ok := config["writeTimeout"].(string); ok {
 		serverConfig.WriteTimeout = writeTimeout
 	}
+	switch proxies := config["trustedProxies"].(type) {
+	case []string:
+		serverConfig.TrustedProxies = proxies
+	case []interface{}:
+		for _, proxy := range proxies {
+			if value, ok := proxy.(string); ok {
+				serverConfig.TrustedProxies = append(serverConfig.TrustedProxies, value)
+			}
+		}
+	}
+	ipExtractor, err := newIPExtractor(serverConfig.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
-	// 創建 Echo 實例
+	// 創建 Echo 實例；c.RealIP() 依 IPExtractor 取得客戶端 IP，登錄限流等按 IP 的邏輯依賴它
 	e := echo.New()
+	e.IPExtractor = ipExtractor
 
 	// 設置中介層
 	e.Use(middleware.Logger())
@@ -85,6 +105,35 @@ func NewEchoHttpServerProvider(config map[string]interface{}, logger contracts.L
 	}, nil
 }
 
+// newIPExtractor 根據可信代理創建客戶端 IP 的提取方式。
+// 未配置可信代理時直接使用連線的遠端地址；配置後只信任這些範圍 (不含預設信任的回環與私有網段) 轉發的 X-Forwarded-For。
+func newIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			if ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
 func (h *EchoHttpServerProvider) GetName() string {
 	return "echo_http_server"
 }
@@ -0,0 +1,51 @@
+package http_server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIPExtractor(t *testing.T) {
+	request := func(remoteAddr, xff string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		req.Header.Set("X-Real-IP", "198.51.100.99")
+		return req
+	}
+
+	// 未配置可信代理時忽略客戶端可偽造的標頭
+	direct, err := newIPExtractor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip := direct(request("203.0.113.7:4321", "198.51.100.1")); ip != "203.0.113.7" {
+		t.Errorf("direct extractor = %s, want the connection address", ip)
+	}
+
+	proxied, err := newIPExtractor([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, remoteAddr, xff, want string
+	}{
+		{"from trusted proxy", "10.1.2.3:80", "198.51.100.1", "198.51.100.1"},
+		{"single trusted address", "192.0.2.10:80", "198.51.100.1", "198.51.100.1"},
+		{"spoofed hop before proxy", "10.1.2.3:80", "198.51.100.66, 203.0.113.7", "203.0.113.7"},
+		{"untrusted peer", "203.0.113.7:80", "198.51.100.1", "203.0.113.7"},
+		{"loopback is not trusted by default", "127.0.0.1:80", "198.51.100.1", "127.0.0.1"},
+	}
+	for _, tc := range cases {
+		if ip := proxied(request(tc.remoteAddr, tc.xff)); ip != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, ip, tc.want)
+		}
+	}
+
+	if _, err := newIPExtractor([]string{"not-an-ip"}); err == nil {
+		t.Error("invalid trusted proxy should be rejected")
+	}
+}
@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// Event 是從發件箱投遞到事件總線的事件信封
+type Event struct {
+	ID        string          `json:"id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SQLOutboxProvider 實現了 pkg/platform/contracts.OutboxProvider 介面。
+// 職責: 將事件寫入 outbox_events 表，並以背景中繼按寫入順序投遞到 EventBusProvider。
+// 投遞語義為至少一次 (at-least-once)，訂閱者應以 Event.ID 去重。
+type SQLOutboxProvider struct {
+	db        *sql.DB
+	config    SQLOutboxConfig
+	bus       contracts.EventBusProvider
+	txManager contracts.TransactionManager
+	logger    contracts.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// SQLOutboxConfig 定義發件箱的配置
+type SQLOutboxConfig struct {
+	Dialect      string `yaml:"dialect" json:"dialect"`           // SQL 方言，例如 "mysql", "postgres"
+	PollInterval string `yaml:"pollInterval" json:"pollInterval"` // 中繼輪詢間隔，預設 "2s"
+	BatchSize    int    `yaml:"batchSize" json:"batchSize"`       // 每次投遞的最大事件數，預設 100
+}
+
+// NewSQLOutboxProvider 創建新的發件箱提供者，bus 為 nil 時只寫入不投遞
+func NewSQLOutboxProvider(db *sql.DB, config SQLOutboxConfig, bus contracts.EventBusProvider, logger contracts.Logger) (*SQLOutboxProvider, error) {
+	if config.Dialect == "" {
+		config.Dialect = database.DialectMySQL
+	}
+	if config.PollInterval == "" {
+		config.PollInterval = "2s"
+	}
+	if _, err := time.ParseDuration(config.PollInterval); err != nil {
+		return nil, fmt.Errorf("invalid outbox pollInterval %q: %w", config.PollInterval, err)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	return &SQLOutboxProvider{
+		db:        db,
+		config:    config,
+		bus:       bus,
+		txManager: database.NewSQLTransactionManager(db, nil, logger),
+		logger:    logger,
+		now:       time.Now,
+	}, nil
+}
+
+// Enqueue 將事件寫入發件箱，payload 以 JSON 形式保存
+func (p *SQLOutboxProvider) Enqueue(ctx context.Context, topic string, payload interface{}) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload for topic %s: %w", topic, err)
+	}
+
+	query := database.Rebind(p.config.Dialect,
+		`INSERT INTO outbox_events (id, topic, payload, created_at, attempts) VALUES (?, ?, ?, ?, 0)`)
+	_, err = database.ExecutorFromContext(ctx, p.db).ExecContext(ctx, query,
+		uuid.New().String(), topic, string(encoded), p.now().UTC())
+	if err != nil {
+		p.logger.Error("寫入發件箱失敗", "topic", topic, "error", err)
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+	return nil
+}
+
+// DispatchPending 投遞一批尚未發布的事件，返回成功投遞的數量。
+// 事件按寫入順序投遞，遇到失敗即停止本批次以保持順序，失敗原因記錄在 last_error。
+func (p *SQLOutboxProvider) DispatchPending(ctx context.Context) (int, error) {
+	if p.bus == nil {
+		return 0, fmt.Errorf("outbox has no event bus configured")
+	}
+
+	dispatched := 0
+	err := p.txManager.RunInTx(ctx, func(ctx context.Context) error {
+		events, err := p.lockPending(ctx)
+		if err != nil {
+			return err
+		}
+
+		exec := database.ExecutorFromContext(ctx, p.db)
+		for _, event := range events {
+			if pubErr := p.bus.Publish(ctx, event.Topic, event); pubErr != nil {
+				p.logger.Warn("發件箱事件投遞失敗", "event_id", event.ID, "topic", event.Topic, "error", pubErr)
+				_, err := exec.ExecContext(ctx, database.Rebind(p.config.Dialect,
+					`UPDATE outbox_events SET attempts = attempts + 1, last_error = ? WHERE id = ?`),
+					pubErr.Error(), event.ID)
+				return err
+			}
+
+			if _, err := exec.ExecContext(ctx, database.Rebind(p.config.Dialect,
+				`UPDATE outbox_events SET attempts = attempts + 1, published_at = ?, last_error = NULL WHERE id = ?`),
+				p.now().UTC(), event.ID); err != nil {
+				return fmt.Errorf("failed to mark outbox event %s as published: %w", event.ID, err)
+			}
+			dispatched++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dispatched, nil
+}
+
+// lockPending 鎖定一批待投遞事件，SKIP LOCKED 讓多個實例可以並行中繼而不重複投遞
+func (p *SQLOutboxProvider) lockPending(ctx context.Context) ([]Event, error) {
+	query := database.Rebind(p.config.Dialect, `SELECT id, topic, payload, created_at FROM outbox_events
+		WHERE published_at IS NULL ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED`)
+
+	rows, err := database.ExecutorFromContext(ctx, p.db).QueryContext(ctx, query, p.config.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.Topic, &payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = json.RawMessage(payload)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Start 啟動背景中繼，未配置事件總線時不做任何事
+func (p *SQLOutboxProvider) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.bus == nil {
+		p.logger.Warn("發件箱未配置事件總線，中繼不會啟動")
+		return nil
+	}
+	if p.stopChan != nil {
+		return fmt.Errorf("outbox relay already started")
+	}
+
+	interval, _ := time.ParseDuration(p.config.PollInterval)
+	p.stopChan = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.relay(ctx, interval, p.stopChan, p.done)
+
+	p.logger.Info("發件箱中繼已啟動", "interval", interval, "batch_size", p.config.BatchSize)
+	return nil
+}
+
+// Stop 停止背景中繼並等待當前批次完成
+func (p *SQLOutboxProvider) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	stopChan, done := p.stopChan, p.done
+	p.stopChan, p.done = nil, nil
+	p.mu.Unlock()
+
+	if stopChan == nil {
+		return nil
+	}
+	close(stopChan)
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.logger.Info("發件箱中繼已停止")
+	return nil
+}
+
+// relay 定期投遞待發布事件；批次已滿時立即繼續以消化積壓
+func (p *SQLOutboxProvider) relay(ctx context.Context, interval time.Duration, stopChan, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for {
+				n, err := p.DispatchPending(ctx)
+				if err != nil {
+					p.logger.Error("發件箱中繼失敗", "error", err)
+					break
+				}
+				if n < p.config.BatchSize {
+					break
+				}
+			}
+		case <-stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetName 返回發件箱提供者的名稱
+func (p *SQLOutboxProvider) GetName() string {
+	return "sql_outbox"
+}
+
+// 確保實現了 OutboxProvider 介面
+var _ contracts.OutboxProvider = (*SQLOutboxProvider)(nil)
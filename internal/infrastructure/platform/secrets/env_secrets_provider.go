@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ErrSecretNotFound 表示環境變數與秘密目錄中都找不到指定的秘密
+var ErrSecretNotFound = errors.New("secret not found")
+
+// EnvSecretsConfig 定義環境變數秘密提供者的配置
+type EnvSecretsConfig struct {
+	EnvPrefix string `yaml:"envPrefix" json:"envPrefix"` // 環境變數前綴，例如 DETECTVIZ_SECRET_
+	Directory string `yaml:"directory" json:"directory"` // 可選的秘密文件目錄 (例如 Kubernetes 掛載的 /var/run/secrets/detectviz)
+}
+
+// EnvSecretsProvider 實現 pkg/platform/contracts.SecretsProvider 介面。
+// 職責: 先從環境變數讀取秘密，鍵名轉為大寫並將非字母數字字符替換為底線後加上前綴
+// (例如 slack/ops-webhook → DETECTVIZ_SECRET_SLACK_OPS_WEBHOOK)；找不到時再讀取秘密目錄下與鍵同名的文件。
+type EnvSecretsProvider struct {
+	config EnvSecretsConfig
+	lookup func(string) (string, bool)
+	logger contracts.Logger
+}
+
+// NewEnvSecretsProvider 創建新的環境變數秘密提供者實例
+func NewEnvSecretsProvider(config EnvSecretsConfig, logger contracts.Logger) *EnvSecretsProvider {
+	return &EnvSecretsProvider{
+		config: config,
+		lookup: os.LookupEnv,
+		logger: logger,
+	}
+}
+
+// GetName 返回秘密管理提供者的名稱
+func (p *EnvSecretsProvider) GetName() string {
+	return "env_secrets"
+}
+
+// GetSecret 根據鍵名讀取秘密，文件內容去除首尾空白
+func (p *EnvSecretsProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("secret key is required")
+	}
+	if value, ok := p.lookup(EnvVarName(p.config.EnvPrefix, key)); ok {
+		return value, nil
+	}
+	if p.config.Directory == "" {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+	}
+
+	// 只允許單層文件名，避免鍵名逃逸出秘密目錄
+	if key != filepath.Base(key) || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid secret key for directory lookup: %q", key)
+	}
+	content, err := os.ReadFile(filepath.Join(p.config.Directory, key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+		}
+		p.logger.Warn("讀取秘密文件失敗", "key", key, "error", err)
+		return "", fmt.Errorf("failed to read secret %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// EnvVarName 返回鍵名對應的環境變數名
+func EnvVarName(prefix, key string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// 確保實現了 SecretsProvider 介面
+var _ contracts.SecretsProvider = (*EnvSecretsProvider)(nil)
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func TestEnvVarName(t *testing.T) {
+	if got := EnvVarName("DETECTVIZ_SECRET_", "slack/ops-webhook"); got != "DETECTVIZ_SECRET_SLACK_OPS_WEBHOOK" {
+		t.Errorf("EnvVarName() = %q", got)
+	}
+}
+
+func TestEnvSecretsProvider_EnvThenDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "teams-webhook"), []byte("https://teams.example.com/hook\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewEnvSecretsProvider(EnvSecretsConfig{EnvPrefix: "DV_", Directory: dir}, &testLogger{})
+	env := map[string]string{"DV_SLACK_WEBHOOK": "https://hooks.slack.com/x", "DV_TEAMS_WEBHOOK": "from-env"}
+	p.lookup = func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	ctx := context.Background()
+
+	if v, err := p.GetSecret(ctx, "slack.webhook"); err != nil || v != "https://hooks.slack.com/x" {
+		t.Errorf("GetSecret(slack.webhook) = %q, %v", v, err)
+	}
+	// 環境變數優先於文件
+	if v, err := p.GetSecret(ctx, "teams-webhook"); err != nil || v != "from-env" {
+		t.Errorf("GetSecret(teams-webhook) = %q, %v", v, err)
+	}
+	delete(env, "DV_TEAMS_WEBHOOK")
+	if v, err := p.GetSecret(ctx, "teams-webhook"); err != nil || v != "https://teams.example.com/hook" {
+		t.Errorf("GetSecret(teams-webhook) from file = %q, %v", v, err)
+	}
+	if _, err := p.GetSecret(ctx, "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret(missing) error = %v, want ErrSecretNotFound", err)
+	}
+	if _, err := p.GetSecret(ctx, "../etc/passwd"); err == nil || errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret(traversal) error = %v, want invalid key", err)
+	}
+}
@@ -0,0 +1,206 @@
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// 卡片的顯示狀態
+const (
+	cardStatusFiring   = "firing"
+	cardStatusResolved = "resolved"
+)
+
+// defaultMaxFields 是未指定 data_fields 時卡片最多展示的 Data 欄位數
+const defaultMaxFields = 10
+
+// severityLevel 是嚴重程度的歸一化級別，各聊天平台按級別選擇顏色
+type severityLevel int
+
+const (
+	severityInfo severityLevel = iota
+	severityWarning
+	severityCritical
+	severityResolved
+)
+
+// alertCard 是聊天類告警插件共用的卡片內容，由分析結果與告警通知信息生成，
+// 再由各插件渲染為 Slack Block Kit 或 Teams Adaptive Card
+type alertCard struct {
+	Status      string
+	Level       severityLevel
+	Title       string
+	Summary     string
+	Severity    string
+	DetectorID  string
+	Fingerprint string
+	Receiver    string
+	Repeat      bool
+	StartsAt    time.Time
+	ResolvedAt  time.Time
+	Fields      []cardField
+	Link        string
+	AckLink     string // 確認事件單的簽名鏈接，由告警管理器在 firing 通知中提供
+}
+
+// cardField 是卡片中的一個鍵值對
+type cardField struct {
+	Name  string
+	Value string
+}
+
+// cardOptions 是渲染卡片時使用的渠道設定
+type cardOptions struct {
+	uiURL      string   // detectviz UI 的外部地址，留空則不生成鏈接
+	dataFields []string // 指定展示的 Data 欄位及順序
+	maxFields  int
+}
+
+// parseCardOptions 讀取 ui_url、data_fields 與 max_fields 設定，ui_url 未設置時使用插件默認值
+func parseCardOptions(cfg map[string]interface{}, defaultUIURL string) (cardOptions, error) {
+	options := cardOptions{uiURL: defaultUIURL, maxFields: defaultMaxFields}
+	if v, ok := cfg["ui_url"].(string); ok && v != "" {
+		options.uiURL = v
+	}
+	options.dataFields = stringListSetting(cfg, "data_fields")
+	var err error
+	if options.maxFields, err = intSetting(cfg, "max_fields", defaultMaxFields); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+// buildAlertCard 生成卡片內容；未經告警管理器直接調用時按分析結果是否異常決定狀態
+func buildAlertCard(result *entities.AnalysisResult, notification *entities.AlertNotification, options cardOptions) *alertCard {
+	payload := buildWebhookPayload(result, notification)
+	card := &alertCard{
+		Status:      cardStatusFiring,
+		Summary:     payload.Summary,
+		Severity:    payload.Severity,
+		DetectorID:  payload.DetectorID,
+		Fingerprint: payload.Fingerprint,
+		Receiver:    payload.Receiver,
+		Repeat:      payload.Repeat,
+	}
+	if payload.Status == entities.AlertStateResolved || payload.Status == "normal" {
+		card.Status = cardStatusResolved
+	}
+	if payload.StartsAt != nil {
+		card.StartsAt = *payload.StartsAt
+	}
+	if payload.ResolvedAt != nil {
+		card.ResolvedAt = *payload.ResolvedAt
+	}
+
+	card.Level = classifySeverity(card.Severity)
+	if card.Status == cardStatusResolved {
+		card.Level = severityResolved
+	}
+	severity := card.Severity
+	if severity == "" {
+		severity = "unknown"
+	}
+	card.Title = fmt.Sprintf("[%s] %s (%s)", strings.ToUpper(card.Status), card.DetectorID, severity)
+	card.Fields = dataFields(payload.Data, options)
+	card.Link = alertLink(options.uiURL, card.DetectorID, card.Fingerprint)
+	if card.Status == cardStatusFiring {
+		card.AckLink = payload.AcknowledgeURL
+	}
+	return card
+}
+
+// classifySeverity 將自由文本的嚴重程度歸類，無法識別時視為 info
+func classifySeverity(severity string) severityLevel {
+	switch strings.ToLower(severity) {
+	case "critical", "fatal", "high", "error", "page":
+		return severityCritical
+	case "warning", "warn", "medium", "major":
+		return severityWarning
+	default:
+		return severityInfo
+	}
+}
+
+// dataFields 選出要展示的 Data 值：指定 data_fields 時按其順序，否則按鍵名排序取前 max_fields 個標量值
+func dataFields(data map[string]interface{}, options cardOptions) []cardField {
+	keys := options.dataFields
+	if len(keys) == 0 {
+		for key, value := range data {
+			if _, ok := formatValue(value); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+	}
+
+	fields := make([]cardField, 0, len(keys))
+	for _, key := range keys {
+		if options.maxFields > 0 && len(fields) >= options.maxFields {
+			break
+		}
+		value, ok := formatValue(data[key])
+		if !ok {
+			continue
+		}
+		fields = append(fields, cardField{Name: key, Value: value})
+	}
+	return fields
+}
+
+// formatValue 格式化標量值，嵌套的映射與切片不展示
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'g', 6, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', 6, 32), true
+	case int, int32, int64, uint, uint32, uint64, bool:
+		return fmt.Sprintf("%v", v), true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339), true
+	case fmt.Stringer:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// alertLink 生成返回 detectviz UI 的鏈接: {ui_url}/detectors/{detector_id}，有告警指紋時附帶 alert 查詢參數
+func alertLink(uiURL, detectorID, fingerprint string) string {
+	if uiURL == "" || detectorID == "" {
+		return ""
+	}
+	link := strings.TrimRight(uiURL, "/") + "/detectors/" + url.PathEscape(detectorID)
+	if fingerprint != "" {
+		link += "?alert=" + url.QueryEscape(fingerprint)
+	}
+	return link
+}
+
+// stringListSetting 讀取字符串列表設定
+func stringListSetting(cfg map[string]interface{}, key string) []string {
+	switch v := cfg[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
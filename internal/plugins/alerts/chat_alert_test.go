@@ -0,0 +1,223 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+)
+
+// chatServer 記錄收到的請求，並以 Slack Web API 的格式響應
+type chatServer struct {
+	mu       sync.Mutex
+	requests []chatRequest
+	nextTS   int
+}
+
+type chatRequest struct {
+	Path          string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+func (c *chatServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	raw, _ := io.ReadAll(r.Body)
+	var body map[string]interface{}
+	_ = json.Unmarshal(raw, &body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, chatRequest{Path: r.URL.Path, Authorization: r.Header.Get("Authorization"), Body: body})
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		c.nextTS++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C123", "ts": fmt.Sprintf("1700000000.%06d", c.nextTS)})
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func resolvedConfig(cfg map[string]interface{}) (*entities.AnalysisResult, map[string]interface{}) {
+	result, base := firingResult()
+	notification := *base[plugins.AlertConfigKeyNotification].(*entities.AlertNotification)
+	notification.Alert.State = entities.AlertStateResolved
+	notification.Alert.ResolvedAt = time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	out := map[string]interface{}{plugins.AlertConfigKeyNotification: &notification}
+	for k, v := range cfg {
+		out[k] = v
+	}
+	return result, out
+}
+
+func TestSlackAlert_WebhookBlockKit(t *testing.T) {
+	chat := &chatServer{}
+	server := httptest.NewServer(chat)
+	defer server.Close()
+
+	s := NewSlackAlertPlugin(mapSecrets{"slack/ops": server.URL + "/services/T0/B0/XYZ"}, &testLogger{})
+	if err := s.Init(context.Background(), map[string]interface{}{"ui_url": "https://detectviz.example.com/"}); err != nil {
+		t.Fatal(err)
+	}
+	result, cfg := firingResult()
+	cfg["webhook_url_secret"] = "slack/ops"
+
+	if err := s.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("TriggerAlert() error = %v", err)
+	}
+	if len(chat.requests) != 1 || chat.requests[0].Path != "/services/T0/B0/XYZ" {
+		t.Fatalf("unexpected requests: %+v", chat.requests)
+	}
+	encoded, _ := json.Marshal(chat.requests[0].Body)
+	for _, want := range []string{`"color":"#E01E5A"`, `[FIRING] cpu (critical)`, `cpu above threshold`, `*value*\n97.5`,
+		`https://detectviz.example.com/detectors/cpu?alert=abc123`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("message missing %s:\n%s", want, encoded)
+		}
+	}
+
+	// 地址不能來自明文配置
+	_, plain := firingResult()
+	plain["webhook_url"] = server.URL
+	if err := s.TriggerAlert(context.Background(), result, plain); err == nil {
+		t.Error("expected error without webhook_url_secret")
+	}
+}
+
+func TestSlackAlert_ThreadsResolvedIntoFiringMessage(t *testing.T) {
+	chat := &chatServer{}
+	server := httptest.NewServer(chat)
+	defer server.Close()
+
+	s := NewSlackAlertPlugin(mapSecrets{"slack/token": "xoxb-test"}, &testLogger{})
+	if err := s.Init(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	settings := map[string]interface{}{"token_secret": "slack/token", "channel": "#ops", "api_url": server.URL + "/api"}
+
+	result, cfg := firingResult()
+	for k, v := range settings {
+		cfg[k] = v
+	}
+	if err := s.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("firing TriggerAlert() error = %v", err)
+	}
+	result, cfg = resolvedConfig(settings)
+	if err := s.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("resolved TriggerAlert() error = %v", err)
+	}
+
+	if len(chat.requests) != 3 {
+		t.Fatalf("expected post, threaded reply and update, got %d requests", len(chat.requests))
+	}
+	first, reply, update := chat.requests[0], chat.requests[1], chat.requests[2]
+	if first.Path != "/api/chat.postMessage" || first.Authorization != "Bearer xoxb-test" || first.Body["thread_ts"] != nil {
+		t.Errorf("unexpected first request: %+v", first)
+	}
+	if reply.Path != "/api/chat.postMessage" || reply.Body["thread_ts"] != "1700000000.000001" || reply.Body["channel"] != "C123" {
+		t.Errorf("resolved notification not threaded: %+v", reply)
+	}
+	if update.Path != "/api/chat.update" || update.Body["ts"] != "1700000000.000001" {
+		t.Errorf("unexpected update request: %+v", update)
+	}
+	attachment := reply.Body["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["color"] != slackColors[severityResolved] {
+		t.Errorf("resolved color = %v", attachment["color"])
+	}
+
+	// 恢復後線程記錄被清除，新的 firing 開始新線程
+	result, cfg = firingResult()
+	for k, v := range settings {
+		cfg[k] = v
+	}
+	if err := s.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if last := chat.requests[len(chat.requests)-1]; last.Body["thread_ts"] != nil {
+		t.Errorf("expected a new thread after resolve, got %+v", last.Body)
+	}
+}
+
+func TestSlackAlert_APIErrorIsPermanent(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	}))
+	defer server.Close()
+
+	s := NewSlackAlertPlugin(mapSecrets{"slack/token": "xoxb-test"}, &testLogger{})
+	if err := s.Init(context.Background(), map[string]interface{}{"initial_interval": "1ms"}); err != nil {
+		t.Fatal(err)
+	}
+	result, cfg := firingResult()
+	cfg["token_secret"] = "slack/token"
+	cfg["channel"] = "#missing"
+	cfg["api_url"] = server.URL
+
+	err := s.TriggerAlert(context.Background(), result, cfg)
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("expected channel_not_found error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestTeamsAlert_AdaptiveCard(t *testing.T) {
+	chat := &chatServer{}
+	server := httptest.NewServer(chat)
+	defer server.Close()
+
+	tp := NewTeamsAlertPlugin(mapSecrets{"teams/ops": server.URL + "/webhookb2/abc"}, &testLogger{})
+	if err := tp.Init(context.Background(), map[string]interface{}{"ui_url": "https://detectviz.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	result, cfg := resolvedConfig(map[string]interface{}{"webhook_url_secret": "teams/ops", "data_fields": []interface{}{"value", "missing"}})
+	if err := tp.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("TriggerAlert() error = %v", err)
+	}
+
+	if len(chat.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(chat.requests))
+	}
+	body := chat.requests[0].Body
+	attachment := body["attachments"].([]interface{})[0].(map[string]interface{})
+	if body["type"] != "message" || attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+	content := attachment["content"].(map[string]interface{})
+	items := content["body"].([]interface{})
+	header := items[0].(map[string]interface{})
+	if header["style"] != "good" {
+		t.Errorf("resolved header style = %v", header["style"])
+	}
+	facts := items[2].(map[string]interface{})["facts"].([]interface{})
+	if len(facts) != 1 || facts[0].(map[string]interface{})["value"] != "97.5" {
+		t.Errorf("unexpected facts: %+v", facts)
+	}
+	action := content["actions"].([]interface{})[0].(map[string]interface{})
+	if action["url"] != "https://detectviz.example.com/detectors/cpu?alert=abc123" {
+		t.Errorf("unexpected action: %+v", action)
+	}
+
+	if err := tp.TriggerAlert(context.Background(), result, map[string]interface{}{}); err == nil {
+		t.Error("expected error without webhook_url_secret")
+	}
+}
+
+func TestClassifySeverity(t *testing.T) {
+	cases := map[string]severityLevel{"critical": severityCritical, "HIGH": severityCritical, "warning": severityWarning, "info": severityInfo, "": severityInfo}
+	for severity, want := range cases {
+		if got := classifySeverity(severity); got != want {
+			t.Errorf("classifySeverity(%q) = %v, want %v", severity, got, want)
+		}
+	}
+}
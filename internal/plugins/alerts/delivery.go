@@ -0,0 +1,361 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DeliveryConfig 定義 HTTP 告警插件的發送與重試參數
+type DeliveryConfig struct {
+	Timeout         time.Duration // 單次請求超時，默認 10s
+	MaxAttempts     int           // 包含第一次在內的最大嘗試次數，默認 5
+	InitialInterval time.Duration // 第一次重試前的等待時間，默認 500ms，之後指數增長
+	MaxInterval     time.Duration // 兩次重試之間的最大等待時間，默認 30s
+	DeadLetterPath  string        // 永久失敗的請求以 JSON Lines 追加到此文件，留空則只記錄錯誤日誌
+}
+
+// parseDeliveryConfig 從插件 Init 配置解析發送參數，未設置的欄位使用 defaults
+func parseDeliveryConfig(cfg map[string]interface{}, defaults DeliveryConfig) (DeliveryConfig, error) {
+	config := defaults
+	var err error
+	if config.Timeout, err = durationSetting(cfg, "timeout", config.Timeout); err != nil {
+		return config, err
+	}
+	if config.InitialInterval, err = durationSetting(cfg, "initial_interval", config.InitialInterval); err != nil {
+		return config, err
+	}
+	if config.MaxInterval, err = durationSetting(cfg, "max_interval", config.MaxInterval); err != nil {
+		return config, err
+	}
+	if config.MaxAttempts, err = intSetting(cfg, "max_attempts", config.MaxAttempts); err != nil {
+		return config, err
+	}
+	if config.MaxAttempts < 1 {
+		return config, fmt.Errorf("max_attempts 必須至少為 1")
+	}
+	if path, ok := cfg["dead_letter_path"].(string); ok {
+		config.DeadLetterPath = path
+	}
+	return config, nil
+}
+
+// defaultDeliveryConfig 返回默認的發送參數
+func defaultDeliveryConfig() DeliveryConfig {
+	return DeliveryConfig{
+		Timeout:         10 * time.Second,
+		MaxAttempts:     5,
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     30 * time.Second,
+	}
+}
+
+// DeadLetter 是一條永久失敗的發送記錄
+type DeadLetter struct {
+	Time        time.Time         `json:"time"`
+	Plugin      string            `json:"plugin"`
+	Target      string            `json:"target"` // 去除查詢參數的目標地址，避免記錄嵌在 URL 中的令牌
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Attempts    int               `json:"attempts"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Error       string            `json:"error"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+}
+
+// deadLetterLog 以 JSON Lines 追加寫入永久失敗的請求
+type deadLetterLog struct {
+	mu     sync.Mutex
+	path   string
+	logger contracts.Logger
+}
+
+// write 追加一條記錄；未配置路徑或寫入失敗時記錄到錯誤日誌
+func (d *deadLetterLog) write(entry DeadLetter) {
+	d.logger.Error("告警發送永久失敗", "plugin", entry.Plugin, "target", entry.Target, "fingerprint", entry.Fingerprint,
+		"attempts", entry.Attempts, "status_code", entry.StatusCode, "error", entry.Error)
+	if d.path == "" {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		d.logger.Error("編碼死信記錄失敗", "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		d.logger.Error("打開死信文件失敗", "path", d.path, "error", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		d.logger.Error("寫入死信文件失敗", "path", d.path, "error", err)
+	}
+}
+
+// httpDelivery 是一次 HTTP 告警請求
+type httpDelivery struct {
+	Method      string
+	URL         string
+	Headers     map[string]string
+	Body        []byte
+	Fingerprint string
+	// SecretURL 表示 URL 本身是秘密 (例如 Slack、Teams 的 incoming webhook)，日誌與死信只記錄協議與主機
+	SecretURL bool
+	// Sign 在每次嘗試前調用，返回需要附加的簽名標頭，使時間戳在重試時保持新鮮
+	Sign func(body []byte) map[string]string
+	// Decode 在 2xx 響應時以響應體調用，用於解析返回值或識別以 200 返回的業務錯誤 (例如 Slack Web API 的 ok=false)；
+	// 返回 backoff.Permanent 包裝的錯誤表示不再重試，其他錯誤按暫時性失敗重試
+	Decode func(body []byte) error
+}
+
+// maxResponseBytes 是 Decode 讀取的響應體上限
+const maxResponseBytes = 1 << 20
+
+// deliverer 以指數退避重試 HTTP 請求，重試耗盡或遇到不可重試的響應時寫入死信
+type deliverer struct {
+	plugin     string
+	client     *http.Client
+	config     DeliveryConfig
+	deadLetter *deadLetterLog
+	logger     contracts.Logger
+}
+
+// newDeliverer 創建發送器
+func newDeliverer(plugin string, config DeliveryConfig, logger contracts.Logger) *deliverer {
+	return &deliverer{
+		plugin:     plugin,
+		client:     &http.Client{Timeout: config.Timeout},
+		config:     config,
+		deadLetter: &deadLetterLog{path: config.DeadLetterPath, logger: logger},
+		logger:     logger,
+	}
+}
+
+// deliveryError 描述一次失敗的嘗試
+type deliveryError struct {
+	statusCode int
+	err        error
+}
+
+func (e *deliveryError) Error() string {
+	if e.statusCode != 0 {
+		return fmt.Sprintf("unexpected status %d: %v", e.statusCode, e.err)
+	}
+	return e.err.Error()
+}
+
+func (e *deliveryError) Unwrap() error { return e.err }
+
+// send 發送請求：網絡錯誤、5xx 與 429 會重試 (429 遵循 Retry-After)，其他 4xx 視為永久失敗
+func (d *deliverer) send(ctx context.Context, delivery httpDelivery) error {
+	method := delivery.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = d.config.InitialInterval
+	b.MaxInterval = d.config.MaxInterval
+
+	attempts := 0
+	_, err := backoff.Retry(ctx, func() (struct{}, error) {
+		attempts++
+		req, err := http.NewRequestWithContext(ctx, method, delivery.URL, bytes.NewReader(delivery.Body))
+		if err != nil {
+			return struct{}{}, backoff.Permanent(&deliveryError{err: err})
+		}
+		for k, v := range delivery.Headers {
+			req.Header.Set(k, v)
+		}
+		if delivery.Sign != nil {
+			for k, v := range delivery.Sign(delivery.Body) {
+				req.Header.Set(k, v)
+			}
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			// url.Error 的消息包含完整地址，替換為去除憑證的版本
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = fmt.Errorf("%s %s: %w", urlErr.Op, redactURL(urlErr.URL, delivery.SecretURL), urlErr.Err)
+			}
+			return struct{}{}, &deliveryError{err: err}
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if delivery.Decode == nil {
+				return struct{}{}, nil
+			}
+			content, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+			if err != nil {
+				return struct{}{}, &deliveryError{err: err}
+			}
+			if err := delivery.Decode(content); err != nil {
+				var permanent *backoff.PermanentError
+				if errors.As(err, &permanent) {
+					return struct{}{}, backoff.Permanent(&deliveryError{statusCode: resp.StatusCode, err: permanent.Err})
+				}
+				return struct{}{}, &deliveryError{statusCode: resp.StatusCode, err: err}
+			}
+			return struct{}{}, nil
+		}
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		failure := &deliveryError{statusCode: resp.StatusCode, err: errors.New(string(bytes.TrimSpace(snippet)))}
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				d.logger.Warn("告警接收端限流，按 Retry-After 重試", "plugin", d.plugin, "retry_after", seconds)
+				return struct{}{}, backoff.RetryAfter(seconds)
+			}
+			return struct{}{}, failure
+		case resp.StatusCode >= 500:
+			return struct{}{}, failure
+		default:
+			return struct{}{}, backoff.Permanent(failure)
+		}
+	},
+		backoff.WithBackOff(b),
+		backoff.WithMaxTries(uint(d.config.MaxAttempts)),
+		backoff.WithMaxElapsedTime(0),
+		backoff.WithNotify(func(err error, next time.Duration) {
+			d.logger.Warn("告警發送失敗，準備重試", "plugin", d.plugin, "fingerprint", delivery.Fingerprint,
+				"error", err, "retry_in", next)
+		}),
+	)
+	if err == nil {
+		return nil
+	}
+
+	entry := DeadLetter{
+		Time:        time.Now().UTC(),
+		Plugin:      d.plugin,
+		Target:      redactURL(delivery.URL, delivery.SecretURL),
+		Fingerprint: delivery.Fingerprint,
+		Attempts:    attempts,
+		Error:       err.Error(),
+		Headers:     redactHeaders(delivery.Headers),
+		Body:        string(delivery.Body),
+	}
+	var failure *deliveryError
+	if errors.As(err, &failure) {
+		entry.StatusCode = failure.statusCode
+	}
+	d.deadLetter.write(entry)
+	return fmt.Errorf("%s delivery failed after %d attempts: %w", d.plugin, attempts, err)
+}
+
+// redactURL 去除 URL 的查詢參數與使用者資訊，hidePath 為 true 時同時去除路徑
+func redactURL(raw string, hidePath bool) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "invalid-url"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	if hidePath && u.Path != "" {
+		u.Path = "/***"
+		u.RawPath = ""
+	}
+	return u.String()
+}
+
+// sensitiveHeaders 是寫入死信前需要遮蔽的標頭
+var sensitiveHeaders = map[string]bool{"authorization": true, "proxy-authorization": true, "x-api-key": true}
+
+// redactHeaders 返回遮蔽了憑證的標頭副本
+func redactHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if sensitiveHeaders[strings.ToLower(k)] {
+			v = "***"
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// durationSetting 讀取時間長度設定，支持字符串與 time.Duration
+func durationSetting(cfg map[string]interface{}, key string, def time.Duration) (time.Duration, error) {
+	switch v := cfg[key].(type) {
+	case nil:
+		return def, nil
+	case time.Duration:
+		return v, nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("無效的 %s: %q", key, v)
+		}
+		return d, nil
+	default:
+		return 0, fmt.Errorf("無效的 %s: %v", key, v)
+	}
+}
+
+// intSetting 讀取整數設定，支持 YAML/JSON 解碼後的各種數值類型
+func intSetting(cfg map[string]interface{}, key string, def int) (int, error) {
+	switch v := cfg[key].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("無效的 %s: %q", key, v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("無效的 %s: %v", key, v)
+	}
+}
+
+// stringMapSetting 讀取字符串映射設定，例如自定義標頭
+func stringMapSetting(cfg map[string]interface{}, key string) map[string]string {
+	out := make(map[string]string)
+	switch v := cfg[key].(type) {
+	case map[string]string:
+		for k, val := range v {
+			out[k] = val
+		}
+	case map[string]interface{}:
+		for k, val := range v {
+			out[k] = fmt.Sprintf("%v", val)
+		}
+	}
+	return out
+}
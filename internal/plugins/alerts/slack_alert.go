@@ -0,0 +1,385 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DefaultSlackAPIURL 是 Slack Web API 的默認地址，Slack 兼容的服務可通過 api_url 覆蓋
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// defaultSlackThreadTTL 是 firing 消息的線程記錄保留時間，超過後新的通知不再回覆到舊線程
+const defaultSlackThreadTTL = 7 * 24 * time.Hour
+
+// Slack 附件的顏色
+var slackColors = map[severityLevel]string{
+	severityInfo:     "#36C5F0",
+	severityWarning:  "#ECB22E",
+	severityCritical: "#E01E5A",
+	severityResolved: "#2EB67D",
+}
+
+// SlackAlertPlugin 實現以 Slack Block Kit 消息發送告警的 AlertPlugin
+// 職責: 將分析結果渲染為帶嚴重程度顏色的附件 (摘要、Data 關鍵值、返回 detectviz UI 的按鈕)。
+// 默認通過 incoming webhook 發送，webhook 地址只能來自 SecretsProvider；incoming webhook 不返回消息時間戳，
+// 因此無法串成線程。設定 token_secret 與 channel 時改用 chat.postMessage，同一告警後續的重複與恢復通知
+// 會回覆到第一條 firing 消息的線程中，恢復時並把原消息更新為恢復狀態。線程記錄只保存在內存中。
+type SlackAlertPlugin struct {
+	name      string
+	secrets   contracts.SecretsProvider
+	logger    contracts.Logger
+	delivery  *deliverer
+	uiURL     string
+	threadTTL time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	threads map[string]slackThread // channel + 指紋 → 線程
+}
+
+// slackThread 是一條 firing 消息的位置
+type slackThread struct {
+	channel   string
+	ts        string
+	createdAt time.Time
+}
+
+// slackSettings 是單次發送使用的渠道設定
+type slackSettings struct {
+	webhookURL     string
+	token          string
+	apiURL         string
+	channel        string
+	username       string
+	iconEmoji      string
+	replyBroadcast bool
+	card           cardOptions
+}
+
+// slackMessage 是 incoming webhook 與 chat.postMessage 共用的請求體
+type slackMessage struct {
+	Channel        string            `json:"channel,omitempty"`
+	TS             string            `json:"ts,omitempty"` // 只用於 chat.update
+	ThreadTS       string            `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool              `json:"reply_broadcast,omitempty"`
+	Username       string            `json:"username,omitempty"`
+	IconEmoji      string            `json:"icon_emoji,omitempty"`
+	Text           string            `json:"text"`
+	Attachments    []slackAttachment `json:"attachments"`
+}
+
+// slackAttachment 以附件承載 Block Kit 區塊，使消息左側顯示嚴重程度顏色
+type slackAttachment struct {
+	Color    string                   `json:"color"`
+	Fallback string                   `json:"fallback"`
+	Blocks   []map[string]interface{} `json:"blocks"`
+}
+
+// slackAPIResponse 是 Slack Web API 的響應
+type slackAPIResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// NewSlackAlertPlugin 創建新的 Slack 告警插件實例
+func NewSlackAlertPlugin(secrets contracts.SecretsProvider, logger contracts.Logger) *SlackAlertPlugin {
+	return &SlackAlertPlugin{
+		name:      "slack_alert",
+		secrets:   secrets,
+		logger:    logger,
+		delivery:  newDeliverer("slack_alert", defaultDeliveryConfig(), logger),
+		threadTTL: defaultSlackThreadTTL,
+		now:       time.Now,
+		threads:   make(map[string]slackThread),
+	}
+}
+
+// GetName 返回插件名稱
+func (s *SlackAlertPlugin) GetName() string {
+	return s.name
+}
+
+// Init 解析發送參數與 ui_url (detectviz UI 的外部地址)、thread_ttl
+func (s *SlackAlertPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	config, err := parseDeliveryConfig(cfg, defaultDeliveryConfig())
+	if err != nil {
+		return fmt.Errorf("解析 slack 配置失敗: %w", err)
+	}
+	if s.threadTTL, err = durationSetting(cfg, "thread_ttl", defaultSlackThreadTTL); err != nil {
+		return fmt.Errorf("解析 slack 配置失敗: %w", err)
+	}
+	s.uiURL, _ = cfg["ui_url"].(string)
+	s.delivery = newDeliverer(s.name, config, s.logger)
+	return nil
+}
+
+// Start 啟動插件
+func (s *SlackAlertPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 停止插件
+func (s *SlackAlertPlugin) Stop(ctx context.Context) error {
+	return nil
+}
+
+// TriggerAlert 渲染並發送 Slack 消息。
+// 支持的設定: webhook_url_secret，或 token_secret 與 channel (使用 Web API 並串成線程)；
+// api_url、username、icon_emoji、reply_broadcast、ui_url、data_fields、max_fields。
+func (s *SlackAlertPlugin) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error {
+	settings, err := s.parseSettings(ctx, alertConfig)
+	if err != nil {
+		return err
+	}
+	card := buildAlertCard(result, plugins.AlertNotificationFromConfig(alertConfig), settings.card)
+	message := buildSlackMessage(card)
+	message.Username = settings.username
+	message.IconEmoji = settings.iconEmoji
+
+	if settings.token == "" {
+		message.Channel = settings.channel
+		return s.post(ctx, settings.webhookURL, "", message, card.Fingerprint, nil)
+	}
+
+	message.Channel = settings.channel
+	key := settings.channel + "/" + card.Fingerprint
+	thread, threaded := s.thread(key)
+	if threaded {
+		message.ThreadTS = thread.ts
+		message.Channel = thread.channel
+		message.ReplyBroadcast = settings.replyBroadcast && card.Status == cardStatusResolved
+	}
+
+	var response slackAPIResponse
+	if err := s.post(ctx, settings.apiURL+"/chat.postMessage", settings.token, message, card.Fingerprint, &response); err != nil {
+		return err
+	}
+
+	switch {
+	case card.Fingerprint == "":
+		// 未經告警管理器的調用沒有指紋，無法關聯線程
+	case card.Status == cardStatusResolved && threaded:
+		s.forget(key)
+		s.updateParent(ctx, settings, thread, card)
+	case card.Status == cardStatusFiring && !threaded && response.TS != "":
+		channel := response.Channel
+		if channel == "" {
+			channel = settings.channel
+		}
+		s.remember(key, slackThread{channel: channel, ts: response.TS, createdAt: s.now()})
+	}
+	return nil
+}
+
+// updateParent 把線程的第一條消息更新為恢復狀態，失敗只記錄警告
+func (s *SlackAlertPlugin) updateParent(ctx context.Context, settings *slackSettings, thread slackThread, card *alertCard) {
+	update := buildSlackMessage(card)
+	update.Channel = thread.channel
+	update.TS = thread.ts
+	if err := s.post(ctx, settings.apiURL+"/chat.update", settings.token, update, card.Fingerprint, &slackAPIResponse{}); err != nil {
+		s.logger.Warn("更新 Slack 告警消息失敗", "plugin", s.name, "fingerprint", card.Fingerprint, "error", err)
+	}
+}
+
+// post 發送消息；token 非空時以 Bearer 令牌調用 Web API 並解析響應到 response
+func (s *SlackAlertPlugin) post(ctx context.Context, target, token string, message *slackMessage, fingerprint string, response *slackAPIResponse) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+	delivery := httpDelivery{
+		URL:         target,
+		Headers:     map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		Body:        body,
+		Fingerprint: fingerprint,
+		SecretURL:   token == "",
+	}
+	if token != "" {
+		delivery.Headers["Authorization"] = "Bearer " + token
+	}
+	if response != nil {
+		delivery.Decode = func(content []byte) error {
+			return decodeSlackResponse(content, response)
+		}
+	}
+	return s.delivery.send(ctx, delivery)
+}
+
+// decodeSlackResponse 解析 Web API 響應；ratelimited 按暫時性失敗重試，其他 ok=false 為永久失敗
+func decodeSlackResponse(content []byte, response *slackAPIResponse) error {
+	if err := json.Unmarshal(content, response); err != nil {
+		return backoff.Permanent(fmt.Errorf("invalid slack api response: %w", err))
+	}
+	if response.OK {
+		return nil
+	}
+	err := errors.New("slack api error: " + response.Error)
+	if response.Error == "ratelimited" {
+		return err
+	}
+	return backoff.Permanent(err)
+}
+
+// thread 返回未過期的線程記錄
+func (s *SlackAlertPlugin) thread(key string) (slackThread, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	thread, ok := s.threads[key]
+	if ok && s.threadTTL > 0 && s.now().Sub(thread.createdAt) > s.threadTTL {
+		delete(s.threads, key)
+		return slackThread{}, false
+	}
+	return thread, ok
+}
+
+// remember 記錄 firing 消息的位置，同時清理過期記錄
+func (s *SlackAlertPlugin) remember(key string, thread slackThread) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, t := range s.threads {
+		if s.threadTTL > 0 && s.now().Sub(t.createdAt) > s.threadTTL {
+			delete(s.threads, k)
+		}
+	}
+	s.threads[key] = thread
+}
+
+// forget 刪除已恢復告警的線程記錄
+func (s *SlackAlertPlugin) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.threads, key)
+}
+
+// parseSettings 解析渠道設定；webhook 地址與令牌只從 SecretsProvider 讀取
+func (s *SlackAlertPlugin) parseSettings(ctx context.Context, cfg map[string]interface{}) (*slackSettings, error) {
+	settings := &slackSettings{apiURL: DefaultSlackAPIURL}
+	var err error
+	if settings.token, err = resolveSecret(ctx, s.secrets, cfg, "token_secret"); err != nil {
+		return nil, err
+	}
+	if settings.token == "" {
+		if settings.webhookURL, err = resolveSecret(ctx, s.secrets, cfg, "webhook_url_secret"); err != nil {
+			return nil, err
+		}
+		if settings.webhookURL == "" {
+			return nil, fmt.Errorf("slack webhook_url_secret or token_secret is required")
+		}
+	}
+	settings.channel, _ = cfg["channel"].(string)
+	if settings.token != "" && settings.channel == "" {
+		return nil, fmt.Errorf("slack channel is required when token_secret is set")
+	}
+	if v, ok := cfg["api_url"].(string); ok && v != "" {
+		settings.apiURL = strings.TrimRight(v, "/")
+	}
+	settings.username, _ = cfg["username"].(string)
+	settings.iconEmoji, _ = cfg["icon_emoji"].(string)
+	settings.replyBroadcast, _ = cfg["reply_broadcast"].(bool)
+	if settings.card, err = parseCardOptions(cfg, s.uiURL); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// buildSlackMessage 將卡片渲染為 Block Kit 附件
+func buildSlackMessage(card *alertCard) *slackMessage {
+	headline := "*" + slackEscape(card.Title) + "*"
+	if card.Summary != "" {
+		headline += "\n" + slackEscape(card.Summary)
+	}
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": slackText(headline)},
+	}
+
+	if len(card.Fields) > 0 {
+		fields := make([]map[string]interface{}, 0, len(card.Fields))
+		for _, field := range card.Fields {
+			// 單個 section 最多 10 個欄位
+			if len(fields) == 10 {
+				break
+			}
+			fields = append(fields, slackText("*"+slackEscape(field.Name)+"*\n"+slackEscape(field.Value)))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+
+	var notes []string
+	if card.Fingerprint != "" {
+		notes = append(notes, "Fingerprint `"+card.Fingerprint+"`")
+	}
+	if !card.StartsAt.IsZero() {
+		notes = append(notes, "Started "+card.StartsAt.UTC().Format(time.RFC3339))
+	}
+	if !card.ResolvedAt.IsZero() {
+		notes = append(notes, "Resolved "+card.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+	if card.Repeat {
+		notes = append(notes, "Repeated notification")
+	}
+	if len(notes) > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]interface{}{slackText(strings.Join(notes, " · "))},
+		})
+	}
+
+	var buttons []map[string]interface{}
+	if card.Link != "" {
+		buttons = append(buttons, map[string]interface{}{
+			"type": "button",
+			"text": map[string]interface{}{"type": "plain_text", "text": "View in detectviz"},
+			"url":  card.Link,
+		})
+	}
+	if card.AckLink != "" {
+		buttons = append(buttons, map[string]interface{}{
+			"type":  "button",
+			"text":  map[string]interface{}{"type": "plain_text", "text": "Acknowledge"},
+			"style": "primary",
+			"url":   card.AckLink,
+		})
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons})
+	}
+
+	fallback := card.Title
+	if card.Summary != "" {
+		fallback += ": " + card.Summary
+	}
+	return &slackMessage{
+		Text: fallback,
+		Attachments: []slackAttachment{{
+			Color:    slackColors[card.Level],
+			Fallback: fallback,
+			Blocks:   blocks,
+		}},
+	}
+}
+
+// slackText 返回 mrkdwn 文本對象
+func slackText(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "mrkdwn", "text": text}
+}
+
+// slackEscape 轉義 mrkdwn 的控制字符
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// 確保實現了 AlertPlugin 介面
+var _ plugins.AlertPlugin = (*SlackAlertPlugin)(nil)
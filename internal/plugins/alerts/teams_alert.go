@@ -0,0 +1,189 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// Adaptive Card 標題容器的樣式
+var teamsStyles = map[severityLevel]string{
+	severityInfo:     "accent",
+	severityWarning:  "warning",
+	severityCritical: "attention",
+	severityResolved: "good",
+}
+
+// TeamsAlertPlugin 實現以 Microsoft Teams Adaptive Card 發送告警的 AlertPlugin
+// 職責: 將分析結果渲染為標題帶嚴重程度樣式的卡片 (摘要、Data 關鍵值事實表、返回 detectviz UI 的按鈕)，
+// 通過 incoming webhook (或 Workflows 的 webhook 觸發器) 發送，地址只能來自 SecretsProvider。
+// Teams 的 webhook 不支持回覆既有消息，恢復通知以獨立卡片發送，並帶上告警指紋與開始時間以便對照。
+type TeamsAlertPlugin struct {
+	name     string
+	secrets  contracts.SecretsProvider
+	logger   contracts.Logger
+	delivery *deliverer
+	uiURL    string
+}
+
+// teamsMessage 是 Teams webhook 接受的消息信封
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsAttachment 承載一張 Adaptive Card
+type teamsAttachment struct {
+	ContentType string                 `json:"contentType"`
+	Content     map[string]interface{} `json:"content"`
+}
+
+// NewTeamsAlertPlugin 創建新的 Teams 告警插件實例
+func NewTeamsAlertPlugin(secrets contracts.SecretsProvider, logger contracts.Logger) *TeamsAlertPlugin {
+	return &TeamsAlertPlugin{
+		name:     "teams_alert",
+		secrets:  secrets,
+		logger:   logger,
+		delivery: newDeliverer("teams_alert", defaultDeliveryConfig(), logger),
+	}
+}
+
+// GetName 返回插件名稱
+func (t *TeamsAlertPlugin) GetName() string {
+	return t.name
+}
+
+// Init 解析發送參數與 ui_url (detectviz UI 的外部地址)
+func (t *TeamsAlertPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	config, err := parseDeliveryConfig(cfg, defaultDeliveryConfig())
+	if err != nil {
+		return fmt.Errorf("解析 teams 配置失敗: %w", err)
+	}
+	t.uiURL, _ = cfg["ui_url"].(string)
+	t.delivery = newDeliverer(t.name, config, t.logger)
+	return nil
+}
+
+// Start 啟動插件
+func (t *TeamsAlertPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 停止插件
+func (t *TeamsAlertPlugin) Stop(ctx context.Context) error {
+	return nil
+}
+
+// TriggerAlert 渲染並發送 Adaptive Card。
+// 支持的設定: webhook_url_secret (必填)、ui_url、data_fields、max_fields。
+func (t *TeamsAlertPlugin) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error {
+	webhookURL, err := resolveSecret(ctx, t.secrets, alertConfig, "webhook_url_secret")
+	if err != nil {
+		return err
+	}
+	if webhookURL == "" {
+		return fmt.Errorf("teams webhook_url_secret is required")
+	}
+	options, err := parseCardOptions(alertConfig, t.uiURL)
+	if err != nil {
+		return err
+	}
+
+	card := buildAlertCard(result, plugins.AlertNotificationFromConfig(alertConfig), options)
+	body, err := json.Marshal(buildTeamsMessage(card))
+	if err != nil {
+		return fmt.Errorf("failed to encode teams card: %w", err)
+	}
+	return t.delivery.send(ctx, httpDelivery{
+		URL:         webhookURL,
+		Headers:     map[string]string{"Content-Type": "application/json"},
+		Body:        body,
+		Fingerprint: card.Fingerprint,
+		SecretURL:   true,
+	})
+}
+
+// buildTeamsMessage 將卡片渲染為 Adaptive Card 1.4
+func buildTeamsMessage(card *alertCard) *teamsMessage {
+	body := []map[string]interface{}{
+		{
+			"type":  "Container",
+			"style": teamsStyles[card.Level],
+			"bleed": true,
+			"items": []map[string]interface{}{{
+				"type":   "TextBlock",
+				"text":   card.Title,
+				"weight": "Bolder",
+				"size":   "Medium",
+				"wrap":   true,
+			}},
+		},
+	}
+	if card.Summary != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": card.Summary, "wrap": true})
+	}
+	if len(card.Fields) > 0 {
+		facts := make([]map[string]string, 0, len(card.Fields))
+		for _, field := range card.Fields {
+			facts = append(facts, map[string]string{"title": field.Name, "value": field.Value})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+
+	var notes []string
+	if card.Fingerprint != "" {
+		notes = append(notes, "Fingerprint "+card.Fingerprint)
+	}
+	if !card.StartsAt.IsZero() {
+		notes = append(notes, "Started "+card.StartsAt.UTC().Format(time.RFC3339))
+	}
+	if !card.ResolvedAt.IsZero() {
+		notes = append(notes, "Resolved "+card.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+	if card.Repeat {
+		notes = append(notes, "Repeated notification")
+	}
+	if len(notes) > 0 {
+		body = append(body, map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     strings.Join(notes, " · "),
+			"isSubtle": true,
+			"size":     "Small",
+			"wrap":     true,
+		})
+	}
+
+	content := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"msteams": map[string]string{"width": "Full"},
+		"body":    body,
+	}
+	var actions []map[string]string
+	if card.Link != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "View in detectviz", "url": card.Link})
+	}
+	if card.AckLink != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "Acknowledge", "url": card.AckLink})
+	}
+	if len(actions) > 0 {
+		content["actions"] = actions
+	}
+	return &teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     content,
+		}},
+	}
+}
+
+// 確保實現了 AlertPlugin 介面
+var _ plugins.AlertPlugin = (*TeamsAlertPlugin)(nil)
@@ -0,0 +1,295 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// 簽名相關的默認標頭
+const (
+	DefaultSignatureHeader = "X-Detectviz-Signature"
+	DefaultTimestampHeader = "X-Detectviz-Timestamp"
+)
+
+// defaultWebhookTemplate 默認以 JSON 輸出整個模板數據
+const defaultWebhookTemplate = `{{ json . }}`
+
+// WebhookAlertPlugin 實現以 HTTP 回調發送告警的 AlertPlugin
+// 職責: 以 text/template 將分析結果與告警管理器附帶的通知信息渲染為請求體 (默認 JSON)，
+// 附加自定義標頭與 HMAC-SHA256 簽名後 POST 到目標地址；暫時性失敗以指數退避重試，
+// 永久失敗寫入死信日誌。目標地址、模板與簽名密鑰由每個接收者的渠道設定提供，同一實例可服務多個接收者。
+type WebhookAlertPlugin struct {
+	name      string
+	secrets   contracts.SecretsProvider
+	logger    contracts.Logger
+	delivery  *deliverer
+	now       func() time.Time
+	templates sync.Map // 模板原文 → *template.Template
+}
+
+// WebhookPayload 是渲染請求體時的模板數據，默認模板直接輸出其 JSON
+type WebhookPayload struct {
+	Status         string                 `json:"status"` // firing、resolved；未經告警管理器時為 anomalous 或 normal
+	DetectorID     string                 `json:"detector_id"`
+	Severity       string                 `json:"severity"`
+	Summary        string                 `json:"summary"`
+	Labels         map[string]string      `json:"labels"`
+	Data           map[string]interface{} `json:"data"`
+	ResultID       string                 `json:"result_id"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Fingerprint    string                 `json:"fingerprint,omitempty"`
+	StartsAt       *time.Time             `json:"starts_at,omitempty"`
+	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+	Receiver       string                 `json:"receiver,omitempty"`
+	GroupKey       string                 `json:"group_key,omitempty"`
+	GroupLabels    map[string]string      `json:"group_labels,omitempty"`
+	Repeat         bool                   `json:"repeat,omitempty"`
+	IncidentID     string                 `json:"incident_id,omitempty"`
+	AcknowledgeURL string                 `json:"acknowledge_url,omitempty"` // 確認事件單的簽名鏈接，只在 firing 通知中提供
+}
+
+// webhookSettings 是單次發送使用的渠道設定
+type webhookSettings struct {
+	url             string
+	method          string
+	headers         map[string]string
+	template        string
+	contentType     string
+	secret          string
+	signatureHeader string
+	timestampHeader string
+}
+
+// NewWebhookAlertPlugin 創建新的 Webhook 告警插件實例，secrets 可為 nil (此時不能使用 *_secret 設定)
+func NewWebhookAlertPlugin(secrets contracts.SecretsProvider, logger contracts.Logger) *WebhookAlertPlugin {
+	return &WebhookAlertPlugin{
+		name:     "webhook_alert",
+		secrets:  secrets,
+		logger:   logger,
+		delivery: newDeliverer("webhook_alert", defaultDeliveryConfig(), logger),
+		now:      time.Now,
+	}
+}
+
+// GetName 返回插件名稱
+func (w *WebhookAlertPlugin) GetName() string {
+	return w.name
+}
+
+// Init 解析發送參數：timeout、max_attempts、initial_interval、max_interval 與 dead_letter_path
+func (w *WebhookAlertPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	config, err := parseDeliveryConfig(cfg, defaultDeliveryConfig())
+	if err != nil {
+		return fmt.Errorf("解析 webhook 配置失敗: %w", err)
+	}
+	w.delivery = newDeliverer(w.name, config, w.logger)
+	return nil
+}
+
+// Start 啟動插件
+func (w *WebhookAlertPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 停止插件
+func (w *WebhookAlertPlugin) Stop(ctx context.Context) error {
+	return nil
+}
+
+// TriggerAlert 渲染請求體並發送到 alertConfig 指定的地址。
+// 支持的設定: url 或 url_secret、method、headers、template、content_type、
+// secret 或 secret_key (HMAC-SHA256 簽名密鑰)、signature_header、timestamp_header。
+func (w *WebhookAlertPlugin) TriggerAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error {
+	settings, err := w.parseSettings(ctx, alertConfig)
+	if err != nil {
+		return err
+	}
+	payload := buildWebhookPayload(result, plugins.AlertNotificationFromConfig(alertConfig))
+	body, err := w.render(settings.template, payload)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Content-Type": settings.contentType}
+	for k, v := range settings.headers {
+		headers[k] = v
+	}
+	delivery := httpDelivery{
+		Method:      settings.method,
+		URL:         settings.url,
+		Headers:     headers,
+		Body:        body,
+		Fingerprint: payload.Fingerprint,
+	}
+	if settings.secret != "" {
+		delivery.Sign = func(body []byte) map[string]string {
+			timestamp := strconv.FormatInt(w.now().Unix(), 10)
+			return map[string]string{
+				settings.timestampHeader: timestamp,
+				settings.signatureHeader: "sha256=" + SignPayload(settings.secret, timestamp, body),
+			}
+		}
+	}
+	return w.delivery.send(ctx, delivery)
+}
+
+// SignPayload 計算 HMAC-SHA256(secret, timestamp + "." + body) 的十六進制摘要。
+// 接收端應以相同方式計算並以常數時間比較，同時拒絕時間戳過舊的請求以防重放。
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSettings 解析渠道設定，從 SecretsProvider 讀取 *_secret 指向的值
+func (w *WebhookAlertPlugin) parseSettings(ctx context.Context, cfg map[string]interface{}) (*webhookSettings, error) {
+	s := &webhookSettings{
+		method:          "POST",
+		headers:         stringMapSetting(cfg, "headers"),
+		template:        defaultWebhookTemplate,
+		contentType:     "application/json",
+		signatureHeader: DefaultSignatureHeader,
+		timestampHeader: DefaultTimestampHeader,
+	}
+	var err error
+	if s.url, err = resolveSecretSetting(ctx, w.secrets, cfg, "url", "url_secret"); err != nil {
+		return nil, err
+	}
+	if s.url == "" {
+		return nil, fmt.Errorf("webhook url is required")
+	}
+	if s.secret, err = resolveSecretSetting(ctx, w.secrets, cfg, "secret", "secret_key"); err != nil {
+		return nil, err
+	}
+	if v, ok := cfg["method"].(string); ok && v != "" {
+		s.method = strings.ToUpper(v)
+	}
+	if v, ok := cfg["template"].(string); ok && v != "" {
+		s.template = v
+	}
+	if v, ok := cfg["content_type"].(string); ok && v != "" {
+		s.contentType = v
+	}
+	if v, ok := cfg["signature_header"].(string); ok && v != "" {
+		s.signatureHeader = v
+	}
+	if v, ok := cfg["timestamp_header"].(string); ok && v != "" {
+		s.timestampHeader = v
+	}
+	return s, nil
+}
+
+// render 以緩存的模板渲染請求體
+func (w *WebhookAlertPlugin) render(text string, payload *WebhookPayload) ([]byte, error) {
+	var tmpl *template.Template
+	if cached, ok := w.templates.Load(text); ok {
+		tmpl = cached.(*template.Template)
+	} else {
+		parsed, err := template.New("webhook").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+		w.templates.Store(text, parsed)
+		tmpl = parsed
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateFuncs 是模板可使用的輔助函數
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// buildWebhookPayload 合併分析結果與告警通知信息
+func buildWebhookPayload(result *entities.AnalysisResult, notification *entities.AlertNotification) *WebhookPayload {
+	payload := &WebhookPayload{
+		Status:     "normal",
+		DetectorID: result.DetectorID,
+		Severity:   result.Severity,
+		Summary:    result.Summary,
+		Labels:     result.Labels(),
+		Data:       result.Data,
+		ResultID:   result.ID,
+		Timestamp:  result.Timestamp,
+	}
+	if result.IsAnomalous() {
+		payload.Status = "anomalous"
+	}
+	if notification == nil {
+		return payload
+	}
+
+	alert := notification.Alert
+	payload.Status = alert.State
+	payload.Labels = alert.Labels
+	payload.Fingerprint = alert.Fingerprint
+	payload.Receiver = notification.Receiver
+	payload.GroupKey = notification.GroupKey
+	payload.GroupLabels = notification.GroupLabels
+	payload.Repeat = notification.Repeat
+	payload.IncidentID = notification.IncidentID
+	payload.AcknowledgeURL = notification.AcknowledgeURL
+	if !alert.StartsAt.IsZero() {
+		startsAt := alert.StartsAt
+		payload.StartsAt = &startsAt
+	}
+	if !alert.ResolvedAt.IsZero() {
+		resolvedAt := alert.ResolvedAt
+		payload.ResolvedAt = &resolvedAt
+	}
+	return payload
+}
+
+// resolveSecretSetting 優先讀取 secretKey 指向的秘密，否則返回 key 的明文設定
+func resolveSecretSetting(ctx context.Context, secrets contracts.SecretsProvider, cfg map[string]interface{}, key, secretKey string) (string, error) {
+	if name, ok := cfg[secretKey].(string); ok && name != "" {
+		return resolveSecret(ctx, secrets, cfg, secretKey)
+	}
+	value, _ := cfg[key].(string)
+	return value, nil
+}
+
+// resolveSecret 讀取 secretKey 指向的秘密，未設置時返回空字符串
+func resolveSecret(ctx context.Context, secrets contracts.SecretsProvider, cfg map[string]interface{}, secretKey string) (string, error) {
+	name, _ := cfg[secretKey].(string)
+	if name == "" {
+		return "", nil
+	}
+	if secrets == nil {
+		return "", fmt.Errorf("%s is set but no secrets provider is configured", secretKey)
+	}
+	value, err := secrets.GetSecret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// 確保實現了 AlertPlugin 介面
+var _ plugins.AlertPlugin = (*WebhookAlertPlugin)(nil)
@@ -0,0 +1,237 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+type mapSecrets map[string]string
+
+func (s mapSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	if v, ok := s[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("secret not found")
+}
+
+func (s mapSecrets) GetName() string { return "map_secrets" }
+
+func newTestWebhook(t *testing.T, secrets contracts.SecretsProvider, cfg map[string]interface{}) *WebhookAlertPlugin {
+	t.Helper()
+	w := NewWebhookAlertPlugin(secrets, &testLogger{})
+	if cfg == nil {
+		cfg = map[string]interface{}{}
+	}
+	if _, ok := cfg["initial_interval"]; !ok {
+		cfg["initial_interval"] = "1ms"
+	}
+	cfg["max_interval"] = "5ms"
+	if err := w.Init(context.Background(), cfg); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	w.now = func() time.Time { return time.Unix(1767225600, 0) }
+	return w
+}
+
+func firingResult() (*entities.AnalysisResult, map[string]interface{}) {
+	alert := entities.Alert{
+		Fingerprint: "abc123",
+		DetectorID:  "cpu",
+		Labels:      map[string]string{"detector_id": "cpu", "host": "web-1"},
+		State:       entities.AlertStateFiring,
+		Severity:    "critical",
+		Summary:     "cpu above threshold",
+		Data:        map[string]interface{}{"value": 97.5},
+		StartsAt:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		LastSeenAt:  time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC),
+	}
+	notification := &entities.AlertNotification{Receiver: "ops", GroupKey: "root/ops:1", Alert: alert}
+	return alert.AnalysisResult(), map[string]interface{}{plugins.AlertConfigKeyNotification: notification}
+}
+
+func TestWebhookAlert_DefaultJSONAndSignature(t *testing.T) {
+	var (
+		body    []byte
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	w := newTestWebhook(t, mapSecrets{"hooks/ops": "s3cret"}, nil)
+	result, cfg := firingResult()
+	cfg["url"] = server.URL
+	cfg["secret_key"] = "hooks/ops"
+	cfg["headers"] = map[string]interface{}{"X-Team": "ops"}
+
+	if err := w.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("TriggerAlert() error = %v", err)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, body)
+	}
+	if payload.Status != "firing" || payload.Fingerprint != "abc123" || payload.Labels["host"] != "web-1" ||
+		payload.Receiver != "ops" || payload.Data["value"] != 97.5 {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if headers.Get("Content-Type") != "application/json" || headers.Get("X-Team") != "ops" {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+	timestamp := headers.Get(DefaultTimestampHeader)
+	if timestamp != "1767225600" {
+		t.Errorf("timestamp header = %q", timestamp)
+	}
+	if want := "sha256=" + SignPayload("s3cret", timestamp, body); headers.Get(DefaultSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", headers.Get(DefaultSignatureHeader), want)
+	}
+}
+
+func TestWebhookAlert_CustomTemplate(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer server.Close()
+
+	w := newTestWebhook(t, nil, nil)
+	result, cfg := firingResult()
+	cfg["url"] = server.URL
+	cfg["content_type"] = "text/plain"
+	cfg["template"] = `{{ upper .Status }} {{ .Labels.host }}: {{ .Summary }} ({{ index .Data "value" }})`
+
+	if err := w.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("TriggerAlert() error = %v", err)
+	}
+	if body != "FIRING web-1: cpu above threshold (97.5)" {
+		t.Errorf("body = %q", body)
+	}
+
+	cfg["template"] = "{{ .Unclosed"
+	if err := w.TriggerAlert(context.Background(), result, cfg); err == nil {
+		t.Error("expected template parse error")
+	}
+}
+
+func TestWebhookAlert_RetriesAndDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/down":
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("bad payload"))
+		}
+	}))
+	defer server.Close()
+
+	deadLetters := filepath.Join(t.TempDir(), "dead_letters.jsonl")
+	w := newTestWebhook(t, nil, map[string]interface{}{"max_attempts": 4, "dead_letter_path": deadLetters})
+	result, cfg := firingResult()
+
+	// 暫時性失敗重試後成功
+	cfg["url"] = server.URL + "/flaky"
+	if err := w.TriggerAlert(context.Background(), result, cfg); err != nil {
+		t.Fatalf("TriggerAlert() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	// 重試耗盡
+	calls.Store(0)
+	cfg["url"] = server.URL + "/down?token=abc"
+	if err := w.TriggerAlert(context.Background(), result, cfg); err == nil {
+		t.Fatal("expected error after retries are exhausted")
+	}
+	if calls.Load() != 4 {
+		t.Errorf("expected 4 attempts, got %d", calls.Load())
+	}
+
+	// 4xx 不重試
+	calls.Store(0)
+	cfg["url"] = server.URL + "/rejected"
+	if err := w.TriggerAlert(context.Background(), result, cfg); err == nil {
+		t.Fatal("expected error for rejected request")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt for 4xx, got %d", calls.Load())
+	}
+
+	data, err := os.ReadFile(deadLetters)
+	if err != nil {
+		t.Fatalf("read dead letters: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d:\n%s", len(lines), data)
+	}
+	var first, second DeadLetter
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Attempts != 4 || first.StatusCode != http.StatusBadGateway || strings.Contains(first.Target, "token") ||
+		first.Fingerprint != "abc123" || first.Body == "" {
+		t.Errorf("unexpected dead letter: %+v", first)
+	}
+	if second.Attempts != 1 || second.StatusCode != http.StatusBadRequest || !strings.Contains(second.Error, "bad payload") {
+		t.Errorf("unexpected dead letter: %+v", second)
+	}
+}
+
+func TestWebhookAlert_InvalidSettings(t *testing.T) {
+	w := newTestWebhook(t, nil, nil)
+	result, cfg := firingResult()
+	if err := w.TriggerAlert(context.Background(), result, cfg); err == nil {
+		t.Error("expected error without url")
+	}
+	cfg["url_secret"] = "hooks/ops"
+	if err := w.TriggerAlert(context.Background(), result, cfg); err == nil {
+		t.Error("expected error when secrets provider is missing")
+	}
+	if err := NewWebhookAlertPlugin(nil, &testLogger{}).Init(context.Background(), map[string]interface{}{"max_attempts": 0}); err == nil {
+		t.Error("expected Init error for max_attempts 0")
+	}
+}
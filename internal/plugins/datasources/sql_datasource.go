@@ -0,0 +1,222 @@
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// identifierPattern 限制表名與欄位名，避免把排程配置拼接成任意 SQL
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLDataSourcePlugin 實現基於關係型資料庫表的時間窗口數據源
+// 職責: 按時間欄位讀取指定窗口內的記錄，供排程器與回放功能使用
+type SQLDataSourcePlugin struct {
+	name          string
+	dbClient      contracts.DBClientProvider
+	logger        contracts.Logger
+	config        SQLDataSourceConfig
+	isInitialized bool
+}
+
+// SQLDataSourceConfig 定義 SQL 數據源的配置
+type SQLDataSourceConfig struct {
+	Dialect         string   `yaml:"dialect" json:"dialect"`                   // SQL 方言，例如 "mysql", "postgres"
+	TableName       string   `yaml:"table_name" json:"table_name"`             // 數據表名
+	TimestampColumn string   `yaml:"timestamp_column" json:"timestamp_column"` // 時間欄位名，默認為 "timestamp"
+	Columns         []string `yaml:"columns" json:"columns"`                   // 要讀取的欄位，為空時讀取全部
+	MaxRows         int      `yaml:"max_rows" json:"max_rows"`                 // 單個窗口最多返回的記錄數
+}
+
+// NewSQLDataSourcePlugin 創建新的 SQL 數據源插件實例
+func NewSQLDataSourcePlugin(dbClient contracts.DBClientProvider, logger contracts.Logger) plugins.DataSourcePlugin {
+	return &SQLDataSourcePlugin{
+		name:     "sql_datasource_plugin",
+		dbClient: dbClient,
+		logger:   logger,
+		config: SQLDataSourceConfig{
+			Dialect:         database.DialectMySQL,
+			TimestampColumn: "timestamp",
+			MaxRows:         10000,
+		},
+	}
+}
+
+// GetName 返回插件名稱
+func (s *SQLDataSourcePlugin) GetName() string {
+	return s.name
+}
+
+// Init 初始化插件
+func (s *SQLDataSourcePlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	s.logger.Info("正在初始化 SQL 數據源插件", "plugin", s.name)
+
+	if dialect, ok := cfg["dialect"].(string); ok {
+		s.config.Dialect = dialect
+	}
+	if tableName, ok := cfg["table_name"].(string); ok {
+		s.config.TableName = tableName
+	}
+	if timestampColumn, ok := cfg["timestamp_column"].(string); ok {
+		s.config.TimestampColumn = timestampColumn
+	}
+	if columns, ok := cfg["columns"].([]interface{}); ok {
+		s.config.Columns = nil
+		for _, c := range columns {
+			if name, ok := c.(string); ok {
+				s.config.Columns = append(s.config.Columns, name)
+			}
+		}
+	}
+	if maxRows, ok := cfg["max_rows"].(int); ok {
+		s.config.MaxRows = maxRows
+	}
+
+	if err := s.validateConfig(); err != nil {
+		return fmt.Errorf("配置驗證失敗: %w", err)
+	}
+
+	s.isInitialized = true
+	s.logger.Info("SQL 數據源插件初始化完成", "plugin", s.name, "table", s.config.TableName)
+	return nil
+}
+
+// Start 啟動插件
+func (s *SQLDataSourcePlugin) Start(ctx context.Context) error {
+	if !s.isInitialized {
+		return fmt.Errorf("插件尚未初始化")
+	}
+	return nil
+}
+
+// Stop 停止插件
+func (s *SQLDataSourcePlugin) Stop(ctx context.Context) error {
+	s.isInitialized = false
+	return nil
+}
+
+// FetchWindow 返回 (start, end] 範圍內的記錄，按時間欄位升序排列。
+// query 中的 "filters" (map[string]interface{}) 以等值條件追加到 WHERE 子句。
+func (s *SQLDataSourcePlugin) FetchWindow(ctx context.Context, query map[string]interface{}, start, end time.Time) ([]map[string]interface{}, error) {
+	if !s.isInitialized {
+		return nil, fmt.Errorf("插件尚未初始化")
+	}
+
+	statement, args, err := s.buildQuery(query, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.dbClient.GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢數據窗口失敗: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("掃描數據記錄失敗: %w", err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				record[column] = string(b)
+			} else {
+				record[column] = values[i]
+			}
+		}
+		// 統一通過 RecordTimestampField 提供記錄時間，回放依此排序與對齊結果
+		if _, ok := record[plugins.RecordTimestampField]; !ok {
+			if ts, ok := record[s.config.TimestampColumn]; ok {
+				record[plugins.RecordTimestampField] = ts
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("讀取數據窗口完成", "plugin", s.name, "start", start, "end", end, "records", len(records))
+	return records, nil
+}
+
+// buildQuery 根據配置與查詢參數構建窗口查詢語句
+func (s *SQLDataSourcePlugin) buildQuery(query map[string]interface{}, start, end time.Time) (string, []interface{}, error) {
+	columns := "*"
+	if len(s.config.Columns) > 0 {
+		columns = strings.Join(s.config.Columns, ", ")
+	}
+
+	ts := s.config.TimestampColumn
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT %s FROM %s WHERE %s > ? AND %s <= ?", columns, s.config.TableName, ts, ts)
+	args := []interface{}{start.UTC(), end.UTC()}
+
+	if filters, ok := query["filters"].(map[string]interface{}); ok {
+		// 按欄位名排序，使生成的語句穩定
+		names := make([]string, 0, len(filters))
+		for name := range filters {
+			if !identifierPattern.MatchString(name) {
+				return "", nil, fmt.Errorf("無效的過濾欄位名: %s", name)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, " AND %s = ?", name)
+			args = append(args, filters[name])
+		}
+	}
+
+	fmt.Fprintf(&b, " ORDER BY %s", ts)
+	if s.config.MaxRows > 0 {
+		b.WriteString(" LIMIT ?")
+		args = append(args, s.config.MaxRows)
+	}
+
+	return database.Rebind(s.config.Dialect, b.String()), args, nil
+}
+
+// validateConfig 驗證配置
+func (s *SQLDataSourcePlugin) validateConfig() error {
+	if !identifierPattern.MatchString(s.config.TableName) {
+		return fmt.Errorf("無效的表名: %q", s.config.TableName)
+	}
+	if !identifierPattern.MatchString(s.config.TimestampColumn) {
+		return fmt.Errorf("無效的時間欄位名: %q", s.config.TimestampColumn)
+	}
+	for _, column := range s.config.Columns {
+		if !identifierPattern.MatchString(column) {
+			return fmt.Errorf("無效的欄位名: %q", column)
+		}
+	}
+	return nil
+}
+
+// 確保實現了 DataSourcePlugin 介面
+var _ plugins.DataSourcePlugin = (*SQLDataSourcePlugin)(nil)
@@ -0,0 +1,79 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func TestSQLDataSourcePlugin_Init(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid", cfg: map[string]interface{}{"table_name": "metrics"}},
+		{name: "missing table", cfg: map[string]interface{}{}, wantErr: true},
+		{name: "injected table", cfg: map[string]interface{}{"table_name": "metrics; DROP TABLE users"}, wantErr: true},
+		{name: "invalid column", cfg: map[string]interface{}{"table_name": "metrics", "columns": []interface{}{"value", "1=1"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := NewSQLDataSourcePlugin(nil, &testLogger{})
+			err := plugin.Init(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Init() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSQLDataSourcePlugin_BuildQuery(t *testing.T) {
+	plugin := NewSQLDataSourcePlugin(nil, &testLogger{}).(*SQLDataSourcePlugin)
+	if err := plugin.Init(context.Background(), map[string]interface{}{
+		"dialect":          "postgres",
+		"table_name":       "metrics",
+		"timestamp_column": "ts",
+		"columns":          []interface{}{"ts", "host", "value"},
+		"max_rows":         500,
+	}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(5 * time.Minute)
+	query, args, err := plugin.buildQuery(map[string]interface{}{
+		"filters": map[string]interface{}{"region": "tw", "host": "web-1"},
+	}, start, end)
+	if err != nil {
+		t.Fatalf("buildQuery() error = %v", err)
+	}
+
+	want := "SELECT ts, host, value FROM metrics WHERE ts > $1 AND ts <= $2 AND host = $3 AND region = $4 ORDER BY ts LIMIT $5"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if len(args) != 5 || args[2] != "web-1" || args[3] != "tw" || args[4] != 500 {
+		t.Errorf("args = %v", args)
+	}
+
+	if _, _, err := plugin.buildQuery(map[string]interface{}{
+		"filters": map[string]interface{}{"host OR 1=1": "x"},
+	}, start, end); err == nil {
+		t.Error("expected error for invalid filter column")
+	}
+}
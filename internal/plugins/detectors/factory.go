@@ -0,0 +1,78 @@
+package detectors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DetectorConstructor 創建尚未初始化的檢測器實例
+type DetectorConstructor func(logger contracts.Logger, metricsProvider contracts.MetricsProvider) plugins.DetectorPlugin
+
+// DetectorFactory 按插件名稱創建獨立的檢測器實例
+// 職責: 為每次回放提供全新的、已初始化並啟動的檢測器，避免有狀態檢測器之間共享狀態
+type DetectorFactory struct {
+	logger          contracts.Logger
+	metricsProvider contracts.MetricsProvider
+
+	mu           sync.RWMutex
+	constructors map[string]DetectorConstructor
+}
+
+// NewDetectorFactory 創建檢測器工廠並註冊內建的檢測器；metricsProvider 可為 nil，
+// 回放時通常傳入 nil，避免模擬執行污染線上指標
+func NewDetectorFactory(logger contracts.Logger, metricsProvider contracts.MetricsProvider) *DetectorFactory {
+	f := &DetectorFactory{
+		logger:          logger,
+		metricsProvider: metricsProvider,
+		constructors:    make(map[string]DetectorConstructor),
+	}
+	f.Register("threshold_detector_plugin", NewThresholdDetectorPlugin)
+	return f
+}
+
+// Register 註冊檢測器構造函數，同名時覆蓋
+func (f *DetectorFactory) Register(pluginName string, constructor DetectorConstructor) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.constructors[pluginName] = constructor
+}
+
+// Plugins 返回已註冊的檢測器插件名稱
+func (f *DetectorFactory) Plugins() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	names := make([]string, 0, len(f.constructors))
+	for name := range f.constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDetector 創建、初始化並啟動一個新的檢測器實例
+func (f *DetectorFactory) NewDetector(ctx context.Context, pluginName string, cfg map[string]interface{}) (plugins.DetectorPlugin, error) {
+	f.mu.RLock()
+	constructor, ok := f.constructors[pluginName]
+	f.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的檢測器插件: %s", pluginName)
+	}
+
+	detector := constructor(f.logger, f.metricsProvider)
+	if err := detector.Init(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("初始化檢測器 %s 失敗: %w", pluginName, err)
+	}
+	if err := detector.Start(ctx); err != nil {
+		return nil, fmt.Errorf("啟動檢測器 %s 失敗: %w", pluginName, err)
+	}
+	return detector, nil
+}
+
+// 確保實現了 DetectorFactory 介面
+var _ plugins.DetectorFactory = (*DetectorFactory)(nil)
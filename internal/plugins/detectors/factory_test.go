@@ -0,0 +1,72 @@
+package detectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDetectorFactory_NewDetector(t *testing.T) {
+	factory := NewDetectorFactory(&MockLogger{}, nil)
+	ctx := context.Background()
+
+	if _, err := factory.NewDetector(ctx, "unknown_detector", nil); err == nil {
+		t.Fatal("期望未知的檢測器返回錯誤")
+	}
+	if _, err := factory.NewDetector(ctx, "threshold_detector_plugin", map[string]interface{}{}); err == nil {
+		t.Fatal("期望缺少 field_name 時初始化失敗")
+	}
+
+	cfg := map[string]interface{}{
+		"field_name":      "cpu_usage",
+		"upper_threshold": 80.0,
+		"enable_lower":    false,
+		"tolerant_count":  float64(2), // 模擬從 JSON 解碼的配置
+	}
+	first, err := factory.NewDetector(ctx, "threshold_detector_plugin", cfg)
+	if err != nil {
+		t.Fatalf("創建檢測器失敗: %v", err)
+	}
+	second, err := factory.NewDetector(ctx, "threshold_detector_plugin", cfg)
+	if err != nil {
+		t.Fatalf("創建檢測器失敗: %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(i int, value float64) map[string]interface{} {
+		return map[string]interface{}{"cpu_usage": value, "timestamp": base.Add(time.Duration(i) * time.Minute)}
+	}
+
+	// 第一次超限未達 tolerant_count
+	result, err := first.Execute(ctx, record(0, 90), cfg)
+	if err != nil {
+		t.Fatalf("執行失敗: %v", err)
+	}
+	if result.IsAnomalous() {
+		t.Error("第一次超限不應視為異常")
+	}
+
+	// 第二次連續超限觸發異常，時間戳取自記錄
+	result, err = first.Execute(ctx, record(1, 95), cfg)
+	if err != nil {
+		t.Fatalf("執行失敗: %v", err)
+	}
+	if !result.IsAnomalous() || result.Severity != "medium" {
+		t.Errorf("期望連續超限觸發 medium 異常，got anomalous=%v severity=%q", result.IsAnomalous(), result.Severity)
+	}
+	if !result.Timestamp.Equal(base.Add(time.Minute)) {
+		t.Errorf("Timestamp = %v, want %v", result.Timestamp, base.Add(time.Minute))
+	}
+	if result.ID == "" || result.Summary == "" {
+		t.Error("期望結果包含 ID 與摘要")
+	}
+
+	// 另一個實例的狀態相互獨立
+	result, err = second.Execute(ctx, record(1, 95), cfg)
+	if err != nil {
+		t.Fatalf("執行失敗: %v", err)
+	}
+	if result.IsAnomalous() {
+		t.Error("新實例不應繼承其他實例的連續計數")
+	}
+}
@@ -4,21 +4,27 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"detectviz-platform/pkg/domain/entities"
 	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/domain/valueobjects"
 	"detectviz-platform/pkg/platform/contracts"
 )
 
 // ThresholdDetectorPlugin 實現基於閾值的異常偵測功能
 // 職責: 根據配置的閾值規則檢測數值型數據的異常
+// 實例會記錄連續超限的次數以支持 tolerant_count，因此是有狀態的，數據需按時間順序傳入。
 type ThresholdDetectorPlugin struct {
 	name            string
 	logger          contracts.Logger
 	metricsProvider contracts.MetricsProvider
 	config          ThresholdDetectorConfig
 	isInitialized   bool
+
+	mu          sync.Mutex
+	consecutive int // 連續超過閾值的次數
 }
 
 // ThresholdDetectorConfig 定義閾值偵測器的配置
@@ -112,6 +118,10 @@ func (t *ThresholdDetectorPlugin) Stop(ctx context.Context) error {
 	t.logger.Info("閾值偵測器插件正在停止", "plugin", t.name)
 	t.isInitialized = false
 
+	t.mu.Lock()
+	t.consecutive = 0
+	t.mu.Unlock()
+
 	// 記錄停止指標
 	if t.metricsProvider != nil {
 		t.metricsProvider.IncCounter("detector_stopped_total", map[string]string{
@@ -164,6 +174,22 @@ func (t *ThresholdDetectorPlugin) Execute(ctx context.Context, data map[string]i
 
 	// 執行閾值檢測
 	result := t.performThresholdCheck(value, runtimeConfig)
+	if ts, err := plugins.RecordTimestamp(data); err == nil {
+		result.DetectedAt = ts
+	}
+
+	// 連續超限次數未達 tolerant_count 前不視為異常
+	t.mu.Lock()
+	if result.IsAnomalous {
+		t.consecutive++
+	} else {
+		t.consecutive = 0
+	}
+	consecutive := t.consecutive
+	t.mu.Unlock()
+	if result.IsAnomalous && consecutive < runtimeConfig.TolerantCount {
+		result.IsAnomalous = false
+	}
 
 	// 記錄檢測指標
 	if t.metricsProvider != nil {
@@ -185,8 +211,21 @@ func (t *ThresholdDetectorPlugin) Execute(ctx context.Context, data map[string]i
 
 	// 構建 AnalysisResult
 	analysisResult := &entities.AnalysisResult{
-		// 這裡應該根據實際的 AnalysisResult 結構來填充
-		// 暫時使用空結構體作為佔位符
+		ID:        valueobjects.GenerateNewIDVO().String(),
+		Timestamp: result.DetectedAt,
+		Summary:   summarizeThresholdResult(result),
+		Data: map[string]interface{}{
+			entities.AnalysisDataKeyAnomalous: result.IsAnomalous,
+			"value":                           result.Value,
+			"threshold":                       result.Threshold,
+			"threshold_type":                  result.ThresholdType,
+			"field_name":                      result.FieldName,
+			"confidence":                      result.Confidence,
+			"consecutive_count":               consecutive,
+		},
+	}
+	if result.IsAnomalous {
+		analysisResult.Severity = result.Severity
 	}
 
 	t.logger.Info("閾值偵測完成",
@@ -229,8 +268,12 @@ func (t *ThresholdDetectorPlugin) parseConfig(cfg map[string]interface{}) error
 		t.config.EnableLower = enableLower
 	}
 
-	if tolerantCount, ok := cfg["tolerant_count"].(int); ok {
+	switch tolerantCount := cfg["tolerant_count"].(type) {
+	case int:
 		t.config.TolerantCount = tolerantCount
+	case float64:
+		// 從 JSON 解碼的配置中數字均為 float64
+		t.config.TolerantCount = int(tolerantCount)
 	}
 
 	return nil
@@ -343,3 +386,15 @@ func (t *ThresholdDetectorPlugin) performThresholdCheck(value float64, config Th
 	result.IsAnomalous = false
 	return result
 }
+
+// summarizeThresholdResult 生成偵測結果的簡要描述
+func summarizeThresholdResult(result *ThresholdDetectionResult) string {
+	switch {
+	case result.IsAnomalous && result.ThresholdType == "upper":
+		return fmt.Sprintf("%s = %g 超過上限閾值 %g", result.FieldName, result.Value, result.Threshold)
+	case result.IsAnomalous:
+		return fmt.Sprintf("%s = %g 低於下限閾值 %g", result.FieldName, result.Value, result.Threshold)
+	default:
+		return fmt.Sprintf("%s = %g 在正常範圍內", result.FieldName, result.Value)
+	}
+}
@@ -0,0 +1,544 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// maxDigestItems 是每個收件人摘要隊列的上限，超過時丟棄最舊的條目
+const maxDigestItems = 500
+
+// 郵件強調色
+var severityColors = map[string]string{
+	"critical": "#E01E5A",
+	"high":     "#E01E5A",
+	"error":    "#E01E5A",
+	"warning":  "#ECB22E",
+	"medium":   "#ECB22E",
+}
+
+const (
+	defaultEmailColor  = "#36C5F0"
+	resolvedEmailColor = "#2EB67D"
+)
+
+// EmailNotifierConfig 定義郵件通知插件的配置
+type EmailNotifierConfig struct {
+	Host               string
+	Port               int           // 默認 587，implicit 模式默認 465
+	TLSMode            string        // starttls (默認)、implicit 或 none
+	InsecureSkipVerify bool          // 跳過服務器證書校驗，僅用於測試環境
+	Username           string        // 留空則不認證
+	Password           string        // 由 password 或 password_secret (SecretsProvider 的鍵) 提供
+	From               string        // 發件人，可帶顯示名稱，例如 "Detectviz <alerts@example.com>"
+	HeloName           string        // EHLO 使用的主機名，默認 localhost
+	Timeout            time.Duration // 連接與單個命令的超時，默認 10s
+	IdleTimeout        time.Duration // 連接空閒多久後關閉，默認 30s；0 表示每封郵件後關閉
+	TemplatesDir       string        // 覆蓋內建模板的目錄
+	UIURL              string        // detectviz UI 的外部地址，用於郵件中的鏈接
+	DigestInterval     time.Duration // 摘要的發送間隔，默認 1h
+	RatePerHour        int           // 每個收件人每小時最多收到的即時郵件數，默認 20；0 表示不限制
+}
+
+// EmailNotifierPlugin 實現通過 SMTP 發送郵件的 NotificationPlugin
+// 職責: 按事件類型 (firing、resolved、notification 或調用方指定的 event_type) 選擇模板，
+// 渲染 multipart/alternative 純文字與 HTML 郵件，通過可重用的 SMTP 連接 (STARTTLS、implicit TLS 或明文) 發送。
+// 渠道設定 digest 或 digest_severities 匹配的通知不即時發送，而是按收件人累積後每 digest_interval 發送一封摘要；
+// 超過每收件人速率限制的即時郵件同樣轉入摘要，不會丟失。摘要隊列只保存在內存中，Stop 時會發送剩餘摘要。
+type EmailNotifierPlugin struct {
+	name      string
+	secrets   contracts.SecretsProvider
+	logger    contracts.Logger
+	config    EmailNotifierConfig
+	envelope  string
+	sender    *smtpSender
+	templates map[string]*emailTemplateSet
+	now       func() time.Time
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	digests  map[string]*digestQueue
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// digestQueue 是一個收件人等待發送的摘要條目
+type digestQueue struct {
+	since   time.Time
+	items   []DigestItem
+	dropped int
+}
+
+// NewEmailNotifierPlugin 創建新的郵件通知插件實例，secrets 可為 nil (此時不能使用 password_secret)
+func NewEmailNotifierPlugin(secrets contracts.SecretsProvider, logger contracts.Logger) *EmailNotifierPlugin {
+	return &EmailNotifierPlugin{
+		name:     "email_notifier",
+		secrets:  secrets,
+		logger:   logger,
+		now:      time.Now,
+		limiters: make(map[string]*rate.Limiter),
+		digests:  make(map[string]*digestQueue),
+	}
+}
+
+// GetName 返回插件名稱
+func (e *EmailNotifierPlugin) GetName() string {
+	return e.name
+}
+
+// Init 解析 SMTP 與摘要配置並載入模板。支持的鍵: host、port、tls、insecure_skip_verify、username、
+// password 或 password_secret、from、helo_name、timeout、idle_timeout、templates_dir、ui_url、
+// digest_interval、rate_per_hour。
+func (e *EmailNotifierPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	config, err := parseEmailConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("解析郵件配置失敗: %w", err)
+	}
+	if name, _ := cfg["password_secret"].(string); name != "" {
+		if e.secrets == nil {
+			return fmt.Errorf("password_secret is set but no secrets provider is configured")
+		}
+		if config.Password, err = e.secrets.GetSecret(ctx, name); err != nil {
+			return fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return fmt.Errorf("無效的 from: %w", err)
+	}
+	templates, err := loadEmailTemplates(config.TemplatesDir)
+	if err != nil {
+		return err
+	}
+
+	e.config = config
+	e.envelope = from.Address
+	e.templates = templates
+	e.sender = newSMTPSender(config, e.logger)
+	return nil
+}
+
+// Start 啟動摘要發送循環
+func (e *EmailNotifierPlugin) Start(ctx context.Context) error {
+	if e.sender == nil {
+		return fmt.Errorf("email notifier is not initialized")
+	}
+	if e.stopCh != nil {
+		return nil
+	}
+	e.stopCh = make(chan struct{})
+	tick := e.config.DigestInterval
+	if tick > time.Minute {
+		tick = time.Minute
+	}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stopCh:
+				return
+			case <-ticker.C:
+				e.flushDigests(context.Background(), false)
+			}
+		}
+	}()
+	e.logger.Info("郵件通知插件已啟動", "host", e.config.Host, "tls", e.config.TLSMode, "digest_interval", e.config.DigestInterval)
+	return nil
+}
+
+// Stop 停止摘要循環，發送所有待發送的摘要並關閉 SMTP 連接
+func (e *EmailNotifierPlugin) Stop(ctx context.Context) error {
+	if e.stopCh != nil {
+		close(e.stopCh)
+		e.wg.Wait()
+		e.stopCh = nil
+	}
+	if e.sender == nil {
+		return nil
+	}
+	e.flushDigests(ctx, true)
+	e.sender.close()
+	return nil
+}
+
+// SendNotification 向 recipient (逗號分隔的多個地址) 發送郵件。
+// metadata 可包含告警管理器附帶的通知信息、event_type、severity，以及渠道設定 digest (bool)
+// 與 digest_severities (列表，匹配的嚴重程度進入摘要)。
+func (e *EmailNotifierPlugin) SendNotification(ctx context.Context, recipient, subject, body string, metadata map[string]interface{}) error {
+	if e.sender == nil {
+		return fmt.Errorf("email notifier is not initialized")
+	}
+	addresses, err := parseRecipients(recipient)
+	if err != nil {
+		return err
+	}
+
+	data := e.emailData(subject, body, metadata)
+	if wantsDigest(metadata, data) {
+		for _, address := range addresses {
+			e.enqueueDigest(address, data)
+		}
+		return nil
+	}
+
+	now := e.now()
+	var allowed []string
+	for _, address := range addresses {
+		if e.allow(address, now) {
+			allowed = append(allowed, address)
+			continue
+		}
+		e.logger.Warn("收件人超過郵件速率限制，轉入摘要", "plugin", e.name, "recipient", address, "subject", subject)
+		e.enqueueDigest(address, data)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	data.Recipient = strings.Join(allowed, ", ")
+	return e.deliver(allowed, data.EventType, data)
+}
+
+// deliver 按事件類型渲染模板並發送
+func (e *EmailNotifierPlugin) deliver(to []string, eventType string, data interface{}) error {
+	set, ok := e.templates[eventType]
+	if !ok {
+		set = e.templates[EventTypeNotification]
+	}
+	subject, text, html, err := set.render(data)
+	if err != nil {
+		return err
+	}
+	msg := &emailMessage{
+		From:      e.config.From,
+		Envelope:  e.envelope,
+		To:        to,
+		Subject:   subject,
+		Text:      text,
+		HTML:      html,
+		EventType: eventType,
+	}
+	if err := e.sender.send(msg); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", strings.Join(to, ", "), err)
+	}
+	e.logger.Debug("郵件已發送", "plugin", e.name, "event_type", eventType, "recipients", len(to))
+	return nil
+}
+
+// emailData 由調用參數與告警通知信息生成模板數據
+func (e *EmailNotifierPlugin) emailData(subject, body string, metadata map[string]interface{}) *EmailData {
+	data := &EmailData{
+		EventType: EventTypeNotification,
+		Subject:   subject,
+		Body:      body,
+		Color:     defaultEmailColor,
+	}
+	if eventType, ok := metadata["event_type"].(string); ok && eventType != "" {
+		data.EventType = eventType
+	}
+	data.Severity, _ = metadata["severity"].(string)
+
+	if notification := plugins.AlertNotificationFromConfig(metadata); notification != nil {
+		alert := notification.Alert
+		data.Alert = &alert
+		data.EventType = alert.State
+		data.Receiver = notification.Receiver
+		data.GroupLabels = notification.GroupLabels
+		data.Repeat = notification.Repeat
+		data.Link = alertLink(e.config.UIURL, alert.DetectorID, alert.Fingerprint)
+		data.AckLink = notification.AcknowledgeURL
+		data.Severity = alert.Severity
+	}
+	if color, ok := severityColors[strings.ToLower(data.Severity)]; ok {
+		data.Color = color
+	}
+	if data.EventType == EventTypeResolved {
+		data.Color = resolvedEmailColor
+	}
+	return data
+}
+
+// wantsDigest 判斷通知是否進入摘要：渠道設定 digest 為 true，或嚴重程度在 digest_severities 中
+func wantsDigest(metadata map[string]interface{}, data *EmailData) bool {
+	if digest, _ := metadata["digest"].(bool); digest {
+		return true
+	}
+	for _, s := range stringList(metadata["digest_severities"]) {
+		if strings.EqualFold(s, data.Severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow 檢查收件人的速率限制
+func (e *EmailNotifierPlugin) allow(address string, now time.Time) bool {
+	if e.config.RatePerHour <= 0 {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	limiter, ok := e.limiters[address]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(float64(e.config.RatePerHour)/3600), e.config.RatePerHour)
+		e.limiters[address] = limiter
+	}
+	return limiter.AllowN(now, 1)
+}
+
+// enqueueDigest 將通知加入收件人的摘要隊列
+func (e *EmailNotifierPlugin) enqueueDigest(address string, data *EmailData) {
+	item := DigestItem{
+		Time:      e.now(),
+		EventType: data.EventType,
+		Severity:  data.Severity,
+		Subject:   data.Subject,
+		Body:      data.Body,
+		Alert:     data.Alert,
+		Link:      data.Link,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	queue, ok := e.digests[address]
+	if !ok {
+		queue = &digestQueue{since: item.Time}
+		e.digests[address] = queue
+	}
+	queue.items = append(queue.items, item)
+	if overflow := len(queue.items) - maxDigestItems; overflow > 0 {
+		queue.items = queue.items[overflow:]
+		queue.dropped += overflow
+	}
+}
+
+// flushDigests 發送已到期的摘要，force 為 true 時發送全部；發送失敗的摘要留待下次重試
+func (e *EmailNotifierPlugin) flushDigests(ctx context.Context, force bool) {
+	now := e.now()
+	e.mu.Lock()
+	due := make(map[string]*digestQueue)
+	for address, queue := range e.digests {
+		if force || now.Sub(queue.since) >= e.config.DigestInterval {
+			due[address] = queue
+			delete(e.digests, address)
+		}
+	}
+	e.mu.Unlock()
+
+	addresses := make([]string, 0, len(due))
+	for address := range due {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		if ctx.Err() != nil {
+			e.requeue(address, due[address])
+			continue
+		}
+		queue := due[address]
+		data := &DigestData{
+			Recipient: address,
+			Since:     queue.since,
+			Until:     now,
+			Items:     queue.items,
+			Dropped:   queue.dropped,
+		}
+		if err := e.deliver([]string{address}, EventTypeDigest, data); err != nil {
+			e.logger.Error("發送郵件摘要失敗", "plugin", e.name, "recipient", address, "items", len(queue.items), "error", err)
+			e.requeue(address, queue)
+			continue
+		}
+		e.logger.Info("郵件摘要已發送", "plugin", e.name, "recipient", address, "items", len(queue.items))
+	}
+}
+
+// requeue 將發送失敗的摘要放回隊列，保留原始開始時間
+func (e *EmailNotifierPlugin) requeue(address string, queue *digestQueue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if current, ok := e.digests[address]; ok {
+		queue.items = append(queue.items, current.items...)
+		queue.dropped += current.dropped
+		if overflow := len(queue.items) - maxDigestItems; overflow > 0 {
+			queue.items = queue.items[overflow:]
+			queue.dropped += overflow
+		}
+	}
+	e.digests[address] = queue
+}
+
+// parseRecipients 解析逗號分隔的收件人列表，返回去重後的小寫純地址
+func parseRecipients(recipient string) ([]string, error) {
+	list, err := mail.ParseAddressList(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email recipient %q: %w", recipient, err)
+	}
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, address := range list {
+		normalized := strings.ToLower(address.Address)
+		if !seen[normalized] {
+			seen[normalized] = true
+			out = append(out, normalized)
+		}
+	}
+	return out, nil
+}
+
+// alertLink 生成返回 detectviz UI 的鏈接: {ui_url}/detectors/{detector_id}?alert={fingerprint}
+func alertLink(uiURL, detectorID, fingerprint string) string {
+	if uiURL == "" || detectorID == "" {
+		return ""
+	}
+	link := strings.TrimRight(uiURL, "/") + "/detectors/" + url.PathEscape(detectorID)
+	if fingerprint != "" {
+		link += "?alert=" + url.QueryEscape(fingerprint)
+	}
+	return link
+}
+
+// parseEmailConfig 解析並驗證插件配置
+func parseEmailConfig(cfg map[string]interface{}) (EmailNotifierConfig, error) {
+	config := EmailNotifierConfig{
+		TLSMode:        TLSModeStartTLS,
+		HeloName:       "localhost",
+		Timeout:        10 * time.Second,
+		IdleTimeout:    30 * time.Second,
+		DigestInterval: time.Hour,
+		RatePerHour:    20,
+	}
+	config.Host, _ = cfg["host"].(string)
+	if config.Host == "" {
+		return config, fmt.Errorf("host 不能為空")
+	}
+	if v, ok := cfg["tls"].(string); ok && v != "" {
+		config.TLSMode = strings.ToLower(v)
+	}
+	switch config.TLSMode {
+	case TLSModeStartTLS:
+		config.Port = 587
+	case TLSModeImplicit:
+		config.Port = 465
+	case TLSModeNone:
+		config.Port = 25
+	default:
+		return config, fmt.Errorf("不支持的 tls 模式: %s", config.TLSMode)
+	}
+	config.InsecureSkipVerify, _ = cfg["insecure_skip_verify"].(bool)
+	config.Username, _ = cfg["username"].(string)
+	config.Password, _ = cfg["password"].(string)
+	config.From, _ = cfg["from"].(string)
+	if config.From == "" {
+		return config, fmt.Errorf("from 不能為空")
+	}
+	if v, ok := cfg["helo_name"].(string); ok && v != "" {
+		config.HeloName = v
+	}
+	config.TemplatesDir, _ = cfg["templates_dir"].(string)
+	config.UIURL, _ = cfg["ui_url"].(string)
+
+	var err error
+	if config.Port, err = intSetting(cfg, "port", config.Port); err != nil {
+		return config, err
+	}
+	if config.RatePerHour, err = intSetting(cfg, "rate_per_hour", config.RatePerHour); err != nil {
+		return config, err
+	}
+	if config.Timeout, err = durationSetting(cfg, "timeout", config.Timeout); err != nil {
+		return config, err
+	}
+	if config.IdleTimeout, err = durationSetting(cfg, "idle_timeout", config.IdleTimeout); err != nil {
+		return config, err
+	}
+	if config.DigestInterval, err = durationSetting(cfg, "digest_interval", config.DigestInterval); err != nil {
+		return config, err
+	}
+	if config.DigestInterval <= 0 {
+		return config, fmt.Errorf("digest_interval 必須大於 0")
+	}
+	return config, nil
+}
+
+// durationSetting 讀取時間長度設定，支持字符串與 time.Duration；"0s" 表示 0
+func durationSetting(cfg map[string]interface{}, key string, def time.Duration) (time.Duration, error) {
+	switch v := cfg[key].(type) {
+	case nil:
+		return def, nil
+	case time.Duration:
+		return v, nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("無效的 %s: %q", key, v)
+		}
+		return d, nil
+	default:
+		return 0, fmt.Errorf("無效的 %s: %v", key, v)
+	}
+}
+
+// intSetting 讀取整數設定，支持 YAML/JSON 解碼後的各種數值類型
+func intSetting(cfg map[string]interface{}, key string, def int) (int, error) {
+	switch v := cfg[key].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("無效的 %s: %q", key, v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("無效的 %s: %v", key, v)
+	}
+}
+
+// stringList 將 []interface{} 或 []string 配置值轉換為字符串切片
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// 確保實現了 NotificationPlugin 介面
+var _ plugins.NotificationPlugin = (*EmailNotifierPlugin)(nil)
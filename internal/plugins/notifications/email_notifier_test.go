@@ -0,0 +1,425 @@
+package notifications
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+// receivedMail 是 SMTP 替身收到的一封郵件
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+	TLS  bool
+	Auth string
+}
+
+// fakeSMTPServer 是測試用的最小 SMTP 服務器，支持 EHLO、STARTTLS、AUTH PLAIN、MAIL、RCPT、DATA、RSET、NOOP 與 QUIT
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config // 非 nil 時公告 STARTTLS
+
+	mu          sync.Mutex
+	messages    []receivedMail
+	connections int
+}
+
+func newFakeSMTPServer(t *testing.T, startTLS bool) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: listener}
+	if startTLS {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}
+	}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) snapshot() ([]receivedMail, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.messages...), s.connections
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+
+	var (
+		current receivedMail
+		secured bool
+		auth    string
+	)
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"fake"}
+			if s.tlsConfig != nil && !secured {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			secured = true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			auth = strings.ReplaceAll(string(decoded), "\x00", ":")
+			_ = tp.PrintfLine("235 ok")
+		case "MAIL":
+			current = receivedMail{From: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>"), TLS: secured, Auth: auth}
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			current.To = append(current.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			current.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			_ = tp.PrintfLine("250 ok")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 unsupported")
+		}
+	}
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// parsedMail 是解碼後的郵件
+type parsedMail struct {
+	Header mail.Header
+	Text   string
+	HTML   string
+}
+
+func parseMail(t *testing.T, data string) parsedMail {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, data)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q: %v", msg.Header.Get("Content-Type"), err)
+	}
+	out := parsedMail{Header: msg.Header}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(part) // NextPart 自動解碼 quoted-printable
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") {
+			out.HTML = string(content)
+		} else {
+			out.Text = string(content)
+		}
+	}
+	return out
+}
+
+func newTestNotifier(t *testing.T, server *fakeSMTPServer, cfg map[string]interface{}) *EmailNotifierPlugin {
+	t.Helper()
+	base := map[string]interface{}{
+		"host":    "127.0.0.1",
+		"port":    server.port(),
+		"tls":     "none",
+		"from":    "Detectviz <alerts@example.com>",
+		"timeout": "2s",
+		"ui_url":  "https://detectviz.example.com",
+	}
+	for k, v := range cfg {
+		base[k] = v
+	}
+	e := NewEmailNotifierPlugin(mapSecrets{"smtp/password": "hunter2"}, &testLogger{})
+	if err := e.Init(context.Background(), base); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { _ = e.Stop(context.Background()) })
+	return e
+}
+
+type mapSecrets map[string]string
+
+func (s mapSecrets) GetSecret(ctx context.Context, key string) (string, error) {
+	return s[key], nil
+}
+
+func (s mapSecrets) GetName() string { return "map_secrets" }
+
+func alertMetadata(state, severity string) map[string]interface{} {
+	alert := entities.Alert{
+		Fingerprint: "abc123",
+		DetectorID:  "cpu",
+		Labels:      map[string]string{"detector_id": "cpu", "host": "web-1"},
+		State:       state,
+		Severity:    severity,
+		Summary:     "cpu <above> threshold",
+		Data:        map[string]interface{}{"value": 97.5},
+		StartsAt:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	return map[string]interface{}{plugins.AlertConfigKeyNotification: &entities.AlertNotification{Receiver: "ops", Alert: alert}}
+}
+
+func TestEmailNotifier_MultipartAndConnectionReuse(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	e := newTestNotifier(t, server, map[string]interface{}{"username": "bot", "password_secret": "smtp/password"})
+
+	for i := 0; i < 2; i++ {
+		err := e.SendNotification(context.Background(), "Ops <OPS@example.com>, dev@example.com",
+			"[FIRING] cpu <above> threshold", "State: firing\n", alertMetadata(entities.AlertStateFiring, "critical"))
+		if err != nil {
+			t.Fatalf("SendNotification() error = %v", err)
+		}
+	}
+
+	messages, connections := server.snapshot()
+	if len(messages) != 2 || connections != 1 {
+		t.Fatalf("expected 2 messages over 1 connection, got %d messages over %d connections", len(messages), connections)
+	}
+	first := messages[0]
+	if first.From != "alerts@example.com" || strings.Join(first.To, ",") != "ops@example.com,dev@example.com" || first.Auth != ":bot:hunter2" {
+		t.Errorf("unexpected envelope: %+v", first)
+	}
+	parsed := parseMail(t, first.Data)
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != "[FIRING] cpu <above> threshold" {
+		t.Errorf("subject = %q", subject)
+	}
+	if parsed.Header.Get("X-Detectviz-Event") != "firing" {
+		t.Errorf("event header = %q", parsed.Header.Get("X-Detectviz-Event"))
+	}
+	if !strings.Contains(parsed.Text, "View in detectviz: https://detectviz.example.com/detectors/cpu?alert=abc123") {
+		t.Errorf("text body missing link:\n%s", parsed.Text)
+	}
+	for _, want := range []string{"cpu &lt;above&gt; threshold", "#E01E5A", "web-1", `href="https://detectviz.example.com/detectors/cpu?alert=abc123"`} {
+		if !strings.Contains(parsed.HTML, want) {
+			t.Errorf("html body missing %q:\n%s", want, parsed.HTML)
+		}
+	}
+}
+
+func TestEmailNotifier_StartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t, true)
+	e := newTestNotifier(t, server, map[string]interface{}{"tls": "starttls", "insecure_skip_verify": true})
+	if err := e.SendNotification(context.Background(), "ops@example.com", "hello", "body", nil); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	messages, _ := server.snapshot()
+	if len(messages) != 1 || !messages[0].TLS {
+		t.Fatalf("expected one message over TLS, got %+v", messages)
+	}
+
+	// 服務器不支持 STARTTLS 時拒絕以明文發送
+	plain := newFakeSMTPServer(t, false)
+	e = newTestNotifier(t, plain, map[string]interface{}{"tls": "starttls"})
+	if err := e.SendNotification(context.Background(), "ops@example.com", "hello", "body", nil); err == nil ||
+		!strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestEmailNotifier_DigestAndRateLimit(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	e := newTestNotifier(t, server, map[string]interface{}{"rate_per_hour": 1, "digest_interval": "1h"})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	// info 級別進入摘要
+	digestSettings := alertMetadata(entities.AlertStateFiring, "info")
+	digestSettings["digest_severities"] = []interface{}{"info", "low"}
+	if err := e.SendNotification(context.Background(), "ops@example.com", "[FIRING] disk", "", digestSettings); err != nil {
+		t.Fatal(err)
+	}
+	// 第一封即時郵件在限額內，第二封轉入摘要
+	for i := 0; i < 2; i++ {
+		if err := e.SendNotification(context.Background(), "ops@example.com", "critical "+strconv.Itoa(i), "", alertMetadata(entities.AlertStateFiring, "critical")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if messages, _ := server.snapshot(); len(messages) != 1 {
+		t.Fatalf("expected 1 immediate message, got %d", len(messages))
+	}
+
+	// 未到期不發送
+	now = now.Add(30 * time.Minute)
+	e.flushDigests(context.Background(), false)
+	if messages, _ := server.snapshot(); len(messages) != 1 {
+		t.Fatalf("digest sent too early")
+	}
+
+	now = now.Add(31 * time.Minute)
+	e.flushDigests(context.Background(), false)
+	messages, _ := server.snapshot()
+	if len(messages) != 2 {
+		t.Fatalf("expected digest to be sent, got %d messages", len(messages))
+	}
+	digest := parseMail(t, messages[1].Data)
+	if subject := digest.Header.Get("Subject"); !strings.HasPrefix(subject, "[DIGEST] 2 notification(s)") {
+		t.Errorf("digest subject = %q", subject)
+	}
+	if !strings.Contains(digest.Text, "[FIRING] disk") || !strings.Contains(digest.Text, "critical 1") {
+		t.Errorf("digest text missing items:\n%s", digest.Text)
+	}
+	if digest.Header.Get("X-Detectviz-Event") != "digest" {
+		t.Errorf("event header = %q", digest.Header.Get("X-Detectviz-Event"))
+	}
+}
+
+func TestEmailNotifier_TemplateOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "resolved.subject.tmpl"), []byte(`OK: {{ .Alert.DetectorID }} on {{ index .Alert.Labels "host" }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.txt.tmpl"), []byte(`Report for {{ .Recipient }}: {{ .Body }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	server := newFakeSMTPServer(t, false)
+	e := newTestNotifier(t, server, map[string]interface{}{"templates_dir": dir})
+
+	if err := e.SendNotification(context.Background(), "ops@example.com", "[RESOLVED] cpu", "", alertMetadata(entities.AlertStateResolved, "critical")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SendNotification(context.Background(), "ops@example.com", "weekly", "all good", map[string]interface{}{"event_type": "report"}); err != nil {
+		t.Fatal(err)
+	}
+	messages, _ := server.snapshot()
+	resolved := parseMail(t, messages[0].Data)
+	if resolved.Header.Get("Subject") != "OK: cpu on web-1" || !strings.Contains(resolved.HTML, resolvedEmailColor) {
+		t.Errorf("unexpected resolved mail: subject %q", resolved.Header.Get("Subject"))
+	}
+	report := parseMail(t, messages[1].Data)
+	if report.Text != "Report for ops@example.com: all good" || report.Header.Get("Subject") != "weekly" {
+		t.Errorf("unexpected report mail: %q / %q", report.Header.Get("Subject"), report.Text)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "firing.html.tmpl"), []byte(`{{ .Unclosed`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEmailNotifierPlugin(nil, &testLogger{}).Init(context.Background(), map[string]interface{}{
+		"host": "127.0.0.1", "from": "alerts@example.com", "templates_dir": dir}); err == nil {
+		t.Error("expected Init error for invalid template")
+	}
+}
+
+func TestEmailNotifier_InvalidConfig(t *testing.T) {
+	cases := []map[string]interface{}{
+		{"from": "alerts@example.com"},
+		{"host": "smtp.example.com"},
+		{"host": "smtp.example.com", "from": "alerts@example.com", "tls": "ssl3"},
+		{"host": "smtp.example.com", "from": "not an address"},
+		{"host": "smtp.example.com", "from": "alerts@example.com", "password_secret": "smtp/password"},
+	}
+	for _, cfg := range cases {
+		if err := NewEmailNotifierPlugin(nil, &testLogger{}).Init(context.Background(), cfg); err == nil {
+			t.Errorf("expected Init error for %v", cfg)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// 郵件事件類型，每種類型有各自的標題、純文字與 HTML 模板
+const (
+	EventTypeFiring       = entities.AlertStateFiring
+	EventTypeResolved     = entities.AlertStateResolved
+	EventTypeNotification = "notification" // 非告警通知，以及沒有專屬模板的自定義事件類型
+	EventTypeDigest       = "digest"       // 批量摘要
+)
+
+// EmailData 是單封通知郵件的模板數據
+type EmailData struct {
+	EventType   string
+	Recipient   string
+	Subject     string // 調用方提供的標題
+	Body        string // 調用方提供的純文字內容
+	Severity    string // 告警的嚴重程度，或非告警通知 metadata 中的 severity
+	Alert       *entities.Alert
+	Receiver    string
+	GroupLabels map[string]string
+	Repeat      bool
+	Link        string // 返回 detectviz UI 的鏈接，未配置 ui_url 時為空
+	AckLink     string // 確認事件單的簽名鏈接，只在告警管理器提供時非空
+	Color       string // 按嚴重程度與狀態選擇的強調色
+}
+
+// DigestData 是摘要郵件的模板數據
+type DigestData struct {
+	Recipient string
+	Since     time.Time
+	Until     time.Time
+	Items     []DigestItem
+	Dropped   int // 超過隊列上限被丟棄的最舊條目數
+}
+
+// DigestItem 是摘要中的一條通知
+type DigestItem struct {
+	Time      time.Time
+	EventType string
+	Severity  string
+	Subject   string
+	Body      string
+	Alert     *entities.Alert
+	Link      string
+}
+
+// emailTemplateSet 是一種事件類型的模板
+type emailTemplateSet struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// render 渲染標題、純文字與 HTML 內容
+func (t *emailTemplateSet) render(data interface{}) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err = t.subject.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email subject: %w", err)
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err = t.text.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email text body: %w", err)
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err = t.html.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email html body: %w", err)
+	}
+	return subject, text, buf.String(), nil
+}
+
+// emailTemplateFuncs 是模板可使用的輔助函數
+var emailTemplateFuncs = map[string]interface{}{
+	"fmtTime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// templateSource 是一種事件類型的模板原文
+type templateSource struct {
+	subject, text, html string
+}
+
+const defaultSubjectTemplate = `{{ .Subject }}`
+
+const defaultTextTemplate = `{{ .Body }}{{ if .Link }}
+View in detectviz: {{ .Link }}
+{{ end }}{{ if .AckLink }}
+Acknowledge: {{ .AckLink }}
+{{ end }}`
+
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family:Arial,Helvetica,sans-serif;color:#1d1c1d;margin:0;padding:16px">
+<table role="presentation" cellpadding="0" cellspacing="0" style="max-width:640px;width:100%;border-left:6px solid {{ .Color }}">
+<tr><td style="padding:12px 16px">
+<h2 style="margin:0 0 12px 0;font-size:18px">{{ .Subject }}</h2>
+{{- with .Alert }}
+<table cellpadding="4" cellspacing="0" style="font-size:14px;border-collapse:collapse">
+<tr><td><b>State</b></td><td>{{ .State }}</td></tr>
+<tr><td><b>Severity</b></td><td>{{ .Severity }}</td></tr>
+<tr><td><b>Detector</b></td><td>{{ .DetectorID }}</td></tr>
+{{- if .Summary }}
+<tr><td><b>Summary</b></td><td>{{ .Summary }}</td></tr>
+{{- end }}
+<tr><td><b>Started</b></td><td>{{ fmtTime .StartsAt }}</td></tr>
+{{- if not .ResolvedAt.IsZero }}
+<tr><td><b>Resolved</b></td><td>{{ fmtTime .ResolvedAt }}</td></tr>
+{{- end }}
+{{- range $name, $value := .Labels }}
+<tr><td style="color:#616061">{{ $name }}</td><td>{{ $value }}</td></tr>
+{{- end }}
+{{- range $name, $value := .Data }}
+<tr><td style="color:#616061">{{ $name }}</td><td>{{ $value }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<pre style="font-size:14px;white-space:pre-wrap">{{ .Body }}</pre>
+{{- end }}
+{{- if or .Link .AckLink }}
+<p>
+{{- if .Link }}
+<a href="{{ .Link }}" style="display:inline-block;padding:8px 14px;background:#1264a3;color:#ffffff;text-decoration:none;border-radius:4px">View in detectviz</a>
+{{- end }}
+{{- if .AckLink }}
+<a href="{{ .AckLink }}" style="display:inline-block;padding:8px 14px;background:#2eb67d;color:#ffffff;text-decoration:none;border-radius:4px">Acknowledge</a>
+{{- end }}
+</p>
+{{- end }}
+</td></tr>
+</table>
+</body>
+</html>
+`
+
+const defaultDigestSubjectTemplate = `[DIGEST] {{ len .Items }} notification(s) since {{ fmtTime .Since }}`
+
+const defaultDigestTextTemplate = `{{ len .Items }} notification(s) between {{ fmtTime .Since }} and {{ fmtTime .Until }}.
+{{- if .Dropped }}
+{{ .Dropped }} older notification(s) were dropped.
+{{- end }}
+{{ range .Items }}
+- {{ fmtTime .Time }} {{ .Subject }}{{ if .Link }}
+  {{ .Link }}{{ end }}
+{{- end }}
+`
+
+const defaultDigestHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family:Arial,Helvetica,sans-serif;color:#1d1c1d;margin:0;padding:16px">
+<h2 style="margin:0 0 8px 0;font-size:18px">{{ len .Items }} notification(s)</h2>
+<p style="color:#616061;font-size:13px">{{ fmtTime .Since }} – {{ fmtTime .Until }}
+{{- if .Dropped }}; {{ .Dropped }} older notification(s) were dropped{{ end }}</p>
+<table cellpadding="6" cellspacing="0" style="font-size:14px;border-collapse:collapse;max-width:800px;width:100%">
+<tr style="background:#f4f4f4;text-align:left"><th>Time</th><th>Severity</th><th>Notification</th></tr>
+{{- range .Items }}
+<tr style="border-top:1px solid #e8e8e8">
+<td style="white-space:nowrap">{{ fmtTime .Time }}</td>
+<td>{{ .Severity }}</td>
+<td>{{ if .Link }}<a href="{{ .Link }}">{{ .Subject }}</a>{{ else }}{{ .Subject }}{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`
+
+// defaultTemplateSources 是內建的模板；firing、resolved 與 notification 共用同一版式，按 Color 區分狀態
+var defaultTemplateSources = map[string]templateSource{
+	EventTypeFiring:       {defaultSubjectTemplate, defaultTextTemplate, defaultHTMLTemplate},
+	EventTypeResolved:     {defaultSubjectTemplate, defaultTextTemplate, defaultHTMLTemplate},
+	EventTypeNotification: {defaultSubjectTemplate, defaultTextTemplate, defaultHTMLTemplate},
+	EventTypeDigest:       {defaultDigestSubjectTemplate, defaultDigestTextTemplate, defaultDigestHTMLTemplate},
+}
+
+// loadEmailTemplates 解析內建模板，並以 dir 中的 <事件類型>.subject.tmpl、<事件類型>.txt.tmpl 與
+// <事件類型>.html.tmpl 覆蓋；目錄中出現的其他事件類型缺少的部分使用 notification 的內建模板
+func loadEmailTemplates(dir string) (map[string]*emailTemplateSet, error) {
+	sources := make(map[string]templateSource, len(defaultTemplateSources))
+	for eventType, source := range defaultTemplateSources {
+		sources[eventType] = source
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email templates directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".tmpl") {
+				continue
+			}
+			parts := strings.Split(strings.TrimSuffix(name, ".tmpl"), ".")
+			if len(parts) != 2 {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read email template %s: %w", name, err)
+			}
+			eventType, kind := parts[0], parts[1]
+			source, ok := sources[eventType]
+			if !ok {
+				source = defaultTemplateSources[EventTypeNotification]
+			}
+			switch kind {
+			case "subject":
+				source.subject = string(content)
+			case "txt":
+				source.text = string(content)
+			case "html":
+				source.html = string(content)
+			default:
+				return nil, fmt.Errorf("unknown email template kind %q in %s (want subject, txt or html)", kind, name)
+			}
+			sources[eventType] = source
+		}
+	}
+
+	sets := make(map[string]*emailTemplateSet, len(sources))
+	for eventType, source := range sources {
+		set, err := parseTemplateSet(eventType, source)
+		if err != nil {
+			return nil, err
+		}
+		sets[eventType] = set
+	}
+	return sets, nil
+}
+
+// parseTemplateSet 解析一種事件類型的模板，HTML 使用 html/template 自動轉義
+func parseTemplateSet(eventType string, source templateSource) (*emailTemplateSet, error) {
+	subject, err := texttemplate.New(eventType + ".subject").Funcs(emailTemplateFuncs).Parse(source.subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s subject template: %w", eventType, err)
+	}
+	text, err := texttemplate.New(eventType + ".txt").Funcs(emailTemplateFuncs).Parse(source.text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s text template: %w", eventType, err)
+	}
+	html, err := htmltemplate.New(eventType + ".html").Funcs(emailTemplateFuncs).Parse(source.html)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s html template: %w", eventType, err)
+	}
+	return &emailTemplateSet{subject: subject, text: text, html: html}, nil
+}
@@ -0,0 +1,303 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SMTP 連接的加密方式
+const (
+	TLSModeStartTLS = "starttls" // 明文連接後以 STARTTLS 升級，服務器不支持時拒絕發送
+	TLSModeImplicit = "implicit" // 連接建立即為 TLS (SMTPS，通常為 465 端口)
+	TLSModeNone     = "none"     // 不加密，僅用於本地中繼與測試
+)
+
+// emailMessage 是一封待發送的郵件
+type emailMessage struct {
+	From      string   // 標頭中的發件人，可帶顯示名稱
+	Envelope  string   // MAIL FROM 使用的純地址
+	To        []string // 收件人純地址
+	Subject   string
+	Text      string
+	HTML      string
+	EventType string
+}
+
+// smtpSender 維護一條可重用的 SMTP 連接：連續發送的郵件共用同一連接，
+// 空閒超過 idleTimeout 後關閉，重用前以 NOOP 檢查連接是否仍然可用
+type smtpSender struct {
+	config    EmailNotifierConfig
+	tlsConfig *tls.Config
+	logger    contracts.Logger
+
+	mu        sync.Mutex
+	client    *smtp.Client
+	conn      net.Conn
+	idleTimer *time.Timer
+}
+
+// newSMTPSender 創建 SMTP 發送器
+func newSMTPSender(config EmailNotifierConfig, logger contracts.Logger) *smtpSender {
+	return &smtpSender{
+		config: config,
+		tlsConfig: &tls.Config{
+			ServerName:         config.Host,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+		},
+		logger: logger,
+	}
+}
+
+// send 發送郵件；重用連接前 NOOP 失敗則重新建立連接，發送出錯時丟棄連接
+func (s *smtpSender) send(msg *emailMessage) error {
+	data, err := buildMIMEMessage(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reused := s.client != nil
+	if reused {
+		s.setDeadline()
+		if err := s.client.Noop(); err != nil {
+			s.logger.Debug("SMTP 連接已失效，重新連接", "host", s.config.Host, "error", err)
+			s.closeLocked()
+			reused = false
+		}
+	}
+	if !reused {
+		if err := s.connectLocked(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.transmitLocked(msg, data); err != nil {
+		s.closeLocked()
+		return err
+	}
+	s.resetIdleTimerLocked()
+	return nil
+}
+
+// connectLocked 建立連接、升級 TLS 並認證
+func (s *smtpSender) connectLocked() error {
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.config.TLSMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server %s: %w", address, err)
+	}
+	s.conn = conn
+	s.setDeadline()
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		s.conn = nil
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	s.client = client
+
+	if err := client.Hello(s.config.HeloName); err != nil {
+		s.closeLocked()
+		return fmt.Errorf("smtp EHLO failed: %w", err)
+	}
+	if s.config.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			s.closeLocked()
+			return fmt.Errorf("smtp server %s does not support STARTTLS", address)
+		}
+		if err := client.StartTLS(s.tlsConfig); err != nil {
+			s.closeLocked()
+			return fmt.Errorf("smtp STARTTLS failed: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			s.closeLocked()
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+	s.logger.Debug("已建立 SMTP 連接", "host", s.config.Host, "tls", s.config.TLSMode)
+	return nil
+}
+
+// transmitLocked 在當前連接上完成一次 MAIL/RCPT/DATA 事務
+func (s *smtpSender) transmitLocked(msg *emailMessage, data []byte) error {
+	s.setDeadline()
+	if err := s.client.Mail(msg.Envelope); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := s.client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+	w, err := s.client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write smtp message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+	return nil
+}
+
+// setDeadline 為接下來的命令設置超時
+func (s *smtpSender) setDeadline() {
+	if s.conn != nil && s.config.Timeout > 0 {
+		_ = s.conn.SetDeadline(time.Now().Add(s.config.Timeout))
+	}
+}
+
+// resetIdleTimerLocked 重新計算空閒關閉時間
+func (s *smtpSender) resetIdleTimerLocked() {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	if s.config.IdleTimeout <= 0 {
+		s.closeLocked()
+		return
+	}
+	client := s.client
+	s.idleTimer = time.AfterFunc(s.config.IdleTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// 期間已重新連接時不關閉新連接
+		if s.client == client {
+			s.quitLocked()
+		}
+	})
+}
+
+// close 關閉連接
+func (s *smtpSender) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	s.quitLocked()
+}
+
+// quitLocked 以 QUIT 正常結束連接
+func (s *smtpSender) quitLocked() {
+	if s.client == nil {
+		return
+	}
+	s.setDeadline()
+	if err := s.client.Quit(); err != nil {
+		s.client.Close()
+	}
+	s.client = nil
+	s.conn = nil
+}
+
+// closeLocked 直接斷開連接，用於出錯後丟棄連接
+func (s *smtpSender) closeLocked() {
+	if s.client != nil {
+		s.client.Close()
+	} else if s.conn != nil {
+		s.conn.Close()
+	}
+	s.client = nil
+	s.conn = nil
+}
+
+// buildMIMEMessage 生成 multipart/alternative 郵件，純文字與 HTML 部分均以 quoted-printable 編碼
+func buildMIMEMessage(msg *emailMessage, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := []struct{ key, value string }{
+		{"From", sanitizeHeader(msg.From)},
+		{"To", sanitizeHeader(strings.Join(msg.To, ", "))},
+		{"Subject", mime.QEncoding.Encode("utf-8", sanitizeHeader(msg.Subject))},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(msg.Envelope)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `multipart/alternative; boundary="` + mw.Boundary() + `"`},
+	}
+	if msg.EventType != "" {
+		headers = append(headers, struct{ key, value string }{"X-Detectviz-Event", sanitizeHeader(msg.EventType)})
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sanitizeHeader 移除換行，防止標頭注入
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// messageID 生成唯一的 Message-ID，域名取自發件人地址
+func messageID(from string) string {
+	domain := "detectviz.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
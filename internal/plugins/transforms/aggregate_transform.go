@@ -0,0 +1,238 @@
+package transforms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// 支持的聚合函數
+var aggregateFunctions = map[string]bool{"avg": true, "sum": true, "min": true, "max": true, "count": true}
+
+// AggregateTransformPlugin 實現按時間窗口聚合數值欄位的轉換
+// 職責: 將記錄按 timestamp 切分到固定長度的時間桶 (例如每分鐘)，可選按維度欄位分組，
+// 對指定的數值欄位計算 avg、sum、min、max 或 count，輸出按時間排序的聚合記錄
+type AggregateTransformPlugin struct {
+	name   string
+	logger contracts.Logger
+}
+
+// AggregateTransformConfig 定義聚合轉換的階段配置
+type AggregateTransformConfig struct {
+	Window   time.Duration // 時間桶長度，默認 1m
+	Fields   []string      // 要聚合的數值欄位
+	Function string        // 聚合函數，默認 avg
+	GroupBy  []string      // 分組的維度欄位
+}
+
+// aggregateBucket 是一個時間桶 + 分組的累計值
+type aggregateBucket struct {
+	start  time.Time
+	group  map[string]interface{}
+	key    string
+	count  int
+	sums   map[string]float64
+	mins   map[string]float64
+	maxs   map[string]float64
+	counts map[string]int
+}
+
+// NewAggregateTransformPlugin 創建新的聚合轉換插件實例
+func NewAggregateTransformPlugin(logger contracts.Logger) plugins.TransformPlugin {
+	return &AggregateTransformPlugin{
+		name:   "aggregate_transform",
+		logger: logger,
+	}
+}
+
+// GetName 返回插件名稱
+func (a *AggregateTransformPlugin) GetName() string {
+	return a.name
+}
+
+// Init 初始化插件，聚合參數由每個管線階段的配置提供
+func (a *AggregateTransformPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	return nil
+}
+
+// Start 啟動插件
+func (a *AggregateTransformPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 停止插件
+func (a *AggregateTransformPlugin) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Transform 按配置聚合記錄；缺少 timestamp 的記錄返回錯誤，欄位缺失或非數值的記錄在該欄位上被忽略
+func (a *AggregateTransformPlugin) Transform(ctx context.Context, records []map[string]interface{}, transformConfig map[string]interface{}) ([]map[string]interface{}, error) {
+	config, err := parseAggregateConfig(transformConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[string]*aggregateBucket)
+	for i, record := range records {
+		at, err := plugins.RecordTimestamp(record)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 條記錄: %w", i, err)
+		}
+		start := at.UTC().Truncate(config.Window)
+
+		group := make(map[string]interface{}, len(config.GroupBy))
+		keyParts := []string{start.Format(time.RFC3339Nano)}
+		for _, field := range config.GroupBy {
+			group[field] = record[field]
+			keyParts = append(keyParts, fmt.Sprintf("%v", record[field]))
+		}
+		key := strings.Join(keyParts, "\x00")
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &aggregateBucket{
+				start:  start,
+				group:  group,
+				key:    key,
+				sums:   make(map[string]float64),
+				mins:   make(map[string]float64),
+				maxs:   make(map[string]float64),
+				counts: make(map[string]int),
+			}
+			buckets[key] = bucket
+		}
+		bucket.count++
+
+		for _, field := range config.Fields {
+			value, ok := toFloat(record[field])
+			if !ok {
+				continue
+			}
+			if bucket.counts[field] == 0 {
+				bucket.mins[field] = value
+				bucket.maxs[field] = value
+			}
+			bucket.sums[field] += value
+			bucket.mins[field] = math.Min(bucket.mins[field], value)
+			bucket.maxs[field] = math.Max(bucket.maxs[field], value)
+			bucket.counts[field]++
+		}
+	}
+
+	ordered := make([]*aggregateBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		ordered = append(ordered, bucket)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].start.Equal(ordered[j].start) {
+			return ordered[i].start.Before(ordered[j].start)
+		}
+		return ordered[i].key < ordered[j].key
+	})
+
+	out := make([]map[string]interface{}, 0, len(ordered))
+	for _, bucket := range ordered {
+		record := map[string]interface{}{
+			plugins.RecordTimestampField: bucket.start,
+			"count":                      bucket.count,
+		}
+		for field, value := range bucket.group {
+			record[field] = value
+		}
+		for _, field := range config.Fields {
+			if bucket.counts[field] == 0 && config.Function != "count" {
+				continue
+			}
+			switch config.Function {
+			case "avg":
+				record[field] = bucket.sums[field] / float64(bucket.counts[field])
+			case "sum":
+				record[field] = bucket.sums[field]
+			case "min":
+				record[field] = bucket.mins[field]
+			case "max":
+				record[field] = bucket.maxs[field]
+			case "count":
+				record[field] = float64(bucket.counts[field])
+			}
+		}
+		out = append(out, record)
+	}
+
+	a.logger.Debug("聚合轉換完成", "plugin", a.name, "input", len(records), "output", len(out))
+	return out, nil
+}
+
+// parseAggregateConfig 解析並驗證階段配置
+func parseAggregateConfig(cfg map[string]interface{}) (AggregateTransformConfig, error) {
+	config := AggregateTransformConfig{Window: time.Minute, Function: "avg"}
+
+	if window, ok := cfg["window"].(string); ok {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("無效的 window: %q", window)
+		}
+		config.Window = d
+	}
+	if function, ok := cfg["function"].(string); ok {
+		config.Function = function
+	}
+	if !aggregateFunctions[config.Function] {
+		return config, fmt.Errorf("不支持的聚合函數: %s", config.Function)
+	}
+	config.Fields = stringList(cfg["fields"])
+	config.GroupBy = stringList(cfg["group_by"])
+	if len(config.Fields) == 0 {
+		return config, fmt.Errorf("fields 不能為空")
+	}
+	return config, nil
+}
+
+// stringList 將 []interface{} 或 []string 配置值轉換為字符串切片
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// toFloat 將數值或數字字符串轉換為 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// 確保實現了 TransformPlugin 介面
+var _ plugins.TransformPlugin = (*AggregateTransformPlugin)(nil)
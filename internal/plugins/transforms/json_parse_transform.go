@@ -0,0 +1,93 @@
+package transforms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"detectviz-platform/pkg/domain/interfaces/plugins"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// JSONParseTransformPlugin 實現將字符串欄位解析為 JSON 物件的轉換
+// 職責: 把日誌等半結構化記錄中的 JSON 字符串展開為頂層欄位，供後續聚合與偵測使用
+type JSONParseTransformPlugin struct {
+	name   string
+	logger contracts.Logger
+}
+
+// NewJSONParseTransformPlugin 創建新的 JSON 解析轉換插件實例
+func NewJSONParseTransformPlugin(logger contracts.Logger) plugins.TransformPlugin {
+	return &JSONParseTransformPlugin{
+		name:   "json_parse_transform",
+		logger: logger,
+	}
+}
+
+// GetName 返回插件名稱
+func (j *JSONParseTransformPlugin) GetName() string {
+	return j.name
+}
+
+// Init 初始化插件，解析參數由每個管線階段的配置提供
+func (j *JSONParseTransformPlugin) Init(ctx context.Context, cfg map[string]interface{}) error {
+	return nil
+}
+
+// Start 啟動插件
+func (j *JSONParseTransformPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 停止插件
+func (j *JSONParseTransformPlugin) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Transform 解析 field 欄位 (默認 "message") 的 JSON 並合併到記錄副本中。
+// drop_invalid 為 true 時丟棄無法解析的記錄，否則返回錯誤；keep_source 為 false 時移除原始欄位。
+func (j *JSONParseTransformPlugin) Transform(ctx context.Context, records []map[string]interface{}, transformConfig map[string]interface{}) ([]map[string]interface{}, error) {
+	field := "message"
+	if f, ok := transformConfig["field"].(string); ok && f != "" {
+		field = f
+	}
+	keepSource, _ := transformConfig["keep_source"].(bool)
+	dropInvalid, _ := transformConfig["drop_invalid"].(bool)
+
+	out := make([]map[string]interface{}, 0, len(records))
+	for i, record := range records {
+		var raw []byte
+		switch v := record[field].(type) {
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		}
+
+		var parsed map[string]interface{}
+		if err := json.Unmarshal(raw, &parsed); err != nil || parsed == nil {
+			if dropInvalid {
+				continue
+			}
+			return nil, fmt.Errorf("第 %d 條記錄的 %s 欄位不是 JSON 物件", i, field)
+		}
+
+		merged := make(map[string]interface{}, len(record)+len(parsed))
+		for k, v := range record {
+			merged[k] = v
+		}
+		if !keepSource {
+			delete(merged, field)
+		}
+		for k, v := range parsed {
+			merged[k] = v
+		}
+		out = append(out, merged)
+	}
+
+	j.logger.Debug("JSON 解析轉換完成", "plugin", j.name, "input", len(records), "output", len(out))
+	return out, nil
+}
+
+// 確保實現了 TransformPlugin 介面
+var _ plugins.TransformPlugin = (*JSONParseTransformPlugin)(nil)
@@ -0,0 +1,96 @@
+package transforms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, fields ...interface{})           {}
+func (l *testLogger) Info(msg string, fields ...interface{})            {}
+func (l *testLogger) Warn(msg string, fields ...interface{})            {}
+func (l *testLogger) Error(msg string, fields ...interface{})           {}
+func (l *testLogger) Fatal(msg string, fields ...interface{})           {}
+func (l *testLogger) WithFields(fields ...interface{}) contracts.Logger { return l }
+func (l *testLogger) WithContext(ctx interface{}) contracts.Logger      { return l }
+func (l *testLogger) GetName() string                                   { return "test_logger" }
+
+func TestAggregateTransform(t *testing.T) {
+	plugin := NewAggregateTransformPlugin(&testLogger{})
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	records := []map[string]interface{}{
+		{"timestamp": base.Add(70 * time.Second), "host": "b", "latency": 30.0},
+		{"timestamp": base.Add(10 * time.Second), "host": "a", "latency": 10.0},
+		{"timestamp": base.Add(40 * time.Second), "host": "a", "latency": "20"},
+		{"timestamp": base.Add(50 * time.Second), "host": "b", "latency": 5.0},
+		{"timestamp": base.Add(80 * time.Second), "host": "b"},
+	}
+
+	out, err := plugin.Transform(context.Background(), records, map[string]interface{}{
+		"window":   "1m",
+		"fields":   []interface{}{"latency"},
+		"function": "avg",
+		"group_by": []interface{}{"host"},
+	})
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+
+	want := []struct {
+		start   time.Time
+		host    string
+		latency float64
+		count   int
+	}{
+		{base, "a", 15, 2},
+		{base, "b", 5, 1},
+		{base.Add(time.Minute), "b", 30, 2},
+	}
+	if len(out) != len(want) {
+		t.Fatalf("got %d records, want %d: %v", len(out), len(want), out)
+	}
+	for i, w := range want {
+		if !out[i]["timestamp"].(time.Time).Equal(w.start) || out[i]["host"] != w.host ||
+			out[i]["latency"] != w.latency || out[i]["count"] != w.count {
+			t.Errorf("record %d = %v, want %+v", i, out[i], w)
+		}
+	}
+
+	if _, err := plugin.Transform(context.Background(), records, map[string]interface{}{"fields": []interface{}{"latency"}, "function": "p99"}); err == nil {
+		t.Error("expected error for unsupported function")
+	}
+	if _, err := plugin.Transform(context.Background(), []map[string]interface{}{{"latency": 1.0}}, map[string]interface{}{"fields": []interface{}{"latency"}}); err == nil {
+		t.Error("expected error for record without timestamp")
+	}
+}
+
+func TestJSONParseTransform(t *testing.T) {
+	plugin := NewJSONParseTransformPlugin(&testLogger{})
+	records := []map[string]interface{}{
+		{"timestamp": "2026-01-01T10:00:00Z", "message": `{"latency": 12.5, "host": "a"}`},
+		{"timestamp": "2026-01-01T10:00:01Z", "message": "not json"},
+	}
+
+	if _, err := plugin.Transform(context.Background(), records, map[string]interface{}{}); err == nil {
+		t.Fatal("expected error for invalid JSON without drop_invalid")
+	}
+
+	out, err := plugin.Transform(context.Background(), records, map[string]interface{}{"drop_invalid": true})
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if len(out) != 1 || out[0]["latency"] != 12.5 || out[0]["host"] != "a" {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	if _, ok := out[0]["message"]; ok {
+		t.Error("source field should be removed unless keep_source is set")
+	}
+	if _, ok := records[0]["latency"]; ok {
+		t.Error("input record must not be modified")
+	}
+}
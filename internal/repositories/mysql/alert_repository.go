@@ -0,0 +1,300 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// AlertRepository 實現了 interfaces.AlertRepository 介面
+// 職責: 以指紋為主鍵保存告警狀態，標籤、最近一次結果數據與升級進度以 JSON 保存
+type AlertRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewAlertRepository 創建新的告警倉儲實例
+func NewAlertRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.AlertRepository {
+	return &AlertRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const alertColumns = `fingerprint, detector_id, labels, state, severity, summary, data, analysis_result_id,
+	starts_at, fired_at, last_seen_at, resolved_at, silenced_by, acknowledged_at, acknowledged_by, escalation, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *AlertRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新告警
+func (r *AlertRepository) Save(ctx context.Context, alert *entities.Alert) error {
+	labels, err := json.Marshal(nonNilLabels(alert.Labels))
+	if err != nil {
+		return fmt.Errorf("failed to encode alert labels: %w", err)
+	}
+	data, err := json.Marshal(nonNilMap(alert.Data))
+	if err != nil {
+		return fmt.Errorf("failed to encode alert data: %w", err)
+	}
+	var silencedBy sql.NullString
+	if len(alert.SilencedBy) > 0 {
+		encoded, err := json.Marshal(alert.SilencedBy)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert silences: %w", err)
+		}
+		silencedBy = sql.NullString{String: string(encoded), Valid: true}
+	}
+	var escalation sql.NullString
+	if alert.Escalation != nil {
+		encoded, err := json.Marshal(alert.Escalation)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert escalation: %w", err)
+		}
+		escalation = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	query := `INSERT INTO alerts (` + alertColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "fingerprint",
+		"state", "severity", "summary", "data", "analysis_result_id", "starts_at", "fired_at",
+		"last_seen_at", "resolved_at", "silenced_by", "acknowledged_at", "acknowledged_by", "escalation",
+		"updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, alert.Fingerprint, alert.DetectorID, string(labels), alert.State,
+		alert.Severity, alert.Summary, string(data), alert.AnalysisResultID, toDBTime(alert.StartsAt),
+		nullableTime(alert.FiredAt), toDBTime(alert.LastSeenAt), nullableTime(alert.ResolvedAt), silencedBy,
+		nullableTime(alert.AcknowledgedAt), alert.AcknowledgedBy, escalation, toDBTime(alert.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存告警失敗", "fingerprint", alert.Fingerprint, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByFingerprint 根據指紋獲取告警，不存在時返回 nil
+func (r *AlertRepository) GetByFingerprint(ctx context.Context, fingerprint string) (*entities.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE fingerprint = ?`
+
+	alert, err := scanAlert(r.executor(ctx).QueryRowContext(ctx, query, fingerprint))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找告警失敗", "fingerprint", fingerprint, "error", err)
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ListByState 列出處於指定狀態的告警
+func (r *AlertRepository) ListByState(ctx context.Context, states ...string) ([]*entities.Alert, error) {
+	if len(states) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(states)), ", ")
+	args := make([]interface{}, len(states))
+	for i, state := range states {
+		args[i] = state
+	}
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE state IN (` + placeholders + `) ORDER BY starts_at`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出告警失敗", "states", states, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []*entities.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			r.logger.Error("掃描告警失敗", "error", err)
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// scanAlert 從一行記錄解析告警
+func scanAlert(row rowScanner) (*entities.Alert, error) {
+	var (
+		alert                           entities.Alert
+		labels, data                    string
+		summary, silencedBy, escalation sql.NullString
+		firedAt, resolvedAt, ackedAt    sql.NullTime
+		startsAt, lastSeenAt            time.Time
+	)
+	if err := row.Scan(&alert.Fingerprint, &alert.DetectorID, &labels, &alert.State, &alert.Severity, &summary, &data,
+		&alert.AnalysisResultID, &startsAt, &firedAt, &lastSeenAt, &resolvedAt, &silencedBy, &ackedAt, &alert.AcknowledgedBy,
+		&escalation, &alert.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(labels), &alert.Labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels of alert %s: %w", alert.Fingerprint, err)
+	}
+	if err := json.Unmarshal([]byte(data), &alert.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode data of alert %s: %w", alert.Fingerprint, err)
+	}
+	if silencedBy.Valid && silencedBy.String != "" {
+		if err := json.Unmarshal([]byte(silencedBy.String), &alert.SilencedBy); err != nil {
+			return nil, fmt.Errorf("failed to decode silences of alert %s: %w", alert.Fingerprint, err)
+		}
+	}
+	if escalation.Valid && escalation.String != "" {
+		if err := json.Unmarshal([]byte(escalation.String), &alert.Escalation); err != nil {
+			return nil, fmt.Errorf("failed to decode escalation of alert %s: %w", alert.Fingerprint, err)
+		}
+	}
+	alert.Summary = summary.String
+	alert.AcknowledgedAt = fromNullTime(ackedAt)
+	alert.StartsAt = startsAt.UTC()
+	alert.FiredAt = fromNullTime(firedAt)
+	alert.LastSeenAt = lastSeenAt.UTC()
+	alert.ResolvedAt = fromNullTime(resolvedAt)
+	alert.UpdatedAt = alert.UpdatedAt.UTC()
+	return &alert, nil
+}
+
+// AlertGroupRepository 實現了 interfaces.AlertGroupRepository 介面
+// 職責: 保存告警分組的通知節奏與每個成員最後一次通知時的狀態
+type AlertGroupRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewAlertGroupRepository 創建新的告警分組倉儲實例
+func NewAlertGroupRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.AlertGroupRepository {
+	return &AlertGroupRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const alertGroupColumns = `group_key, route_id, receiver, labels, members, next_flush_at, last_notified_at, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *AlertGroupRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新分組
+func (r *AlertGroupRepository) Save(ctx context.Context, group *entities.AlertGroup) error {
+	labels, err := json.Marshal(nonNilLabels(group.Labels))
+	if err != nil {
+		return fmt.Errorf("failed to encode alert group labels: %w", err)
+	}
+	members, err := json.Marshal(nonNilLabels(group.Members))
+	if err != nil {
+		return fmt.Errorf("failed to encode alert group members: %w", err)
+	}
+
+	query := `INSERT INTO alert_groups (` + alertGroupColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "group_key",
+		"members", "next_flush_at", "last_notified_at", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, group.Key, group.RouteID, group.Receiver, string(labels), string(members),
+		toDBTime(group.NextFlushAt), nullableTime(group.LastNotifiedAt), toDBTime(group.CreatedAt), toDBTime(group.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存告警分組失敗", "group_key", group.Key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Get 根據分組標識獲取分組，不存在時返回 nil
+func (r *AlertGroupRepository) Get(ctx context.Context, key string) (*entities.AlertGroup, error) {
+	query := `SELECT ` + alertGroupColumns + ` FROM alert_groups WHERE group_key = ?`
+
+	group, err := scanAlertGroup(r.executor(ctx).QueryRowContext(ctx, query, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找告警分組失敗", "group_key", key, "error", err)
+		return nil, err
+	}
+	return group, nil
+}
+
+// ListDue 列出 next_flush_at 不晚於 now 的分組
+func (r *AlertGroupRepository) ListDue(ctx context.Context, now time.Time) ([]*entities.AlertGroup, error) {
+	query := `SELECT ` + alertGroupColumns + ` FROM alert_groups WHERE next_flush_at <= ? ORDER BY next_flush_at`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, toDBTime(now))
+	if err != nil {
+		r.logger.Error("列出到期告警分組失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*entities.AlertGroup
+	for rows.Next() {
+		group, err := scanAlertGroup(rows)
+		if err != nil {
+			r.logger.Error("掃描告警分組失敗", "error", err)
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// Delete 刪除分組
+func (r *AlertGroupRepository) Delete(ctx context.Context, key string) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM alert_groups WHERE group_key = ?`, key); err != nil {
+		r.logger.Error("刪除告警分組失敗", "group_key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// scanAlertGroup 從一行記錄解析告警分組
+func scanAlertGroup(row rowScanner) (*entities.AlertGroup, error) {
+	var (
+		group          entities.AlertGroup
+		labels         string
+		members        string
+		lastNotifiedAt sql.NullTime
+	)
+	if err := row.Scan(&group.Key, &group.RouteID, &group.Receiver, &labels, &members, &group.NextFlushAt, &lastNotifiedAt,
+		&group.CreatedAt, &group.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(labels), &group.Labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels of alert group %s: %w", group.Key, err)
+	}
+	if err := json.Unmarshal([]byte(members), &group.Members); err != nil {
+		return nil, fmt.Errorf("failed to decode members of alert group %s: %w", group.Key, err)
+	}
+	group.NextFlushAt = group.NextFlushAt.UTC()
+	group.LastNotifiedAt = fromNullTime(lastNotifiedAt)
+	group.CreatedAt = group.CreatedAt.UTC()
+	group.UpdatedAt = group.UpdatedAt.UTC()
+	return &group, nil
+}
+
+// nonNilLabels 確保標籤 JSON 編碼結果為物件而非 null
+func nonNilLabels(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,151 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// AnalysisResultRepository 實現了 interfaces.AnalysisResultRepository 介面
+// 職責: 提供分析結果的 MySQL 數據庫操作，詳細數據以 JSON 保存
+type AnalysisResultRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewAnalysisResultRepository 創建新的分析結果倉儲實例
+func NewAnalysisResultRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.AnalysisResultRepository {
+	return &AnalysisResultRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const analysisResultColumns = `id, detector_id, result_timestamp, severity, summary, data`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *AnalysisResultRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Create 創建新分析結果
+func (r *AnalysisResultRepository) Create(ctx context.Context, result *entities.AnalysisResult) error {
+	data, err := json.Marshal(nonNilMap(result.Data))
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis result data: %w", err)
+	}
+
+	query := `INSERT INTO analysis_results (` + analysisResultColumns + `) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = r.executor(ctx).ExecContext(ctx, query, result.ID, result.DetectorID, toDBTime(result.Timestamp),
+		result.Severity, result.Summary, string(data))
+	if err != nil {
+		r.logger.Error("創建分析結果失敗", "result_id", result.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根據 ID 獲取分析結果，不存在時返回 nil
+func (r *AnalysisResultRepository) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.AnalysisResult, error) {
+	query := `SELECT ` + analysisResultColumns + ` FROM analysis_results WHERE id = ?`
+
+	result, err := scanAnalysisResult(r.executor(ctx).QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找分析結果失敗", "result_id", id.String(), "error", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// Update 更新分析結果
+func (r *AnalysisResultRepository) Update(ctx context.Context, result *entities.AnalysisResult) error {
+	data, err := json.Marshal(nonNilMap(result.Data))
+	if err != nil {
+		return fmt.Errorf("failed to encode analysis result data: %w", err)
+	}
+
+	query := `UPDATE analysis_results SET detector_id = ?, result_timestamp = ?, severity = ?, summary = ?, data = ?
+			  WHERE id = ?`
+	_, err = r.executor(ctx).ExecContext(ctx, query, result.DetectorID, toDBTime(result.Timestamp), result.Severity,
+		result.Summary, string(data), result.ID)
+	if err != nil {
+		r.logger.Error("更新分析結果失敗", "result_id", result.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Delete 刪除分析結果
+func (r *AnalysisResultRepository) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM analysis_results WHERE id = ?`, id.String()); err != nil {
+		r.logger.Error("刪除分析結果失敗", "result_id", id.String(), "error", err)
+		return err
+	}
+	return nil
+}
+
+// List 列出分析結果，按結果時間倒序
+func (r *AnalysisResultRepository) List(ctx context.Context, offset, limit int) ([]*entities.AnalysisResult, error) {
+	query := `SELECT ` + analysisResultColumns + ` FROM analysis_results ORDER BY result_timestamp DESC`
+	args := []interface{}{}
+
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	if offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, offset)
+	}
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出分析結果失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*entities.AnalysisResult
+	for rows.Next() {
+		result, err := scanAnalysisResult(rows)
+		if err != nil {
+			r.logger.Error("掃描分析結果失敗", "error", err)
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// scanAnalysisResult 從一行記錄解析分析結果
+func scanAnalysisResult(row rowScanner) (*entities.AnalysisResult, error) {
+	var (
+		result  entities.AnalysisResult
+		summary sql.NullString
+		data    string
+	)
+	if err := row.Scan(&result.ID, &result.DetectorID, &result.Timestamp, &result.Severity, &summary, &data); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(data), &result.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode analysis result %s: %w", result.ID, err)
+	}
+	result.Timestamp = result.Timestamp.UTC()
+	result.Summary = summary.String
+	return &result, nil
+}
+
+// 確保實現了 AnalysisResultRepository 介面
+var _ interfaces.AnalysisResultRepository = (*AnalysisResultRepository)(nil)
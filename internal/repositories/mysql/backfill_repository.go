@@ -0,0 +1,239 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// BackfillJobRepository 實現了 interfaces.BackfillJobRepository 介面
+// 職責: 提供回放任務的 MySQL 數據庫操作，比較報告以 JSON 保存
+type BackfillJobRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewBackfillJobRepository 創建新的回放任務倉儲實例
+func NewBackfillJobRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.BackfillJobRepository {
+	return &BackfillJobRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const backfillJobColumns = `id, detector_id, revision, baseline_revision, range_start, range_end, status,
+	processed_until, report, error, created_by, created_at, started_at, finished_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *BackfillJobRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Create 保存新任務
+func (r *BackfillJobRepository) Create(ctx context.Context, job *entities.BackfillJob) error {
+	report, err := encodeBackfillReport(job.Report)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO backfill_jobs (` + backfillJobColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.executor(ctx).ExecContext(ctx, query, job.ID, job.DetectorID, job.Revision, job.BaselineRevision,
+		toDBTime(job.RangeStart), toDBTime(job.RangeEnd), job.Status, nullableTime(job.ProcessedUntil), report,
+		job.Error, job.CreatedBy, toDBTime(job.CreatedAt), nullableTime(job.StartedAt), nullableTime(job.FinishedAt))
+	if err != nil {
+		r.logger.Error("保存回放任務失敗", "job_id", job.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Update 更新任務的狀態、進度與報告
+func (r *BackfillJobRepository) Update(ctx context.Context, job *entities.BackfillJob) error {
+	report, err := encodeBackfillReport(job.Report)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE backfill_jobs SET baseline_revision = ?, status = ?, processed_until = ?, report = ?, error = ?,
+			  started_at = ?, finished_at = ? WHERE id = ?`
+	_, err = r.executor(ctx).ExecContext(ctx, query, job.BaselineRevision, job.Status, nullableTime(job.ProcessedUntil),
+		report, job.Error, nullableTime(job.StartedAt), nullableTime(job.FinishedAt), job.ID)
+	if err != nil {
+		r.logger.Error("更新回放任務失敗", "job_id", job.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 獲取任務，不存在時返回 nil
+func (r *BackfillJobRepository) GetByID(ctx context.Context, id string) (*entities.BackfillJob, error) {
+	query := `SELECT ` + backfillJobColumns + ` FROM backfill_jobs WHERE id = ?`
+
+	var (
+		job                                   entities.BackfillJob
+		processedUntil, startedAt, finishedAt sql.NullTime
+		report, errMsg                        sql.NullString
+	)
+	err := r.executor(ctx).QueryRowContext(ctx, query, id).Scan(&job.ID, &job.DetectorID, &job.Revision,
+		&job.BaselineRevision, &job.RangeStart, &job.RangeEnd, &job.Status, &processedUntil, &report, &errMsg,
+		&job.CreatedBy, &job.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找回放任務失敗", "job_id", id, "error", err)
+		return nil, err
+	}
+
+	if report.Valid && report.String != "" {
+		job.Report = &entities.BackfillReport{}
+		if err := json.Unmarshal([]byte(report.String), job.Report); err != nil {
+			return nil, fmt.Errorf("failed to decode report of backfill job %s: %w", id, err)
+		}
+	}
+	job.RangeStart = job.RangeStart.UTC()
+	job.RangeEnd = job.RangeEnd.UTC()
+	job.CreatedAt = job.CreatedAt.UTC()
+	job.ProcessedUntil = fromNullTime(processedUntil)
+	job.StartedAt = fromNullTime(startedAt)
+	job.FinishedAt = fromNullTime(finishedAt)
+	job.Error = errMsg.String
+	return &job, nil
+}
+
+// SimulationResultRepository 實現了 interfaces.SimulationResultRepository 介面
+// 職責: 將回放結果寫入獨立的 simulation_results 表，與線上結果及告警流程隔離
+type SimulationResultRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewSimulationResultRepository 創建新的模擬結果倉儲實例
+func NewSimulationResultRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.SimulationResultRepository {
+	return &SimulationResultRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *SimulationResultRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// SaveBatch 以單條多值 INSERT 批量保存回放結果
+func (r *SimulationResultRepository) SaveBatch(ctx context.Context, results []*entities.SimulationResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(results))
+	args := make([]interface{}, 0, len(results)*8)
+	for _, sr := range results {
+		data, err := json.Marshal(nonNilMap(sr.Result.Data))
+		if err != nil {
+			return fmt.Errorf("failed to encode simulation result data: %w", err)
+		}
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, sr.Result.ID, sr.JobID, sr.Variant, sr.Result.DetectorID, toDBTime(sr.Result.Timestamp),
+			sr.Result.Severity, sr.Result.Summary, string(data))
+	}
+
+	query := `INSERT INTO simulation_results (id, job_id, variant, detector_id, result_timestamp, severity, summary, data)
+			  VALUES ` + strings.Join(placeholders, ", ")
+	if _, err := r.executor(ctx).ExecContext(ctx, query, args...); err != nil {
+		r.logger.Error("保存模擬結果失敗", "job_id", results[0].JobID, "count", len(results), "error", err)
+		return err
+	}
+	return nil
+}
+
+// ListByJob 按時間順序列出任務的結果，variant 為空時返回所有配置的結果
+func (r *SimulationResultRepository) ListByJob(ctx context.Context, jobID, variant string, offset, limit int) ([]*entities.SimulationResult, error) {
+	query := `SELECT id, job_id, variant, detector_id, result_timestamp, severity, summary, data
+			  FROM simulation_results WHERE job_id = ?`
+	args := []interface{}{jobID}
+	if variant != "" {
+		query += ` AND variant = ?`
+		args = append(args, variant)
+	}
+	query += ` ORDER BY result_timestamp, variant LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出模擬結果失敗", "job_id", jobID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*entities.SimulationResult
+	for rows.Next() {
+		var (
+			sr      entities.SimulationResult
+			result  entities.AnalysisResult
+			summary sql.NullString
+			data    string
+		)
+		if err := rows.Scan(&result.ID, &sr.JobID, &sr.Variant, &result.DetectorID, &result.Timestamp,
+			&result.Severity, &summary, &data); err != nil {
+			r.logger.Error("掃描模擬結果失敗", "error", err)
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &result.Data); err != nil {
+			return nil, fmt.Errorf("failed to decode simulation result %s: %w", result.ID, err)
+		}
+		result.Timestamp = result.Timestamp.UTC()
+		result.Summary = summary.String
+		sr.Result = &result
+		results = append(results, &sr)
+	}
+	return results, rows.Err()
+}
+
+// encodeBackfillReport 將報告編碼為 JSON，nil 時返回 NULL
+func encodeBackfillReport(report *entities.BackfillReport) (interface{}, error) {
+	if report == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backfill report: %w", err)
+	}
+	return string(data), nil
+}
+
+// nullableTime 將零值時間轉換為 NULL
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return toDBTime(t)
+}
+
+// fromNullTime 將可為 NULL 的時間轉換為 UTC，NULL 時返回零值
+func fromNullTime(t sql.NullTime) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+	return t.Time.UTC()
+}
+
+// 確保實現了相應的倉儲介面
+var (
+	_ interfaces.BackfillJobRepository      = (*BackfillJobRepository)(nil)
+	_ interfaces.SimulationResultRepository = (*SimulationResultRepository)(nil)
+)
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DetectorConfigRevisionRepository 實現了 interfaces.DetectorConfigRevisionRepository 介面
+// 職責: 提供檢測器配置版本的 MySQL 數據庫操作，版本一經創建不再修改，只切換生效標記
+type DetectorConfigRevisionRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewDetectorConfigRevisionRepository 創建新的檢測器配置版本倉儲實例
+func NewDetectorConfigRevisionRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.DetectorConfigRevisionRepository {
+	return &DetectorConfigRevisionRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const detectorConfigRevisionColumns = `detector_id, revision, plugin, config, comment, created_by, active, created_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *DetectorConfigRevisionRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Create 保存新版本
+func (r *DetectorConfigRevisionRepository) Create(ctx context.Context, revision *entities.DetectorConfigRevision) error {
+	config, err := json.Marshal(nonNilMap(revision.Config))
+	if err != nil {
+		return fmt.Errorf("failed to encode detector config: %w", err)
+	}
+
+	query := `INSERT INTO detector_config_revisions (` + detectorConfigRevisionColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.executor(ctx).ExecContext(ctx, query, revision.DetectorID, revision.Revision, revision.Plugin,
+		string(config), revision.Comment, revision.CreatedBy, revision.Active, toDBTime(revision.CreatedAt))
+	if err != nil {
+		r.logger.Error("保存檢測器配置版本失敗", "detector_id", revision.DetectorID, "revision", revision.Revision, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Get 獲取指定版本，不存在時返回 nil
+func (r *DetectorConfigRevisionRepository) Get(ctx context.Context, detectorID string, revision int) (*entities.DetectorConfigRevision, error) {
+	query := `SELECT ` + detectorConfigRevisionColumns + ` FROM detector_config_revisions WHERE detector_id = ? AND revision = ?`
+	return r.getOne(ctx, query, detectorID, revision)
+}
+
+// GetActive 獲取目前生效的版本，沒有時返回 nil
+func (r *DetectorConfigRevisionRepository) GetActive(ctx context.Context, detectorID string) (*entities.DetectorConfigRevision, error) {
+	query := `SELECT ` + detectorConfigRevisionColumns + ` FROM detector_config_revisions WHERE detector_id = ? AND active = TRUE`
+	return r.getOne(ctx, query, detectorID)
+}
+
+// LatestRevision 返回最新的版本號，沒有任何版本時返回 0
+func (r *DetectorConfigRevisionRepository) LatestRevision(ctx context.Context, detectorID string) (int, error) {
+	var latest sql.NullInt64
+	err := r.executor(ctx).QueryRowContext(ctx,
+		`SELECT MAX(revision) FROM detector_config_revisions WHERE detector_id = ?`, detectorID).Scan(&latest)
+	if err != nil {
+		r.logger.Error("查詢最新檢測器配置版本失敗", "detector_id", detectorID, "error", err)
+		return 0, err
+	}
+	return int(latest.Int64), nil
+}
+
+// List 按版本號倒序列出所有版本
+func (r *DetectorConfigRevisionRepository) List(ctx context.Context, detectorID string) ([]*entities.DetectorConfigRevision, error) {
+	query := `SELECT ` + detectorConfigRevisionColumns + ` FROM detector_config_revisions
+			  WHERE detector_id = ? ORDER BY revision DESC`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, detectorID)
+	if err != nil {
+		r.logger.Error("列出檢測器配置版本失敗", "detector_id", detectorID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*entities.DetectorConfigRevision
+	for rows.Next() {
+		revision, err := scanDetectorConfigRevision(rows)
+		if err != nil {
+			r.logger.Error("掃描檢測器配置版本失敗", "error", err)
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// SetActive 將指定版本標記為生效；應在事務中調用，避免短暫出現沒有生效版本的狀態
+func (r *DetectorConfigRevisionRepository) SetActive(ctx context.Context, detectorID string, revision int) error {
+	_, err := r.executor(ctx).ExecContext(ctx,
+		`UPDATE detector_config_revisions SET active = (revision = ?) WHERE detector_id = ?`, revision, detectorID)
+	if err != nil {
+		r.logger.Error("切換生效的檢測器配置版本失敗", "detector_id", detectorID, "revision", revision, "error", err)
+		return err
+	}
+	return nil
+}
+
+// getOne 查詢單個版本，不存在時返回 nil
+func (r *DetectorConfigRevisionRepository) getOne(ctx context.Context, query string, args ...interface{}) (*entities.DetectorConfigRevision, error) {
+	revision, err := scanDetectorConfigRevision(r.executor(ctx).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找檢測器配置版本失敗", "error", err)
+		return nil, err
+	}
+	return revision, nil
+}
+
+// scanDetectorConfigRevision 從一行記錄解析配置版本
+func scanDetectorConfigRevision(row rowScanner) (*entities.DetectorConfigRevision, error) {
+	var (
+		rev     entities.DetectorConfigRevision
+		config  string
+		comment sql.NullString
+	)
+	if err := row.Scan(&rev.DetectorID, &rev.Revision, &rev.Plugin, &config, &comment, &rev.CreatedBy,
+		&rev.Active, &rev.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(config), &rev.Config); err != nil {
+		return nil, fmt.Errorf("failed to decode config of %s revision %d: %w", rev.DetectorID, rev.Revision, err)
+	}
+	rev.Comment = comment.String
+	rev.CreatedAt = rev.CreatedAt.UTC()
+	return &rev, nil
+}
+
+// 確保實現了 DetectorConfigRevisionRepository 介面
+var _ interfaces.DetectorConfigRevisionRepository = (*DetectorConfigRevisionRepository)(nil)
@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/domain/valueobjects"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DetectorRepository 實現了 interfaces.DetectorRepository 介面
+// 職責: 提供檢測器實體的 MySQL 數據庫操作，並自動加入 ctx 中進行中的事務
+type DetectorRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewDetectorRepository 創建新的檢測器倉儲實例
+func NewDetectorRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.DetectorRepository {
+	return &DetectorRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *DetectorRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Create 創建新檢測器
+func (r *DetectorRepository) Create(ctx context.Context, detector *entities.Detector) error {
+	query := `INSERT INTO detectors (id, name, description, owner_id, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := r.executor(ctx).ExecContext(ctx, query, detector.ID, detector.Name, detector.Description,
+		detector.OwnerID, detector.CreatedAt, detector.UpdatedAt)
+	if err != nil {
+		r.logger.Error("創建檢測器失敗", "detector_id", detector.ID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("檢測器創建成功", "detector_id", detector.ID)
+	return nil
+}
+
+// GetByID 根據 ID 查找檢測器，不存在時返回 nil
+func (r *DetectorRepository) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.Detector, error) {
+	query := `SELECT id, name, description, owner_id, created_at, updated_at FROM detectors WHERE id = ?`
+
+	var detector entities.Detector
+	row := r.executor(ctx).QueryRowContext(ctx, query, id.String())
+	err := row.Scan(&detector.ID, &detector.Name, &detector.Description, &detector.OwnerID,
+		&detector.CreatedAt, &detector.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("檢測器未找到", "detector_id", id.String())
+			return nil, nil
+		}
+		r.logger.Error("查找檢測器失敗", "detector_id", id.String(), "error", err)
+		return nil, err
+	}
+
+	return &detector, nil
+}
+
+// Update 更新檢測器
+func (r *DetectorRepository) Update(ctx context.Context, detector *entities.Detector) error {
+	query := `UPDATE detectors SET name = ?, description = ?, owner_id = ?, updated_at = ? WHERE id = ?`
+
+	_, err := r.executor(ctx).ExecContext(ctx, query, detector.Name, detector.Description, detector.OwnerID,
+		detector.UpdatedAt, detector.ID)
+	if err != nil {
+		r.logger.Error("更新檢測器失敗", "detector_id", detector.ID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("檢測器更新成功", "detector_id", detector.ID)
+	return nil
+}
+
+// Delete 刪除檢測器
+func (r *DetectorRepository) Delete(ctx context.Context, id valueobjects.IDVO) error {
+	query := `DELETE FROM detectors WHERE id = ?`
+
+	_, err := r.executor(ctx).ExecContext(ctx, query, id.String())
+	if err != nil {
+		r.logger.Error("刪除檢測器失敗", "detector_id", id.String(), "error", err)
+		return err
+	}
+
+	r.logger.Debug("檢測器刪除成功", "detector_id", id.String())
+	return nil
+}
+
+// List 按創建時間列出檢測器
+func (r *DetectorRepository) List(ctx context.Context, offset, limit int) ([]*entities.Detector, error) {
+	query := `SELECT id, name, description, owner_id, created_at, updated_at FROM detectors ORDER BY created_at`
+	args := []interface{}{}
+
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	if offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, offset)
+	}
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出檢測器失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var detectors []*entities.Detector
+	for rows.Next() {
+		var detector entities.Detector
+		if err := rows.Scan(&detector.ID, &detector.Name, &detector.Description, &detector.OwnerID,
+			&detector.CreatedAt, &detector.UpdatedAt); err != nil {
+			r.logger.Error("掃描檢測器記錄失敗", "error", err)
+			return nil, err
+		}
+		detectors = append(detectors, &detector)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("遍歷檢測器記錄失敗", "error", err)
+		return nil, err
+	}
+
+	return detectors, nil
+}
+
+// 確保實現了 DetectorRepository 介面
+var _ interfaces.DetectorRepository = (*DetectorRepository)(nil)
@@ -0,0 +1,216 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// DetectorScheduleRepository 實現了 interfaces.DetectorScheduleRepository 介面
+// 職責: 提供檢測器排程的 MySQL 數據庫操作，時間一律以 UTC 微秒精度保存
+type DetectorScheduleRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewDetectorScheduleRepository 創建新的檢測器排程倉儲實例
+func NewDetectorScheduleRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.DetectorScheduleRepository {
+	return &DetectorScheduleRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const detectorScheduleColumns = `detector_id, detector_plugin, detector_config, spec, source, source_query,
+	window_ms, jitter_ms, max_concurrency, missed_run_policy, enabled, next_run_at, last_run_at,
+	last_status, last_error, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *DetectorScheduleRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新排程配置；配置變更時清空 next_run_at，讓排程器按新表達式重新計算
+func (r *DetectorScheduleRepository) Save(ctx context.Context, schedule *entities.DetectorSchedule) error {
+	detectorConfig, err := json.Marshal(nonNilMap(schedule.DetectorConfig))
+	if err != nil {
+		return fmt.Errorf("failed to encode detector config: %w", err)
+	}
+	sourceQuery, err := json.Marshal(nonNilMap(schedule.SourceQuery))
+	if err != nil {
+		return fmt.Errorf("failed to encode source query: %w", err)
+	}
+
+	query := `INSERT INTO detector_schedules (detector_id, detector_plugin, detector_config, spec, source, source_query,
+			  window_ms, jitter_ms, max_concurrency, missed_run_policy, enabled, next_run_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?)
+			  ` + database.OnConflictPrefix(r.dialect, "detector_id") +
+		// 規格變更時清空下一次執行時間以按新規格重新計算；MySQL 按順序賦值，必須在更新 spec 之前比較
+		`next_run_at = CASE WHEN detector_schedules.spec = ` + database.Excluded(r.dialect, "spec") +
+		` THEN detector_schedules.next_run_at END, ` +
+		database.ConflictAssignments(r.dialect, "detector_plugin", "detector_config", "spec", "source", "source_query",
+			"window_ms", "jitter_ms", "max_concurrency", "missed_run_policy", "enabled", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, schedule.DetectorID, schedule.DetectorPlugin, string(detectorConfig),
+		schedule.Spec, schedule.Source, string(sourceQuery), schedule.Window.Milliseconds(), schedule.Jitter.Milliseconds(),
+		schedule.MaxConcurrency, schedule.MissedRunPolicy, schedule.Enabled, toDBTime(schedule.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存檢測器排程失敗", "detector_id", schedule.DetectorID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByDetectorID 根據檢測器 ID 獲取排程，不存在時返回 nil
+func (r *DetectorScheduleRepository) GetByDetectorID(ctx context.Context, detectorID string) (*entities.DetectorSchedule, error) {
+	query := `SELECT ` + detectorScheduleColumns + ` FROM detector_schedules WHERE detector_id = ?`
+
+	schedule, err := scanDetectorSchedule(r.executor(ctx).QueryRowContext(ctx, query, detectorID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找檢測器排程失敗", "detector_id", detectorID, "error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// ListEnabled 列出所有已啟用的排程
+func (r *DetectorScheduleRepository) ListEnabled(ctx context.Context) ([]*entities.DetectorSchedule, error) {
+	query := `SELECT ` + detectorScheduleColumns + ` FROM detector_schedules WHERE enabled = TRUE ORDER BY detector_id`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("列出檢測器排程失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*entities.DetectorSchedule
+	for rows.Next() {
+		schedule, err := scanDetectorSchedule(rows)
+		if err != nil {
+			r.logger.Error("掃描檢測器排程失敗", "error", err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// ClaimNextRun 僅當目前的 next_run_at 等於 expected 時將其推進到 next
+func (r *DetectorScheduleRepository) ClaimNextRun(ctx context.Context, detectorID string, expected, next time.Time) (bool, error) {
+	var (
+		result sql.Result
+		err    error
+	)
+	if expected.IsZero() {
+		result, err = r.executor(ctx).ExecContext(ctx,
+			`UPDATE detector_schedules SET next_run_at = ? WHERE detector_id = ? AND next_run_at IS NULL`,
+			toDBTime(next), detectorID)
+	} else {
+		result, err = r.executor(ctx).ExecContext(ctx,
+			`UPDATE detector_schedules SET next_run_at = ? WHERE detector_id = ? AND next_run_at = ?`,
+			toDBTime(next), detectorID, toDBTime(expected))
+	}
+	if err != nil {
+		r.logger.Error("認領檢測器排程失敗", "detector_id", detectorID, "error", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RecordRun 記錄一次執行的結果；last_run_at 只會向前推進，避免並行執行時較早的排程覆蓋較晚的結果
+func (r *DetectorScheduleRepository) RecordRun(ctx context.Context, detectorID string, scheduledAt time.Time, status, errMsg string) error {
+	query := `UPDATE detector_schedules SET
+			  last_run_at = CASE WHEN last_run_at IS NULL OR last_run_at < ? THEN ? ELSE last_run_at END,
+			  last_status = ?, last_error = ?
+			  WHERE detector_id = ?`
+
+	at := toDBTime(scheduledAt)
+	_, err := r.executor(ctx).ExecContext(ctx, query, at, at, status, errMsg, detectorID)
+	if err != nil {
+		r.logger.Error("記錄檢測器排程執行結果失敗", "detector_id", detectorID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Delete 刪除排程
+func (r *DetectorScheduleRepository) Delete(ctx context.Context, detectorID string) error {
+	_, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM detector_schedules WHERE detector_id = ?`, detectorID)
+	if err != nil {
+		r.logger.Error("刪除檢測器排程失敗", "detector_id", detectorID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// rowScanner 是 *sql.Row 與 *sql.Rows 共同的掃描介面
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDetectorSchedule 從一行記錄解析排程
+func scanDetectorSchedule(row rowScanner) (*entities.DetectorSchedule, error) {
+	var (
+		s                           entities.DetectorSchedule
+		detectorConfig, sourceQuery string
+		windowMs, jitterMs          int64
+		nextRunAt, lastRunAt        sql.NullTime
+		lastError                   sql.NullString
+	)
+	if err := row.Scan(&s.DetectorID, &s.DetectorPlugin, &detectorConfig, &s.Spec, &s.Source, &sourceQuery,
+		&windowMs, &jitterMs, &s.MaxConcurrency, &s.MissedRunPolicy, &s.Enabled, &nextRunAt, &lastRunAt,
+		&s.LastStatus, &lastError, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(detectorConfig), &s.DetectorConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode detector config of %s: %w", s.DetectorID, err)
+	}
+	if err := json.Unmarshal([]byte(sourceQuery), &s.SourceQuery); err != nil {
+		return nil, fmt.Errorf("failed to decode source query of %s: %w", s.DetectorID, err)
+	}
+	s.Window = time.Duration(windowMs) * time.Millisecond
+	s.Jitter = time.Duration(jitterMs) * time.Millisecond
+	if nextRunAt.Valid {
+		s.NextRunAt = nextRunAt.Time.UTC()
+	}
+	if lastRunAt.Valid {
+		s.LastRunAt = lastRunAt.Time.UTC()
+	}
+	s.LastError = lastError.String
+	return &s, nil
+}
+
+// toDBTime 將時間轉換為資料庫保存的 UTC 微秒精度
+func toDBTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// nonNilMap 確保 JSON 編碼結果為物件而非 null
+func nonNilMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+// 確保實現了 DetectorScheduleRepository 介面
+var _ interfaces.DetectorScheduleRepository = (*DetectorScheduleRepository)(nil)
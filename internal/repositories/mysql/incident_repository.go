@@ -0,0 +1,193 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// IncidentRepository 實現了 interfaces.IncidentRepository 介面
+// 職責: 保存事件單，成員告警、分析結果與時間線以 JSON 保存；
+// 成員告警另存於 incident_alerts 表，以便按告警指紋查找事件單
+type IncidentRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewIncidentRepository 創建新的事件單倉儲實例
+func NewIncidentRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.IncidentRepository {
+	return &IncidentRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const incidentColumns = `id, title, state, severity, assignee, group_key, alerts, analysis_result_ids, timeline,
+	acknowledged_at, acknowledged_by, resolved_at, resolved_by, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *IncidentRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新事件單，並為新的成員告警寫入 incident_alerts
+func (r *IncidentRepository) Save(ctx context.Context, incident *entities.Incident) error {
+	alerts, err := json.Marshal(nonNilStrings(incident.Alerts))
+	if err != nil {
+		return fmt.Errorf("failed to encode incident alerts: %w", err)
+	}
+	results, err := json.Marshal(nonNilStrings(incident.AnalysisResultIDs))
+	if err != nil {
+		return fmt.Errorf("failed to encode incident analysis results: %w", err)
+	}
+	timeline := incident.Timeline
+	if timeline == nil {
+		timeline = []entities.IncidentEvent{}
+	}
+	encodedTimeline, err := json.Marshal(timeline)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident timeline: %w", err)
+	}
+
+	query := `INSERT INTO incidents (` + incidentColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"title", "state", "severity", "assignee", "alerts", "analysis_result_ids", "timeline",
+		"acknowledged_at", "acknowledged_by", "resolved_at", "resolved_by", "updated_at")
+
+	executor := r.executor(ctx)
+	_, err = executor.ExecContext(ctx, query, incident.ID, incident.Title, incident.State, incident.Severity,
+		incident.Assignee, incident.GroupKey, string(alerts), string(results), string(encodedTimeline),
+		nullableTime(incident.AcknowledgedAt), incident.AcknowledgedBy, nullableTime(incident.ResolvedAt),
+		incident.ResolvedBy, toDBTime(incident.CreatedAt), toDBTime(incident.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存事件單失敗", "id", incident.ID, "error", err)
+		return err
+	}
+	// 已記錄的成員告警保持不變
+	memberQuery := `INSERT IGNORE INTO incident_alerts (incident_id, fingerprint, created_at) VALUES (?, ?, ?)`
+	if r.dialect == database.DialectPostgres {
+		memberQuery = `INSERT INTO incident_alerts (incident_id, fingerprint, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`
+	}
+	for _, fingerprint := range incident.Alerts {
+		if _, err := executor.ExecContext(ctx, memberQuery, incident.ID, fingerprint, toDBTime(incident.CreatedAt)); err != nil {
+			r.logger.Error("保存事件單成員告警失敗", "id", incident.ID, "fingerprint", fingerprint, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID 根據 ID 獲取事件單，不存在時返回 nil
+func (r *IncidentRepository) GetByID(ctx context.Context, id string) (*entities.Incident, error) {
+	return r.get(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = ?`, id)
+}
+
+// List 列出事件單，按開立時間倒序
+func (r *IncidentRepository) List(ctx context.Context, state string, limit int) ([]*entities.Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents`
+	var args []interface{}
+	if state != "" {
+		query += ` WHERE state = ?`
+		args = append(args, state)
+	}
+	query += ` ORDER BY created_at DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出事件單失敗", "state", state, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []*entities.Incident
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			r.logger.Error("掃描事件單失敗", "error", err)
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetLatestByAlert 返回包含該告警且最近開立的事件單
+func (r *IncidentRepository) GetLatestByAlert(ctx context.Context, fingerprint string) (*entities.Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents
+			  WHERE id IN (SELECT incident_id FROM incident_alerts WHERE fingerprint = ?)
+			  ORDER BY created_at DESC LIMIT 1`
+	return r.get(ctx, query, fingerprint)
+}
+
+// GetUnresolvedByGroup 返回由該告警分組自動開立且尚未解決的事件單
+func (r *IncidentRepository) GetUnresolvedByGroup(ctx context.Context, groupKey string) (*entities.Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents
+			  WHERE group_key = ? AND state <> ? ORDER BY created_at DESC LIMIT 1`
+	return r.get(ctx, query, groupKey, entities.IncidentStateResolved)
+}
+
+// get 查詢單個事件單，不存在時返回 nil
+func (r *IncidentRepository) get(ctx context.Context, query string, args ...interface{}) (*entities.Incident, error) {
+	incident, err := scanIncident(r.executor(ctx).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找事件單失敗", "args", args, "error", err)
+		return nil, err
+	}
+	return incident, nil
+}
+
+// scanIncident 從一行記錄解析事件單
+func scanIncident(row rowScanner) (*entities.Incident, error) {
+	var (
+		incident                  entities.Incident
+		alerts, results, timeline string
+		acknowledgedAt            sql.NullTime
+		resolvedAt                sql.NullTime
+	)
+	if err := row.Scan(&incident.ID, &incident.Title, &incident.State, &incident.Severity, &incident.Assignee,
+		&incident.GroupKey, &alerts, &results, &timeline, &acknowledgedAt, &incident.AcknowledgedBy, &resolvedAt,
+		&incident.ResolvedBy, &incident.CreatedAt, &incident.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(alerts), &incident.Alerts); err != nil {
+		return nil, fmt.Errorf("failed to decode alerts of incident %s: %w", incident.ID, err)
+	}
+	if err := json.Unmarshal([]byte(results), &incident.AnalysisResultIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode analysis results of incident %s: %w", incident.ID, err)
+	}
+	if err := json.Unmarshal([]byte(timeline), &incident.Timeline); err != nil {
+		return nil, fmt.Errorf("failed to decode timeline of incident %s: %w", incident.ID, err)
+	}
+	incident.AcknowledgedAt = fromNullTime(acknowledgedAt)
+	incident.ResolvedAt = fromNullTime(resolvedAt)
+	incident.CreatedAt = incident.CreatedAt.UTC()
+	incident.UpdatedAt = incident.UpdatedAt.UTC()
+	return &incident, nil
+}
+
+// nonNilStrings 將 nil 切片轉為空切片，使 JSON 編碼為 []
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// 確保實現了 IncidentRepository 介面
+var _ interfaces.IncidentRepository = (*IncidentRepository)(nil)
@@ -0,0 +1,171 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// NotificationDeliveryRepository 實現了 interfaces.NotificationDeliveryRepository 介面
+// 職責: 保存通知投遞記錄，渠道設定與通知內容以 JSON 保存，以便重試時原樣重新發送
+type NotificationDeliveryRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewNotificationDeliveryRepository 創建新的通知投遞倉儲實例
+func NewNotificationDeliveryRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const notificationDeliveryColumns = `id, receiver, plugin, recipient, settings, notification, fingerprint, payload_hash,
+	status, attempts, last_error, next_attempt_at, delivered_at, resend_of, requested_by, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *NotificationDeliveryRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新投遞記錄
+func (r *NotificationDeliveryRepository) Save(ctx context.Context, delivery *entities.NotificationDelivery) error {
+	settings, err := json.Marshal(nonNilMap(delivery.Settings))
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery settings: %w", err)
+	}
+	notification, err := json.Marshal(delivery.Notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery notification: %w", err)
+	}
+
+	query := `INSERT INTO notification_deliveries (` + notificationDeliveryColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"status", "attempts", "last_error", "next_attempt_at", "delivered_at", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, delivery.ID, delivery.Receiver, delivery.Plugin, delivery.Recipient,
+		string(settings), string(notification), delivery.Fingerprint, delivery.PayloadHash, delivery.Status,
+		delivery.Attempts, delivery.LastError, nullableTime(delivery.NextAttemptAt), nullableTime(delivery.DeliveredAt),
+		delivery.ResendOf, delivery.RequestedBy, toDBTime(delivery.CreatedAt), toDBTime(delivery.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存通知投遞記錄失敗", "delivery_id", delivery.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 獲取投遞記錄，不存在時返回 nil
+func (r *NotificationDeliveryRepository) GetByID(ctx context.Context, id string) (*entities.NotificationDelivery, error) {
+	query := `SELECT ` + notificationDeliveryColumns + ` FROM notification_deliveries WHERE id = ?`
+
+	delivery, err := scanNotificationDelivery(r.executor(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找通知投遞記錄失敗", "delivery_id", id, "error", err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// List 按創建時間倒序列出符合篩選條件的投遞記錄
+func (r *NotificationDeliveryRepository) List(ctx context.Context, filter interfaces.NotificationDeliveryFilter) ([]*entities.NotificationDelivery, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Receiver != "" {
+		conditions = append(conditions, "receiver = ?")
+		args = append(args, filter.Receiver)
+	}
+	if filter.Fingerprint != "" {
+		conditions = append(conditions, "fingerprint = ?")
+		args = append(args, filter.Fingerprint)
+	}
+
+	query := `SELECT ` + notificationDeliveryColumns + ` FROM notification_deliveries`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+	return r.list(ctx, query, args...)
+}
+
+// ListDue 按重試時間順序列出到期的 pending 投遞
+func (r *NotificationDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*entities.NotificationDelivery, error) {
+	query := `SELECT ` + notificationDeliveryColumns + ` FROM notification_deliveries
+			  WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?`
+	return r.list(ctx, query, entities.DeliveryStatusPending, toDBTime(now), limit)
+}
+
+// list 執行查詢並解析多條投遞記錄
+func (r *NotificationDeliveryRepository) list(ctx context.Context, query string, args ...interface{}) ([]*entities.NotificationDelivery, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出通知投遞記錄失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*entities.NotificationDelivery
+	for rows.Next() {
+		delivery, err := scanNotificationDelivery(rows)
+		if err != nil {
+			r.logger.Error("掃描通知投遞記錄失敗", "error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// scanNotificationDelivery 從一行記錄解析投遞記錄
+func scanNotificationDelivery(row rowScanner) (*entities.NotificationDelivery, error) {
+	var (
+		delivery                   entities.NotificationDelivery
+		settings, notification     string
+		lastError                  sql.NullString
+		nextAttemptAt, deliveredAt sql.NullTime
+	)
+	if err := row.Scan(&delivery.ID, &delivery.Receiver, &delivery.Plugin, &delivery.Recipient, &settings, &notification,
+		&delivery.Fingerprint, &delivery.PayloadHash, &delivery.Status, &delivery.Attempts, &lastError, &nextAttemptAt,
+		&deliveredAt, &delivery.ResendOf, &delivery.RequestedBy, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(settings), &delivery.Settings); err != nil {
+		return nil, fmt.Errorf("failed to decode settings of delivery %s: %w", delivery.ID, err)
+	}
+	if err := json.Unmarshal([]byte(notification), &delivery.Notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification of delivery %s: %w", delivery.ID, err)
+	}
+	delivery.LastError = lastError.String
+	delivery.NextAttemptAt = fromNullTime(nextAttemptAt)
+	delivery.DeliveredAt = fromNullTime(deliveredAt)
+	delivery.CreatedAt = delivery.CreatedAt.UTC()
+	delivery.UpdatedAt = delivery.UpdatedAt.UTC()
+	return &delivery, nil
+}
+
+// 確保實現了 NotificationDeliveryRepository 介面
+var _ interfaces.NotificationDeliveryRepository = (*NotificationDeliveryRepository)(nil)
@@ -0,0 +1,285 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// OnCallScheduleRepository 實現了 interfaces.OnCallScheduleRepository 介面
+// 職責: 保存值班表，輪值層與替班以 JSON 保存
+type OnCallScheduleRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewOnCallScheduleRepository 創建新的值班表倉儲實例
+func NewOnCallScheduleRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.OnCallScheduleRepository {
+	return &OnCallScheduleRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const onCallScheduleColumns = `id, name, team, description, timezone, layers, overrides, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *OnCallScheduleRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新值班表
+func (r *OnCallScheduleRepository) Save(ctx context.Context, schedule *entities.OnCallSchedule) error {
+	layers := schedule.Layers
+	if layers == nil {
+		layers = []entities.OnCallLayer{}
+	}
+	encodedLayers, err := json.Marshal(layers)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule layers: %w", err)
+	}
+	overrides := schedule.Overrides
+	if overrides == nil {
+		overrides = []entities.OnCallOverride{}
+	}
+	encodedOverrides, err := json.Marshal(overrides)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule overrides: %w", err)
+	}
+
+	query := `INSERT INTO oncall_schedules (` + onCallScheduleColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"name", "team", "description", "timezone", "layers", "overrides", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, schedule.ID, schedule.Name, schedule.Team, schedule.Description,
+		schedule.Timezone, string(encodedLayers), string(encodedOverrides), toDBTime(schedule.CreatedAt),
+		toDBTime(schedule.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存值班表失敗", "id", schedule.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根據 ID 獲取值班表，不存在時返回 nil
+func (r *OnCallScheduleRepository) GetByID(ctx context.Context, id string) (*entities.OnCallSchedule, error) {
+	query := `SELECT ` + onCallScheduleColumns + ` FROM oncall_schedules WHERE id = ?`
+
+	schedule, err := scanOnCallSchedule(r.executor(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找值班表失敗", "id", id, "error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// List 列出所有值班表，按團隊與名稱排序
+func (r *OnCallScheduleRepository) List(ctx context.Context) ([]*entities.OnCallSchedule, error) {
+	return r.query(ctx, `SELECT `+onCallScheduleColumns+` FROM oncall_schedules ORDER BY team, name`)
+}
+
+// ListByTeam 列出團隊的值班表
+func (r *OnCallScheduleRepository) ListByTeam(ctx context.Context, team string) ([]*entities.OnCallSchedule, error) {
+	return r.query(ctx, `SELECT `+onCallScheduleColumns+` FROM oncall_schedules WHERE team = ? ORDER BY name`, team)
+}
+
+// Delete 刪除值班表
+func (r *OnCallScheduleRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM oncall_schedules WHERE id = ?`, id); err != nil {
+		r.logger.Error("刪除值班表失敗", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// query 執行查詢並解析所有值班表
+func (r *OnCallScheduleRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.OnCallSchedule, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出值班表失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*entities.OnCallSchedule
+	for rows.Next() {
+		schedule, err := scanOnCallSchedule(rows)
+		if err != nil {
+			r.logger.Error("掃描值班表失敗", "error", err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// scanOnCallSchedule 從一行記錄解析值班表
+func scanOnCallSchedule(row rowScanner) (*entities.OnCallSchedule, error) {
+	var (
+		schedule          entities.OnCallSchedule
+		description       sql.NullString
+		layers, overrides string
+	)
+	if err := row.Scan(&schedule.ID, &schedule.Name, &schedule.Team, &description, &schedule.Timezone, &layers, &overrides,
+		&schedule.CreatedAt, &schedule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(layers), &schedule.Layers); err != nil {
+		return nil, fmt.Errorf("failed to decode layers of schedule %s: %w", schedule.ID, err)
+	}
+	if err := json.Unmarshal([]byte(overrides), &schedule.Overrides); err != nil {
+		return nil, fmt.Errorf("failed to decode overrides of schedule %s: %w", schedule.ID, err)
+	}
+	schedule.Description = description.String
+	schedule.CreatedAt = schedule.CreatedAt.UTC()
+	schedule.UpdatedAt = schedule.UpdatedAt.UTC()
+	return &schedule, nil
+}
+
+// EscalationPolicyRepository 實現了 interfaces.EscalationPolicyRepository 介面
+// 職責: 保存升級策略，升級層級以 JSON 保存，延遲以秒保存
+type EscalationPolicyRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewEscalationPolicyRepository 創建新的升級策略倉儲實例
+func NewEscalationPolicyRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.EscalationPolicyRepository {
+	return &EscalationPolicyRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const escalationPolicyColumns = `id, name, description, levels, repeat_count, created_at, updated_at`
+
+// escalationLevelRecord 是升級層級的存儲格式
+type escalationLevelRecord struct {
+	DelaySeconds int64                       `json:"delaySeconds"`
+	Targets      []entities.EscalationTarget `json:"targets"`
+}
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *EscalationPolicyRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新升級策略
+func (r *EscalationPolicyRepository) Save(ctx context.Context, policy *entities.EscalationPolicy) error {
+	records := make([]escalationLevelRecord, 0, len(policy.Levels))
+	for _, level := range policy.Levels {
+		records = append(records, escalationLevelRecord{DelaySeconds: int64(level.Delay / time.Second), Targets: level.Targets})
+	}
+	levels, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode escalation levels: %w", err)
+	}
+
+	query := `INSERT INTO escalation_policies (` + escalationPolicyColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"name", "description", "levels", "repeat_count", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, policy.ID, policy.Name, policy.Description, string(levels),
+		policy.RepeatCount, toDBTime(policy.CreatedAt), toDBTime(policy.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存升級策略失敗", "id", policy.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根據 ID 獲取升級策略，不存在時返回 nil
+func (r *EscalationPolicyRepository) GetByID(ctx context.Context, id string) (*entities.EscalationPolicy, error) {
+	return r.get(ctx, `SELECT `+escalationPolicyColumns+` FROM escalation_policies WHERE id = ?`, id)
+}
+
+// GetByName 根據名稱獲取升級策略，不存在時返回 nil
+func (r *EscalationPolicyRepository) GetByName(ctx context.Context, name string) (*entities.EscalationPolicy, error) {
+	return r.get(ctx, `SELECT `+escalationPolicyColumns+` FROM escalation_policies WHERE name = ?`, name)
+}
+
+// List 列出所有升級策略，按名稱排序
+func (r *EscalationPolicyRepository) List(ctx context.Context) ([]*entities.EscalationPolicy, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, `SELECT `+escalationPolicyColumns+` FROM escalation_policies ORDER BY name`)
+	if err != nil {
+		r.logger.Error("列出升級策略失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*entities.EscalationPolicy
+	for rows.Next() {
+		policy, err := scanEscalationPolicy(rows)
+		if err != nil {
+			r.logger.Error("掃描升級策略失敗", "error", err)
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// Delete 刪除升級策略
+func (r *EscalationPolicyRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM escalation_policies WHERE id = ?`, id); err != nil {
+		r.logger.Error("刪除升級策略失敗", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// get 查詢單個升級策略，不存在時返回 nil
+func (r *EscalationPolicyRepository) get(ctx context.Context, query string, arg string) (*entities.EscalationPolicy, error) {
+	policy, err := scanEscalationPolicy(r.executor(ctx).QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找升級策略失敗", "key", arg, "error", err)
+		return nil, err
+	}
+	return policy, nil
+}
+
+// scanEscalationPolicy 從一行記錄解析升級策略
+func scanEscalationPolicy(row rowScanner) (*entities.EscalationPolicy, error) {
+	var (
+		policy      entities.EscalationPolicy
+		description sql.NullString
+		levels      string
+	)
+	if err := row.Scan(&policy.ID, &policy.Name, &description, &levels, &policy.RepeatCount, &policy.CreatedAt,
+		&policy.UpdatedAt); err != nil {
+		return nil, err
+	}
+	var records []escalationLevelRecord
+	if err := json.Unmarshal([]byte(levels), &records); err != nil {
+		return nil, fmt.Errorf("failed to decode levels of escalation policy %s: %w", policy.ID, err)
+	}
+	for _, record := range records {
+		policy.Levels = append(policy.Levels, entities.EscalationLevel{
+			Delay:   time.Duration(record.DelaySeconds) * time.Second,
+			Targets: record.Targets,
+		})
+	}
+	policy.Description = description.String
+	policy.CreatedAt = policy.CreatedAt.UTC()
+	policy.UpdatedAt = policy.UpdatedAt.UTC()
+	return &policy, nil
+}
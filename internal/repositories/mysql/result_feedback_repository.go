@@ -0,0 +1,137 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ResultFeedbackRepository 實現了 interfaces.ResultFeedbackRepository 介面
+// 職責: 保存分析師對分析結果的標記，每個結果一行，重新標記時覆蓋標籤、標記者與原因
+type ResultFeedbackRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewResultFeedbackRepository 創建新的反饋標籤倉儲實例
+func NewResultFeedbackRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.ResultFeedbackRepository {
+	return &ResultFeedbackRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const resultFeedbackColumns = `analysis_result_id, detector_id, result_timestamp, label, user_id, reason, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *ResultFeedbackRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新結果的標記
+func (r *ResultFeedbackRepository) Save(ctx context.Context, feedback *entities.ResultFeedback) error {
+	query := `INSERT INTO result_feedback (` + resultFeedbackColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "analysis_result_id",
+		"label", "user_id", "reason", "updated_at")
+
+	_, err := r.executor(ctx).ExecContext(ctx, query, feedback.AnalysisResultID, feedback.DetectorID,
+		toDBTime(feedback.ResultTimestamp), feedback.Label, feedback.UserID, feedback.Reason,
+		toDBTime(feedback.CreatedAt), toDBTime(feedback.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存反饋標籤失敗", "result_id", feedback.AnalysisResultID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByResult 獲取分析結果的標記，不存在時返回 nil
+func (r *ResultFeedbackRepository) GetByResult(ctx context.Context, analysisResultID string) (*entities.ResultFeedback, error) {
+	query := `SELECT ` + resultFeedbackColumns + ` FROM result_feedback WHERE analysis_result_id = ?`
+
+	feedback, err := scanResultFeedback(r.executor(ctx).QueryRowContext(ctx, query, analysisResultID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找反饋標籤失敗", "result_id", analysisResultID, "error", err)
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// ListByDetector 按結果時間順序列出檢測器在 (start, end] 範圍內被標記的結果
+func (r *ResultFeedbackRepository) ListByDetector(ctx context.Context, detectorID string, start, end time.Time) ([]*entities.ResultFeedback, error) {
+	query := `SELECT ` + resultFeedbackColumns + ` FROM result_feedback
+			  WHERE detector_id = ? AND result_timestamp > ? AND result_timestamp <= ?
+			  ORDER BY result_timestamp`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, detectorID, toDBTime(start), toDBTime(end))
+	if err != nil {
+		r.logger.Error("列出反饋標籤失敗", "detector_id", detectorID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedback []*entities.ResultFeedback
+	for rows.Next() {
+		f, err := scanResultFeedback(rows)
+		if err != nil {
+			r.logger.Error("掃描反饋標籤失敗", "error", err)
+			return nil, err
+		}
+		feedback = append(feedback, f)
+	}
+	return feedback, rows.Err()
+}
+
+// CountByDetector 返回檢測器各標籤的標記數量
+func (r *ResultFeedbackRepository) CountByDetector(ctx context.Context, detectorID string) (map[string]int, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx,
+		`SELECT label, COUNT(*) FROM result_feedback WHERE detector_id = ? GROUP BY label`, detectorID)
+	if err != nil {
+		r.logger.Error("統計反饋標籤失敗", "detector_id", detectorID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			label string
+			count int
+		)
+		if err := rows.Scan(&label, &count); err != nil {
+			return nil, err
+		}
+		counts[label] = count
+	}
+	return counts, rows.Err()
+}
+
+// scanResultFeedback 從一行記錄解析反饋標籤
+func scanResultFeedback(row rowScanner) (*entities.ResultFeedback, error) {
+	var (
+		feedback entities.ResultFeedback
+		reason   sql.NullString
+	)
+	if err := row.Scan(&feedback.AnalysisResultID, &feedback.DetectorID, &feedback.ResultTimestamp, &feedback.Label,
+		&feedback.UserID, &reason, &feedback.CreatedAt, &feedback.UpdatedAt); err != nil {
+		return nil, err
+	}
+	feedback.Reason = reason.String
+	feedback.ResultTimestamp = feedback.ResultTimestamp.UTC()
+	feedback.CreatedAt = feedback.CreatedAt.UTC()
+	feedback.UpdatedAt = feedback.UpdatedAt.UTC()
+	return &feedback, nil
+}
+
+// 確保實現了 ResultFeedbackRepository 介面
+var _ interfaces.ResultFeedbackRepository = (*ResultFeedbackRepository)(nil)
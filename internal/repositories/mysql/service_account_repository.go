@@ -0,0 +1,233 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// ServiceAccountRepository 實現了 interfaces.ServiceAccountRepository 介面
+// 職責: 保存服務帳號與 API 金鑰，角色以 JSON 保存；金鑰只保存前綴與雜湊
+type ServiceAccountRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewServiceAccountRepository 創建新的服務帳號倉儲實例
+func NewServiceAccountRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.ServiceAccountRepository {
+	return &ServiceAccountRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const serviceAccountColumns = `id, name, description, roles, disabled, created_by, created_at, updated_at`
+
+const apiKeyColumns = `id, service_account_id, name, prefix, hash, roles, expires_at, last_used_at, revoked_at, revoked_by,
+	created_by, created_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *ServiceAccountRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// SaveAccount 創建或更新服務帳號
+func (r *ServiceAccountRepository) SaveAccount(ctx context.Context, account *entities.ServiceAccount) error {
+	roles, err := json.Marshal(nonNilStrings(account.Roles))
+	if err != nil {
+		return fmt.Errorf("failed to encode service account roles: %w", err)
+	}
+
+	query := `INSERT INTO service_accounts (` + serviceAccountColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"description", "roles", "disabled", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, account.ID, account.Name, account.Description, string(roles),
+		account.Disabled, account.CreatedBy, toDBTime(account.CreatedAt), toDBTime(account.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存服務帳號失敗", "service_account_id", account.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetAccount 根據 ID 獲取服務帳號，不存在時返回 nil
+func (r *ServiceAccountRepository) GetAccount(ctx context.Context, id string) (*entities.ServiceAccount, error) {
+	return r.getAccount(ctx, "id", id)
+}
+
+// GetAccountByName 根據名稱獲取服務帳號，不存在時返回 nil
+func (r *ServiceAccountRepository) GetAccountByName(ctx context.Context, name string) (*entities.ServiceAccount, error) {
+	return r.getAccount(ctx, "name", name)
+}
+
+// getAccount 按唯一欄位查找服務帳號
+func (r *ServiceAccountRepository) getAccount(ctx context.Context, column, value string) (*entities.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE ` + column + ` = ?`
+
+	account, err := scanServiceAccount(r.executor(ctx).QueryRowContext(ctx, query, value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找服務帳號失敗", column, value, "error", err)
+		return nil, err
+	}
+	return account, nil
+}
+
+// ListAccounts 按名稱列出所有服務帳號
+func (r *ServiceAccountRepository) ListAccounts(ctx context.Context) ([]*entities.ServiceAccount, error) {
+	query := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ORDER BY name`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("列出服務帳號失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*entities.ServiceAccount
+	for rows.Next() {
+		account, err := scanServiceAccount(rows)
+		if err != nil {
+			r.logger.Error("掃描服務帳號失敗", "error", err)
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// SaveKey 創建或更新 API 金鑰，前綴與雜湊簽發後不再改變
+func (r *ServiceAccountRepository) SaveKey(ctx context.Context, key *entities.APIKey) error {
+	roles, err := json.Marshal(nonNilStrings(key.Roles))
+	if err != nil {
+		return fmt.Errorf("failed to encode API key roles: %w", err)
+	}
+
+	query := `INSERT INTO api_keys (` + apiKeyColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"name", "expires_at", "last_used_at", "revoked_at", "revoked_by")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, key.ID, key.ServiceAccountID, key.Name, key.Prefix, key.Hash,
+		string(roles), nullableTime(key.ExpiresAt), nullableTime(key.LastUsedAt), nullableTime(key.RevokedAt),
+		key.RevokedBy, key.CreatedBy, toDBTime(key.CreatedAt))
+	if err != nil {
+		r.logger.Error("保存 API 金鑰失敗", "api_key_id", key.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetKey 根據 ID 獲取 API 金鑰，不存在時返回 nil
+func (r *ServiceAccountRepository) GetKey(ctx context.Context, id string) (*entities.APIKey, error) {
+	return r.getKey(ctx, "id", id)
+}
+
+// GetKeyByPrefix 根據前綴獲取 API 金鑰，不存在時返回 nil
+func (r *ServiceAccountRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	return r.getKey(ctx, "prefix", prefix)
+}
+
+// getKey 按唯一欄位查找 API 金鑰
+func (r *ServiceAccountRepository) getKey(ctx context.Context, column, value string) (*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ` + column + ` = ?`
+
+	key, err := scanAPIKey(r.executor(ctx).QueryRowContext(ctx, query, value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找 API 金鑰失敗", column, value, "error", err)
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListKeys 按簽發時間倒序列出服務帳號的金鑰
+func (r *ServiceAccountRepository) ListKeys(ctx context.Context, accountID string) ([]*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE service_account_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, accountID)
+	if err != nil {
+		r.logger.Error("列出 API 金鑰失敗", "service_account_id", accountID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*entities.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.Error("掃描 API 金鑰失敗", "error", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchKey 更新金鑰的最近使用時間
+func (r *ServiceAccountRepository) TouchKey(ctx context.Context, id string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+
+	if _, err := r.executor(ctx).ExecContext(ctx, query, toDBTime(usedAt), id); err != nil {
+		r.logger.Error("更新 API 金鑰使用時間失敗", "api_key_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// scanServiceAccount 從一行記錄解析服務帳號
+func scanServiceAccount(row rowScanner) (*entities.ServiceAccount, error) {
+	var (
+		account entities.ServiceAccount
+		roles   string
+	)
+	if err := row.Scan(&account.ID, &account.Name, &account.Description, &roles, &account.Disabled, &account.CreatedBy,
+		&account.CreatedAt, &account.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(roles), &account.Roles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles of service account %s: %w", account.ID, err)
+	}
+	account.CreatedAt = account.CreatedAt.UTC()
+	account.UpdatedAt = account.UpdatedAt.UTC()
+	return &account, nil
+}
+
+// scanAPIKey 從一行記錄解析 API 金鑰
+func scanAPIKey(row rowScanner) (*entities.APIKey, error) {
+	var (
+		key                              entities.APIKey
+		roles                            string
+		expiresAt, lastUsedAt, revokedAt sql.NullTime
+	)
+	if err := row.Scan(&key.ID, &key.ServiceAccountID, &key.Name, &key.Prefix, &key.Hash, &roles, &expiresAt, &lastUsedAt,
+		&revokedAt, &key.RevokedBy, &key.CreatedBy, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(roles), &key.Roles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles of API key %s: %w", key.ID, err)
+	}
+	key.ExpiresAt = fromNullTime(expiresAt)
+	key.LastUsedAt = fromNullTime(lastUsedAt)
+	key.RevokedAt = fromNullTime(revokedAt)
+	key.CreatedAt = key.CreatedAt.UTC()
+	return &key, nil
+}
+
+// 確保實現了 ServiceAccountRepository 介面
+var _ interfaces.ServiceAccountRepository = (*ServiceAccountRepository)(nil)
@@ -0,0 +1,143 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// SilenceRepository 實現了 interfaces.SilenceRepository 介面
+// 職責: 保存靜默規則，匹配條件以 JSON 保存，維護窗口長度以秒保存
+type SilenceRepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewSilenceRepository 創建新的靜默倉儲實例
+func NewSilenceRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.SilenceRepository {
+	return &SilenceRepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const silenceColumns = `id, matchers, starts_at, ends_at, schedule, duration_seconds, created_by, comment, created_at, updated_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *SilenceRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// Save 創建或更新靜默
+func (r *SilenceRepository) Save(ctx context.Context, silence *entities.Silence) error {
+	matchers, err := json.Marshal(silence.Matchers)
+	if err != nil {
+		return fmt.Errorf("failed to encode silence matchers: %w", err)
+	}
+
+	query := `INSERT INTO silences (` + silenceColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "id",
+		"matchers", "starts_at", "ends_at", "schedule", "duration_seconds", "comment", "updated_at")
+
+	_, err = r.executor(ctx).ExecContext(ctx, query, silence.ID, string(matchers), toDBTime(silence.StartsAt),
+		nullableTime(silence.EndsAt), silence.Schedule, int64(silence.Duration/time.Second), silence.CreatedBy,
+		silence.Comment, toDBTime(silence.CreatedAt), toDBTime(silence.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存靜默失敗", "id", silence.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetByID 根據 ID 獲取靜默，不存在時返回 nil
+func (r *SilenceRepository) GetByID(ctx context.Context, id string) (*entities.Silence, error) {
+	query := `SELECT ` + silenceColumns + ` FROM silences WHERE id = ?`
+
+	silence, err := scanSilence(r.executor(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("查找靜默失敗", "id", id, "error", err)
+		return nil, err
+	}
+	return silence, nil
+}
+
+// List 列出所有靜默，按開始時間倒序
+func (r *SilenceRepository) List(ctx context.Context) ([]*entities.Silence, error) {
+	return r.query(ctx, `SELECT `+silenceColumns+` FROM silences ORDER BY starts_at DESC`)
+}
+
+// ListUnexpired 列出在 now 時尚未結束的靜默
+func (r *SilenceRepository) ListUnexpired(ctx context.Context, now time.Time) ([]*entities.Silence, error) {
+	return r.query(ctx, `SELECT `+silenceColumns+` FROM silences WHERE ends_at IS NULL OR ends_at > ? ORDER BY starts_at`,
+		toDBTime(now))
+}
+
+// DeleteExpiredBefore 刪除在 before 之前已結束的靜默，返回刪除數量
+func (r *SilenceRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM silences WHERE ends_at IS NOT NULL AND ends_at < ?`,
+		toDBTime(before))
+	if err != nil {
+		r.logger.Error("清理過期靜默失敗", "error", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// query 執行查詢並解析所有靜默
+func (r *SilenceRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.Silence, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("列出靜默失敗", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var silences []*entities.Silence
+	for rows.Next() {
+		silence, err := scanSilence(rows)
+		if err != nil {
+			r.logger.Error("掃描靜默失敗", "error", err)
+			return nil, err
+		}
+		silences = append(silences, silence)
+	}
+	return silences, rows.Err()
+}
+
+// scanSilence 從一行記錄解析靜默
+func scanSilence(row rowScanner) (*entities.Silence, error) {
+	var (
+		silence         entities.Silence
+		matchers        string
+		comment         sql.NullString
+		endsAt          sql.NullTime
+		durationSeconds int64
+	)
+	if err := row.Scan(&silence.ID, &matchers, &silence.StartsAt, &endsAt, &silence.Schedule, &durationSeconds,
+		&silence.CreatedBy, &comment, &silence.CreatedAt, &silence.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(matchers), &silence.Matchers); err != nil {
+		return nil, fmt.Errorf("failed to decode matchers of silence %s: %w", silence.ID, err)
+	}
+	silence.StartsAt = silence.StartsAt.UTC()
+	silence.EndsAt = fromNullTime(endsAt)
+	silence.Duration = time.Duration(durationSeconds) * time.Second
+	silence.Comment = comment.String
+	silence.CreatedAt = silence.CreatedAt.UTC()
+	silence.UpdatedAt = silence.UpdatedAt.UTC()
+	return &silence, nil
+}
@@ -0,0 +1,171 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"detectviz-platform/internal/infrastructure/database"
+	"detectviz-platform/pkg/domain/entities"
+	"detectviz-platform/pkg/domain/interfaces"
+	"detectviz-platform/pkg/platform/contracts"
+)
+
+// UserMFARepository 實現了 interfaces.UserMFARepository 介面
+// 職責: 保存本地用戶加密的 TOTP 密鑰與恢復碼雜湊；時間步與恢復碼以條件更新標記，併發請求中只有一個成功
+type UserMFARepository struct {
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
+}
+
+// NewUserMFARepository 創建新的多因素認證倉儲實例
+func NewUserMFARepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.UserMFARepository {
+	return &UserMFARepository{
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
+	}
+}
+
+const userMFAColumns = `user_id, encrypted_secret, confirmed_at, last_used_step, created_at, updated_at`
+
+const recoveryCodeColumns = `id, user_id, code_hash, used_at, created_at`
+
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *UserMFARepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
+// GetMFA 獲取用戶的 TOTP 設定，不存在時返回 nil
+func (r *UserMFARepository) GetMFA(ctx context.Context, userID string) (*entities.UserMFA, error) {
+	query := `SELECT ` + userMFAColumns + ` FROM user_mfa WHERE user_id = ?`
+
+	var (
+		mfa         entities.UserMFA
+		confirmedAt sql.NullTime
+	)
+	err := r.executor(ctx).QueryRowContext(ctx, query, userID).Scan(&mfa.UserID, &mfa.EncryptedSecret, &confirmedAt,
+		&mfa.LastUsedStep, &mfa.CreatedAt, &mfa.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("查詢多因素認證設定失敗", "user_id", userID, "error", err)
+		return nil, err
+	}
+	mfa.ConfirmedAt = fromNullTime(confirmedAt)
+	mfa.CreatedAt = mfa.CreatedAt.UTC()
+	mfa.UpdatedAt = mfa.UpdatedAt.UTC()
+	return &mfa, nil
+}
+
+// SaveMFA 創建或更新用戶的 TOTP 設定
+func (r *UserMFARepository) SaveMFA(ctx context.Context, mfa *entities.UserMFA) error {
+	query := `INSERT INTO user_mfa (` + userMFAColumns + `)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ` + database.OnConflictUpdate(r.dialect, "user_id",
+		"encrypted_secret", "confirmed_at", "last_used_step", "updated_at")
+
+	_, err := r.executor(ctx).ExecContext(ctx, query, mfa.UserID, mfa.EncryptedSecret, nullableTime(mfa.ConfirmedAt),
+		mfa.LastUsedStep, toDBTime(mfa.CreatedAt), toDBTime(mfa.UpdatedAt))
+	if err != nil {
+		r.logger.Error("保存多因素認證設定失敗", "user_id", mfa.UserID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteMFA 刪除用戶的 TOTP 設定與全部恢復碼
+func (r *UserMFARepository) DeleteMFA(ctx context.Context, userID string) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		r.logger.Error("刪除恢復碼失敗", "user_id", userID, "error", err)
+		return err
+	}
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = ?`, userID); err != nil {
+		r.logger.Error("刪除多因素認證設定失敗", "user_id", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// AdvanceStep 在 step 大於已記錄的時間步時更新並返回 true
+func (r *UserMFARepository) AdvanceStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`
+
+	result, err := r.executor(ctx).ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		r.logger.Error("更新驗證碼時間步失敗", "user_id", userID, "error", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ReplaceRecoveryCodes 刪除用戶原有的恢復碼並保存新的恢復碼
+func (r *UserMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error {
+	if _, err := r.executor(ctx).ExecContext(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		r.logger.Error("刪除恢復碼失敗", "user_id", userID, "error", err)
+		return err
+	}
+	query := `INSERT INTO user_mfa_recovery_codes (` + recoveryCodeColumns + `) VALUES (?, ?, ?, ?, ?)`
+	for _, code := range codes {
+		if _, err := r.executor(ctx).ExecContext(ctx, query, code.ID, userID, code.CodeHash, nullableTime(code.UsedAt),
+			toDBTime(code.CreatedAt)); err != nil {
+			r.logger.Error("保存恢復碼失敗", "user_id", userID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRecoveryCodes 列出用戶的恢復碼，包括已使用的恢復碼
+func (r *UserMFARepository) ListRecoveryCodes(ctx context.Context, userID string) ([]*entities.MFARecoveryCode, error) {
+	query := `SELECT ` + recoveryCodeColumns + ` FROM user_mfa_recovery_codes WHERE user_id = ? ORDER BY created_at, id`
+
+	rows, err := r.executor(ctx).QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("查詢恢復碼失敗", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*entities.MFARecoveryCode
+	for rows.Next() {
+		var (
+			code   entities.MFARecoveryCode
+			usedAt sql.NullTime
+		)
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash, &usedAt, &code.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recovery code: %w", err)
+		}
+		code.UsedAt = fromNullTime(usedAt)
+		code.CreatedAt = code.CreatedAt.UTC()
+		codes = append(codes, &code)
+	}
+	return codes, rows.Err()
+}
+
+// UseRecoveryCode 在恢復碼未使用時標記為已使用並返回 true
+func (r *UserMFARepository) UseRecoveryCode(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	query := `UPDATE user_mfa_recovery_codes SET used_at = ? WHERE id = ? AND used_at IS NULL`
+
+	result, err := r.executor(ctx).ExecContext(ctx, query, toDBTime(usedAt), id)
+	if err != nil {
+		r.logger.Error("標記恢復碼失敗", "recovery_code_id", id, "error", err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// 確保實現了 UserMFARepository 介面
+var _ interfaces.UserMFARepository = (*UserMFARepository)(nil)
@@ -3,7 +3,10 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 
+	"detectviz-platform/internal/infrastructure/database"
 	"detectviz-platform/pkg/domain/entities"
 	"detectviz-platform/pkg/domain/interfaces"
 	"detectviz-platform/pkg/domain/valueobjects"
@@ -13,24 +16,39 @@ import (
 // UserRepository 實現了 interfaces.UserRepository 介面
 // 職責: 提供用戶實體的 MySQL 數據庫操作
 type UserRepository struct {
-	db     *sql.DB
-	logger contracts.Logger
+	db      *sql.DB
+	dialect string
+	logger  contracts.Logger
 }
 
+// userColumns 是查詢用戶時讀取的欄位，順序與 scanUser 一致
+const userColumns = `id, name, email, password_hash, roles, email_verified_at, created_at, updated_at`
+
 // NewUserRepository 創建新的用戶倉儲實例
-func NewUserRepository(db *sql.DB, logger contracts.Logger) interfaces.UserRepository {
+func NewUserRepository(db *sql.DB, dialect string, logger contracts.Logger) interfaces.UserRepository {
 	return &UserRepository{
-		db:     db,
-		logger: logger,
+		db:      db,
+		dialect: dialect,
+		logger:  logger,
 	}
 }
 
+// executor 返回 ctx 中進行中的事務，不在事務中時返回連線池；語句按方言改寫佔位符
+func (r *UserRepository) executor(ctx context.Context) database.Executor {
+	return database.RebindExecutor(database.ExecutorFromContext(ctx, r.db), r.dialect)
+}
+
 // Create 創建新用戶
 func (r *UserRepository) Create(ctx context.Context, user *entities.User) error {
-	query := `INSERT INTO users (id, email, password_hash, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?, ?)`
+	roles, err := json.Marshal(nonNilStrings(user.Roles))
+	if err != nil {
+		return fmt.Errorf("failed to encode user roles: %w", err)
+	}
+	query := `INSERT INTO users (` + userColumns + `) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.PasswordHash, user.CreatedAt, user.UpdatedAt)
+	_, err = r.executor(ctx).ExecContext(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, string(roles),
+		nullableTime(user.EmailVerifiedAt), user.CreatedAt, user.UpdatedAt)
 	if err != nil {
 		r.logger.Error("創建用戶失敗", "user_id", user.ID, "error", err)
 		return err
@@ -42,11 +60,9 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 
 // GetByID 根據 ID 查找用戶
 func (r *UserRepository) GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.User, error) {
-	query := `SELECT id, email, password_hash, created_at, updated_at FROM users WHERE id = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
 
-	var user entities.User
-	row := r.db.QueryRowContext(ctx, query, id.String())
-	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	user, err := scanUser(r.executor(ctx).QueryRowContext(ctx, query, id.String()))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("用戶未找到", "user_id", id.String())
@@ -57,16 +73,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id valueobjects.IDVO) (*en
 	}
 
 	r.logger.Debug("用戶查找成功", "user_id", id.String())
-	return &user, nil
+	return user, nil
 }
 
 // GetByEmail 根據郵箱查找用戶
 func (r *UserRepository) GetByEmail(ctx context.Context, email valueobjects.EmailVO) (*entities.User, error) {
-	query := `SELECT id, email, password_hash, created_at, updated_at FROM users WHERE email = ?`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ?`
 
-	var user entities.User
-	row := r.db.QueryRowContext(ctx, query, email.String())
-	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	user, err := scanUser(r.executor(ctx).QueryRowContext(ctx, query, email.String()))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			r.logger.Debug("用戶未找到", "email", email.String())
@@ -77,14 +91,19 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email valueobjects.Emai
 	}
 
 	r.logger.Debug("根據郵箱查找用戶成功", "email", email.String())
-	return &user, nil
+	return user, nil
 }
 
 // Update 更新用戶信息
 func (r *UserRepository) Update(ctx context.Context, user *entities.User) error {
-	query := `UPDATE users SET email = ?, password_hash = ?, updated_at = ? WHERE id = ?`
+	roles, err := json.Marshal(nonNilStrings(user.Roles))
+	if err != nil {
+		return fmt.Errorf("failed to encode user roles: %w", err)
+	}
+	query := `UPDATE users SET name = ?, email = ?, password_hash = ?, roles = ?, email_verified_at = ?, updated_at = ? WHERE id = ?`
 
-	_, err := r.db.ExecContext(ctx, query, user.Email, user.PasswordHash, user.UpdatedAt, user.ID)
+	_, err = r.executor(ctx).ExecContext(ctx, query, user.Name, user.Email, user.PasswordHash, string(roles),
+		nullableTime(user.EmailVerifiedAt), user.UpdatedAt, user.ID)
 	if err != nil {
 		r.logger.Error("更新用戶失敗", "user_id", user.ID, "error", err)
 		return err
@@ -98,7 +117,7 @@ func (r *UserRepository) Update(ctx context.Context, user *entities.User) error
 func (r *UserRepository) Delete(ctx context.Context, id valueobjects.IDVO) error {
 	query := `DELETE FROM users WHERE id = ?`
 
-	_, err := r.db.ExecContext(ctx, query, id.String())
+	_, err := r.executor(ctx).ExecContext(ctx, query, id.String())
 	if err != nil {
 		r.logger.Error("刪除用戶失敗", "user_id", id.String(), "error", err)
 		return err
@@ -110,7 +129,7 @@ func (r *UserRepository) Delete(ctx context.Context, id valueobjects.IDVO) error
 
 // List 列出所有用戶
 func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, error) {
-	query := `SELECT id, email, password_hash, created_at, updated_at FROM users`
+	query := `SELECT ` + userColumns + ` FROM users`
 	args := []interface{}{}
 
 	if limit > 0 {
@@ -122,7 +141,7 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entiti
 		args = append(args, offset)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("列出用戶失敗", "error", err)
 		return nil, err
@@ -131,12 +150,12 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entiti
 
 	var users []*entities.User
 	for rows.Next() {
-		var user entities.User
-		if err := rows.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			r.logger.Error("掃描用戶記錄失敗", "error", err)
 			return nil, err
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -147,3 +166,24 @@ func (r *UserRepository) List(ctx context.Context, offset, limit int) ([]*entiti
 	r.logger.Debug("列出用戶成功", "count", len(users))
 	return users, nil
 }
+
+// scanUser 從一行記錄解析用戶
+func scanUser(row rowScanner) (*entities.User, error) {
+	var (
+		user            entities.User
+		roles           string
+		emailVerifiedAt sql.NullTime
+	)
+	if err := row.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &roles, &emailVerifiedAt,
+		&user.CreatedAt, &user.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(roles), &user.Roles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles of user %s: %w", user.ID, err)
+	}
+	user.EmailVerifiedAt = fromNullTime(emailVerifiedAt)
+	return &user, nil
+}
+
+// 確保實現了 UserRepository 介面
+var _ interfaces.UserRepository = (*UserRepository)(nil)
@@ -0,0 +1,189 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 告警狀態
+const (
+	// AlertStatePending 檢測器已報告異常，但持續時間尚未達到觸發門檻
+	AlertStatePending = "pending"
+	// AlertStateFiring 告警已觸發，會通知接收者
+	AlertStateFiring = "firing"
+	// AlertStateResolved 檢測器報告恢復正常或超過 resolve_timeout 未再出現
+	AlertStateResolved = "resolved"
+)
+
+// AlertLabelDetectorID 是每個告警都帶有的檢測器 ID 標籤
+const AlertLabelDetectorID = "detector_id"
+
+// Alert 是以指紋標識的告警實體。
+// 職責: 合併同一檢測器、同一組標籤的重複分析結果，記錄 pending → firing → resolved 的狀態轉換。
+// 同一指紋恢復後再次出現異常時，沿用原實體開始新一輪 (StartsAt 重新計算)。
+type Alert struct {
+	// Fingerprint 由標籤計算的穩定標識，見 AlertFingerprint。
+	Fingerprint string
+	// DetectorID 產生此告警的檢測器。
+	DetectorID string
+	// Labels 標識告警的標籤，包含 detector_id 以及分析結果帶的標籤。
+	Labels map[string]string
+	// State 當前狀態，見 AlertState* 常量。
+	State string
+	// Severity 最近一次異常結果的嚴重程度。
+	Severity string
+	// Summary 最近一次異常結果的摘要。
+	Summary string
+	// Data 最近一次異常結果的詳細數據。
+	Data map[string]interface{}
+	// AnalysisResultID 最近一次異常結果的 ID。
+	AnalysisResultID string
+	// StartsAt 本輪異常第一次出現的時間。
+	StartsAt time.Time
+	// FiredAt 本輪進入 firing 的時間，尚未觸發時為零值。
+	FiredAt time.Time
+	// LastSeenAt 最近一次收到異常結果的時間。
+	LastSeenAt time.Time
+	// ResolvedAt 本輪恢復的時間，尚未恢復時為零值。
+	ResolvedAt time.Time
+	// SilencedBy 最近一次通知評估時抑制此告警的靜默 ID，未被靜默時為空。
+	SilencedBy []string
+	// AcknowledgedAt 本輪被確認的時間，尚未確認時為零值；確認後停止升級。
+	AcknowledgedAt time.Time
+	// AcknowledgedBy 確認者。
+	AcknowledgedBy string
+	// Escalation 本輪在升級策略中的進度，未使用升級策略時為 nil。
+	Escalation *AlertEscalation
+	// UpdatedAt 最近一次狀態更新的時間。
+	UpdatedAt time.Time
+}
+
+// 匹配告警時可使用的附加標籤，不參與指紋計算
+const (
+	// AlertLabelSeverity 告警的嚴重程度
+	AlertLabelSeverity = "severity"
+	// AlertLabelOwner 檢測器擁有者，由告警管理器在路由時查找
+	AlertLabelOwner = "owner"
+)
+
+// MatchLabels 返回用於靜默與路由匹配的標籤：告警標籤加上 severity
+func (a *Alert) MatchLabels() map[string]string {
+	labels := make(map[string]string, len(a.Labels)+1)
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	if _, ok := labels[AlertLabelSeverity]; !ok {
+		labels[AlertLabelSeverity] = a.Severity
+	}
+	return labels
+}
+
+// IsAcknowledged 返回告警本輪是否已被確認
+func (a *Alert) IsAcknowledged() bool {
+	return !a.AcknowledgedAt.IsZero()
+}
+
+// IsActive 返回告警是否處於 pending 或 firing
+func (a *Alert) IsActive() bool {
+	return a.State == AlertStatePending || a.State == AlertStateFiring
+}
+
+// AnalysisResult 以告警最近一次的異常結果構造 AlertPlugin 使用的分析結果，
+// 恢復的告警將 is_anomalous 設為 false，時間戳為恢復時間。
+func (a *Alert) AnalysisResult() *AnalysisResult {
+	data := make(map[string]interface{}, len(a.Data)+1)
+	for k, v := range a.Data {
+		data[k] = v
+	}
+	data[AnalysisDataKeyAnomalous] = a.State == AlertStateFiring
+
+	timestamp := a.LastSeenAt
+	if a.State == AlertStateResolved && !a.ResolvedAt.IsZero() {
+		timestamp = a.ResolvedAt
+	}
+	return &AnalysisResult{
+		ID:         a.AnalysisResultID,
+		DetectorID: a.DetectorID,
+		Timestamp:  timestamp,
+		Summary:    a.Summary,
+		Data:       data,
+		Severity:   a.Severity,
+	}
+}
+
+// AlertFingerprint 根據標籤計算告警指紋，與標籤順序無關
+func AlertFingerprint(labels map[string]string) string {
+	return hashLabels(labels)
+}
+
+// AlertGroup 是一組同時通知的告警。
+// 職責: 按 group_by 標籤聚合告警，並記錄每個成員最後一次通知時的狀態，
+// 使通知的節奏 (group_wait、group_interval、repeat_interval) 在重啟後得以延續。
+type AlertGroup struct {
+	// Key 由路由、接收者與分組標籤計算的標識，見 AlertGroupKey。
+	Key string
+	// RouteID 產生此分組的路由節點，決定分組的通知時間。
+	RouteID string
+	// Receiver 接收這組告警的接收者名稱。
+	Receiver string
+	// Labels 分組標籤的取值。
+	Labels map[string]string
+	// Members 成員指紋對應最後一次通知時的狀態，尚未通知時為空字串。
+	Members map[string]string
+	// NextFlushAt 下一次評估是否需要通知的時間。
+	NextFlushAt time.Time
+	// LastNotifiedAt 最近一次成功通知的時間，從未通知時為零值。
+	LastNotifiedAt time.Time
+	// CreatedAt 分組創建時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次更新時間。
+	UpdatedAt time.Time
+}
+
+// AlertGroupKey 根據路由、接收者與分組標籤計算分組標識。
+// 同一告警經 continue 進入多個路由時，每個路由各自形成分組。
+func AlertGroupKey(routeID, receiver string, labels map[string]string) string {
+	return routeID + "/" + receiver + ":" + hashLabels(labels)
+}
+
+// AlertNotification 描述告警管理器調用 AlertPlugin 時對應的告警與分組
+type AlertNotification struct {
+	// Receiver 接收者名稱。
+	Receiver string
+	// GroupKey 告警所屬分組。
+	GroupKey string
+	// GroupLabels 分組標籤的取值。
+	GroupLabels map[string]string
+	// Alert 本次通知的告警，State 為 firing 或 resolved。
+	Alert Alert
+	// Repeat 表示這是 repeat_interval 到期後對未變化告警的重複通知。
+	Repeat bool
+	// EscalationLevel 升級通知的層級 (從 1 開始)，一般通知為 0。
+	EscalationLevel int
+	// IncidentID 告警所屬的事件單，未啟用事件單時為空。
+	IncidentID string
+	// AcknowledgeURL 確認事件單的簽名鏈接，只在事件單尚未解決的 firing 通知中提供。
+	AcknowledgeURL string
+}
+
+// hashLabels 按鍵排序後計算標籤集合的 SHA-256 摘要 (取前 16 字節)
+func hashLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:16])
+}
@@ -1,6 +1,15 @@
 package entities
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// AnalysisDataKeyAnomalous 是 AnalysisResult.Data 中標記結果是否為異常的鍵。
+const AnalysisDataKeyAnomalous = "is_anomalous"
+
+// AnalysisDataKeyLabels 是 AnalysisResult.Data 中攜帶告警標籤 (例如 host、owner) 的鍵。
+const AnalysisDataKeyLabels = "labels"
 
 // AnalysisResult 是一個表示數據分析結果的領域實體。
 // 職責: 捕獲並封裝分析過程產生的結構化結果，例如偵測到的異常、趨勢、或洞察。
@@ -19,3 +28,35 @@ type AnalysisResult struct {
 	// Severity 表示分析結果的重要性或嚴重程度。
 	Severity string
 }
+
+// IsAnomalous 返回檢測器是否將此結果標記為異常
+func (r *AnalysisResult) IsAnomalous() bool {
+	if r == nil {
+		return false
+	}
+	anomalous, _ := r.Data[AnalysisDataKeyAnomalous].(bool)
+	return anomalous
+}
+
+// Labels 返回 Data["labels"] 中的標籤，支持 map[string]string 與 map[string]interface{}，非字串值會被格式化
+func (r *AnalysisResult) Labels() map[string]string {
+	labels := make(map[string]string)
+	if r == nil {
+		return labels
+	}
+	switch raw := r.Data[AnalysisDataKeyLabels].(type) {
+	case map[string]string:
+		for k, v := range raw {
+			labels[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				labels[k] = s
+			} else {
+				labels[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,125 @@
+package entities
+
+import "time"
+
+// 回放任務的狀態
+const (
+	BackfillStatusPending   = "pending"
+	BackfillStatusRunning   = "running"
+	BackfillStatusSucceeded = "succeeded"
+	BackfillStatusFailed    = "failed"
+	BackfillStatusCancelled = "cancelled"
+)
+
+// 模擬結果所屬的配置
+const (
+	// SimulationVariantCandidate 表示由被回放的配置版本產生的結果
+	SimulationVariantCandidate = "candidate"
+	// SimulationVariantBaseline 表示由目前生效的配置產生的結果
+	SimulationVariantBaseline = "baseline"
+)
+
+// BackfillJob 描述一次對歷史數據的檢測器回放。
+// 職責: 記錄回放的檢測器、配置版本、時間範圍與執行進度，完成後保存與生效配置的比較報告。
+type BackfillJob struct {
+	// ID 任務的唯一標識符。
+	ID string
+	// DetectorID 被回放的檢測器 ID。
+	DetectorID string
+	// Revision 被回放的配置版本。
+	Revision int
+	// BaselineRevision 作為比較基準的生效版本，0 表示使用排程中的配置。
+	BaselineRevision int
+	// RangeStart 與 RangeEnd 定義回放的數據範圍 (RangeStart, RangeEnd]。
+	RangeStart time.Time
+	RangeEnd   time.Time
+	// Status 任務狀態，見 BackfillStatus* 常量。
+	Status string
+	// ProcessedUntil 已回放到的數據時間點。
+	ProcessedUntil time.Time
+	// Report 任務成功完成後的比較報告。
+	Report *BackfillReport
+	// Error 任務失敗時的錯誤訊息。
+	Error string
+	// CreatedBy 發起回放的使用者 ID。
+	CreatedBy string
+	// CreatedAt、StartedAt 與 FinishedAt 記錄任務的生命週期。
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// BackfillReport 比較回放版本與生效配置在同一批數據上的偵測結果。
+// 兩邊的偵測以數據記錄的時間戳對齊。
+type BackfillReport struct {
+	// RecordsProcessed 回放的數據記錄數。
+	RecordsProcessed int `json:"records_processed"`
+	// CandidateAlerts 回放版本產生的異常數。
+	CandidateAlerts int `json:"candidate_alerts"`
+	// BaselineAlerts 生效配置產生的異常數。
+	BaselineAlerts int `json:"baseline_alerts"`
+	// Overlap 兩邊都偵測到的異常數。
+	Overlap int `json:"overlap"`
+	// NewDetections 只有回放版本偵測到的異常數。
+	NewDetections int `json:"new_detections"`
+	// MissedDetections 只有生效配置偵測到的異常數。
+	MissedDetections int `json:"missed_detections"`
+	// NewDetectionSamples 部分新增偵測的時間點，便於人工抽查。
+	NewDetectionSamples []time.Time `json:"new_detection_samples,omitempty"`
+	// MissedDetectionSamples 部分消失偵測的時間點。
+	MissedDetectionSamples []time.Time `json:"missed_detection_samples,omitempty"`
+	// Evaluation 以回放範圍內分析師標記過的結果評估兩種配置，範圍內沒有標記時為 nil。
+	Evaluation *BackfillEvaluation `json:"evaluation,omitempty"`
+}
+
+// BackfillEvaluation 以標記過的線上結果作為評估數據。
+// 標記與偵測以結果的時間戳對齊：某個配置在標記結果的時間點偵測到異常即視為偵測到該結果。
+type BackfillEvaluation struct {
+	// LabelledResults 回放範圍內被標記的結果數。
+	LabelledResults int `json:"labelled_results"`
+	// Candidate 回放版本在標記結果上的表現。
+	Candidate LabelScore `json:"candidate"`
+	// Baseline 生效配置在標記結果上的表現。
+	Baseline LabelScore `json:"baseline"`
+}
+
+// LabelScore 統計一種配置在標記結果時間點上的偵測情況。
+type LabelScore struct {
+	// TruePositivesDetected 偵測到的 true_positive 結果數。
+	TruePositivesDetected int `json:"true_positives_detected"`
+	// TruePositivesMissed 沒有偵測到的 true_positive 結果數。
+	TruePositivesMissed int `json:"true_positives_missed"`
+	// FalsePositivesDetected 仍然偵測到的 false_positive 結果數。
+	FalsePositivesDetected int `json:"false_positives_detected"`
+	// ExpectedDetected 仍然偵測到的 expected 結果數。
+	ExpectedDetected int `json:"expected_detected"`
+}
+
+// Precision 返回偵測到的標記結果中 true_positive 的比例，expected 不計入；沒有可計算的結果時第二個返回值為 false。
+func (s LabelScore) Precision() (float64, bool) {
+	total := s.TruePositivesDetected + s.FalsePositivesDetected
+	if total == 0 {
+		return 0, false
+	}
+	return float64(s.TruePositivesDetected) / float64(total), true
+}
+
+// Recall 返回 true_positive 結果中被偵測到的比例；沒有 true_positive 標記時第二個返回值為 false。
+func (s LabelScore) Recall() (float64, bool) {
+	total := s.TruePositivesDetected + s.TruePositivesMissed
+	if total == 0 {
+		return 0, false
+	}
+	return float64(s.TruePositivesDetected) / float64(total), true
+}
+
+// SimulationResult 是回放產生的分析結果。
+// 它保存在獨立的模擬命名空間中，不會交給告警流程處理。
+type SimulationResult struct {
+	// JobID 產生此結果的回放任務 ID。
+	JobID string
+	// Variant 產生此結果的配置，見 SimulationVariant* 常量。
+	Variant string
+	// Result 檢測器返回的分析結果。
+	Result *AnalysisResult
+}
@@ -1,5 +1,7 @@
 package entities
 
+import "time"
+
 // DetectionResult 是表示一個偵測事件處理後的最終結果的領域值物件。
 // 職責: 封裝偵測事件被處理後的輸出，包括是否產生了分析結果以及處理狀態。
 // 它是一個不可變的對象，代表了對一個 Detection 的最終裁定。
@@ -12,4 +14,24 @@ type DetectionResult struct {
 	AnalysisResultID string
 	// Message 提供了關於處理結果的額外信息，例如忽略原因或錯誤詳情。
 	Message string
+	// StageTimings 記錄經由檢測管線處理時每個階段的執行情況，按階段完成順序排列。
+	StageTimings []StageTiming
+}
+
+// StageTiming 記錄檢測管線中單個階段的執行情況。
+type StageTiming struct {
+	// Stage 階段名稱。
+	Stage string
+	// Type 階段類型，例如 "transform", "detector", "post_processor", "alert"。
+	Type string
+	// Status 階段狀態，例如 "succeeded", "failed", "skipped"。
+	Status string
+	// StartedAt 階段開始執行的時間，被略過的階段為零值。
+	StartedAt time.Time
+	// Duration 階段包含重試在內的總耗時。
+	Duration time.Duration
+	// Attempts 實際嘗試執行的次數。
+	Attempts int
+	// Error 階段失敗時的錯誤訊息。
+	Error string
 }
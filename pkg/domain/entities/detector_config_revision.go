@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+// DetectorConfigRevision 是檢測器配置的一個不可變版本。
+// 職責: 保存每次調整後的檢測器插件與配置，讓回放可以針對指定版本執行，
+// 並記錄目前生效的版本，作為回放比較的基準。
+type DetectorConfigRevision struct {
+	// DetectorID 關聯的檢測器 ID。
+	DetectorID string
+	// Revision 版本號，同一檢測器內從 1 開始遞增。
+	Revision int
+	// Plugin 檢測器插件名稱，即 DetectorPlugin.GetName() 的返回值。
+	Plugin string
+	// Config 初始化檢測器並在每次執行時傳入的配置。
+	Config map[string]interface{}
+	// Comment 描述本次調整的說明。
+	Comment string
+	// CreatedBy 創建此版本的使用者 ID。
+	CreatedBy string
+	// Active 是否為目前排程使用的版本。
+	Active bool
+	// CreatedAt 版本創建時間。
+	CreatedAt time.Time
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+// 錯過執行時間時的處理策略
+const (
+	// MissedRunPolicySkip 只執行最近一次到期的排程，較早錯過的排程直接略過
+	MissedRunPolicySkip = "skip"
+	// MissedRunPolicyCatchUp 按時間順序補跑所有錯過的排程
+	MissedRunPolicyCatchUp = "catch_up"
+)
+
+// 排程執行的狀態
+const (
+	ScheduleRunStatusSucceeded = "succeeded"
+	ScheduleRunStatusFailed    = "failed"
+	ScheduleRunStatusSkipped   = "skipped"
+)
+
+// DetectorSchedule 描述一個檢測器的週期性執行計劃。
+// 職責: 記錄檢測器以何種頻率、從哪個數據源、以多大的數據窗口執行，
+// 並持久化下次與上次執行時間，使平台重啟後既不重複觸發也不遺漏排程。
+type DetectorSchedule struct {
+	// DetectorID 關聯的檢測器 ID。
+	DetectorID string
+	// DetectorPlugin 執行偵測的 DetectorPlugin 在註冊表中的名稱。
+	DetectorPlugin string
+	// DetectorConfig 傳給 DetectorPlugin.Execute 的運行時配置。
+	DetectorConfig map[string]interface{}
+	// Spec 是排程表達式，支持標準 5 欄位 cron、"@every 5m" 或 "@hourly" 等描述符。
+	Spec string
+	// Source 提供數據的 DataSourcePlugin 在註冊表中的名稱。
+	Source string
+	// SourceQuery 傳給數據源的查詢參數。
+	SourceQuery map[string]interface{}
+	// Window 每次執行拉取的數據窗口長度，窗口為 (排程時間 - Window, 排程時間]。
+	Window time.Duration
+	// Jitter 每次執行前的最大隨機延遲，用於分散同一時刻到期的排程。
+	Jitter time.Duration
+	// MaxConcurrency 同一檢測器允許同時進行的最大執行數。
+	MaxConcurrency int
+	// MissedRunPolicy 錯過執行時間時的處理策略，見 MissedRunPolicySkip 與 MissedRunPolicyCatchUp。
+	MissedRunPolicy string
+	// Enabled 是否啟用排程。
+	Enabled bool
+	// NextRunAt 下次應執行的排程時間，零值表示尚未計算。
+	NextRunAt time.Time
+	// LastRunAt 最近一次完成執行所對應的排程時間。
+	LastRunAt time.Time
+	// LastStatus 最近一次執行的狀態。
+	LastStatus string
+	// LastError 最近一次執行失敗的錯誤訊息。
+	LastError string
+	// UpdatedAt 排程配置最後更新時間。
+	UpdatedAt time.Time
+}
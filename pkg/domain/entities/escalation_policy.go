@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 升級目標的類型
+const (
+	// EscalationTargetUser 直接通知指定用戶
+	EscalationTargetUser = "user"
+	// EscalationTargetSchedule 通知值班表在升級時的值班者
+	EscalationTargetSchedule = "schedule"
+	// EscalationTargetTeam 通知團隊所有值班表在升級時的值班者
+	EscalationTargetTeam = "team"
+)
+
+// EscalationPolicy 描述告警未被確認時的升級順序。
+// 職責: 按順序排列升級層級，每一層在上一次通知後等待 Delay 仍未確認即通知該層的目標；
+// 最後一層通知後按 RepeatCount 從第一層重新開始。
+type EscalationPolicy struct {
+	// ID 升級策略的唯一標識。
+	ID string
+	// Name 策略名稱，接收者以名稱引用，不能重複。
+	Name string
+	// Description 描述。
+	Description string
+	// Levels 升級層級，按順序觸發。
+	Levels []EscalationLevel
+	// RepeatCount 最後一層通知後從第一層重新開始的次數，0 表示不重複。
+	RepeatCount int
+	// CreatedAt 創建時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次更新時間。
+	UpdatedAt time.Time
+}
+
+// EscalationLevel 是升級策略中的一層
+type EscalationLevel struct {
+	// Delay 上一次通知後等待多久仍未確認才通知此層。
+	Delay time.Duration `json:"delay"`
+	// Targets 此層要通知的用戶、值班表或團隊。
+	Targets []EscalationTarget `json:"targets"`
+}
+
+// EscalationTarget 是升級層級的一個通知目標
+type EscalationTarget struct {
+	// Type 目標類型，見 EscalationTarget* 常量。
+	Type string `json:"type"`
+	// ID 用戶 ID、值班表 ID 或團隊名稱。
+	ID string `json:"id"`
+}
+
+// Validate 檢查策略名稱與每一層的延遲和目標
+func (p *EscalationPolicy) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("escalation policy name is required")
+	}
+	if len(p.Levels) == 0 {
+		return fmt.Errorf("escalation policy requires at least one level")
+	}
+	if p.RepeatCount < 0 {
+		return fmt.Errorf("repeat count must not be negative")
+	}
+	for i, level := range p.Levels {
+		if level.Delay < 0 {
+			return fmt.Errorf("level %d: delay must not be negative", i+1)
+		}
+		if len(level.Targets) == 0 {
+			return fmt.Errorf("level %d: at least one target is required", i+1)
+		}
+		for _, target := range level.Targets {
+			switch target.Type {
+			case EscalationTargetUser, EscalationTargetSchedule, EscalationTargetTeam:
+			default:
+				return fmt.Errorf("level %d: invalid target type %q, expected user, schedule or team", i+1, target.Type)
+			}
+			if target.ID == "" {
+				return fmt.Errorf("level %d: target id is required", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// AlertEscalation 記錄一個告警在升級策略中的進度
+type AlertEscalation struct {
+	// Policy 使用的升級策略名稱。
+	Policy string `json:"policy"`
+	// Receiver 觸發升級的接收者，升級通知使用其通知渠道發送。
+	Receiver string `json:"receiver"`
+	// Level 下一個要通知的層級 (從 0 開始)。
+	Level int `json:"level"`
+	// Cycle 已經從第一層重新開始的次數。
+	Cycle int `json:"cycle"`
+	// NextAt 下一次升級的時間，零值表示升級已結束。
+	NextAt time.Time `json:"nextAt"`
+	// LastEscalatedAt 最近一次升級通知的時間。
+	LastEscalatedAt time.Time `json:"lastEscalatedAt,omitempty"`
+}
+
+// Pending 返回升級是否仍在等待下一層
+func (e *AlertEscalation) Pending() bool {
+	return e != nil && !e.NextAt.IsZero()
+}
@@ -0,0 +1,151 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// 事件單狀態
+const (
+	// IncidentStateOpen 事件單已開立，尚無人確認
+	IncidentStateOpen = "open"
+	// IncidentStateAcknowledged 已有人確認並處理中，成員告警停止升級
+	IncidentStateAcknowledged = "acknowledged"
+	// IncidentStateResolved 事件單已解決，不再接收新的告警
+	IncidentStateResolved = "resolved"
+)
+
+// 事件單時間線的事件類型
+const (
+	// IncidentEventCreated 事件單開立
+	IncidentEventCreated = "created"
+	// IncidentEventStateChanged 狀態轉換，Data 帶 from 與 to
+	IncidentEventStateChanged = "state_changed"
+	// IncidentEventAssigned 負責人變更，Data 帶 assignee
+	IncidentEventAssigned = "assigned"
+	// IncidentEventNote 處理人員的備註
+	IncidentEventNote = "note"
+	// IncidentEventAlertAdded 告警加入事件單，Data 帶 fingerprint
+	IncidentEventAlertAdded = "alert_added"
+	// IncidentEventNotificationSent 已發送通知，Data 帶 receiver、plugin 與 recipient
+	IncidentEventNotificationSent = "notification_sent"
+)
+
+// IncidentActorSystem 是告警管理器自動產生的時間線事件的操作者
+const IncidentActorSystem = "system"
+
+// Incident 是聚合一個或多個告警的事件單。
+// 職責: 記錄誰在何時確認、指派與解決了異常，以及期間的備註與已發送的通知，
+// 並關聯成員告警觸發時的分析結果，作為事後檢討的依據。
+type Incident struct {
+	// ID 是事件單的唯一標識符。
+	ID string
+	// Title 事件單標題，自動開立時取第一個告警的摘要。
+	Title string
+	// State 當前狀態，見 IncidentState* 常量。
+	State string
+	// Severity 開立時告警的嚴重程度。
+	Severity string
+	// Assignee 負責人，未指派時為空。
+	Assignee string
+	// GroupKey 自動開立時的告警分組，同一分組的新告警加入尚未解決的事件單；手動開立時為空。
+	GroupKey string
+	// Alerts 成員告警的指紋，按加入順序。
+	Alerts []string
+	// AnalysisResultIDs 成員告警觸發時的分析結果 ID。
+	AnalysisResultIDs []string
+	// Timeline 按時間順序的事件。
+	Timeline []IncidentEvent
+	// AcknowledgedAt 確認時間，尚未確認時為零值。
+	AcknowledgedAt time.Time
+	// AcknowledgedBy 確認者。
+	AcknowledgedBy string
+	// ResolvedAt 解決時間，尚未解決時為零值。
+	ResolvedAt time.Time
+	// ResolvedBy 解決者，所有成員告警恢復而自動解決時為 system。
+	ResolvedBy string
+	// CreatedAt 開立時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次更新時間。
+	UpdatedAt time.Time
+}
+
+// IncidentEvent 是事件單時間線上的一筆記錄
+type IncidentEvent struct {
+	ID      string            `json:"id"`
+	Time    time.Time         `json:"time"`
+	Type    string            `json:"type"`
+	Actor   string            `json:"actor"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// IsResolved 返回事件單是否已解決
+func (i *Incident) IsResolved() bool {
+	return i.State == IncidentStateResolved
+}
+
+// HasAlert 返回告警是否屬於事件單
+func (i *Incident) HasAlert(fingerprint string) bool {
+	for _, f := range i.Alerts {
+		if f == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAlert 將告警與其最近一次的分析結果加入事件單，告警已是成員時只補充分析結果。
+// 返回告警是否為新成員。
+func (i *Incident) AddAlert(alert *Alert) bool {
+	i.LinkAnalysisResult(alert.AnalysisResultID)
+	if i.HasAlert(alert.Fingerprint) {
+		return false
+	}
+	i.Alerts = append(i.Alerts, alert.Fingerprint)
+	return true
+}
+
+// LinkAnalysisResult 關聯分析結果，已關聯或 ID 為空時返回 false
+func (i *Incident) LinkAnalysisResult(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, existing := range i.AnalysisResultIDs {
+		if existing == id {
+			return false
+		}
+	}
+	i.AnalysisResultIDs = append(i.AnalysisResultIDs, id)
+	return true
+}
+
+// Acknowledge 將 open 的事件單轉為 acknowledged，已確認時保持原來的確認者並返回 false
+func (i *Incident) Acknowledge(by string, at time.Time) (bool, error) {
+	switch i.State {
+	case IncidentStateResolved:
+		return false, fmt.Errorf("incident %s is already resolved", i.ID)
+	case IncidentStateAcknowledged:
+		return false, nil
+	}
+	i.State = IncidentStateAcknowledged
+	i.AcknowledgedAt = at
+	i.AcknowledgedBy = by
+	return true, nil
+}
+
+// Resolve 將事件單轉為 resolved，已解決時返回 false
+func (i *Incident) Resolve(by string, at time.Time) bool {
+	if i.IsResolved() {
+		return false
+	}
+	i.State = IncidentStateResolved
+	i.ResolvedAt = at
+	i.ResolvedBy = by
+	return true
+}
+
+// Record 在時間線末尾追加事件
+func (i *Incident) Record(event IncidentEvent) {
+	i.Timeline = append(i.Timeline, event)
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 標籤匹配運算符
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// LabelMatcher 描述對單個標籤的匹配條件，用於靜默與通知路由。
+// 正則表達式匹配整個標籤值；不存在的標籤視為空字串。
+type LabelMatcher struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// ParseLabelMatcher 解析 "name=value"、"name!=value"、"name=~regex" 或 "name!~regex" 形式的匹配條件
+func ParseLabelMatcher(expr string) (LabelMatcher, error) {
+	// 先匹配兩個字元的運算符，避免 "!=" 被當作 "="
+	for _, op := range []string{MatchNotEqual, MatchRegexp, MatchNotRegexp, MatchEqual} {
+		if i := strings.Index(expr, op); i > 0 {
+			m := LabelMatcher{
+				Name:     strings.TrimSpace(expr[:i]),
+				Operator: op,
+				Value:    strings.Trim(strings.TrimSpace(expr[i+len(op):]), `"`),
+			}
+			return m, m.Validate()
+		}
+	}
+	return LabelMatcher{}, fmt.Errorf("invalid label matcher %q, expected name=value, name!=value, name=~regex or name!~regex", expr)
+}
+
+// Validate 檢查標籤名稱、運算符與正則表達式
+func (m LabelMatcher) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("label matcher name is required")
+	}
+	switch m.Operator {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		if _, err := m.compile(); err != nil {
+			return fmt.Errorf("invalid regular expression for label %s: %w", m.Name, err)
+		}
+	default:
+		return fmt.Errorf("unknown label matcher operator %q", m.Operator)
+	}
+	return nil
+}
+
+// Matches 返回標籤集合是否滿足匹配條件，無效的正則表達式視為不匹配
+func (m LabelMatcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	switch m.Operator {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp, MatchNotRegexp:
+		re, err := m.compile()
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value) == (m.Operator == MatchRegexp)
+	}
+	return false
+}
+
+// String 返回匹配條件的文字形式
+func (m LabelMatcher) String() string {
+	return m.Name + m.Operator + m.Value
+}
+
+func (m LabelMatcher) compile() (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + m.Value + ")$")
+}
+
+// MatchAll 返回標籤集合是否滿足所有匹配條件
+func MatchAll(matchers []LabelMatcher, labels map[string]string) bool {
+	for _, m := range matchers {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
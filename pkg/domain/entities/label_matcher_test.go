@@ -0,0 +1,62 @@
+package entities
+
+import "testing"
+
+func TestParseLabelMatcher(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    LabelMatcher
+		wantErr bool
+	}{
+		{expr: "host=web-1", want: LabelMatcher{Name: "host", Operator: MatchEqual, Value: "web-1"}},
+		{expr: "severity!=low", want: LabelMatcher{Name: "severity", Operator: MatchNotEqual, Value: "low"}},
+		{expr: `host=~"db-.*"`, want: LabelMatcher{Name: "host", Operator: MatchRegexp, Value: "db-.*"}},
+		{expr: "env!~staging|dev", want: LabelMatcher{Name: "env", Operator: MatchNotRegexp, Value: "staging|dev"}},
+		{expr: "host", wantErr: true},
+		{expr: "=web-1", wantErr: true},
+		{expr: "host=~(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseLabelMatcher(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLabelMatcher(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLabelMatcher(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelMatcher_Matches(t *testing.T) {
+	labels := map[string]string{"host": "db-1", "severity": "high"}
+
+	tests := []struct {
+		matcher LabelMatcher
+		want    bool
+	}{
+		{LabelMatcher{Name: "host", Operator: MatchEqual, Value: "db-1"}, true},
+		{LabelMatcher{Name: "host", Operator: MatchNotEqual, Value: "db-1"}, false},
+		{LabelMatcher{Name: "host", Operator: MatchRegexp, Value: "db-.*"}, true},
+		// 正則表達式需匹配整個標籤值
+		{LabelMatcher{Name: "host", Operator: MatchRegexp, Value: "db"}, false},
+		{LabelMatcher{Name: "severity", Operator: MatchNotRegexp, Value: "low|medium"}, true},
+		// 不存在的標籤視為空字串
+		{LabelMatcher{Name: "env", Operator: MatchEqual, Value: ""}, true},
+		{LabelMatcher{Name: "env", Operator: MatchNotEqual, Value: "prod"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.matcher.Matches(labels); got != tt.want {
+			t.Errorf("%s matches %v = %v, want %v", tt.matcher, labels, got, tt.want)
+		}
+	}
+}
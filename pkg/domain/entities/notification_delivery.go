@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// 通知投遞的狀態
+const (
+	// DeliveryStatusPending 尚未成功，等待 NextAttemptAt 到期後重試
+	DeliveryStatusPending = "pending"
+	// DeliveryStatusDelivered 渠道插件已接受通知
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusFailed 達到最大嘗試次數仍未成功，不再自動重試
+	DeliveryStatusFailed = "failed"
+	// DeliveryStatusSuperseded 重試前告警狀態已改變 (例如 firing 通知重試前告警已恢復)，不再發送過時的通知
+	DeliveryStatusSuperseded = "superseded"
+)
+
+// NotificationDelivery 記錄一次發往單個渠道與接收者的通知。
+// 職責: 保存通知內容、payload 摘要、嘗試次數與最後的錯誤，作為通知已發出的審計記錄；
+// 失敗的投遞按退避時間重試，手動重新發送時建立引用原記錄的新投遞。
+type NotificationDelivery struct {
+	// ID 投遞的唯一標識符。
+	ID string
+	// Receiver 接收者名稱。
+	Receiver string
+	// Plugin 渠道使用的插件名稱。
+	Plugin string
+	// Recipient NotificationPlugin 的接收者，例如郵件地址；AlertPlugin 渠道為空。
+	Recipient string
+	// Settings 渠道設定，重試時與通知信息一起傳給插件。
+	Settings map[string]interface{}
+	// Notification 發送的告警通知。
+	Notification *AlertNotification
+	// Fingerprint 通知的告警指紋。
+	Fingerprint string
+	// PayloadHash 通知內容的 SHA-256 摘要，見 NotificationPayloadHash。
+	PayloadHash string
+	// Status 投遞狀態，見 DeliveryStatus* 常量。
+	Status string
+	// Attempts 已嘗試的次數。
+	Attempts int
+	// LastError 最近一次失敗的錯誤訊息。
+	LastError string
+	// NextAttemptAt 狀態為 pending 時下一次重試的時間。
+	NextAttemptAt time.Time
+	// DeliveredAt 成功投遞的時間。
+	DeliveredAt time.Time
+	// ResendOf 手動重新發送時，被重新發送的原投遞 ID。
+	ResendOf string
+	// RequestedBy 手動重新發送的使用者。
+	RequestedBy string
+	// CreatedAt 與 UpdatedAt 記錄投遞的生命週期。
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NotificationPayloadHash 計算通知內容、渠道與接收者的 SHA-256 摘要，用於證明發出的是哪一份內容
+func NotificationPayloadHash(plugin, recipient string, notification *AlertNotification) (string, error) {
+	payload, err := json.Marshal(struct {
+		Plugin       string             `json:"plugin"`
+		Recipient    string             `json:"recipient"`
+		Notification *AlertNotification `json:"notification"`
+	}{plugin, recipient, notification})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,267 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 輪值班次的單位
+const (
+	// RotationHourly 每班 ShiftLength 小時
+	RotationHourly = "hourly"
+	// RotationDaily 每班 ShiftLength 天，在排班時區的同一當地時刻交班
+	RotationDaily = "daily"
+	// RotationWeekly 每班 ShiftLength 週，在排班時區的同一當地時刻交班
+	RotationWeekly = "weekly"
+)
+
+// OnCallSchedule 是一個團隊的值班表。
+// 職責: 以多個輪值層 (layer) 描述誰在值班，後面的層在其有人值班的時段覆蓋前面的層；
+// 臨時替班 (override) 優先於所有層。交班時間以 Timezone 的當地時間計算，跨夏令時切換時仍在同一當地時刻交班。
+type OnCallSchedule struct {
+	// ID 值班表的唯一標識。
+	ID string
+	// Name 值班表名稱。
+	Name string
+	// Team 值班表所屬的團隊，路由以團隊名稱查找當前值班者。
+	Team string
+	// Description 描述。
+	Description string
+	// Timezone IANA 時區名稱，例如 "Asia/Taipei"；為空時使用 UTC。
+	Timezone string
+	// Layers 輪值層，越後面的層優先級越高。
+	Layers []OnCallLayer
+	// Overrides 臨時替班，時間重疊時後加入的優先。
+	Overrides []OnCallOverride
+	// CreatedAt 創建時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次更新時間。
+	UpdatedAt time.Time
+}
+
+// OnCallLayer 是值班表中的一個輪值層，參與者按順序輪流值班
+type OnCallLayer struct {
+	// Name 輪值層名稱，例如 "primary"。
+	Name string `json:"name"`
+	// Start 第一班的開始時間，其在排班時區中的當地時刻即為每次交班的時刻。
+	Start time.Time `json:"start"`
+	// Rotation 班次單位，見 Rotation* 常量。
+	Rotation string `json:"rotation"`
+	// ShiftLength 每班包含的單位數，零值視為 1。
+	ShiftLength int `json:"shiftLength,omitempty"`
+	// Participants 參與輪值的用戶 ID，按值班順序排列。
+	Participants []string `json:"participants"`
+	// Restriction 限制此層只在每天的某個時段生效，例如只覆蓋上班時間；為 nil 時全天生效。
+	Restriction *OnCallRestriction `json:"restriction,omitempty"`
+}
+
+// OnCallRestriction 限制輪值層每天生效的當地時段
+type OnCallRestriction struct {
+	// StartTime 每天開始生效的當地時間，格式 "HH:MM"。
+	StartTime string `json:"startTime"`
+	// EndTime 每天結束的當地時間，格式 "HH:MM"；早於 StartTime 時表示跨越午夜。
+	EndTime string `json:"endTime"`
+	// Weekdays 生效的星期 (0 為星期日)，跨越午夜的時段以開始當天計算；為空時每天生效。
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+}
+
+// OnCallOverride 是一段時間內由指定用戶臨時替班
+type OnCallOverride struct {
+	// ID 替班記錄的唯一標識。
+	ID string `json:"id"`
+	// UserID 替班的用戶。
+	UserID string `json:"userId"`
+	// Start 開始時間。
+	Start time.Time `json:"start"`
+	// End 結束時間。
+	End time.Time `json:"end"`
+}
+
+// Location 返回值班表的時區
+func (s *OnCallSchedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Validate 檢查時區、輪值層與替班的設定
+func (s *OnCallSchedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	if strings.TrimSpace(s.Team) == "" {
+		return fmt.Errorf("schedule team is required")
+	}
+	if _, err := s.Location(); err != nil {
+		return err
+	}
+	if len(s.Layers) == 0 && len(s.Overrides) == 0 {
+		return fmt.Errorf("schedule requires at least one layer")
+	}
+	for i, layer := range s.Layers {
+		if err := layer.Validate(); err != nil {
+			return fmt.Errorf("layer %d: %w", i, err)
+		}
+	}
+	for i, override := range s.Overrides {
+		if override.UserID == "" {
+			return fmt.Errorf("override %d: user is required", i)
+		}
+		if !override.End.After(override.Start) {
+			return fmt.Errorf("override %d: end must be after start", i)
+		}
+	}
+	return nil
+}
+
+// Validate 檢查輪值層的班次與參與者
+func (l *OnCallLayer) Validate() error {
+	switch l.Rotation {
+	case RotationHourly, RotationDaily, RotationWeekly:
+	default:
+		return fmt.Errorf("invalid rotation %q, expected hourly, daily or weekly", l.Rotation)
+	}
+	if l.ShiftLength < 0 {
+		return fmt.Errorf("shift length must not be negative")
+	}
+	if l.Start.IsZero() {
+		return fmt.Errorf("rotation start is required")
+	}
+	if len(l.Participants) == 0 {
+		return fmt.Errorf("layer requires at least one participant")
+	}
+	for _, id := range l.Participants {
+		if id == "" {
+			return fmt.Errorf("participant id must not be empty")
+		}
+	}
+	if l.Restriction != nil {
+		if _, err := parseClock(l.Restriction.StartTime); err != nil {
+			return fmt.Errorf("restriction start: %w", err)
+		}
+		if _, err := parseClock(l.Restriction.EndTime); err != nil {
+			return fmt.Errorf("restriction end: %w", err)
+		}
+		if l.Restriction.StartTime == l.Restriction.EndTime {
+			return fmt.Errorf("restriction start and end must differ")
+		}
+	}
+	return nil
+}
+
+// OnCallAt 返回在 t 時值班的用戶 ID，沒有人值班時返回空字串
+func (s *OnCallSchedule) OnCallAt(t time.Time) (string, error) {
+	for i := len(s.Overrides) - 1; i >= 0; i-- {
+		o := s.Overrides[i]
+		if !t.Before(o.Start) && t.Before(o.End) {
+			return o.UserID, nil
+		}
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return "", err
+	}
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		if userID := s.Layers[i].OnCallAt(t, loc); userID != "" {
+			return userID, nil
+		}
+	}
+	return "", nil
+}
+
+// OnCallAt 返回此層在 t 時的值班用戶 ID；在第一班之前或限制時段之外返回空字串
+func (l *OnCallLayer) OnCallAt(t time.Time, loc *time.Location) string {
+	if len(l.Participants) == 0 || t.Before(l.Start) || !l.restrictionAllows(t.In(loc)) {
+		return ""
+	}
+	shift := l.shiftIndex(t, loc)
+	return l.Participants[shift%len(l.Participants)]
+}
+
+// shiftIndex 計算 t 所在的班次序號 (從 0 開始)。
+// 按天與週輪值時以當地日曆推算交班時間，因此夏令時切換的那一天班次會長或短一小時。
+func (l *OnCallLayer) shiftIndex(t time.Time, loc *time.Location) int {
+	length := l.ShiftLength
+	if length <= 0 {
+		length = 1
+	}
+	if l.Rotation == RotationHourly {
+		return int(t.Sub(l.Start) / (time.Duration(length) * time.Hour))
+	}
+
+	days := length
+	if l.Rotation == RotationWeekly {
+		days *= 7
+	}
+	start := l.Start.In(loc)
+	shiftStart := func(k int) time.Time { return start.AddDate(0, 0, k*days) }
+
+	// 先以固定長度估算，再按實際的當地交班時間修正
+	k := int(t.Sub(start) / (time.Duration(days) * 24 * time.Hour))
+	for k > 0 && shiftStart(k).After(t) {
+		k--
+	}
+	for !shiftStart(k + 1).After(t) {
+		k++
+	}
+	return k
+}
+
+// restrictionAllows 判斷當地時間 local 是否在此層每天生效的時段內
+func (l *OnCallLayer) restrictionAllows(local time.Time) bool {
+	r := l.Restriction
+	if r == nil {
+		return true
+	}
+	start, _ := parseClock(r.StartTime)
+	end, _ := parseClock(r.EndTime)
+	minute := local.Hour()*60 + local.Minute()
+
+	day := local.Weekday()
+	var inWindow bool
+	if start < end {
+		inWindow = minute >= start && minute < end
+	} else {
+		// 跨越午夜：午夜後的部分屬於前一天開始的時段
+		inWindow = minute >= start || minute < end
+		if minute < end {
+			day = (day + 6) % 7
+		}
+	}
+	if !inWindow {
+		return false
+	}
+	if len(r.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range r.Weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock 解析 "HH:MM" 並返回當天的分鐘數
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
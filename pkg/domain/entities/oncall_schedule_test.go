@@ -0,0 +1,168 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestOnCallSchedule_WeeklyRotationInTimezone(t *testing.T) {
+	loc := mustLocation(t, "Asia/Taipei")
+	schedule := &OnCallSchedule{
+		Name:     "primary",
+		Team:     "sre",
+		Timezone: "Asia/Taipei",
+		Layers: []OnCallLayer{{
+			Name:         "weekly",
+			Start:        time.Date(2026, 3, 2, 9, 0, 0, 0, loc), // 星期一 09:00 (台北) 交班
+			Rotation:     RotationWeekly,
+			Participants: []string{"alice", "bob", "carol"},
+		}},
+	}
+	if err := schedule.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 1, 12, 0, 0, 0, loc), ""}, // 第一班之前
+		{time.Date(2026, 3, 2, 9, 0, 0, 0, loc), "alice"},
+		{time.Date(2026, 3, 9, 8, 59, 0, 0, loc), "alice"},
+		{time.Date(2026, 3, 9, 9, 0, 0, 0, loc), "bob"},
+		{time.Date(2026, 3, 16, 9, 0, 0, 0, loc), "carol"},
+		{time.Date(2026, 3, 23, 9, 0, 0, 0, loc), "alice"},
+		// 同一時刻以 UTC 表示
+		{time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC), "bob"},
+	}
+	for _, tc := range cases {
+		got, err := schedule.OnCallAt(tc.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("OnCallAt(%s) = %q, want %q", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestOnCallSchedule_DailyHandoffAcrossDST(t *testing.T) {
+	loc := mustLocation(t, "America/New_York")
+	schedule := &OnCallSchedule{
+		Name:     "daily",
+		Team:     "db",
+		Timezone: "America/New_York",
+		Layers: []OnCallLayer{{
+			Start:        time.Date(2026, 3, 7, 9, 0, 0, 0, loc),
+			Rotation:     RotationDaily,
+			Participants: []string{"alice", "bob"},
+		}},
+	}
+	// 2026-03-08 開始夏令時，交班仍在當地 09:00
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 8, 8, 30, 0, 0, loc), "alice"},
+		{time.Date(2026, 3, 8, 9, 0, 0, 0, loc), "bob"},
+		{time.Date(2026, 3, 9, 8, 59, 0, 0, loc), "bob"},
+		{time.Date(2026, 3, 9, 9, 0, 0, 0, loc), "alice"},
+	}
+	for _, tc := range cases {
+		got, err := schedule.OnCallAt(tc.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("OnCallAt(%s) = %q, want %q", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestOnCallSchedule_LayersRestrictionsAndOverrides(t *testing.T) {
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC) // 星期一
+	schedule := &OnCallSchedule{
+		Name: "follow-the-sun",
+		Team: "sre",
+		Layers: []OnCallLayer{
+			{Name: "base", Start: start, Rotation: RotationWeekly, Participants: []string{"night"}},
+			{Name: "business-hours", Start: start, Rotation: RotationDaily, Participants: []string{"day"},
+				Restriction: &OnCallRestriction{StartTime: "09:00", EndTime: "18:00",
+					Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}},
+			{Name: "late", Start: start, Rotation: RotationHourly, ShiftLength: 24, Participants: []string{"late"},
+				Restriction: &OnCallRestriction{StartTime: "22:00", EndTime: "02:00", Weekdays: []time.Weekday{time.Friday}}},
+		},
+		Overrides: []OnCallOverride{
+			{UserID: "cover", Start: time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)},
+		},
+	}
+	if err := schedule.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), "day"},
+		{time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC), "night"},
+		{time.Date(2026, 3, 3, 11, 0, 0, 0, time.UTC), "cover"},
+		{time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), "night"}, // 星期六
+		{time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC), "late"},
+		{time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC), "late"}, // 星期五開始的跨午夜時段
+		{time.Date(2026, 3, 8, 1, 0, 0, 0, time.UTC), "night"},
+	}
+	for _, tc := range cases {
+		got, err := schedule.OnCallAt(tc.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("OnCallAt(%s) = %q, want %q", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestOnCallSchedule_Validate(t *testing.T) {
+	valid := OnCallLayer{Start: time.Now(), Rotation: RotationDaily, Participants: []string{"u1"}}
+	cases := map[string]*OnCallSchedule{
+		"missing team": {Name: "s", Layers: []OnCallLayer{valid}},
+		"bad timezone": {Name: "s", Team: "t", Timezone: "Mars/Olympus", Layers: []OnCallLayer{valid}},
+		"no layers":    {Name: "s", Team: "t"},
+		"bad rotation": {Name: "s", Team: "t", Layers: []OnCallLayer{{Start: time.Now(), Rotation: "monthly", Participants: []string{"u1"}}}},
+		"bad restriction": {Name: "s", Team: "t", Layers: []OnCallLayer{{Start: time.Now(), Rotation: RotationDaily,
+			Participants: []string{"u1"}, Restriction: &OnCallRestriction{StartTime: "25:00", EndTime: "08:00"}}}},
+		"override order": {Name: "s", Team: "t", Layers: []OnCallLayer{valid},
+			Overrides: []OnCallOverride{{UserID: "u2", Start: time.Now(), End: time.Now().Add(-time.Hour)}}},
+	}
+	for name, schedule := range cases {
+		if err := schedule.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestEscalationPolicy_Validate(t *testing.T) {
+	policy := &EscalationPolicy{Name: "critical", Levels: []EscalationLevel{
+		{Delay: 15 * time.Minute, Targets: []EscalationTarget{{Type: EscalationTargetTeam, ID: "sre"}}},
+	}}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	policy.Levels = append(policy.Levels, EscalationLevel{Delay: time.Minute, Targets: []EscalationTarget{{Type: "group", ID: "x"}}})
+	if err := policy.Validate(); err == nil {
+		t.Error("expected error for unknown target type")
+	}
+	if err := (&EscalationPolicy{Name: "empty"}).Validate(); err == nil {
+		t.Error("expected error without levels")
+	}
+}
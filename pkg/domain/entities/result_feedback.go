@@ -0,0 +1,68 @@
+package entities
+
+import "time"
+
+// 分析師對分析結果的反饋標籤
+const (
+	// FeedbackLabelTruePositive 結果是真實且需要處理的異常
+	FeedbackLabelTruePositive = "true_positive"
+	// FeedbackLabelFalsePositive 結果是誤報
+	FeedbackLabelFalsePositive = "false_positive"
+	// FeedbackLabelExpected 數據確實偏離常態，但屬於預期行為 (例如計劃內的維護或發布)
+	FeedbackLabelExpected = "expected"
+)
+
+// IsValidFeedbackLabel 返回標籤是否為 FeedbackLabel* 常量之一
+func IsValidFeedbackLabel(label string) bool {
+	switch label {
+	case FeedbackLabelTruePositive, FeedbackLabelFalsePositive, FeedbackLabelExpected:
+		return true
+	}
+	return false
+}
+
+// ResultFeedback 是分析師對一個分析結果的標記。
+// 職責: 記錄結果的標籤、標記者與原因，每個結果只保留最近一次標記。
+// 檢測器 ID 與結果時間從被標記的結果複製而來，使標記可以按檢測器與時間範圍查詢，
+// 作為回放新配置時的評估數據。
+type ResultFeedback struct {
+	// AnalysisResultID 被標記的分析結果。
+	AnalysisResultID string
+	// DetectorID 產生該結果的檢測器。
+	DetectorID string
+	// ResultTimestamp 被標記結果的時間戳。
+	ResultTimestamp time.Time
+	// Label 標籤，見 FeedbackLabel* 常量。
+	Label string
+	// UserID 最近一次標記的使用者 ID。
+	UserID string
+	// Reason 標記原因。
+	Reason string
+	// CreatedAt 第一次標記的時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次標記的時間。
+	UpdatedAt time.Time
+}
+
+// DetectorQuality 彙總一個檢測器已標記結果的標籤數量。
+type DetectorQuality struct {
+	DetectorID     string
+	TruePositives  int
+	FalsePositives int
+	Expected       int
+}
+
+// Labelled 返回已標記的結果數
+func (q *DetectorQuality) Labelled() int {
+	return q.TruePositives + q.FalsePositives + q.Expected
+}
+
+// Precision 返回 true_positive / (true_positive + false_positive)。
+// expected 的結果既不是誤報也不需要處理，不計入精確率；沒有可計算的標記時第二個返回值為 false。
+func (q *DetectorQuality) Precision() (float64, bool) {
+	total := q.TruePositives + q.FalsePositives
+	if total == 0 {
+		return 0, false
+	}
+	return float64(q.TruePositives) / float64(total), true
+}
@@ -0,0 +1,79 @@
+package entities
+
+import "time"
+
+// ServiceAccount 是供採集器與 CI 等機器客戶端使用的非人類帳號。
+// 職責: 持有角色並簽發 API 金鑰；金鑰以服務帳號的身份訪問 API，角色與令牌中的角色一樣經 RBAC 角色映射授權。
+type ServiceAccount struct {
+	// ID 是服務帳號的唯一標識符，也是 API 金鑰主體的用戶 ID。
+	ID string
+	// Name 服務帳號名稱，唯一。
+	Name string
+	// Description 用途說明。
+	Description string
+	// Roles 服務帳號的角色，等同令牌 roles 聲明中的取值。
+	Roles []string
+	// Disabled 停用後所有金鑰都無法使用，重新啟用後恢復。
+	Disabled bool
+	// CreatedBy 建立者。
+	CreatedBy string
+	// CreatedAt 與 UpdatedAt 記錄帳號的生命週期。
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// APIKey 是服務帳號的 API 金鑰。
+// 金鑰明文只在簽發時返回一次，只保存前綴與雜湊；前綴公開，用於識別與查找金鑰。
+type APIKey struct {
+	// ID 是金鑰的唯一標識符。
+	ID string
+	// ServiceAccountID 所屬的服務帳號。
+	ServiceAccountID string
+	// Name 金鑰名稱，例如使用它的 CI 工作。
+	Name string
+	// Prefix 金鑰明文的識別前綴，唯一。
+	Prefix string
+	// Hash 金鑰秘密部分的雜湊，帶演算法標記。
+	Hash string
+	// Roles 金鑰的角色範圍，必須是服務帳號角色的子集；為空時使用服務帳號的全部角色。
+	Roles []string
+	// ExpiresAt 過期時間，零值表示不過期。
+	ExpiresAt time.Time
+	// LastUsedAt 最近一次成功認證的時間。
+	LastUsedAt time.Time
+	// RevokedAt 撤銷時間，未撤銷時為零值。
+	RevokedAt time.Time
+	// RevokedBy 撤銷者。
+	RevokedBy string
+	// CreatedBy 簽發者。
+	CreatedBy string
+	// CreatedAt 簽發時間。
+	CreatedAt time.Time
+}
+
+// Revoked 返回金鑰是否已撤銷
+func (k *APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Expired 返回金鑰在 now 是否已過期
+func (k *APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// EffectiveRoles 返回金鑰生效的角色：金鑰的角色範圍與服務帳號目前角色的交集，未限定範圍時為帳號的全部角色
+func (k *APIKey) EffectiveRoles(account *ServiceAccount) []string {
+	if len(k.Roles) == 0 {
+		return append([]string(nil), account.Roles...)
+	}
+	var roles []string
+	for _, role := range k.Roles {
+		for _, granted := range account.Roles {
+			if role == granted {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	return roles
+}
@@ -0,0 +1,49 @@
+package entities
+
+import "time"
+
+// 靜默狀態
+const (
+	// SilenceStatusPending 尚未開始，或週期性靜默不在維護窗口內
+	SilenceStatusPending = "pending"
+	// SilenceStatusActive 正在抑制匹配的告警通知
+	SilenceStatusActive = "active"
+	// SilenceStatusExpired 已過結束時間或被手動結束
+	SilenceStatusExpired = "expired"
+)
+
+// Silence 是在一段時間內抑制匹配告警通知的靜默規則。
+// 職責: 以標籤匹配條件選出告警 (例如 detector_id 或 host)，在 [StartsAt, EndsAt) 內抑制其 firing 通知。
+// 設置 Schedule 時為週期性維護窗口：每次 cron 觸發後的 Duration 內生效，StartsAt/EndsAt 限定規則的有效期。
+type Silence struct {
+	// ID 靜默的唯一標識。
+	ID string
+	// Matchers 告警標籤需同時滿足的匹配條件，不能為空。
+	Matchers []LabelMatcher
+	// StartsAt 開始時間。
+	StartsAt time.Time
+	// EndsAt 結束時間，週期性靜默可為零值表示長期有效。
+	EndsAt time.Time
+	// Schedule 週期性維護窗口的 cron 表達式，可加 "CRON_TZ=Asia/Taipei " 前綴指定時區；為空表示一次性靜默。
+	Schedule string
+	// Duration 每個維護窗口的長度，僅在設置 Schedule 時使用。
+	Duration time.Duration
+	// CreatedBy 創建者。
+	CreatedBy string
+	// Comment 靜默原因。
+	Comment string
+	// CreatedAt 創建時間。
+	CreatedAt time.Time
+	// UpdatedAt 最近一次更新時間。
+	UpdatedAt time.Time
+}
+
+// IsRecurring 返回是否為週期性維護窗口
+func (s *Silence) IsRecurring() bool {
+	return s.Schedule != ""
+}
+
+// IsExpired 返回靜默在 now 時是否已結束
+func (s *Silence) IsExpired(now time.Time) bool {
+	return !s.EndsAt.IsZero() && !now.Before(s.EndsAt)
+}
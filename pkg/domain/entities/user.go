@@ -18,8 +18,12 @@ type User struct {
 	Name         string
 	Email        string
 	PasswordHash string `json:"-"` // 儲存密碼的散列值，不在JSON序列化中暴露
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// Roles 是本地認證用戶的角色，簽發的令牌中以 roles 聲明攜帶，經 RBAC 策略映射為權限
+	Roles []string
+	// EmailVerifiedAt 是用戶完成郵件驗證的時間，為零表示尚未驗證
+	EmailVerifiedAt time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 // NewUser 是一個工廠函數，用於創建新的用戶實體。
@@ -59,3 +63,8 @@ func (u *User) CheckPassword(password string) bool {
 	}
 	return isValid
 }
+
+// EmailVerified 返回用戶是否已驗證郵箱
+func (u *User) EmailVerified() bool {
+	return !u.EmailVerifiedAt.IsZero()
+}
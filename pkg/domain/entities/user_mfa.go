@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+// UserMFA 是本地用戶的 TOTP 多因素認證設定。
+// 職責: 保存加密後的 TOTP 密鑰與登記狀態；密鑰以 SecretsProvider 中的加密密鑰加密，不保存明文。
+type UserMFA struct {
+	// UserID 所屬的用戶，每個用戶最多一個 TOTP 設定。
+	UserID string
+	// EncryptedSecret 加密後的 TOTP 密鑰，帶版本前綴。
+	EncryptedSecret string
+	// ConfirmedAt 用戶以第一個驗證碼確認登記的時間，為零表示登記尚未完成，登錄時不要求驗證碼。
+	ConfirmedAt time.Time
+	// LastUsedStep 最近一次接受的驗證碼的時間步，同一時間步及之前的驗證碼不能再次使用。
+	LastUsedStep int64
+	// CreatedAt 與 UpdatedAt 記錄設定的生命週期。
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Enabled 返回用戶是否已完成 TOTP 登記
+func (m *UserMFA) Enabled() bool {
+	return !m.ConfirmedAt.IsZero()
+}
+
+// MFARecoveryCode 是無法使用驗證器時登錄用的一次性恢復碼。
+// 明文只在生成時顯示一次，只保存雜湊。
+type MFARecoveryCode struct {
+	// ID 是恢復碼的唯一標識符。
+	ID string
+	// UserID 所屬的用戶。
+	UserID string
+	// CodeHash 恢復碼的雜湊。
+	CodeHash string
+	// UsedAt 使用時間，未使用時為零值。
+	UsedAt time.Time
+	// CreatedAt 生成時間。
+	CreatedAt time.Time
+}
+
+// Used 返回恢復碼是否已使用
+func (c *MFARecoveryCode) Used() bool {
+	return !c.UsedAt.IsZero()
+}
@@ -0,0 +1,57 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// AlertRepository 定義了告警狀態的持久化介面。
+// 職責: 以指紋為主鍵保存告警的當前狀態，使告警管理器重啟後不會重新觸發已通知的告警。
+// AI_PLUGIN_TYPE: "alert_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewAlertRepository"
+// @See: internal/repositories/mysql/alert_repository.go
+type AlertRepository interface {
+	// Save 創建或更新告警
+	Save(ctx context.Context, alert *entities.Alert) error
+	// GetByFingerprint 根據指紋獲取告警，不存在時返回 nil
+	GetByFingerprint(ctx context.Context, fingerprint string) (*entities.Alert, error)
+	// ListByState 列出處於指定狀態的告警
+	ListByState(ctx context.Context, states ...string) ([]*entities.Alert, error)
+}
+
+// AlertGroupRepository 定義了告警分組通知狀態的持久化介面。
+// AI_PLUGIN_TYPE: "alert_group_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewAlertGroupRepository"
+// @See: internal/repositories/mysql/alert_repository.go
+type AlertGroupRepository interface {
+	// Save 創建或更新分組
+	Save(ctx context.Context, group *entities.AlertGroup) error
+	// Get 根據分組標識獲取分組，不存在時返回 nil
+	Get(ctx context.Context, key string) (*entities.AlertGroup, error)
+	// ListDue 列出 NextFlushAt 不晚於 now 的分組
+	ListDue(ctx context.Context, now time.Time) ([]*entities.AlertGroup, error)
+	// Delete 刪除沒有成員的分組
+	Delete(ctx context.Context, key string) error
+}
+
+// SilenceRepository 定義了靜默規則的持久化介面。
+// AI_PLUGIN_TYPE: "silence_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewSilenceRepository"
+// @See: internal/repositories/mysql/silence_repository.go
+type SilenceRepository interface {
+	// Save 創建或更新靜默
+	Save(ctx context.Context, silence *entities.Silence) error
+	// GetByID 根據 ID 獲取靜默，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.Silence, error)
+	// List 列出所有靜默，按開始時間倒序
+	List(ctx context.Context) ([]*entities.Silence, error)
+	// ListUnexpired 列出在 now 時尚未結束的靜默
+	ListUnexpired(ctx context.Context, now time.Time) ([]*entities.Silence, error)
+	// DeleteExpiredBefore 刪除在 before 之前已結束的靜默，返回刪除數量
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
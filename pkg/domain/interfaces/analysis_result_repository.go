@@ -8,6 +8,8 @@ import (
 
 // AnalysisResultRepository 定義了分析結果數據持久化的介面。
 // 職責: 封裝分析結果實體的 CRUD 操作，隔離數據存儲細節。
+// 告警管理器保存收到的異常結果，使告警與事件單引用的結果可以被查詢與標記。
+// 偵測事件與結果的關聯見 DetectionResult.AnalysisResultID。
 // AI_PLUGIN_TYPE: "analysis_result_repository"
 // AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
 // AI_IMPL_CONSTRUCTOR: "NewAnalysisResultRepository"
@@ -15,14 +17,12 @@ import (
 type AnalysisResultRepository interface {
 	// Create 創建新分析結果
 	Create(ctx context.Context, result *entities.AnalysisResult) error
-	// GetByID 根據 ID 獲取分析結果
+	// GetByID 根據 ID 獲取分析結果，不存在時返回 nil
 	GetByID(ctx context.Context, id valueobjects.IDVO) (*entities.AnalysisResult, error)
 	// Update 更新分析結果
 	Update(ctx context.Context, result *entities.AnalysisResult) error
 	// Delete 刪除分析結果
 	Delete(ctx context.Context, id valueobjects.IDVO) error
-	// List 列出分析結果
+	// List 列出分析結果，按結果時間倒序
 	List(ctx context.Context, offset, limit int) ([]*entities.AnalysisResult, error)
-	// GetByDetectionID 根據檢測 ID 獲取分析結果
-	GetByDetectionID(ctx context.Context, detectionID valueobjects.IDVO) ([]*entities.AnalysisResult, error)
 }
@@ -0,0 +1,34 @@
+package interfaces
+
+import (
+	"context"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// BackfillJobRepository 定義了回放任務的持久化介面。
+// AI_PLUGIN_TYPE: "backfill_job_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewBackfillJobRepository"
+// @See: internal/repositories/mysql/backfill_repository.go
+type BackfillJobRepository interface {
+	// Create 保存新任務
+	Create(ctx context.Context, job *entities.BackfillJob) error
+	// Update 更新任務的狀態、進度與報告
+	Update(ctx context.Context, job *entities.BackfillJob) error
+	// GetByID 獲取任務，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.BackfillJob, error)
+}
+
+// SimulationResultRepository 定義了回放結果的持久化介面。
+// 職責: 將回放結果保存在與線上結果隔離的模擬命名空間，告警流程不會讀取這些結果。
+// AI_PLUGIN_TYPE: "simulation_result_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewSimulationResultRepository"
+// @See: internal/repositories/mysql/backfill_repository.go
+type SimulationResultRepository interface {
+	// SaveBatch 批量保存回放結果
+	SaveBatch(ctx context.Context, results []*entities.SimulationResult) error
+	// ListByJob 按時間順序列出任務的結果，variant 為空時返回所有配置的結果
+	ListByJob(ctx context.Context, jobID, variant string, offset, limit int) ([]*entities.SimulationResult, error)
+}
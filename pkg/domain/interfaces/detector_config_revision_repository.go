@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// DetectorConfigRevisionRepository 定義了檢測器配置版本的持久化介面。
+// 職責: 保存不可變的配置版本，並標記每個檢測器目前生效的版本。
+// AI_PLUGIN_TYPE: "detector_config_revision_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewDetectorConfigRevisionRepository"
+// @See: internal/repositories/mysql/detector_config_revision_repository.go
+type DetectorConfigRevisionRepository interface {
+	// Create 保存新版本，同一檢測器的版本號重複時返回錯誤
+	Create(ctx context.Context, revision *entities.DetectorConfigRevision) error
+	// Get 獲取指定版本，不存在時返回 nil
+	Get(ctx context.Context, detectorID string, revision int) (*entities.DetectorConfigRevision, error)
+	// GetActive 獲取目前生效的版本，沒有時返回 nil
+	GetActive(ctx context.Context, detectorID string) (*entities.DetectorConfigRevision, error)
+	// LatestRevision 返回檢測器最新的版本號，沒有任何版本時返回 0
+	LatestRevision(ctx context.Context, detectorID string) (int, error)
+	// List 按版本號倒序列出檢測器的所有版本
+	List(ctx context.Context, detectorID string) ([]*entities.DetectorConfigRevision, error)
+	// SetActive 將指定版本標記為生效，並取消其他版本的生效標記
+	SetActive(ctx context.Context, detectorID string, revision int) error
+}
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// DetectorScheduleRepository 定義了檢測器排程數據持久化的介面。
+// 職責: 保存排程配置與執行狀態，並以比較後更新 (compare-and-set) 的方式認領到期的排程，
+// 讓多個平台實例共享同一資料庫時不會重複執行同一個排程。
+// AI_PLUGIN_TYPE: "detector_schedule_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewDetectorScheduleRepository"
+// @See: internal/repositories/mysql/detector_schedule_repository.go
+type DetectorScheduleRepository interface {
+	// Save 創建或更新排程配置，不會覆蓋執行狀態
+	Save(ctx context.Context, schedule *entities.DetectorSchedule) error
+	// GetByDetectorID 根據檢測器 ID 獲取排程，不存在時返回 nil
+	GetByDetectorID(ctx context.Context, detectorID string) (*entities.DetectorSchedule, error)
+	// ListEnabled 列出所有已啟用的排程
+	ListEnabled(ctx context.Context) ([]*entities.DetectorSchedule, error)
+	// ClaimNextRun 僅當目前的 next_run_at 等於 expected 時將其更新為 next，返回是否認領成功
+	ClaimNextRun(ctx context.Context, detectorID string, expected, next time.Time) (bool, error)
+	// RecordRun 記錄一次執行的結果
+	RecordRun(ctx context.Context, detectorID string, scheduledAt time.Time, status, errMsg string) error
+	// Delete 刪除排程
+	Delete(ctx context.Context, detectorID string) error
+}
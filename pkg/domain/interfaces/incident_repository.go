@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// IncidentRepository 定義了事件單的持久化介面。
+// 職責: 保存事件單及其時間線，並按成員告警或告警分組查找事件單，使同一異常的後續通知歸入同一事件單。
+// AI_PLUGIN_TYPE: "incident_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewIncidentRepository"
+// @See: internal/repositories/mysql/incident_repository.go
+type IncidentRepository interface {
+	// Save 創建或更新事件單，成員告警只增不減
+	Save(ctx context.Context, incident *entities.Incident) error
+	// GetByID 根據 ID 獲取事件單，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.Incident, error)
+	// List 列出事件單，按開立時間倒序；state 為空時列出所有狀態，limit 不大於 0 時不限制數量
+	List(ctx context.Context, state string, limit int) ([]*entities.Incident, error)
+	// GetLatestByAlert 返回包含該告警且最近開立的事件單，不存在時返回 nil
+	GetLatestByAlert(ctx context.Context, fingerprint string) (*entities.Incident, error)
+	// GetUnresolvedByGroup 返回由該告警分組自動開立且尚未解決的事件單，不存在時返回 nil
+	GetUnresolvedByGroup(ctx context.Context, groupKey string) (*entities.Incident, error)
+}
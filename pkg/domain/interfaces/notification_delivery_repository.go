@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// NotificationDeliveryRepository 定義了通知投遞記錄的持久化介面。
+// 職責: 保存每一次通知投遞的內容與結果，作為出站通知的審計記錄與重試佇列。
+// AI_PLUGIN_TYPE: "notification_delivery_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewNotificationDeliveryRepository"
+// @See: internal/repositories/mysql/notification_delivery_repository.go
+type NotificationDeliveryRepository interface {
+	// Save 創建或更新投遞記錄
+	Save(ctx context.Context, delivery *entities.NotificationDelivery) error
+	// GetByID 獲取投遞記錄，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.NotificationDelivery, error)
+	// List 按創建時間倒序列出符合篩選條件的投遞記錄
+	List(ctx context.Context, filter NotificationDeliveryFilter) ([]*entities.NotificationDelivery, error)
+	// ListDue 按重試時間順序列出狀態為 pending 且 NextAttemptAt 不晚於 now 的投遞，最多 limit 條
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*entities.NotificationDelivery, error)
+}
+
+// NotificationDeliveryFilter 是列出投遞記錄時的篩選條件，空值表示不篩選
+type NotificationDeliveryFilter struct {
+	Status      string
+	Receiver    string
+	Fingerprint string
+	Limit       int // 不大於 0 時不限制數量
+}
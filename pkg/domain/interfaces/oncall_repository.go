@@ -0,0 +1,44 @@
+package interfaces
+
+import (
+	"context"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// OnCallScheduleRepository 定義了值班表的持久化介面。
+// 職責: 保存值班表及其輪值層與替班，並按團隊查找值班表供告警路由解析當前值班者。
+// AI_PLUGIN_TYPE: "oncall_schedule_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewOnCallScheduleRepository"
+// @See: internal/repositories/mysql/oncall_repository.go
+type OnCallScheduleRepository interface {
+	// Save 創建或更新值班表
+	Save(ctx context.Context, schedule *entities.OnCallSchedule) error
+	// GetByID 根據 ID 獲取值班表，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.OnCallSchedule, error)
+	// List 列出所有值班表
+	List(ctx context.Context) ([]*entities.OnCallSchedule, error)
+	// ListByTeam 列出團隊的值班表
+	ListByTeam(ctx context.Context, team string) ([]*entities.OnCallSchedule, error)
+	// Delete 刪除值班表
+	Delete(ctx context.Context, id string) error
+}
+
+// EscalationPolicyRepository 定義了升級策略的持久化介面。
+// AI_PLUGIN_TYPE: "escalation_policy_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewEscalationPolicyRepository"
+// @See: internal/repositories/mysql/oncall_repository.go
+type EscalationPolicyRepository interface {
+	// Save 創建或更新升級策略
+	Save(ctx context.Context, policy *entities.EscalationPolicy) error
+	// GetByID 根據 ID 獲取升級策略，不存在時返回 nil
+	GetByID(ctx context.Context, id string) (*entities.EscalationPolicy, error)
+	// GetByName 根據名稱獲取升級策略，不存在時返回 nil
+	GetByName(ctx context.Context, name string) (*entities.EscalationPolicy, error)
+	// List 列出所有升級策略
+	List(ctx context.Context) ([]*entities.EscalationPolicy, error)
+	// Delete 刪除升級策略
+	Delete(ctx context.Context, id string) error
+}
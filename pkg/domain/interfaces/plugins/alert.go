@@ -16,3 +16,21 @@ type AlertPlugin interface {
 	// TriggerAlert 根據分析結果和配置觸發一個告警。
 	TriggerAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error
 }
+
+// AlertResolverPlugin 是可以接收恢復信號的 AlertPlugin。
+// 職責: 管線的 alert 階段對非異常結果調用 ResolveAlert，使有狀態的告警插件 (例如告警管理器) 能結束對應的告警。
+type AlertResolverPlugin interface {
+	AlertPlugin
+	// ResolveAlert 通知插件該分析結果對應的對象已恢復正常。
+	ResolveAlert(ctx context.Context, result *entities.AnalysisResult, alertConfig map[string]interface{}) error
+}
+
+// AlertConfigKeyNotification 是告警管理器調用 TriggerAlert 時在 alertConfig 中附帶
+// *entities.AlertNotification 的鍵，插件可據此取得告警指紋、狀態與分組信息。
+const AlertConfigKeyNotification = "_alert_notification"
+
+// AlertNotificationFromConfig 取出告警管理器附帶的通知信息，直接由管線調用時返回 nil
+func AlertNotificationFromConfig(alertConfig map[string]interface{}) *entities.AlertNotification {
+	notification, _ := alertConfig[AlertConfigKeyNotification].(*entities.AlertNotification)
+	return notification
+}
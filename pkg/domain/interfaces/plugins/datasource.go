@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordTimestampField 是數據源記錄中保存記錄時間的欄位名
+const RecordTimestampField = "timestamp"
+
+// DataSourcePlugin 定義了按時間窗口讀取數據的介面。
+// 職責: 為排程與回放等功能提供指定時間範圍內的數據記錄，記錄按時間先後排序，
+// 並在 RecordTimestampField 欄位提供每條記錄的時間。
+// AI_PLUGIN_TYPE: "datasource_plugin"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/plugins/datasources"
+// AI_IMPL_CONSTRUCTOR: "NewSQLDataSourcePlugin"
+// AI 擴展點: AI 可生成 `PrometheusDataSourcePlugin`、`ElasticsearchDataSourcePlugin` 等具體實現。
+type DataSourcePlugin interface {
+	Plugin
+	// FetchWindow 返回 (start, end] 範圍內的數據記錄，query 為數據源特定的查詢參數
+	FetchWindow(ctx context.Context, query map[string]interface{}, start, end time.Time) ([]map[string]interface{}, error)
+}
+
+// RecordTimestamp 解析記錄的 RecordTimestampField 欄位，支持 time.Time、RFC3339 與 "2006-01-02 15:04:05" 格式的字符串
+func RecordTimestamp(record map[string]interface{}) (time.Time, error) {
+	raw, ok := record[RecordTimestampField]
+	if !ok || raw == nil {
+		return time.Time{}, fmt.Errorf("record has no %s field", RecordTimestampField)
+	}
+
+	switch v := raw.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid %s value: %q", RecordTimestampField, v)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported %s type: %T", RecordTimestampField, raw)
+	}
+}
@@ -14,3 +14,13 @@ type DetectorPlugin interface {
 	Plugin
 	Execute(ctx context.Context, data map[string]interface{}, detectorConfig map[string]interface{}) (*entities.AnalysisResult, error)
 }
+
+// DetectorFactory 根據插件名稱與配置創建獨立的檢測器實例。
+// 職責: 讓回放等需要隔離狀態的場景取得全新的、已初始化並啟動的檢測器，
+// 使有狀態檢測器 (例如連續超限計數) 不會與線上實例共享狀態。調用方負責在使用完畢後調用 Stop。
+// AI_PLUGIN_TYPE: "detector_factory"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/plugins/detectors"
+// AI_IMPL_CONSTRUCTOR: "NewDetectorFactory"
+type DetectorFactory interface {
+	NewDetector(ctx context.Context, pluginName string, cfg map[string]interface{}) (DetectorPlugin, error)
+}
@@ -0,0 +1,15 @@
+package plugins
+
+import "context"
+
+// TransformPlugin 定義了數據轉換插件的介面。
+// 職責: 在偵測之前對一批記錄進行解析、過濾或聚合，輸出新的記錄批次，作為檢測管線的 transform 階段。
+// AI_PLUGIN_TYPE: "transform_plugin"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/plugins/transforms"
+// AI_IMPL_CONSTRUCTOR: "NewAggregateTransformPlugin"
+// AI 擴展點: AI 可生成 `RegexParseTransformPlugin`、`FilterTransformPlugin` 等具體實現。
+type TransformPlugin interface {
+	Plugin
+	// Transform 轉換一批記錄，不得修改傳入的記錄，transformConfig 為管線階段的配置
+	Transform(ctx context.Context, records []map[string]interface{}, transformConfig map[string]interface{}) ([]map[string]interface{}, error)
+}
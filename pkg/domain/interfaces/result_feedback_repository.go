@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// ResultFeedbackRepository 定義了分析結果反饋標籤的持久化介面。
+// 職責: 保存分析師對分析結果的標記，並按檢測器彙總標籤或按結果時間範圍列出，用於品質指標與回放評估。
+// AI_PLUGIN_TYPE: "result_feedback_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewResultFeedbackRepository"
+// @See: internal/repositories/mysql/result_feedback_repository.go
+type ResultFeedbackRepository interface {
+	// Save 創建或更新結果的標記，每個分析結果只保留一條
+	Save(ctx context.Context, feedback *entities.ResultFeedback) error
+	// GetByResult 獲取分析結果的標記，不存在時返回 nil
+	GetByResult(ctx context.Context, analysisResultID string) (*entities.ResultFeedback, error)
+	// ListByDetector 按結果時間順序列出檢測器在 (start, end] 範圍內被標記的結果
+	ListByDetector(ctx context.Context, detectorID string, start, end time.Time) ([]*entities.ResultFeedback, error)
+	// CountByDetector 返回檢測器各標籤的標記數量
+	CountByDetector(ctx context.Context, detectorID string) (map[string]int, error)
+}
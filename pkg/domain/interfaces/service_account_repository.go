@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// ServiceAccountRepository 定義了服務帳號與 API 金鑰的持久化介面。
+// 職責: 保存服務帳號及其金鑰 (只有前綴與雜湊)，按前綴查找金鑰以驗證 API 請求，並記錄金鑰最近的使用時間。
+// AI_PLUGIN_TYPE: "service_account_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewServiceAccountRepository"
+// @See: internal/repositories/mysql/service_account_repository.go
+type ServiceAccountRepository interface {
+	// SaveAccount 創建或更新服務帳號
+	SaveAccount(ctx context.Context, account *entities.ServiceAccount) error
+	// GetAccount 根據 ID 獲取服務帳號，不存在時返回 nil
+	GetAccount(ctx context.Context, id string) (*entities.ServiceAccount, error)
+	// GetAccountByName 根據名稱獲取服務帳號，不存在時返回 nil
+	GetAccountByName(ctx context.Context, name string) (*entities.ServiceAccount, error)
+	// ListAccounts 按名稱列出所有服務帳號
+	ListAccounts(ctx context.Context) ([]*entities.ServiceAccount, error)
+
+	// SaveKey 創建或更新 API 金鑰
+	SaveKey(ctx context.Context, key *entities.APIKey) error
+	// GetKey 根據 ID 獲取 API 金鑰，不存在時返回 nil
+	GetKey(ctx context.Context, id string) (*entities.APIKey, error)
+	// GetKeyByPrefix 根據前綴獲取 API 金鑰，不存在時返回 nil
+	GetKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+	// ListKeys 按簽發時間倒序列出服務帳號的金鑰，包括已撤銷與已過期的金鑰
+	ListKeys(ctx context.Context, accountID string) ([]*entities.APIKey, error)
+	// TouchKey 更新金鑰的最近使用時間
+	TouchKey(ctx context.Context, id string, usedAt time.Time) error
+}
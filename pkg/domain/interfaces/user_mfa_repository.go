@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"detectviz-platform/pkg/domain/entities"
+)
+
+// UserMFARepository 定義了本地用戶多因素認證設定的持久化介面。
+// 職責: 保存加密的 TOTP 密鑰與一次性恢復碼的雜湊，並以條件更新保證驗證碼與恢復碼只能使用一次。
+// AI_PLUGIN_TYPE: "user_mfa_repository"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/repositories/mysql"
+// AI_IMPL_CONSTRUCTOR: "NewUserMFARepository"
+// @See: internal/repositories/mysql/user_mfa_repository.go
+type UserMFARepository interface {
+	// GetMFA 獲取用戶的 TOTP 設定，不存在時返回 nil
+	GetMFA(ctx context.Context, userID string) (*entities.UserMFA, error)
+	// SaveMFA 創建或更新用戶的 TOTP 設定
+	SaveMFA(ctx context.Context, mfa *entities.UserMFA) error
+	// DeleteMFA 刪除用戶的 TOTP 設定與全部恢復碼
+	DeleteMFA(ctx context.Context, userID string) error
+	// AdvanceStep 在 step 大於已記錄的時間步時更新並返回 true，否則返回 false，用於拒絕重放的驗證碼
+	AdvanceStep(ctx context.Context, userID string, step int64) (bool, error)
+
+	// ReplaceRecoveryCodes 刪除用戶原有的恢復碼並保存新的恢復碼
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error
+	// ListRecoveryCodes 列出用戶的恢復碼，包括已使用的恢復碼
+	ListRecoveryCodes(ctx context.Context, userID string) ([]*entities.MFARecoveryCode, error)
+	// UseRecoveryCode 在恢復碼未使用時標記為已使用並返回 true，已使用時返回 false
+	UseRecoveryCode(ctx context.Context, id string, usedAt time.Time) (bool, error)
+}
@@ -20,12 +20,26 @@ type EventBusProvider interface {
 // AuditLogProvider 定義了審計記錄的儲存與查詢功能。
 // 職責: 記錄平台中的關鍵操作（誰、在何時、做了什麼），以滿足安全和合規需求。
 // AI_PLUGIN_TYPE: "audit_log_provider"
-// AI_IMPL_PACKAGE: "detectviz-platform/internal/platform/providers/audit_log/db_audit_log"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/infrastructure/platform/audit"
 // AI_IMPL_CONSTRUCTOR: "NewDBAuditLogProvider"
-// @See: internal/platform/providers/audit_log/db_audit_log.go
+// @See: internal/infrastructure/platform/audit/db_audit_log_provider.go
 type AuditLogProvider interface {
 	// LogAction 記錄一個審計日誌條目。
 	LogAction(ctx context.Context, userID, action, resource string, metadata map[string]any) error
 	// GetName 返回審計日誌提供者的名稱。
 	GetName() string
 }
+
+// OutboxProvider 定義了事務性發件箱 (Transactional Outbox) 的介面。
+// 職責: 在業務事務中寫入待發布事件，事務提交後再由中繼投遞到 EventBusProvider，
+// 保證資料變更與事件發布的一致性，避免「已提交但未發布」或「已發布但已回滾」。
+// AI_PLUGIN_TYPE: "outbox_provider"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/infrastructure/platform/outbox"
+// AI_IMPL_CONSTRUCTOR: "NewSQLOutboxProvider"
+// @See: internal/infrastructure/platform/outbox/sql_outbox_provider.go
+type OutboxProvider interface {
+	// Enqueue 將事件寫入發件箱；ctx 中存在事務時隨事務一同提交或回滾。
+	Enqueue(ctx context.Context, topic string, payload interface{}) error
+	// GetName 返回發件箱提供者的名稱。
+	GetName() string
+}
@@ -8,18 +8,16 @@ import (
 // Note: MigrationRunner moved to database.go
 
 // TransactionManager 定義了事務管理服務的介面。
-// 職責: 提供數據庫事務的開始、提交和回滾功能，以保證操作的原子性。
+// 職責: 以工作單元 (Unit of Work) 的方式保證跨多個 Repository 操作的原子性。
+// 事務透過 context 傳遞，Repository 會自動使用 ctx 中的事務；巢狀調用以保存點實現。
 // AI_PLUGIN_TYPE: "transaction_manager_provider"
-// AI_IMPL_PACKAGE: "detectviz-platform/internal/infrastructure/database/gorm_transaction_manager"
-// AI_IMPL_CONSTRUCTOR: "NewGORMTransactionManager"
-// @See: internal/infrastructure/database/gorm_transaction_manager.go
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/infrastructure/database"
+// AI_IMPL_CONSTRUCTOR: "NewSQLTransactionManager"
+// @See: internal/infrastructure/database/sql_transaction_manager.go
 type TransactionManager interface {
-	// BeginTx 開始一個新的事務並返回一個事務上下文，例如 *gorm.DB 或 *sql.Tx。
-	BeginTx(ctx context.Context, opts *interface{}) (interface{}, error)
-	// CommitTx 提交一個事務。
-	CommitTx(tx interface{}) error
-	// RollbackTx 回滾一個事務。
-	RollbackTx(tx interface{}) error
+	// RunInTx 在事務中執行 fn，fn 返回錯誤或 panic 時回滾，否則提交。
+	// fn 收到的 ctx 攜帶事務；若傳入的 ctx 已在事務中，則建立保存點，失敗時只回滾到該保存點。
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
 	// GetName 返回事務管理器的名稱。
 	GetName() string
 }
@@ -37,6 +35,9 @@ type CacheProvider interface {
 	Get(ctx context.Context, key string) (interface{}, error)
 	// Delete 從緩存中刪除一個值。
 	Delete(ctx context.Context, key string) error
+	// Increment 原子地將整數值加上 delta 並返回新值，鍵不存在或已過期時從 0 開始，並重新設置過期時間。
+	// 以 Increment 寫入的鍵只應以 Increment、Get 與 Delete 訪問。
+	Increment(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)
 	// GetName 返回緩存提供者的名稱。
 	GetName() string
 }
@@ -44,9 +45,9 @@ type CacheProvider interface {
 // SecretsProvider 定義了秘密管理服務的介面。
 // 職責: 安全地讀取和管理敏感資訊 (如 API 金鑰、數據庫憑證)，避免硬編碼在代碼中。
 // AI_PLUGIN_TYPE: "secrets_provider"
-// AI_IMPL_PACKAGE: "detectviz-platform/internal/platform/providers/secrets/env_secrets"
+// AI_IMPL_PACKAGE: "detectviz-platform/internal/infrastructure/platform/secrets"
 // AI_IMPL_CONSTRUCTOR: "NewEnvSecretsProvider"
-// @See: internal/platform/providers/secrets/env_secrets.go
+// @See: internal/infrastructure/platform/secrets/env_secrets_provider.go
 type SecretsProvider interface {
 	// GetSecret 根據鍵名安全地檢索一個秘密值。
 	GetSecret(ctx context.Context, key string) (string, error)